@@ -8,132 +8,1960 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"caligra/internal/analyse"
+	"caligra/internal/audit"
+	"caligra/internal/batch"
+	"caligra/internal/census"
+	"caligra/internal/compliance"
+	"caligra/internal/config"
 	"caligra/internal/daemon"
+	"caligra/internal/filter"
+	"caligra/internal/find"
+	"caligra/internal/formats"
+	"caligra/internal/i18n"
+	"caligra/internal/index"
+	"caligra/internal/remote"
+	"caligra/internal/schema"
+	"caligra/internal/selftest"
+	"caligra/internal/serve"
+	"caligra/internal/snapshot"
 	"caligra/internal/util"
 	"caligra/internal/wipe"
 )
 
 func main() {
+	// Windows rarely sets HOME, which config, logs and keys are found by
+	if os.Getenv("HOME") == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			os.Setenv("HOME", home)
+		}
+	}
+
+	// --lang, --plain, --clear, --no-ext-fallback and --max-file-size may
+	// appear anywhere; commands never see them
+	lang := i18n.FromEnvironment()
+	extFallback := config.ExtensionFallbackDefault()
+	maxFileSize := config.MaxFileSizeDefault()
+	args := os.Args[:1]
+	for i := 1; i < len(os.Args); i++ {
+		switch {
+		case os.Args[i] == "--lang" && i+1 < len(os.Args):
+			i++
+			lang = os.Args[i]
+		case strings.HasPrefix(os.Args[i], "--lang="):
+			lang = strings.TrimPrefix(os.Args[i], "--lang=")
+		case os.Args[i] == "--plain":
+			util.SetPlain(true)
+		case os.Args[i] == "--clear":
+			util.SetClearScreen(true)
+		case os.Args[i] == "--no-ext-fallback":
+			extFallback = false
+		case os.Args[i] == "--max-file-size" && i+1 < len(os.Args):
+			i++
+			mb, err := strconv.Atoi(os.Args[i])
+			if err != nil || mb < 0 {
+				fmt.Println(util.BRH.Render("[X] " + i18n.T("--max-file-size takes a size in MB (0 for no limit), not %q", os.Args[i])))
+				os.Exit(1)
+			}
+			maxFileSize = int64(mb) << 20
+		default:
+			args = append(args, os.Args[i])
+		}
+	}
+	os.Args = args
+	analyse.SetExtensionFallback(extFallback)
+	util.SetMaxFileSize(maxFileSize)
+	util.SetCopyHash(config.CopyHashDefault())
+	if err := i18n.SetLanguage(lang); err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+
+	util.Wiper()
+
+	// find's and index query's output is a list of paths for other programs,
+	// and JSON output is for programs too
+	pathsOnly := len(os.Args) > 1 && (os.Args[1] == "find" ||
+		os.Args[1] == "index" && len(os.Args) > 2 && os.Args[2] == "query")
+	machine := len(os.Args) > 1 && (os.Args[1] == "schema" || slices.Contains(os.Args[2:], "--json"))
+	if !pathsOnly && !machine {
+		printHeader()
+	}
+
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	command := os.Args[1]
+
+	switch command {
+	case "analyse", "analyze":
+		handleAnalyseCommand(os.Args[2:])
+	case "wipe":
+		handleWipeCommand(os.Args[2:])
+	case "daemon":
+		handleDaemonCommand(os.Args[2:])
+	case "import":
+		handleImportCommand(os.Args[2:])
+	case "clip":
+		handleClipCommand()
+	case "attest":
+		handleAttestCommand(os.Args[2:])
+	case "history":
+		handleHistoryCommand(os.Args[2:])
+	case "report":
+		handleReportCommand(os.Args[2:])
+	case "theme":
+		handleThemeCommand(os.Args[2:])
+	case "config":
+		handleConfigCommand(os.Args[2:])
+	case "selftest":
+		handleSelftestCommand(os.Args[2:])
+	case "stats":
+		handleStatsCommand(os.Args[2:])
+	case "snapshot":
+		handleSnapshotCommand(os.Args[2:])
+	case "find":
+		handleFindCommand(os.Args[2:])
+	case "index":
+		handleIndexCommand(os.Args[2:])
+	case "schema":
+		os.Stdout.Write(schema.JSON())
+	case "serve":
+		handleServeCommand(os.Args[2:])
+	case "help":
+		util.Wiper()
+		printUsage()
+	case "version":
+		printVersion(os.Args[2:])
+	default:
+		util.Wiper()
+		fmt.Println(util.BRH.Render("[!] " + i18n.T("Unknown command: %s", command) + "\n"))
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func handleAnalyseCommand(args []string) {
+	util.Wiper()
+
+	if i := slices.Index(args, "--compare"); i >= 0 {
+		if i+1 >= len(args) {
+			fmt.Println(util.BRH.Render("[X] " + i18n.T("--compare needs a snapshot file")))
+			fmt.Println(util.SUB.Render(i18n.T("Usage: %s", "caligra analyse --compare <snapshot> [dir]")))
+			os.Exit(1)
+		}
+		handleCompareCommand(args[i+1], slices.Delete(slices.Clone(args), i, i+2))
+		return
+	}
+
+	raw := slices.Contains(args, "--raw")
+	jsonOut := slices.Contains(args, "--json")
+	args = slices.DeleteFunc(args, func(arg string) bool { return arg == "--raw" || arg == "--json" })
+
+	if jsonOut {
+		if raw {
+			failJSON(fmt.Errorf("--raw has no JSON form"))
+		}
+		if len(args) < 1 {
+			failJSON(fmt.Errorf("no file specified for analysis"))
+		}
+		analyseJSON(args)
+		return
+	}
+
+	if len(args) < 1 {
+		fmt.Println(util.LBL.Render("[X] " + i18n.T("No file specified for analysis")))
+		fmt.Println(util.SUB.Render(i18n.T("Usage: %s", "caligra analyse <file>")))
+		os.Exit(1)
+	}
+
+	path := args[0]
+	maxSize := parseMaxSize(args[1:])
+	scanContent := slices.Contains(args[1:], "--pii")
+
+	// remote files are analysed from a private temp copy
+	target := path
+	if remote.IsURL(path) {
+		fmt.Println(util.NSH.Render("[~] " + i18n.T("Downloading: %s", path)))
+		tmpPath, cleanup, err := remote.Fetch(path, maxSize)
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		defer cleanup()
+		target = tmpPath
+	} else if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Println(util.LBL.Render("[X] " + i18n.T("File not found: %s", path)))
+		os.Exit(1)
+	}
+
+	fmt.Println(util.NSH.Render("[~] " + i18n.T("Analyzing: %s", path)))
+
+	if raw {
+		layout, err := formats.ReadLayout(target)
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + i18n.T("Analysis failed: %s", err)))
+			printHint(err)
+			os.Exit(1)
+		}
+		fmt.Println(analyse.GenerateRawDump(path, layout))
+		return
+	}
+
+	var result string
+	err := util.Track(i18n.T("Analyzing metadata"), func() error {
+		report, err := analyse.Analyze(target)
+		if err != nil {
+			return err
+		}
+		if scanContent {
+			if err := analyse.ScanContent(report); err != nil {
+				return err
+			}
+		}
+		report.Path = path
+		result = analyse.GenerateReport(report)
+		return nil
+	})
+
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("Analysis failed: %s", err)))
+		printHint(err)
+		os.Exit(1)
+	}
+
+	fmt.Println(util.LBL.Render("[✓] " + i18n.T("Analysis completed successfully") + "\n"))
+	fmt.Println(result)
+}
+
+// analyse --json: one analysis document, or an error document
+func analyseJSON(args []string) {
+	util.SetReporter(util.Silent)
+	path := args[0]
+
+	target := path
+	if remote.IsURL(path) {
+		tmpPath, cleanup, err := remote.Fetch(path, parseMaxSize(args[1:]))
+		if err != nil {
+			failJSON(err)
+		}
+		defer cleanup()
+		target = tmpPath
+	}
+
+	report, err := analyse.Analyze(target)
+	if err == nil && slices.Contains(args[1:], "--pii") {
+		err = analyse.ScanContent(report)
+	}
+	if err != nil {
+		failJSON(err)
+	}
+	report.Path = path
+	printJSON(schema.FromReport(report))
+}
+
+// wipe --json takes one local file, so the result is one document
+func wipeJSONChecks(args []string) {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		failJSON(fmt.Errorf("no file specified for wiping"))
+	}
+	path := args[0]
+	batched := len(args) > 1 && !strings.HasPrefix(args[1], "-") ||
+		slices.Contains(args, "--manifest") || slices.Contains(args, "--resume")
+	if batched || remote.IsURL(path) || remote.IsTarget(path) {
+		failJSON(fmt.Errorf("--json works on one local file; batch results are in the manifest"))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		failJSON(err)
+	}
+	if info.IsDir() {
+		failJSON(fmt.Errorf("--json works on one local file; batch results are in the manifest"))
+	}
+}
+
+// a document of the published schema, indented
+func printJSON(doc any) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		data, _ = json.Marshal(schema.FromError(err))
+	}
+	fmt.Println(string(data))
+}
+
+// in JSON mode failures are documents too, with the usual exit status
+func failJSON(err error) {
+	printJSON(schema.FromError(err))
+	os.Exit(1)
+}
+
+func handleWipeCommand(args []string) {
+	util.Wiper()
+
+	jsonOut := slices.Contains(args, "--json")
+	args = slices.DeleteFunc(args, func(arg string) bool { return arg == "--json" })
+	if jsonOut {
+		util.SetReporter(util.Silent)
+		wipeJSONChecks(args)
+	}
+
+	if len(args) < 1 {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("No file specified for wiping")))
+		fmt.Println(util.NSH.Render(i18n.T("Usage: %s", "caligra wipe <file> [options]")))
+		os.Exit(1)
+	}
+
+	// leading arguments are inputs, the rest options
+	inputs := []string{}
+	for len(inputs) < len(args) && !strings.HasPrefix(args[len(inputs)], "-") {
+		inputs = append(inputs, args[len(inputs)])
+	}
+	resuming := slices.Contains(args, "--resume")
+	if len(inputs) == 0 && !resuming {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("No file specified for wiping")))
+		os.Exit(1)
+	}
+	if len(inputs) > 0 && resuming {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("--resume takes its inputs from the manifest")))
+		os.Exit(1)
+	}
+
+	for _, input := range inputs {
+		if remote.IsURL(input) || remote.IsTarget(input) {
+			if len(inputs) > 1 {
+				fmt.Println(util.BRH.Render("[X] " + i18n.T("Remote locations are wiped one at a time")))
+				os.Exit(1)
+			}
+			continue
+		}
+		if _, err := os.Stat(input); os.IsNotExist(err) {
+			fmt.Println(util.BRH.Render("[X] " + i18n.T("File not found: %s", input)))
+			os.Exit(1)
+		}
+	}
+
+	options := wipe.DefaultWipeOptions()
+	options.Audit = "cli"
+	recursive := false
+	manifestPath := ""
+	selection := ""
+
+	for i := len(inputs); i < len(args); i++ {
+		switch args[i] {
+		case "-r", "--recursive":
+			recursive = true
+		case "--filter":
+			if i+1 < len(args) {
+				i++
+				if _, err := filter.Parse(args[i]); err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+				selection = args[i]
+			}
+		case "--manifest", "--resume":
+			if i+1 < len(args) {
+				i++
+				manifestPath = args[i]
+			}
+		case "--no-profile":
+			options.InjectProfile = false
+		case "--in-place":
+			options.CreateCopy = false
+		case "--no-backup":
+			options.KeepBackup = false
+		case "--secure":
+			options.SecureDelete = true
+		case "--profile":
+			if i+1 < len(args) {
+				i++
+				// checked now, loaded again for each file
+				if _, err := config.LoadNamedProfile(args[i]); err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+				options.ProfileName = args[i]
+			}
+		case "--timezone":
+			if i+1 < len(args) {
+				i++
+				loc, err := time.LoadLocation(args[i])
+				if err != nil {
+					fmt.Println(util.BRH.Render("[X] " + i18n.T("Unknown timezone: %s", args[i])))
+					os.Exit(1)
+				}
+				options.Timezone = loc
+			}
+		case "--utc":
+			options.Timezone = time.UTC
+		case "--redact-pii":
+			options.RedactPII = true
+		case "--policy":
+			if i+1 < len(args) {
+				i++
+				policy, err := config.LoadPolicy(args[i])
+				if err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+				options.Policy = policy
+			}
+		case "--cover-art":
+			if i+1 < len(args) {
+				i++
+				if options.Policy == nil {
+					options.Policy = config.DefaultPolicy()
+				}
+				options.Policy.CoverArt = args[i]
+				if err := options.Policy.Validate(); err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+			}
+		case "--dates":
+			if i+1 < len(args) {
+				i++
+				if options.Policy == nil {
+					options.Policy = config.DefaultPolicy()
+				}
+				options.Policy.Dates = args[i]
+				if err := options.Policy.Validate(); err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+			}
+		case "--keep-encoder", "--keep-replaygain", "--keep-musicbrainz", "--keep-acoustid":
+			if options.Policy == nil {
+				options.Policy = config.DefaultPolicy()
+			}
+			switch args[i] {
+			case "--keep-encoder":
+				options.Policy.Encoder = config.Keep
+			case "--keep-replaygain":
+				options.Policy.ReplayGain = config.Keep
+			case "--keep-musicbrainz":
+				options.Policy.MusicBrainz = config.Keep
+			case "--keep-acoustid":
+				options.Policy.AcoustID = config.Keep
+			}
+		case "--attendees":
+			if i+1 < len(args) {
+				i++
+				if options.Policy == nil {
+					options.Policy = config.DefaultPolicy()
+				}
+				options.Policy.Attendees = args[i]
+				if err := options.Policy.Validate(); err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+			}
+		case "--strip-coordinates":
+			if options.Policy == nil {
+				options.Policy = config.DefaultPolicy()
+			}
+			options.Policy.Coordinates = config.Remove
+		case "--excise-makernote":
+			if options.Policy == nil {
+				options.Policy = config.DefaultPolicy()
+			}
+			options.Policy.ExciseMakerNote = true
+		case "--auto-rotate":
+			if options.Policy == nil {
+				options.Policy = config.DefaultPolicy()
+			}
+			options.Policy.AutoRotate = true
+		case "--sidecars":
+			if i+1 < len(args) {
+				i++
+				if err := wipe.ValidateSidecars(args[i]); err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+				options.Sidecars = args[i]
+			}
+		case "--mkv-keep":
+			if i+1 < len(args) {
+				i++
+				keep, err := formats.ParseMatroskaKeep(args[i])
+				if err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+				options.Matroska = &keep
+			}
+		}
+	}
+
+	if resuming {
+		if manifestPath == "" {
+			fmt.Println(util.BRH.Render("[X] " + i18n.T("--resume needs the manifest of the interrupted run")))
+			os.Exit(1)
+		}
+		resumeBatch(manifestPath, options)
+		return
+	}
+
+	path := inputs[0]
+
+	if jsonOut {
+		wiped, err := wipe.WipeFile(path, options)
+		if err != nil {
+			failJSON(err)
+		}
+		printJSON(schema.FromWipe(wiped))
+		if !wiped.Success {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if remote.IsURL(path) {
+		wipeRemoteFile(path, options, parseMaxSize(args[1:]))
+		return
+	}
+
+	if remote.IsTarget(path) {
+		wipeRemoteTarget(path, options, parseMaxSize(args[1:]))
+		return
+	}
+
+	if info, err := os.Stat(path); len(inputs) > 1 || manifestPath != "" || selection != "" || (err == nil && info.IsDir()) {
+		wipeBatch(inputs, recursive, selection, options, manifestPath)
+		return
+	}
+
+	fmt.Println(util.NSH.Render("[~] " + i18n.T("Processing: %s", path)))
+
+	var result string
+	err := util.Track(i18n.T("Removing metadata"), func() error {
+		wiped, err := wipe.WipeFile(path, options)
+		if err != nil {
+			return err
+		}
+		result = wipe.FormatWipeResult(wiped)
+		return nil
+	})
+
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("Wipe failed: %s", err)))
+		printHint(err)
+		os.Exit(1)
+	}
+
+	fmt.Println(util.LBL.Render("[✓] " + i18n.T("Wipe completed successfully") + "\n"))
+	fmt.Println(result)
+}
+
+func handleImportCommand(args []string) {
+	util.Wiper()
+
+	if len(args) < 1 {
+		fmt.Println(util.BRH.Render("[X] No card specified for import"))
+		fmt.Println(util.NSH.Render("Usage: caligra import <mounted-card> --to <dir> [options]"))
+		os.Exit(1)
+	}
+
+	source := args[0]
+	options := batch.ImportOptions{
+		Rename:        batch.RenameKeep,
+		InjectProfile: true,
+	}
+	manifestPath := ""
+
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--to":
+			if i+1 < len(args) {
+				i++
+				options.Destination = args[i]
+			}
+		case "--rename":
+			if i+1 < len(args) {
+				i++
+				options.Rename = args[i]
+			}
+		case "--manifest":
+			if i+1 < len(args) {
+				i++
+				manifestPath = args[i]
+			}
+		case "--no-profile":
+			options.InjectProfile = false
+		}
+	}
+
+	if options.Destination == "" {
+		fmt.Println(util.BRH.Render("[X] No destination given (use --to <dir>)"))
+		os.Exit(1)
+	}
+
+	if manifestPath == "" {
+		manifestPath = filepath.Join(options.Destination,
+			"import-manifest-"+time.Now().Format("20060102-150405")+".json")
+	}
+
+	fmt.Println(util.NSH.Render("[~] Importing from: " + source))
+
+	manifest, err := batch.Import(source, options, func(entry batch.ManifestEntry) {
+		switch entry.Status {
+		case batch.StatusOK:
+			fmt.Println(util.SEC.Render("  ✓ " + entry.Input + " → " + entry.Output))
+		case batch.StatusSkipped:
+			fmt.Println(util.SUB.Render("  - " + entry.Input + " (" + entry.Error + ")"))
+		default:
+			fmt.Println(util.BRH.Render("  ! " + entry.Input + ": " + entry.Error))
+		}
+	})
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Import failed: " + err.Error()))
+		os.Exit(1)
+	}
+
+	if err := manifest.Save(manifestPath); err != nil {
+		fmt.Println(util.BRH.Render("[!] " + err.Error()))
+	}
+
+	summary := manifest.Summary()
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(fmt.Sprintf("[✓] Imported %d files (%d with issues, %d failed, %d skipped)",
+		summary[batch.StatusOK]+summary[batch.StatusIssues], summary[batch.StatusIssues],
+		summary[batch.StatusFailed], summary[batch.StatusSkipped])))
+	fmt.Println(util.NSH.Render("[i] Manifest written to: " + manifestPath))
+}
+
+func handleClipCommand() {
+	util.Wiper()
+
+	data, mime, err := util.ReadClipboardImage()
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+
+	fmt.Println(util.NSH.Render(fmt.Sprintf("[~] Clipboard image: %s, %d bytes", mime, len(data))))
+
+	tmp, err := util.CreateTempFile("caligra-clip-*" + util.ImageExtensionForMIME(mime))
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Could not create temp file: " + err.Error()))
+		os.Exit(1)
+	}
+	tmpPath := tmp.Name()
+	// the original image never lingers on disk
+	defer util.SecureOverwriteFile(tmpPath)
+
+	if err := util.EnsureSafePermissions(tmpPath); err != nil {
+		fmt.Println(util.BRH.Render("[!] Could not restrict temp file permissions"))
+	}
+	_, err = tmp.Write(data)
+	tmp.Close()
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Could not write temp file: " + err.Error()))
+		return
+	}
+
+	result, err := wipe.WipeFile(tmpPath, &wipe.WipeOptions{
+		InjectProfile: false,
+		CreateCopy:    false,
+		KeepBackup:    false,
+		SecureDelete:  true,
+	})
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Wipe failed: " + err.Error()))
+		return
+	}
+
+	clean, err := os.ReadFile(tmpPath)
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Could not read cleaned image: " + err.Error()))
+		return
+	}
+
+	if err := util.WriteClipboardImage(clean, mime); err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		return
+	}
+
+	fmt.Println(util.LBL.Render("[✓] Clean image placed back on the clipboard\n"))
+	fmt.Println(wipe.FormatWipeResult(result))
+}
+
+// checks an attestation's signature and the file it describes
+func handleAttestCommand(args []string) {
+	util.Wiper()
+
+	if len(args) < 1 {
+		fmt.Println(util.BRH.Render("[X] No attestation specified"))
+		fmt.Println(util.NSH.Render("Usage: caligra attest <file.attestation.json>"))
+		os.Exit(1)
+	}
+
+	att, err := wipe.VerifyAttestation(args[0])
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Attestation invalid: " + err.Error()))
+		os.Exit(1)
+	}
+
+	fmt.Println(util.LBL.Render("[✓] Signature valid\n"))
+	fmt.Println(util.NSH.Render(fmt.Sprintf("File:    %s (%d bytes)", att.File, att.Size)))
+	fmt.Println(util.NSH.Render("SHA-256: " + att.SHA256))
+	fmt.Println(util.NSH.Render("Created: " + att.Created))
+	if att.Policy != "" {
+		fmt.Println(util.NSH.Render("Policy:  " + att.Policy))
+	}
+	fmt.Println(util.NSH.Render("Key:     " + att.PublicKey))
+	fmt.Println("")
+	for _, step := range att.Steps {
+		fmt.Println(util.SEC.Render("  • " + step))
+	}
+	for _, e := range att.Errors {
+		fmt.Println(util.BRH.Render("  • " + e))
+	}
+}
+
+// the audit journal: opt in, list, anchor and verify
+func handleHistoryCommand(args []string) {
+	util.Wiper()
+
+	subcommand := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		subcommand, args = args[0], args[1:]
+	}
+	journal := audit.JournalPath()
+	if subcommand != "init" && !audit.Enabled() {
+		fmt.Println(util.BRH.Render("[!] " + i18n.T("No audit journal; start one with: caligra history init")))
+		os.Exit(1)
+	}
+
+	switch subcommand {
+	case "init":
+		created, err := audit.Init()
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		if !created {
+			fmt.Println(util.NSH.Render("[i] " + i18n.T("Audit journal already exists: %s", journal)))
+			return
+		}
+		fmt.Println(util.LBL.Render("[✓] " + i18n.T("Audit journal started: %s", journal)))
+		fmt.Println(util.SUB.Render("[i] " + i18n.T("Wipes by the CLI and the daemon are recorded from now on")))
+
+	case "list":
+		limit := 20
+		for i := 0; i < len(args); i++ {
+			if (args[i] == "--limit" || args[i] == "-n") && i+1 < len(args) {
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 0 {
+					fmt.Println(util.BRH.Render("[X] " + i18n.T("Invalid %s: %s", "--limit", args[i])))
+					os.Exit(1)
+				}
+				limit = n
+			}
+		}
+		entries, err := audit.ReadJournal(journal)
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		if limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		for _, entry := range entries {
+			fmt.Println(formatAuditEntry(entry))
+		}
+
+	case "anchor":
+		targets := []string{audit.AnchorsPath()}
+		for i := 0; i < len(args); i++ {
+			if args[i] == "--to" && i+1 < len(args) {
+				i++
+				targets = append(targets, config.ExpandPath(args[i]))
+			}
+		}
+		for _, target := range targets {
+			anchor, err := audit.AnchorHead(target)
+			if err != nil {
+				fmt.Println(util.BRH.Render("[X] " + err.Error()))
+				os.Exit(1)
+			}
+			fmt.Println(util.LBL.Render("[✓] " + i18n.T("Anchored entry %d (%s) in %s", anchor.Seq, anchor.Hash[:16], target)))
+		}
+
+	case "verify":
+		anchors := audit.AnchorsPath()
+		for i := 0; i < len(args); i++ {
+			if args[i] == "--anchors" && i+1 < len(args) {
+				i++
+				anchors = config.ExpandPath(args[i])
+			}
+		}
+		report, err := audit.Verify(journal, anchors)
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		if !report.Intact() {
+			fmt.Println(util.BRH.Render("[X] " + i18n.T("Audit journal was tampered with") + "\n"))
+			for _, problem := range report.Problems {
+				where := i18n.T("anchors")
+				if problem.Line > 0 {
+					where = i18n.T("line %d", problem.Line)
+				}
+				fmt.Println(util.BRH.Render("  • " + where + ": " + problem.Message))
+			}
+			os.Exit(1)
+		}
+		fmt.Println(util.LBL.Render("[✓] " + i18n.T("Audit journal intact: %d entries, %d anchors checked", report.Entries, report.Anchors)))
+		fmt.Println(util.SUB.Render("[i] " + i18n.T("Head: %s", report.Head)))
+		if report.Anchors == 0 {
+			fmt.Println(util.NSH.Render("[!] " + i18n.T("No anchors yet; without one, a rewritten journal cannot be told apart")))
+		}
+
+	default:
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("Unknown history command: %s", subcommand)))
+		fmt.Println(util.NSH.Render(i18n.T("Usage: %s", "caligra history [init|list|anchor|verify]")))
+		os.Exit(1)
+	}
+}
+
+// one journal entry as a line: sequence, time, outcome and paths
+func formatAuditEntry(entry audit.Entry) string {
+	line := fmt.Sprintf("%5d  %s  ", entry.Seq, entry.Time.Local().Format("2006-01-02 15:04:05"))
+	if entry.Operation == audit.OperationInit {
+		return util.SUB.Render(line + i18n.T("journal started"))
+	}
+
+	line += fmt.Sprintf("%-6s %-7s %s", entry.Status, entry.Source, entry.Input)
+	if entry.Output != "" && entry.Output != entry.Input {
+		line += " → " + entry.Output
+	}
+	switch entry.Status {
+	case audit.StatusOK:
+		return util.SEC.Render(line)
+	case audit.StatusIssues:
+		return util.LBL.Render(line + " (" + entry.Error + ")")
+	default:
+		return util.BRH.Render(line + " (" + entry.Error + ")")
+	}
+}
+
+// checks scroud.toml, yogra.toml and the profile, or the files given
+func handleConfigCommand(args []string) {
+	util.Wiper()
+
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Println(util.NSH.Render(i18n.T("Usage: %s", "caligra config validate [file...]")))
+		os.Exit(1)
+	}
+
+	known := config.Known{Extensions: formats.SupportedFormats()}
+	for _, ext := range known.Extensions {
+		if format, err := formats.GetFormatType(ext); err == nil && !slices.Contains(known.Formats, format) {
+			known.Formats = append(known.Formats, format)
+		}
+	}
+
+	// a file's kind goes by its name; without files, the ones in use
+	type target struct {
+		kind, path string
+	}
+	var targets []target
+	for _, path := range args[1:] {
+		switch {
+		case strings.HasSuffix(path, ".lua"):
+			targets = append(targets, target{"profile.lua", path})
+		case filepath.Base(path) == "yogra.toml":
+			targets = append(targets, target{"yogra.toml", path})
+		default:
+			targets = append(targets, target{"scroud.toml", path})
+		}
+	}
+	if len(targets) == 0 {
+		daemonConfig := config.DaemonConfigPath()
+		if _, err := os.Stat(daemonConfig); err != nil {
+			daemonConfig = ""
+		}
+		targets = []target{
+			{"scroud.toml", daemonConfig},
+			{"yogra.toml", util.ThemeConfigFile()},
+			{"profile.lua", config.ProfilePath("profile")},
+		}
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, t := range targets {
+		if t.path == "" {
+			fmt.Println(util.SUB.Render("[i] " + i18n.T("No %s found; defaults are used", t.kind)))
+			continue
+		}
+
+		var issues []config.Issue
+		switch t.kind {
+		case "profile.lua":
+			issues = config.ValidateProfile(t.path)
+		case "yogra.toml":
+			issues = config.ValidateThemeConfig(t.path)
+		default:
+			issues = config.ValidateDaemonConfig(t.path, known)
+		}
+
+		if len(issues) == 0 {
+			fmt.Println(util.LBL.Render("[✓] " + i18n.T("%s: no problems found", t.path)))
+			continue
+		}
+		for _, issue := range issues {
+			if issue.Warning {
+				warningCount++
+				fmt.Println(util.NSH.Render("[!] " + issue.String()))
+			} else {
+				errorCount++
+				fmt.Println(util.BRH.Render("[X] " + issue.String()))
+			}
+		}
+	}
+
+	if errorCount > 0 {
+		fmt.Println(util.BRH.Render("\n[X] " + i18n.T("%d errors, %d warnings", errorCount, warningCount)))
+		os.Exit(1)
+	}
+	if warningCount > 0 {
+		fmt.Println(util.NSH.Render("\n[!] " + i18n.T("%d errors, %d warnings", errorCount, warningCount)))
+	}
+}
+
+// lists, selects and previews the color themes
+func handleThemeCommand(args []string) {
+	util.Wiper()
+
+	subcommand := "list"
+	if len(args) > 0 {
+		subcommand = args[0]
+	}
+
+	switch subcommand {
+	case "list":
+		config, err := util.LoadThemeConfig()
+		if err != nil {
+			fmt.Println(util.BRH.Render("[!] " + err.Error()))
+		}
+		for _, theme := range util.Themes() {
+			marker := "  "
+			if strings.EqualFold(theme.Name, config.Name()) {
+				marker = util.LBL.Render("→ ")
+			}
+			fmt.Printf("%s%s %s\n", marker, util.NSH.Render(fmt.Sprintf("%-14s", theme.Name)), util.SUB.Render(theme.Description))
+		}
+		fmt.Println("")
+		if config.Path == "" {
+			fmt.Println(util.SEC.Render("[i] No theme file, using " + util.DefaultTheme + " (would be " + util.ThemePath() + ")"))
+		} else {
+			fmt.Println(util.SEC.Render("[i] Theme file: " + config.Path))
+			if config.Customized() {
+				fmt.Println(util.SEC.Render("[i] [colors] overrides some roles of " + config.Name()))
+			}
+		}
+	case "set":
+		if len(args) < 2 {
+			fmt.Println(util.BRH.Render("[X] No theme specified"))
+			fmt.Println(util.NSH.Render("Usage: caligra theme set <name>"))
+			os.Exit(1)
+		}
+		backup, err := util.SetTheme(args[1])
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		preview, _ := util.PreviewTheme(args[1])
+		fmt.Print(preview)
+		fmt.Println(util.LBL.Render("\n[✓] Theme set to " + args[1]))
+		fmt.Println(util.SEC.Render("[i] Written to: " + util.ThemePath()))
+		if backup != "" {
+			fmt.Println(util.SEC.Render("[i] Previous custom colors kept in: " + backup))
+		}
+	case "preview":
+		name := ""
+		if len(args) > 1 {
+			name = args[1]
+		}
+		preview, err := util.PreviewTheme(name)
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		fmt.Print(preview)
+	default:
+		fmt.Println(util.BRH.Render("[X] Unknown theme command: " + subcommand))
+		fmt.Println(util.NSH.Render("Usage: caligra theme list|set <name>|preview [name]"))
+		os.Exit(1)
+	}
+}
+
+// generates a sample of each format and runs analyse → wipe → verify on it
+func handleSelftestCommand(args []string) {
+	util.Wiper()
+
+	keep := false
+	var exts []string
+	for _, arg := range args {
+		switch arg {
+		case "--keep":
+			keep = true
+		default:
+			exts = append(exts, arg)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "caligra-selftest-")
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+	if !keep {
+		defer os.RemoveAll(dir)
+	}
+
+	fmt.Println(util.NSH.Render("[~] " + i18n.T("Running analyse → wipe → verify on generated samples")))
+	fmt.Println("")
+
+	// the pipelines' own progress would bury the table
+	previous := util.SetReporter(util.Silent)
+	results, err := selftest.Run(dir, exts, printSelftestResult)
+	util.SetReporter(previous)
+	if err != nil {
+		os.RemoveAll(dir)
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+
+	ok, failed, skipped := selftest.Summarize(results)
+	fmt.Println("")
+	fmt.Println(util.LBL.Render("[✓] " + i18n.T("%d pipelines work, %d failed, %d skipped", ok, failed, skipped)))
+	if skipped > 0 {
+		fmt.Println(util.SEC.Render("[i] " + i18n.T("Install the missing backends to enable the skipped formats")))
+	}
+	if keep {
+		fmt.Println(util.SEC.Render("[i] " + i18n.T("Samples kept in: %s", dir)))
+	}
+	if failed > 0 {
+		if !keep {
+			os.RemoveAll(dir)
+		}
+		os.Exit(1)
+	}
+}
+
+func printSelftestResult(r selftest.Result) {
+	format := fmt.Sprintf("%-5s", r.Format)
+	backends := "-"
+	if len(r.Needs) > 0 {
+		backends = strings.Join(r.Needs, ", ")
+	}
+	backends = fmt.Sprintf("%-28s", backends)
+
+	switch r.Status {
+	case selftest.StatusOK:
+		fmt.Println(util.SEC.Render("  ✓ ") + util.NSH.Render(format) + " " + util.SUB.Render(backends) + " " + util.SEC.Render(r.Detail))
+	case selftest.StatusSkipped:
+		fmt.Println(util.SUB.Render("  – " + format + " " + backends + " " + i18n.T("skipped: %s", r.Detail)))
+	default:
+		fmt.Println(util.BRH.Render("  ✗ ") + util.NSH.Render(format) + " " + util.SUB.Render(backends) + " " + util.BRH.Render(r.Stage+": "+r.Detail))
+		if r.Hint != "" {
+			fmt.Println(util.SEC.Render("    " + r.Hint))
+		}
+	}
+}
+
+// wipes several files or directories (those the filter expression selects)
+// and records every result in a manifest, saved after each file so the run
+// can be resumed
+func wipeBatch(inputs []string, recursive bool, selection string, options *wipe.WipeOptions, manifestPath string) {
+	if manifestPath == "" {
+		manifestPath = "wipe-manifest-" + time.Now().Format("20060102-150405") + ".json"
+	}
+
+	fmt.Println(util.NSH.Render("[~] " + i18n.T("Processing: %s", strings.Join(inputs, ", "))))
+	fmt.Println(util.SUB.Render("[i] " + i18n.T("Journal: %s (continue with --resume if interrupted)", manifestPath)))
+
+	manifest, err := batch.Wipe(inputs, recursive, selection, options, manifestPath, printBatchEntry)
+	finishBatch(manifest, manifestPath, err)
+}
+
+// continues an interrupted batch wipe from its manifest
+func resumeBatch(manifestPath string, options *wipe.WipeOptions) {
+	previous, err := batch.LoadManifest(manifestPath)
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+	done := 0
+	for _, entry := range previous.Entries {
+		if entry.Done() {
+			done++
+		}
+	}
+
+	fmt.Println(util.NSH.Render("[~] " + i18n.T("Resuming: %s", previous.Source)))
+	fmt.Println(util.SUB.Render("[i] " + i18n.T("%d files already done, skipping them", done)))
+	if previous.Settings != nil {
+		fmt.Println(util.SUB.Render("[i] " + i18n.T("Wiping with the options of the interrupted run")))
+	}
+
+	manifest, err := batch.Resume(manifestPath, options, printBatchEntry)
+	finishBatch(manifest, manifestPath, err)
+}
+
+func printBatchEntry(entry batch.ManifestEntry) {
+	switch entry.Status {
+	case batch.StatusOK:
+		fmt.Println(util.SEC.Render("  ✓ " + entry.Input + " → " + entry.Output))
+	default:
+		fmt.Println(util.BRH.Render("  ! " + entry.Input + ": " + entry.Error))
+		if entry.Hint != "" {
+			fmt.Println(util.SEC.Render("    " + entry.Hint))
+		}
+	}
+}
+
+// prints what can be done about err, when the library knows
+func printHint(err error) {
+	if hint := wipe.Hint(err); hint != "" {
+		fmt.Println(util.SEC.Render("[i] " + hint))
+	}
+}
+
+func finishBatch(manifest *batch.Manifest, manifestPath string, err error) {
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("Wipe failed: %s", err)))
+		printHint(err)
+		os.Exit(1)
+	}
+
+	summary := manifest.Summary()
+	fmt.Println("")
+	fmt.Println(util.LBL.Render("[✓] " + i18n.T("Wiped %d files (%d with issues, %d failed)",
+		summary[batch.StatusOK]+summary[batch.StatusIssues], summary[batch.StatusIssues],
+		summary[batch.StatusFailed])))
+	if before, after, files := manifest.Sizes(); files > 0 {
+		message := i18n.T("%s → %s across %d files, %s saved",
+			util.FormatBytes(before), util.FormatBytes(after), files, util.FormatBytes(before-after))
+		if after > before {
+			message = i18n.T("%s → %s across %d files, %s added",
+				util.FormatBytes(before), util.FormatBytes(after), files, util.FormatBytes(after-before))
+		}
+		fmt.Println(util.NSH.Render("[i] " + message))
+	}
+	fmt.Println(util.NSH.Render("[i] " + i18n.T("Manifest written to: %s", manifestPath)))
+
+	if summary[batch.StatusFailed] > 0 {
+		fmt.Println(util.NSH.Render("[i] " + i18n.T("Retry the failures with: %s", "caligra wipe --resume "+manifestPath)))
+		os.Exit(1)
+	}
+}
+
+// audits a directory and writes a compliance document
+func handleReportCommand(args []string) {
+	util.Wiper()
+
+	if len(args) < 1 {
+		fmt.Println(util.BRH.Render("[X] No directory specified for the report"))
+		fmt.Println(util.NSH.Render("Usage: caligra report <dir> [--format html|pdf] [--policy <name>] [-o <file>]"))
+		os.Exit(1)
+	}
+
+	dir := args[0]
+	format := "html"
+	output := ""
+	var policy *config.Policy
+
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--format":
+			if i+1 < len(args) {
+				i++
+				format = args[i]
+			}
+		case "--policy":
+			if i+1 < len(args) {
+				i++
+				p, err := config.LoadPolicy(args[i])
+				if err != nil {
+					fmt.Println(util.BRH.Render("[X] " + err.Error()))
+					os.Exit(1)
+				}
+				policy = p
+			}
+		case "-o", "--output":
+			if i+1 < len(args) {
+				i++
+				output = args[i]
+			}
+		}
+	}
+
+	if format != "html" && format != "pdf" {
+		fmt.Println(util.BRH.Render("[X] Unknown report format: " + format + " (html or pdf)"))
+		os.Exit(1)
+	}
+	if output == "" {
+		output = fmt.Sprintf("caligra-report-%s.%s", time.Now().Format("20060102"), format)
+	}
+
+	fmt.Println(util.NSH.Render("[~] Scanning: " + dir))
+
+	report, err := compliance.Scan(dir, policy, func(file compliance.FileRecord) {
+		fmt.Println(util.SUB.Render(fmt.Sprintf("  %-16s %s", file.Status, file.Path)))
+	})
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Scan failed: " + err.Error()))
+		os.Exit(1)
+	}
+
+	var data []byte
+	if format == "pdf" {
+		data, err = compliance.RenderPDF(report)
+	} else {
+		data, err = compliance.RenderHTML(report)
+	}
+	if err == nil {
+		err = os.WriteFile(output, data, 0644)
+	}
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Could not write report: " + err.Error()))
+		os.Exit(1)
+	}
+
+	counts := report.StatusCounts()
+	fmt.Println(util.LBL.Render(fmt.Sprintf("\n[✓] %d files: %d clean, %d remediated, %d need action, %d errors",
+		len(report.Files), counts[compliance.StatusClean], counts[compliance.StatusRemediated],
+		counts[compliance.StatusAction], counts[compliance.StatusError])))
+	fmt.Println(util.NSH.Render("[i] Report written to: " + output))
+}
+
+// counts formats, exposure and leaking applications across a directory tree
+func handleStatsCommand(args []string) {
+	util.Wiper()
+
+	if len(args) < 1 {
+		fmt.Println(util.BRH.Render("[X] No directory specified for stats"))
+		fmt.Println(util.NSH.Render("Usage: caligra stats <dir> [--top <n>]"))
+		os.Exit(1)
+	}
+
+	dir := args[0]
+	top := 10
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--top":
+			if i+1 < len(args) {
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 1 {
+					fmt.Println(util.BRH.Render("[X] --top needs a positive number, not " + args[i]))
+					os.Exit(1)
+				}
+				top = n
+			}
+		}
+	}
+
+	fmt.Println(util.NSH.Render("[~] Scanning: " + dir))
+
+	// one analysis per file; their own progress would scroll past by the thousand
+	previous := util.SetReporter(util.Silent)
+	result, err := census.Take(dir, top, func(done, total int) {
+		if !util.Plain() {
+			fmt.Print("\r\033[K" + util.SUB.Render(fmt.Sprintf("    %d/%d files", done, total)))
+		}
+	})
+	util.SetReporter(previous)
+	if !util.Plain() {
+		fmt.Print("\r\033[K")
+	}
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] Scan failed: " + err.Error()))
+		os.Exit(1)
+	}
+
+	row := func(name string, files int) {
+		fmt.Println(util.NSH.Render(fmt.Sprintf("  %-26s", name)) +
+			util.SUB.Render(fmt.Sprintf("%7d  %5.1f%%", files, result.Percent(files))))
+	}
+
+	fmt.Println("")
+	fmt.Println(util.LBL.Render("FILES BY FORMAT"))
+	for _, format := range result.Formats {
+		row(format.Name, format.Files)
+	}
+
+	fmt.Println("")
+	fmt.Println(util.LBL.Render("EXPOSURE"))
+	for _, exposure := range census.Exposures {
+		row(exposure.Label, result.Exposure[exposure])
+	}
+
+	if len(result.Software) > 0 {
+		fmt.Println("")
+		fmt.Println(util.LBL.Render("TOP LEAKING APPLICATIONS"))
+		for _, app := range result.Software {
+			row(app.Name, app.Files)
+		}
+	}
+
+	if len(result.Largest) > 0 {
+		fmt.Println("")
+		fmt.Println(util.LBL.Render("LARGEST METADATA PAYLOADS"))
+		for _, payload := range result.Largest {
+			fmt.Println(util.NSH.Render(fmt.Sprintf("  %9s  %4d tags  ", util.FormatBytes(int64(payload.Bytes)), payload.Tags)) +
+				util.SUB.Render(payload.Path))
+		}
+	}
+
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(fmt.Sprintf("[✓] %d files analysed, %d errors, %d unsupported skipped",
+		result.Files, result.Errors, result.Skipped)))
+}
+
+// records the metadata of every supported file under a directory, for
+// 'caligra analyse --compare' to check later
+func handleSnapshotCommand(args []string) {
 	util.Wiper()
 
-	printHeader()
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("No directory specified for the snapshot")))
+		fmt.Println(util.SUB.Render(i18n.T("Usage: %s", "caligra snapshot <dir> [-o <file>]")))
+		os.Exit(1)
+	}
+
+	dir := args[0]
+	output := fmt.Sprintf("caligra-snapshot-%s.json", time.Now().Format("20060102-150405"))
+	for i := 1; i < len(args); i++ {
+		if (args[i] == "-o" || args[i] == "--output") && i+1 < len(args) {
+			i++
+			output = args[i]
+		}
+	}
+
+	fmt.Println(util.NSH.Render("[~] " + i18n.T("Scanning: %s", dir)))
+	snap, err := snapshotScan(func(progress func(done, total int)) (*snapshot.Snapshot, error) {
+		return snapshot.Take(dir, progress)
+	})
+	if err == nil {
+		err = snap.Save(output)
+	}
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("Snapshot failed: %s", err)))
+		os.Exit(1)
+	}
+
+	fmt.Println(util.LBL.Render("[✓] " + i18n.T("%d files recorded, %d errors", len(snap.Files), snap.Errors)))
+	fmt.Println(util.NSH.Render("[i] " + i18n.T("Snapshot written to: %s", output)))
+	fmt.Println(util.SUB.Render("[i] " + i18n.T("Check for drift with: caligra analyse --compare %s", output)))
+}
+
+// analyse --compare: files whose metadata appeared or changed since the
+// snapshot; exits 1 when there are any, so it can run from cron or CI
+func handleCompareCommand(snapshotPath string, args []string) {
+	snap, err := snapshot.Load(snapshotPath)
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+	dir := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		dir = args[0] // the tree was moved, or a copy is checked
+	}
+
+	scope := dir
+	if scope == "" {
+		scope = snap.Scope
+	}
+	fmt.Println(util.NSH.Render("[~] " + i18n.T("Comparing %s with the snapshot of %s", scope, snap.Taken.Local().Format("2006-01-02 15:04"))))
+	var comparison *snapshot.Comparison
+	_, err = snapshotScan(func(progress func(done, total int)) (*snapshot.Snapshot, error) {
+		var err error
+		comparison, err = snapshot.Compare(snap, dir, progress)
+		if err != nil {
+			return nil, err
+		}
+		return comparison.Current, nil
+	})
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("Comparison failed: %s", err)))
+		os.Exit(1)
+	}
+
+	counts := make(map[string]int)
+	for _, drift := range comparison.Drifts {
+		counts[drift.Kind]++
+		switch drift.Kind {
+		case snapshot.DriftNew, snapshot.DriftChanged:
+			label := i18n.T("metadata changed")
+			if drift.Kind == snapshot.DriftNew {
+				label = i18n.T("new file with metadata")
+			}
+			fmt.Println(util.BRH.Render("[!] "+drift.Path) + util.SUB.Render("  "+label))
+			if len(drift.Added) > 0 {
+				fmt.Println(util.NSH.Render("      + " + strings.Join(drift.Added, ", ")))
+			}
+			if len(drift.Changed) > 0 {
+				fmt.Println(util.NSH.Render("      ~ " + strings.Join(drift.Changed, ", ")))
+			}
+			if len(drift.Sensitive) > 0 {
+				fmt.Println(util.BRH.Render("      ! " + i18n.T("sensitive: %s", strings.Join(drift.Sensitive, ", "))))
+			}
+		}
+	}
+
+	fmt.Println("")
+	summary := i18n.T("%d unchanged, %d changed, %d new with metadata, %d cleaned, %d removed",
+		comparison.Unchanged, counts[snapshot.DriftChanged], counts[snapshot.DriftNew],
+		counts[snapshot.DriftCleaned], counts[snapshot.DriftRemoved])
+	if len(comparison.Regressions()) > 0 {
+		fmt.Println(util.BRH.Render("[!] " + summary))
+		os.Exit(1)
+	}
+	fmt.Println(util.LBL.Render("[✓] " + summary))
+}
+
+// runs a snapshot scan with a file counter instead of per-file progress,
+// which would scroll past by the thousand
+func snapshotScan(scan func(progress func(done, total int)) (*snapshot.Snapshot, error)) (*snapshot.Snapshot, error) {
+	previous := util.SetReporter(util.Silent)
+	snap, err := scan(func(done, total int) {
+		if !util.Plain() {
+			fmt.Print("\r\033[K" + util.SUB.Render(fmt.Sprintf("    %d/%d files", done, total)))
+		}
+	})
+	util.SetReporter(previous)
+	if !util.Plain() {
+		fmt.Print("\r\033[K")
+	}
+	return snap, err
+}
+
+// prints the paths of files matching sensitivity criteria, one per line,
+// for xargs and friends; messages go to stderr
+func handleFindCommand(args []string) {
+	usage := "Usage: caligra find <dir> [--has <kind>] [--format <format|ext>] [--since <date|span>] [--filter <expr>] [-0]"
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		pathsFatal("find", "no directory specified\n"+usage)
+	}
+
+	dir := args[0]
+	options := parsePathQuery("find", args[1:], usage, false)
+
+	// nothing but paths may reach stdout
+	util.SetReporter(util.Silent)
+
+	out := bufio.NewWriter(os.Stdout)
+	err := find.Run(dir, options.criteria, options.jobs,
+		func(path string) {
+			out.WriteString(path + options.separator)
+			out.Flush()
+		},
+		func(path string, err error) {
+			fmt.Fprintf(os.Stderr, "caligra find: %s: %v\n", path, err)
+		})
+	out.Flush()
+	if err != nil {
+		pathsFatal("find", err.Error())
+	}
+}
+
+// options shared by find and index query
+type pathQuery struct {
+	criteria     find.Criteria
+	separator    string
+	jobs         int
+	changedSince time.Time // index query only
+
+	// "previous" compares with the scan before the latest
+	changedSinceText string
+}
+
+// for commands whose stdout is paths: the error goes to stderr, exit 2
+func pathsFatal(command, message string) {
+	fmt.Fprintln(os.Stderr, "caligra "+command+": "+message)
+	os.Exit(2)
+}
+
+func parsePathQuery(command string, args []string, usage string, indexed bool) pathQuery {
+	options := pathQuery{separator: "\n"}
+
+	value := func(i *int) string {
+		if *i+1 >= len(args) {
+			pathsFatal(command, args[*i]+" needs a value\n"+usage)
+		}
+		*i++
+		return args[*i]
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--has":
+			for _, key := range strings.Split(value(&i), ",") {
+				if key == "any" {
+					continue
+				}
+				exposure, ok := census.FindExposure(key)
+				if !ok {
+					pathsFatal(command, "unknown --has "+key+" ("+census.ExposureKeys()+" or any)")
+				}
+				options.criteria.Has = append(options.criteria.Has, exposure)
+			}
+		case args[i] == "--format":
+			for _, format := range strings.Split(value(&i), ",") {
+				options.criteria.Formats = append(options.criteria.Formats, strings.ToLower(strings.TrimPrefix(format, ".")))
+			}
+		case args[i] == "--since":
+			since, err := find.ParseSince(value(&i))
+			if err != nil {
+				pathsFatal(command, err.Error())
+			}
+			options.criteria.Since = since
+		case args[i] == "--filter":
+			f, err := filter.Parse(value(&i))
+			if err != nil {
+				pathsFatal(command, err.Error())
+			}
+			options.criteria.Filter = f
+		case args[i] == "--changed-since" && indexed:
+			options.changedSinceText = value(&i)
+			if options.changedSinceText != "previous" {
+				since, err := find.ParseSince(options.changedSinceText)
+				if err != nil {
+					pathsFatal(command, err.Error())
+				}
+				options.changedSince = since
+			}
+		case (args[i] == "-j" || args[i] == "--jobs") && !indexed:
+			n, err := strconv.Atoi(value(&i))
+			if err != nil || n < 1 {
+				pathsFatal(command, "--jobs needs a positive number")
+			}
+			options.jobs = n
+		case args[i] == "-0" || args[i] == "--print0":
+			options.separator = "\x00"
+		default:
+			pathsFatal(command, "unknown option "+args[i]+"\n"+usage)
+		}
+	}
+	return options
+}
 
-	if len(os.Args) < 2 {
-		printUsage()
-		os.Exit(1)
+// builds and queries the local analysis index
+func handleIndexCommand(args []string) {
+	subcommand := ""
+	if len(args) > 0 {
+		subcommand = args[0]
 	}
 
-	command := os.Args[1]
+	switch subcommand {
+	case "build":
+		util.Wiper()
+		if len(args) < 2 {
+			fmt.Println(util.BRH.Render("[X] No directory specified for the index"))
+			fmt.Println(util.NSH.Render("Usage: caligra index build <dir> [-j <n>]"))
+			os.Exit(1)
+		}
+		jobs := 0
+		for i := 2; i < len(args); i++ {
+			if (args[i] == "-j" || args[i] == "--jobs") && i+1 < len(args) {
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 1 {
+					fmt.Println(util.BRH.Render("[X] --jobs needs a positive number"))
+					os.Exit(1)
+				}
+				jobs = n
+			}
+		}
 
-	switch command {
-	case "analyse", "analyze":
-		handleAnalyseCommand(os.Args[2:])
-	case "wipe":
-		handleWipeCommand(os.Args[2:])
-	case "daemon":
-		handleDaemonCommand(os.Args[2:])
-	case "help":
+		idx := openIndex(false)
+		defer idx.Close()
+
+		fmt.Println(util.NSH.Render("[~] Indexing: " + args[1]))
+		previous := util.SetReporter(util.Silent)
+		scan, err := idx.Build(args[1], jobs, func(done, total int) {
+			if !util.Plain() {
+				fmt.Print("\r\033[K" + util.SUB.Render(fmt.Sprintf("    %d/%d files", done, total)))
+			}
+		})
+		util.SetReporter(previous)
+		if !util.Plain() {
+			fmt.Print("\r\033[K")
+		}
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] Indexing failed: " + err.Error()))
+			os.Exit(1)
+		}
+		fmt.Println(util.LBL.Render(fmt.Sprintf("[✓] %d files indexed: %d analysed, %d unchanged, %d removed, %d errors",
+			scan.Files, scan.Analysed, scan.Reused, scan.Removed, scan.Errors)))
+		fmt.Println(util.SEC.Render("[i] Index: " + index.DefaultPath()))
+
+	case "query":
+		usage := "Usage: caligra index query [dir] [--has <kind>] [--format <format|ext>] [--since <when>] [--filter <expr>] [--changed-since <when>] [-0]"
+		rest := args[1:]
+		dir := ""
+		if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+			dir, rest = rest[0], rest[1:]
+		}
+		options := parsePathQuery("index query", rest, usage, true)
+
+		idx := openIndex(true)
+		defer idx.Close()
+
+		if options.changedSinceText == "previous" {
+			scan, err := idx.PreviousScan(dir)
+			if err != nil {
+				idx.Close()
+				pathsFatal("index query", err.Error())
+			}
+			options.changedSince = scan.Started
+		}
+
+		if !options.changedSince.IsZero() {
+			changes, base, err := idx.ChangesSince(dir, options.changedSince)
+			if err != nil {
+				idx.Close()
+				pathsFatal("index query", err.Error())
+			}
+			fmt.Fprintf(os.Stderr, "caligra index query: compared with the scan of %s from %s\n",
+				base.Root, base.Started.Local().Format("2006-01-02 15:04"))
+			for _, change := range changes {
+				fmt.Print(formatChange(change) + options.separator)
+			}
+			return
+		}
+
+		entries, err := idx.Files(dir)
+		if err != nil {
+			idx.Close()
+			pathsFatal("index query", err.Error())
+		}
+		out := bufio.NewWriter(os.Stdout)
+		for _, entry := range entries {
+			if entry.Matches(options.criteria) {
+				out.WriteString(entry.Path + options.separator)
+			}
+		}
+		out.Flush()
+
+	case "scans":
 		util.Wiper()
-		printUsage()
-	case "version":
-		printVersion()
+		idx := openIndex(true)
+		defer idx.Close()
+		scans, err := idx.Scans()
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		if len(scans) == 0 {
+			fmt.Println(util.SEC.Render("[i] No scans yet; run 'caligra index build <dir>'"))
+			return
+		}
+		for _, scan := range scans {
+			fmt.Println(util.NSH.Render(scan.Started.Local().Format("2006-01-02 15:04")+"  ") +
+				util.SUB.Render(fmt.Sprintf("%6d files  %5d analysed  %4d errors  ", scan.Files, scan.Analysed, scan.Errors)) +
+				util.NSH.Render(scan.Root))
+		}
+
 	default:
 		util.Wiper()
-		fmt.Println(util.BRH.Render("[!] Unknown command: " + command + "\n"))
-		printUsage()
+		if subcommand != "" {
+			fmt.Println(util.BRH.Render("[X] Unknown index command: " + subcommand))
+		}
+		fmt.Println(util.NSH.Render("Usage: caligra index build <dir> | query [dir] [criteria] | scans"))
 		os.Exit(1)
 	}
 }
 
-func handleAnalyseCommand(args []string) {
-	util.Wiper()
-
-	if len(args) < 1 {
-		fmt.Println(util.LBL.Render("[X] No file specified for analysis"))
-		fmt.Println(util.SUB.Render("Usage: caligra analyse <file>"))
+// the index at its default path; query commands report errors on stderr
+func openIndex(quiet bool) *index.Index {
+	idx, err := index.Open(index.DefaultPath())
+	if err != nil {
+		if quiet {
+			pathsFatal("index", err.Error())
+		}
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
 		os.Exit(1)
 	}
+	return idx
+}
 
-	path := args[0]
+// "+ path  [gps]", "- path", "~ path  [+author -gps]"
+func formatChange(change index.Change) string {
+	var marks []string
+	for _, key := range change.Gained {
+		marks = append(marks, "+"+key)
+	}
+	for _, key := range change.Lost {
+		marks = append(marks, "-"+key)
+	}
+	prefix := map[string]string{index.ChangeAdded: "+ ", index.ChangeRemoved: "- ", index.ChangeModified: "~ "}[change.Kind]
+	line := prefix + change.Path
+	if len(marks) > 0 {
+		line += "  [" + strings.Join(marks, " ") + "]"
+	}
+	return line
+}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Println(util.LBL.Render("[X] File not found: " + path))
+// downloads a remote file and writes a sanitized copy to the current directory
+func wipeRemoteFile(rawURL string, options *wipe.WipeOptions, maxSize int64) {
+	fmt.Println(util.NSH.Render("[~] " + i18n.T("Downloading: %s", rawURL)))
+
+	tmpPath, cleanup, err := remote.Fetch(rawURL, maxSize)
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
 		os.Exit(1)
 	}
+	defer cleanup()
 
-	fmt.Println(util.NSH.Render("[~] Analyzing: " + path))
+	// the temp file is the working copy; only its clean version is kept
+	options.CreateCopy = false
+	options.KeepBackup = false
+	options.Audit = "" // a temp copy's path proves nothing
+	skipLocalPolicySteps(options)
 
-	result, err := util.SpinWhile("[~] Analyzing metadata", func() (string, error) {
-		report, err := analyse.Analyze(path)
+	var result string
+	err = util.Track(i18n.T("Removing metadata"), func() error {
+		wiped, err := wipe.WipeFile(tmpPath, options)
 		if err != nil {
-			return "", err
+			return err
 		}
-		return analyse.GenerateReport(report), nil
+		discardBackup(wiped)
+		if !wiped.Success {
+			return incompleteWipe(wiped, i18n.T("no copy was written"))
+		}
+
+		outputPath := remote.LocalOutputPath(rawURL)
+		if err := util.SafeCopy(tmpPath, outputPath); err != nil {
+			return err
+		}
+		wiped.OriginalPath = rawURL
+		wiped.OutputPath = outputPath
+		result = wipe.FormatWipeResult(wiped)
+		return nil
 	})
 
 	if err != nil {
-		fmt.Println(util.BRH.Render("[X] Analysis failed: " + err.Error()))
+		fmt.Println(util.BRH.Render("[X] " + i18n.T("Wipe failed: %s", err)))
+		printHint(err)
 		os.Exit(1)
 	}
 
-	fmt.Println(util.LBL.Render("[✓] Analysis completed successfully\n"))
+	fmt.Println(util.LBL.Render("[✓] " + i18n.T("Wipe completed successfully") + "\n"))
 	fmt.Println(result)
 }
 
-func handleWipeCommand(args []string) {
-	util.Wiper()
+// a failed wipe keeps its backup of the temp copy; the original is still remote
+func discardBackup(wiped *wipe.WipeResult) {
+	if wiped.BackupPath != "" {
+		_ = util.RemoveFile(wiped.BackupPath)
+		wiped.BackupPath = ""
+	}
+}
 
-	if len(args) < 1 {
-		fmt.Println(util.BRH.Render("[X] No file specified for wiping"))
-		fmt.Println(util.NSH.Render("Usage: caligra wipe <file> [options]"))
-		os.Exit(1)
+// the wipe errors (or the failed verification) of a wipe that must not be published
+func incompleteWipe(wiped *wipe.WipeResult, consequence string) error {
+	reason := strings.Join(wiped.WipeErrors, "; ")
+	if reason == "" {
+		reason = i18n.T("metadata remains after the wipe")
 	}
+	return errors.New(reason + "; " + consequence)
+}
 
-	path := args[0]
+// renaming and attestation name a local output; a temp working copy has none
+func skipLocalPolicySteps(options *wipe.WipeOptions) {
+	if options.Policy == nil || (options.Policy.Rename == "" && !options.Policy.Attestation) {
+		return
+	}
+	fmt.Println(util.NSH.Render("[i] " + i18n.T("Random renaming and attestation are skipped for remote files")))
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Println(util.BRH.Render("[X] File not found: " + path))
+	policy := *options.Policy
+	policy.Rename = ""
+	policy.Attestation = false
+	options.Policy = &policy
+}
+
+// downloads, sanitizes and re-uploads an S3/WebDAV object (or every object under a prefix)
+func wipeRemoteTarget(location string, options *wipe.WipeOptions, maxSize int64) {
+	target, err := remote.ParseTarget(location)
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
 		os.Exit(1)
 	}
 
-	options := wipe.DefaultWipeOptions()
+	objects, err := target.List()
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
 
-	for i := 1; i < len(args); i++ {
-		switch args[i] {
-		case "--no-profile":
-			options.InjectProfile = false
-		case "--in-place":
-			options.CreateCopy = false
-		case "--no-backup":
-			options.KeepBackup = false
-		case "--secure":
-			options.SecureDelete = true
-		}
+	if len(objects) == 0 {
+		fmt.Println(util.NSH.Render("[~] " + i18n.T("No objects found under %s", location)))
+		return
 	}
 
-	fmt.Println(util.NSH.Render("[~] Processing: " + path))
+	// the download is the working copy; the upload replaces the original
+	options.CreateCopy = false
+	options.KeepBackup = false
+	options.Audit = "" // a temp copy's path proves nothing
+	skipLocalPolicySteps(options)
+
+	failed := 0
+	for _, object := range objects {
+		fmt.Println(util.NSH.Render("[~] " + i18n.T("Processing: %s", object)))
+
+		var result string
+		err := util.Track(i18n.T("Removing metadata"), func() error {
+			tmpPath, cleanup, err := remote.FetchTarget(object, maxSize)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			wiped, err := wipe.WipeFile(tmpPath, options)
+			if err != nil {
+				return err
+			}
+			discardBackup(wiped)
+			if !wiped.Success {
+				return incompleteWipe(wiped, i18n.T("the original was not replaced"))
+			}
+
+			if err := object.Upload(tmpPath); err != nil {
+				return err
+			}
+			wiped.OriginalPath = object.String()
+			wiped.OutputPath = object.String()
+			result = wipe.FormatWipeResult(wiped)
+			return nil
+		})
 
-	result, err := util.SpinWhile("[~] Removing metadata", func() (string, error) {
-		result, err := wipe.WipeFile(path, options)
 		if err != nil {
-			return "", err
+			fmt.Println(util.BRH.Render("[X] " + i18n.T("Wipe failed: %s", err)))
+			printHint(err)
+			failed++
+			continue
 		}
-		return wipe.FormatWipeResult(result), nil
-	})
 
+		fmt.Println(util.LBL.Render("[✓] " + i18n.T("Wipe completed successfully") + "\n"))
+		fmt.Println(result)
+	}
+
+	if len(objects) > 1 {
+		fmt.Println(util.NSH.Render("[~] " + i18n.T("%d of %d objects sanitized", len(objects)-failed, len(objects))))
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// reads --max-size <MB> for remote downloads
+func parseMaxSize(args []string) int64 {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "--max-size" {
+			mb, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || mb <= 0 {
+				fmt.Println(util.BRH.Render("[X] " + i18n.T("Invalid --max-size: %s", args[i+1])))
+				os.Exit(1)
+			}
+			return mb << 20
+		}
+	}
+	return remote.DefaultMaxSize
+}
+
+// runs the HTTP API until interrupted
+func handleServeCommand(args []string) {
+	cfg := serve.Config{MaxUpload: parseMaxSize(args), Log: func(format string, a ...any) {
+		fmt.Println(util.SUB.Render(time.Now().Format("15:04:05") + " " + fmt.Sprintf(format, a...)))
+	}}
+
+	number := func(flag, value string) int {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			fmt.Println(util.BRH.Render("[X] " + i18n.T("Invalid %s: %s", flag, value)))
+			os.Exit(1)
+		}
+		return n
+	}
+	for i := 0; i < len(args)-1; i++ {
+		switch args[i] {
+		case "--listen":
+			cfg.Addr = args[i+1]
+		case "--token-file":
+			cfg.TokenFile = args[i+1]
+		case "--rate":
+			cfg.Rate = number(args[i], args[i+1])
+		case "--burst":
+			cfg.Burst = number(args[i], args[i+1])
+		case "--tls-cert":
+			cfg.TLSCert = args[i+1]
+		case "--tls-key":
+			cfg.TLSKey = args[i+1]
+		case "--client-ca":
+			cfg.ClientCA = args[i+1]
+		case "--policy":
+			policy, err := config.LoadPolicy(args[i+1])
+			if err != nil {
+				fmt.Println(util.BRH.Render("[X] " + err.Error()))
+				os.Exit(1)
+			}
+			cfg.Policy = policy
+		default:
+			continue
+		}
+		i++
+	}
+
+	server, err := serve.New(cfg)
 	if err != nil {
-		fmt.Println(util.BRH.Render("[X] Wipe failed: " + err.Error()))
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
 		os.Exit(1)
 	}
 
-	fmt.Println(util.LBL.Render("[✓] Wipe completed successfully\n"))
-	fmt.Println(result)
+	// progress of concurrent requests would interleave; the request log is enough
+	util.SetReporter(util.Silent)
+
+	scheme, addr := "http", cfg.Addr
+	if cfg.TLSCert != "" {
+		scheme = "https"
+	}
+	if addr == "" {
+		addr = serve.DefaultAddr
+	}
+	fmt.Println(util.LBL.Render("[✓] " + i18n.T("Serving on %s", scheme+"://"+addr)))
+	fmt.Println(util.SUB.Render("[i] " + i18n.T("POST /v1/analyse, /v1/wipe and /v1/jobs with multipart field \"file\"; Ctrl-C stops")))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := server.ListenAndServe(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
 }
 
 func handleDaemonCommand(args []string) {
@@ -141,19 +1969,12 @@ func handleDaemonCommand(args []string) {
 
 	if len(args) < 1 {
 		fmt.Println(util.BRH.Render("[X] Daemon mode requires a subcommand"))
-		fmt.Println(util.NSH.Render("Usage: caligra daemon [on|off|status]"))
+		fmt.Println(util.NSH.Render("Usage: caligra daemon [on|off|status|add-dir|remove-dir|logs|events|stats|install|uninstall|install-launchd]"))
 		os.Exit(1)
 	}
 
 	subcommand := args[0]
-
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Println(util.BRH.Render("[X] Cannot determine home directory"))
-		os.Exit(1)
-	}
-
-	pidFile := filepath.Join(homeDir, ".caligra", "daemon.pid")
+	pidFile := daemon.PIDPath()
 
 	switch subcommand {
 	case "on", "start":
@@ -189,7 +2010,14 @@ func handleDaemonCommand(args []string) {
 		fmt.Println(util.NSH.Render("[✓] Daemon started successfully"))
 
 		// keep running until interrupted
-		select {}
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		<-sigs
+
+		if err := d.Stop(); err != nil {
+			fmt.Println(util.BRH.Render("[!] Daemon shutdown incomplete: " + err.Error()))
+		}
+		os.Remove(pidFile)
 
 	case "off", "stop":
 		if !isDaemonRunning(pidFile) {
@@ -222,17 +2050,259 @@ func handleDaemonCommand(args []string) {
 			pidStr := strings.TrimSpace(string(pidBytes))
 			fmt.Println(util.NSH.Render("[...] Daemon is running (PID " + pidStr + ")"))
 
-			// in a real implementation, get more status info
-			// this could include watched directories, processed files, etc.
+			if stats, err := daemon.LoadStats(daemon.StatsPath()); err == nil {
+				today := daemon.Totals(stats.Since(time.Now()))
+				fmt.Println(util.SUB.Render(fmt.Sprintf("      today: %d scanned, %d wiped, %d errors",
+					today.Scanned, today.Wiped, today.Errors)))
+			}
 		} else {
 			fmt.Println(util.NSH.Render("[...] Daemon is not running"))
 		}
 
+	case "add-dir", "remove-dir":
+		handleDaemonDir(subcommand, args[1:], isDaemonRunning(pidFile))
+
+	case "logs":
+		handleDaemonLogs(args[1:])
+
+	case "events":
+		handleDaemonEvents(args[1:])
+
+	case "stats":
+		handleDaemonStats(args[1:])
+
+	case "install":
+		if err := daemon.InstallService(os.Getenv("HOME")); err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		fmt.Println(util.SEC.Render("[✓] Installed the " + daemon.ServiceName + " service, started at boot"))
+		fmt.Println(util.NSH.Render("[i] Start it now with: sc start " + daemon.ServiceName))
+
+	case "uninstall":
+		if err := daemon.UninstallService(); err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		fmt.Println(util.SEC.Render("[✓] Removed the " + daemon.ServiceName + " service"))
+
+	case "install-launchd":
+		path, err := daemon.InstallLaunchAgent()
+		if err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		fmt.Println(util.SEC.Render("[✓] Installed and loaded " + path))
+		fmt.Println(util.NSH.Render("[i] The daemon now starts at login; remove it with: launchctl bootout gui/$(id -u)/" + daemon.LaunchAgentLabel))
+
+	case "service":
+		// entry point for the service control manager, not for users
+		home := ""
+		if len(args) > 2 && args[1] == "--home" {
+			home = args[2]
+		}
+		if err := daemon.RunService(home); err != nil {
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+
 	default:
 		fmt.Println(util.BRH.Render("[X] Unknown daemon command: " + subcommand))
-		fmt.Println(util.NSH.Render("Usage: caligra daemon [on|off|status]"))
+		fmt.Println(util.NSH.Render("Usage: caligra daemon [on|off|status|add-dir|remove-dir|logs|events|stats|install|uninstall|install-launchd]"))
+		os.Exit(1)
+	}
+}
+
+// adds or removes a watch directory in scroud.toml and tells a running daemon
+func handleDaemonDir(subcommand string, args []string, running bool) {
+	if len(args) < 1 {
+		fmt.Println(util.BRH.Render("[X] No directory specified"))
+		fmt.Println(util.NSH.Render("Usage: caligra daemon " + subcommand + " <dir> [--policy <name>]"))
+		os.Exit(1)
+	}
+
+	dir, err := filepath.Abs(config.ExpandPath(args[0]))
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+
+	policy := ""
+	for i := 1; i < len(args); i++ {
+		if args[i] == "--policy" && i+1 < len(args) {
+			i++
+			policy = args[i]
+		}
+	}
+
+	configPath := config.DaemonConfigPath()
+	request := daemon.IPCRequest{Command: subcommand, Path: dir, Policy: policy}
+
+	if subcommand == daemon.CommandAddDir {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			fmt.Println(util.BRH.Render("[X] Not a directory: " + dir))
+			os.Exit(1)
+		}
+		if policy != "" {
+			if _, err := config.LoadPolicy(policy); err != nil {
+				fmt.Println(util.BRH.Render("[X] " + err.Error()))
+				os.Exit(1)
+			}
+		}
+		err = config.AddWatchPath(configPath, dir, policy)
+	} else {
+		err = config.RemoveWatchPath(configPath, dir)
+	}
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+	fmt.Println(util.SEC.Render("[✓] Updated " + configPath))
+
+	if !running {
+		fmt.Println(util.NSH.Render("[i] Daemon is not running; the change applies at next start"))
+		return
+	}
+
+	response, err := daemon.SendCommand(request)
+	if err != nil {
+		fmt.Println(util.BRH.Render("[!] Running daemon not updated: " + err.Error()))
+		fmt.Println(util.NSH.Render("[i] Restart it to apply the change"))
+		os.Exit(1)
+	}
+	fmt.Println(util.SEC.Render("[✓] Daemon " + response.Message))
+}
+
+func handleDaemonLogs(args []string) {
+	options := daemon.LogViewOptions{
+		MinLevel: daemon.LevelDebug,
+		Tail:     20,
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--follow", "-f":
+			options.Follow = true
+		case "--level":
+			if i+1 >= len(args) {
+				fmt.Println(util.BRH.Render("[X] --level requires a value"))
+				os.Exit(1)
+			}
+			i++
+			level, err := daemon.ParseLogLevel(args[i])
+			if err != nil {
+				fmt.Println(util.BRH.Render("[X] " + err.Error()))
+				os.Exit(1)
+			}
+			options.MinLevel = level
+		case "--lines", "-n":
+			if i+1 >= len(args) {
+				fmt.Println(util.BRH.Render("[X] --lines requires a value"))
+				os.Exit(1)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 0 {
+				fmt.Println(util.BRH.Render("[X] Invalid line count: " + args[i]))
+				os.Exit(1)
+			}
+			options.Tail = n
+		}
+	}
+
+	logPath := daemon.LogPath()
+	fmt.Println(util.NSH.Render("[~] Reading " + logPath + "\n"))
+
+	err := daemon.ViewLog(logPath, options, func(line string) {
+		fmt.Println(daemon.FormatLogLine(line))
+	})
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+}
+
+// follows the running daemon's activity until interrupted
+func handleDaemonEvents(args []string) {
+	asJSON := slices.Contains(args, "--json")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sub, err := daemon.Subscribe(ctx)
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+	defer sub.Close()
+	if !asJSON {
+		fmt.Println(util.NSH.Render(i18n.T("[~] Following daemon events; Ctrl-C stops") + "\n"))
+	}
+
+	for {
+		event, err := sub.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Println(util.BRH.Render("[X] " + err.Error()))
+			os.Exit(1)
+		}
+		if asJSON {
+			line, _ := json.Marshal(event)
+			fmt.Println(string(line))
+			continue
+		}
+		fmt.Println(formatDaemonEvent(event))
+	}
+}
+
+// one styled line per event
+func formatDaemonEvent(event daemon.Event) string {
+	stamp := event.Time.Local().Format("15:04:05") + " "
+	switch event.Type {
+	case daemon.EventFileDetected:
+		return util.SUB.Render(stamp + "[~] " + i18n.T("Detected %s", event.Path))
+	case daemon.EventFileClean:
+		return util.SUB.Render(stamp + "[i] " + i18n.T("No sensitive metadata in %s", event.Path))
+	case daemon.EventFileAnalysed:
+		return util.LBL.Render(stamp + "[!] " + i18n.T("%d sensitive fields in %s, not wiped", len(event.SensitiveFields), event.Path))
+	case daemon.EventFileWiped:
+		if len(event.Issues) > 0 {
+			return util.BRH.Render(stamp + "[!] " + i18n.T("Wiped %s with issues: %s", event.Path, strings.Join(event.Issues, "; ")))
+		}
+		return util.SEC.Render(stamp + "[✓] " + i18n.T("Wiped %s → %s", event.Path, event.Output))
+	default:
+		line := stamp + "[X] " + event.Message
+		if event.Path != "" {
+			line += " (" + event.Path + ")"
+		}
+		return util.BRH.Render(line)
+	}
+}
+
+func handleDaemonStats(args []string) {
+	span := "30d"
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--since" && i+1 < len(args) {
+			i++
+			span = args[i]
+		}
+	}
+
+	window, err := daemon.ParseSince(span)
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
 		os.Exit(1)
 	}
+
+	stats, err := daemon.LoadStats(daemon.StatsPath())
+	if err != nil {
+		fmt.Println(util.BRH.Render("[X] " + err.Error()))
+		os.Exit(1)
+	}
+
+	fmt.Print(daemon.FormatStats(stats.Since(time.Now().Add(-window)), span))
 }
 
 func isDaemonRunning(pidFile string) bool {
@@ -255,28 +2325,220 @@ func printHeader() {
 }
 
 func printUsage() {
-	fmt.Println(util.LBL.Render("USAGE"))
+	fmt.Println(util.LBL.Render(i18n.T("USAGE")))
 	fmt.Println("  caligra <command> [options]")
 	fmt.Println("")
-	fmt.Println(util.LBL.Render("COMMANDS"))
-	fmt.Println("  analyse <file>          analyze metadata in a file")
-	fmt.Println("  wipe <file> [options]   remove metadata from a file")
-	fmt.Println("  daemon <on|off|status>  manage background monitoring service")
-	fmt.Println("  help                    show this help information")
-	fmt.Println("  version                 show version information")
+	fmt.Println(util.LBL.Render(i18n.T("COMMANDS")))
+	usageLine("analyse <file|url>", "analyze metadata in a file")
+	usageLine("wipe <file|url> [opts]", "remove metadata from a file")
+	usageLine("wipe <files|dirs...>", "wipe many files, writing a JSON manifest")
+	usageLine("wipe <s3://|webdav://>", "clean remote objects in place (prefix with /)")
+	usageLine("daemon <on|off|status>", "manage background monitoring service")
+	usageLine("daemon add-dir <dir>", "watch <dir> (--policy <name>), no restart needed")
+	usageLine("daemon remove-dir <dir>", "stop watching <dir>")
+	usageLine("daemon logs [options]", "view the daemon log")
+	usageLine("daemon events [--json]", "follow what the running daemon does, live")
+	usageLine("daemon stats [--since]", "show daemon activity trends (default 30d)")
+	usageLine("daemon install", "register the daemon as a Windows service")
+	usageLine("daemon uninstall", "remove the Windows service")
+	usageLine("daemon install-launchd", "start the daemon at login on macOS (LaunchAgent)")
+	usageLine("import <card> --to <dir>", "copy and clean a camera card's DCIM folder")
+	usageLine("clip", "strip metadata from the clipboard image")
+	usageLine("attest <report>", "verify a signed wipe attestation")
+	usageLine("history init", "start the hash-chained audit journal of wipes")
+	usageLine("history [--limit <n>]", "list recent journal entries (default 20)")
+	usageLine("history anchor", "anchor the journal head (--to <file> for a copy)")
+	usageLine("history verify", "detect changes to the journal (--anchors <file>)")
+	usageLine("report <dir> [opts]", "write an HTML/PDF compliance report for a directory")
+	usageLine("config validate", "check scroud.toml, yogra.toml and the profile")
+	usageLine("theme list", "show the built-in color themes")
+	usageLine("theme set <name>", "switch the color theme")
+	usageLine("theme preview [name]", "show a theme's colors and sample output")
+	usageLine("selftest [ext...]", "check which format pipelines work on this machine")
+	usageLine("stats <dir> [--top <n>]", "count formats, exposure and leaking apps in a tree")
+	usageLine("snapshot <dir> [-o <f>]", "record a tree's metadata as a baseline")
+	usageLine("find <dir> [criteria]", "print paths of sensitive files, for xargs")
+	usageLine("index build <dir>", "index analysis results for instant queries")
+	usageLine("index query [dir]", "query the index (find criteria, --changed-since)")
+	usageLine("index scans", "list past index builds")
+	usageLine("schema", "print the JSON schema of --json output")
+	usageLine("serve [options]", "run the HTTP API for analysing and wiping uploads")
+	usageLine("help", "show this help information")
+	usageLine("version [--tools]", "show build information (and backend versions)")
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(i18n.T("ANALYSE OPTIONS")))
+	usageLine("--pii", "scan text bodies for emails, phones, IBANs, IPs")
+	usageLine("--raw", "dump every segment with offset, length and hex preview")
+	usageLine("--json", "print a versioned JSON document (see 'caligra schema')")
+	usageLine("--max-size <MB>", "download limit for URLs (default 100)")
+	usageLine("--compare <snapshot>", "list files whose metadata appeared or changed since")
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(i18n.T("WIPE OPTIONS")))
+	usageLine("-r, --recursive", "include sub-directories of directory inputs")
+	usageLine("--manifest <file>", "where to write the batch manifest")
+	usageLine("--resume <manifest>", "continue an interrupted batch wipe")
+	usageLine("--filter <expr>", "wipe only files matching, e.g. 'size<500MB && ext=jpg'")
+	usageLine("--json", "print the result as JSON (one local file)")
+	usageLine("--no-profile", "don't inject profile metadata")
+	usageLine("--in-place", "modify file in place (don't create copy)")
+	usageLine("--no-backup", "don't keep backup of original file")
+	usageLine("--secure", "securely overwrite original data")
+	usageLine("--profile <name>", "inject <name>.lua instead of profile.lua")
+	usageLine("--timezone <zone>", "rewrite dates into <zone>, strip offsets")
+	usageLine("--utc", "same as --timezone UTC")
+	usageLine("--redact-pii", "replace personal data in text bodies")
+	usageLine("--policy <name>", "apply a policy from policies.toml or a built-in preset")
+	usageLine("--cover-art <choice>", "audio cover art: keep | remove | strip")
+	usageLine("--dates <choice>", "photo and track dates: keep | remove | day | month | year")
+	usageLine("--strip-coordinates", "drop the points of GPS tracks, keep their names")
+	usageLine("--attendees <choice>", "calendar organizers and attendees: keep | remove | strip")
+	usageLine("--excise-makernote", "cut the vendor MakerNote out of EXIF whole")
+	usageLine("--auto-rotate", "apply EXIF orientation to the pixels before removing it")
+	usageLine("--keep-encoder", "keep encoder tags and LAME settings")
+	usageLine("--keep-replaygain", "keep ReplayGain/R128 gain tags")
+	usageLine("--keep-musicbrainz", "keep MusicBrainz track/release/artist IDs")
+	usageLine("--keep-acoustid", "keep AcoustID IDs and fingerprints")
+	usageLine("--sidecars <choice>", "XMP sidecars (photo.xmp, photo.jpg.xmp): wipe | delete")
+	usageLine("--mkv-keep <items>", "MKV parts to keep: fonts,covers,chapters,track-names|none")
+	usageLine("--max-size <MB>", "download limit for URLs (default 100)")
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(i18n.T("IMPORT OPTIONS")))
+	usageLine("--to <dir>", "destination for cleaned copies")
+	usageLine("--rename <scheme>", "keep | sequence | date")
+	usageLine("--manifest <file>", "where to write the import manifest")
+	usageLine("--no-profile", "don't inject profile metadata")
 	fmt.Println("")
-	fmt.Println(util.LBL.Render("WIPE OPTIONS"))
-	fmt.Println("  --no-profile            don't inject profile metadata")
-	fmt.Println("  --in-place              modify file in place (don't create copy)")
-	fmt.Println("  --no-backup             don't keep backup of original file")
-	fmt.Println("  --secure                securely overwrite original data")
+	fmt.Println(util.LBL.Render(i18n.T("REPORT OPTIONS")))
+	usageLine("--format <html|pdf>", "document format (default html)")
+	usageLine("--policy <name>", "policy the directory is held to")
+	usageLine("-o, --output <file>", "where to write the report")
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(i18n.T("FIND OPTIONS")))
+	usageLine("--has <kinds>", "gps, author, serial, identifier, device, software, timestamp")
+	usageLine("--format <formats>", "image, audio, video, text, matroska or an extension")
+	usageLine("--since <when>", "modified since a date (2023, 2023-05) or span (30d)")
+	usageLine("-j, --jobs <n>", "files analysed in parallel (default: CPU count)")
+	usageLine("-0, --print0", "separate paths with NUL, for xargs -0")
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(i18n.T("SELFTEST OPTIONS")))
+	usageLine("--keep", "keep the generated samples and their wiped copies")
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(i18n.T("SERVE OPTIONS")))
+	usageLine("--listen <addr>", "address to listen on (default 127.0.0.1:8470)")
+	usageLine("--token-file <file>", "bearer tokens, one per line (chmod 600)")
+	usageLine("--rate <n>", "requests per client per minute (default 60)")
+	usageLine("--burst <n>", "requests a client may send at once")
+	usageLine("--max-size <MB>", "upload limit (default 100)")
+	usageLine("--tls-cert <file>", "serve HTTPS with this certificate (and --tls-key)")
+	usageLine("--client-ca <file>", "require client certificates signed by this CA")
+	usageLine("--policy <name>", "wipe policy applied to every upload")
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(i18n.T("LOG OPTIONS")))
+	usageLine("-f, --follow", "keep printing new entries")
+	usageLine("--level <level>", "minimum level (debug|info|warning|error)")
+	usageLine("-n, --lines <n>", "existing lines to show (0 = all)")
+	fmt.Println("")
+	fmt.Println(util.LBL.Render(i18n.T("GLOBAL OPTIONS")))
+	usageLine("--plain", "no colors, spinner or screen clearing (automatic when piped)")
+	usageLine("--clear", "clear the screen first (or clear_screen in yogra.toml)")
+	usageLine("--no-ext-fallback", "detect by content only; unrecognized files are unknown")
+	usageLine("--max-file-size <MB>", "skip larger files (or max_size_mb in scroud.toml)")
+	usageLine("--lang <code>", i18n.T("message language: %s (default from LANG)", strings.Join(i18n.Languages(), ", ")))
+}
+
+// one help line: the command or option, then its translated description
+func usageLine(command, description string) {
+	fmt.Printf("  %-23s %s\n", command, i18n.T(description))
+}
+
+// set at release time: go build -ldflags "-X main.version=1.0.1 -X main.commit=... -X main.date=..."
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// reported when neither ldflags nor the module give a version
+const baseVersion = "1.0.0"
+
+// build details for bug reports; ldflags win, then what the Go toolchain
+// recorded (module version and VCS stamp when built from a checkout)
+func buildInfo() (ver, rev, built, goVersion string, modified bool) {
+	ver, rev, built = version, commit, date
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		if ver == "" {
+			ver = baseVersion
+		}
+		return ver, rev, built, "", false
+	}
+	goVersion = info.GoVersion
+
+	if v := info.Main.Version; ver == "" && v != "" && v != "(devel)" {
+		ver = strings.TrimPrefix(v, "v")
+	}
+	if ver == "" {
+		ver = baseVersion
+	}
+	stamped := rev == ""
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if stamped {
+				rev = setting.Value
+			}
+		case "vcs.time":
+			if built == "" {
+				built = setting.Value
+			}
+		case "vcs.modified":
+			modified = stamped && setting.Value == "true"
+		}
+	}
+	return ver, rev, built, goVersion, modified
 }
 
-func printVersion() {
+func printVersion(args []string) {
 	util.Wiper()
 
-	fmt.Println(util.LBL.Render("CALIGRA v1.0.0"))
-	fmt.Println(util.LBL.Render("→ A CLI metadata control utility for Linux"))
+	ver, rev, built, goVersion, modified := buildInfo()
+
+	fmt.Println(util.LBL.Render("CALIGRA v" + ver))
+	fmt.Println(util.LBL.Render("→ " + i18n.T("A CLI metadata control utility for Linux")))
+	fmt.Println("")
+
+	if rev != "" {
+		if len(rev) > 12 {
+			rev = rev[:12]
+		}
+		if modified {
+			rev += " (modified)"
+		}
+		fmt.Println(util.SUB.Render("  commit:   " + rev))
+	}
+	if built != "" {
+		fmt.Println(util.SUB.Render("  date:     " + built))
+	}
+	if goVersion != "" {
+		fmt.Println(util.SUB.Render("  go:       " + goVersion))
+	}
+	fmt.Println(util.SUB.Render("  platform: " + runtime.GOOS + "/" + runtime.GOARCH))
+
+	if slices.Contains(args, "--tools") {
+		fmt.Println("")
+		fmt.Println(util.LBL.Render(i18n.T("BACKENDS")))
+		for _, tool := range util.DetectTools() {
+			if tool.Path == "" {
+				fmt.Println(util.BRH.Render(fmt.Sprintf("  [X] %-9s %s", tool.Name,
+					i18n.T("not found (%s)", i18n.T(tool.Purpose)))))
+				continue
+			}
+			fmt.Println(util.NSH.Render(fmt.Sprintf("  [✓] %-9s %s", tool.Name, tool.Version)))
+			fmt.Println(util.SUB.Render("      " + tool.Path))
+		}
+	}
+
 	fmt.Println("")
 	fmt.Println(util.NSH.Render("Copyright (c) 2025 bxavaby"))
 	fmt.Println(util.SHE.Render("https://github.com/bxavaby/caligra"))
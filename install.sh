@@ -68,7 +68,9 @@ if ! command -v ffmpeg &> /dev/null; then
 fi
 
 echo -e "\n${BOLD}Building CALIGRA...${RESET}"
-go build -o caligra cmd/caligra/main.go
+COMMIT=$(git rev-parse --short HEAD 2>/dev/null)
+BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+go build -ldflags "-X main.commit=${COMMIT} -X main.date=${BUILD_DATE}" -o caligra ./cmd/caligra
 
 if [ $? -ne 0 ]; then
     echo -e "${RED}Build failed!${RESET}"
@@ -92,8 +94,10 @@ if [ ! -f "$CONFIG_DIR/scroud.toml" ]; then
     echo -e "✓ Installed default daemon configuration"
 fi
 
-if [ ! -f "$CONFIG_DIR/yogra.toml" ]; then
-    cp -n config/yogra.toml "$CONFIG_DIR/" 2>/dev/null || true
+THEME_DIR="${XDG_CONFIG_HOME:-$HOME/.config}/caligra"
+if [ ! -f "$THEME_DIR/yogra.toml" ] && [ ! -f "$CONFIG_DIR/yogra.toml" ]; then
+    mkdir -p "$THEME_DIR"
+    cp -n config/yogra.toml "$THEME_DIR/" 2>/dev/null || true
     echo -e "✓ Installed default color theme"
 fi
 
@@ -26,7 +26,7 @@ func Analyze(path string) (*AnalysisReport, error) {
 
 	// format support
 	if !formats.IsSupported(fileType.Extension) {
-		return nil, fmt.Errorf("unsupported file type: %s", fileType.Extension)
+		return nil, fmt.Errorf("%w: %s", formats.ErrUnsupportedFormat, fileType.Extension)
 	}
 
 	handler, err := formats.GetHandler(fileType.Format)
@@ -39,7 +39,7 @@ func Analyze(path string) (*AnalysisReport, error) {
 		return nil, fmt.Errorf("metadata extraction failed: %w", err)
 	}
 
-	sensitiveFields := identifySensitiveFields(metadata)
+	sensitiveFields, valueLeaks := identifySensitiveFields(metadata)
 
 	// generate report
 	report := &AnalysisReport{
@@ -47,27 +47,73 @@ func Analyze(path string) (*AnalysisReport, error) {
 		FileType:        fileType,
 		Metadata:        metadata,
 		SensitiveFields: sensitiveFields,
+		ValueLeaks:      valueLeaks,
+	}
+	if links, err := util.LinkCount(path); err == nil {
+		report.HardLinks = links
+	}
+	report.Sidecars = util.FindSidecars(path)
+
+	// payloads beyond tags (telemetry streams, attachments, ...)
+	if lister, ok := handler.(formats.EmbeddedLister); ok {
+		embedded, err := lister.ListEmbedded(path)
+		if err != nil {
+			return nil, fmt.Errorf("embedded content inspection failed: %w", err)
+		}
+		report.Embedded = embedded
+
+		// critical payloads (GPS tracks) make the file worth wiping on their own
+		for _, item := range embedded {
+			if item.Critical {
+				report.SensitiveFields = append(report.SensitiveFields, item.Name)
+			}
+		}
+	}
+
+	// byte accounting needs the structure; text files have none
+	if layout, err := formats.ReadLayout(path); err == nil {
+		report.Regions = layout.MetadataRegions()
 	}
 
 	return report, nil
 }
 
-// finds metadata fields that may contain sensitive information
-func identifySensitiveFields(metadata map[string]any) []string {
+// finds metadata fields that may contain sensitive information,
+// by name or by what their values look like (field -> leak kind)
+func identifySensitiveFields(metadata map[string]any) ([]string, map[string]string) {
 	var sensitive []string
+	leaks := make(map[string]string)
 	profileValues := getProfileValues()
 
-	fmt.Println("DEBUG: Profile values loaded:", profileValues)
-
 	for key, value := range metadata {
-		if strings.HasPrefix(key, "_") {
+		if strings.HasPrefix(key, "_") || isFilesystemField(key) {
 			continue
 		}
 
 		strValue := fmt.Sprintf("%v", value)
 
 		if isProfileMetadata(key, strValue, profileValues) {
-			fmt.Printf("DEBUG: Skipping profile field: %s = %s\n", key, strValue)
+			continue
+		}
+
+		// signing keys and signers name the author even with the rest stripped
+		if util.IsPGPIdentifier(key, strValue) {
+			leaks[key] = util.LeakPGPKey
+			sensitive = append(sensitive, key)
+			continue
+		}
+
+		// serials tie the file to one camera, lens or drone
+		if util.IsSerialNumber(key, strValue) {
+			leaks[key] = util.LeakSerialNumber
+			sensitive = append(sensitive, key)
+			continue
+		}
+
+		// values can name the user or machine under any tag
+		if kind := util.DetectValueLeak(strValue); kind != "" {
+			leaks[key] = kind
+			sensitive = append(sensitive, key)
 			continue
 		}
 
@@ -76,7 +122,16 @@ func identifySensitiveFields(metadata map[string]any) []string {
 		}
 	}
 
-	return sensitive
+	return sensitive, leaks
+}
+
+// exiftool's own view of the file on disk (path, permissions), not embedded metadata
+func isFilesystemField(key string) bool {
+	switch key {
+	case "SourceFile", "Directory", "FilePermissions":
+		return true
+	}
+	return false
 }
 
 func getProfileValues() map[string]string {
@@ -90,20 +145,31 @@ func getProfileValues() map[string]string {
 
 // does metadata match profile values?
 func isProfileMetadata(key string, value string, profileValues map[string]string) bool {
-	lowerKey := strings.ToLower(key)
+	lowerKey := strings.ToLower(util.MemberField(key))
 
 	profileMappings := map[string]string{
-		"artist":       "author",
-		"author":       "author",
-		"creator":      "author",
-		"software":     "software",
-		"createdate":   "created",
-		"datecreated":  "created",
-		"copyright":    "organization",
-		"organization": "organization",
-		"location":     "location",
-		"usercomment":  "comment",
-		"comment":      "comment",
+		"artist":         "author",
+		"author":         "author",
+		"creator":        "author",
+		"lastmodifiedby": "author",
+		"initialcreator": "author",
+		"username":       "author", // TAR entry owners
+		"groupname":      "author",
+		"software":       "software",
+		"producer":       "software",
+		"user-agent":     "software", // email clients
+		"createdate":     "created",
+		"datecreated":    "created",
+		"modifydate":     "created", // TAR entry dates
+		"copyright":      "organization",
+		"organization":   "organization",
+		"company":        "organization",
+		"location":       "location",
+		"usercomment":    "comment",
+		"comment":        "comment",
+		"comments":       "comment",
+		"subject":        "comment",
+		"description":    "comment",
 	}
 
 	profileKey, exists := profileMappings[lowerKey]
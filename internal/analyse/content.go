@@ -0,0 +1,53 @@
+// BYZRA ⸻ internal/analyse/content.go
+// content-level scanning of text bodies
+
+package analyse
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"caligra/internal/i18n"
+	"caligra/internal/util"
+)
+
+// scans the body of a text file for personal data and adds it to the report
+func ScanContent(report *AnalysisReport) error {
+	if report.FileType.Format != "text" {
+		return fmt.Errorf("content scanning only covers text files, not %s", report.FileType.Format)
+	}
+
+	data, err := os.ReadFile(report.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read content: %w", err)
+	}
+
+	report.ContentFindings = util.ScanPII(string(data))
+	report.ContentScanned = true
+	return nil
+}
+
+// content findings section of a report
+func formatContentFindings(report *AnalysisReport) string {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(util.LBL.Render(i18n.T("Content Findings:")))
+	sb.WriteString("\n\n")
+
+	if len(report.ContentFindings) == 0 {
+		sb.WriteString(util.SEC.Render(" ✓ "+i18n.T("No personal data found in the body")) + "\n")
+		return sb.String()
+	}
+
+	for _, finding := range report.ContentFindings {
+		sb.WriteString(fmt.Sprintf(" %s %s %s %s\n",
+			util.LBL.Render("!"),
+			util.NSH.Render(i18n.T("line %d:", finding.Line)),
+			util.NSH.Render(finding.Value),
+			util.BRH.Render("("+finding.Kind+")")))
+	}
+
+	return sb.String()
+}
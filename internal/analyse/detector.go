@@ -5,19 +5,32 @@ package analyse
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"caligra/internal/formats"
 )
 
 type FileType struct {
-	Format    string // "image", "audio", "video", "text"
+	Format    string // "image", "audio", "video", "text", "matroska", "document", "pdf", "archive", "executable", "email", "gps", "database", "calendar"
 	Extension string // "jpg", "mp3", etc
 	MimeType  string // "image/jpeg", etc
 }
 
+// when false, files whose content is not recognized are unknown instead of
+// being routed to a handler by their extension (--no-ext-fallback)
+var extensionFallback = true
+
+// turns DetectFile's extension fallback on or off
+func SetExtensionFallback(on bool) {
+	extensionFallback = on
+}
+
 func DetectFile(path string) (FileType, error) {
 	ext := strings.ToLower(filepath.Ext(path))
 	if ext != "" && ext[0] == '.' {
@@ -30,13 +43,31 @@ func DetectFile(path string) (FileType, error) {
 		return ft, nil
 	}
 
+	// a renamed file must not reach a handler that trusts its name
+	if !extensionFallback {
+		if err != nil {
+			return FileType{}, err
+		}
+		return FileType{}, fmt.Errorf("%w: content of %s not recognized (extension fallback disabled)", formats.ErrUnsupportedFormat, path)
+	}
+
 	// fallback to extension
 	ft = detectByExtension(ext)
 	if ft.Format != "" {
 		return ft, nil
 	}
 
-	return FileType{}, fmt.Errorf("unknown file type for %s", path)
+	return FileType{}, fmt.Errorf("%w: unknown file type for %s", formats.ErrUnsupportedFormat, path)
+}
+
+// MIME type and format of a file, empty when it is not recognized
+// (a filter.Detector)
+func DetectType(path string) (mimeType, format string) {
+	ft, err := DetectFile(path)
+	if err != nil {
+		return "", ""
+	}
+	return ft.MimeType, ft.Format
 }
 
 // examines file headers to determine type
@@ -73,9 +104,30 @@ func detectByMagicNumbers(path string) (FileType, error) {
 	// TIFF: 49 49 2A 00 or 4D 4D 00 2A (II* or MM*)
 	if bytes.HasPrefix(buffer, []byte{0x49, 0x49, 0x2A, 0x00}) ||
 		bytes.HasPrefix(buffer, []byte{0x4D, 0x4D, 0x00, 0x2A}) {
+		// camera RAW is TIFF inside
+		switch formats.RawImageType(path) {
+		case "cr2":
+			return FileType{Format: "image", Extension: "cr2", MimeType: "image/x-canon-cr2"}, nil
+		case "nef":
+			return FileType{Format: "image", Extension: "nef", MimeType: "image/x-nikon-nef"}, nil
+		case "arw":
+			return FileType{Format: "image", Extension: "arw", MimeType: "image/x-sony-arw"}, nil
+		case "dng":
+			return FileType{Format: "image", Extension: "dng", MimeType: "image/x-adobe-dng"}, nil
+		}
 		return FileType{Format: "image", Extension: "tiff", MimeType: "image/tiff"}, nil
 	}
 
+	// WebP: 52 49 46 46 ... 57 45 42 50 (RIFF...WEBP)
+	if bytes.HasPrefix(buffer, []byte("RIFF")) && bytes.Equal(buffer[8:12], []byte("WEBP")) {
+		return FileType{Format: "image", Extension: "webp", MimeType: "image/webp"}, nil
+	}
+
+	// Photoshop: 38 42 50 53 (8BPS), PSD or its large-document variant PSB
+	if bytes.HasPrefix(buffer, []byte("8BPS")) {
+		return FileType{Format: "image", Extension: "psd", MimeType: "image/vnd.adobe.photoshop"}, nil
+	}
+
 	// SVG: Usually starts with XML declaration or <svg
 	// for this, we need to check more bytes, reopen and check for text patterns
 	if isSVG(path) {
@@ -101,12 +153,83 @@ func detectByMagicNumbers(path string) (FileType, error) {
 		return FileType{Format: "audio", Extension: "ogg", MimeType: "audio/ogg"}, nil
 	}
 
-	// MP4: varies but often starts with ftyp at position 4
+	// ISO base media (MP4, MOV, AVIF): ftyp at position 4
 	if bytes.Equal(buffer[4:8], []byte{0x66, 0x74, 0x79, 0x70}) {
-		return FileType{Format: "video", Extension: "mp4", MimeType: "video/mp4"}, nil
+		return detectISOBMFF(file), nil
+	}
+
+	// QuickTime from before ftyp: the movie or its data comes first
+	if bytes.Equal(buffer[4:8], []byte("moov")) || bytes.Equal(buffer[4:8], []byte("mdat")) ||
+		bytes.Equal(buffer[4:8], []byte("wide")) {
+		return FileType{Format: "video", Extension: "mov", MimeType: "video/quicktime"}, nil
+	}
+
+	// OLE2 compound file: D0 CF 11 E0 A1 B1 1A E1 (Word, Excel, PowerPoint 97-2003)
+	if bytes.HasPrefix(buffer, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}) {
+		switch formats.OLEDocumentType(path) {
+		case "doc":
+			return FileType{Format: "document", Extension: "doc", MimeType: "application/msword"}, nil
+		case "xls":
+			return FileType{Format: "document", Extension: "xls", MimeType: "application/vnd.ms-excel"}, nil
+		case "ppt":
+			return FileType{Format: "document", Extension: "ppt", MimeType: "application/vnd.ms-powerpoint"}, nil
+		}
+		return FileType{}, nil
+	}
+
+	// ZIP: 50 4B 03 04 (PK), an OpenDocument file or EPUB by its mimetype
+	// entry, an Office Open XML document when it has [Content_Types].xml,
+	// otherwise a plain archive
+	if bytes.HasPrefix(buffer, []byte{0x50, 0x4B, 0x03, 0x04}) {
+		if formats.EPUBDocumentType(path) != "" {
+			return FileType{Format: "document", Extension: "epub", MimeType: "application/epub+zip"}, nil
+		}
+		switch formats.ODFDocumentType(path) {
+		case "odt":
+			return FileType{Format: "document", Extension: "odt", MimeType: "application/vnd.oasis.opendocument.text"}, nil
+		case "ods":
+			return FileType{Format: "document", Extension: "ods", MimeType: "application/vnd.oasis.opendocument.spreadsheet"}, nil
+		case "odp":
+			return FileType{Format: "document", Extension: "odp", MimeType: "application/vnd.oasis.opendocument.presentation"}, nil
+		}
+		switch formats.OOXMLDocumentType(path) {
+		case "docx":
+			return FileType{Format: "document", Extension: "docx", MimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"}, nil
+		case "xlsx":
+			return FileType{Format: "document", Extension: "xlsx", MimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}, nil
+		case "pptx":
+			return FileType{Format: "document", Extension: "pptx", MimeType: "application/vnd.openxmlformats-officedocument.presentationml.presentation"}, nil
+		}
+		return FileType{Format: "archive", Extension: "zip", MimeType: "application/zip"}, nil
+	}
+
+	// RTF: {\rtf
+	if bytes.HasPrefix(buffer, []byte(`{\rtf`)) {
+		return FileType{Format: "document", Extension: "rtf", MimeType: "application/rtf"}, nil
+	}
+
+	// SQLite: "SQLite format 3" and a NUL, longer than the bytes read here
+	if formats.SQLiteType(path) != "" {
+		return FileType{Format: "database", Extension: "sqlite", MimeType: "application/vnd.sqlite3"}, nil
 	}
 
-	// AVI: 52 49 46 46 ...  41 56 49 (RIFF...AVI)
+	// PDF: %PDF-
+	if bytes.HasPrefix(buffer, []byte("%PDF-")) {
+		return FileType{Format: "pdf", Extension: "pdf", MimeType: "application/pdf"}, nil
+	}
+
+	// Matroska/WebM: 1A 45 DF A3 (EBML header)
+	if bytes.HasPrefix(buffer, []byte{0x1A, 0x45, 0xDF, 0xA3}) {
+		return detectMatroska(file), nil
+	}
+
+	// AIFF: 46 4F 52 4D ... 41 49 46 46 (FORM...AIFF, or AIFC when compressed)
+	if bytes.HasPrefix(buffer, []byte("FORM")) &&
+		(bytes.Equal(buffer[8:12], []byte("AIFF")) || bytes.Equal(buffer[8:12], []byte("AIFC"))) {
+		return FileType{Format: "audio", Extension: "aiff", MimeType: "audio/aiff"}, nil
+	}
+
+	// AVI and WAV: 52 49 46 46 ... (RIFF...AVI or RIFF...WAVE)
 	if bytes.HasPrefix(buffer, []byte{0x52, 0x49, 0x46, 0x46}) {
 		// check for AVI marker
 		file.Seek(8, 0)
@@ -115,6 +238,47 @@ func detectByMagicNumbers(path string) (FileType, error) {
 		if bytes.Equal(aviMarker, []byte{0x41, 0x56, 0x49, 0x20}) {
 			return FileType{Format: "video", Extension: "avi", MimeType: "video/x-msvideo"}, nil
 		}
+		if bytes.Equal(aviMarker, []byte("WAVE")) {
+			return FileType{Format: "audio", Extension: "wav", MimeType: "audio/wav"}, nil
+		}
+	}
+
+	// ELF: 7F 45 4C 46; PE: 4D 5A (MZ) with "PE\0\0" where the DOS header
+	// points
+	switch formats.ExecutableType(path) {
+	case "elf":
+		return FileType{Format: "executable", Extension: "elf", MimeType: "application/x-elf"}, nil
+	case "exe":
+		return FileType{Format: "executable", Extension: "exe", MimeType: "application/vnd.microsoft.portable-executable"}, nil
+	case "dll":
+		return FileType{Format: "executable", Extension: "dll", MimeType: "application/vnd.microsoft.portable-executable"}, nil
+	}
+
+	// TAR: no signature, but a header block with a valid checksum ("ustar"
+	// at 257 in POSIX archives), also when gzip-compressed (1F 8B)
+	switch formats.TarArchiveType(path) {
+	case "tar":
+		return FileType{Format: "archive", Extension: "tar", MimeType: "application/x-tar"}, nil
+	case "tgz":
+		return FileType{Format: "archive", Extension: "tgz", MimeType: "application/gzip"}, nil
+	}
+
+	// email: RFC 5322 header fields up to the first blank line, with From
+	if formats.EmailType(path) != "" {
+		return FileType{Format: "email", Extension: "eml", MimeType: "message/rfc822"}, nil
+	}
+
+	// GPS tracks: XML with a gpx or kml root element
+	switch formats.GPSTrackType(path) {
+	case "gpx":
+		return FileType{Format: "gps", Extension: "gpx", MimeType: "application/gpx+xml"}, nil
+	case "kml":
+		return FileType{Format: "gps", Extension: "kml", MimeType: "application/vnd.google-earth.kml+xml"}, nil
+	}
+
+	// iCalendar: BEGIN:VCALENDAR on the first line
+	if formats.CalendarType(path) != "" {
+		return FileType{Format: "calendar", Extension: "ics", MimeType: "text/calendar"}, nil
 	}
 
 	// Plaintext detection requires different approach
@@ -186,13 +350,23 @@ func isTextFile(path string) bool {
 	return nullCount < threshold && controlCount < threshold
 }
 
-// checks if text file is HTML, Markdown or plain
+// checks if text file is a subtitle, HTML, Markdown or plain
 func determineTextType(path string) (FileType, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return FileType{}, err
 	}
 
+	// subtitles, by extension or by their first lines
+	switch formats.SubtitleType(path, content) {
+	case "srt":
+		return FileType{Format: "text", Extension: "srt", MimeType: "application/x-subrip"}, nil
+	case "ass":
+		return FileType{Format: "text", Extension: "ass", MimeType: "text/x-ssa"}, nil
+	case "vtt":
+		return FileType{Format: "text", Extension: "vtt", MimeType: "text/vtt"}, nil
+	}
+
 	// convert to string and lowercase for easier pattern matching
 	text := strings.ToLower(string(content))
 
@@ -226,6 +400,48 @@ func determineTextType(path string) (FileType, error) {
 	return FileType{Format: "text", Extension: "txt", MimeType: "text/plain"}, nil
 }
 
+// tells AVIF, QuickTime and M4A from MP4 by the brands of the ftyp box:
+// the major brand, or for AVIF a compatible one behind the generic HEIF
+// brands
+func detectISOBMFF(file *os.File) FileType {
+	header := make([]byte, 64)
+	file.Seek(0, 0)
+	n, _ := io.ReadFull(file, header)
+
+	size := min(int(binary.BigEndian.Uint32(header[:4])), n)
+	if size >= 12 {
+		major := string(header[8:12])
+		brands := []string{major}
+		for off := 16; off+4 <= size; off += 4 {
+			brands = append(brands, string(header[off:off+4]))
+		}
+		if major == "avif" || major == "avis" ||
+			((major == "mif1" || major == "msf1") && (slices.Contains(brands, "avif") || slices.Contains(brands, "avis"))) {
+			return FileType{Format: "image", Extension: "avif", MimeType: "image/avif"}
+		}
+		if major == "qt  " {
+			return FileType{Format: "video", Extension: "mov", MimeType: "video/quicktime"}
+		}
+		switch major {
+		case "M4A ", "M4B ", "M4P ":
+			return FileType{Format: "audio", Extension: "m4a", MimeType: "audio/mp4"}
+		}
+	}
+	return FileType{Format: "video", Extension: "mp4", MimeType: "video/mp4"}
+}
+
+// tells WebM from Matroska by the DocType in the EBML header
+func detectMatroska(file *os.File) FileType {
+	header := make([]byte, 64)
+	file.Seek(0, 0)
+	n, _ := file.Read(header)
+
+	if bytes.Contains(header[:n], []byte("webm")) {
+		return FileType{Format: "matroska", Extension: "webm", MimeType: "video/webm"}
+	}
+	return FileType{Format: "matroska", Extension: "mkv", MimeType: "video/x-matroska"}
+}
+
 // maps file extensions to types (fallback method)
 func detectByExtension(ext string) FileType {
 	// image
@@ -238,12 +454,32 @@ func detectByExtension(ext string) FileType {
 		return FileType{Format: "image", Extension: ext, MimeType: "image/gif"}
 	case "tiff":
 		return FileType{Format: "image", Extension: ext, MimeType: "image/tiff"}
+	case "webp":
+		return FileType{Format: "image", Extension: ext, MimeType: "image/webp"}
+	case "avif":
+		return FileType{Format: "image", Extension: ext, MimeType: "image/avif"}
+	case "cr2":
+		return FileType{Format: "image", Extension: ext, MimeType: "image/x-canon-cr2"}
+	case "nef":
+		return FileType{Format: "image", Extension: ext, MimeType: "image/x-nikon-nef"}
+	case "arw":
+		return FileType{Format: "image", Extension: ext, MimeType: "image/x-sony-arw"}
+	case "dng":
+		return FileType{Format: "image", Extension: ext, MimeType: "image/x-adobe-dng"}
+	case "psd":
+		return FileType{Format: "image", Extension: ext, MimeType: "image/vnd.adobe.photoshop"}
 	case "svg":
 		return FileType{Format: "image", Extension: ext, MimeType: "image/svg+xml"}
 
 	// audio
 	case "mp3":
 		return FileType{Format: "audio", Extension: ext, MimeType: "audio/mpeg"}
+	case "m4a":
+		return FileType{Format: "audio", Extension: ext, MimeType: "audio/mp4"}
+	case "wav":
+		return FileType{Format: "audio", Extension: ext, MimeType: "audio/wav"}
+	case "aiff", "aif":
+		return FileType{Format: "audio", Extension: ext, MimeType: "audio/aiff"}
 	case "flac":
 		return FileType{Format: "audio", Extension: ext, MimeType: "audio/flac"}
 	case "opus":
@@ -254,8 +490,16 @@ func detectByExtension(ext string) FileType {
 	// video
 	case "mp4":
 		return FileType{Format: "video", Extension: ext, MimeType: "video/mp4"}
+	case "mov":
+		return FileType{Format: "video", Extension: ext, MimeType: "video/quicktime"}
 	case "avi":
 		return FileType{Format: "video", Extension: ext, MimeType: "video/x-msvideo"}
+	case "mkv":
+		return FileType{Format: "matroska", Extension: ext, MimeType: "video/x-matroska"}
+	case "mka":
+		return FileType{Format: "matroska", Extension: ext, MimeType: "audio/x-matroska"}
+	case "webm":
+		return FileType{Format: "matroska", Extension: ext, MimeType: "video/webm"}
 
 	// text
 	case "txt":
@@ -264,6 +508,58 @@ func detectByExtension(ext string) FileType {
 		return FileType{Format: "text", Extension: ext, MimeType: "text/markdown"}
 	case "html", "htm":
 		return FileType{Format: "text", Extension: ext, MimeType: "text/html"}
+	case "srt":
+		return FileType{Format: "text", Extension: ext, MimeType: "application/x-subrip"}
+
+	// document
+	case "doc":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/msword"}
+	case "xls":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/vnd.ms-excel"}
+	case "ppt":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/vnd.ms-powerpoint"}
+	case "rtf":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/rtf"}
+	case "docx":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"}
+	case "xlsx":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
+	case "pptx":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/vnd.openxmlformats-officedocument.presentationml.presentation"}
+	case "odt":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/vnd.oasis.opendocument.text"}
+	case "ods":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/vnd.oasis.opendocument.spreadsheet"}
+	case "odp":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/vnd.oasis.opendocument.presentation"}
+	case "epub":
+		return FileType{Format: "document", Extension: ext, MimeType: "application/epub+zip"}
+	case "pdf":
+		return FileType{Format: "pdf", Extension: ext, MimeType: "application/pdf"}
+	case "zip":
+		return FileType{Format: "archive", Extension: ext, MimeType: "application/zip"}
+	case "tar":
+		return FileType{Format: "archive", Extension: ext, MimeType: "application/x-tar"}
+	case "tgz":
+		return FileType{Format: "archive", Extension: ext, MimeType: "application/gzip"}
+	case "exe", "dll":
+		return FileType{Format: "executable", Extension: ext, MimeType: "application/vnd.microsoft.portable-executable"}
+	case "so", "elf":
+		return FileType{Format: "executable", Extension: ext, MimeType: "application/x-elf"}
+	case "eml":
+		return FileType{Format: "email", Extension: ext, MimeType: "message/rfc822"}
+	case "gpx":
+		return FileType{Format: "gps", Extension: ext, MimeType: "application/gpx+xml"}
+	case "kml":
+		return FileType{Format: "gps", Extension: ext, MimeType: "application/vnd.google-earth.kml+xml"}
+	case "sqlite", "sqlite3", "db3":
+		return FileType{Format: "database", Extension: ext, MimeType: "application/vnd.sqlite3"}
+	case "ics":
+		return FileType{Format: "calendar", Extension: ext, MimeType: "text/calendar"}
+	case "ass", "ssa":
+		return FileType{Format: "text", Extension: ext, MimeType: "text/x-ssa"}
+	case "vtt":
+		return FileType{Format: "text", Extension: ext, MimeType: "text/vtt"}
 	}
 
 	return FileType{} // unknown
@@ -0,0 +1,74 @@
+// BYZRA ⸻ internal/analyse/raw.go
+// raw dump of a file's segments, chunks and atoms
+
+package analyse
+
+import (
+	"fmt"
+	"strings"
+
+	"caligra/internal/formats"
+	"caligra/internal/i18n"
+	"caligra/internal/util"
+)
+
+// bytes per hex preview line
+const rawLineWidth = 16
+
+// every segment with its offset, length and a hex/ASCII preview
+func GenerateRawDump(path string, layout *formats.Layout) string {
+	var sb strings.Builder
+
+	sb.WriteString(util.NSH.Render(i18n.T("File: ")) + util.NSH.Render(path) + "\n")
+	sb.WriteString(util.NSH.Render(i18n.T("Container: ")) +
+		util.NSH.Render(i18n.T("%s, %d bytes", layout.Container, layout.Size)) + "\n\n")
+
+	sb.WriteString(util.LBL.Render(fmt.Sprintf(" %-10s  %10s  %s", i18n.T("offset"), i18n.T("length"), i18n.T("segment"))))
+	sb.WriteString("\n")
+
+	for _, segment := range layout.Segments {
+		indent := strings.Repeat("  ", segment.Depth)
+		sb.WriteString(fmt.Sprintf(" %s  %s  %s\n",
+			util.NSH.Render(fmt.Sprintf("0x%08X", segment.Offset)),
+			util.NSH.Render(fmt.Sprintf("%10d", segment.Length)),
+			util.LBL.Render(indent+segment.Name)))
+
+		for _, line := range hexLines(segment.Preview) {
+			sb.WriteString(strings.Repeat(" ", 25) + indent + util.SUB.Render(line) + "\n")
+		}
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(util.NSH.Render("[i] " + i18n.T("%d segments; previews show the first %d payload bytes", len(layout.Segments), 2*rawLineWidth)))
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// hexdump -C style: "45 78 69 66 00 00 4d 4d  00 2a 00 00 00 08 00 0c  |Exif..MM.*......|"
+func hexLines(data []byte) []string {
+	var lines []string
+	for start := 0; start < len(data); start += rawLineWidth {
+		chunk := data[start:min(start+rawLineWidth, len(data))]
+
+		var hex, text strings.Builder
+		for i := range rawLineWidth {
+			if i == rawLineWidth/2 {
+				hex.WriteByte(' ')
+			}
+			if i < len(chunk) {
+				fmt.Fprintf(&hex, "%02x ", chunk[i])
+			} else {
+				hex.WriteString("   ")
+			}
+		}
+		for _, b := range chunk {
+			if b >= 0x20 && b <= 0x7E {
+				text.WriteByte(b)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		lines = append(lines, hex.String()+" |"+text.String()+"|")
+	}
+	return lines
+}
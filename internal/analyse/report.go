@@ -8,6 +8,8 @@ import (
 	"sort"
 	"strings"
 
+	"caligra/internal/formats"
+	"caligra/internal/i18n"
 	"caligra/internal/util"
 )
 
@@ -17,22 +19,91 @@ type AnalysisReport struct {
 	FileType        FileType
 	Metadata        map[string]any
 	SensitiveFields []string
+	ValueLeaks      map[string]string // field -> identifier kind found in its value
+
+	// streams/attachments found inside the file
+	Embedded []formats.Embedded
+
+	// where metadata sits in the file, outermost segments only; empty when
+	// the structure cannot be read (text files)
+	Regions []formats.Segment
+
+	// body scan results (text formats, on request)
+	ContentScanned  bool
+	ContentFindings []util.PIIMatch
+
+	// names the file has on disk; above 1, wiping it in place changes or
+	// leaves behind the others
+	HardLinks int
+
+	// XMP sidecars next to the file, which keep its metadata after a wipe
+	// unless handled too (wipe --sidecars)
+	Sidecars []string
+}
+
+// displayable fields as text, internal and filesystem fields left out
+func (r *AnalysisReport) Fields() map[string]string {
+	fields := make(map[string]string)
+	for key, value := range r.Metadata {
+		if strings.HasPrefix(key, "_") || strings.HasPrefix(key, "File") {
+			continue
+		}
+		if text := formatValue(value); text != "" {
+			fields[key] = text
+		}
+	}
+	return fields
+}
+
+// bytes the metadata regions take up
+func (r *AnalysisReport) MetadataBytes() int64 {
+	return formats.RegionBytes(r.Regions)
+}
+
+// fields whose values identify a single device (sorted)
+func (r *AnalysisReport) CriticalFields() []string {
+	var critical []string
+	for field, kind := range r.ValueLeaks {
+		if util.LeakSeverity(kind) == util.SeverityCritical {
+			critical = append(critical, field)
+		}
+	}
+	for _, item := range r.Embedded {
+		if item.Critical {
+			critical = append(critical, item.Name)
+		}
+	}
+	sort.Strings(critical)
+	return critical
 }
 
 func GenerateReport(report *AnalysisReport) string {
 	var sb strings.Builder
 
 	// info header
-	sb.WriteString(util.NSH.Render("File: ") + util.NSH.Render(report.Path) + "\n")
-	sb.WriteString(util.NSH.Render("Type: ") + util.NSH.Render(fmt.Sprintf("%s (%s)", report.FileType.Format, report.FileType.MimeType)) + "\n\n")
+	sb.WriteString(util.NSH.Render(i18n.T("File: ")) + util.NSH.Render(report.Path) + "\n")
+	sb.WriteString(util.NSH.Render(i18n.T("Type: ")) + util.NSH.Render(fmt.Sprintf("%s (%s)", report.FileType.Format, report.FileType.MimeType)) + "\n")
+	if report.HardLinks > 1 {
+		sb.WriteString(util.BRH.Render("[!] "+i18n.T("%d other hard links share this file's content; an in-place wipe or secure delete reaches them too", report.HardLinks-1)) + "\n")
+	}
+	for _, sidecar := range report.Sidecars {
+		sb.WriteString(util.BRH.Render("[!] "+i18n.T("XMP sidecar %s holds metadata for this file; 'caligra wipe --sidecars wipe|delete' handles it too", sidecar)) + "\n")
+	}
+	if report.Metadata["_source"] == "ffprobe" {
+		sb.WriteString(util.SUB.Render("[i] "+i18n.T("exiftool not found; container and stream tags read with ffprobe")) + "\n")
+	}
+	sb.WriteString("\n")
 
 	// no metadata
-	if len(report.Metadata) == 0 {
-		sb.WriteString(util.LBL.Render("✓ No metadata detected\n"))
+	if len(report.Metadata) == 0 && len(report.Embedded) == 0 {
+		sb.WriteString(util.LBL.Render("✓ " + i18n.T("No metadata detected") + "\n"))
+		if report.ContentScanned {
+			sb.WriteString(formatContentFindings(report))
+		}
 		return sb.String()
 	}
 
-	sb.WriteString(util.LBL.Render("Detected Metadata:"))
+	sb.WriteString(util.LBL.Render(i18n.T("Detected Metadata:")))
 	sb.WriteString("\n\n")
 
 	// sorted keys for consistent output
@@ -60,7 +131,18 @@ func GenerateReport(report *AnalysisReport) string {
 
 		// is field sensitive
 		isSensitive := isSensitiveField(key, report.SensitiveFields)
-		if isSensitive {
+		if kind, ok := report.ValueLeaks[key]; ok {
+			sensitiveCount++
+			marker, label := "!", "("+kind+")"
+			if util.LeakSeverity(kind) == util.SeverityCritical {
+				marker, label = "‼", "("+i18n.T("%s, critical", kind)+")"
+			}
+			sb.WriteString(fmt.Sprintf(" %s %s: %s %s\n",
+				util.LBL.Render(marker),
+				util.NSH.Render(key),
+				util.NSH.Render(valueStr),
+				util.BRH.Render(label)))
+		} else if isSensitive {
 			sensitiveCount++
 			sb.WriteString(fmt.Sprintf(" %s %s: %s\n",
 				util.LBL.Render("!"),
@@ -74,25 +156,60 @@ func GenerateReport(report *AnalysisReport) string {
 		}
 	}
 
+	if len(report.Embedded) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(util.LBL.Render(i18n.T("Embedded Content:")))
+		sb.WriteString("\n\n")
+
+		for _, item := range report.Embedded {
+			if item.Critical {
+				sensitiveCount++
+				sb.WriteString(fmt.Sprintf(" %s %s: %s %s\n",
+					util.LBL.Render("‼"),
+					util.NSH.Render(item.Name),
+					util.NSH.Render(item.Detail),
+					util.BRH.Render("("+i18n.T("%s, critical", item.Kind)+")")))
+			} else {
+				sb.WriteString(fmt.Sprintf(" %s %s: %s\n",
+					util.LBL.Render("•"),
+					util.NSH.Render(item.Name),
+					util.NSH.Render(item.Detail)))
+			}
+		}
+	}
+
 	// summary and recommendation
 	sb.WriteString("\n")
+	if len(report.Regions) > 0 {
+		message := "[i] " + i18n.T("%s of metadata across %d segments", util.FormatBytes(report.MetadataBytes()), len(report.Regions))
+		sb.WriteString(util.NSH.Render(message) + "\n")
+	}
 	if sensitiveCount > 0 {
-		warning := fmt.Sprintf("[!] Found %d potentially sensitive metadata fields.", sensitiveCount)
+		warning := "[!] " + i18n.T("Found %d potentially sensitive metadata fields.", sensitiveCount)
 		sb.WriteString(util.BRH.Render(warning) + "\n")
 
+		if critical := report.CriticalFields(); len(critical) > 0 {
+			message := "[‼] " + i18n.T("%d critical: %s", len(critical), strings.Join(critical, ", "))
+			sb.WriteString(util.BRH.Render(message) + "\n")
+		}
+
 		// already processed file?
 		if strings.Contains(report.Path, ".volena.") {
-			info := "[i] This file has already been processed by CALIGRA. Consider checking profile configuration."
+			info := "[i] " + i18n.T("This file has already been processed by CALIGRA. Consider checking profile configuration.")
 			sb.WriteString(util.NSH.Render(info) + "\n")
 		} else {
-			recommendation := "[i] Consider using 'caligra wipe' to remove metadata."
+			recommendation := "[i] " + i18n.T("Consider using 'caligra wipe' to remove metadata.")
 			sb.WriteString(util.NSH.Render(recommendation) + "\n")
 		}
 	} else {
-		message := "✓ No sensitive metadata detected"
+		message := "✓ " + i18n.T("No sensitive metadata detected")
 		sb.WriteString(util.LBL.Render(message) + "\n")
 	}
 
+	if report.ContentScanned {
+		sb.WriteString(formatContentFindings(report))
+	}
+
 	return sb.String()
 }
 
@@ -116,7 +233,10 @@ func GenerateSimplifiedReport(report *AnalysisReport) string {
 		}
 
 		isSensitive := isSensitiveField(k, report.SensitiveFields)
-		if isSensitive {
+		if kind, ok := report.ValueLeaks[k]; ok {
+			sensitiveCount++
+			sb.WriteString(fmt.Sprintf("sensitive:%s: %s [%s]\n", k, valueStr, kind))
+		} else if isSensitive {
 			sensitiveCount++
 			sb.WriteString(fmt.Sprintf("sensitive:%s: %s\n", k, valueStr))
 		} else {
@@ -125,6 +245,14 @@ func GenerateSimplifiedReport(report *AnalysisReport) string {
 	}
 
 	sb.WriteString(fmt.Sprintf("sensitive_count: %d\n", sensitiveCount))
+	sb.WriteString(fmt.Sprintf("critical_count: %d\n", len(report.CriticalFields())))
+	sb.WriteString(fmt.Sprintf("metadata_bytes: %d\n", report.MetadataBytes()))
+	for _, sidecar := range report.Sidecars {
+		sb.WriteString(fmt.Sprintf("sidecar: %s\n", sidecar))
+	}
+	for _, region := range report.Regions {
+		sb.WriteString(fmt.Sprintf("region:%s: offset=%d length=%d\n", region.Name, region.Offset, region.Length))
+	}
 
 	return sb.String()
 }
@@ -0,0 +1,230 @@
+// BYZRA ⸻ internal/audit/audit.go
+// append-only, hash-chained journal of wipes
+
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// operations recorded in the journal
+const (
+	OperationInit = "init" // first entry, written by Init
+	OperationWipe = "wipe"
+)
+
+// wipe outcomes, as in batch manifests
+const (
+	StatusOK     = "ok"
+	StatusIssues = "issues"
+	StatusFailed = "failed"
+)
+
+// the head is anchored automatically every this many entries
+const anchorEvery = 50
+
+// prev of the first entry
+var genesis = strings.Repeat("0", sha256.Size*2)
+
+// one line of the journal; Hash covers every other field, Prev included,
+// so changing any entry breaks the chain from there on
+type Entry struct {
+	Seq       int64     `json:"seq"`
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Source    string    `json:"source,omitempty"` // "cli", "daemon"
+
+	Input        string `json:"input,omitempty"`
+	InputSHA256  string `json:"input_sha256,omitempty"` // before wiping
+	Output       string `json:"output,omitempty"`
+	OutputSHA256 string `json:"output_sha256,omitempty"`
+	Policy       string `json:"policy,omitempty"`
+	Status       string `json:"status,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Sensitive    int    `json:"sensitive_fields,omitempty"` // fields found before wiping
+
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// SHA-256 of the entry with Hash left empty
+func (e Entry) digest() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ~/.caligra/audit.jsonl
+func JournalPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra", "audit.jsonl")
+}
+
+// ~/.caligra/audit.anchors, one head per line
+func AnchorsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra", "audit.anchors")
+}
+
+// is there a journal to record into? Recording is opt-in: 'caligra history
+// init' creates it
+func Enabled() bool {
+	_, err := os.Stat(JournalPath())
+	return err == nil
+}
+
+// starts the journal with its init entry; false when it already exists
+func Init() (bool, error) {
+	if Enabled() {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(JournalPath()), 0700); err != nil {
+		return false, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	_, err := Append(Entry{Operation: OperationInit})
+	return err == nil, err
+}
+
+// chains entry to the journal's head and appends it; Seq, Time, Prev and
+// Hash are filled in
+func Append(entry Entry) (Entry, error) {
+	path := JournalPath()
+	unlock, err := lock(path)
+	if err != nil {
+		return entry, err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return entry, fmt.Errorf("failed to open audit journal: %w", err)
+	}
+	defer file.Close()
+
+	head, err := lastEntry(file)
+	if err != nil {
+		return entry, err
+	}
+	entry.Seq, entry.Prev = 0, genesis
+	if head != nil {
+		entry.Seq, entry.Prev = head.Seq+1, head.Hash
+	}
+	entry.Time = time.Now().UTC()
+	entry.Hash = entry.digest()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return entry, fmt.Errorf("failed to write audit journal: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return entry, fmt.Errorf("failed to write audit journal: %w", err)
+	}
+
+	if entry.Seq > 0 && entry.Seq%anchorEvery == 0 {
+		if err := writeAnchor(AnchorsPath(), entry); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+// appends to the journal when it is enabled; a no-op otherwise
+func Record(entry Entry) error {
+	if !Enabled() {
+		return nil
+	}
+	_, err := Append(entry)
+	return err
+}
+
+// every entry, in order; lines that do not parse are an error
+func ReadJournal(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit journal: %w", err)
+	}
+	var entries []Entry
+	for i, line := range bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// the last complete line of the journal, nil when it is empty
+func lastEntry(file *os.File) (*Entry, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil
+	}
+
+	// read backwards until the start of the last line is in the buffer
+	chunk := int64(4096)
+	for {
+		start := max(0, size-chunk)
+		buf := make([]byte, size-start)
+		if _, err := file.ReadAt(buf, start); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		buf = bytes.TrimRight(buf, "\n")
+		if i := bytes.LastIndexByte(buf, '\n'); i >= 0 || start == 0 {
+			var entry Entry
+			if err := json.Unmarshal(buf[i+1:], &entry); err != nil {
+				return nil, fmt.Errorf("audit journal ends in a damaged entry; run 'caligra history verify': %w", err)
+			}
+			return &entry, nil
+		}
+		chunk *= 2
+	}
+}
+
+// ╭─ LOCKING ───────────────────────────────────╮
+
+// a lock older than this was left by a crashed writer
+const staleLock = 30 * time.Second
+
+// serializes writers (the CLI and the daemon) with a lock file next to path
+func lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock audit journal: %w", err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("audit journal is locked by another process (%s)", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,189 @@
+// BYZRA ⸻ internal/audit/verify.go
+// anchors of the journal head, and checking the chain against them
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// the journal head at some point; kept in a separate file (or copied off
+// the machine), it shows entries up to Seq were not rewritten since
+type Anchor struct {
+	Seq  int64     `json:"seq"`
+	Hash string    `json:"hash"`
+	Time time.Time `json:"time"`
+}
+
+// appends the current head to path; 'caligra history anchor' and every
+// anchorEvery entries
+func AnchorHead(path string) (Anchor, error) {
+	unlock, err := lock(JournalPath())
+	if err != nil {
+		return Anchor{}, err
+	}
+	defer unlock()
+
+	file, err := os.Open(JournalPath())
+	if err != nil {
+		return Anchor{}, fmt.Errorf("failed to read audit journal: %w", err)
+	}
+	defer file.Close()
+
+	head, err := lastEntry(file)
+	if err != nil {
+		return Anchor{}, err
+	}
+	if head == nil {
+		return Anchor{}, fmt.Errorf("audit journal is empty")
+	}
+	if err := writeAnchor(path, *head); err != nil {
+		return Anchor{}, err
+	}
+	return Anchor{Seq: head.Seq, Hash: head.Hash, Time: time.Now().UTC()}, nil
+}
+
+func writeAnchor(path string, head Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create anchor directory: %w", err)
+	}
+	line, err := json.Marshal(Anchor{Seq: head.Seq, Hash: head.Hash, Time: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write anchor: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write anchor: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write anchor: %w", err)
+	}
+	return file.Close()
+}
+
+// every anchor in path; a missing file has none
+func ReadAnchors(path string) ([]Anchor, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anchors: %w", err)
+	}
+	var anchors []Anchor
+	for i, line := range bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var anchor Anchor
+		if err := json.Unmarshal(line, &anchor); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		anchors = append(anchors, anchor)
+	}
+	return anchors, nil
+}
+
+// ╭─ VERIFICATION ──────────────────────────────╮
+
+// a place where the journal does not add up
+type Problem struct {
+	Line    int // 1-based line of the journal, 0 for the journal as a whole
+	Message string
+}
+
+type Report struct {
+	Entries  int
+	Head     string // hash of the last entry
+	Anchors  int    // anchors checked
+	Problems []Problem
+}
+
+func (r *Report) Intact() bool {
+	return len(r.Problems) == 0
+}
+
+// most problems listed; past the first break the rest usually follow from it
+const maxProblems = 20
+
+// recomputes every hash and link, and checks the anchors in anchorsPath
+// against the entries they name
+func Verify(journalPath, anchorsPath string) (*Report, error) {
+	data, err := os.ReadFile(journalPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit journal: %w", err)
+	}
+	anchors, err := ReadAnchors(anchorsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	problem := func(line int, format string, args ...any) {
+		if len(report.Problems) < maxProblems {
+			report.Problems = append(report.Problems, Problem{Line: line, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	hashes := make(map[int64]string)
+	prev, seq := genesis, int64(0)
+	lines := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+	if len(data) == 0 {
+		lines = nil
+	}
+	for i, line := range lines {
+		n := i + 1
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			problem(n, "not a journal entry: %v", err)
+			prev, seq = "", seq+1
+			continue
+		}
+
+		// fields added or reformatted would not change the hash otherwise
+		if canonical, _ := json.Marshal(entry); !bytes.Equal(canonical, line) {
+			problem(n, "entry %d was edited: it differs from its canonical form", entry.Seq)
+		}
+		if entry.Seq != seq {
+			problem(n, "expected entry %d, found %d: entries were removed or inserted", seq, entry.Seq)
+		}
+		if entry.digest() != entry.Hash {
+			problem(n, "entry %d was modified: its hash does not match its contents", entry.Seq)
+		}
+		if prev != "" && entry.Prev != prev {
+			problem(n, "entry %d does not follow entry %d: the chain is broken", entry.Seq, entry.Seq-1)
+		}
+		if i == 0 && entry.Operation != OperationInit {
+			problem(n, "the journal does not start with its init entry")
+		}
+
+		hashes[entry.Seq] = entry.Hash
+		prev, seq = entry.Hash, entry.Seq+1
+		report.Entries++
+		report.Head = entry.Hash
+	}
+
+	for _, anchor := range anchors {
+		report.Anchors++
+		hash, ok := hashes[anchor.Seq]
+		switch {
+		case !ok:
+			problem(0, "anchor of %s names entry %d, but the journal ends before it: entries were removed",
+				anchor.Time.Format(time.RFC3339), anchor.Seq)
+		case hash != anchor.Hash:
+			problem(0, "entry %d no longer matches its anchor of %s: the journal was rewritten",
+				anchor.Seq, anchor.Time.Format(time.RFC3339))
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,207 @@
+// BYZRA ⸻ internal/batch/import.go
+// "clean as you copy" import from camera cards
+
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"caligra/internal/analyse"
+	"caligra/internal/formats"
+	"caligra/internal/util"
+	"caligra/internal/wipe"
+)
+
+// how imported files are named
+const (
+	RenameKeep     = "keep"     // original card filename
+	RenameSequence = "sequence" // img-0001.jpg, img-0002.jpg ...
+	RenameDate     = "date"     // 2024-05-17-0001.jpg (day only)
+)
+
+// configures an import
+type ImportOptions struct {
+	// destination directory for cleaned files
+	Destination string
+
+	// naming scheme (RenameKeep, RenameSequence or RenameDate)
+	Rename string
+
+	// inject profile metadata after wiping?
+	InjectProfile bool
+}
+
+// copies media from a card's DCIM folders into Destination, wiping each copy
+// the card itself is never modified; progress is called after every file
+func Import(source string, options ImportOptions, progress func(ManifestEntry)) (*Manifest, error) {
+	dcim, err := findDCIM(source)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := collectMedia(dcim)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(options.Destination, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create destination: %w", err)
+	}
+
+	manifest := NewManifest("import", source, options.Destination)
+
+	for i, src := range files {
+		entry := ManifestEntry{Input: src}
+
+		name, err := importName(src, i+1, options.Rename)
+		if err != nil {
+			entry.Status = StatusFailed
+			entry.Error = err.Error()
+		} else {
+			entry = importFile(src, filepath.Join(options.Destination, name), options)
+		}
+
+		manifest.Entries = append(manifest.Entries, entry)
+		if progress != nil {
+			progress(entry)
+		}
+	}
+
+	return manifest, nil
+}
+
+// copies and wipes a single file
+func importFile(src, dst string, options ImportOptions) ManifestEntry {
+	entry := ManifestEntry{Input: src, Output: dst}
+
+	if _, err := os.Stat(dst); err == nil {
+		entry.Status = StatusSkipped
+		entry.Error = "destination already exists"
+		return entry
+	}
+
+	if err := util.SafeCopy(src, dst); err != nil {
+		entry.Status = StatusFailed
+		entry.Error = err.Error()
+		entry.Hint = wipe.Hint(err)
+		return entry
+	}
+
+	result, err := wipe.WipeFile(dst, &wipe.WipeOptions{
+		InjectProfile: options.InjectProfile,
+		CreateCopy:    false,
+		KeepBackup:    false,
+	})
+	if err != nil {
+		// never leave an uncleaned copy behind
+		util.RemoveFile(dst)
+		entry.Status = StatusFailed
+		entry.Error = err.Error()
+		entry.Hint = wipe.Hint(err)
+		return entry
+	}
+
+	entry.Status = StatusOK
+	if !result.Success {
+		entry.Status = StatusIssues
+		entry.Error = result.ErrText()
+		entry.Hint = strings.Join(result.Hints(), "; ")
+	}
+
+	if hash, err := util.FileSHA256(dst); err == nil {
+		entry.SHA256 = hash
+	}
+
+	return entry
+}
+
+// the card's DCIM folder, or the source itself if it already is one
+func findDCIM(source string) (string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return "", fmt.Errorf("cannot read source: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("source is not a directory: %s", source)
+	}
+
+	if strings.EqualFold(filepath.Base(source), "DCIM") {
+		return source, nil
+	}
+
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return "", fmt.Errorf("cannot list source: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.EqualFold(entry.Name(), "DCIM") {
+			return filepath.Join(source, entry.Name()), nil
+		}
+	}
+
+	return "", fmt.Errorf("no DCIM folder found in %s", source)
+}
+
+// supported media under dir, in a stable order
+func collectMedia(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		// cameras keep thumbnails and databases in hidden folders
+		if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && formats.IsSupported(filepath.Ext(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// destination filename for the n-th imported file
+func importName(src string, n int, scheme string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(src))
+
+	switch scheme {
+	case "", RenameKeep:
+		return filepath.Base(src), nil
+	case RenameSequence:
+		return fmt.Sprintf("img-%04d%s", n, ext), nil
+	case RenameDate:
+		return fmt.Sprintf("%s-%04d%s", captureDay(src).Format("2006-01-02"), n, ext), nil
+	default:
+		return "", fmt.Errorf("unknown rename scheme: %s", scheme)
+	}
+}
+
+// capture day from metadata, falling back to the file time
+func captureDay(path string) time.Time {
+	if report, err := analyse.Analyze(path); err == nil {
+		for _, key := range []string{"DateTimeOriginal", "CreateDate"} {
+			if value, ok := report.Metadata[key].(string); ok && len(value) >= 10 {
+				if t, err := time.Parse("2006:01:02", value[:10]); err == nil {
+					return t
+				}
+			}
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Now()
+}
@@ -0,0 +1,142 @@
+// BYZRA ⸻ internal/batch/manifest.go
+// machine-readable record of multi-file operations
+
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"caligra/internal/config"
+	"caligra/internal/formats"
+)
+
+// outcome of a single file in a batch
+const (
+	StatusOK      = "ok"
+	StatusIssues  = "issues"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// one processed file
+type ManifestEntry struct {
+	Input       string `json:"input"`
+	InputSHA256 string `json:"input_sha256,omitempty"` // before wiping
+	Output      string `json:"output,omitempty"`
+	Backup      string `json:"backup,omitempty"`
+	Attestation string `json:"attestation,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	Hint        string `json:"hint,omitempty"`   // what to do about Error
+	SHA256      string `json:"sha256,omitempty"` // of the output
+	InputSize   int64  `json:"input_size,omitempty"`
+	OutputSize  int64  `json:"output_size,omitempty"`
+}
+
+// record of a whole batch
+type Manifest struct {
+	Operation   string          `json:"operation"`
+	Created     time.Time       `json:"created"`
+	Source      string          `json:"source"`
+	Destination string          `json:"destination,omitempty"`
+	Inputs      []string        `json:"inputs,omitempty"` // what a resumed run collects again
+	Recursive   bool            `json:"recursive,omitempty"`
+	Filter      string          `json:"filter,omitempty"`   // selection expression
+	Settings    *WipeSettings   `json:"settings,omitempty"` // what a resumed run wipes with
+	Entries     []ManifestEntry `json:"entries"`
+}
+
+// the wipe options of a journaled run, so a resume matches it whatever
+// flags it is given
+type WipeSettings struct {
+	InjectProfile bool                     `json:"inject_profile"`
+	Profile       string                   `json:"profile,omitempty"` // name or .lua path
+	CustomProfile map[string]string        `json:"custom_profile,omitempty"`
+	CreateCopy    bool                     `json:"create_copy"`
+	KeepBackup    bool                     `json:"keep_backup"`
+	SecureDelete  bool                     `json:"secure_delete,omitempty"`
+	Timezone      string                   `json:"timezone,omitempty"`
+	RedactPII     bool                     `json:"redact_pii,omitempty"`
+	Matroska      *formats.MatroskaOptions `json:"matroska,omitempty"`
+	Policy        *config.Policy           `json:"policy,omitempty"`
+	Sidecars      string                   `json:"sidecars,omitempty"`
+}
+
+// new empty manifest
+func NewManifest(operation, source, destination string) *Manifest {
+	return &Manifest{
+		Operation:   operation,
+		Created:     time.Now().UTC(),
+		Source:      source,
+		Destination: destination,
+		Entries:     []ManifestEntry{},
+	}
+}
+
+// counts entries per status
+func (m *Manifest) Summary() map[string]int {
+	summary := make(map[string]int)
+	for _, entry := range m.Entries {
+		summary[entry.Status]++
+	}
+	return summary
+}
+
+// total input and output sizes of the finished entries
+func (m *Manifest) Sizes() (before, after int64, files int) {
+	for _, entry := range m.Entries {
+		if entry.Done() && entry.OutputSize > 0 {
+			before += entry.InputSize
+			after += entry.OutputSize
+			files++
+		}
+	}
+	return before, after, files
+}
+
+// writes the manifest as indented JSON
+func (m *Manifest) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create manifest directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	// written aside and renamed, so an interrupted save never truncates it
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// has this entry finished (successfully or with issues)?
+func (e ManifestEntry) Done() bool {
+	return e.Status == StatusOK || e.Status == StatusIssues || e.Status == StatusSkipped
+}
+
+// reads a manifest written by Save
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,355 @@
+// BYZRA ⸻ internal/batch/wipe.go
+// multi-file and recursive wipes
+
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"caligra/internal/analyse"
+	"caligra/internal/config"
+	"caligra/internal/filter"
+	"caligra/internal/formats"
+	"caligra/internal/util"
+	"caligra/internal/wipe"
+)
+
+// wipes every input file, and the supported files in every input directory
+// (sub-directories too when recursive), keeping those the selection
+// expression matches; the manifest is saved to journal after every file so
+// an interrupted run can be resumed, and progress is called after every file
+func Wipe(inputs []string, recursive bool, selection string, options *wipe.WipeOptions, journal string, progress func(ManifestEntry)) (*Manifest, error) {
+	// absolute, so a resume from another directory finds the same files
+	absolute := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		abs, err := filepath.Abs(input)
+		if err != nil {
+			return nil, err
+		}
+		absolute = append(absolute, abs)
+	}
+
+	manifest := NewManifest("wipe", strings.Join(absolute, ", "), "")
+	manifest.Inputs = absolute
+	manifest.Recursive = recursive
+	manifest.Filter = selection
+	manifest.Settings = settingsOf(options)
+
+	return runWipe(manifest, options, journal, progress)
+}
+
+// continues a journaled wipe: finished files are skipped, failed ones
+// retried, and files not reached yet processed. The run's own settings
+// are used; options only stand in for manifests written without them
+func Resume(journal string, options *wipe.WipeOptions, progress func(ManifestEntry)) (*Manifest, error) {
+	manifest, err := LoadManifest(journal)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Operation != "wipe" || len(manifest.Inputs) == 0 {
+		return nil, fmt.Errorf("%s is not a resumable wipe manifest", journal)
+	}
+
+	if manifest.Settings != nil {
+		if options, err = manifest.Settings.options(options.Audit); err != nil {
+			return nil, fmt.Errorf("%s: %w", journal, err)
+		}
+	}
+
+	return runWipe(manifest, options, journal, progress)
+}
+
+// the settings a journal records for options
+func settingsOf(options *wipe.WipeOptions) *WipeSettings {
+	settings := &WipeSettings{
+		InjectProfile: options.InjectProfile,
+		Profile:       options.ProfileName,
+		CustomProfile: options.CustomProfile,
+		CreateCopy:    options.CreateCopy,
+		KeepBackup:    options.KeepBackup,
+		SecureDelete:  options.SecureDelete,
+		RedactPII:     options.RedactPII,
+		Matroska:      options.Matroska,
+		Policy:        options.Policy,
+		Sidecars:      options.Sidecars,
+	}
+	if options.Timezone != nil {
+		settings.Timezone = options.Timezone.String()
+	}
+	return settings
+}
+
+// wipe options from recorded settings; audit names who is resuming
+func (s *WipeSettings) options(audit string) (*wipe.WipeOptions, error) {
+	options := &wipe.WipeOptions{
+		InjectProfile: s.InjectProfile,
+		ProfileName:   s.Profile,
+		CustomProfile: s.CustomProfile,
+		CreateCopy:    s.CreateCopy,
+		KeepBackup:    s.KeepBackup,
+		SecureDelete:  s.SecureDelete,
+		RedactPII:     s.RedactPII,
+		Matroska:      s.Matroska,
+		Policy:        s.Policy,
+		Sidecars:      s.Sidecars,
+		Audit:         audit,
+	}
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone %q", s.Timezone)
+		}
+		options.Timezone = loc
+	}
+	if s.Policy != nil {
+		if err := s.Policy.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+// processes every collected file that has no finished entry yet
+func runWipe(manifest *Manifest, options *wipe.WipeOptions, journal string, progress func(ManifestEntry)) (*Manifest, error) {
+	locals := config.NewLocalConfigs()
+	files, err := CollectInputs(manifest.Inputs, manifest.Recursive, locals)
+	if err != nil {
+		return nil, err
+	}
+	selection, err := filter.Parse(manifest.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	outputs := make(map[string]bool)
+	for i, entry := range manifest.Entries {
+		index[entry.Input] = i
+		if entry.Done() && entry.Output != entry.Input {
+			outputs[entry.Output] = true
+		}
+	}
+
+	save := func() error {
+		if journal == "" {
+			return nil
+		}
+		return manifest.Save(journal)
+	}
+	if err := save(); err != nil {
+		return nil, err
+	}
+
+	for _, path := range files {
+		i, seen := index[path]
+		if seen && manifest.Entries[i].Done() {
+			continue
+		}
+		// outputs of earlier runs (e.g. randomly renamed) are not inputs
+		if outputs[path] || !selection.MatchPath(path, analyse.DetectType) {
+			continue
+		}
+
+		var entry ManifestEntry
+		if fileOptions, err := localOptions(locals, manifest.Inputs, path, options); err != nil {
+			entry = ManifestEntry{Input: path, Status: StatusFailed, Error: err.Error()}
+		} else {
+			entry = wipeFile(path, fileOptions)
+		}
+		if seen {
+			manifest.Entries[i] = entry
+		} else {
+			index[path] = len(manifest.Entries)
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+
+		if err := save(); err != nil {
+			return manifest, err
+		}
+		if progress != nil {
+			progress(entry)
+		}
+	}
+
+	return manifest, nil
+}
+
+// options with the policy and profile of the .caligra.toml files between
+// the input directory holding path and path itself; files given explicitly
+// are wiped with options as-is
+func localOptions(locals *config.LocalConfigs, inputs []string, path string, options *wipe.WipeOptions) (*wipe.WipeOptions, error) {
+	root := inputRoot(inputs, path)
+	if root == "" {
+		return options, nil
+	}
+
+	settings, err := locals.For(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Policy == nil && settings.Profile == "" {
+		return options, nil
+	}
+
+	local := *options
+	if settings.Policy != nil {
+		local.Policy = settings.Policy
+	}
+	if settings.Profile != "" {
+		local.ProfileName = settings.Profile
+	}
+	return &local, nil
+}
+
+// the input directory path was collected from, "" for explicit files
+func inputRoot(inputs []string, path string) string {
+	root := ""
+	for _, input := range inputs {
+		input = filepath.Clean(input)
+		if input == filepath.Clean(path) {
+			return ""
+		}
+		rel, err := filepath.Rel(input, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if len(input) > len(root) {
+			root = input
+		}
+	}
+	return root
+}
+
+// wipes one file, recording hashes before and after
+func wipeFile(path string, options *wipe.WipeOptions) ManifestEntry {
+	entry := ManifestEntry{Input: path}
+
+	// hashed first: in-place wipes overwrite it
+	if hash, err := util.FileSHA256(path); err == nil {
+		entry.InputSHA256 = hash
+	}
+
+	result, err := wipe.WipeFile(path, options)
+	entry.InputSize = result.SizeBefore
+	if err != nil {
+		entry.Status = StatusFailed
+		entry.Error = err.Error()
+		entry.Hint = wipe.Hint(err)
+		entry.Backup = result.BackupPath
+		return entry
+	}
+
+	entry.Output = result.OutputPath
+	if entry.Output == "" {
+		entry.Output = path
+	}
+	entry.Backup = result.BackupPath
+	entry.Attestation = result.Attestation
+	entry.OutputSize = result.SizeAfter
+
+	entry.Status = StatusOK
+	if !result.Success {
+		entry.Status = StatusIssues
+		entry.Error = result.ErrText()
+		entry.Hint = strings.Join(result.Hints(), "; ")
+	}
+
+	if hash, err := util.FileSHA256(entry.Output); err == nil {
+		entry.SHA256 = hash
+	}
+
+	return entry
+}
+
+// expands directories into their supported files, leaving out earlier
+// .volena outputs; explicit files are kept as given
+func CollectInputs(inputs []string, recursive bool, locals *config.LocalConfigs) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", input, err)
+		}
+		if !info.IsDir() {
+			add(input)
+			continue
+		}
+
+		found, err := collectFiles(input, recursive, locals)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range found {
+			add(path)
+		}
+	}
+
+	return files, nil
+}
+
+// supported files under dir that are not wipe outputs or backups, nor
+// ignored by a .caligra.toml
+func collectFiles(dir string, recursive bool, locals *config.LocalConfigs) ([]string, error) {
+	var files []string
+
+	ignored := func(path string, isDir bool) (bool, error) {
+		settings, err := locals.For(dir, path)
+		if err != nil {
+			return false, err
+		}
+		return settings.Ignored(path, isDir), nil
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if !recursive || strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			skip, err := ignored(path, true)
+			if err != nil {
+				return err
+			}
+			if skip {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !formats.IsSupported(filepath.Ext(path)) {
+			return nil
+		}
+		if strings.Contains(d.Name(), ".volena.") {
+			return nil
+		}
+		if skip, err := ignored(path, false); err != nil || skip {
+			return err
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
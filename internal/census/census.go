@@ -0,0 +1,326 @@
+// BYZRA ⸻ internal/census/census.go
+// metadata exposure census: aggregate statistics over a directory tree
+
+package census
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"caligra/internal/analyse"
+	"caligra/internal/formats"
+	"caligra/internal/util"
+)
+
+// a kind of exposure: Key for the command line, Label for reports
+type Exposure struct {
+	Key   string
+	Label string
+}
+
+// kinds of exposure counted per file
+var (
+	ExposureGPS        = Exposure{"gps", "GPS location"}
+	ExposureAuthor     = Exposure{"author", "author / owner"}
+	ExposureSerial     = Exposure{"serial", "serial numbers"}
+	ExposureIdentifier = Exposure{"identifier", "user / host identifiers"}
+	ExposureDevice     = Exposure{"device", "device make / model"}
+	ExposureSoftware   = Exposure{"software", "software"}
+	ExposureTimestamp  = Exposure{"timestamp", "timestamps"}
+)
+
+// every kind, in report order
+var Exposures = []Exposure{
+	ExposureGPS, ExposureAuthor, ExposureSerial, ExposureIdentifier,
+	ExposureDevice, ExposureSoftware, ExposureTimestamp,
+}
+
+// the kind with this key ("gps", "author", ...)
+func FindExposure(key string) (Exposure, bool) {
+	for _, exposure := range Exposures {
+		if strings.EqualFold(exposure.Key, key) {
+			return exposure, true
+		}
+	}
+	return Exposure{}, false
+}
+
+// keys of every kind, for usage messages
+func ExposureKeys() string {
+	keys := make([]string, len(Exposures))
+	for i, exposure := range Exposures {
+		keys[i] = exposure.Key
+	}
+	return strings.Join(keys, ", ")
+}
+
+// tags naming the application that wrote a file
+var softwareTags = map[string]bool{
+	"software": true, "creatortool": true, "producer": true, "encoder": true,
+	"encodedby": true, "writingapp": true, "historysoftwareagent": true,
+}
+
+// what exiftool reports about the file on disk or itself, not metadata the
+// file carries
+var fileSystemTags = map[string]bool{
+	"SourceFile": true, "ExifToolVersion": true, "FileName": true, "Directory": true,
+	"FileSize": true, "FileModifyDate": true, "FileAccessDate": true,
+	"FileInodeChangeDate": true, "FilePermissions": true, "FileType": true,
+	"FileTypeExtension": true, "MIMEType": true,
+}
+
+// one file's metadata size
+type Payload struct {
+	Path  string // relative to the scope
+	Bytes int    // text of all embedded tags and values
+	Tags  int
+}
+
+// a name and how many files have it
+type Count struct {
+	Name  string
+	Files int
+}
+
+type Census struct {
+	Scope    string
+	Files    int     // analysed successfully
+	Errors   int     // could not be analysed
+	Skipped  int     // unsupported extensions
+	Formats  []Count // by extension, most common first
+	Exposure map[Exposure]int
+	Software []Count   // most common first
+	Largest  []Payload // biggest metadata payloads first
+}
+
+// analyses every supported file under dir; progress is called after each
+// file with the number done and the total, top limits the software and
+// payload lists
+func Take(dir string, top int, progress func(done, total int)) (*Census, error) {
+	scope, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(scope)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", dir)
+	}
+
+	census := &Census{Scope: scope, Exposure: make(map[Exposure]int)}
+
+	var paths []string
+	err = filepath.WalkDir(scope, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != scope && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if !formats.IsSupported(filepath.Ext(path)) {
+			census.Skipped++
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	sort.Strings(paths)
+
+	byFormat := make(map[string]int)
+	bySoftware := make(map[string]int)
+	var payloads []Payload
+
+	for i, path := range paths {
+		report, err := analyse.Analyze(path)
+		if progress != nil {
+			progress(i+1, len(paths))
+		}
+		if err != nil {
+			census.Errors++
+			continue
+		}
+
+		census.Files++
+		byFormat[strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))]++
+
+		for _, exposure := range Classify(report) {
+			census.Exposure[exposure]++
+		}
+		for _, name := range software(report) {
+			bySoftware[name]++
+		}
+
+		rel, _ := filepath.Rel(scope, path)
+		payload := payloadOf(report)
+		payload.Path = rel
+		payloads = append(payloads, payload)
+	}
+
+	census.Formats = ranked(byFormat, 0)
+	census.Software = ranked(bySoftware, top)
+
+	sort.SliceStable(payloads, func(i, j int) bool { return payloads[i].Bytes > payloads[j].Bytes })
+	if top > 0 && len(payloads) > top {
+		payloads = payloads[:top]
+	}
+	census.Largest = payloads
+
+	return census, nil
+}
+
+// share of the analysed files, in percent
+func (c *Census) Percent(files int) float64 {
+	if c.Files == 0 {
+		return 0
+	}
+	return float64(files) * 100 / float64(c.Files)
+}
+
+// the kinds of exposure in one file, each once
+func Classify(report *analyse.AnalysisReport) []Exposure {
+	found := make(map[Exposure]bool)
+	for _, field := range report.SensitiveFields {
+		switch report.ValueLeaks[field] {
+		case "":
+		case util.LeakSerialNumber:
+			found[ExposureSerial] = true
+			continue
+		case util.LeakPGPKey:
+			found[ExposureAuthor] = true
+			continue
+		default:
+			found[ExposureIdentifier] = true
+			continue
+		}
+
+		switch util.SensitiveFieldCategory(field) {
+		case "location":
+			found[ExposureGPS] = true
+		case "identity":
+			found[ExposureAuthor] = true
+		case "network":
+			found[ExposureIdentifier] = true
+		case "device":
+			if strings.Contains(strings.ToLower(field), "serial") {
+				found[ExposureSerial] = true
+			} else {
+				found[ExposureDevice] = true
+			}
+		case "software":
+			found[ExposureSoftware] = true
+		case "timestamp":
+			found[ExposureTimestamp] = true
+		}
+	}
+	for _, item := range report.Embedded {
+		switch {
+		case item.Kind == formats.EmbeddedOpenPGP:
+			found[ExposureAuthor] = true
+		case item.Critical:
+			found[ExposureGPS] = true
+		}
+	}
+
+	var kinds []Exposure
+	for _, exposure := range Exposures {
+		if found[exposure] {
+			kinds = append(kinds, exposure)
+		}
+	}
+	return kinds
+}
+
+// applications named by the file's software tags, each once; values that
+// match the injected profile are not counted
+func software(report *analyse.AnalysisReport) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, field := range report.SensitiveFields {
+		if !softwareTags[strings.ToLower(field)] {
+			continue
+		}
+		name := applicationName(fmt.Sprintf("%v", report.Metadata[field]))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// "Adobe Photoshop 25.0 (Windows)" → "Adobe Photoshop", "Lavf60.3.100" →
+// "Lavf"; versions would split one application into many rows
+func applicationName(value string) string {
+	var words []string
+	for _, word := range strings.Fields(value) {
+		if isVersion(word) {
+			break
+		}
+		words = append(words, word)
+	}
+	if len(words) == 0 {
+		return ""
+	}
+
+	// a version glued to the name; short names like x264 keep their digits
+	last := len(words) - 1
+	trimmed := strings.TrimRightFunc(words[last], func(r rune) bool { return unicode.IsDigit(r) || r == '.' })
+	if len(trimmed) >= 3 {
+		words[last] = trimmed
+	}
+	return strings.Join(words, " ")
+}
+
+// "25.0", "v1.2", "(Windows)"
+func isVersion(word string) bool {
+	first := rune(word[0])
+	return first == '(' || unicode.IsDigit(first) ||
+		((first == 'v' || first == 'V') && len(word) > 1 && unicode.IsDigit(rune(word[1])))
+}
+
+// size of the tags the file carries, as their names and values in text
+func payloadOf(report *analyse.AnalysisReport) Payload {
+	var payload Payload
+	for key, value := range report.Metadata {
+		if fileSystemTags[key] || strings.HasPrefix(key, "_") {
+			continue
+		}
+		payload.Tags++
+		payload.Bytes += len(key) + len(fmt.Sprintf("%v", value))
+	}
+	return payload
+}
+
+// counts sorted by files, then name; top > 0 keeps only the first top
+func ranked(counts map[string]int, top int) []Count {
+	list := make([]Count, 0, len(counts))
+	for name, files := range counts {
+		list = append(list, Count{Name: name, Files: files})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Files != list[j].Files {
+			return list[i].Files > list[j].Files
+		}
+		return list[i].Name < list[j].Name
+	})
+	if top > 0 && len(list) > top {
+		list = list[:top]
+	}
+	return list
+}
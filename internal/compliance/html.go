@@ -0,0 +1,158 @@
+// BYZRA ⸻ internal/compliance/html.go
+// self-contained HTML rendering of a compliance report
+
+package compliance
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"upper":  strings.ToUpper,
+	"size":   formatSize,
+	"status": statusClass,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Metadata Compliance Report — {{.Scope}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1d1d1f; margin: 2.5em; font-size: 14px; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ccc; padding-bottom: 0.2em; }
+table { border-collapse: collapse; width: 100%; margin-top: 0.6em; }
+th, td { text-align: left; padding: 0.35em 0.6em; border-bottom: 1px solid #e4e4e4; vertical-align: top; }
+th { background: #f4f4f6; }
+pre { white-space: pre-wrap; background: #f8f8fa; padding: 0.6em; }
+code, .hash { font-family: ui-monospace, Menlo, Consolas, monospace; font-size: 0.85em; word-break: break-all; }
+.muted { color: #6e6e73; }
+.critical { color: #b00020; font-weight: 600; }
+.high { color: #c25e00; font-weight: 600; }
+.medium { color: #8a6d00; }
+.clean, .remediated { color: #1b7f3b; }
+.action, .error { color: #b00020; font-weight: 600; }
+@media print { body { margin: 1cm; } h2 { page-break-after: avoid; } tr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Metadata Compliance Report</h1>
+<p class="muted">Generated {{.Generated.Format "2006-01-02 15:04:05 UTC"}} by caligra</p>
+
+<h2>Scope</h2>
+<table>
+<tr><th>Directory</th><td><code>{{.Scope}}</code></td></tr>
+<tr><th>Files scanned</th><td>{{len .Files}}</td></tr>
+<tr><th>Unsupported files skipped</th><td>{{.Skipped}}</td></tr>
+</table>
+
+<h2>Policy Applied</h2>
+<p><strong>{{.Policy}}</strong></p>
+{{if .Settings}}<pre><code>{{range .Settings}}{{.}}
+{{end}}</code></pre>{{end}}
+
+<h2>Summary</h2>
+<table>
+<tr><th>Severity</th><th>Findings</th><th>Files affected</th></tr>
+{{range .SeverityRows}}<tr><td class="{{.Severity}}">{{upper .Severity}}</td><td>{{.Findings}}</td><td>{{.Files}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th>Status</th><th>Files</th></tr>
+{{range .StatusRows}}<tr><td class="{{status .Status}}">{{.Status}}</td><td>{{.Files}}</td></tr>
+{{end}}</table>
+
+<h2>Findings by Severity</h2>
+{{if .HasFindings}}{{range .SeverityRows}}{{if .Records}}
+<h3 class="{{.Severity}}">{{upper .Severity}}</h3>
+<table>
+<tr><th>File</th><th>Field</th><th>Reason</th></tr>
+{{$severity := .Severity}}{{range .Records}}{{$path := .Path}}{{range .Findings}}{{if eq .Severity $severity}}<tr><td><code>{{$path}}</code></td><td>{{.Field}}</td><td>{{.Reason}}</td></tr>
+{{end}}{{end}}{{end}}</table>
+{{end}}{{end}}{{else}}<p>No findings.</p>
+{{end}}
+
+<h2>Files</h2>
+<table>
+<tr><th>File</th><th>Format</th><th>Size</th><th>Status</th><th>SHA-256</th></tr>
+{{range .Files}}<tr>
+<td><code>{{.Path}}</code>{{if .Sanitized}}<br><span class="muted">clean copy: <code>{{.Sanitized}}</code></span>{{end}}{{if .Error}}<br><span class="muted">{{.Error}}</span>{{end}}</td>
+<td>{{.Format}}</td><td>{{size .Size}}</td><td class="{{status .Status}}">{{.Status}}</td><td class="hash">{{.SHA256}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// one row of the severity summary
+type severityRow struct {
+	Severity string
+	Findings int
+	Files    int
+	Records  []FileRecord
+}
+
+// one row of the status summary
+type statusRow struct {
+	Status string
+	Files  int
+}
+
+// summary rows in severity order
+func (r *Report) SeverityRows() []severityRow {
+	counts := r.SeverityCounts()
+	rows := make([]severityRow, 0, len(Severities))
+	for _, severity := range Severities {
+		files := r.FilesWith(severity)
+		rows = append(rows, severityRow{
+			Severity: severity,
+			Findings: counts[severity],
+			Files:    len(files),
+			Records:  files,
+		})
+	}
+	return rows
+}
+
+// summary rows in a fixed status order
+func (r *Report) StatusRows() []statusRow {
+	counts := r.StatusCounts()
+	var rows []statusRow
+	for _, status := range []string{StatusAction, StatusRemediated, StatusClean, StatusError} {
+		if counts[status] > 0 {
+			rows = append(rows, statusRow{Status: status, Files: counts[status]})
+		}
+	}
+	return rows
+}
+
+// renders the report as a standalone HTML document
+func RenderHTML(report *Report) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, report); err != nil {
+		return nil, fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// css class for a status
+func statusClass(status string) string {
+	if status == StatusAction {
+		return "action"
+	}
+	return status
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -0,0 +1,244 @@
+// BYZRA ⸻ internal/compliance/pdf.go
+// plain-text PDF rendering of a compliance report, no external tools
+
+package compliance
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 in points, with margins
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	pageMargin   = 50.0
+	footerHeight = 30.0
+)
+
+// standard PDF fonts, so nothing needs embedding
+const (
+	fontRegular = "F1" // Helvetica
+	fontBold    = "F2" // Helvetica-Bold
+	fontMono    = "F3" // Courier
+)
+
+// one positioned line of text
+type pdfLine struct {
+	font string
+	size float64
+	x, y float64
+	text string
+}
+
+// lays out lines onto pages, top to bottom
+type pdfWriter struct {
+	pages [][]pdfLine
+	y     float64
+}
+
+func newPDFWriter() *pdfWriter {
+	w := &pdfWriter{}
+	w.newPage()
+	return w
+}
+
+func (w *pdfWriter) newPage() {
+	w.pages = append(w.pages, nil)
+	w.y = pageHeight - pageMargin
+}
+
+// writes text at an indent, wrapping at the right margin
+func (w *pdfWriter) text(font string, size, indent float64, text string) {
+	width := pageWidth - 2*pageMargin - indent
+	for _, line := range wrapText(text, maxChars(font, size, width)) {
+		if w.y-size < pageMargin+footerHeight {
+			w.newPage()
+		}
+		w.y -= size * 1.35
+		page := len(w.pages) - 1
+		w.pages[page] = append(w.pages[page], pdfLine{font, size, pageMargin + indent, w.y, line})
+	}
+}
+
+// vertical space
+func (w *pdfWriter) gap(points float64) {
+	w.y -= points
+}
+
+func (w *pdfWriter) heading(text string) {
+	w.gap(10)
+	w.text(fontBold, 13, 0, text)
+	w.gap(3)
+}
+
+// assembles the PDF objects, cross-reference table and trailer
+func (w *pdfWriter) bytes() []byte {
+	var objects []string
+
+	// 1: catalog, 2: page tree, 3-5: fonts, then page/content pairs
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>", "")
+	for _, base := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		objects = append(objects, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", base))
+	}
+
+	var kids []string
+	for i, lines := range w.pages {
+		pageObj := len(objects) + 1
+		contentObj := pageObj + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+
+		footer := pdfLine{fontRegular, 8, pageMargin, pageMargin - 10,
+			fmt.Sprintf("caligra compliance report - page %d of %d", i+1, len(w.pages))}
+
+		var stream strings.Builder
+		for _, line := range append(lines, footer) {
+			fmt.Fprintf(&stream, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+				line.font, line.size, line.x, line.y, pdfEscape(line.text))
+		}
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+				"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+				pageWidth, pageHeight, contentObj),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(latin1(stream.String())), stream.String()))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.WriteString(latin1(object))
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// renders the report as a PDF document
+func RenderPDF(report *Report) ([]byte, error) {
+	w := newPDFWriter()
+
+	w.text(fontBold, 18, 0, "Metadata Compliance Report")
+	w.text(fontRegular, 9, 0, "Generated "+report.Generated.Format("2006-01-02 15:04:05 UTC")+" by caligra")
+
+	w.heading("Scope")
+	w.text(fontRegular, 10, 0, "Directory: "+report.Scope)
+	w.text(fontRegular, 10, 0, fmt.Sprintf("Files scanned: %d", len(report.Files)))
+	w.text(fontRegular, 10, 0, fmt.Sprintf("Unsupported files skipped: %d", report.Skipped))
+
+	w.heading("Policy Applied")
+	w.text(fontBold, 10, 0, report.Policy)
+	for _, line := range report.Settings {
+		w.text(fontMono, 9, 12, line)
+	}
+
+	w.heading("Summary")
+	for _, row := range report.SeverityRows() {
+		w.text(fontRegular, 10, 0, fmt.Sprintf("%-8s  %d findings in %d files",
+			strings.ToUpper(row.Severity), row.Findings, row.Files))
+	}
+	w.gap(4)
+	for _, row := range report.StatusRows() {
+		w.text(fontRegular, 10, 0, fmt.Sprintf("%s: %d files", row.Status, row.Files))
+	}
+
+	w.heading("Findings by Severity")
+	if !report.HasFindings() {
+		w.text(fontRegular, 10, 0, "No findings.")
+	}
+	for _, row := range report.SeverityRows() {
+		if len(row.Records) == 0 {
+			continue
+		}
+		w.gap(4)
+		w.text(fontBold, 11, 0, strings.ToUpper(row.Severity))
+		for _, file := range row.Records {
+			w.text(fontMono, 9, 12, file.Path)
+			for _, finding := range file.Findings {
+				if finding.Severity == row.Severity {
+					w.text(fontRegular, 9, 24, finding.Field+" ("+finding.Reason+")")
+				}
+			}
+		}
+	}
+
+	w.heading("Files")
+	for _, file := range report.Files {
+		w.gap(3)
+		w.text(fontBold, 10, 0, file.Path)
+		w.text(fontRegular, 9, 12, fmt.Sprintf("%s, %s - %s", file.Format, formatSize(file.Size), file.Status))
+		if file.Sanitized != "" {
+			w.text(fontRegular, 9, 12, "clean copy: "+file.Sanitized)
+		}
+		if file.Error != "" {
+			w.text(fontRegular, 9, 12, file.Error)
+		}
+		w.text(fontMono, 8, 12, "SHA-256 "+file.SHA256)
+	}
+
+	return w.bytes(), nil
+}
+
+// rough characters per line; Courier is exact, Helvetica averages about half an em
+func maxChars(font string, size, width float64) int {
+	perChar := size * 0.5
+	if font == fontMono {
+		perChar = size * 0.6
+	}
+	if n := int(width / perChar); n > 10 {
+		return n
+	}
+	return 10
+}
+
+// breaks text at spaces, or mid-word when a word (a path, a hash) is too long
+func wrapText(text string, limit int) []string {
+	var lines []string
+	for len([]rune(text)) > limit {
+		runes := []rune(text)
+		cut := strings.LastIndex(string(runes[:limit]), " ")
+		if cut <= 0 {
+			lines = append(lines, string(runes[:limit]))
+			text = string(runes[limit:])
+			continue
+		}
+		lines = append(lines, text[:cut])
+		text = text[cut+1:]
+	}
+	return append(lines, text)
+}
+
+// escapes string delimiters for a PDF literal
+func pdfEscape(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return replacer.Replace(text)
+}
+
+// WinAnsi bytes for the standard fonts; anything outside Latin-1 becomes "?"
+func latin1(text string) string {
+	var buf bytes.Buffer
+	for _, r := range text {
+		switch {
+		case r < 0x100:
+			buf.WriteByte(byte(r))
+		case r == '—' || r == '–':
+			buf.WriteByte('-')
+		default:
+			buf.WriteByte('?')
+		}
+	}
+	return buf.String()
+}
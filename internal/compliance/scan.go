@@ -0,0 +1,310 @@
+// BYZRA ⸻ internal/compliance/scan.go
+// directory audits for data-minimization reporting
+
+package compliance
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"caligra/internal/analyse"
+	"caligra/internal/config"
+	"caligra/internal/formats"
+	"caligra/internal/util"
+)
+
+// finding severities, most serious first
+const (
+	SeverityCritical = util.SeverityCritical // identifies a single device, machine or signing key
+	SeverityHigh     = util.SeverityHigh     // identifies a person or place
+	SeverityMedium   = "medium"              // describes equipment, software or timing
+)
+
+// Severities in report order
+var Severities = []string{SeverityCritical, SeverityHigh, SeverityMedium}
+
+// per-file remediation status
+const (
+	StatusClean      = "clean"           // nothing sensitive found
+	StatusRemediated = "remediated"      // a sanitized copy sits next to it
+	StatusAction     = "action required" // sensitive metadata, no sanitized copy
+	StatusError      = "error"           // could not be analysed
+)
+
+// one sensitive field or payload
+type Finding struct {
+	Field    string
+	Severity string
+	Reason   string // leak kind or category
+}
+
+// one scanned file
+type FileRecord struct {
+	Path       string // relative to the scope
+	Format     string
+	Size       int64
+	SHA256     string
+	Status     string
+	Sanitized  string // relative path of the sanitized copy, if any
+	Findings   []Finding
+	Error      string
+	Severities map[string]int
+}
+
+// everything an auditor needs from one scan
+type Report struct {
+	Scope     string
+	Generated time.Time
+	Policy    string
+	Settings  []string // policy settings as toml lines
+	Files     []FileRecord
+	Skipped   int // files with unsupported extensions
+}
+
+// scans every supported file under dir; progress is called after each file
+func Scan(dir string, policy *config.Policy, progress func(FileRecord)) (*Report, error) {
+	scope, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(scope)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", dir)
+	}
+
+	name := ""
+	if policy == nil {
+		policy = config.DefaultPolicy()
+		name = "default (no policy: all metadata removed)"
+	}
+
+	report := &Report{
+		Scope:     scope,
+		Generated: time.Now().UTC(),
+		Policy:    policy.Name,
+		Settings:  policySettings(policy),
+	}
+	if name != "" {
+		report.Policy = name
+	}
+
+	var paths []string
+	err = filepath.WalkDir(scope, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != scope && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		if !formats.IsSupported(filepath.Ext(path)) {
+			report.Skipped++
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		record := scanFile(scope, path)
+		report.Files = append(report.Files, record)
+		if progress != nil {
+			progress(record)
+		}
+	}
+
+	// a sanitized copy only counts if it is clean itself
+	status := make(map[string]string)
+	for _, file := range report.Files {
+		status[file.Path] = file.Status
+	}
+	for i := range report.Files {
+		file := &report.Files[i]
+		if file.Status == StatusRemediated && status[file.Sanitized] != StatusClean {
+			file.Status = StatusAction
+		}
+	}
+
+	return report, nil
+}
+
+func scanFile(scope, path string) FileRecord {
+	rel, _ := filepath.Rel(scope, path)
+	record := FileRecord{Path: rel, Severities: make(map[string]int)}
+
+	if info, err := os.Stat(path); err == nil {
+		record.Size = info.Size()
+	}
+	if sum, err := util.FileSHA256(path); err == nil {
+		record.SHA256 = sum
+	}
+
+	report, err := analyse.Analyze(path)
+	if err != nil {
+		record.Status = StatusError
+		record.Error = err.Error()
+		return record
+	}
+	record.Format = report.FileType.Format
+	record.Findings = classify(report)
+
+	for _, finding := range record.Findings {
+		record.Severities[finding.Severity]++
+	}
+
+	switch {
+	case len(record.Findings) == 0:
+		record.Status = StatusClean
+	case sanitizedCopy(path) != "":
+		record.Status = StatusRemediated
+		record.Sanitized, _ = filepath.Rel(scope, sanitizedCopy(path))
+	default:
+		record.Status = StatusAction
+	}
+	return record
+}
+
+// severity of every sensitive field and critical payload, most serious first
+func classify(report *analyse.AnalysisReport) []Finding {
+	var findings []Finding
+	seen := make(map[string]bool)
+
+	for _, field := range report.SensitiveFields {
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+
+		if kind, ok := report.ValueLeaks[field]; ok {
+			findings = append(findings, Finding{Field: field, Severity: util.LeakSeverity(kind), Reason: kind})
+			continue
+		}
+
+		category := util.SensitiveFieldCategory(field)
+		severity := SeverityMedium
+		switch category {
+		case "location", "identity", "network":
+			severity = SeverityHigh
+		}
+		findings = append(findings, Finding{Field: field, Severity: severity, Reason: category})
+	}
+
+	// critical payloads are already in SensitiveFields by name
+	for _, item := range report.Embedded {
+		if !item.Critical {
+			continue
+		}
+		for i := range findings {
+			if findings[i].Field == item.Name {
+				findings[i].Severity = SeverityCritical
+				findings[i].Reason = item.Kind
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if rank(findings[i].Severity) != rank(findings[j].Severity) {
+			return rank(findings[i].Severity) < rank(findings[j].Severity)
+		}
+		return findings[i].Field < findings[j].Field
+	})
+	return findings
+}
+
+func rank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+// the .volena copy wipe leaves next to a file, or "" when there is none
+func sanitizedCopy(path string) string {
+	if strings.Contains(filepath.Base(path), ".volena.") {
+		return ""
+	}
+	output := util.GenerateOutputPath(path)
+	if _, err := os.Stat(output); err == nil {
+		return output
+	}
+	return ""
+}
+
+// the policy as it would be written in policies.toml
+func policySettings(policy *config.Policy) []string {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(policy); err != nil {
+		return nil
+	}
+
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// files per status
+func (r *Report) StatusCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, file := range r.Files {
+		counts[file.Status]++
+	}
+	return counts
+}
+
+// findings per severity across all files
+func (r *Report) SeverityCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, file := range r.Files {
+		for severity, n := range file.Severities {
+			counts[severity] += n
+		}
+	}
+	return counts
+}
+
+// files with at least one finding of the given severity
+func (r *Report) FilesWith(severity string) []FileRecord {
+	var files []FileRecord
+	for _, file := range r.Files {
+		if file.Severities[severity] > 0 {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// any finding at all?
+func (r *Report) HasFindings() bool {
+	for _, file := range r.Files {
+		if len(file.Findings) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"caligra/internal/filter"
+	"caligra/internal/util"
 
 	"github.com/BurntSushi/toml"
 )
@@ -15,23 +20,330 @@ import (
 type DaemonConfig struct {
 	Watch struct {
 		Paths []string `toml:"paths"`
+		Mode  string   `toml:"mode"` // "inotify" (default) or "fanotify"
+
+		// per-directory policy names, e.g. "/home/me/Exports" = "photo-share"
+		Policies map[string]string `toml:"policies"`
 	} `toml:"watch"`
 	Filter struct {
 		Extensions []string `toml:"extensions"`
+
+		// route unrecognized content by extension? (unset means true)
+		ExtFallback *bool `toml:"ext_fallback"`
+
+		// larger files are skipped, 0 for no limit
+		MaxSizeMB int `toml:"max_size_mb"`
+
+		// selection expression, e.g. `size<500MB && mime=~"image/"`;
+		// files must also pass extensions and max_size_mb
+		Expression string `toml:"expression"`
 	} `toml:"filter"`
+	Removable struct {
+		Enabled  bool     `toml:"enabled"`
+		Patterns []string `toml:"patterns"` // e.g. "/run/media/$USER/*"
+	} `toml:"removable"`
+	Wipe struct {
+		Timezone string `toml:"timezone"` // e.g. "UTC"; empty leaves timestamps alone
+		Policy   string `toml:"policy"`   // e.g. "photo-share"; empty wipes everything
+
+		// hash checking working copies: "sha256" (default) or "xxhash"
+		CopyHash string `toml:"copy_hash"`
+	} `toml:"wipe"`
+	Rules []Rule `toml:"rules"`
+
+	// keeps bursts of new files from hogging the desktop
+	Throttle Throttle `toml:"throttle"`
+
+	// what the daemon does per format ("image", "video", ...)
+	Actions map[string]FormatAction `toml:"actions"`
+
+	// publishes daemon events to an MQTT broker
+	MQTT MQTT `toml:"mqtt"`
 }
 
-// loads the daemon config
-func LoadDaemonConfig() (*DaemonConfig, error) {
-	// search common locations
-	paths := []string{
+// MQTT broker and topics for daemon events; an empty broker disables it
+type MQTT struct {
+	Broker   string `toml:"broker"`    // "tcp://host:1883" or "tls://host:8883"
+	Topic    string `toml:"topic"`     // prefix, "caligra" by default
+	ClientID string `toml:"client_id"` // "caligra-<hostname>" by default
+	Username string `toml:"username"`
+
+	// file holding the password, so scroud.toml can be shared
+	PasswordFile string `toml:"password_file"`
+
+	QoS    int  `toml:"qos"`    // 0 (at most once) or 1 (at least once)
+	Retain bool `toml:"retain"` // brokers keep the last event per topic
+
+	// topic level per watch directory, e.g. "/home/me/Exports" = "exports";
+	// the directory's base name by default
+	Topics map[string]string `toml:"topics"`
+}
+
+func (m *MQTT) validate() error {
+	switch {
+	case m.Broker == "":
+		return nil
+	case m.QoS != 0 && m.QoS != 1:
+		return &fieldError{"mqtt", "qos", fmt.Sprintf("must be 0 or 1, not %d", m.QoS)}
+	case strings.ContainsAny(m.Topic, "+#"):
+		return &fieldError{"mqtt", "topic", "must not contain the wildcards + or #"}
+	}
+	for dir, level := range m.Topics {
+		if level == "" || strings.ContainsAny(level, "+#") {
+			return &fieldError{"mqtt.topics", strconv.Quote(dir), "must be non-empty and free of the wildcards + and #"}
+		}
+	}
+	return nil
+}
+
+// scheduling and rate limits for the daemon's work; zero values leave the
+// default behaviour
+type Throttle struct {
+	Nice      int  `toml:"nice"`       // 0 (normal) to 19 (lowest)
+	SchedIdle bool `toml:"sched_idle"` // run only when the CPU is otherwise idle (SCHED_IDLE)
+
+	// I/O scheduling class: "idle", "best-effort" or "" to leave it
+	IOClass    string `toml:"io_class"`
+	IOPriority int    `toml:"io_priority"` // 0 (highest) to 7 within best-effort
+
+	MaxConcurrent     int `toml:"max_concurrent"`       // files processed at once, 0 for no limit
+	OverwriteMBPerSec int `toml:"overwrite_mb_per_sec"` // secure overwrite speed, 0 for no limit
+}
+
+// I/O scheduling classes
+const (
+	IOClassIdle       = "idle"
+	IOClassBestEffort = "best-effort"
+)
+
+func (t *Throttle) validate() error {
+	switch {
+	case t.Nice < 0 || t.Nice > 19:
+		return &fieldError{"throttle", "nice", fmt.Sprintf("must be between 0 and 19, not %d", t.Nice)}
+	case t.IOClass != "" && t.IOClass != IOClassIdle && t.IOClass != IOClassBestEffort:
+		return &fieldError{"throttle", "io_class", fmt.Sprintf("must be idle or best-effort, not %q", t.IOClass)}
+	case t.IOPriority < 0 || t.IOPriority > 7:
+		return &fieldError{"throttle", "io_priority", fmt.Sprintf("must be between 0 and 7, not %d", t.IOPriority)}
+	case t.MaxConcurrent < 0:
+		return &fieldError{"throttle", "max_concurrent", "must not be negative"}
+	case t.OverwriteMBPerSec < 0:
+		return &fieldError{"throttle", "overwrite_mb_per_sec", "must not be negative"}
+	}
+	return nil
+}
+
+// a bad value in scroud.toml; table and key let 'caligra config validate'
+// point at its line
+type fieldError struct {
+	table, key, message string
+}
+
+func (e *fieldError) Error() string {
+	return fmt.Sprintf("[%s] %s %s", e.table, e.key, e.message)
+}
+
+// may detection fall back to the file extension when the content is not
+// recognized?
+func (c *DaemonConfig) ExtensionFallback() bool {
+	return c.Filter.ExtFallback == nil || *c.Filter.ExtFallback
+}
+
+// the [filter] ext_fallback default for the CLI; true when no scroud.toml
+// sets it
+func ExtensionFallbackDefault() bool {
+	config := cliDaemonConfig()
+	return config == nil || config.ExtensionFallback()
+}
+
+// largest file analysed or wiped, in bytes; 0 for no limit
+func (c *DaemonConfig) MaxFileSize() int64 {
+	return int64(c.Filter.MaxSizeMB) << 20
+}
+
+// the [filter] max_size_mb default for the CLI; 0 when no scroud.toml sets it
+func MaxFileSizeDefault() int64 {
+	config := cliDaemonConfig()
+	if config == nil || config.Filter.MaxSizeMB < 0 {
+		return 0
+	}
+	return config.MaxFileSize()
+}
+
+// the first scroud.toml found, for the [filter] settings the CLI shares with
+// the daemon; nil when there is none or it does not decode
+func cliDaemonConfig() *DaemonConfig {
+	for _, path := range daemonConfigPaths() {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		var config DaemonConfig
+		if _, err := toml.DecodeFile(path, &config); err != nil {
+			return nil
+		}
+		return &config
+	}
+	return nil
+}
+
+// the [wipe] copy_hash default for the CLI; "" (SHA-256) when no scroud.toml
+// sets it
+func CopyHashDefault() string {
+	config := cliDaemonConfig()
+	if config == nil || validateCopyHash(config.Wipe.CopyHash) != nil {
+		return ""
+	}
+	return config.Wipe.CopyHash
+}
+
+func validateCopyHash(name string) error {
+	switch name {
+	case "", util.CopyHashSHA256, util.CopyHashXXHash:
+		return nil
+	}
+	return &fieldError{"wipe", "copy_hash", fmt.Sprintf("must be sha256 or xxhash, not %q", name)}
+}
+
+func validateMaxSize(mb int) error {
+	if mb < 0 {
+		return &fieldError{"filter", "max_size_mb", "must not be negative"}
+	}
+	return nil
+}
+
+// daemon actions for a format
+const (
+	ActionWipe    = "wipe"    // analyse, then wipe when something sensitive is found
+	ActionAnalyse = "analyse" // analyse and log findings, never modify
+	ActionIgnore  = "ignore"  // leave the file alone
+)
+
+// how the daemon treats one format
+type FormatAction struct {
+	Action string `toml:"action"`
+
+	// wipe the file itself instead of writing a .volena copy (a backup is kept)
+	InPlace bool `toml:"in_place"`
+
+	// policy for this format's wipes
+	Policy string `toml:"policy"`
+}
+
+func (a FormatAction) validate(format string) error {
+	switch a.Action {
+	case "", ActionWipe, ActionAnalyse, ActionIgnore:
+		return nil
+	}
+	return &fieldError{"actions." + format, "action", fmt.Sprintf("must be wipe, analyse or ignore, not %q", a.Action)}
+}
+
+// the action for a detected file: by format ("matroska"), then by
+// MIME type ("video" for video/webm); ok is false when none is configured
+func (c *DaemonConfig) ActionFor(format, mimeType string) (FormatAction, bool) {
+	if action, ok := c.Actions[format]; ok {
+		return action, true
+	}
+	if kind, _, found := strings.Cut(mimeType, "/"); found {
+		if action, ok := c.Actions[kind]; ok {
+			return action, true
+		}
+	}
+	return FormatAction{}, false
+}
+
+// named daemon rule applied to files under its paths
+type Rule struct {
+	Name string `toml:"name"`
+
+	// ready-made behaviour that fills in defaults ("screenshot")
+	Workflow string `toml:"workflow"`
+
+	// directories the rule covers (watched in addition to [watch] paths)
+	Paths []string `toml:"paths"`
+
+	// neutral filename pattern, e.g. "shot-{random}" (extension is kept);
+	// "none" keeps the original name
+	Rename string `toml:"rename"`
+
+	// selection expression; files under paths it rejects are handled as if
+	// the rule did not exist
+	Filter string `toml:"filter"`
+
+	// folder cleaned files are moved into
+	MoveTo string `toml:"move_to"`
+
+	// folder sanitized copies are written into, mirroring the layout under
+	// the rule's paths; originals are never touched
+	MirrorTo string `toml:"mirror_to"`
+}
+
+// a renamed file left under the rule's paths is picked up again as a new
+// one, so renaming in place needs somewhere else to put the result
+func (r *Rule) checkRename() error {
+	if r.Rename == "" || r.Rename == "none" || r.MirrorTo != "" {
+		return nil
+	}
+	if r.MoveTo == "" {
+		return fmt.Errorf("rename needs move_to, or renamed files are processed again")
+	}
+	for _, dir := range r.Paths {
+		if filepath.Clean(r.MoveTo) == filepath.Clean(dir) {
+			return fmt.Errorf("move_to cannot be one of its paths when rename is set")
+		}
+	}
+	return nil
+}
+
+// fills rule fields left empty from its workflow
+func (r *Rule) ApplyWorkflowDefaults() {
+	switch r.Workflow {
+	case "screenshot":
+		if len(r.Paths) == 0 {
+			r.Paths = []string{filepath.Join(os.Getenv("HOME"), "Pictures", "Screenshots")}
+		}
+		if r.Rename == "" {
+			r.Rename = "shot-{random}"
+		}
+		if r.MoveTo == "" && r.MirrorTo == "" && len(r.Paths) > 0 {
+			r.MoveTo = filepath.Join(r.Paths[0], "clean")
+		}
+	}
+
+	for i, path := range r.Paths {
+		r.Paths[i] = ExpandPath(path)
+	}
+	r.MoveTo = ExpandPath(r.MoveTo)
+	r.MirrorTo = ExpandPath(r.MirrorTo)
+}
+
+// folder the rule writes its output into, if any
+func (r *Rule) OutputDir() string {
+	if r.MirrorTo != "" {
+		return r.MirrorTo
+	}
+	return r.MoveTo
+}
+
+// expands a leading ~ and environment variables
+func ExpandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return os.ExpandEnv(path)
+}
+
+// common scroud.toml locations, in search order
+func daemonConfigPaths() []string {
+	return []string{
 		"config/scroud.toml",
 		"./scroud.toml",
 		filepath.Join(os.Getenv("HOME"), ".caligra/config/scroud.toml"),
 	}
+}
 
+// loads the daemon config
+func LoadDaemonConfig() (*DaemonConfig, error) {
 	var configPath string
-	for _, path := range paths {
+	for _, path := range daemonConfigPaths() {
 		if _, err := os.Stat(path); err == nil {
 			configPath = path
 			break
@@ -51,11 +363,65 @@ func LoadDaemonConfig() (*DaemonConfig, error) {
 	var activePaths []string
 	for _, path := range config.Watch.Paths {
 		if len(path) > 0 && path[0] != '#' {
-			activePaths = append(activePaths, path)
+			activePaths = append(activePaths, ExpandPath(path))
 		}
 	}
 	config.Watch.Paths = activePaths
 
+	policies := make(map[string]string, len(config.Watch.Policies))
+	for path, policy := range config.Watch.Policies {
+		policies[filepath.Clean(ExpandPath(path))] = policy
+	}
+	config.Watch.Policies = policies
+
+	if config.Removable.Enabled && len(config.Removable.Patterns) == 0 {
+		config.Removable.Patterns = DefaultRemovablePatterns()
+	}
+
+	for i := range config.Rules {
+		config.Rules[i].ApplyWorkflowDefaults()
+		if _, err := filter.Parse(config.Rules[i].Filter); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", config.Rules[i].Name, err)
+		}
+		if err := config.Rules[i].checkRename(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", config.Rules[i].Name, err)
+		}
+	}
+	if _, err := filter.Parse(config.Filter.Expression); err != nil {
+		return nil, fmt.Errorf("[filter] expression: %w", err)
+	}
+
+	if err := validateMaxSize(config.Filter.MaxSizeMB); err != nil {
+		return nil, err
+	}
+	if err := validateCopyHash(config.Wipe.CopyHash); err != nil {
+		return nil, err
+	}
+
+	if err := config.Throttle.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.MQTT.validate(); err != nil {
+		return nil, err
+	}
+	topics := make(map[string]string, len(config.MQTT.Topics))
+	for path, level := range config.MQTT.Topics {
+		topics[filepath.Clean(ExpandPath(path))] = level
+	}
+	config.MQTT.Topics = topics
+	config.MQTT.PasswordFile = ExpandPath(config.MQTT.PasswordFile)
+
+	for format, action := range config.Actions {
+		if err := action.validate(format); err != nil {
+			return nil, err
+		}
+		if action.Action == "" {
+			action.Action = ActionWipe
+			config.Actions[format] = action
+		}
+	}
+
 	return &config, nil
 }
 
@@ -71,9 +437,18 @@ func GetDefaultConfig() *DaemonConfig {
 		".mp4", ".avi",
 		".txt", ".md", ".html",
 	}
+	config.Removable.Patterns = DefaultRemovablePatterns()
 	return config
 }
 
+// usual automount locations for camera cards and USB sticks
+func DefaultRemovablePatterns() []string {
+	return []string{
+		"/run/media/$USER/*",
+		"/media/$USER/*",
+	}
+}
+
 // saves the current configuration to a file
 func SaveDaemonConfig(config *DaemonConfig, path string) error {
 	dir := filepath.Dir(path)
@@ -0,0 +1,325 @@
+// BYZRA ⸻ internal/config/edit.go
+// in-place edits to scroud.toml that keep the user's comments and layout
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// the scroud.toml in use, or where a new one goes
+func DaemonConfigPath() string {
+	for _, path := range daemonConfigPaths() {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(os.Getenv("HOME"), ".caligra/config/scroud.toml")
+}
+
+// adds dir to [watch] paths, with an optional policy in [watch.policies]
+func AddWatchPath(configPath, dir, policy string) error {
+	lines, err := readConfigLines(configPath)
+	if err != nil {
+		return err
+	}
+
+	lines, err = editWatchPaths(lines, func(paths []string) []string {
+		if slices.ContainsFunc(paths, func(p string) bool { return samePath(p, dir) }) {
+			return paths
+		}
+		return append(paths, dir)
+	})
+	if err != nil {
+		return err
+	}
+
+	lines = removeWatchPolicy(lines, dir)
+	if policy != "" {
+		lines = setWatchPolicy(lines, dir, policy)
+	}
+
+	return writeConfigLines(configPath, lines)
+}
+
+// removes dir from [watch] paths and [watch.policies]
+func RemoveWatchPath(configPath, dir string) error {
+	lines, err := readConfigLines(configPath)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	lines, err = editWatchPaths(lines, func(paths []string) []string {
+		return slices.DeleteFunc(paths, func(p string) bool {
+			if samePath(p, dir) {
+				found = true
+				return true
+			}
+			return false
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s is not in the watch paths of %s", dir, configPath)
+	}
+
+	return writeConfigLines(configPath, removeWatchPolicy(lines, dir))
+}
+
+func readConfigLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
+
+// validates before writing, so a bad edit never replaces a working config
+func writeConfigLines(path string, lines []string) error {
+	text := strings.Join(lines, "\n") + "\n"
+
+	var check DaemonConfig
+	if _, err := toml.Decode(text, &check); err != nil {
+		return fmt.Errorf("edit would leave %s invalid: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text), 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// rewrites the [watch] paths array through edit; entries are rewritten one
+// per line, comment lines inside the array are kept
+func editWatchPaths(lines []string, edit func([]string) []string) ([]string, error) {
+	start, end := section(lines, "watch")
+	if start < 0 {
+		paths := edit(nil)
+		return appendSection(lines, "[watch]", "paths = "+quoteList(paths)), nil
+	}
+
+	key := -1
+	for i := start + 1; i < end; i++ {
+		if k, _ := splitKey(lines[i]); k == "paths" {
+			key = i
+			break
+		}
+	}
+	if key < 0 {
+		paths := edit(nil)
+		return slices.Insert(lines, start+1, "paths = "+quoteList(paths)), nil
+	}
+
+	// single line: paths = ["a", "b"]
+	_, value := splitKey(lines[key])
+	if strings.Contains(stripComment(value), "]") {
+		var parsed struct{ Paths []string }
+		if _, err := toml.Decode("paths = "+stripComment(value), &parsed); err != nil {
+			return nil, fmt.Errorf("cannot parse [watch] paths: %w", err)
+		}
+		lines[key] = "paths = " + quoteList(edit(parsed.Paths))
+		if comment := strings.TrimPrefix(value, stripComment(value)); comment != "" {
+			lines[key] += " " + comment
+		}
+		return lines, nil
+	}
+
+	// multi-line: entries until the closing bracket
+	closing := -1
+	var paths, comments []string
+	for i := key + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "]") {
+			closing = i
+			break
+		}
+		entry := strings.TrimSuffix(strings.TrimSpace(stripComment(trimmed)), ",")
+		if entry == "" {
+			if trimmed != "" {
+				comments = append(comments, lines[i])
+			}
+			continue
+		}
+		path, err := unquote(entry)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse [watch] paths entry %s", entry)
+		}
+		paths = append(paths, path)
+	}
+	if closing < 0 {
+		return nil, fmt.Errorf("unterminated [watch] paths array")
+	}
+
+	body := append([]string{}, comments...)
+	for _, path := range edit(paths) {
+		body = append(body, "    "+strconv.Quote(path)+",")
+	}
+
+	rebuilt := append(append(append([]string{}, lines[:key+1]...), body...), lines[closing:]...)
+	return rebuilt, nil
+}
+
+// sets "dir" = "policy" in [watch.policies], creating the table if needed
+func setWatchPolicy(lines []string, dir, policy string) []string {
+	entry := strconv.Quote(dir) + " = " + strconv.Quote(policy)
+
+	start, end := section(lines, "watch.policies")
+	if start < 0 {
+		return appendSection(lines, "[watch.policies]", entry)
+	}
+
+	// after the last entry, before trailing blank lines and comments
+	at := start + 1
+	for i := start + 1; i < end; i++ {
+		if k, _ := splitKey(lines[i]); k != "" {
+			at = i + 1
+		}
+	}
+	return slices.Insert(lines, at, entry)
+}
+
+// adds a table at the end, separated by a blank line
+func appendSection(lines []string, header string, body ...string) []string {
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	return append(append(lines, header), body...)
+}
+
+// drops dir's line from [watch.policies]
+func removeWatchPolicy(lines []string, dir string) []string {
+	start, end := section(lines, "watch.policies")
+	if start < 0 {
+		return lines
+	}
+
+	for i := end - 1; i > start; i-- {
+		key, _ := splitKey(lines[i])
+		if key == "" {
+			continue
+		}
+		if path, err := unquote(key); err == nil && samePath(path, dir) {
+			lines = slices.Delete(lines, i, i+1)
+		}
+	}
+	return lines
+}
+
+// line range of a [table]: header index and the index after its last line
+func section(lines []string, name string) (int, int) {
+	start := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(stripComment(line))
+		if !strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+		if start >= 0 {
+			return start, i
+		}
+		if trimmed == "["+name+"]" {
+			start = i
+		}
+	}
+	if start < 0 {
+		return -1, -1
+	}
+	return start, len(lines)
+}
+
+// "key = value" -> key, value; "" for comments, blanks and headers
+func splitKey(line string) (string, string) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "[") {
+		return "", ""
+	}
+
+	// keys may be quoted paths containing "="
+	if strings.HasPrefix(trimmed, `"`) {
+		if end := closingQuote(trimmed); end > 0 {
+			rest := strings.TrimSpace(trimmed[end+1:])
+			if strings.HasPrefix(rest, "=") {
+				return trimmed[:end+1], strings.TrimSpace(rest[1:])
+			}
+		}
+		return "", ""
+	}
+
+	key, value, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return "", ""
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value)
+}
+
+// drops a trailing # comment that is not inside a string
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// index of the quote closing a basic string that starts at 0
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// a TOML basic or literal string
+func unquote(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+	return strconv.Unquote(s)
+}
+
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = strconv.Quote(item)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// same directory once ~ and variables are expanded
+func samePath(a, b string) bool {
+	return filepath.Clean(ExpandPath(a)) == filepath.Clean(ExpandPath(b))
+}
@@ -0,0 +1,176 @@
+// BYZRA ⸻ internal/config/local.go
+// per-directory .caligra.toml overrides, scoped like .gitignore
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// name of the per-directory override file
+const LocalConfigName = ".caligra.toml"
+
+// one .caligra.toml; applies to its directory and everything below it
+type LocalConfig struct {
+	Policy  string   `toml:"policy"`  // e.g. "source-protection"
+	Profile string   `toml:"profile"` // profile name, or a .lua path relative to the file
+	Ignore  []string `toml:"ignore"`  // gitignore-style patterns, relative to the file
+
+	path    string
+	modTime time.Time
+	policy  *Policy
+	profile string // Profile, with a relative .lua path made absolute
+}
+
+// overrides in effect for one file, merged from the outermost
+// .caligra.toml inwards
+type LocalSettings struct {
+	Policy  *Policy  // nil keeps the caller's
+	Profile string   // profile name or path, "" keeps the caller's
+	Sources []string // contributing .caligra.toml files, outermost first
+
+	configs []*LocalConfig
+}
+
+// reads .caligra.toml files on demand, re-reading any that change
+type LocalConfigs struct {
+	mu    sync.Mutex
+	cache map[string]*LocalConfig // by directory
+}
+
+func NewLocalConfigs() *LocalConfigs {
+	return &LocalConfigs{cache: make(map[string]*LocalConfig)}
+}
+
+// settings for path from the .caligra.toml files in its directory and the
+// directories above it, up to and including root ("" goes up to the
+// filesystem root); nearer files override policy and profile, ignore
+// patterns add up
+func (l *LocalConfigs) For(root, path string) (*LocalSettings, error) {
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+		parent := filepath.Dir(dir)
+		if dir == filepath.Clean(root) || parent == dir {
+			break
+		}
+	}
+
+	settings := &LocalSettings{}
+	for _, dir := range dirs {
+		local, err := l.load(dir)
+		if err != nil {
+			return nil, err
+		}
+		if local == nil {
+			continue
+		}
+
+		settings.Sources = append(settings.Sources, local.path)
+		settings.configs = append(settings.configs, local)
+		if local.policy != nil {
+			settings.Policy = local.policy
+		}
+		if local.profile != "" {
+			settings.Profile = local.profile
+		}
+	}
+	return settings, nil
+}
+
+// the .caligra.toml in dir, nil when there is none
+func (l *LocalConfigs) load(dir string) (*LocalConfig, error) {
+	path := filepath.Join(dir, LocalConfigName)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if cached := l.cache[dir]; cached != nil && cached.modTime.Equal(info.ModTime()) {
+		return cached, nil
+	}
+
+	local := &LocalConfig{path: path, modTime: info.ModTime()}
+	if _, err := toml.DecodeFile(path, local); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if local.Policy != "" {
+		if local.policy, err = LoadPolicy(local.Policy); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if local.Profile != "" {
+		name := local.Profile
+		if (strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, ".lua")) && !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		// loaded here to report errors early, and per file when wiping
+		if _, err := LoadNamedProfile(name); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		local.profile = name
+	}
+
+	l.cache[dir] = local
+	return local, nil
+}
+
+// is path (a directory when isDir) matched by an ignore pattern of any
+// .caligra.toml above it?
+func (s *LocalSettings) Ignored(path string, isDir bool) bool {
+	for _, local := range s.configs {
+		if local.ignores(path, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitignore-style matching: patterns without a slash match a name at any
+// depth, patterns with one match from the .caligra.toml's directory, and a
+// trailing slash only matches directories
+func (c *LocalConfig) ignores(target string, isDir bool) bool {
+	rel, err := filepath.Rel(filepath.Dir(c.path), target)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	for _, pattern := range c.Ignore {
+		dirOnly := strings.HasSuffix(pattern, "/")
+		pattern = strings.TrimSuffix(pattern, "/")
+		anchored := strings.Contains(pattern, "/")
+		pattern = strings.TrimPrefix(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		for i := range parts {
+			// the last part is target itself; earlier ones are directories
+			if dirOnly && i == len(parts)-1 && !isDir {
+				continue
+			}
+
+			candidate := parts[i]
+			if anchored {
+				candidate = strings.Join(parts[:i+1], "/")
+			}
+			if matched, _ := path.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
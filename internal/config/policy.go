@@ -0,0 +1,303 @@
+// BYZRA ⸻ internal/config/policy.go
+// wipe policies: what to keep, remove or clean per file
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// cover art choices for audio files
+const (
+	CoverArtKeep   = "keep"   // leave the image untouched
+	CoverArtRemove = "remove" // drop it with the rest of the tags
+	CoverArtStrip  = "strip"  // keep the image, minus its own EXIF/XMP
+)
+
+// keep/remove choice for a tag family
+const (
+	Keep   = "keep"
+	Remove = "remove"
+)
+
+// attendee choice for calendars, besides keep/remove
+const AttendeesStrip = "strip" // keep each entry's role and reply, not who it is
+
+// how far photo dates are coarsened (besides keep/remove)
+const (
+	DatesDay   = "day"   // 2024:05:17 00:00:00
+	DatesMonth = "month" // 2024:05:01 00:00:00
+	DatesYear  = "year"  // 2024:01:01 00:00:00
+)
+
+// what happens to files with other hard links
+const (
+	HardLinksWarn   = "warn"   // wipe them and say so
+	HardLinksBreak  = "break"  // give the wiped name its own copy first
+	HardLinksRefuse = "refuse" // leave them alone
+)
+
+// named set of wipe choices
+type Policy struct {
+	Name string `toml:"-" json:"name,omitempty"`
+
+	// "keep", "remove" or "strip"
+	CoverArt string `toml:"cover_art" json:"cover_art,omitempty"`
+
+	// encoder strings and settings (TSSE/TENC, ENCODER, LAME header): "keep" or "remove"
+	Encoder string `toml:"encoder" json:"encoder,omitempty"`
+
+	// ReplayGain/R128 gain tags and LAME header gain fields: "keep" or "remove"
+	ReplayGain string `toml:"replaygain" json:"replaygain,omitempty"`
+
+	// MusicBrainz track/release/artist IDs: "keep" or "remove"
+	MusicBrainz string `toml:"musicbrainz" json:"musicbrainz,omitempty"`
+
+	// AcoustID IDs and fingerprints: "keep" or "remove"
+	AcoustID string `toml:"acoustid" json:"acoustid,omitempty"`
+
+	// ordinary tags to keep by name ("title", "artist", ...); everything else goes
+	KeepTags []string `toml:"keep_tags" json:"keep_tags,omitempty"`
+
+	// exiftool tags to keep in images ("Orientation", "ICC_Profile", ...)
+	KeepImageTags []string `toml:"keep_image_tags" json:"keep_image_tags,omitempty"`
+
+	// photo capture dates and GPS track times: "keep", "remove", "day",
+	// "month" or "year"
+	Dates string `toml:"dates" json:"dates,omitempty"`
+
+	// positions in GPS tracks: "keep" or "remove"
+	Coordinates string `toml:"coordinates" json:"coordinates,omitempty"`
+
+	// calendar organizers and attendees: "keep", "remove" or "strip"
+	Attendees string `toml:"attendees" json:"attendees,omitempty"`
+
+	// cut the vendor MakerNote out of the EXIF block before the tag wipe
+	ExciseMakerNote bool `toml:"excise_makernote" json:"excise_makernote,omitempty"`
+
+	// apply the EXIF orientation to the image (lossless for JPEG and PNG)
+	// when the Orientation tag is not kept, so the output displays upright
+	AutoRotate bool `toml:"auto_rotate" json:"auto_rotate,omitempty"`
+
+	// decode and re-encode images, leaving nothing but pixels
+	Reencode bool `toml:"reencode" json:"reencode,omitempty"`
+
+	// rebuild audio/video containers from their streams
+	Remux bool `toml:"remux" json:"remux,omitempty"`
+
+	// output file name: "" keeps it, "random" replaces it with random hex
+	Rename string `toml:"rename" json:"rename,omitempty"`
+
+	// rewrite remaining dates into this zone (e.g. "UTC")
+	Timezone string `toml:"timezone" json:"timezone,omitempty"`
+
+	// overwrite originals and backups before deleting them
+	SecureDelete bool `toml:"secure_delete" json:"secure_delete,omitempty"`
+
+	// files with other hard links, wiped in place or securely deleted:
+	// "warn", "break" or "refuse"
+	HardLinks string `toml:"hardlinks" json:"hardlinks,omitempty"`
+
+	// write a signed report of every step next to the output
+	Attestation bool `toml:"attestation" json:"attestation,omitempty"`
+}
+
+// file name choices
+const RenameRandom = "random"
+
+// built-in policies, usable without a policies.toml
+var presets = map[string]func() *Policy{
+	// share a music folder without breaking the library:
+	// what players sort by stays, comments/ratings/play counts/URLs/IDs go
+	"music-library": func() *Policy {
+		return &Policy{
+			CoverArt:    CoverArtStrip,
+			Encoder:     Remove,
+			ReplayGain:  Keep,
+			MusicBrainz: Remove,
+			AcoustID:    Remove,
+			KeepTags: []string{
+				"title", "artist", "album", "album_artist",
+				"track", "tracknumber", "disc", "discnumber",
+				"date", "year", "genre",
+			},
+		}
+	},
+
+	// share photos without location, owner or device history:
+	// what renders the image right and how it was exposed stays
+	"photo-share": func() *Policy {
+		return &Policy{
+			Dates: Remove,
+			KeepImageTags: []string{
+				"Orientation", "ICC_Profile", "ColorSpace",
+				"ExposureTime", "FNumber", "ISO", "FocalLength",
+				"ExposureProgram", "ExposureCompensation",
+				"MeteringMode", "Flash", "WhiteBalance",
+			},
+		}
+	},
+
+	// whistleblower/source material: nothing kept, nothing traceable,
+	// and a signed record of what was done
+	"source-protection": func() *Policy {
+		return &Policy{
+			CoverArt:     CoverArtRemove,
+			Dates:        Remove,
+			Coordinates:  Remove,
+			Attendees:    Remove,
+			Reencode:     true,
+			Remux:        true,
+			Rename:       RenameRandom,
+			Timezone:     "UTC",
+			SecureDelete: true,
+			Attestation:  true,
+		}
+	},
+}
+
+// names of the built-in policies
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the behaviour without a policy: everything goes
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Name:        "default",
+		CoverArt:    CoverArtRemove,
+		Encoder:     Remove,
+		ReplayGain:  Remove,
+		MusicBrainz: Remove,
+		AcoustID:    Remove,
+		Dates:       Remove,
+		Coordinates: Keep,
+		Attendees:   Remove,
+		HardLinks:   HardLinksWarn,
+	}
+}
+
+// checks choices and fills in defaults
+func (p *Policy) Validate() error {
+	switch p.CoverArt {
+	case "":
+		p.CoverArt = CoverArtRemove
+	case CoverArtKeep, CoverArtRemove, CoverArtStrip:
+	default:
+		return fmt.Errorf("policy %q: cover_art must be keep, remove or strip, not %q", p.Name, p.CoverArt)
+	}
+
+	switch p.Dates {
+	case "":
+		p.Dates = Remove
+	case Keep, Remove, DatesDay, DatesMonth, DatesYear:
+	default:
+		return fmt.Errorf("policy %q: dates must be keep, remove, day, month or year, not %q", p.Name, p.Dates)
+	}
+
+	switch p.Coordinates {
+	case "":
+		p.Coordinates = Keep // the points are what a track is for
+	case Keep, Remove:
+	default:
+		return fmt.Errorf("policy %q: coordinates must be keep or remove, not %q", p.Name, p.Coordinates)
+	}
+
+	switch p.Attendees {
+	case "":
+		p.Attendees = Remove
+	case Keep, Remove, AttendeesStrip:
+	default:
+		return fmt.Errorf("policy %q: attendees must be keep, remove or strip, not %q", p.Name, p.Attendees)
+	}
+
+	switch p.HardLinks {
+	case "":
+		p.HardLinks = HardLinksWarn
+	case HardLinksWarn, HardLinksBreak, HardLinksRefuse:
+	default:
+		return fmt.Errorf("policy %q: hardlinks must be warn, break or refuse, not %q", p.Name, p.HardLinks)
+	}
+
+	switch p.Rename {
+	case "", RenameRandom:
+	default:
+		return fmt.Errorf("policy %q: rename must be empty or random, not %q", p.Name, p.Rename)
+	}
+
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("policy %q: unknown timezone %q", p.Name, p.Timezone)
+		}
+	}
+
+	choices := map[string]*string{
+		"encoder":     &p.Encoder,
+		"replaygain":  &p.ReplayGain,
+		"musicbrainz": &p.MusicBrainz,
+		"acoustid":    &p.AcoustID,
+	}
+	for field, value := range choices {
+		switch *value {
+		case "":
+			*value = Remove
+		case Keep, Remove:
+		default:
+			return fmt.Errorf("policy %q: %s must be keep or remove, not %q", p.Name, field, *value)
+		}
+	}
+	return nil
+}
+
+// policies.toml layout: [policies.<name>]
+type policyFile struct {
+	Policies map[string]*Policy `toml:"policies"`
+}
+
+// loads a named policy from policies.toml, falling back to the built-in presets
+func LoadPolicy(name string) (*Policy, error) {
+	paths := []string{
+		"config/policies.toml",
+		"./policies.toml",
+		filepath.Join(os.Getenv("HOME"), ".caligra/config/policies.toml"),
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		var file policyFile
+		if _, err := toml.DecodeFile(path, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		if policy, ok := file.Policies[name]; ok {
+			policy.Name = name
+			if err := policy.Validate(); err != nil {
+				return nil, err
+			}
+			return policy, nil
+		}
+	}
+
+	if preset, ok := presets[name]; ok {
+		policy := preset()
+		policy.Name = name
+		return policy, policy.Validate()
+	}
+
+	return nil, fmt.Errorf("unknown policy: %s (built-in: %s)", name, strings.Join(PresetNames(), ", "))
+}
@@ -4,9 +4,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	lua "github.com/yuin/gopher-lua"
 )
@@ -21,62 +23,178 @@ type Profile struct {
 	Comment      string
 }
 
+// directories searched for profile files, in order
+func profileDirs() []string {
+	return []string{
+		"config",
+		".",
+		filepath.Join(os.Getenv("HOME"), ".caligra/config"),
+		filepath.Join(os.Getenv("HOME"), ".caligra/config/profiles"),
+	}
+}
+
+// limit on how many profiles an extends chain may stack
+const maxProfileDepth = 16
+
+// fields every profile must set, itself or through extends
+var requiredProfileFields = []string{"author", "software", "created"}
+
+// wrapped when a named profile is not in the search paths
+var ErrProfileNotFound = errors.New("profile not found")
+
 // loads profile
 func LoadProfile() (map[string]string, error) {
-	// search common locations
-	paths := []string{
-		"config/profile.lua",
-		"./profile.lua",
-		filepath.Join(os.Getenv("HOME"), ".caligra/config/profile.lua"),
+	return LoadNamedProfile("profile")
+}
+
+// loads <name>.lua from the profile search paths, resolving extends; a
+// function profile is called without a file
+func LoadNamedProfile(name string) (map[string]string, error) {
+	return LoadProfileFor(name, nil)
+}
+
+// loads <name>.lua for one file: a profile that returns a function (or
+// extends one that does) is called with file
+func LoadProfileFor(name string, file *ProfileFile) (map[string]string, error) {
+	profilePath := findProfile(name, "")
+	if profilePath == "" {
+		return nil, fmt.Errorf("%w: %s.lua is not in the search paths", ErrProfileNotFound, name)
+	}
+
+	profile, err := loadProfileChain(profilePath, nil, file)
+	if err != nil {
+		return nil, err
 	}
 
-	var profilePath string
-	for _, path := range paths {
+	// validate required fields (after inheritance, so overlays can stay thin)
+	for _, field := range requiredProfileFields {
+		if _, ok := profile[field]; !ok {
+			return nil, fmt.Errorf("profile is missing required field: %s", field)
+		}
+	}
+
+	return profile, nil
+}
+
+// path of the named profile in the search paths, "" when there is none
+func ProfilePath(name string) string {
+	return findProfile(name, "")
+}
+
+// locates a profile by name or path; the extending file's directory is searched first
+func findProfile(name, fromDir string) string {
+	if strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, ".lua") {
+		if !filepath.IsAbs(name) && fromDir != "" {
+			name = filepath.Join(fromDir, name)
+		}
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+		return ""
+	}
+
+	dirs := profileDirs()
+	if fromDir != "" {
+		dirs = append([]string{fromDir}, dirs...)
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name+".lua")
 		if _, err := os.Stat(path); err == nil {
-			profilePath = path
-			break
+			return path
 		}
 	}
+	return ""
+}
 
-	if profilePath == "" {
-		return nil, fmt.Errorf("profile.lua not found in search paths")
+// loads a profile and everything it extends; chain holds the files above it
+func loadProfileChain(path string, chain []string, file *ProfileFile) (map[string]string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+
+	for _, seen := range chain {
+		if seen == absPath {
+			return nil, fmt.Errorf("profile inheritance cycle: %s", formatProfileChain(append(chain, absPath)))
+		}
+	}
+	chain = append(chain, absPath)
+
+	if len(chain) > maxProfileDepth {
+		return nil, fmt.Errorf("profile inheritance deeper than %d levels: %s", maxProfileDepth, formatProfileChain(chain))
 	}
 
-	data, err := os.ReadFile(profilePath)
+	own, _, err := readProfileFile(path, file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read profile: %w", err)
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 
-	L := lua.NewState()
-	defer L.Close()
+	base, ok := own["extends"]
+	if !ok {
+		return own, nil
+	}
+	delete(own, "extends")
+
+	basePath := findProfile(base, filepath.Dir(path))
+	if basePath == "" {
+		return nil, fmt.Errorf("%s: extends unknown profile %q", path, base)
+	}
+
+	profile, err := loadProfileChain(basePath, chain, file)
+	if err != nil {
+		return nil, err
+	}
+
+	// the overlay wins over its base
+	for key, value := range own {
+		profile[key] = value
+	}
+	return profile, nil
+}
+
+// runs one profile file (calling it with file when it returns a function)
+// and returns its table as strings, plus the keys skipped for not holding
+// a string
+func readProfileFile(path string, file *ProfileFile) (map[string]string, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read profile: %w", err)
+	}
 
-	if err := L.DoString(string(data)); err != nil {
-		return nil, fmt.Errorf("failed to execute profile Lua: %w", err)
+	L, err := runSandboxed(string(data), file)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer L.Close()
 
 	result := L.Get(-1)
 	if result.Type() != lua.LTTable {
-		return nil, fmt.Errorf("profile Lua must return a table")
+		return nil, nil, fmt.Errorf("profile Lua must return a table, or a function returning one")
 	}
 
 	// convert Lua table 2 Go map
 	profile := make(map[string]string)
+	var skipped []string
 	lTable := result.(*lua.LTable)
 	lTable.ForEach(func(k, v lua.LValue) {
 		if k.Type() == lua.LTString && v.Type() == lua.LTString {
 			profile[k.String()] = v.String()
+		} else {
+			skipped = append(skipped, k.String())
 		}
 	})
 
-	// validate required fields
-	requiredFields := []string{"author", "software", "created"}
-	for _, field := range requiredFields {
-		if _, ok := profile[field]; !ok {
-			return nil, fmt.Errorf("profile is missing required field: %s", field)
-		}
-	}
+	return profile, skipped, nil
+}
 
-	return profile, nil
+// "a.lua -> b.lua -> a.lua" using base names
+func formatProfileChain(chain []string) string {
+	names := make([]string, len(chain))
+	for i, path := range chain {
+		names[i] = filepath.Base(path)
+	}
+	return strings.Join(names, " -> ")
 }
 
 // fallback values if no profile is found
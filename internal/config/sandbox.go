@@ -0,0 +1,154 @@
+// BYZRA ⸻ internal/config/sandbox.go
+// restricted Lua state for running profiles
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// wrapped by every profile that reaches outside the sandbox or runs too long
+var ErrProfileSandbox = errors.New("profile broke the sandbox")
+
+// how long one profile file may run
+const profileTimeout = 2 * time.Second
+
+// os functions left to profiles: clocks and dates, nothing that touches the
+// system or the environment
+var safeOsFuncs = []string{"clock", "date", "difftime", "time"}
+
+// base functions that read files or load modules from disk
+var blockedGlobals = []string{"dofile", "loadfile", "require", "module"}
+
+// runs source in a state with only the base, table, string, math and
+// coroutine libraries, a bounded stack and profileTimeout; a chunk that
+// returns a function has it called with file. The returned state holds the
+// result on top of its stack and must be closed
+func runSandboxed(source string, file *ProfileFile) (*lua.LState, error) {
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   256,
+		RegistryMaxSize: 256 * 1024, // runaway recursion fails instead of growing without end
+	})
+
+	// the first blocked call, kept even if the profile catches the error
+	violation := ""
+	blocked := func(name string) *lua.LFunction {
+		return L.NewFunction(func(L *lua.LState) int {
+			message := name + " is not available to profiles"
+			if violation == "" {
+				// the profile line, past pcall and other Go frames
+				where := L.Where(1)
+				for level := 2; strings.HasPrefix(where, "[G]") && level < 8; level++ {
+					where = L.Where(level)
+				}
+				violation = where + " " + message
+			}
+			L.RaiseError("%s", message)
+			return 0
+		})
+	}
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+		{lua.CoroutineLibName, lua.OpenCoroutine},
+		{lua.OsLibName, lua.OpenOs}, // cut down below
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range blockedGlobals {
+		L.SetGlobal(name, blocked(name))
+	}
+	L.SetGlobal("_printregs", lua.LNil)
+
+	// os keeps its clocks; io is absent; any other field of either raises
+	full := L.GetGlobal(lua.OsLibName).(*lua.LTable)
+	safeOs := L.NewTable()
+	for _, name := range safeOsFuncs {
+		safeOs.RawSetString(name, full.RawGetString(name))
+	}
+	for lib, table := range map[string]*lua.LTable{lua.OsLibName: safeOs, lua.IoLibName: L.NewTable()} {
+		meta := L.NewTable()
+		meta.RawSetString("__index", L.NewFunction(func(L *lua.LState) int {
+			L.Push(blocked(lib + "." + L.Get(2).String()))
+			return 1
+		}))
+		L.SetMetatable(table, meta)
+		L.SetGlobal(lib, table)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), profileTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	err := L.DoString(source)
+	if fn, ok := L.Get(-1).(*lua.LFunction); ok && err == nil {
+		L.Pop(1)
+		err = L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, fileTable(L, file))
+	}
+	L.RemoveContext()
+	if err == nil && violation == "" {
+		return L, nil
+	}
+	L.Close()
+	switch {
+	case violation != "":
+		return nil, fmt.Errorf("%w: %s", ErrProfileSandbox, violation)
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%w: still running after %s", ErrProfileSandbox, profileTimeout)
+	}
+	return nil, fmt.Errorf("failed to execute profile Lua: %w", err)
+}
+
+// ╭─ FUNCTION PROFILES ─────────────────────────╮
+
+// the file a function profile is asked about
+type ProfileFile struct {
+	Path     string
+	Format   string            // "image", "audio", ...
+	MIMEType string            // "image/jpeg", ...
+	Metadata map[string]string // tags found before wiping
+}
+
+// file as the table a function profile receives: path, name, dir, ext,
+// format, mime and metadata; fields are empty strings (and metadata an
+// empty table) when file is nil, as for 'caligra config validate'
+func fileTable(L *lua.LState, file *ProfileFile) *lua.LTable {
+	if file == nil {
+		file = &ProfileFile{}
+	}
+	name, dir := "", ""
+	if file.Path != "" {
+		name, dir = filepath.Base(file.Path), filepath.Dir(file.Path)
+	}
+
+	table := L.NewTable()
+	table.RawSetString("path", lua.LString(file.Path))
+	table.RawSetString("name", lua.LString(name))
+	table.RawSetString("dir", lua.LString(dir))
+	table.RawSetString("ext", lua.LString(strings.ToLower(filepath.Ext(file.Path))))
+	table.RawSetString("format", lua.LString(file.Format))
+	table.RawSetString("mime", lua.LString(file.MIMEType))
+
+	metadata := L.NewTable()
+	for tag, value := range file.Metadata {
+		metadata.RawSetString(tag, lua.LString(value))
+	}
+	table.RawSetString("metadata", metadata)
+	return table
+}
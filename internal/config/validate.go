@@ -0,0 +1,485 @@
+// BYZRA ⸻ internal/config/validate.go
+// line-level checks of scroud.toml, yogra.toml and profiles, which otherwise
+// fall back to defaults without a word
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"caligra/internal/filter"
+	"caligra/internal/util"
+
+	"github.com/BurntSushi/toml"
+)
+
+// something wrong in a config file
+type Issue struct {
+	File    string
+	Line    int // 1-based, 0 when it is not on one line
+	Message string
+	Warning bool // the file loads, but likely not as meant
+}
+
+// "scroud.toml:12: message", like a compiler
+func (i Issue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.File, i.Message)
+}
+
+// names only callers can supply, since formats imports config
+type Known struct {
+	Extensions []string // with a handler, without the dot: "jpg", ...
+	Formats    []string // handler names: "image", "matroska", ...
+}
+
+// top-level MIME types [actions] may be keyed by, besides formats
+var mimeKinds = []string{"application", "audio", "font", "image", "model", "text", "video"}
+
+// checks a scroud.toml: syntax and types, unknown keys, watch and rule
+// paths, extensions, policies, time zones and the [throttle], [mqtt] and
+// [actions] values the daemon would refuse
+func ValidateDaemonConfig(path string, known Known) []Issue {
+	c, data := newChecker(path)
+	if data == nil {
+		return c.issues
+	}
+
+	var config DaemonConfig
+	md, err := toml.Decode(string(data), &config)
+	if err != nil {
+		c.decodeError(err)
+		return c.issues
+	}
+	c.unknownKeys(md)
+
+	// [watch]
+	for _, raw := range config.Watch.Paths {
+		if raw == "" || raw[0] == '#' {
+			continue // skipped by LoadDaemonConfig too
+		}
+		c.checkDir(c.lines.findValue("watch", -1, "paths", raw), "watch path", raw, false)
+	}
+	switch config.Watch.Mode {
+	case "", "inotify", "fanotify":
+	default:
+		c.errorf(c.lines.find("watch", -1, "mode"), "[watch] mode must be inotify or fanotify, not %q", config.Watch.Mode)
+	}
+	for dir, policy := range config.Watch.Policies {
+		line := c.lines.find("watch.policies", -1, dir)
+		c.checkPolicy(line, "[watch.policies]", policy)
+		c.checkDir(line, "policy directory", dir, true)
+	}
+
+	// [filter]
+	for _, ext := range config.Filter.Extensions {
+		line := c.lines.findValue("filter", -1, "extensions", ext)
+		switch {
+		case !strings.HasPrefix(ext, "."):
+			c.errorf(line, "[filter] extension %q never matches; write it with a dot: %q", ext, "."+ext)
+		case !slices.Contains(known.Extensions, strings.ToLower(ext[1:])):
+			c.warnf(line, "[filter] %s is not an extension caligra supports", ext)
+		}
+	}
+	c.fieldError(validateMaxSize(config.Filter.MaxSizeMB))
+	if _, err := filter.Parse(config.Filter.Expression); err != nil {
+		c.errorf(c.lines.find("filter", -1, "expression"), "[filter] expression: %v", err)
+	}
+
+	// [removable]
+	for _, pattern := range config.Removable.Patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			c.errorf(c.lines.findValue("removable", -1, "patterns", pattern), "[removable] bad pattern %q: %v", pattern, err)
+		}
+	}
+
+	// [wipe]
+	if tz := config.Wipe.Timezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			c.errorf(c.lines.find("wipe", -1, "timezone"), "[wipe] unknown time zone %q", tz)
+		}
+	}
+	if config.Wipe.Policy != "" {
+		c.checkPolicy(c.lines.find("wipe", -1, "policy"), "[wipe]", config.Wipe.Policy)
+	}
+	c.fieldError(validateCopyHash(config.Wipe.CopyHash))
+
+	// [[rules]]
+	for i, rule := range config.Rules {
+		header := c.lines.find("rules", i, "")
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if rule.Workflow != "" && rule.Workflow != "screenshot" {
+			c.errorf(c.lines.find("rules", i, "workflow"), "rule %s: unknown workflow %q (built-in: screenshot)", name, rule.Workflow)
+		}
+
+		if _, err := filter.Parse(rule.Filter); err != nil {
+			c.errorf(c.lines.find("rules", i, "filter"), "rule %s: %v", name, err)
+		}
+
+		written := len(rule.Paths)
+		rule.Paths = slices.Clone(rule.Paths)
+		rule.ApplyWorkflowDefaults()
+		if len(rule.Paths) == 0 {
+			c.errorf(header, "rule %s has no paths", name)
+		}
+		for j, dir := range rule.Paths {
+			line := header
+			if j < written {
+				line = c.lines.findValue("rules", i, "paths", config.Rules[i].Paths[j])
+			}
+			c.checkDir(line, "rule "+name+" path", dir, false)
+			if rule.MirrorTo != "" && filepath.Clean(rule.MirrorTo) == filepath.Clean(dir) {
+				c.errorf(c.lines.find("rules", i, "mirror_to"), "rule %s: mirror_to cannot be one of its paths", name)
+			}
+		}
+		if rule.MirrorTo != "" && config.Rules[i].MoveTo != "" {
+			c.errorf(c.lines.find("rules", i, "mirror_to"), "rule %s: mirror_to and move_to cannot be combined", name)
+		}
+		if err := rule.checkRename(); err != nil {
+			c.errorf(c.lines.find("rules", i, "rename"), "rule %s: %v", name, err)
+		}
+	}
+
+	// [throttle], [mqtt]
+	c.fieldError(config.Throttle.validate())
+	c.fieldError(config.MQTT.validate())
+	if file := config.MQTT.PasswordFile; config.MQTT.Broker != "" && file != "" {
+		if _, err := os.Stat(ExpandPath(file)); err != nil {
+			c.errorf(c.lines.find("mqtt", -1, "password_file"), "[mqtt] password_file cannot be read: %s", file)
+		}
+	}
+
+	// [actions.<format>]
+	for format, action := range config.Actions {
+		table := "actions." + format
+		if !slices.Contains(known.Formats, format) && !slices.Contains(mimeKinds, format) {
+			c.warnf(c.lines.find(table, -1, ""), "[%s] %q is neither a format (%s) nor a MIME type",
+				table, format, strings.Join(known.Formats, ", "))
+		}
+		c.fieldError(action.validate(format))
+		if action.Policy != "" {
+			c.checkPolicy(c.lines.find(table, -1, "policy"), "["+table+"]", action.Policy)
+		}
+	}
+
+	return c.sorted()
+}
+
+// checks a yogra.toml: syntax, unknown keys, the theme name and [colors]
+func ValidateThemeConfig(path string) []Issue {
+	c, data := newChecker(path)
+	if data == nil {
+		return c.issues
+	}
+
+	var config util.ThemeConfig
+	md, err := toml.Decode(string(data), &config)
+	if err != nil {
+		c.decodeError(err)
+		return c.issues
+	}
+	c.unknownKeys(md, "metadata") // the BYZRA Series header, not read by caligra
+
+	// keys written below [metadata] belong to it, not to the top level
+	for _, key := range md.Undecoded() {
+		if len(key) == 2 && key[0] == "metadata" && (key[1] == "theme" || key[1] == "clear_screen") {
+			c.errorf(c.lines.find("metadata", -1, key[1]), "%s is inside [metadata] and ignored; move it above the first table", key[1])
+		}
+	}
+
+	if _, ok := util.FindTheme(config.Name()); !ok {
+		names := make([]string, 0, len(util.Themes()))
+		for _, theme := range util.Themes() {
+			names = append(names, theme.Name)
+		}
+		c.errorf(c.lines.find("", -1, "theme"), "unknown theme %q (built-in: %s)", config.Theme, strings.Join(names, ", "))
+	}
+
+	colors := []struct{ role, value string }{
+		{"CHRM", config.Colors.CHRM}, {"HEAT", config.Colors.HEAT}, {"HOTP", config.Colors.HOTP},
+		{"GUNM", config.Colors.GUNM}, {"VBLK", config.Colors.VBLK}, {"CSTL", config.Colors.CSTL},
+	}
+	for _, color := range colors {
+		if color.value != "" && !isColor(color.value) {
+			c.errorf(c.lines.find("colors", -1, color.role), "[colors] %s: %q is not a color; use #RRGGBB, #RGB or an ANSI number 0-255",
+				color.role, color.value)
+		}
+	}
+
+	return c.sorted()
+}
+
+// checks a profile: that it runs, returns a table of strings, resolves its
+// extends and sets every required field
+func ValidateProfile(path string) []Issue {
+	c, data := newChecker(path)
+	if data == nil {
+		return c.issues
+	}
+
+	_, skipped, err := readProfileFile(path, nil)
+	if err != nil {
+		line, message := luaError(err)
+		c.errorf(line, "%s", message)
+		return c.issues
+	}
+	for _, key := range skipped {
+		c.warnf(luaKeyLine(data, key), "%s is ignored: profile values must be strings", key)
+	}
+
+	profile, err := loadProfileChain(path, nil, nil)
+	if err != nil {
+		c.errorf(luaKeyLine(data, "extends"), "%v", err)
+		return c.sorted()
+	}
+	for _, field := range requiredProfileFields {
+		if _, ok := profile[field]; !ok {
+			c.errorf(0, "missing required field: %s", field)
+		}
+	}
+
+	return c.sorted()
+}
+
+// ╭─ CHECKER ───────────────────────────────────╮
+
+// collects the issues of one file
+type checker struct {
+	file   string
+	lines  tomlLines
+	issues []Issue
+}
+
+// reads path; data is nil when it cannot be read, with the issue recorded
+func newChecker(path string) (*checker, []byte) {
+	c := &checker{file: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.errorf(0, "%v", err)
+		return c, nil
+	}
+	c.lines = strings.Split(string(data), "\n")
+	return c, data
+}
+
+func (c *checker) errorf(line int, format string, args ...any) {
+	c.issues = append(c.issues, Issue{File: c.file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) warnf(line int, format string, args ...any) {
+	c.issues = append(c.issues, Issue{File: c.file, Line: line, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+// in file order; map iteration left them shuffled
+func (c *checker) sorted() []Issue {
+	sort.SliceStable(c.issues, func(i, j int) bool { return c.issues[i].Line < c.issues[j].Line })
+	return c.issues
+}
+
+// "toml: line 2 (last key \"watch.paths\"): incompatible types: ..."
+var tomlLineRe = regexp.MustCompile(`^toml: line (\d+)(?: \(last key "[^"]*"\))?: (.*)$`)
+
+// a syntax or type error, at the line the decoder names
+func (c *checker) decodeError(err error) {
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		c.errorf(parseErr.Position.Line, "%s", parseErr.Message)
+		return
+	}
+	if m := tomlLineRe.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		c.errorf(line, "%s", m[2])
+		return
+	}
+	c.errorf(0, "%v", err)
+}
+
+// keys the config structs have no field for: typos, or options from
+// another version; keys under skip tables are allowed
+func (c *checker) unknownKeys(md toml.MetaData, skip ...string) {
+	var reported []string
+	for _, key := range md.Undecoded() {
+		if slices.Contains(skip, key[0]) {
+			continue
+		}
+		// a whole unknown table is one issue, not one per key
+		if slices.ContainsFunc(reported, func(table string) bool { return strings.HasPrefix(key.String(), table+".") }) {
+			continue
+		}
+		reported = append(reported, key.String())
+		table, name := strings.Join(key[:len(key)-1], "."), key[len(key)-1]
+		c.errorf(c.lines.find(table, -1, name), "unknown key %s", key)
+	}
+}
+
+// a fieldError from a validate method, at its key's line
+func (c *checker) fieldError(err error) {
+	if err == nil {
+		return
+	}
+	var field *fieldError
+	if errors.As(err, &field) {
+		c.errorf(c.lines.find(field.table, -1, keyName(field.key)), "%v", err)
+		return
+	}
+	c.errorf(0, "%v", err)
+}
+
+func (c *checker) checkDir(line int, what, raw string, warning bool) {
+	report := c.errorf
+	if warning {
+		report = c.warnf
+	}
+	dir := ExpandPath(raw)
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		report(line, "%s does not exist: %s", what, dir)
+	case err != nil:
+		report(line, "%s cannot be read: %v", what, err)
+	case !info.IsDir():
+		report(line, "%s is not a directory: %s", what, dir)
+	}
+}
+
+func (c *checker) checkPolicy(line int, where, name string) {
+	if _, err := LoadPolicy(name); err != nil {
+		c.errorf(line, "%s %v", where, err)
+	}
+}
+
+// "#RRGGBB", "#RGB" or an ANSI color number
+func isColor(value string) bool {
+	if n, err := strconv.Atoi(value); err == nil {
+		return n >= 0 && n <= 255
+	}
+	if !strings.HasPrefix(value, "#") || (len(value) != 4 && len(value) != 7) {
+		return false
+	}
+	_, err := strconv.ParseUint(value[1:], 16, 32)
+	return err == nil
+}
+
+// ╭─ LINES ─────────────────────────────────────╮
+
+// a TOML file's lines, for pointing issues at where a key or value sits
+type tomlLines []string
+
+// 1-based line of key in table ("" for the top level), or of a header:
+// table's when key is "", a subtable's when key names one; nth picks an
+// element of an array table, -1 searches them all; 0 when not found
+func (t tomlLines) find(table string, nth int, key string) int {
+	current, element := "", -1
+	for i, line := range t {
+		if name, ok := tableHeader(line); ok {
+			current = name
+			if name == table {
+				element++
+				if key == "" && (nth < 0 || element == nth) {
+					return i + 1
+				}
+			}
+			if key != "" && name == joinKey(table, key) {
+				return i + 1
+			}
+			continue
+		}
+		if key == "" || current != table || (nth >= 0 && element != nth) {
+			continue
+		}
+		if k, _ := splitKey(line); k != "" && keyName(k) == key {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// line of one entry of an array value, the key's line when the entry is
+// not found on a line of its own
+func (t tomlLines) findValue(table string, nth int, key, value string) int {
+	start := t.find(table, nth, key)
+	if start == 0 {
+		return 0
+	}
+	quoted := []string{strconv.Quote(value), "'" + value + "'"}
+	for i := start - 1; i < len(t); i++ {
+		code := stripComment(t[i])
+		for _, q := range quoted {
+			if strings.Contains(code, q) {
+				return i + 1
+			}
+		}
+		if strings.Contains(code, "]") {
+			break
+		}
+	}
+	return start
+}
+
+// "[a.b]" or "[[a]]" -> "a.b", "a"
+func tableHeader(line string) (string, bool) {
+	trimmed := strings.TrimSpace(stripComment(line))
+	if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+		return "", false
+	}
+	name := strings.Trim(trimmed, "[]")
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = keyName(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, "."), true
+}
+
+// a key as written, without its quotes
+func keyName(key string) string {
+	if name, err := unquote(key); err == nil {
+		return name
+	}
+	return key
+}
+
+func joinKey(table, key string) string {
+	if table == "" {
+		return key
+	}
+	return table + "." + key
+}
+
+// "<string> line:3(column:14) near ','" and "<string>:3: attempt to ..."
+var luaLineRe = regexp.MustCompile(`<string>(?: line:(\d+)\(column:\d+\)|:(\d+):)\s*(.*)`)
+
+// the line and message of a profile Lua error, without the traceback
+func luaError(err error) (int, string) {
+	first, _, _ := strings.Cut(err.Error(), "\n")
+	m := luaLineRe.FindStringSubmatch(first)
+	if m == nil {
+		return 0, first
+	}
+	line, _ := strconv.Atoi(m[1] + m[2])
+	return line, strings.Join(strings.Fields(m[3]), " ")
+}
+
+// first line assigning key in a Lua table, 0 when there is none
+func luaKeyLine(data []byte, key string) int {
+	re := regexp.MustCompile(`^\s*(?:` + regexp.QuoteMeta(key) + `|\[["']` + regexp.QuoteMeta(key) + `["']\])\s*=`)
+	for i, line := range strings.Split(string(data), "\n") {
+		if re.MatchString(line) {
+			return i + 1
+		}
+	}
+	return 0
+}
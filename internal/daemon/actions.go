@@ -0,0 +1,59 @@
+// BYZRA ⸻ internal/daemon/actions.go
+// per-format daemon actions: wipe, analyse only, or ignore
+
+package daemon
+
+import (
+	"fmt"
+	"strings"
+
+	"caligra/internal/analyse"
+	"caligra/internal/config"
+)
+
+// the configured action for a file; formats without one are wiped
+func (d *Daemon) actionFor(path string) config.FormatAction {
+	fileType, err := analyse.DetectFile(path)
+	if err != nil {
+		return config.FormatAction{Action: config.ActionWipe}
+	}
+
+	if action, ok := d.config.ActionFor(fileType.Format, fileType.MimeType); ok {
+		return action
+	}
+	return config.FormatAction{Action: config.ActionWipe}
+}
+
+// most specific policy wins: the file's watch directory, then its
+// format action, then [wipe] policy (a .caligra.toml beats all three)
+func (d *Daemon) wipePolicy(path string, action config.FormatAction) *config.Policy {
+	if policy := d.dirPolicy(path); policy != nil {
+		return policy
+	}
+	if policy, ok := d.actionPolicies[action.Policy]; ok {
+		return policy
+	}
+	return d.policy
+}
+
+// loads the policies named by [actions.*]
+func loadActionPolicies(actions map[string]config.FormatAction) (map[string]*config.Policy, error) {
+	policies := make(map[string]*config.Policy)
+	for format, action := range actions {
+		if action.Policy == "" || policies[action.Policy] != nil {
+			continue
+		}
+		policy, err := config.LoadPolicy(action.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy for [actions.%s]: %w", format, err)
+		}
+		policies[action.Policy] = policy
+	}
+	return policies, nil
+}
+
+// logs what an analyse-only format would have had wiped
+func (d *Daemon) reportFindings(path string, fields []string) {
+	d.logger.Warning(fmt.Sprintf("[!] %d sensitive fields in %s, not wiped (analyse only): %s",
+		len(fields), path, strings.Join(fields, ", ")))
+}
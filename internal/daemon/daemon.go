@@ -5,21 +5,54 @@ package daemon
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"caligra/internal/analyse"
 	"caligra/internal/config"
+	"caligra/internal/filter"
+	"caligra/internal/util"
 	"caligra/internal/wipe"
 )
 
 // background service that monitors files
 type Daemon struct {
-	config  *config.DaemonConfig
-	logger  *Logger
-	watcher *Watcher
-	running bool
+	config    *config.DaemonConfig
+	logger    *Logger
+	watcher   Monitor
+	mounts    *MountWatcher
+	stats     *Stats
+	running   bool
+	startTime time.Time
+	timezone  *time.Location // [wipe] timezone, nil when unset
+	policy    *config.Policy // [wipe] policy, nil wipes everything
+	ipc       net.Listener   // control socket, nil when unavailable
+	events    *eventHub      // subscribers of the control socket's event stream
+	roots     *rootSet       // watched top-level directories, for naming events
+	mqtt      *mqttPublisher // nil without an [mqtt] broker
+
+	// policies named by [actions.*], by name
+	actionPolicies map[string]*config.Policy
+
+	// [filter] expression, and each rule's filter by rule index
+	filter      *filter.Filter
+	ruleFilters []*filter.Filter
+
+	// [watch.policies] plus directories added at runtime
+	dirPolicies     map[string]*config.Policy
+	dirPoliciesLock sync.RWMutex
+
+	// .caligra.toml overrides found above watched files
+	locals *config.LocalConfigs
+
+	// counters for the current run
+	processed atomic.Int64
+	errors    atomic.Int64
 }
 
 // current state of the daemon
@@ -32,6 +65,14 @@ type DaemonStatus struct {
 	StartTime      time.Time
 }
 
+// name of the installed service (and its Windows event log source)
+const ServiceName = "caligra"
+
+// file holding the pid of the running daemon
+func PIDPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra", "daemon.pid")
+}
+
 // new daemon instance
 func NewDaemon(configPath string) (*Daemon, error) {
 	cfg, err := config.LoadDaemonConfig()
@@ -39,19 +80,70 @@ func NewDaemon(configPath string) (*Daemon, error) {
 		cfg = config.GetDefaultConfig()
 	}
 
-	logDir := filepath.Join(os.Getenv("HOME"), ".caligra/logs")
-	if err := os.MkdirAll(logDir, 0755); err != nil {
+	logPath := LogPath()
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
-	logger, err := NewLogger(filepath.Join(logDir, "caligra-daemon.log"), LevelInfo)
+	logger, err := NewLogger(logPath, LevelInfo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize logger: %w", err)
 	}
 
+	stats, err := LoadStats(StatsPath())
+	if err != nil {
+		logger.Warning(fmt.Sprintf("[!] Could not load stats, starting fresh: %v", err))
+		stats = &Stats{path: StatsPath(), Days: make(map[string]*DayStats)}
+	}
+
+	analyse.SetExtensionFallback(cfg.ExtensionFallback())
+	util.SetMaxFileSize(cfg.MaxFileSize())
+	util.SetCopyHash(cfg.Wipe.CopyHash) // checked by LoadDaemonConfig
+
 	daemon := &Daemon{
 		config: cfg,
 		logger: logger,
+		stats:  stats,
+		events: newEventHub(),
+		locals: config.NewLocalConfigs(),
+	}
+
+	if cfg.Wipe.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Wipe.Timezone)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("[!] Unknown timezone %q, timestamps left as-is", cfg.Wipe.Timezone))
+		} else {
+			daemon.timezone = loc
+		}
+	}
+
+	if cfg.Wipe.Policy != "" {
+		policy, err := config.LoadPolicy(cfg.Wipe.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid [wipe] policy: %w", err)
+		}
+		daemon.policy = policy
+	}
+
+	daemon.actionPolicies, err = loadActionPolicies(cfg.Actions)
+	if err != nil {
+		return nil, err
+	}
+
+	// checked by LoadDaemonConfig
+	daemon.filter, _ = filter.Parse(cfg.Filter.Expression)
+	for _, rule := range cfg.Rules {
+		f, _ := filter.Parse(rule.Filter)
+		daemon.ruleFilters = append(daemon.ruleFilters, f)
+	}
+
+	daemon.dirPolicies = make(map[string]*config.Policy)
+	for dir, name := range cfg.Watch.Policies {
+		policy, err := config.LoadPolicy(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy for %s: %w", dir, err)
+		}
+		daemon.dirPolicies[dir] = policy
 	}
 
 	return daemon, nil
@@ -64,24 +156,85 @@ func (d *Daemon) Start() error {
 
 	d.logger.Info("Starting daemon")
 
+	// wipes run unattended; their results go to the log instead
+	util.SetReporter(util.Silent)
+
+	d.applyThrottle()
+
 	options := WatchOptions{
 		Extensions:  d.config.Filter.Extensions,
-		ExcludeDirs: []string{".git", "node_modules", ".venv"},
+		ExcludeDirs: append([]string{".git", "node_modules", ".venv"}, macOSMetadataDirs...),
 		MinFileAge:  2 * time.Second,
+		Filter:      d.filter,
 		Recursive:   true,
+		AllowEmpty:  d.config.Removable.Enabled,
+	}
+
+	// rule outputs must not be picked up again
+	for _, rule := range d.config.Rules {
+		if out := rule.OutputDir(); out != "" {
+			options.ExcludeDirs = append(options.ExcludeDirs, out)
+		}
 	}
 
 	fileHandler := func(path string) error {
+		// .caligra.toml files above the watched directory do not apply
+		root := d.roots.holding(path)
+		if root == "" {
+			root = filepath.Dir(path)
+		}
+		local, err := d.locals.For(root, path)
+		if err != nil {
+			d.logger.Warning(fmt.Sprintf("[!] Skipping %s: %v", path, err))
+			d.emit(Event{Type: EventError, Path: path, Message: err.Error()})
+			d.recordError()
+			return err
+		}
+		if local.Ignored(path, false) {
+			d.logger.Debug(fmt.Sprintf("Ignoring %s (%s)", path, config.LocalConfigName))
+			return nil
+		}
+
+		if rule := d.ruleFor(path); rule != nil {
+			d.emit(Event{Type: EventFileDetected, Path: path, Rule: rule.Name})
+			err := d.applyRule(rule, path)
+			if err != nil {
+				d.emit(Event{Type: EventError, Path: path, Rule: rule.Name, Message: err.Error()})
+			}
+			return err
+		}
+
+		action := d.actionFor(path)
+		if action.Action == config.ActionIgnore {
+			d.logger.Debug(fmt.Sprintf("Ignoring %s (format action)", path))
+			return nil
+		}
+		d.emit(Event{Type: EventFileDetected, Path: path})
+
 		// analyze file
 		report, err := analyse.Analyze(path)
 		if err != nil {
 			d.logger.Warning(fmt.Sprintf("[!] Analysis failed for %s: %v", path, err))
+			d.emit(Event{Type: EventError, Path: path, Message: err.Error()})
+			d.recordError()
 			return err
 		}
 
+		d.processed.Add(1)
+		if err := d.stats.RecordScan(); err != nil {
+			d.logger.Warning(fmt.Sprintf("[!] Could not save stats: %v", err))
+		}
+
 		// no sensitive metadata = no need to wipe
 		if len(report.SensitiveFields) == 0 {
 			d.logger.Debug(fmt.Sprintf("No sensitive metadata in %s, skipping", path))
+			d.emit(Event{Type: EventFileClean, Path: path})
+			return nil
+		}
+
+		if action.Action == config.ActionAnalyse {
+			d.reportFindings(path, report.SensitiveFields)
+			d.emit(Event{Type: EventFileAnalysed, Path: path, SensitiveFields: report.SensitiveFields})
 			return nil
 		}
 
@@ -92,19 +245,31 @@ func (d *Daemon) Start() error {
 		// wiping options
 		wipeOptions := &wipe.WipeOptions{
 			InjectProfile: true,
-			CustomProfile: nil, // default profile
-			CreateCopy:    true,
+			ProfileName:   local.Profile, // "" for the default profile
+			CreateCopy:    !action.InPlace,
 			KeepBackup:    true,
 			SecureDelete:  false,
+			Timezone:      d.timezone,
+			Policy:        d.wipePolicy(path, action),
+			Audit:         "daemon",
+		}
+		if local.Policy != nil {
+			wipeOptions.Policy = local.Policy
 		}
 
 		// perform wipe
 		result, err := wipe.WipeFile(path, wipeOptions)
 		if err != nil {
 			d.logger.Error(fmt.Sprintf("[X] Wipe failed for %s: %v", path, err))
+			d.emit(Event{Type: EventError, Path: path, Message: err.Error()})
+			d.recordError()
 			return err
 		}
 
+		if err := d.stats.RecordWipe(report.SensitiveFields); err != nil {
+			d.logger.Warning(fmt.Sprintf("[!] Could not save stats: %v", err))
+		}
+
 		if result.Success {
 			d.logger.Info(fmt.Sprintf("Successfully processed %s → %s",
 				path, result.OutputPath))
@@ -112,29 +277,121 @@ func (d *Daemon) Start() error {
 			d.logger.Warning(fmt.Sprintf("[!] Wipe completed with issues for %s: %v",
 				path, result.WipeErrors))
 		}
+		output := result.OutputPath
+		if output == "" {
+			output = path
+		}
+		d.emit(Event{Type: EventFileWiped, Path: path, Output: output,
+			SensitiveFields: report.SensitiveFields, Issues: result.WipeErrors})
 
 		return nil
 	}
 
 	// create and start watcher
-	watcher, err := NewWatcher(d.config.Watch.Paths, options, fileHandler, d.logger)
+	handler := limitConcurrency(fileHandler, d.config.Throttle.MaxConcurrent)
+	d.roots = &rootSet{dirs: d.watchPaths()}
+	monitor, err := d.newMonitor(d.watchPaths(), options, handler)
 	if err != nil {
 		d.logger.Error(fmt.Sprintf("[X] Failed to create watcher: %v", err))
 		return fmt.Errorf("failed to create watcher: %w", err)
 	}
 
+	watcher := &rootMonitor{Monitor: monitor, roots: d.roots}
 	if err := watcher.Start(); err != nil {
 		d.logger.Error(fmt.Sprintf("[X] Failed to start watcher: %v", err))
 		return fmt.Errorf("failed to start watcher: %w", err)
 	}
 
 	d.watcher = watcher
+
+	if d.config.Removable.Enabled {
+		mounts := NewMountWatcher(d.config.Removable.Patterns, watcher, d.logger)
+		if err := mounts.Start(); err != nil {
+			d.logger.Warning(fmt.Sprintf("[!] Removable media watch unavailable: %v", err))
+		} else {
+			d.mounts = mounts
+		}
+	}
+
+	if err := d.startIPC(); err != nil {
+		d.logger.Warning(fmt.Sprintf("[!] Control socket unavailable, add-dir/remove-dir need a restart: %v", err))
+	}
+
+	if d.config.MQTT.Broker != "" {
+		publisher, err := newMQTTPublisher(d.config.MQTT, d.events, d.logger)
+		if err != nil {
+			d.logger.Warning(fmt.Sprintf("[!] MQTT publishing disabled: %v", err))
+		} else {
+			d.mqtt = publisher
+			publisher.Start()
+		}
+	}
+
 	d.running = true
+	d.startTime = time.Now()
 	d.logger.Info("Daemon started successfully")
 
 	return nil
 }
 
+// policy of the most specific watched directory holding path, nil when none has one
+func (d *Daemon) dirPolicy(path string) *config.Policy {
+	d.dirPoliciesLock.RLock()
+	defer d.dirPoliciesLock.RUnlock()
+
+	var best *config.Policy
+	bestLen := -1
+	for dir, policy := range d.dirPolicies {
+		if isUnder(path, dir) && len(dir) > bestLen {
+			best = policy
+			bestLen = len(dir)
+		}
+	}
+	return best
+}
+
+// sets or (with nil) clears a directory's policy
+func (d *Daemon) setDirPolicy(dir string, policy *config.Policy) {
+	d.dirPoliciesLock.Lock()
+	defer d.dirPoliciesLock.Unlock()
+
+	if policy == nil {
+		delete(d.dirPolicies, dir)
+	} else {
+		d.dirPolicies[dir] = policy
+	}
+}
+
+// picks the monitor backend from config, falling back to inotify
+func (d *Daemon) newMonitor(paths []string, options WatchOptions, handler FileHandler) (Monitor, error) {
+	if d.config.Watch.Mode == "fanotify" {
+		monitor, err := NewFanotifyMonitor(paths, options, handler, d.logger)
+		if err == nil {
+			return monitor, nil
+		}
+		d.logger.Warning(fmt.Sprintf("[!] Fanotify unavailable, using per-directory watches: %v", err))
+	}
+
+	return NewWatcher(paths, options, handler, d.logger)
+}
+
+// configured watch paths plus the paths covered by rules
+func (d *Daemon) watchPaths() []string {
+	paths := slices.Clone(d.config.Watch.Paths)
+	for _, rule := range d.config.Rules {
+		for _, path := range rule.Paths {
+			if out := rule.OutputDir(); out != "" {
+				// make sure the clean folder exists so it can be excluded
+				os.MkdirAll(out, 0755)
+			}
+			if !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
 // halts the daemon
 func (d *Daemon) Stop() error {
 	if !d.running {
@@ -143,6 +400,20 @@ func (d *Daemon) Stop() error {
 
 	d.logger.Info("Stopping daemon")
 
+	if d.ipc != nil {
+		d.ipc.Close()
+		os.Remove(SocketPath())
+	}
+	if d.mqtt != nil {
+		d.mqtt.Stop()
+	}
+	d.events.close()
+
+	// detach removable media before the watcher goes away
+	if d.mounts != nil {
+		d.mounts.Stop()
+	}
+
 	// stop watcher
 	if d.watcher != nil {
 		if err := d.watcher.Stop(); err != nil {
@@ -150,7 +421,11 @@ func (d *Daemon) Stop() error {
 		}
 	}
 
-	// close logger
+	// flush pending entries, then close logger
+	if err := d.logger.Sync(); err != nil {
+		return fmt.Errorf("error flushing logger: %w", err)
+	}
+
 	if err := d.logger.Close(); err != nil {
 		return fmt.Errorf("error closing logger: %w", err)
 	}
@@ -168,9 +443,20 @@ func (d *Daemon) Status() *DaemonStatus {
 	}
 
 	return &DaemonStatus{
-		Running:     true,
-		WatchedDirs: d.config.Watch.Paths,
-		FileTypes:   d.config.Filter.Extensions,
+		Running:        true,
+		WatchedDirs:    d.config.Watch.Paths,
+		FileTypes:      d.config.Filter.Extensions,
+		ProcessedFiles: int(d.processed.Load()),
+		ErrorCount:     int(d.errors.Load()),
+		StartTime:      d.startTime,
+	}
+}
+
+// counts a failure in both the run and persistent counters
+func (d *Daemon) recordError() {
+	d.errors.Add(1)
+	if err := d.stats.RecordError(); err != nil {
+		d.logger.Warning(fmt.Sprintf("[!] Could not save stats: %v", err))
 	}
 }
 
@@ -0,0 +1,250 @@
+// BYZRA ⸻ internal/daemon/events.go
+// live stream of daemon activity over the control socket
+
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// event types
+const (
+	EventFileDetected = "file-detected" // picked up by a watch, about to be analysed
+	EventFileClean    = "file-clean"    // no sensitive metadata, left alone
+	EventFileAnalysed = "file-analysed" // sensitive fields found, not wiped (analyse only)
+	EventFileWiped    = "file-wiped"    // wiped, possibly with issues
+	EventError        = "error"         // analysis, wipe or rule failed
+	EventOverflow     = "overflow"      // the subscriber fell behind; the stream ends
+)
+
+// events a subscriber may fall behind by before it is cut off
+const eventBuffer = 256
+
+// one thing the daemon did
+type Event struct {
+	Time            time.Time `json:"time"`
+	Type            string    `json:"type"`
+	Path            string    `json:"path,omitempty"`
+	Dir             string    `json:"dir,omitempty"`    // the watched directory holding Path
+	Output          string    `json:"output,omitempty"` // where the wiped file ended up
+	Rule            string    `json:"rule,omitempty"`
+	SensitiveFields []string  `json:"sensitive_fields,omitempty"`
+	Issues          []string  `json:"issues,omitempty"` // wiped, but not cleanly
+	Message         string    `json:"message,omitempty"`
+}
+
+type subscriber struct {
+	events  chan Event
+	dropped bool // closed for falling behind, not by the daemon stopping
+}
+
+// fans events out to every subscriber without ever blocking the daemon
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[*subscriber]struct{})}
+}
+
+func (h *eventHub) subscribe() *subscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub := &subscriber{events: make(chan Event, eventBuffer)}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (h *eventHub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// a subscriber whose buffer is full is dropped rather than waited for
+func (h *eventHub) publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped = true
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// ends every stream, for shutdown
+func (h *eventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// stamps and publishes an event
+func (d *Daemon) emit(event Event) {
+	event.Time = time.Now().UTC()
+	if event.Path != "" && d.roots != nil {
+		event.Dir = d.roots.holding(event.Path)
+	}
+	d.events.publish(event)
+}
+
+// top-level watch directories, including ones added at runtime and mounted
+// media
+type rootSet struct {
+	mu   sync.RWMutex
+	dirs []string
+}
+
+// the most specific directory holding path, "" when none does
+func (r *rootSet) holding(path string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	best := ""
+	for _, dir := range r.dirs {
+		if isUnder(path, dir) && len(dir) > len(best) {
+			best = dir
+		}
+	}
+	return best
+}
+
+// keeps a rootSet in step with the directories a monitor watches
+type rootMonitor struct {
+	Monitor
+	roots *rootSet
+}
+
+func (m *rootMonitor) AddDir(dir string) error {
+	if err := m.Monitor.AddDir(dir); err != nil {
+		return err
+	}
+	m.roots.mu.Lock()
+	defer m.roots.mu.Unlock()
+	if !slices.Contains(m.roots.dirs, dir) {
+		m.roots.dirs = append(m.roots.dirs, dir)
+	}
+	return nil
+}
+
+func (m *rootMonitor) RemoveDir(dir string) error {
+	if err := m.Monitor.RemoveDir(dir); err != nil {
+		return err
+	}
+	m.roots.mu.Lock()
+	defer m.roots.mu.Unlock()
+	m.roots.dirs = slices.DeleteFunc(m.roots.dirs, func(d string) bool { return d == dir })
+	return nil
+}
+
+// writes events to conn, one JSON object per line, until the client hangs
+// up or the daemon stops
+func (d *Daemon) streamEvents(conn net.Conn) {
+	sub := d.events.subscribe()
+	defer d.events.unsubscribe(sub)
+
+	// nothing more is read; EOF means the client is gone
+	gone := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+
+	encoder := json.NewEncoder(conn)
+	for {
+		select {
+		case <-gone:
+			return
+		case event, ok := <-sub.events:
+			if !ok {
+				if sub.dropped {
+					encoder.Encode(Event{Time: time.Now().UTC(), Type: EventOverflow,
+						Message: fmt.Sprintf("more than %d events behind, stream closed", eventBuffer)})
+				}
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ╭─ CLIENT ────────────────────────────────────╮
+
+// an open event stream from the running daemon
+type Subscription struct {
+	conn    net.Conn
+	decoder *json.Decoder
+	stop    func() bool
+}
+
+// ErrStreamClosed is returned by Next when the daemon ends the stream
+var ErrStreamClosed = errors.New("daemon closed the event stream")
+
+// connects to the running daemon's event stream; cancelling ctx closes it
+func Subscribe(ctx context.Context) (*Subscription, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(), 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("daemon is not reachable: %w", err)
+	}
+
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err := json.NewEncoder(conn).Encode(IPCRequest{Command: CommandEvents}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	decoder := json.NewDecoder(conn)
+	var response IPCResponse
+	if err := decoder.Decode(&response); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if !response.OK {
+		conn.Close()
+		return nil, fmt.Errorf("%s", response.Error)
+	}
+	conn.SetDeadline(time.Time{})
+
+	return &Subscription{
+		conn:    conn,
+		decoder: decoder,
+		stop:    context.AfterFunc(ctx, func() { conn.Close() }),
+	}, nil
+}
+
+// blocks until the next event
+func (s *Subscription) Next() (Event, error) {
+	var event Event
+	if err := s.decoder.Decode(&event); err != nil {
+		if errors.Is(err, io.EOF) {
+			return event, ErrStreamClosed
+		}
+		return event, err
+	}
+	return event, nil
+}
+
+func (s *Subscription) Close() error {
+	s.stop()
+	return s.conn.Close()
+}
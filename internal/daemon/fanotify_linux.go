@@ -0,0 +1,293 @@
+// BYZRA ⸻ internal/daemon/fanotify_linux.go
+// mount-wide monitoring via fanotify filesystem marks
+
+//go:build linux
+
+package daemon
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// capability bit required by fanotify_init
+const capSysAdmin = 21
+
+// size of struct fanotify_event_metadata
+const fanotifyMetadataSize = 24
+
+// watches whole filesystems instead of individual directories
+// one mark covers every directory, so deep trees don't exhaust inotify watches
+type FanotifyMonitor struct {
+	fd      int
+	dirs    []string
+	dirsMu  sync.Mutex
+	gate    *Watcher // shared filtering and dispatch
+	logger  *Logger
+	done    chan struct{}
+	running bool
+}
+
+// new fanotify monitor; fails if the process lacks CAP_SYS_ADMIN
+func NewFanotifyMonitor(dirs []string, options WatchOptions, handler FileHandler, logger *Logger) (*FanotifyMonitor, error) {
+	ok, err := hasCapability(capSysAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check capabilities: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("fanotify requires CAP_SYS_ADMIN (run as root or grant the capability)")
+	}
+
+	var validDirs []string
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Skipping invalid directory %s: %v", dir, err))
+			continue
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			logger.Warning(fmt.Sprintf("Skipping non-directory path %s", dir))
+			continue
+		}
+		validDirs = append(validDirs, abs)
+	}
+
+	if len(validDirs) == 0 && !options.AllowEmpty {
+		return nil, fmt.Errorf("no valid directories to watch")
+	}
+
+	fd, err := unix.FanotifyInit(unix.FAN_CLASS_NOTIF|unix.FAN_CLOEXEC|unix.FAN_NONBLOCK,
+		unix.O_RDONLY|unix.O_LARGEFILE)
+	if err != nil {
+		return nil, fmt.Errorf("fanotify_init failed: %w", err)
+	}
+
+	return &FanotifyMonitor{
+		fd:   fd,
+		dirs: validDirs,
+		gate: &Watcher{
+			options:   options,
+			handler:   handler,
+			logger:    logger,
+			processed: make(map[string]time.Time),
+			pending:   make(map[string]bool),
+		},
+		logger: logger,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// marks the filesystems holding each directory and starts reading events
+func (m *FanotifyMonitor) Start() error {
+	if m.running {
+		return fmt.Errorf("monitor already running")
+	}
+
+	marked := 0
+	m.dirsMu.Lock()
+	for _, dir := range m.dirs {
+		if err := m.mark(dir); err != nil {
+			m.logger.Warning(err.Error())
+			continue
+		}
+		marked++
+	}
+	m.dirsMu.Unlock()
+
+	if marked == 0 && !m.gate.options.AllowEmpty {
+		unix.Close(m.fd)
+		return fmt.Errorf("no filesystems could be marked")
+	}
+
+	m.running = true
+	m.gate.running.Store(true)
+
+	go m.readEvents()
+	go m.gate.periodicCleanup()
+	m.logger.Info("Fanotify monitor started")
+
+	return nil
+}
+
+// terminates the monitor
+func (m *FanotifyMonitor) Stop() error {
+	if !m.running {
+		return nil
+	}
+
+	close(m.done)
+	m.running = false
+	m.gate.running.Store(false)
+	m.logger.Info("Fanotify monitor stopped")
+
+	return nil
+}
+
+// adds a filesystem mark for the filesystem holding dir
+func (m *FanotifyMonitor) mark(dir string) error {
+	err := unix.FanotifyMark(m.fd, unix.FAN_MARK_ADD|unix.FAN_MARK_FILESYSTEM,
+		unix.FAN_CLOSE_WRITE, unix.AT_FDCWD, dir)
+	if err != nil {
+		return fmt.Errorf("failed to mark filesystem of %s: %w", dir, err)
+	}
+	m.logger.Debug(fmt.Sprintf("Watching filesystem containing: %s", dir))
+	return nil
+}
+
+// starts handling files under another directory
+func (m *FanotifyMonitor) AddDir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("cannot watch %s: %w", dir, err)
+	}
+
+	m.dirsMu.Lock()
+	defer m.dirsMu.Unlock()
+
+	if slices.Contains(m.dirs, abs) {
+		return nil
+	}
+
+	// marks are per filesystem, so adding an existing one is harmless
+	if m.running {
+		if err := m.mark(abs); err != nil {
+			return err
+		}
+	}
+
+	m.dirs = append(m.dirs, abs)
+	m.logger.Info(fmt.Sprintf("Added watch directory: %s", abs))
+	return nil
+}
+
+// stops handling files under a directory
+// the filesystem mark stays, since other directories may share it
+func (m *FanotifyMonitor) RemoveDir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	m.dirsMu.Lock()
+	defer m.dirsMu.Unlock()
+
+	idx := slices.Index(m.dirs, abs)
+	if idx < 0 {
+		return fmt.Errorf("directory is not watched: %s", dir)
+	}
+	m.dirs = slices.Delete(m.dirs, idx, idx+1)
+	m.logger.Info(fmt.Sprintf("Removed watch directory: %s", abs))
+	return nil
+}
+
+// polls the fanotify descriptor until stopped
+func (m *FanotifyMonitor) readEvents() {
+	defer unix.Close(m.fd)
+
+	buf := make([]byte, 4096)
+	fds := []unix.PollFd{{Fd: int32(m.fd), Events: unix.POLLIN}}
+
+	for {
+		select {
+		case <-m.done:
+			return
+		default:
+		}
+
+		n, err := unix.Poll(fds, 500)
+		if err != nil && err != unix.EINTR {
+			m.logger.Error(fmt.Sprintf("[X] Fanotify poll error: %v", err))
+			return
+		}
+		if n <= 0 {
+			continue
+		}
+
+		read, err := unix.Read(m.fd, buf)
+		if err != nil {
+			if err != unix.EAGAIN {
+				m.logger.Error(fmt.Sprintf("[X] Fanotify read error: %v", err))
+			}
+			continue
+		}
+
+		m.handleEvents(buf[:read])
+	}
+}
+
+// walks a buffer of fanotify_event_metadata records
+func (m *FanotifyMonitor) handleEvents(data []byte) {
+	for len(data) >= fanotifyMetadataSize {
+		eventLen := binary.NativeEndian.Uint32(data[0:4])
+		fd := int32(binary.NativeEndian.Uint32(data[16:20]))
+
+		if eventLen < fanotifyMetadataSize || int(eventLen) > len(data) {
+			return
+		}
+
+		if fd >= 0 {
+			path, err := os.Readlink("/proc/self/fd/" + strconv.Itoa(int(fd)))
+			unix.Close(int(fd))
+
+			if err == nil && m.inScope(path) && m.gate.shouldProcessFile(path) {
+				go m.gate.dispatch(path)
+			}
+		}
+
+		data = data[eventLen:]
+	}
+}
+
+// only files under the configured directories are handled
+func (m *FanotifyMonitor) inScope(path string) bool {
+	if m.gate.isExcluded(path) {
+		return false
+	}
+
+	m.dirsMu.Lock()
+	defer m.dirsMu.Unlock()
+
+	for _, dir := range m.dirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			if !m.gate.options.Recursive && filepath.Dir(path) != dir {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// reads the effective capability set of this process
+func hasCapability(bit uint) (bool, error) {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "CapEff:") {
+			continue
+		}
+		caps, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+		if err != nil {
+			return false, err
+		}
+		return caps&(1<<bit) != 0, nil
+	}
+
+	return false, scanner.Err()
+}
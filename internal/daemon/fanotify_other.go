@@ -0,0 +1,24 @@
+// BYZRA ⸻ internal/daemon/fanotify_other.go
+// fanotify is Linux-only; other platforms use the inotify-style watcher
+
+//go:build !linux
+
+package daemon
+
+import "fmt"
+
+type FanotifyMonitor struct{}
+
+func NewFanotifyMonitor(dirs []string, options WatchOptions, handler FileHandler, logger *Logger) (*FanotifyMonitor, error) {
+	return nil, fmt.Errorf("fanotify is only available on Linux")
+}
+
+func (m *FanotifyMonitor) Start() error { return fmt.Errorf("fanotify is only available on Linux") }
+
+func (m *FanotifyMonitor) Stop() error { return nil }
+
+func (m *FanotifyMonitor) AddDir(dir string) error {
+	return fmt.Errorf("fanotify is only available on Linux")
+}
+
+func (m *FanotifyMonitor) RemoveDir(dir string) error { return nil }
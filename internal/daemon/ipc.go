@@ -0,0 +1,163 @@
+// BYZRA ⸻ internal/daemon/ipc.go
+// control socket: the CLI asks a running daemon to adjust its watches, or
+// follows what it does
+
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"caligra/internal/config"
+)
+
+// requests understood by the daemon
+const (
+	CommandAddDir    = "add-dir"
+	CommandRemoveDir = "remove-dir"
+	CommandEvents    = "events" // answered, then followed by a stream of events
+)
+
+// one request per connection
+type IPCRequest struct {
+	Command string `json:"command"`
+	Path    string `json:"path"`
+	Policy  string `json:"policy,omitempty"`
+}
+
+// the daemon's answer
+type IPCResponse struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// control socket location, in a directory only its owner can enter
+func SocketPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra", "run", "daemon.sock")
+}
+
+// sends a request to the running daemon
+func SendCommand(request IPCRequest) (*IPCResponse, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(), 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("daemon is not reachable: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var response IPCResponse
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if !response.OK {
+		return &response, fmt.Errorf("%s", response.Error)
+	}
+	return &response, nil
+}
+
+// starts accepting control requests
+func (d *Daemon) startIPC() error {
+	path := SocketPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	// only this user may reconfigure the daemon; the directory is closed
+	// before the socket exists, so there is no moment others can connect
+	if err := os.Chmod(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	// left behind by a daemon that did not shut down cleanly
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to open control socket: %w", err)
+	}
+	os.Chmod(path, 0600)
+
+	d.ipc = listener
+	go d.acceptIPC(listener)
+	return nil
+}
+
+func (d *Daemon) acceptIPC(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return // listener closed
+		}
+		go d.serveIPC(conn)
+	}
+}
+
+func (d *Daemon) serveIPC(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var request IPCRequest
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		json.NewEncoder(conn).Encode(IPCResponse{Error: "malformed request"})
+		return
+	}
+
+	if request.Command == CommandEvents {
+		json.NewEncoder(conn).Encode(IPCResponse{OK: true})
+		conn.SetDeadline(time.Time{})
+		d.streamEvents(conn)
+		return
+	}
+
+	message, err := d.handleIPC(request)
+	response := IPCResponse{OK: err == nil, Message: message}
+	if err != nil {
+		response.Error = err.Error()
+		d.logger.Warning(fmt.Sprintf("[!] Control request %s %s failed: %v", request.Command, request.Path, err))
+	}
+	json.NewEncoder(conn).Encode(response)
+}
+
+func (d *Daemon) handleIPC(request IPCRequest) (string, error) {
+	dir := filepath.Clean(config.ExpandPath(request.Path))
+
+	switch request.Command {
+	case CommandAddDir:
+		var policy *config.Policy
+		if request.Policy != "" {
+			p, err := config.LoadPolicy(request.Policy)
+			if err != nil {
+				return "", err
+			}
+			policy = p
+		}
+
+		if err := d.watcher.AddDir(dir); err != nil {
+			return "", err
+		}
+		d.setDirPolicy(dir, policy)
+
+		if policy != nil {
+			return fmt.Sprintf("watching %s with policy %s", dir, policy.Name), nil
+		}
+		return "watching " + dir, nil
+
+	case CommandRemoveDir:
+		if err := d.watcher.RemoveDir(dir); err != nil {
+			return "", err
+		}
+		d.setDirPolicy(dir, nil)
+		return "stopped watching " + dir, nil
+
+	default:
+		return "", fmt.Errorf("unknown command: %s", request.Command)
+	}
+}
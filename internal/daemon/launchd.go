@@ -0,0 +1,104 @@
+// BYZRA ⸻ internal/daemon/launchd.go
+// macOS LaunchAgent: starts the daemon at login and restarts it if it dies
+
+package daemon
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// launchd label of the agent
+const LaunchAgentLabel = "com.caligra.daemon"
+
+// launchd starts agents with a bare PATH; exiftool and ffmpeg usually
+// come from Homebrew
+const launchAgentPATH = "/opt/homebrew/bin:/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin"
+
+// where the agent's plist is installed
+func LaunchAgentPath() string {
+	return filepath.Join(os.Getenv("HOME"), "Library", "LaunchAgents", LaunchAgentLabel+".plist")
+}
+
+// plist running exe as "daemon on"; KeepAlive restarts it after a crash
+// but not after a clean exit (e.g. when it was already running)
+func LaunchAgentPlist(exe, home string) string {
+	logPath := filepath.Join(home, ".caligra", "logs", "launchd.log")
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", plistEscape(LaunchAgentLabel))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range []string{exe, "daemon", "on"} {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", plistEscape(arg))
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	b.WriteString("\t<key>ProcessType</key>\n\t<string>Background</string>\n")
+	b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+	fmt.Fprintf(&b, "\t\t<key>HOME</key>\n\t\t<string>%s</string>\n", plistEscape(home))
+	fmt.Fprintf(&b, "\t\t<key>PATH</key>\n\t\t<string>%s</string>\n", launchAgentPATH)
+	b.WriteString("\t</dict>\n")
+	fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", plistEscape(logPath))
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", plistEscape(logPath))
+	b.WriteString("</dict>\n</plist>\n")
+
+	return b.String()
+}
+
+// escapes a value for a plist <string>
+func plistEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// writes the LaunchAgent for the current user and loads it, replacing
+// an earlier one; returns the plist path
+func InstallLaunchAgent() (string, error) {
+	if runtime.GOOS != "darwin" {
+		return "", fmt.Errorf("launchd is only available on macOS")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate caligra executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	home := os.Getenv("HOME")
+	path := LaunchAgentPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(home, ".caligra", "logs"), 0755); err != nil {
+		return "", fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(LaunchAgentPlist(exe, home)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	domain := fmt.Sprintf("gui/%d", os.Getuid())
+
+	// not loaded yet is fine
+	exec.Command("launchctl", "bootout", domain+"/"+LaunchAgentLabel).Run()
+
+	if out, err := exec.Command("launchctl", "bootstrap", domain, path).CombinedOutput(); err != nil {
+		return path, fmt.Errorf("launchctl bootstrap failed: %s", strings.TrimSpace(string(out)))
+	}
+
+	return path, nil
+}
@@ -4,9 +4,11 @@
 package daemon
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -20,12 +22,30 @@ const (
 	LevelError
 )
 
+// how often buffered entries are flushed to disk
+const logFlushInterval = time.Second
+
+// size of the queue between callers and the writer goroutine
+const logQueueSize = 1024
+
+// extra destination for log entries, e.g. the Windows event log
+type LogSink func(level LogLevel, message string)
+
 // daemon activity logging
+// safe for concurrent use; lines are queued and written by a single goroutine
 type Logger struct {
+	mu          sync.Mutex
 	logFile     *os.File
+	writer      *bufio.Writer
 	level       LogLevel
 	initialized bool
 	path        string
+	sink        LogSink
+
+	queue   chan string
+	syncReq chan chan error
+	done    chan struct{}
+	wg      sync.WaitGroup
 }
 
 func NewLogger(logPath string, level LogLevel) (*Logger, error) {
@@ -39,16 +59,72 @@ func NewLogger(logPath string, level LogLevel) (*Logger, error) {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
 
-	return &Logger{
+	l := &Logger{
 		logFile:     logFile,
+		writer:      bufio.NewWriter(logFile),
 		level:       level,
 		initialized: true,
 		path:        logPath,
-	}, nil
+	}
+	l.startWriter()
+
+	return l, nil
+}
+
+// launches the background writer for the current file
+func (l *Logger) startWriter() {
+	l.queue = make(chan string, logQueueSize)
+	l.syncReq = make(chan chan error)
+	l.done = make(chan struct{})
+
+	l.wg.Add(1)
+	go l.run(l.queue, l.syncReq, l.done, l.writer)
+}
+
+// drains the queue, flushing periodically and on request
+func (l *Logger) run(queue <-chan string, syncReq <-chan chan error, done <-chan struct{}, w *bufio.Writer) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(logFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line := <-queue:
+			w.WriteString(line)
+
+		case <-ticker.C:
+			w.Flush()
+
+		case reply := <-syncReq:
+			drainQueue(queue, w)
+			reply <- w.Flush()
+
+		case <-done:
+			drainQueue(queue, w)
+			w.Flush()
+			return
+		}
+	}
+}
+
+// writes whatever is still waiting in the queue
+func drainQueue(queue <-chan string, w *bufio.Writer) {
+	for {
+		select {
+		case line := <-queue:
+			w.WriteString(line)
+		default:
+			return
+		}
+	}
 }
 
 // writes a message to the log with timestamp
 func (l *Logger) Log(level LogLevel, message string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	if !l.initialized {
 		return fmt.Errorf("logger not initialized")
 	}
@@ -59,10 +135,19 @@ func (l *Logger) Log(level LogLevel, message string) error {
 
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	levelStr := getLevelString(level)
-	logLine := fmt.Sprintf("[%s] %s: %s\n", timestamp, levelStr, message)
+	l.queue <- fmt.Sprintf("[%s] %s: %s\n", timestamp, levelStr, message)
+	if l.sink != nil {
+		l.sink(level, message)
+	}
 
-	_, err := l.logFile.WriteString(logLine)
-	return err
+	return nil
+}
+
+// also sends entries at or above the log level to sink
+func (l *Logger) SetSink(sink LogSink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sink = sink
 }
 
 // debug logs
@@ -85,41 +170,84 @@ func (l *Logger) Error(message string) error {
 	return l.Log(LevelError, message)
 }
 
+// flushes queued entries and commits them to disk
+func (l *Logger) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.initialized {
+		return nil
+	}
+
+	return l.syncLocked()
+}
+
+func (l *Logger) syncLocked() error {
+	reply := make(chan error)
+	l.syncReq <- reply
+	if err := <-reply; err != nil {
+		return fmt.Errorf("failed to flush log: %w", err)
+	}
+
+	return l.logFile.Sync()
+}
+
 // close properly
 func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.closeLocked()
+}
+
+func (l *Logger) closeLocked() error {
 	if !l.initialized || l.logFile == nil {
 		return nil
 	}
 
+	// stop the writer; it drains and flushes before returning
+	close(l.done)
+	l.wg.Wait()
+
 	err := l.logFile.Close()
 	l.initialized = false
 	l.logFile = nil
+	l.writer = nil
 	return err
 }
 
 // new log file and archives the old one
 func (l *Logger) Rotate() error {
+	l.mu.Lock()
+
 	if !l.initialized {
+		l.mu.Unlock()
 		return fmt.Errorf("logger not initialized")
 	}
 
-	if err := l.Close(); err != nil {
+	if err := l.closeLocked(); err != nil {
+		l.mu.Unlock()
 		return fmt.Errorf("failed to close log file: %w", err)
 	}
 
 	timestamp := time.Now().Format("20060102-150405")
 	newPath := fmt.Sprintf("%s.%s", l.path, timestamp)
 	if err := os.Rename(l.path, newPath); err != nil {
+		l.mu.Unlock()
 		return fmt.Errorf("failed to rotate log file: %w", err)
 	}
 
 	logFile, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
+		l.mu.Unlock()
 		return fmt.Errorf("failed to create new log file: %w", err)
 	}
 
 	l.logFile = logFile
+	l.writer = bufio.NewWriter(logFile)
 	l.initialized = true
+	l.startWriter()
+	l.mu.Unlock()
 
 	// log rotation
 	return l.Info(fmt.Sprintf("Log rotated, previous log saved as %s", newPath))
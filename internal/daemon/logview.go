@@ -0,0 +1,200 @@
+// BYZRA ⸻ internal/daemon/logview.go
+// reading and tailing the daemon log
+
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// how often a followed log is polled for new lines
+const followInterval = 500 * time.Millisecond
+
+// location of the daemon log file
+func LogPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra/logs", "caligra-daemon.log")
+}
+
+// options for viewing the log
+type LogViewOptions struct {
+	// keep reading as new lines are appended
+	Follow bool
+
+	// hide entries below this level
+	MinLevel LogLevel
+
+	// number of existing lines to show before following (0 = all)
+	Tail int
+}
+
+// matches "[timestamp] LEVEL: message"
+var logLinePattern = regexp.MustCompile(`^\[([^\]]+)\] ([A-Z]+): (.*)$`)
+
+// absolute paths inside messages
+var logPathPattern = regexp.MustCompile(`(/[^\s:,→]+)`)
+
+// parses a level name such as "warning" or "ERROR"
+func ParseLogLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warning", "warn":
+		return LevelWarning, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelDebug, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
+// level of a raw log line, or -1 if it can't be parsed
+func lineLevel(line string) LogLevel {
+	match := logLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return -1
+	}
+
+	level, err := ParseLogLevel(match[2])
+	if err != nil {
+		return -1
+	}
+	return level
+}
+
+// styles a raw log line for the terminal
+func FormatLogLine(line string) string {
+	match := logLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return util.NSH.Render(line)
+	}
+
+	var levelStyle = util.NSH
+	switch match[2] {
+	case "DEBUG":
+		levelStyle = util.SUB
+	case "WARNING":
+		levelStyle = util.LBL
+	case "ERROR":
+		levelStyle = util.BRH
+	}
+
+	message := logPathPattern.ReplaceAllStringFunc(match[3], func(p string) string {
+		return util.SEC.Render(p)
+	})
+
+	return fmt.Sprintf("%s %s %s",
+		util.SUB.Render(match[1]),
+		levelStyle.Render(fmt.Sprintf("%-7s", match[2])),
+		message)
+}
+
+// prints the log through emit, optionally following new entries
+func ViewLog(path string, options LogViewOptions, emit func(string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open daemon log: %w", err)
+	}
+	defer func() { file.Close() }()
+
+	// the line without its line break, unless it is empty or below the level
+	visible := func(line string) (string, bool) {
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			return "", false
+		}
+		// continuation lines without a header are always shown
+		if level := lineLevel(line); level >= 0 && level < options.MinLevel {
+			return "", false
+		}
+		return line, true
+	}
+	show := func(line string) {
+		if line, ok := visible(line); ok {
+			emit(line)
+		}
+	}
+
+	// existing content, filtered first and then trimmed to the requested tail
+	var lines []string
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if line, ok := visible(line); ok {
+			lines = append(lines, line)
+			if options.Tail > 0 && len(lines) > options.Tail {
+				lines = lines[1:]
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read daemon log: %w", err)
+		}
+	}
+
+	for _, line := range lines {
+		emit(line)
+	}
+
+	if !options.Follow {
+		return nil
+	}
+
+	offset, _ := file.Seek(0, io.SeekCurrent)
+	partial := ""
+
+	for {
+		time.Sleep(followInterval)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue // log may be mid-rotation
+		}
+
+		current, _ := file.Stat()
+		// reopen after rotation or truncation
+		if !os.SameFile(info, current) || info.Size() < offset {
+			reopened, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			file.Close()
+			file = reopened
+			offset = 0
+			partial = ""
+		}
+
+		if info.Size() == offset {
+			continue
+		}
+
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek daemon log: %w", err)
+		}
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return fmt.Errorf("failed to read daemon log: %w", err)
+		}
+		offset += int64(len(data))
+
+		chunk := partial + string(data)
+		parts := strings.Split(chunk, "\n")
+		partial = parts[len(parts)-1] // incomplete trailing line
+		for _, line := range parts[:len(parts)-1] {
+			show(line)
+		}
+	}
+}
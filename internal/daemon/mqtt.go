@@ -0,0 +1,455 @@
+// BYZRA ⸻ internal/daemon/mqtt.go
+// daemon events published to an MQTT broker (3.1.1, publish only)
+
+package daemon
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"caligra/internal/config"
+)
+
+const (
+	mqttDefaultTopic = "caligra"
+	mqttKeepAlive    = 60 * time.Second
+	mqttTimeout      = 10 * time.Second
+	mqttMaxBackoff   = 2 * time.Minute
+)
+
+// packet types, in the high nibble of the first byte
+const (
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublish    = 0x30
+	mqttPubAck     = 0x40
+	mqttPingReq    = 0xC0
+	mqttPingResp   = 0xD0
+	mqttDisconnect = 0xE0
+)
+
+// CONNACK return codes
+var mqttRefusals = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// forwards the daemon's events to <topic>/<watch dir>/<event type>; events
+// raised while the broker is unreachable are dropped, not queued
+type mqttPublisher struct {
+	config   config.MQTT
+	password string
+	hub      *eventHub
+	sub      *subscriber
+	logger   *Logger
+	quit     chan struct{}
+	done     chan struct{}
+
+	conn     net.Conn
+	reader   *bufio.Reader
+	packetID uint16
+	backoff  time.Duration
+	retryAt  time.Time
+}
+
+func newMQTTPublisher(cfg config.MQTT, hub *eventHub, logger *Logger) (*mqttPublisher, error) {
+	if _, err := mqttAddress(cfg.Broker); err != nil {
+		return nil, err
+	}
+	if cfg.Topic == "" {
+		cfg.Topic = mqttDefaultTopic
+	}
+	cfg.Topic = strings.Trim(cfg.Topic, "/")
+	if cfg.ClientID == "" {
+		host, _ := os.Hostname()
+		cfg.ClientID = "caligra-" + host
+	}
+
+	p := &mqttPublisher{config: cfg, hub: hub, logger: logger, quit: make(chan struct{}), done: make(chan struct{})}
+	if cfg.PasswordFile != "" {
+		password, err := readPasswordFile(cfg.PasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		p.password = password
+	}
+	return p, nil
+}
+
+// the broker password, refused when the file is open to other users
+func readPasswordFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read [mqtt] password_file: %w", err)
+	}
+	defer file.Close()
+
+	// Windows reports no meaningful permission bits
+	if info, err := file.Stat(); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("[mqtt] password_file %s is readable by others (chmod 600 it)", path)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read [mqtt] password_file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// starts forwarding in the background; a broker that is down is retried
+// as events arrive
+func (p *mqttPublisher) Start() {
+	p.sub = p.hub.subscribe()
+	go p.run()
+}
+
+// publishes what is still buffered, marks the daemon offline and hangs up
+func (p *mqttPublisher) Stop() {
+	close(p.quit)
+	<-p.done
+}
+
+func (p *mqttPublisher) run() {
+	defer close(p.done)
+	ping := time.NewTicker(mqttKeepAlive / 2)
+	defer ping.Stop()
+
+	if err := p.connect(); err != nil {
+		p.logger.Warning(fmt.Sprintf("[!] MQTT broker %s unreachable, retrying: %v", p.config.Broker, err))
+	}
+
+	for {
+		select {
+		case <-p.quit:
+			p.hub.unsubscribe(p.sub)
+			for event := range p.sub.events {
+				p.forward(event)
+			}
+			p.disconnect()
+			return
+
+		case event, ok := <-p.sub.events:
+			if !ok {
+				// dropped for falling behind, or the daemon is stopping
+				if p.sub.dropped {
+					p.logger.Warning("[!] MQTT publishing fell behind, some events were not sent")
+				}
+				p.sub = p.hub.subscribe()
+				continue
+			}
+			p.forward(event)
+
+		case <-ping.C:
+			if p.conn == nil {
+				continue
+			}
+			if err := p.ping(); err != nil {
+				p.logger.Warning(fmt.Sprintf("[!] MQTT broker %s lost: %v", p.config.Broker, err))
+				p.close()
+			}
+		}
+	}
+}
+
+func (p *mqttPublisher) forward(event Event) {
+	if err := p.publishEvent(event); err != nil {
+		p.logger.Warning(fmt.Sprintf("[!] MQTT publish failed: %v", err))
+		p.close()
+	}
+}
+
+// <topic>/<level of the watch dir>/<event type>
+func (p *mqttPublisher) topic(event Event) string {
+	dir := event.Dir
+	if dir == "" {
+		dir = filepath.Dir(event.Path)
+	}
+	level, ok := p.config.Topics[dir]
+	if !ok {
+		level = mqttTopicLevel(filepath.Base(dir))
+	}
+	return p.config.Topic + "/" + level + "/" + event.Type
+}
+
+// a directory name as one topic level: no separators or wildcards
+func mqttTopicLevel(name string) string {
+	level := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', '+', '#', 0:
+			return '_'
+		}
+		return r
+	}, name)
+	if level == "" || level == "." || level == "_" {
+		return "root"
+	}
+	return level
+}
+
+// the availability topic, "online" while connected ("offline" via the will)
+func (p *mqttPublisher) statusTopic() string {
+	return p.config.Topic + "/status"
+}
+
+func (p *mqttPublisher) publishEvent(event Event) error {
+	if p.conn == nil {
+		if time.Now().Before(p.retryAt) {
+			return nil
+		}
+		if err := p.connect(); err != nil {
+			p.logger.Debug(fmt.Sprintf("MQTT reconnect failed: %v", err))
+			return nil
+		}
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.publish(p.topic(event), payload, byte(p.config.QoS), p.config.Retain)
+}
+
+// ╭─ CONNECTION ────────────────────────────────╮
+
+// host:port of a tcp:// (mqtt://) or tls:// (mqtts://, ssl://) broker URL
+func mqttAddress(broker string) (*url.URL, error) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("[mqtt] broker must look like tcp://host:1883, not %q", broker)
+	}
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "tls", "mqtts", "ssl":
+		port = "8883"
+	default:
+		return nil, fmt.Errorf("[mqtt] broker scheme must be tcp or tls, not %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, nil
+}
+
+func (p *mqttPublisher) connect() error {
+	u, _ := mqttAddress(p.config.Broker)
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+
+	var conn net.Conn
+	var err error
+	if u.Scheme == "tcp" || u.Scheme == "mqtt" {
+		conn, err = dialer.Dial("tcp", u.Host)
+	} else {
+		conn, err = tls.DialWithDialer(dialer, "tcp", u.Host, &tls.Config{MinVersion: tls.VersionTLS12, ServerName: u.Hostname()})
+	}
+	if err != nil {
+		p.retryLater()
+		return err
+	}
+	p.conn, p.reader = conn, bufio.NewReader(conn)
+
+	if err := p.handshake(); err != nil {
+		p.close()
+		p.retryLater()
+		return err
+	}
+	if err := p.publish(p.statusTopic(), []byte("online"), 1, true); err != nil {
+		p.close()
+		p.retryLater()
+		return err
+	}
+	p.backoff = 0
+	p.logger.Info(fmt.Sprintf("Publishing events to MQTT broker %s under %s/", p.config.Broker, p.config.Topic))
+	return nil
+}
+
+// CONNECT with a retained "offline" will, then CONNACK
+func (p *mqttPublisher) handshake() error {
+	flags := byte(0x02)         // clean session
+	flags |= 0x04 | 1<<3 | 0x20 // will, QoS 1, retained
+	var payload []byte
+	payload = mqttString(payload, p.config.ClientID)
+	payload = mqttString(payload, p.statusTopic())
+	payload = mqttString(payload, "offline")
+	if p.config.Username != "" {
+		flags |= 0x80
+		payload = mqttString(payload, p.config.Username)
+		if p.password != "" {
+			flags |= 0x40
+			payload = mqttString(payload, p.password)
+		}
+	}
+
+	body := mqttString(nil, "MQTT")
+	body = append(body, 4, flags) // protocol level 3.1.1
+	body = binary.BigEndian.AppendUint16(body, uint16(mqttKeepAlive/time.Second))
+	body = append(body, payload...)
+	if err := p.send(mqttConnect, body); err != nil {
+		return err
+	}
+
+	kind, reply, err := p.receive()
+	if err != nil {
+		return err
+	}
+	if kind != mqttConnAck || len(reply) != 2 {
+		return fmt.Errorf("broker answered CONNECT with packet type %#x", kind)
+	}
+	if code := reply[1]; code != 0 {
+		if reason, ok := mqttRefusals[code]; ok {
+			return fmt.Errorf("broker refused the connection: %s", reason)
+		}
+		return fmt.Errorf("broker refused the connection (code %d)", code)
+	}
+	return nil
+}
+
+// PUBLISH; at QoS 1, waits for the broker's PUBACK
+func (p *mqttPublisher) publish(topic string, payload []byte, qos byte, retain bool) error {
+	header := byte(mqttPublish) | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	body := mqttString(nil, topic)
+	var id uint16
+	if qos > 0 {
+		p.packetID++
+		if p.packetID == 0 {
+			p.packetID = 1
+		}
+		id = p.packetID
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, payload...)
+	if err := p.send(header, body); err != nil {
+		return err
+	}
+	if qos == 0 {
+		return nil
+	}
+
+	kind, reply, err := p.receive()
+	if err != nil {
+		return err
+	}
+	if kind != mqttPubAck || len(reply) != 2 || binary.BigEndian.Uint16(reply) != id {
+		return fmt.Errorf("expected PUBACK for packet %d, got packet type %#x", id, kind)
+	}
+	return nil
+}
+
+func (p *mqttPublisher) ping() error {
+	if err := p.send(mqttPingReq, nil); err != nil {
+		return err
+	}
+	kind, _, err := p.receive()
+	if err != nil {
+		return err
+	}
+	if kind != mqttPingResp {
+		return fmt.Errorf("expected PINGRESP, got packet type %#x", kind)
+	}
+	return nil
+}
+
+// a clean goodbye: "offline" published and DISCONNECT, so the will is not sent
+func (p *mqttPublisher) disconnect() {
+	if p.conn == nil {
+		return
+	}
+	p.publish(p.statusTopic(), []byte("offline"), 1, true)
+	p.send(mqttDisconnect, nil)
+	p.close()
+}
+
+func (p *mqttPublisher) close() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn, p.reader = nil, nil
+	}
+}
+
+// doubles the wait before the next connection attempt, up to mqttMaxBackoff
+func (p *mqttPublisher) retryLater() {
+	p.backoff = min(max(2*p.backoff, time.Second), mqttMaxBackoff)
+	p.retryAt = time.Now().Add(p.backoff)
+}
+
+// ╭─ WIRE FORMAT ───────────────────────────────╮
+
+func (p *mqttPublisher) send(header byte, body []byte) error {
+	if p.conn == nil {
+		return errors.New("not connected")
+	}
+	packet := append([]byte{header}, mqttLength(len(body))...)
+	packet = append(packet, body...)
+	p.conn.SetWriteDeadline(time.Now().Add(mqttTimeout))
+	_, err := p.conn.Write(packet)
+	return err
+}
+
+// reads one packet: its type and its body
+func (p *mqttPublisher) receive() (byte, []byte, error) {
+	p.conn.SetReadDeadline(time.Now().Add(mqttTimeout))
+	header, err := p.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, shift := 0, 0
+	for {
+		b, err := p.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length |= int(b&0x7F) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(p.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header & 0xF0, body, nil
+}
+
+// the variable-length "remaining length" encoding
+func mqttLength(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			return out
+		}
+	}
+}
+
+// a UTF-8 string with its 16-bit length
+func mqttString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
@@ -0,0 +1,183 @@
+// BYZRA ⸻ internal/daemon/removable.go
+// automatic watching of removable media as it is mounted
+
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// how often the mount table is re-read
+const mountPollInterval = 2 * time.Second
+
+// attaches mounts matching configured patterns to a monitor
+type MountWatcher struct {
+	patterns []string
+	target   Monitor
+	logger   *Logger
+	done     chan struct{}
+
+	mu       sync.Mutex // guards attached and stopped, shared with the poller
+	attached map[string]bool
+	stopped  bool
+}
+
+// new mount watcher; $USER and other variables in patterns are expanded
+func NewMountWatcher(patterns []string, target Monitor, logger *Logger) *MountWatcher {
+	expanded := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		expanded = append(expanded, os.ExpandEnv(pattern))
+	}
+
+	return &MountWatcher{
+		patterns: expanded,
+		target:   target,
+		logger:   logger,
+		attached: make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+}
+
+// attaches already-present media and starts polling for changes
+func (m *MountWatcher) Start() error {
+	if _, err := readMountPoints(); err != nil {
+		return fmt.Errorf("cannot read mount table: %w", err)
+	}
+
+	m.sync()
+	go m.poll()
+
+	m.logger.Info(fmt.Sprintf("Removable media watch started (%s)", strings.Join(m.patterns, ", ")))
+	return nil
+}
+
+// stops polling and detaches all media
+func (m *MountWatcher) Stop() error {
+	close(m.done)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+	for mount := range m.attached {
+		m.detach(mount)
+	}
+	return nil
+}
+
+func (m *MountWatcher) poll() {
+	ticker := time.NewTicker(mountPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.sync()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// diffs the mount table against attached media
+func (m *MountWatcher) sync() {
+	mounts, err := readMountPoints()
+	if err != nil {
+		m.logger.Warning(fmt.Sprintf("[!] Could not read mount table: %v", err))
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return
+	}
+
+	present := make(map[string]bool)
+	for _, mount := range mounts {
+		if !m.matches(mount) {
+			continue
+		}
+		present[mount] = true
+
+		if !m.attached[mount] {
+			if err := m.target.AddDir(mount); err != nil {
+				m.logger.Warning(fmt.Sprintf("[!] Could not watch removable media %s: %v", mount, err))
+				continue
+			}
+			m.attached[mount] = true
+			m.logger.Info(fmt.Sprintf("Removable media attached: %s", mount))
+		}
+	}
+
+	for mount := range m.attached {
+		if !present[mount] {
+			m.detach(mount)
+		}
+	}
+}
+
+// caller holds the lock
+func (m *MountWatcher) detach(mount string) {
+	if err := m.target.RemoveDir(mount); err != nil {
+		m.logger.Debug(fmt.Sprintf("Detach of %s: %v", mount, err))
+	}
+	delete(m.attached, mount)
+	m.logger.Info(fmt.Sprintf("Removable media detached: %s", mount))
+}
+
+func (m *MountWatcher) matches(mount string) bool {
+	for _, pattern := range m.patterns {
+		if ok, _ := filepath.Match(pattern, mount); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mount points listed in /proc/self/mountinfo
+func readMountPoints() ([]string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, unescapeMountPath(fields[4]))
+	}
+
+	return mounts, scanner.Err()
+}
+
+// decodes the octal escapes (\040 etc.) used in mountinfo
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if code, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(path[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,228 @@
+// BYZRA ⸻ internal/daemon/rules.go
+// named daemon rules and their workflows
+
+package daemon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"caligra/internal/analyse"
+	"caligra/internal/config"
+	"caligra/internal/util"
+	"caligra/internal/wipe"
+)
+
+// rule whose paths contain the file (most specific path wins) and whose
+// filter selects it
+func (d *Daemon) ruleFor(path string) *config.Rule {
+	var best *config.Rule
+	bestLen := -1
+
+	for i := range d.config.Rules {
+		rule := &d.config.Rules[i]
+		for _, dir := range rule.Paths {
+			if isUnder(path, dir) && len(dir) > bestLen && d.ruleSelects(i, path) {
+				best = rule
+				bestLen = len(dir)
+			}
+		}
+	}
+
+	return best
+}
+
+// does rule i's filter select the file? rules without one select all
+func (d *Daemon) ruleSelects(i int, path string) bool {
+	return i >= len(d.ruleFilters) || d.ruleFilters[i].MatchPath(path, analyse.DetectType)
+}
+
+// strips, renames and relocates a file according to a rule
+func (d *Daemon) applyRule(rule *config.Rule, path string) error {
+	d.logger.Info(fmt.Sprintf("Rule %q: processing %s", rule.Name, path))
+
+	if rule.MirrorTo != "" {
+		return d.mirrorRule(rule, path)
+	}
+
+	// in place: the file is moved afterwards, so no .volena copy or backup
+	options := &wipe.WipeOptions{
+		InjectProfile: false,
+		CreateCopy:    false,
+		KeepBackup:    false,
+		Timezone:      d.timezone,
+		Policy:        d.policy,
+		Audit:         "daemon",
+	}
+
+	result, err := wipe.WipeFile(path, options)
+	if err != nil {
+		d.recordError()
+		return fmt.Errorf("rule %q: wipe failed: %w", rule.Name, err)
+	}
+	// a file that may still carry metadata stays out of the clean folder
+	if !result.Success {
+		d.recordError()
+		return fmt.Errorf("rule %q: wipe left issues for %s, file left in place: %s",
+			rule.Name, path, result.ErrText())
+	}
+
+	if err := d.stats.RecordWipe(result.SensitiveData); err != nil {
+		d.logger.Warning(fmt.Sprintf("[!] Could not save stats: %v", err))
+	}
+
+	// a [wipe] policy may have renamed the file already
+	wipedPath := result.OutputPath
+	if wipedPath == "" {
+		wipedPath = path
+	}
+
+	name := filepath.Base(wipedPath)
+	if rule.Rename != "" && rule.Rename != "none" {
+		name = expandRenamePattern(rule.Rename) + filepath.Ext(wipedPath)
+	}
+
+	dir := filepath.Dir(path)
+	if rule.MoveTo != "" {
+		dir = rule.MoveTo
+	}
+
+	target := filepath.Join(dir, name)
+	wiped := Event{Type: EventFileWiped, Path: path, Output: wipedPath, Rule: rule.Name,
+		SensitiveFields: result.SensitiveData, Issues: result.WipeErrors}
+	if target == wipedPath {
+		d.emit(wiped)
+		return nil
+	}
+
+	if err := moveFile(wipedPath, target); err != nil {
+		d.recordError()
+		return fmt.Errorf("rule %q: %w", rule.Name, err)
+	}
+
+	d.logger.Info(fmt.Sprintf("Rule %q: %s → %s", rule.Name, path, target))
+	wiped.Output = target
+	d.emit(wiped)
+	return nil
+}
+
+// writes a sanitized copy into the rule's mirror tree, leaving the original alone
+func (d *Daemon) mirrorRule(rule *config.Rule, path string) error {
+	rel := mirrorPath(rule, path)
+	if rule.Rename != "" && rule.Rename != "none" {
+		rel = filepath.Join(filepath.Dir(rel), expandRenamePattern(rule.Rename)+filepath.Ext(path))
+	}
+	target := filepath.Join(rule.MirrorTo, rel)
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		d.recordError()
+		return fmt.Errorf("rule %q: failed to create mirror directory: %w", rule.Name, err)
+	}
+
+	// wiped under a hidden name so the mirror never shows an unsanitized file
+	tmp := filepath.Join(filepath.Dir(target), ".caligra-"+util.GenerateRandomID()+filepath.Ext(path))
+	if err := util.SafeCopy(path, tmp); err != nil {
+		d.recordError()
+		return fmt.Errorf("rule %q: %w", rule.Name, err)
+	}
+
+	options := &wipe.WipeOptions{
+		InjectProfile: false,
+		CreateCopy:    false,
+		KeepBackup:    false,
+		Timezone:      d.timezone,
+		Policy:        d.policy,
+		Audit:         "daemon",
+	}
+
+	result, err := wipe.WipeFile(tmp, options)
+	if err != nil {
+		os.Remove(tmp)
+		d.recordError()
+		return fmt.Errorf("rule %q: wipe failed: %w", rule.Name, err)
+	}
+	// a [wipe] policy may have renamed the copy already
+	if result.OutputPath != "" {
+		tmp = result.OutputPath
+	}
+
+	// a copy that may still carry metadata never reaches the mirror
+	if !result.Success {
+		os.Remove(tmp)
+		d.recordError()
+		return fmt.Errorf("rule %q: wipe left issues for %s, no mirror copy written: %s",
+			rule.Name, path, result.ErrText())
+	}
+
+	if err := d.stats.RecordWipe(result.SensitiveData); err != nil {
+		d.logger.Warning(fmt.Sprintf("[!] Could not save stats: %v", err))
+	}
+
+	// a newer version of the same source replaces the previous mirror copy
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		d.recordError()
+		return fmt.Errorf("rule %q: failed to place mirror copy: %w", rule.Name, err)
+	}
+
+	d.logger.Info(fmt.Sprintf("Rule %q: %s → %s (original kept)", rule.Name, path, target))
+	d.emit(Event{Type: EventFileWiped, Path: path, Output: target, Rule: rule.Name,
+		SensitiveFields: result.SensitiveData, Issues: result.WipeErrors})
+	return nil
+}
+
+// path of a file relative to the most specific rule path containing it
+func mirrorPath(rule *config.Rule, path string) string {
+	root := ""
+	for _, dir := range rule.Paths {
+		if isUnder(path, dir) && len(dir) > len(root) {
+			root = dir
+		}
+	}
+	if rel, err := filepath.Rel(root, path); err == nil && root != "" {
+		return rel
+	}
+	return filepath.Base(path)
+}
+
+// fills {random} placeholders with short random hex strings
+func expandRenamePattern(pattern string) string {
+	for strings.Contains(pattern, "{random}") {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			b = []byte(util.GenerateRandomID())
+		}
+		pattern = strings.Replace(pattern, "{random}", hex.EncodeToString(b), 1)
+	}
+	return pattern
+}
+
+// renames across directories, copying when they are on different filesystems
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("destination already exists: %s", dst)
+	}
+
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	if err := util.SafeCopy(src, dst); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	return util.RemoveFile(src)
+}
+
+// is path inside dir (or dir itself)?
+func isUnder(path, dir string) bool {
+	dir = filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
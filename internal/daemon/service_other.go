@@ -0,0 +1,20 @@
+// BYZRA ⸻ internal/daemon/service_other.go
+// service registration is only implemented on Windows
+
+//go:build !windows
+
+package daemon
+
+import "fmt"
+
+func InstallService(home string) error {
+	return fmt.Errorf("service install is only available on Windows")
+}
+
+func UninstallService() error {
+	return fmt.Errorf("service install is only available on Windows")
+}
+
+func RunService(home string) error {
+	return fmt.Errorf("running as a service is only available on Windows")
+}
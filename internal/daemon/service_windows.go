@@ -0,0 +1,159 @@
+// BYZRA ⸻ internal/daemon/service_windows.go
+// running the daemon as a Windows service, logging to the event log
+
+//go:build windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// event ID used for every entry; the source is registered via EventCreate
+const serviceEventID = 1
+
+// registers the daemon with the service control manager, started at boot;
+// home is handed to the service so it reads the installing user's config
+func InstallService(home string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate caligra executable: %w", err)
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(ServiceName); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s is already installed", ServiceName)
+	}
+
+	s, err := m.CreateService(ServiceName, exe, mgr.Config{
+		DisplayName: "CALIGRA metadata daemon",
+		Description: "Watches directories and wipes sensitive metadata from new files",
+		StartType:   mgr.StartAutomatic,
+	}, "daemon", "service", "--home", home)
+	if err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	defer s.Close()
+
+	levels := uint32(eventlog.Error | eventlog.Warning | eventlog.Info)
+	if err := eventlog.InstallAsEventCreate(ServiceName, levels); err != nil {
+		s.Delete()
+		return fmt.Errorf("failed to register event log source: %w", err)
+	}
+
+	return nil
+}
+
+// stops and removes the service and its event log source
+func UninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(ServiceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", ServiceName)
+	}
+	defer s.Close()
+
+	// a stopped service refuses the request; deleting works either way
+	s.Control(svc.Stop)
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	if err := eventlog.Remove(ServiceName); err != nil {
+		return fmt.Errorf("failed to remove event log source: %w", err)
+	}
+
+	return nil
+}
+
+// runs the daemon under the service control manager until it is stopped
+func RunService(home string) error {
+	if inService, err := svc.IsWindowsService(); err != nil || !inService {
+		return fmt.Errorf("not started by the service manager; use caligra daemon on")
+	}
+	if home != "" {
+		os.Setenv("HOME", home)
+	}
+
+	elog, err := eventlog.Open(ServiceName)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer elog.Close()
+
+	return svc.Run(ServiceName, &service{elog: elog})
+}
+
+// svc.Handler around a Daemon
+type service struct {
+	elog *eventlog.Log
+}
+
+func (s *service) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	d, err := NewDaemon("")
+	if err != nil {
+		s.elog.Error(serviceEventID, "Failed to create daemon: "+err.Error())
+		return true, 1
+	}
+	d.logger.SetSink(s.log)
+
+	if err := d.Start(); err != nil {
+		s.elog.Error(serviceEventID, "Failed to start daemon: "+err.Error())
+		return true, 2
+	}
+
+	// lets daemon status and add-dir find the service
+	pidFile := PIDPath()
+	os.MkdirAll(filepath.Dir(pidFile), 0755)
+	os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644)
+	defer os.Remove(pidFile)
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for request := range requests {
+		switch request.Cmd {
+		case svc.Interrogate:
+			status <- request.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			status <- svc.Status{State: svc.StopPending}
+			if err := d.Stop(); err != nil {
+				s.elog.Warning(serviceEventID, "Daemon shutdown incomplete: "+err.Error())
+			}
+			return false, 0
+		}
+	}
+
+	return false, 0
+}
+
+// mirrors daemon log entries into the event log
+func (s *service) log(level LogLevel, message string) {
+	switch level {
+	case LevelError:
+		s.elog.Error(serviceEventID, message)
+	case LevelWarning:
+		s.elog.Warning(serviceEventID, message)
+	case LevelInfo:
+		s.elog.Info(serviceEventID, message)
+	}
+}
@@ -0,0 +1,266 @@
+// BYZRA ⸻ internal/daemon/stats.go
+// persistent daemon activity counters
+
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// date format used as the key for daily counters
+const statsDayFormat = "2006-01-02"
+
+// counters for a single day
+type DayStats struct {
+	Scanned int            `json:"scanned"`
+	Wiped   int            `json:"wiped"`
+	Errors  int            `json:"errors"`
+	Fields  map[string]int `json:"fields"` // removed fields by category
+}
+
+// daily counters persisted across daemon runs
+type Stats struct {
+	mu   sync.Mutex
+	path string
+	Days map[string]*DayStats `json:"days"`
+}
+
+// a day and its counters, for ordered rendering
+type DayEntry struct {
+	Day   string
+	Stats DayStats
+}
+
+// location of the stats file
+func StatsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra", "stats.json")
+}
+
+// loads counters from disk, starting empty if the file doesn't exist
+func LoadStats(path string) (*Stats, error) {
+	stats := &Stats{
+		path: path,
+		Days: make(map[string]*DayStats),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse stats: %w", err)
+	}
+	if stats.Days == nil {
+		stats.Days = make(map[string]*DayStats)
+	}
+
+	return stats, nil
+}
+
+// counters for today, created on demand (caller holds the lock)
+func (s *Stats) today() *DayStats {
+	key := time.Now().Format(statsDayFormat)
+	day, ok := s.Days[key]
+	if !ok {
+		day = &DayStats{Fields: make(map[string]int)}
+		s.Days[key] = day
+	}
+	if day.Fields == nil {
+		day.Fields = make(map[string]int)
+	}
+	return day
+}
+
+// counts a file that was analysed
+func (s *Stats) RecordScan() error {
+	s.mu.Lock()
+	s.today().Scanned++
+	s.mu.Unlock()
+	return s.Save()
+}
+
+// counts a wiped file and the sensitive fields removed from it
+func (s *Stats) RecordWipe(fields []string) error {
+	s.mu.Lock()
+	day := s.today()
+	day.Wiped++
+	for _, field := range fields {
+		day.Fields[util.SensitiveFieldCategory(field)]++
+	}
+	s.mu.Unlock()
+	return s.Save()
+}
+
+// counts a failed analysis or wipe
+func (s *Stats) RecordError() error {
+	s.mu.Lock()
+	s.today().Errors++
+	s.mu.Unlock()
+	return s.Save()
+}
+
+// writes counters to disk atomically; the lock is held until the rename,
+// so concurrent saves never share or publish a half-written temp file
+func (s *Stats) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stats: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// days on or after the cutoff, oldest first
+func (s *Stats) Since(cutoff time.Time) []DayEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoffKey := cutoff.Format(statsDayFormat)
+	var entries []DayEntry
+	for key, day := range s.Days {
+		if key >= cutoffKey {
+			entries = append(entries, DayEntry{Day: key, Stats: *day})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Day < entries[j].Day
+	})
+
+	return entries
+}
+
+// sums every counter across entries
+func Totals(entries []DayEntry) DayStats {
+	total := DayStats{Fields: make(map[string]int)}
+	for _, entry := range entries {
+		total.Scanned += entry.Stats.Scanned
+		total.Wiped += entry.Stats.Wiped
+		total.Errors += entry.Stats.Errors
+		for category, count := range entry.Stats.Fields {
+			total.Fields[category] += count
+		}
+	}
+	return total
+}
+
+// parses spans like "30d", "2w" or "12h"
+func ParseSince(span string) (time.Duration, error) {
+	span = strings.TrimSpace(strings.ToLower(span))
+	if span == "" {
+		return 0, fmt.Errorf("empty time span")
+	}
+
+	unit := span[len(span)-1]
+	if unit >= '0' && unit <= '9' {
+		unit = 'd'
+	} else {
+		span = span[:len(span)-1]
+	}
+
+	n, err := strconv.Atoi(span)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid time span: %s", span)
+	}
+
+	switch unit {
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unknown time unit: %c", unit)
+	}
+}
+
+// renders daily trends and totals for the terminal
+func FormatStats(entries []DayEntry, span string) string {
+	var sb strings.Builder
+
+	sb.WriteString(util.LBL.Render(fmt.Sprintf("Daemon activity (last %s)", span)))
+	sb.WriteString("\n\n")
+
+	if len(entries) == 0 {
+		sb.WriteString(util.NSH.Render("[i] No activity recorded in this period"))
+		sb.WriteString("\n")
+		return sb.String()
+	}
+
+	// scale bars against the busiest day
+	peak := 1
+	for _, entry := range entries {
+		peak = max(peak, entry.Stats.Scanned)
+	}
+
+	const barWidth = 24
+	for _, entry := range entries {
+		scanned := entry.Stats.Scanned * barWidth / peak
+		wiped := min(entry.Stats.Wiped*barWidth/peak, scanned)
+
+		bar := util.LBL.Render(strings.Repeat("█", wiped)) +
+			util.SUB.Render(strings.Repeat("█", scanned-wiped))
+
+		sb.WriteString(fmt.Sprintf(" %s %s %s\n",
+			util.NSH.Render(entry.Day),
+			bar+strings.Repeat(" ", barWidth-scanned),
+			util.SUB.Render(fmt.Sprintf("%d scanned, %d wiped, %d errors",
+				entry.Stats.Scanned, entry.Stats.Wiped, entry.Stats.Errors))))
+	}
+
+	total := Totals(entries)
+	sb.WriteString("\n")
+	sb.WriteString(util.LBL.Render("Totals"))
+	sb.WriteString("\n")
+	sb.WriteString(util.NSH.Render(fmt.Sprintf(" • scanned: %d", total.Scanned)) + "\n")
+	sb.WriteString(util.NSH.Render(fmt.Sprintf(" • wiped:   %d", total.Wiped)) + "\n")
+	sb.WriteString(util.NSH.Render(fmt.Sprintf(" • errors:  %d", total.Errors)) + "\n")
+
+	if len(total.Fields) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(util.LBL.Render("Fields removed by category"))
+		sb.WriteString("\n")
+
+		categories := make([]string, 0, len(total.Fields))
+		for category := range total.Fields {
+			categories = append(categories, category)
+		}
+		sort.Slice(categories, func(i, j int) bool {
+			return total.Fields[categories[i]] > total.Fields[categories[j]]
+		})
+
+		for _, category := range categories {
+			sb.WriteString(util.NSH.Render(fmt.Sprintf(" • %s: %d", category, total.Fields[category])))
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,63 @@
+// BYZRA ⸻ internal/daemon/throttle.go
+// [throttle]: CPU/IO scheduling, a cap on concurrent files and overwrite speed
+
+package daemon
+
+import (
+	"fmt"
+
+	"caligra/internal/config"
+	"caligra/internal/util"
+)
+
+// lowers the daemon's scheduling priority and sets the overwrite rate;
+// scheduling failures are logged, the daemon still runs
+func (d *Daemon) applyThrottle() {
+	throttle := d.config.Throttle
+
+	if throttle.OverwriteMBPerSec > 0 {
+		util.SetOverwriteRate(int64(throttle.OverwriteMBPerSec) << 20)
+		d.logger.Info(fmt.Sprintf("Secure overwrites limited to %d MB/s", throttle.OverwriteMBPerSec))
+	}
+
+	if throttle.Nice == 0 && !throttle.SchedIdle && throttle.IOClass == "" {
+		return
+	}
+	if err := setScheduling(throttle); err != nil {
+		d.logger.Warning(fmt.Sprintf("[!] Could not lower scheduling priority: %v", err))
+		return
+	}
+	d.logger.Info(fmt.Sprintf("Scheduling lowered (%s)", describeScheduling(throttle)))
+}
+
+// wraps handler so at most max files are processed at once; further
+// files wait their turn
+func limitConcurrency(handler FileHandler, max int) FileHandler {
+	if max <= 0 {
+		return handler
+	}
+	slots := make(chan struct{}, max)
+	return func(path string) error {
+		slots <- struct{}{}
+		defer func() { <-slots }()
+		return handler(path)
+	}
+}
+
+func describeScheduling(t config.Throttle) string {
+	cpu := "normal"
+	if t.SchedIdle {
+		cpu = "idle"
+	}
+	if t.Nice != 0 {
+		cpu += fmt.Sprintf(", nice %d", t.Nice)
+	}
+	io := "unchanged"
+	switch t.IOClass {
+	case config.IOClassIdle:
+		io = "idle"
+	case config.IOClassBestEffort:
+		io = fmt.Sprintf("best-effort %d", t.IOPriority)
+	}
+	return "cpu " + cpu + "; io " + io
+}
@@ -0,0 +1,67 @@
+// BYZRA ⸻ internal/daemon/throttle_linux.go
+// SCHED_IDLE, nice and ioprio for every thread of the daemon
+
+//go:build linux
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"caligra/internal/config"
+
+	"golang.org/x/sys/unix"
+)
+
+// ioprio_set(2) constants, not exported by x/sys
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioClassBE    = 2
+	ioprioClassIdle  = 3
+	ioprioIdleLevel  = 7
+)
+
+// one entry per thread of this process
+const taskDir = "/proc/self/task"
+
+// scheduling attributes are per thread on Linux, so each existing thread
+// is changed; threads and tool processes started later inherit them
+func setScheduling(t config.Throttle) error {
+	entries, err := os.ReadDir(taskDir)
+	if err != nil {
+		return fmt.Errorf("failed to list threads: %w", err)
+	}
+
+	attr := unix.SchedAttr{Policy: unix.SCHED_NORMAL, Nice: int32(t.Nice)}
+	if t.SchedIdle {
+		attr.Policy = unix.SCHED_IDLE
+	}
+
+	ioprio := 0
+	switch t.IOClass {
+	case config.IOClassIdle:
+		ioprio = ioprioClassIdle<<ioprioClassShift | ioprioIdleLevel
+	case config.IOClassBestEffort:
+		ioprio = ioprioClassBE<<ioprioClassShift | t.IOPriority
+	}
+
+	for _, entry := range entries {
+		tid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if err := unix.SchedSetAttr(tid, &attr, 0); err != nil && err != unix.ESRCH {
+			return fmt.Errorf("sched_setattr: %w", err)
+		}
+		if ioprio != 0 {
+			_, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(ioprio))
+			if errno != 0 && errno != unix.ESRCH {
+				return fmt.Errorf("ioprio_set: %w", errno)
+			}
+		}
+	}
+	return nil
+}
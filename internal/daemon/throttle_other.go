@@ -0,0 +1,16 @@
+// BYZRA ⸻ internal/daemon/throttle_other.go
+// scheduling classes are only implemented on Linux
+
+//go:build !linux
+
+package daemon
+
+import (
+	"fmt"
+
+	"caligra/internal/config"
+)
+
+func setScheduling(t config.Throttle) error {
+	return fmt.Errorf("nice, sched_idle and io_class are only available on Linux")
+}
@@ -7,16 +7,32 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"caligra/internal/analyse"
+	"caligra/internal/filter"
+	"caligra/internal/util"
+
 	"github.com/fsnotify/fsnotify"
 )
 
 // processes a detected file
 type FileHandler func(path string) error
 
+// source of file events feeding a FileHandler
+type Monitor interface {
+	Start() error
+	Stop() error
+
+	// adjust the watched top-level directories at runtime
+	AddDir(dir string) error
+	RemoveDir(dir string) error
+}
+
 // configures the watcher behavior
 type WatchOptions struct {
 	// extensions to monitor
@@ -28,20 +44,89 @@ type WatchOptions struct {
 	// min file age before processing (avoid processing incomplete files)
 	MinFileAge time.Duration
 
+	// [filter] expression a settled file must match; nil matches all
+	Filter *filter.Filter
+
 	// process files recursively in subdirectories?
 	Recursive bool
+
+	// start even with no valid directories (more may be attached later)
+	AllowEmpty bool
+
+	// scan interval for directories that can't use inotify (network filesystems)
+	PollInterval time.Duration
+
+	// gap between size checks before a file counts as complete
+	SettleInterval time.Duration
+}
+
+// default scan interval for polled directories
+const defaultPollInterval = 5 * time.Second
+
+// default gap between size checks while a file settles
+const defaultSettleInterval = 500 * time.Millisecond
+
+// give up on files that keep changing for longer than this
+const maxSettleWait = 10 * time.Minute
+
+// suffixes browsers use while a download is in progress
+var partialDownloadSuffixes = []string{".part", ".crdownload", ".download", ".partial"}
+
+// folders macOS keeps on every volume (camera cards and USB sticks too);
+// FSEvents churns in them constantly and they never hold user files
+var macOSMetadataDirs = []string{
+	".Spotlight-V100", ".fseventsd", ".Trashes", ".TemporaryItems", ".DocumentRevisions-V100",
+}
+
+// is this a "._name" AppleDouble file macOS writes next to every file
+// on non-HFS volumes? it shares the real file's extension
+func isAppleDouble(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), "._")
+}
+
+// is this an in-progress download rather than a finished file?
+func isPartialDownload(path string) bool {
+	lower := strings.ToLower(path)
+	for _, suffix := range partialDownloadSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// does a temporary download still exist alongside the final name?
+func hasPartialSibling(path string) bool {
+	for _, suffix := range partialDownloadSuffixes {
+		if _, err := os.Stat(path + suffix); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
 // monitors directories for file changes
 type Watcher struct {
 	watcher     *fsnotify.Watcher
 	dirs        []string
+	dirsLock    sync.Mutex
 	options     WatchOptions
 	handler     FileHandler
 	logger      *Logger
 	processed   map[string]time.Time
 	processLock sync.Mutex
-	running     bool
+	pending     map[string]bool
+	running     atomic.Bool
+
+	// directories scanned periodically instead of watched
+	polled    []string
+	pollState map[string]fileState
+}
+
+// last seen size and mtime of a polled file
+type fileState struct {
+	size    int64
+	modTime time.Time
 }
 
 // new file system watcher
@@ -67,7 +152,7 @@ func NewWatcher(dirs []string, options WatchOptions, handler FileHandler, logger
 		validDirs = append(validDirs, dir)
 	}
 
-	if len(validDirs) == 0 {
+	if len(validDirs) == 0 && !options.AllowEmpty {
 		return nil, fmt.Errorf("no valid directories to watch")
 	}
 
@@ -78,71 +163,153 @@ func NewWatcher(dirs []string, options WatchOptions, handler FileHandler, logger
 		handler:   handler,
 		logger:    logger,
 		processed: make(map[string]time.Time),
+		pending:   make(map[string]bool),
+		pollState: make(map[string]fileState),
 	}, nil
 }
 
 // begins watching the configured directories
 func (w *Watcher) Start() error {
-	if w.running {
+	if w.running.Load() {
 		return fmt.Errorf("watcher already running")
 	}
 
 	// add directories to watch
+	w.dirsLock.Lock()
 	for _, dir := range w.dirs {
-		if w.options.Recursive {
-			if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					w.logger.Warning(fmt.Sprintf("Error accessing path %s: %v", path, err))
-					return nil // Continue walking
-				}
+		w.watchTree(dir)
+	}
+	w.dirsLock.Unlock()
 
-				if info.IsDir() {
-					for _, exclude := range w.options.ExcludeDirs {
-						if strings.Contains(path, exclude) {
-							return filepath.SkipDir
-						}
-					}
+	// start processing events
+	go w.processEvents()
 
-					if err := w.watcher.Add(path); err != nil {
-						w.logger.Warning(fmt.Sprintf("Failed to watch directory %s: %v", path, err))
-					} else {
-						w.logger.Debug(fmt.Sprintf("Watching directory: %s", path))
-					}
-				}
-				return nil
-			}); err != nil {
-				w.logger.Error(fmt.Sprintf("Error walking directory %s: %v", dir, err))
-			}
+	// mark running before the background loops check it
+	w.running.Store(true)
+
+	// scan network directories
+	go w.pollLoop()
+
+	// start cleanup routine
+	go w.periodicCleanup()
+
+	w.logger.Info("File watcher started")
+
+	return nil
+}
+
+// adds a directory (and its subdirectories in recursive mode) to fsnotify
+func (w *Watcher) watchTree(dir string) {
+	// inotify doesn't see changes made by other clients of a network share
+	if util.IsNetworkFilesystem(dir) {
+		w.logger.Warning(fmt.Sprintf("[!] %s is on a network filesystem; inotify is unreliable there, polling instead", dir))
+		w.polled = append(w.polled, dir)
+		w.scanPolled(dir, false)
+		return
+	}
+
+	if !w.options.Recursive {
+		// just watch the top-level directory
+		if err := w.watcher.Add(dir); err != nil {
+			w.logger.Warning(fmt.Sprintf("Failed to watch directory %s: %v", dir, err))
 		} else {
-			// Just watch the top-level directory
-			if err := w.watcher.Add(dir); err != nil {
-				w.logger.Warning(fmt.Sprintf("Failed to watch directory %s: %v", dir, err))
+			w.logger.Debug(fmt.Sprintf("Watching directory: %s", dir))
+		}
+		return
+	}
+
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			w.logger.Warning(fmt.Sprintf("Error accessing path %s: %v", path, err))
+			return nil // continue walking
+		}
+
+		if info.IsDir() {
+			if w.isExcluded(path) {
+				return filepath.SkipDir
+			}
+
+			if err := w.watcher.Add(path); err != nil {
+				w.logger.Warning(fmt.Sprintf("Failed to watch directory %s: %v", path, err))
 			} else {
-				w.logger.Debug(fmt.Sprintf("Watching directory: %s", dir))
+				w.logger.Debug(fmt.Sprintf("Watching directory: %s", path))
 			}
 		}
+		return nil
+	}); err != nil {
+		w.logger.Error(fmt.Sprintf("Error walking directory %s: %v", dir, err))
 	}
+}
 
-	// start processing events
-	go w.processEvents()
+// starts watching another top-level directory while running
+func (w *Watcher) AddDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("cannot watch %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
 
-	// start cleanup routine
-	go w.periodicCleanup()
+	w.dirsLock.Lock()
+	defer w.dirsLock.Unlock()
 
-	w.running = true
-	w.logger.Info("File watcher started")
+	if slices.Contains(w.dirs, dir) {
+		return nil
+	}
+
+	w.dirs = append(w.dirs, dir)
+	if w.running.Load() {
+		w.watchTree(dir)
+	}
+	w.logger.Info(fmt.Sprintf("Added watch directory: %s", dir))
+
+	return nil
+}
+
+// stops watching a top-level directory and everything below it
+func (w *Watcher) RemoveDir(dir string) error {
+	w.dirsLock.Lock()
+	defer w.dirsLock.Unlock()
+
+	idx := slices.Index(w.dirs, dir)
+	if idx < 0 {
+		return fmt.Errorf("directory is not watched: %s", dir)
+	}
+	w.dirs = slices.Delete(w.dirs, idx, idx+1)
+
+	prefix := dir + string(filepath.Separator)
+	if i := slices.Index(w.polled, dir); i >= 0 {
+		w.polled = slices.Delete(w.polled, i, i+1)
+
+		w.processLock.Lock()
+		for path := range w.pollState {
+			if strings.HasPrefix(path, prefix) {
+				delete(w.pollState, path)
+			}
+		}
+		w.processLock.Unlock()
+	}
+
+	for _, path := range w.watcher.WatchList() {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			// may already be gone if the filesystem was unmounted
+			_ = w.watcher.Remove(path)
+		}
+	}
+	w.logger.Info(fmt.Sprintf("Removed watch directory: %s", dir))
 
 	return nil
 }
 
 // terminates the watcher
 func (w *Watcher) Stop() error {
-	if !w.running {
+	if !w.running.Load() {
 		return nil
 	}
 
 	err := w.watcher.Close()
-	w.running = false
+	w.running.Store(false)
 	w.logger.Info("File watcher stopped")
 
 	return err
@@ -150,6 +317,11 @@ func (w *Watcher) Stop() error {
 
 // checks if a file should be processed based on options
 func (w *Watcher) shouldProcessFile(path string) bool {
+	// the finished file arrives later under its real name
+	if isPartialDownload(path) || isAppleDouble(path) {
+		return false
+	}
+
 	ext := strings.ToLower(filepath.Ext(path))
 	if len(w.options.Extensions) > 0 {
 		matched := false
@@ -164,17 +336,10 @@ func (w *Watcher) shouldProcessFile(path string) bool {
 		}
 	}
 
-	// is file old enough?
-	if w.options.MinFileAge > 0 {
-		info, err := os.Stat(path)
-		if err != nil {
-			return false
-		}
-
-		// if file was modified less than MinFileAge ago, don't process
-		if time.Since(info.ModTime()) < w.options.MinFileAge {
-			return false
-		}
+	// file age is enforced in waitUntilSettled, since no further
+	// event may arrive once a young file is complete
+	if _, err := os.Stat(path); err != nil {
+		return false
 	}
 
 	w.processLock.Lock()
@@ -206,6 +371,7 @@ func (w *Watcher) processEvents() {
 			}
 
 			// creation or write event?
+			// a download renamed into place arrives as a Create on the final name
 			if event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
 				path := event.Name
 
@@ -233,20 +399,7 @@ func (w *Watcher) processEvents() {
 				}
 
 				if w.shouldProcessFile(path) {
-					go func(filePath string) {
-						// small delay to ensure file is completely written
-						time.Sleep(500 * time.Millisecond)
-
-						w.logger.Debug(fmt.Sprintf("Processing file: %s", filePath))
-
-						if err := w.handler(filePath); err != nil {
-							w.logger.Error(fmt.Sprintf("[X] Failed to process file %s: %v", filePath, err))
-						} else {
-							w.logger.Info(fmt.Sprintf("Successfully processed file: %s", filePath))
-						}
-
-						w.markProcessed(filePath)
-					}(path)
+					go w.dispatch(path)
 				}
 			}
 
@@ -259,6 +412,189 @@ func (w *Watcher) processEvents() {
 	}
 }
 
+// runs the handler for a file that passed the filters
+func (w *Watcher) dispatch(filePath string) {
+	// a burst of write events must not start several handlers for one file
+	w.processLock.Lock()
+	if w.pending[filePath] {
+		w.processLock.Unlock()
+		return
+	}
+	w.pending[filePath] = true
+	w.processLock.Unlock()
+
+	defer func() {
+		w.processLock.Lock()
+		delete(w.pending, filePath)
+		w.processLock.Unlock()
+	}()
+
+	if !w.waitUntilSettled(filePath) {
+		w.logger.Debug(fmt.Sprintf("File did not settle, skipping for now: %s", filePath))
+		return
+	}
+
+	w.logger.Debug(fmt.Sprintf("Processing file: %s", filePath))
+
+	if err := w.handler(filePath); err != nil {
+		w.logger.Error(fmt.Sprintf("[X] Failed to process file %s: %v", filePath, err))
+	} else {
+		w.logger.Info(fmt.Sprintf("Successfully processed file: %s", filePath))
+	}
+
+	w.markProcessed(filePath)
+}
+
+// waits until a file stops growing and neither a download nor another
+// process is still writing it
+// returns false if the file vanished or never settled
+func (w *Watcher) waitUntilSettled(path string) bool {
+	interval := w.options.SettleInterval
+	if interval <= 0 {
+		interval = defaultSettleInterval
+	}
+	deadline := time.Now().Add(maxSettleWait)
+
+	lastSize := int64(-1)
+	var busy error
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return false // renamed or deleted meanwhile
+		}
+
+		// browsers may create the final name before the payload is renamed over it
+		if hasPartialSibling(path) {
+			lastSize = -1
+			continue
+		}
+
+		// stable size, empty included, and old enough (avoid processing
+		// incomplete files)
+		if info.Size() == lastSize && time.Since(info.ModTime()) >= w.options.MinFileAge {
+			// an export can pause between writes for longer than the size check
+			if busy = util.CheckNotBusy(path); busy != nil {
+				continue
+			}
+			file := filter.File{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+			if w.options.Filter.Match(file, analyse.DetectType) {
+				return true
+			}
+			// an age condition may still come true
+			if !w.options.Filter.UsesAge() {
+				return false
+			}
+			continue
+		}
+		lastSize = info.Size()
+	}
+
+	if busy != nil {
+		w.logger.Warning(fmt.Sprintf("[!] Skipping %s: %v", path, busy))
+	}
+	return false
+}
+
+// is the path inside an excluded directory?
+func (w *Watcher) isExcluded(path string) bool {
+	for _, exclude := range w.options.ExcludeDirs {
+		if strings.Contains(path, exclude) {
+			return true
+		}
+	}
+	return false
+}
+
+// rescans polled directories until the watcher stops
+func (w *Watcher) pollLoop() {
+	interval := w.options.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !w.running.Load() {
+			return
+		}
+
+		w.dirsLock.Lock()
+		polled := slices.Clone(w.polled)
+		w.dirsLock.Unlock()
+
+		for _, dir := range polled {
+			w.scanPolled(dir, true)
+		}
+	}
+}
+
+// records file states under dir, dispatching new or changed files when notify is set
+func (w *Watcher) scanPolled(dir string, notify bool) {
+	seen := make(map[string]bool)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if info.IsDir() {
+			if path != dir && (!w.options.Recursive || w.isExcluded(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		seen[path] = true
+
+		w.processLock.Lock()
+		previous, known := w.pollState[path]
+		w.pollState[path] = state
+		w.processLock.Unlock()
+
+		if notify && (!known || previous != state) && w.shouldProcessFile(path) {
+			go w.dispatch(path)
+		}
+		return nil
+	})
+
+	w.prunePollState(dir, seen)
+}
+
+// forgets files under dir that the last scan missed and that are gone;
+// files merely skipped (unreadable, excluded) keep their state
+func (w *Watcher) prunePollState(dir string, seen map[string]bool) {
+	prefix := dir + string(filepath.Separator)
+
+	w.processLock.Lock()
+	var missing []string
+	for path := range w.pollState {
+		if strings.HasPrefix(path, prefix) && !seen[path] {
+			missing = append(missing, path)
+		}
+	}
+	w.processLock.Unlock()
+
+	var gone []string
+	for _, path := range missing {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			gone = append(gone, path)
+		}
+	}
+	if len(gone) == 0 {
+		return
+	}
+
+	w.processLock.Lock()
+	for _, path := range gone {
+		delete(w.pollState, path)
+	}
+	w.processLock.Unlock()
+}
+
 // periodically cleans the processed files map
 func (w *Watcher) periodicCleanup() {
 	ticker := time.NewTicker(15 * time.Minute)
@@ -282,7 +618,7 @@ func (w *Watcher) periodicCleanup() {
 			w.logger.Debug("Cleaned processed files cache")
 
 		default:
-			if !w.running {
+			if !w.running.Load() {
 				return
 			}
 			time.Sleep(1 * time.Second)
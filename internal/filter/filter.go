@@ -0,0 +1,272 @@
+// BYZRA ⸻ internal/filter/filter.go
+// small expression language for selecting files
+
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a compiled expression such as
+//
+//	size<500MB && mime=~"image/" && age>10s && name!~"\.volena\."
+type Filter struct {
+	source string
+	root   node
+}
+
+// what an expression is evaluated against
+type File struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+
+	// detected from the content when empty and the expression asks
+	MimeType string
+	Format   string
+}
+
+// reports the MIME type and format of a file from its content, empty when
+// it is not recognized (analyse.DetectType)
+type Detector func(path string) (mimeType, format string)
+
+// the file at path, as an expression sees it
+func Stat(path string) (File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Path: path, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// compiles an expression; an empty one matches everything
+func Parse(expr string) (*Filter, error) {
+	f := &Filter{source: expr}
+	if strings.TrimSpace(expr) == "" {
+		return f, nil
+	}
+
+	p := &parser{lexer: lexer{input: expr}}
+	p.next()
+	root, err := p.or()
+	if err == nil && p.tok.kind != tokEOF {
+		err = p.errorf("unexpected %s", p.tok)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	f.root = root
+	return f, nil
+}
+
+// the expression as written
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.source
+}
+
+// does the file satisfy the expression? detect is only called when the
+// expression looks at mime or format; a nil filter matches everything
+func (f *Filter) Match(file File, detect Detector) bool {
+	if f == nil || f.root == nil {
+		return true
+	}
+	return f.root.eval(&subject{File: file, detect: detect})
+}
+
+// matches the file at path; files that cannot be read never match
+func (f *Filter) MatchPath(path string, detect Detector) bool {
+	if f == nil || f.root == nil {
+		return true
+	}
+	file, err := Stat(path)
+	return err == nil && f.Match(file, detect)
+}
+
+// does the expression look at the age of a file? such a filter can start
+// matching later without the file changing
+func (f *Filter) UsesAge() bool {
+	return f != nil && f.root != nil && f.root.uses(fieldAge)
+}
+
+// ╭─ FIELDS ────────────────────────────────────╮
+
+type field int
+
+const (
+	fieldName   field = iota // base name
+	fieldPath                // full path
+	fieldExt                 // extension, lowercase without the dot
+	fieldMime                // detected MIME type
+	fieldFormat              // detected format ("image", "matroska")
+	fieldSize                // bytes
+	fieldAge                 // time since the last modification
+)
+
+var fields = map[string]field{
+	"name":   fieldName,
+	"path":   fieldPath,
+	"ext":    fieldExt,
+	"mime":   fieldMime,
+	"format": fieldFormat,
+	"size":   fieldSize,
+	"age":    fieldAge,
+}
+
+func (fl field) numeric() bool {
+	return fl == fieldSize || fl == fieldAge
+}
+
+// the string value of a text field
+func (fl field) text(file *subject) string {
+	switch fl {
+	case fieldName:
+		return filepath.Base(file.Path)
+	case fieldPath:
+		return file.Path
+	case fieldExt:
+		return strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Path), "."))
+	}
+
+	// detection only happens for expressions that ask
+	if file.MimeType == "" && file.Format == "" && !file.detected && file.detect != nil {
+		file.MimeType, file.Format = file.detect(file.Path)
+		file.detected = true
+	}
+	if fl == fieldMime {
+		return file.MimeType
+	}
+	return file.Format
+}
+
+// the value of a numeric field: bytes, or nanoseconds of age
+func (fl field) number(file *subject) int64 {
+	if fl == fieldSize {
+		return file.Size
+	}
+	return int64(time.Since(file.ModTime))
+}
+
+// ╭─ EVALUATION ────────────────────────────────╮
+
+// a file during evaluation, its type detected at most once
+type subject struct {
+	File
+	detect   Detector
+	detected bool
+}
+
+type node interface {
+	eval(file *subject) bool
+	uses(fl field) bool
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ operand node }
+
+func (n andNode) eval(file *subject) bool { return n.left.eval(file) && n.right.eval(file) }
+func (n orNode) eval(file *subject) bool  { return n.left.eval(file) || n.right.eval(file) }
+func (n notNode) eval(file *subject) bool { return !n.operand.eval(file) }
+
+func (n andNode) uses(fl field) bool { return n.left.uses(fl) || n.right.uses(fl) }
+func (n orNode) uses(fl field) bool  { return n.left.uses(fl) || n.right.uses(fl) }
+func (n notNode) uses(fl field) bool { return n.operand.uses(fl) }
+
+// one comparison, e.g. size<500MB
+type compare struct {
+	field field
+	op    string
+	text  string         // "=" and "!=" on text fields: a glob
+	re    *regexp.Regexp // "=~" and "!~"
+	num   int64          // numeric fields
+}
+
+func (c compare) uses(fl field) bool { return c.field == fl }
+
+func (c compare) eval(file *subject) bool {
+	if c.field.numeric() {
+		v := c.field.number(file)
+		switch c.op {
+		case "<":
+			return v < c.num
+		case "<=":
+			return v <= c.num
+		case ">":
+			return v > c.num
+		case ">=":
+			return v >= c.num
+		case "!=":
+			return v != c.num
+		}
+		return v == c.num
+	}
+
+	v := c.field.text(file)
+	switch c.op {
+	case "=~":
+		return c.re.MatchString(v)
+	case "!~":
+		return !c.re.MatchString(v)
+	}
+	ok, _ := filepath.Match(c.text, v)
+	if c.field == fieldExt || c.field == fieldMime || c.field == fieldFormat {
+		ok, _ = filepath.Match(strings.ToLower(c.text), strings.ToLower(v))
+	}
+	if c.op == "!=" {
+		return !ok
+	}
+	return ok
+}
+
+// ╭─ VALUES ────────────────────────────────────╮
+
+// "500MB", "1.5G", "2048" (bytes); units are powers of 1024
+func parseSize(value string) (int64, error) {
+	number, unit := splitUnit(value)
+	scale := map[string]float64{
+		"": 1, "b": 1,
+		"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+		"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+		"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+		"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+	}[strings.ToLower(unit)]
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || scale == 0 || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500MB, 2G, 4096)", value)
+	}
+	return int64(n * scale), nil
+}
+
+// "10s", "5m", "2h", "30d", "1w"
+func parseAge(value string) (int64, error) {
+	number, unit := splitUnit(value)
+	scale := map[string]time.Duration{
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute,
+		"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+	}[strings.ToLower(unit)]
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || scale == 0 || n < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 10s, 5m, 2h, 30d)", value)
+	}
+	return int64(n * float64(scale)), nil
+}
+
+// "500MB" → "500", "MB"
+func splitUnit(value string) (string, string) {
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		return value, ""
+	}
+	return value[:i], value[i:]
+}
@@ -0,0 +1,267 @@
+// BYZRA ⸻ internal/filter/parse.go
+// tokenizer and recursive-descent parser for filter expressions
+
+package filter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ╭─ TOKENS ────────────────────────────────────╮
+
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokWord             // field name or bare value
+	tokString           // "quoted" or 'quoted' value
+	tokOp               // comparison operator
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // byte offset, for error messages
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return "\"" + t.text + "\""
+}
+
+// comparison operators, longest first so "<=" wins over "<"
+var operators = []string{"==", "!=", "=~", "!~", "<=", ">=", "=", "<", ">"}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && strings.ContainsRune(" \t\r\n", rune(l.input[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	rest := l.input[l.pos:]
+	switch {
+	case strings.HasPrefix(rest, "&&"):
+		l.pos += 2
+		return token{tokAnd, "&&", start}, nil
+	case strings.HasPrefix(rest, "||"):
+		l.pos += 2
+		return token{tokOr, "||", start}, nil
+	case rest[0] == '(':
+		l.pos++
+		return token{tokLParen, "(", start}, nil
+	case rest[0] == ')':
+		l.pos++
+		return token{tokRParen, ")", start}, nil
+	case rest[0] == '"' || rest[0] == '\'':
+		return l.quoted(rest[0])
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			l.pos += len(op)
+			return token{tokOp, op, start}, nil
+		}
+	}
+	if rest[0] == '!' {
+		l.pos++
+		return token{tokNot, "!", start}, nil
+	}
+
+	// a bare word runs up to whitespace, an operator or a parenthesis
+	for l.pos < len(l.input) && !strings.ContainsRune(" \t\r\n()!=<>&|\"'", rune(l.input[l.pos])) {
+		l.pos++
+	}
+	if l.pos == start {
+		return token{}, fmt.Errorf("unexpected %q at column %d", l.input[start], start+1)
+	}
+	return token{tokWord, l.input[start:l.pos], start}, nil
+}
+
+// a quoted value; a backslash keeps the quote character, other escapes are
+// left for the regular expression
+func (l *lexer) quoted(quote byte) (token, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch {
+		case c == quote:
+			l.pos++
+			return token{tokString, b.String(), start}, nil
+		case c == '\\' && l.pos+1 < len(l.input) && l.input[l.pos+1] == quote:
+			b.WriteByte(quote)
+			l.pos += 2
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at column %d", start+1)
+}
+
+// ╭─ PARSER ────────────────────────────────────╮
+
+// or      = and { "||" and }
+// and     = unary { "&&" unary }
+// unary   = "!" unary | "(" or ")" | field op value
+type parser struct {
+	lexer lexer
+	tok   token
+	err   error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lexer.next()
+	if p.err != nil {
+		p.tok = token{kind: tokEOF, pos: p.lexer.pos}
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf(format+" at column %d", append(args, p.tok.pos+1)...)
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	for err == nil && p.tok.kind == tokOr {
+		p.next()
+		var right node
+		if right, err = p.and(); err == nil {
+			left = orNode{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	for err == nil && p.tok.kind == tokAnd {
+		p.next()
+		var right node
+		if right, err = p.unary(); err == nil {
+			left = andNode{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *parser) unary() (node, error) {
+	switch p.tok.kind {
+	case tokNot:
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case tokLParen:
+		p.next()
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected \")\" but found %s", p.tok)
+		}
+		p.next()
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	if p.tok.kind != tokWord {
+		return nil, p.errorf("expected a field (%s) but found %s", fieldNames(), p.tok)
+	}
+	fl, ok := fields[strings.ToLower(p.tok.text)]
+	if !ok {
+		return nil, p.errorf("unknown field %q (%s)", p.tok.text, fieldNames())
+	}
+	name := p.tok.text
+	p.next()
+
+	if p.tok.kind != tokOp {
+		return nil, p.errorf("expected an operator after %s but found %s", name, p.tok)
+	}
+	op := p.tok.text
+	if op == "==" {
+		op = "="
+	}
+	opTok := p.tok
+	p.next()
+
+	if p.tok.kind != tokWord && p.tok.kind != tokString {
+		return nil, p.errorf("expected a value after %s%s but found %s", name, op, p.tok)
+	}
+	value := p.tok.text
+	valueTok := p.tok
+	p.next()
+
+	c := compare{field: fl, op: op}
+	var err error
+	switch {
+	case fl.numeric() && (op == "=~" || op == "!~"):
+		p.tok = opTok
+		return nil, p.errorf("%s is a number; %s needs a text field", name, op)
+	case fl == fieldSize:
+		c.num, err = parseSize(value)
+	case fl == fieldAge:
+		c.num, err = parseAge(value)
+	case op == "=~" || op == "!~":
+		c.re, err = regexp.Compile(value)
+	case op == "=" || op == "!=":
+		if fl == fieldExt {
+			value = strings.TrimPrefix(value, ".")
+		}
+		if _, err = filepath.Match(value, ""); err != nil {
+			err = fmt.Errorf("invalid pattern %q", value)
+		}
+		c.text = value
+	default:
+		p.tok = opTok
+		return nil, p.errorf("%s is text; %s needs size or age", name, op)
+	}
+	if err != nil {
+		p.tok = valueTok
+		return nil, p.errorf("%v", err)
+	}
+	return c, nil
+}
+
+// "age, ext, format, ..." for error messages
+func fieldNames() string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,183 @@
+// BYZRA ⸻ internal/find/find.go
+// streams paths of files matching sensitivity criteria, analysed in parallel
+
+package find
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"caligra/internal/analyse"
+	"caligra/internal/census"
+	"caligra/internal/filter"
+	"caligra/internal/formats"
+)
+
+// what a file must match; zero values match everything
+type Criteria struct {
+	// kinds of exposure the file must all carry; empty means any sensitive field
+	Has []census.Exposure
+
+	// formats ("image", "matroska") or extensions ("jpg"); any one matches
+	Formats []string
+
+	// only files modified at or after this time
+	Since time.Time
+
+	// expression on name, size, age and type (--filter); nil matches all
+	Filter *filter.Filter
+}
+
+// walks dir and analyses candidate files on workers goroutines (NumCPU when
+// 0); match and fail are called as results arrive, never concurrently
+func Run(dir string, criteria Criteria, workers int, match func(path string), fail func(path string, err error)) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", dir)
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	paths := make(chan string, workers*4)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				ok, err := criteria.matches(path)
+				mu.Lock()
+				switch {
+				case err != nil:
+					if fail != nil {
+						fail(path, err)
+					}
+				case ok:
+					match(path)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// cheap checks on the walk, so only candidates get analysed
+	walkErr := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if fail != nil && path != dir {
+				mu.Lock()
+				fail(path, err)
+				mu.Unlock()
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !criteria.candidate(path, entry) {
+			return nil
+		}
+		paths <- path
+		return nil
+	})
+
+	close(paths)
+	wg.Wait()
+	return walkErr
+}
+
+// supported, of a wanted format by name, recent enough and selected by the
+// filter expression
+func (c Criteria) candidate(path string, entry fs.DirEntry) bool {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if !formats.IsSupported(ext) {
+		return false
+	}
+	if len(c.Formats) > 0 {
+		format, _ := formats.GetFormatType(ext)
+		if !slices.Contains(c.Formats, ext) && !slices.Contains(c.Formats, format) {
+			return false
+		}
+	}
+	if !c.Since.IsZero() {
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(c.Since) {
+			return false
+		}
+	}
+	if c.Filter != nil {
+		info, err := entry.Info()
+		if err != nil || !c.Filter.Match(filter.File{Path: path, Size: info.Size(), ModTime: info.ModTime()}, analyse.DetectType) {
+			return false
+		}
+	}
+	return true
+}
+
+// analyses path and checks the detected format and its exposure
+func (c Criteria) matches(path string) (bool, error) {
+	report, err := analyse.Analyze(path)
+	if err != nil {
+		return false, err
+	}
+
+	// the content may not be what the name said
+	if len(c.Formats) > 0 && !slices.Contains(c.Formats, report.FileType.Format) &&
+		!slices.Contains(c.Formats, report.FileType.Extension) {
+		return false, nil
+	}
+
+	if len(c.Has) == 0 {
+		return len(report.SensitiveFields) > 0, nil
+	}
+	found := census.Classify(report)
+	for _, want := range c.Has {
+		if !slices.Contains(found, want) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// "2023", "2023-05", "2023-05-17" (local time), or a span back from now
+// like "30d", "2w", "12h"
+func ParseSince(value string) (time.Time, error) {
+	for _, layout := range []string{"2006", "2006-01", "2006-01-02"} {
+		if len(value) == len(layout) {
+			if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	value = strings.ToLower(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return time.Time{}, fmt.Errorf("invalid --since: %q (a date like 2023-05 or a span like 30d)", value)
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since: %q (a date like 2023-05 or a span like 30d)", value)
+	}
+	unit := map[byte]time.Duration{'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[value[len(value)-1]]
+	if unit == 0 {
+		return time.Time{}, fmt.Errorf("invalid --since: %q (units are h, d and w)", value)
+	}
+	return time.Now().Add(-time.Duration(n) * unit), nil
+}
@@ -0,0 +1,144 @@
+// BYZRA ⸻ internal/formats/archive.go
+// archive handler: members listed, and wiped with their own handlers
+
+package formats
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// implements FormatHandler for ZIP and TAR archives, the latter plain or
+// gzip-compressed
+type ArchiveHandler struct {
+	depth int // archives this one is nested in
+}
+
+// how deep archives inside archives are opened; deeper ones are copied
+const archiveMaxDepth = 4
+
+// largest member extracted to be wiped
+const archiveMaxMember = 4 << 30
+
+// members reported one by one; the rest are counted
+const archiveMaxListed = 100
+
+// the kind of archive at path: "tar", "tgz", or "zip" for anything else
+func archiveKind(path string) string {
+	if kind := TarArchiveType(path); kind != "" {
+		return kind
+	}
+	return "zip"
+}
+
+// comments, and per member its dates, owner and extra fields or PAX records
+func (h *ArchiveHandler) ExtractMetadata(path string) (map[string]any, error) {
+	if archiveKind(path) != "zip" {
+		return extractTarMetadata(path)
+	}
+	return extractZipMetadata(path)
+}
+
+// rebuilds the archive: dates and owners reset, extra fields, PAX records
+// and comments dropped, supported members wiped by their handlers
+func (h *ArchiveHandler) WipeMetadata(path string) error {
+	if archiveKind(path) != "zip" {
+		return h.wipeTar(path)
+	}
+	return h.wipeZip(path)
+}
+
+// ZIP archives hold a comment; TAR entries an owner name and a date, and
+// the archive a comment
+func (h *ArchiveHandler) InjectMetadata(path string, profile map[string]string) error {
+	if archiveKind(path) != "zip" {
+		return injectTar(path, profile)
+	}
+	for key, value := range profile {
+		if strings.EqualFold(key, "comment") && value != "" {
+			return rebuildZip(path, value, false, nil)
+		}
+	}
+	return nil
+}
+
+// every member decompresses and matches its checksum
+func (h *ArchiveHandler) VerifyIntegrity(path string) bool {
+	if archiveKind(path) != "zip" {
+		return verifyTar(path)
+	}
+	return verifyZip(path)
+}
+
+// the members a wipe runs a handler on
+func (h *ArchiveHandler) ListEmbedded(path string) ([]Embedded, error) {
+	if archiveKind(path) != "zip" {
+		return h.listTarMembers(path)
+	}
+	return h.listZipMembers(path)
+}
+
+// the handler that wipes a member, by its extension; nil for members that
+// are copied as they are: unsupported ones, archives nested too deep, text,
+// whose metadata is part of what it says, and .gz files other than .tar.gz,
+// which may hold anything
+func (h *ArchiveHandler) memberHandler(name string) FormatHandler {
+	format := memberFormat(name)
+	switch {
+	case format == "" || format == "text" || strings.HasSuffix(name, "/"):
+		return nil
+	case format == "archive":
+		lower := strings.ToLower(name)
+		if h.depth+1 >= archiveMaxDepth || (path.Ext(lower) == ".gz" && !strings.HasSuffix(lower, ".tar.gz")) {
+			return nil
+		}
+		return &ArchiveHandler{depth: h.depth + 1}
+	}
+	handler, err := GetHandler(format)
+	if err != nil {
+		return nil
+	}
+	return handler
+}
+
+// the format a member's extension belongs to, "" when unsupported
+func memberFormat(name string) string {
+	format, _ := GetFormatType(path.Ext(name))
+	return format
+}
+
+// extracts a member into dir and wipes it with handler; the file it
+// returns passed the handler's integrity check. Signed executables are
+// copied as they are: "" and no error
+func wipeMember(handler FormatHandler, dir, name string, r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp(dir, "member-*"+path.Ext(name))
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(tmp, io.LimitReader(r, archiveMaxMember+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("%s: failed to extract: %w", name, err)
+	case n > archiveMaxMember:
+		return "", fmt.Errorf("%s: larger than %d bytes, too large to wipe", name, int64(archiveMaxMember))
+	}
+
+	err = handler.WipeMetadata(tmp.Name())
+	switch {
+	case errors.Is(err, errPESigned):
+		return "", nil // copied with its signature intact
+	case err != nil:
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	if !handler.VerifyIntegrity(tmp.Name()) {
+		return "", fmt.Errorf("%s: integrity check failed after the wipe", name)
+	}
+	return tmp.Name(), nil
+}
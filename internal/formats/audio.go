@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"caligra/internal/util"
 )
@@ -16,22 +17,21 @@ type AudioHandler struct{}
 
 // extracts metadata from audio files
 func (h *AudioHandler) ExtractMetadata(path string) (map[string]any, error) {
-	data, err := util.ExifToolExtract(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract audio metadata: %w", err)
+	if waveKind(path) != "" {
+		return extractWaveMetadata(path) // exiftool reads WAV and AIFF but cannot write them
 	}
-
-	// parse the JSON response into a map
-	metadata, err := util.ParseExifToolOutput(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse audio metadata: %w", err)
-	}
-
-	return metadata, nil
+	return extractMediaMetadata(path, "audio")
 }
 
 // removes all metadata from audio files
 func (h *AudioHandler) WipeMetadata(path string) error {
+	if waveKind(path) != "" {
+		if err := wipeWaveMetadata(path); err != nil {
+			return fmt.Errorf("failed to wipe audio metadata: %w", err)
+		}
+		return nil
+	}
+
 	err := util.ExifToolRemove(path)
 	if err != nil {
 		return fmt.Errorf("failed to wipe audio metadata: %w", err)
@@ -41,15 +41,25 @@ func (h *AudioHandler) WipeMetadata(path string) error {
 
 // adds profile metadata to audio files
 func (h *AudioHandler) InjectMetadata(path string, profile map[string]string) error {
+	if waveKind(path) != "" {
+		if err := injectWaveMetadata(path, profile); err != nil {
+			return fmt.Errorf("failed to inject audio metadata: %w", err)
+		}
+		return nil
+	}
+
+	mapTag := mapProfileKeyToAudioTag
+	if isM4A(path) {
+		mapTag = mapProfileKeyToITunesTag
+	}
 	for key, value := range profile {
 		// map profile keys to audio metadata tags
-		tag := mapProfileKeyToAudioTag(key)
+		tag := mapTag(key)
 		if tag == "" {
 			continue // skip unmapped keys
 		}
 
-		cmd := exec.Command("exiftool", fmt.Sprintf("-%s=%s", tag, value), "-overwrite_original", path)
-		if err := cmd.Run(); err != nil {
+		if err := util.ExifToolSetTag(path, tag, value); err != nil {
 			return fmt.Errorf("failed to inject %s metadata: %w", key, err)
 		}
 	}
@@ -83,3 +93,51 @@ func mapProfileKeyToAudioTag(key string) string {
 		return ""
 	}
 }
+
+// maps profile keys to the iTunes atoms of M4A files (©ART, ©too, ©day,
+// cprt, ©wrt, ©cmt), as exiftool names them
+func mapProfileKeyToITunesTag(key string) string {
+	switch strings.ToLower(key) {
+	case "author":
+		return "ItemList:Artist"
+	case "software":
+		return "ItemList:Encoder"
+	case "created":
+		return "ItemList:ContentCreateDate"
+	case "organization":
+		return "ItemList:Copyright"
+	case "location":
+		return "ItemList:Composer" // as for the other audio formats
+	case "comment":
+		return "ItemList:Comment"
+	default:
+		return ""
+	}
+}
+
+// an MPEG-4 audio file (M4A, or M4B for audiobooks and M4P from the iTunes
+// Store) rather than an MP4 video
+func isM4A(path string) bool {
+	switch bmffBrand(path) {
+	case "M4A ", "M4B ", "M4P ":
+		return true
+	}
+	return false
+}
+
+// rewrites date-time tags into loc and strips offset tags
+func (h *AudioHandler) NormalizeTimestamps(path string, loc *time.Location) ([]string, error) {
+	if waveKind(path) != "" {
+		return nil, nil // INFO and bext dates carry no zone
+	}
+	return exifToolNormalizeTimestamps(path, loc)
+}
+
+// lists ID3 chapter tables and lyrics, and the production notes of WAV and
+// AIFF files
+func (h *AudioHandler) ListEmbedded(path string) ([]Embedded, error) {
+	if waveKind(path) != "" {
+		return listWaveEmbedded(path)
+	}
+	return listID3Content(path)
+}
@@ -0,0 +1,256 @@
+// BYZRA ⸻ internal/formats/audiopolicy.go
+// policy-driven wiping of audio files
+
+package formats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"caligra/internal/config"
+	"caligra/internal/util"
+)
+
+// a group of tags a policy keeps or removes as one
+type tagFamily struct {
+	match  func(name string) bool // on normalized names ("musicbrainz_album_id")
+	choice func(p *config.Policy) string
+	report func(o *PolicyOutcome) *string
+	lame   bool // also lives in the MP3 LAME header
+}
+
+var audioTagFamilies = []tagFamily{
+	{
+		match: func(name string) bool {
+			switch name {
+			case "encoder", "encoded_by", "encoder_options", "encoder_settings", "encoding":
+				return true
+			}
+			return false
+		},
+		choice: func(p *config.Policy) string { return p.Encoder },
+		report: func(o *PolicyOutcome) *string { return &o.Encoder },
+		lame:   true,
+	},
+	{
+		match: func(name string) bool {
+			return strings.HasPrefix(name, "replaygain_") || strings.HasPrefix(name, "r128_")
+		},
+		choice: func(p *config.Policy) string { return p.ReplayGain },
+		report: func(o *PolicyOutcome) *string { return &o.ReplayGain },
+		lame:   true,
+	},
+	{
+		// MUSICBRAINZ_TRACKID, "MusicBrainz Album Id", ...
+		match:  func(name string) bool { return strings.HasPrefix(name, "musicbrainz_") },
+		choice: func(p *config.Policy) string { return p.MusicBrainz },
+		report: func(o *PolicyOutcome) *string { return &o.MusicBrainz },
+	},
+	{
+		// ACOUSTID_ID, "Acoustid Fingerprint", ...
+		match:  func(name string) bool { return strings.HasPrefix(name, "acoustid_") },
+		choice: func(p *config.Policy) string { return p.AcoustID },
+		report: func(o *PolicyOutcome) *string { return &o.AcoustID },
+	},
+}
+
+// "MusicBrainz Album Id" -> "musicbrainz_album_id"
+func normalizeTagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
+
+// wipes an audio file, handling cover art and each tag family explicitly
+func (h *AudioHandler) WipeWithPolicy(path string, policy *config.Policy) (*PolicyOutcome, error) {
+	outcome := &PolicyOutcome{}
+
+	// tags the policy keeps, read before they are wiped
+	kept, err := keptAudioTags(path, policy)
+	if err != nil {
+		return outcome, err
+	}
+
+	cover, err := saveCoverArt(path, policy.CoverArt, outcome)
+	if err != nil {
+		return outcome, err
+	}
+	if cover != nil {
+		defer cover.discard()
+	}
+
+	// chapters and lyrics go first and by name, whatever the tag wipe covers
+	frames, err := stripID3Frames(path, id3ContentFrames)
+	if err != nil {
+		return outcome, err
+	}
+	if len(frames) > 0 {
+		outcome.Frames = "removed " + summarizeFrames(frames)
+	}
+
+	if err := h.WipeMetadata(path); err != nil {
+		return outcome, err
+	}
+
+	// the LAME header sits in the audio stream, where tag removal never looks
+	lame := false
+	if strings.HasSuffix(strings.ToLower(path), ".mp3") {
+		lame, err = scrubLAMEHeader(path, lameScrub{
+			encoder:    policy.Encoder == config.Remove,
+			replayGain: policy.ReplayGain == config.Remove,
+		})
+		if err != nil {
+			return outcome, err
+		}
+	}
+
+	// ffmpeg drops the source's Xing/Info frame and writes its own, so a
+	// remux loses the LAME header whatever the policy keeps
+	remuxed := false
+	if len(kept) > 0 {
+		remuxed = true
+		args := []string{"-map", "0", "-map_chapters", "-1"}
+		for _, name := range sortedKeys(kept) {
+			args = append(args, "-metadata", name+"="+kept[name])
+		}
+		err := remuxInPlace(path, func(src, dst string) error {
+			return util.FFmpegRemux(src, dst, args)
+		})
+		if err != nil {
+			return outcome, fmt.Errorf("failed to restore kept tags: %w", err)
+		}
+	}
+
+	if cover != nil {
+		remuxed = true
+		if err := cover.restore(path); err != nil {
+			return outcome, err
+		}
+	}
+
+	for _, family := range audioTagFamilies {
+		*family.report(outcome) = describeTagFamily(family, policy, kept, lame, lame && remuxed)
+	}
+	if len(policy.KeepTags) > 0 {
+		outcome.Tags = describeKeptTags(policy, kept)
+	}
+	return outcome, nil
+}
+
+// format-level tags in the families the policy keeps, under their original names
+func keptAudioTags(path string, policy *config.Policy) (map[string]string, error) {
+	kept := make(map[string]string)
+
+	keeping := len(policy.KeepTags) > 0
+	for _, family := range audioTagFamilies {
+		if family.choice(policy) == config.Keep {
+			keeping = true
+		}
+	}
+	if !keeping {
+		return kept, nil
+	}
+
+	if !util.HasFFProbe() {
+		return nil, fmt.Errorf("ffprobe is required to keep audio tags")
+	}
+
+	tags, err := util.FFProbeFormatTags(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keepByName := make(map[string]bool)
+	for _, name := range policy.KeepTags {
+		keepByName[normalizeTagName(name)] = true
+	}
+
+	for name, value := range tags {
+		normalized := normalizeTagName(name)
+		if keepByName[normalized] {
+			kept[name] = value
+			continue
+		}
+		for _, family := range audioTagFamilies {
+			if family.choice(policy) == config.Keep && family.match(normalized) {
+				kept[name] = value
+			}
+		}
+	}
+	return kept, nil
+}
+
+// "kept (encoder)" / "removed (tags and LAME header)"; lost is set when a
+// remux replaced the LAME header after it was scrubbed
+func describeTagFamily(family tagFamily, policy *config.Policy, kept map[string]string, lame, lost bool) string {
+	if family.choice(policy) == config.Keep {
+		var names []string
+		for name := range kept {
+			if family.match(normalizeTagName(name)) {
+				names = append(names, name)
+			}
+		}
+		described := "kept (none present)"
+		if len(names) > 0 {
+			sort.Strings(names)
+			described = "kept (" + strings.Join(names, ", ") + ")"
+		}
+		if lost && family.lame {
+			described += "; LAME header not kept (rewritten by the remux)"
+		}
+		return described
+	}
+
+	if lame && family.lame {
+		return "removed (tags and LAME header)"
+	}
+	return "removed"
+}
+
+// "kept title, artist, album; removed the rest"
+func describeKeptTags(policy *config.Policy, kept map[string]string) string {
+	keepByName := make(map[string]bool)
+	for _, name := range policy.KeepTags {
+		keepByName[normalizeTagName(name)] = true
+	}
+
+	var names []string
+	for _, name := range sortedKeys(kept) {
+		if keepByName[normalizeTagName(name)] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none of the kept tags present; removed the rest"
+	}
+	return "kept " + strings.Join(names, ", ") + "; removed the rest"
+}
+
+// "CHAP ×3, USLT"
+func summarizeFrames(ids []string) string {
+	counts := make(map[string]int)
+	var order []string
+	for _, id := range ids {
+		if counts[id] == 0 {
+			order = append(order, id)
+		}
+		counts[id]++
+	}
+
+	parts := make([]string, len(order))
+	for i, id := range order {
+		parts[i] = id
+		if counts[id] > 1 {
+			parts[i] = fmt.Sprintf("%s ×%d", id, counts[id])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,505 @@
+// BYZRA ⸻ internal/formats/calendar.go
+// iCalendar handler: organizers, attendees, UIDs, stamps and the producing
+// software reported, then removed or replaced
+
+package formats
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"caligra/internal/config"
+)
+
+// implements FormatHandler for iCalendar files (.ics)
+type CalendarHandler struct{}
+
+// what every DTSTAMP is reset to, the date TAR and ZIP entries get; the
+// property is required, so it cannot go
+const calendarResetStamp = "19800101T000000Z"
+
+// stripped attendees and organizers get addresses in a reserved domain,
+// numbered by first appearance
+const calendarAnonymousDomain = "@calendar.invalid"
+
+// properties recording when and by whom an entry was made rather than what
+// it says; removed whole
+var calendarRemoved = []string{"CREATED", "LAST-MODIFIED", "CONTACT"}
+
+// vendor properties clients need to read the times right; every other X-
+// property is removed
+var calendarKeptX = []string{"X-WR-TIMEZONE", "X-LIC-LOCATION"}
+
+// parameters a stripped attendee keeps: what part it plays and how it replied
+var calendarKeptParams = []string{"CUTYPE", "ROLE", "PARTSTAT", "RSVP"}
+
+// names and UIDs reported one by one; the rest are counted
+const calendarMaxNames = 10
+
+// octets per line before folding (RFC 5545, 3.1)
+const calendarLineOctets = 75
+
+// one content line with its folded continuations and line endings, as in
+// the file
+type calendarLine struct {
+	raw    string
+	name   string // upper case; "" for blank or malformed lines
+	params []calendarParam
+	value  string
+}
+
+type calendarParam struct {
+	name  string // upper case
+	value string // as written, quotes included
+}
+
+// the parameter's value, unquoted
+func (l calendarLine) param(name string) string {
+	for _, p := range l.params {
+		if p.name == name {
+			return strings.Trim(p.value, `"`)
+		}
+	}
+	return ""
+}
+
+// splits a calendar into content lines, unfolding each; false when a line
+// is neither a property nor the continuation of one
+func splitCalendar(content string) ([]calendarLine, bool) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	var lines []calendarLine
+	var unfolded []string
+	ok := true
+	for pos := 0; pos < len(content); {
+		line := content[pos:]
+		if end := strings.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end+1]
+		}
+		pos += len(line)
+		text := strings.TrimRight(line, "\r\n")
+
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].raw += line
+			unfolded[len(unfolded)-1] += text[1:]
+			continue
+		}
+		lines = append(lines, calendarLine{raw: line})
+		unfolded = append(unfolded, text)
+	}
+	for i, text := range unfolded {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if !parseCalendarLine(&lines[i], text) {
+			ok = false
+		}
+	}
+	return lines, ok
+}
+
+// name, parameters and value of an unfolded content line:
+// NAME;PARAM=value,"quoted";PARAM=value:value
+func parseCalendarLine(line *calendarLine, text string) bool {
+	i := strings.IndexAny(text, ";:")
+	if i <= 0 {
+		return false
+	}
+	line.name = strings.ToUpper(text[:i])
+	for text[i] == ';' {
+		eq := strings.IndexByte(text[i:], '=')
+		if eq < 0 {
+			return false
+		}
+		name := strings.ToUpper(text[i+1 : i+eq])
+		start, quoted := i+eq+1, false
+		j := start
+		for ; j < len(text); j++ {
+			if text[j] == '"' {
+				quoted = !quoted
+			} else if !quoted && (text[j] == ';' || text[j] == ':') {
+				break
+			}
+		}
+		if j == len(text) {
+			return false
+		}
+		line.params = append(line.params, calendarParam{name: name, value: text[start:j]})
+		i = j
+	}
+	line.value = text[i+1:]
+	return true
+}
+
+// a content line, folded at 75 octets without splitting a character
+func formatCalendarLine(name string, params []calendarParam, value, newline string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, p := range params {
+		b.WriteString(";" + p.name + "=" + p.value)
+	}
+	b.WriteString(":" + value)
+
+	text := b.String()
+	var out strings.Builder
+	for limit := calendarLineOctets; len(text) > limit; limit = calendarLineOctets - 1 {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		out.WriteString(text[:cut] + newline + " ")
+		text = text[cut:]
+	}
+	out.WriteString(text + newline)
+	return out.String()
+}
+
+// TEXT values with their escapes undone and line breaks as spaces
+func unescapeCalendarText(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+func escapeCalendarText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(value)
+}
+
+// "Jane Doe <jane@example.org>" for an ORGANIZER or ATTENDEE
+func calendarPerson(line calendarLine) string {
+	address := line.value
+	if len(address) > 7 && strings.EqualFold(address[:7], "mailto:") {
+		address = address[7:]
+	}
+	if name := line.param("CN"); name != "" {
+		return name + " <" + address + ">"
+	}
+	return address
+}
+
+// "ics" for a file whose first line is BEGIN:VCALENDAR, "" otherwise
+func CalendarType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 64)
+	n, _ := io.ReadFull(file, head)
+	content := strings.TrimLeft(strings.TrimPrefix(string(head[:n]), "\ufeff"), "\r\n")
+	first, _, _ := strings.Cut(content, "\n")
+	if strings.EqualFold(strings.TrimSpace(first), "BEGIN:VCALENDAR") {
+		return "ics"
+	}
+	return ""
+}
+
+// ╭─ HANDLER ───────────────────────────────────╮
+
+// the producing software, organizers, attendees, UIDs, creation and
+// modification stamps, contacts and vendor X- properties
+func (h *CalendarHandler) ExtractMetadata(path string) (map[string]any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	lines, _ := splitCalendar(string(content))
+
+	metadata := make(map[string]any)
+	var organizers, attendees, uids []string
+	var stamps []time.Time
+	anonymous, randomUIDs := 0, 0
+	values := make(map[string][]string)
+	var names []string
+
+	add := func(list *[]string, value string) {
+		if value != "" && !slices.Contains(*list, value) {
+			*list = append(*list, value)
+		}
+	}
+	for _, line := range lines {
+		switch {
+		case line.name == "PRODID":
+			if value := unescapeCalendarText(line.value); value != "" {
+				metadata["Software"] = value
+			}
+		case line.name == "ORGANIZER" || line.name == "ATTENDEE":
+			switch {
+			case strings.HasSuffix(strings.ToLower(line.value), calendarAnonymousDomain):
+				anonymous++
+			case line.name == "ORGANIZER":
+				add(&organizers, calendarPerson(line))
+			default:
+				add(&attendees, calendarPerson(line))
+			}
+		case line.name == "UID":
+			if isRandomUUID(line.value) {
+				randomUIDs++
+			} else {
+				add(&uids, line.value)
+			}
+		case line.name == "DTSTAMP" || line.name == "CREATED" || line.name == "LAST-MODIFIED":
+			if line.value == calendarResetStamp {
+				continue
+			}
+			if t, err := time.Parse("20060102T150405Z", line.value); err == nil {
+				stamps = append(stamps, t)
+			}
+		case line.name == "CONTACT" || isRemovedCalendarX(line.name):
+			if _, seen := values[line.name]; !seen {
+				names = append(names, line.name)
+			}
+			list := values[line.name]
+			add(&list, clipEmailValue(unescapeCalendarText(line.value)))
+			values[line.name] = list
+		}
+	}
+
+	list := func(values []string) string {
+		if len(values) > calendarMaxNames {
+			values = append(values[:calendarMaxNames:calendarMaxNames], fmt.Sprintf("and %d more", len(values)-calendarMaxNames))
+		}
+		return strings.Join(values, "; ")
+	}
+	if len(organizers) > 0 {
+		metadata["Organizer"] = list(organizers)
+	}
+	if len(attendees) > 0 {
+		metadata["Attendees"] = pluralCount(len(attendees), "attendee") + ": " + list(attendees)
+	}
+	if anonymous > 0 {
+		metadata["Participants"] = pluralCount(anonymous, "anonymous attendee")
+	}
+	if len(uids) > 0 {
+		metadata["EventUID"] = list(uids)
+	}
+	if randomUIDs > 0 {
+		metadata["EventIDs"] = pluralCount(randomUIDs, "random UUID")
+	}
+	if len(stamps) > 0 {
+		sort.Slice(stamps, func(i, j int) bool { return stamps[i].Before(stamps[j]) })
+		first, last := stamps[0].Format(time.RFC3339), stamps[len(stamps)-1].Format(time.RFC3339)
+		if first == last {
+			metadata["StampDates"] = fmt.Sprintf("%s, %s", pluralCount(len(stamps), "stamp"), first)
+		} else {
+			metadata["StampDates"] = fmt.Sprintf("%s, %s to %s", pluralCount(len(stamps), "stamp"), first, last)
+		}
+	}
+	for _, name := range names {
+		if len(values[name]) > 0 {
+			metadata[calendarFieldName(name)] = list(values[name])
+		}
+	}
+	return metadata, nil
+}
+
+// removes organizers, attendees, creation and modification stamps, contacts
+// and vendor properties, replaces UIDs and DTSTAMPs, and empties PRODID
+func (h *CalendarHandler) WipeMetadata(path string) error {
+	_, err := h.WipeWithPolicy(path, config.DefaultPolicy())
+	return err
+}
+
+// as WipeMetadata, with organizers and attendees removed, kept, or kept
+// without names and addresses as the policy says. UIDs are replaced
+// consistently, so recurrence exceptions and RELATED-TO links still point
+// at their event
+func (h *CalendarHandler) WipeWithPolicy(path string, policy *config.Policy) (*PolicyOutcome, error) {
+	outcome := &PolicyOutcome{}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return outcome, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	lines, ok := splitCalendar(string(content))
+	if !ok {
+		return outcome, fmt.Errorf("failed to parse calendar")
+	}
+	newline := calendarNewline(lines)
+
+	uids := make(map[string]string)
+	people := make(map[string]string)
+	participants := 0
+	var b strings.Builder
+	for _, line := range lines {
+		switch {
+		case line.name == "PRODID":
+			b.WriteString("PRODID:" + newline)
+		case line.name == "UID" || line.name == "RELATED-TO":
+			if _, seen := uids[line.value]; !seen {
+				uids[line.value] = randomUUID()
+			}
+			// the relation type says which way the link points
+			var params []calendarParam
+			for _, p := range line.params {
+				if p.name == "RELTYPE" {
+					params = append(params, p)
+				}
+			}
+			b.WriteString(formatCalendarLine(line.name, params, uids[line.value], newline))
+		case line.name == "DTSTAMP":
+			b.WriteString("DTSTAMP:" + calendarResetStamp + newline)
+		case line.name == "ORGANIZER" || line.name == "ATTENDEE":
+			participants++
+			switch policy.Attendees {
+			case config.Keep:
+				b.WriteString(line.raw)
+			case config.AttendeesStrip:
+				address := strings.ToLower(line.value)
+				if _, seen := people[address]; !seen {
+					people[address] = fmt.Sprintf("mailto:attendee-%d%s", len(people)+1, calendarAnonymousDomain)
+				}
+				var params []calendarParam
+				for _, p := range line.params {
+					if slices.Contains(calendarKeptParams, p.name) {
+						params = append(params, p)
+					}
+				}
+				b.WriteString(formatCalendarLine(line.name, params, people[address], newline))
+			}
+		case slices.Contains(calendarRemoved, line.name) || isRemovedCalendarX(line.name):
+		default:
+			b.WriteString(line.raw)
+		}
+	}
+
+	if participants > 0 {
+		switch policy.Attendees {
+		case config.Keep:
+			outcome.Attendees = "kept"
+		case config.AttendeesStrip:
+			outcome.Attendees = fmt.Sprintf("names and addresses removed (%d)", participants)
+		default:
+			outcome.Attendees = fmt.Sprintf("removed (%d)", participants)
+		}
+	}
+	return outcome, replaceFile(path, []byte(b.String()))
+}
+
+// the profile's software as PRODID
+func (h *CalendarHandler) InjectMetadata(path string, profile map[string]string) error {
+	software := ""
+	for key, value := range profile {
+		if strings.EqualFold(key, "software") {
+			software = value
+		}
+	}
+	if software == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read calendar: %w", err)
+	}
+	lines, ok := splitCalendar(string(content))
+	if !ok {
+		return fmt.Errorf("failed to parse calendar")
+	}
+	prodid := formatCalendarLine("PRODID", nil, escapeCalendarText(software), calendarNewline(lines))
+
+	var b strings.Builder
+	written := false
+	for _, line := range lines {
+		switch {
+		case line.name == "PRODID":
+			if !written {
+				b.WriteString(prodid)
+				written = true
+			}
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VCALENDAR") && !written:
+			b.WriteString(line.raw + prodid)
+			written = true
+		default:
+			b.WriteString(line.raw)
+		}
+	}
+	return replaceFile(path, []byte(b.String()))
+}
+
+// every line parses and BEGIN and END lines pair up, VCALENDAR outermost
+func (h *CalendarHandler) VerifyIntegrity(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	lines, ok := splitCalendar(string(content))
+	if !ok {
+		return false
+	}
+	var open []string
+	calendars := 0
+	for _, line := range lines {
+		switch line.name {
+		case "BEGIN":
+			component := strings.ToUpper(line.value)
+			if (len(open) == 0) != (component == "VCALENDAR") {
+				return false
+			}
+			if component == "VCALENDAR" {
+				calendars++
+			}
+			open = append(open, component)
+		case "END":
+			if len(open) == 0 || open[len(open)-1] != strings.ToUpper(line.value) {
+				return false
+			}
+			open = open[:len(open)-1]
+		case "":
+		default:
+			if len(open) == 0 {
+				return false
+			}
+		}
+	}
+	return calendars > 0 && len(open) == 0
+}
+
+// ╭─ HELPERS ───────────────────────────────────╮
+
+func isRemovedCalendarX(name string) bool {
+	return strings.HasPrefix(name, "X-") && !slices.Contains(calendarKeptX, name)
+}
+
+// "CONTACT" as "Contact"; X- properties keep their names
+func calendarFieldName(name string) string {
+	if strings.HasPrefix(name, "X-") {
+		return name
+	}
+	return name[:1] + strings.ToLower(name[1:])
+}
+
+// the file's line ending, CRLF as the standard has it unless the file uses
+// bare LF
+func calendarNewline(lines []calendarLine) string {
+	if len(lines) > 0 && strings.HasSuffix(lines[0].raw, "\n") && !strings.HasSuffix(lines[0].raw, "\r\n") {
+		return "\n"
+	}
+	return "\r\n"
+}
+
+// a version 4 UUID, as randomUUID writes them and as some clients pick
+// their UIDs: nothing in it points back to a host or an account
+func isRandomUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+	for i, c := range strings.ToLower(value) {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case i == 14:
+			if c != '4' {
+				return false
+			}
+		case (c < '0' || c > '9') && (c < 'a' || c > 'f'):
+			return false
+		}
+	}
+	return true
+}
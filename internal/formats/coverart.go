@@ -0,0 +1,122 @@
+// BYZRA ⸻ internal/formats/coverart.go
+// embedded cover art (ID3 APIC, FLAC PICTURE) handling
+
+package formats
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"caligra/internal/config"
+	"caligra/internal/util"
+)
+
+// cover image held aside while an audio file is wiped
+type savedCover struct {
+	dir     string
+	picture string
+	before  int64
+}
+
+// extracts (and for "strip", cleans) the cover per policy; nil when there is nothing to put back
+func saveCoverArt(path, choice string, outcome *PolicyOutcome) (*savedCover, error) {
+	cover, err := findCoverArt(path, choice)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cover == nil:
+		outcome.CoverArt = "none present"
+		return nil, nil
+	case choice == config.CoverArtRemove:
+		outcome.CoverArt = "removed 1 image"
+		return nil, nil
+	}
+
+	dir, err := os.MkdirTemp("", "caligra-cover-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	saved := &savedCover{dir: dir, picture: filepath.Join(dir, "cover"+coverExtension(cover.CodecName))}
+	if err := util.FFmpegExtractStream(path, cover.Index, saved.picture); err != nil {
+		saved.discard()
+		return nil, fmt.Errorf("failed to extract cover art: %w", err)
+	}
+	saved.before = fileSize(saved.picture)
+
+	if choice == config.CoverArtStrip {
+		if err := util.ExifToolRemove(saved.picture); err != nil {
+			saved.discard()
+			return nil, fmt.Errorf("failed to strip cover art metadata: %w", err)
+		}
+		outcome.CoverArt = fmt.Sprintf("stripped metadata from 1 image (%s → %s)",
+			formatKB(saved.before), formatKB(fileSize(saved.picture)))
+	} else {
+		outcome.CoverArt = fmt.Sprintf("kept 1 image (%s)", formatKB(saved.before))
+	}
+
+	return saved, nil
+}
+
+// attaches the saved cover to the (wiped) file
+func (c *savedCover) restore(path string) error {
+	err := remuxInPlace(path, func(src, dst string) error {
+		return util.FFmpegAttachPicture(src, c.picture, dst)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reattach cover art: %w", err)
+	}
+	return nil
+}
+
+func (c *savedCover) discard() {
+	os.RemoveAll(c.dir)
+}
+
+// the attached picture stream, if any
+func findCoverArt(path, choice string) (*util.ProbeStream, error) {
+	if !util.HasFFProbe() {
+		if choice == config.CoverArtRemove {
+			return nil, nil // a plain wipe removes it anyway
+		}
+		return nil, fmt.Errorf("ffprobe is required to keep cover art")
+	}
+
+	streams, err := util.FFProbeStreams(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stream := range streams {
+		if stream.CodecType == "video" && stream.Disposition["attached_pic"] == 1 {
+			return &stream, nil
+		}
+	}
+	return nil, nil
+}
+
+func coverExtension(codec string) string {
+	switch codec {
+	case "png":
+		return ".png"
+	case "webp":
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func formatKB(size int64) string {
+	return fmt.Sprintf("%d KB", (size+1023)/1024)
+}
@@ -0,0 +1,113 @@
+// BYZRA ⸻ internal/formats/document.go
+// document format handlers
+
+package formats
+
+import (
+	"bytes"
+	"os"
+)
+
+// implements FormatHandler for office documents
+type DocumentHandler struct{}
+
+// "rtf", "ole", "odf", "epub" or "ooxml", by the first bytes of the file
+// and, for ZIP archives, their mimetype entry
+func documentKind(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 8)
+	n, _ := file.Read(head)
+	switch {
+	case bytes.HasPrefix(head[:n], rtfSignature):
+		return "rtf"
+	case bytes.Equal(head[:n], oleSignature):
+		return "ole"
+	case bytes.HasPrefix(head[:n], zipSignature) && ODFDocumentType(path) != "":
+		return "odf"
+	case bytes.HasPrefix(head[:n], zipSignature) && EPUBDocumentType(path) != "":
+		return "epub"
+	case bytes.HasPrefix(head[:n], zipSignature):
+		return "ooxml"
+	}
+	return ""
+}
+
+// extracts document properties
+func (h *DocumentHandler) ExtractMetadata(path string) (map[string]any, error) {
+	switch documentKind(path) {
+	case "rtf":
+		return extractRTFMetadata(path)
+	case "odf":
+		return extractODFMetadata(path)
+	case "epub":
+		return extractEPUBMetadata(path)
+	case "ooxml":
+		return extractOOXMLMetadata(path)
+	}
+	return extractOLEMetadata(path)
+}
+
+// removes document properties
+func (h *DocumentHandler) WipeMetadata(path string) error {
+	switch documentKind(path) {
+	case "rtf":
+		return wipeRTFMetadata(path)
+	case "odf":
+		return wipeODFMetadata(path)
+	case "epub":
+		return wipeEPUBMetadata(path)
+	case "ooxml":
+		return wipeOOXMLMetadata(path)
+	}
+	return wipeOLEMetadata(path)
+}
+
+// adds profile metadata as document properties
+func (h *DocumentHandler) InjectMetadata(path string, profile map[string]string) error {
+	switch documentKind(path) {
+	case "rtf":
+		return injectRTFMetadata(path, profile)
+	case "odf":
+		return injectODFMetadata(path, profile)
+	case "epub":
+		return injectEPUBMetadata(path, profile)
+	case "ooxml":
+		return injectOOXMLMetadata(path, profile)
+	}
+	return injectOLEMetadata(path, profile)
+}
+
+// lists embedded objects and thumbnails
+func (h *DocumentHandler) ListEmbedded(path string) ([]Embedded, error) {
+	switch documentKind(path) {
+	case "rtf":
+		return listRTFEmbedded(path)
+	case "odf":
+		return listODFEmbedded(path)
+	case "epub":
+		return listEPUBEmbedded(path)
+	case "ooxml":
+		return listOOXMLEmbedded(path)
+	}
+	return listOLEEmbedded(path)
+}
+
+// checks that the document still parses
+func (h *DocumentHandler) VerifyIntegrity(path string) bool {
+	switch documentKind(path) {
+	case "rtf":
+		return verifyRTF(path)
+	case "odf":
+		return verifyODF(path)
+	case "epub":
+		return verifyEPUB(path)
+	case "ooxml":
+		return verifyOOXML(path)
+	}
+	return verifyOLE(path)
+}
@@ -0,0 +1,225 @@
+// BYZRA ⸻ internal/formats/elfbinary.go
+// ELF executables, libraries and objects: notes, compiler comments and
+// debug info read, non-allocated sections emptied
+
+package formats
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"caligra/internal/util"
+)
+
+// ╭─ READING ───────────────────────────────────╮
+
+func extractELFMetadata(data []byte) (map[string]any, error) {
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ELF: %w", err)
+	}
+	defer f.Close()
+
+	metadata := make(map[string]any)
+	var debugSections []string
+	var debugSize uint64
+	for _, section := range f.Sections {
+		if section.Type == elf.SHT_NOBITS || section.Size == 0 {
+			continue
+		}
+		content, err := section.Data()
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case section.Type == elf.SHT_NOTE:
+			for _, note := range elfNotes(content, f.ByteOrder, section.Addralign) {
+				switch {
+				case note.name == "GNU" && note.kind == 3: // NT_GNU_BUILD_ID
+					metadata["BuildID"] = hex.EncodeToString(note.desc)
+				case note.name == "Go" && note.kind == 4: // the Go toolchain's build ID
+					metadata["GoBuildID"] = string(note.desc)
+				}
+			}
+		case section.Name == ".comment":
+			// one NUL-terminated string per compiler and linker that touched
+			// the file
+			if comments := elfStrings(content); comments != "" {
+				metadata["Compiler"] = comments
+			}
+		case section.Name == ".GCC.command.line":
+			if commands := elfStrings(content); commands != "" {
+				metadata["CompilerCommandLine"] = commands
+			}
+		case section.Name == ".gnu_debuglink" || section.Name == ".gnu_debugaltlink":
+			// the file name of the separate debug info, then its checksum
+			if name, _, _ := bytes.Cut(content, []byte{0}); len(name) > 0 {
+				metadata["DebugLink"] = string(name)
+			}
+		case strings.HasPrefix(section.Name, ".debug") || strings.HasPrefix(section.Name, ".zdebug"):
+			debugSections = append(debugSections, section.Name)
+			debugSize += section.FileSize
+		case section.Type == elf.SHT_SYMTAB:
+			if section.Entsize > 0 {
+				metadata["SymbolTable"] = fmt.Sprintf("%d symbols", section.Size/section.Entsize)
+			}
+		}
+	}
+
+	if len(debugSections) > 0 {
+		metadata["DebugInfo"] = fmt.Sprintf("%s (%s)", strings.Join(debugSections, ", "), util.FormatBytes(int64(debugSize)))
+		if debug, err := f.DWARF(); err == nil {
+			if dirs := compileDirectories(debug); dirs != "" {
+				metadata["CompileDirectory"] = dirs
+			}
+		}
+	}
+	return metadata, nil
+}
+
+// one entry of a note section
+type elfNote struct {
+	name string
+	kind uint32
+	desc []byte
+}
+
+// the entries of a note section: sizes of name and descriptor, type, then
+// both padded to the section's alignment (4, or 8 for some GNU notes)
+func elfNotes(content []byte, order binary.ByteOrder, align uint64) []elfNote {
+	pad := uint64(4)
+	if align == 8 {
+		pad = 8
+	}
+	aligned := func(n uint64) uint64 { return (n + pad - 1) &^ (pad - 1) }
+
+	var notes []elfNote
+	for len(content) >= 12 {
+		nameSize, descSize := uint64(order.Uint32(content)), uint64(order.Uint32(content[4:]))
+		kind := order.Uint32(content[8:])
+		nameEnd := 12 + aligned(nameSize)
+		if nameEnd > uint64(len(content)) || nameEnd+descSize > uint64(len(content)) {
+			break
+		}
+		name := strings.TrimRight(string(content[12:12+nameSize]), "\x00")
+		notes = append(notes, elfNote{name: name, kind: kind, desc: content[nameEnd : nameEnd+descSize]})
+		next := nameEnd + aligned(descSize)
+		if next > uint64(len(content)) {
+			break
+		}
+		content = content[next:]
+	}
+	return notes
+}
+
+// the distinct NUL-separated strings of a section, joined
+func elfStrings(content []byte) string {
+	var values []string
+	for _, value := range strings.Split(string(content), "\x00") {
+		if value = strings.TrimSpace(value); value != "" && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, "; ")
+}
+
+// ╭─ WRITING ───────────────────────────────────╮
+
+// whether a wipe empties section: compiler comments and command lines
+// always; in executables and libraries also debug info, symbol tables,
+// debug links and notes nothing loads. Objects keep their symbols and
+// debug info, which linking and relocations need
+func elfStripped(section *elf.Section, object bool) bool {
+	if section.Flags&elf.SHF_ALLOC != 0 || section.Type == elf.SHT_NOBITS || section.Type == elf.SHT_NULL {
+		return false
+	}
+	name := section.Name
+	switch {
+	case name == ".comment" || name == ".GCC.command.line":
+		return true
+	case object:
+		return false
+	}
+	return strings.HasPrefix(name, ".debug") || strings.HasPrefix(name, ".zdebug") ||
+		strings.HasPrefix(name, ".rela.debug") || strings.HasPrefix(name, ".rel.debug") ||
+		section.Type == elf.SHT_SYMTAB || name == ".strtab" ||
+		name == ".gnu_debuglink" || name == ".gnu_debugaltlink" ||
+		section.Type == elf.SHT_NOTE
+}
+
+// zeroes the sections elfStripped picks and sets their size to 0 in the
+// section headers, with the compressed flag cleared; no offset moves, so
+// segments and the other sections are left as they are
+func stripELF(data []byte) error {
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse ELF: %w", err)
+	}
+	defer f.Close()
+
+	// where the section headers are, and where sh_flags and sh_size are in
+	// one; both are as wide as an address
+	var tableOffset, entrySize, sizeField, width uint64
+	wide := f.Class == elf.ELFCLASS64
+	if wide {
+		tableOffset, entrySize, sizeField, width = f.ByteOrder.Uint64(data[0x28:]), uint64(f.ByteOrder.Uint16(data[0x3A:])), 0x20, 8
+	} else {
+		tableOffset, entrySize, sizeField, width = uint64(f.ByteOrder.Uint32(data[0x20:])), uint64(f.ByteOrder.Uint16(data[0x2E:])), 0x14, 4
+	}
+	const flagsField = 0x08
+
+	object := f.Type == elf.ET_REL
+	for i, section := range f.Sections {
+		if !elfStripped(section, object) {
+			continue
+		}
+		header := tableOffset + uint64(i)*entrySize
+		if header+sizeField+width > uint64(len(data)) {
+			return fmt.Errorf("section header %d lies past the end of the file", i)
+		}
+		zeroRange(data, section.Offset, section.FileSize)
+		flags := uint64(section.Flags &^ elf.SHF_COMPRESSED)
+		if wide {
+			f.ByteOrder.PutUint64(data[header+flagsField:], flags)
+			f.ByteOrder.PutUint64(data[header+sizeField:], 0)
+		} else {
+			f.ByteOrder.PutUint32(data[header+flagsField:], uint32(flags))
+			f.ByteOrder.PutUint32(data[header+sizeField:], 0)
+		}
+	}
+	return nil
+}
+
+// ╭─ INTEGRITY ─────────────────────────────────╮
+
+// the headers parse and every segment and section with content lies within
+// the file
+func checkELF(data []byte) bool {
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	size := uint64(len(data))
+	for _, prog := range f.Progs {
+		if prog.Off > size || prog.Filesz > size-prog.Off {
+			return false
+		}
+	}
+	for _, section := range f.Sections {
+		if section.Type == elf.SHT_NOBITS || section.Type == elf.SHT_NULL {
+			continue
+		}
+		if section.Offset > size || section.FileSize > size-section.Offset {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,405 @@
+// BYZRA ⸻ internal/formats/email.go
+// email handler: trace headers reported and removed, the body kept byte for
+// byte
+
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"slices"
+	"strings"
+)
+
+// implements FormatHandler for RFC 5322 messages (.eml)
+type EmailHandler struct{}
+
+// header fields that make up the correspondence, or that mail clients need
+// to show the body; every other field is trace and goes with a wipe
+var emailKeptHeaders = []string{
+	"from", "sender", "reply-to", "to", "cc", "bcc", "subject", "date",
+	"mime-version", "content-type", "content-transfer-encoding",
+	"content-disposition", "content-language", "content-id", "content-description",
+}
+
+// fields holding a DKIM or ARC signature, reported by signing domain and
+// selector rather than by their hashes
+var emailSignatureHeaders = []string{
+	"dkim-signature", "x-google-dkim-signature", "arc-seal", "arc-message-signature",
+}
+
+// profile fields and the header fields they become
+var emailProfileHeaders = map[string]string{
+	"software": "User-Agent",
+	"comment":  "Comments",
+}
+
+// longest header value reported, in characters
+const emailMaxValue = 200
+
+// how far a header must end for content detection
+const emailMaxHeader = 64 << 10
+
+// multipart levels searched for attachments
+const emailMaxDepth = 4
+
+// one header field with its folded lines and line endings, as in the file
+type emailField struct {
+	name string
+	raw  string
+}
+
+// splits a message into the mbox "From " line some clients save first, the
+// header fields, and the rest: the blank line and the body; false when a
+// line is neither a field nor the continuation of one
+func splitEmail(content string) (envelope string, fields []emailField, rest string, ok bool) {
+	pos := 0
+	if strings.HasPrefix(content, "From ") {
+		end := strings.IndexByte(content, '\n')
+		if end < 0 {
+			return "", nil, "", false
+		}
+		envelope, pos = content[:end+1], end+1
+	}
+
+	for pos < len(content) {
+		line := content[pos:]
+		if end := strings.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end+1]
+		}
+		switch {
+		case line == "\n" || line == "\r\n":
+			return envelope, fields, content[pos:], true
+		case line[0] == ' ' || line[0] == '\t':
+			if len(fields) == 0 {
+				return "", nil, "", false
+			}
+			fields[len(fields)-1].raw += line
+		default:
+			name, _, found := strings.Cut(line, ":")
+			if !found || !isEmailFieldName(name) {
+				return "", nil, "", false
+			}
+			fields = append(fields, emailField{name: name, raw: line})
+		}
+		pos += len(line)
+	}
+	return envelope, fields, "", true
+}
+
+// printable ASCII without spaces or colons (RFC 5322 ftext)
+func isEmailFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] < 33 || name[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// the value of a field, unfolded, decoded from RFC 2047 words and with its
+// whitespace collapsed
+func (f emailField) value() string {
+	_, value, _ := strings.Cut(f.raw, ":")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(value); err == nil {
+		value = decoded
+	}
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func isKeptEmailField(name string) bool {
+	return slices.Contains(emailKeptHeaders, strings.ToLower(name))
+}
+
+// "eml" for a message: header fields up to the first blank line, a From
+// field and a date, ID or trace field; "" otherwise
+func EmailType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, emailMaxHeader)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	content := string(head[:n])
+	if n == len(head) {
+		// the last line may be cut short
+		content = content[:strings.LastIndexByte(content, '\n')+1]
+	}
+
+	_, fields, _, ok := splitEmail(content)
+	if !ok {
+		return ""
+	}
+	var from, trace bool
+	for _, field := range fields {
+		switch strings.ToLower(field.name) {
+		case "from":
+			from = true
+		case "date", "message-id", "received", "return-path":
+			trace = true
+		}
+	}
+	if from && trace {
+		return "eml"
+	}
+	return ""
+}
+
+// ╭─ HANDLER ───────────────────────────────────╮
+
+// every header field a wipe removes: Received hops, originating IPs, the
+// message ID, the mail client, signatures and the rest of the X- fields
+func (h *EmailHandler) ExtractMetadata(path string) (map[string]any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email: %w", err)
+	}
+	envelope, fields, _, ok := splitEmail(string(content))
+	if !ok {
+		return nil, fmt.Errorf("failed to parse email headers")
+	}
+
+	metadata := make(map[string]any)
+	if envelope != "" {
+		metadata["Envelope"] = strings.TrimSpace(strings.TrimPrefix(envelope, "From "))
+	}
+
+	// repeated fields are reported once, under the first spelling seen
+	names := make(map[string]string)
+	values := make(map[string][]string)
+	var hops []string
+	for _, field := range fields {
+		lower := strings.ToLower(field.name)
+		if isKeptEmailField(lower) {
+			continue
+		}
+		value := field.value()
+		switch {
+		case lower == "received":
+			// "from ... by ... with ...; date": the hop, without its date
+			if i := strings.LastIndexByte(value, ';'); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			hops = append(hops, clipEmailValue(value))
+			continue
+		case slices.Contains(emailSignatureHeaders, lower):
+			value = emailSignatureSummary(value)
+		default:
+			value = clipEmailValue(value)
+		}
+		if _, seen := names[lower]; !seen {
+			names[lower] = field.name
+		}
+		if value != "" && !slices.Contains(values[lower], value) {
+			values[lower] = append(values[lower], value)
+		}
+	}
+
+	// each server puts its field on top: reversed, the hops run from the
+	// sender's server on
+	switch len(hops) {
+	case 0:
+	case 1:
+		metadata["Received"] = "1 hop: " + hops[0]
+	default:
+		slices.Reverse(hops)
+		metadata["Received"] = fmt.Sprintf("%d hops: %s", len(hops), strings.Join(hops, "; "))
+	}
+	for lower, name := range names {
+		if len(values[lower]) > 0 {
+			metadata[name] = strings.Join(values[lower], "; ")
+		}
+	}
+	return metadata, nil
+}
+
+// keeps the mail's fields (from, to, subject, date, ...) and the MIME
+// fields the body needs, drops every other field and the mbox "From " line;
+// the body is left as it is
+func (h *EmailHandler) WipeMetadata(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read email: %w", err)
+	}
+	_, fields, rest, ok := splitEmail(string(content))
+	if !ok {
+		return fmt.Errorf("failed to parse email headers")
+	}
+
+	var b strings.Builder
+	for _, field := range fields {
+		if isKeptEmailField(field.name) {
+			b.WriteString(field.raw)
+		}
+	}
+	b.WriteString(rest)
+	return replaceFile(path, []byte(b.String()))
+}
+
+// the profile's software as User-Agent and its comment as Comments, after
+// the other fields
+func (h *EmailHandler) InjectMetadata(path string, profile map[string]string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read email: %w", err)
+	}
+	envelope, fields, rest, ok := splitEmail(string(content))
+	if !ok {
+		return fmt.Errorf("failed to parse email headers")
+	}
+
+	added := make(map[string]string)
+	for key, value := range profile {
+		if name, ok := emailProfileHeaders[strings.ToLower(key)]; ok && value != "" {
+			added[strings.ToLower(name)] = name + ": " + mime.QEncoding.Encode("utf-8", value)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	newline := "\n"
+	if len(fields) > 0 && strings.HasSuffix(fields[0].raw, "\r\n") {
+		newline = "\r\n"
+	}
+	var b strings.Builder
+	b.WriteString(envelope)
+	for _, field := range fields {
+		if _, replaced := added[strings.ToLower(field.name)]; !replaced {
+			b.WriteString(field.raw)
+		}
+	}
+	for _, name := range sortedKeys(added) {
+		b.WriteString(added[name] + newline)
+	}
+	if rest == "" {
+		rest = newline
+	}
+	b.WriteString(rest)
+	return replaceFile(path, []byte(b.String()))
+}
+
+// the header fields still parse
+func (h *EmailHandler) VerifyIntegrity(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	_, _, _, ok := splitEmail(string(content))
+	return ok
+}
+
+// attachments and forwarded messages, which keep whatever they carry: the
+// body is not changed
+func (h *EmailHandler) ListEmbedded(path string) ([]Embedded, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email: %w", err)
+	}
+	envelope, _, _, _ := splitEmail(string(content))
+
+	message, err := mail.ReadMessage(bytes.NewReader(content[len(envelope):]))
+	if err != nil {
+		return nil, nil // no MIME structure to list
+	}
+	var embedded []Embedded
+	listEmailParts(message.Header.Get("Content-Type"), message.Body, 0, &embedded)
+	return embedded, nil
+}
+
+// ╭─ HELPERS ───────────────────────────────────╮
+
+// the parts of a multipart body, nested ones included; malformed parts end
+// the listing quietly
+func listEmailParts(contentType string, body io.Reader, depth int, embedded *[]Embedded) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" || depth >= emailMaxDepth {
+		return
+	}
+
+	r := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := r.NextRawPart()
+		if err != nil {
+			return
+		}
+		partType := part.Header.Get("Content-Type")
+		mediaType, _, _ := mime.ParseMediaType(partType)
+		switch {
+		case strings.HasPrefix(mediaType, "multipart/"):
+			listEmailParts(partType, part, depth+1, embedded)
+		case mediaType == "message/rfc822":
+			*embedded = append(*embedded, forwardedEmail(part))
+		case part.FileName() != "":
+			*embedded = append(*embedded, Embedded{
+				Kind:   "attachment",
+				Name:   part.FileName(),
+				Detail: fmt.Sprintf("%s; kept as it is, wipe it on its own", mediaType),
+			})
+		}
+	}
+}
+
+// a message attached to the message, with the trace fields of its own
+func forwardedEmail(part *multipart.Part) Embedded {
+	item := Embedded{Kind: "message", Name: "forwarded message", Detail: "message/rfc822; kept as it is"}
+	if part.FileName() != "" {
+		item.Name = part.FileName()
+	}
+	content, err := io.ReadAll(io.LimitReader(part, emailMaxHeader))
+	if err != nil {
+		return item
+	}
+	_, fields, _, ok := splitEmail(string(content))
+	if !ok {
+		return item
+	}
+	trace := 0
+	for _, field := range fields {
+		if strings.EqualFold(field.name, "subject") && part.FileName() == "" {
+			item.Name = field.value()
+		}
+		if !isKeptEmailField(field.name) {
+			trace++
+		}
+	}
+	if trace > 0 {
+		item.Detail = fmt.Sprintf("message/rfc822 with trace fields of its own (%d); kept as it is", trace)
+	}
+	return item
+}
+
+// "d=example.org, s=selector" from a DKIM or ARC tag list
+func emailSignatureSummary(value string) string {
+	var tags []string
+	for _, tag := range strings.Split(value, ";") {
+		key, tagValue, ok := strings.Cut(strings.TrimSpace(tag), "=")
+		if ok && (key == "d" || key == "s" || key == "i") {
+			tags = append(tags, key+"="+strings.TrimSpace(tagValue))
+		}
+	}
+	if len(tags) == 0 {
+		return clipEmailValue(value)
+	}
+	return strings.Join(tags, ", ")
+}
+
+func clipEmailValue(value string) string {
+	if runes := []rune(value); len(runes) > emailMaxValue {
+		return string(runes[:emailMaxValue]) + "…"
+	}
+	return value
+}
@@ -0,0 +1,126 @@
+// BYZRA ⸻ internal/formats/embedded.go
+// embedded streams, attachments and other non-tag payloads
+
+package formats
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"caligra/internal/util"
+)
+
+// something carried inside a file besides its tags
+type Embedded struct {
+	Kind     string // "telemetry", "data", "subtitle", ...
+	Name     string
+	Detail   string
+	Critical bool
+}
+
+// kind of signatures, keys and encrypted messages in text bodies
+const EmbeddedOpenPGP = "openpgp"
+
+// optional capability: list embedded payloads
+type EmbeddedLister interface {
+	ListEmbedded(path string) ([]Embedded, error)
+}
+
+// sensor/GPS track formats, by codec tag or handler name fragment
+var telemetryMarkers = map[string]string{
+	"gpmd":      "GoPro GPMF telemetry (GPS, gyro, accelerometer)",
+	"gopro met": "GoPro GPMF telemetry (GPS, gyro, accelerometer)",
+	"camm":      "camera motion metadata (GPS, orientation)",
+	"djmd":      "DJI flight telemetry",
+	"dbgi":      "DJI debug telemetry",
+	"dji meta":  "DJI flight telemetry",
+	"dji.sub":   "DJI flight log subtitles (GPS, altitude)",
+}
+
+// what kind of telemetry a stream carries ("" if none)
+func telemetryDescription(stream util.ProbeStream) string {
+	candidates := []string{
+		strings.ToLower(stream.CodecTag),
+		strings.ToLower(stream.CodecName),
+		strings.ToLower(stream.Tags["handler_name"]),
+	}
+
+	for marker, description := range telemetryMarkers {
+		for _, candidate := range candidates {
+			if candidate != "" && strings.Contains(candidate, marker) {
+				return description
+			}
+		}
+	}
+	return ""
+}
+
+// non audio/video streams of a media file; telemetry is critical
+func probeEmbeddedStreams(path string) ([]Embedded, []int, error) {
+	if !util.HasFFProbe() {
+		return nil, nil, nil // stream inspection needs ffprobe
+	}
+
+	streams, err := util.FFProbeStreams(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var embedded []Embedded
+	var telemetry []int
+	for _, stream := range streams {
+		if stream.CodecType == "video" || stream.CodecType == "audio" {
+			continue
+		}
+
+		name := fmt.Sprintf("stream #%d", stream.Index)
+		if handler := stream.Tags["handler_name"]; handler != "" {
+			name += " (" + strings.TrimSpace(handler) + ")"
+		}
+
+		if description := telemetryDescription(stream); description != "" {
+			embedded = append(embedded, Embedded{
+				Kind: "telemetry", Name: name, Detail: description, Critical: true,
+			})
+			telemetry = append(telemetry, stream.Index)
+			continue
+		}
+
+		detail := stream.CodecName
+		if detail == "" {
+			detail = stream.CodecTag
+		}
+		embedded = append(embedded, Embedded{Kind: stream.CodecType, Name: name, Detail: detail})
+	}
+
+	return embedded, telemetry, nil
+}
+
+// removes telemetry streams in place by remuxing without them
+func dropTelemetryStreams(path string) error {
+	_, telemetry, err := probeEmbeddedStreams(path)
+	if err != nil || len(telemetry) == 0 {
+		return err
+	}
+
+	return remuxInPlace(path, func(src, dst string) error {
+		return util.FFmpegDropStreams(src, dst, telemetry)
+	})
+}
+
+// runs a remux into a sibling temp file and swaps it over the original
+func remuxInPlace(path string, remux func(src, dst string) error) error {
+	tmp := filepath.Join(filepath.Dir(path), ".caligra-remux-"+filepath.Base(path))
+	if err := remux(path, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace file after remux: %w", err)
+	}
+	return nil
+}
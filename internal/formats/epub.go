@@ -0,0 +1,452 @@
+// BYZRA ⸻ internal/formats/epub.go
+// e-books (EPUB 2 and 3): the Dublin Core metadata of the package document
+
+package formats
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	epubMimeType = "application/epub+zip"
+	nsOPF        = "http://www.idpf.org/2007/opf"
+)
+
+// the dcterms:modified wiping leaves, as old as a ZIP entry can be; it
+// tells nothing, so analysis leaves it out
+const epubClearedDate = "1980-01-01T00:00:00Z"
+
+// algorithms that obfuscate fonts with the book's unique identifier as key
+var epubFontObfuscation = []string{"http://www.idpf.org/2008/embedding", "http://ns.adobe.com/pdf/enc#RC"}
+
+// Dublin Core elements, by what they hold
+var epubDCNames = map[string]string{
+	"creator": "Creator", "contributor": "Contributor", "date": "CreateDate",
+	"identifier": "Identifier", "publisher": "Publisher", "rights": "Rights",
+	"title": "Title", "description": "Description", "subject": "Subject",
+	"language": "Language", "source": "Source",
+}
+
+// <meta> names that say which program made the book, renamed like the
+// other formats
+var epubGenerators = []string{"generator", "Sigil version"}
+
+// calibre metadata that describes the book rather than who had it
+var epubCalibreKept = []string{"calibre:series", "calibre:series_index", "calibre:title_sort"}
+
+// "epub" for a ZIP holding an EPUB publication, "" otherwise
+func EPUBDocumentType(path string) string {
+	parts, err := readZipParts(path, "mimetype")
+	if err != nil || strings.TrimSpace(string(parts["mimetype"])) != epubMimeType {
+		return ""
+	}
+	return "epub"
+}
+
+// a file the package document lists in its manifest
+type epubItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+// the package document and what its manifest says
+type epubPackage struct {
+	opf        string // path of the package document
+	data       []byte
+	items      []epubItem
+	cover      string // manifest ID of the cover image, from EPUB 2 <meta name="cover">
+	obfuscated bool   // fonts are obfuscated with the unique identifier
+}
+
+// finds the package document through META-INF/container.xml
+func openEPUB(path string) (*epubPackage, error) {
+	parts, err := readZipParts(path, "META-INF/container.xml", "META-INF/encryption.xml")
+	if err != nil {
+		return nil, err
+	}
+	var container struct {
+		Rootfiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(parts["META-INF/container.xml"], &container); err != nil {
+		return nil, fmt.Errorf("invalid container.xml: %w", err)
+	}
+	pkg := &epubPackage{}
+	for _, rootfile := range container.Rootfiles {
+		if rootfile.MediaType == "application/oebps-package+xml" || pkg.opf == "" {
+			pkg.opf = rootfile.FullPath
+		}
+	}
+	if pkg.opf == "" {
+		return nil, fmt.Errorf("container.xml names no package document")
+	}
+	opf, err := readZipParts(path, pkg.opf)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.data = opf[pkg.opf]; pkg.data == nil {
+		return nil, fmt.Errorf("package document %s is missing", pkg.opf)
+	}
+
+	var manifest struct {
+		Items []epubItem `xml:"manifest>item"`
+		Metas []struct {
+			Name    string `xml:"name,attr"`
+			Content string `xml:"content,attr"`
+		} `xml:"metadata>meta"`
+	}
+	if err := xml.Unmarshal(pkg.data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid package document: %w", err)
+	}
+	pkg.items = manifest.Items
+	for _, meta := range manifest.Metas {
+		if meta.Name == "cover" {
+			pkg.cover = meta.Content
+		}
+	}
+	for _, algorithm := range epubFontObfuscation {
+		pkg.obfuscated = pkg.obfuscated || bytes.Contains(parts["META-INF/encryption.xml"], []byte(algorithm))
+	}
+	return pkg, nil
+}
+
+// the path in the archive of a manifest href, which is relative to the
+// package document
+func (pkg *epubPackage) resolve(href string) string {
+	return strings.TrimPrefix(path.Join(path.Dir(pkg.opf), href), "/")
+}
+
+// the metadata element of the package document and its children, with
+// the package element around them
+func (pkg *epubPackage) metadata() (*xmlElement, *xmlElement, []xmlElement, error) {
+	root, _, err := xmlChildren(pkg.data, 1)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	metadata, children, err := xmlChildren(pkg.data, 2)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if metadata.local != "metadata" {
+		return nil, nil, nil, fmt.Errorf("%s: metadata is not the first element of the package", pkg.opf)
+	}
+	return root, metadata, children, nil
+}
+
+// the Dublin Core elements and <meta> properties of the package document
+func extractEPUBMetadata(path string) (map[string]any, error) {
+	pkg, err := openEPUB(path)
+	if err != nil {
+		return nil, err
+	}
+	root, _, children, err := pkg.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]any{"EPUBVersion": root.attr("version")}
+	add := func(name, value string) {
+		value = strings.TrimSpace(value)
+		if name == "" || value == "" {
+			return
+		}
+		if previous, ok := metadata[name].(string); ok {
+			value = previous + ", " + value // several creators, subjects, identifiers
+		}
+		metadata[name] = value
+	}
+	for _, child := range children {
+		if child.local != "meta" {
+			name := epubDCNames[child.local]
+			value := child.text
+			if child.local == "date" {
+				if child.attr("event") == "modification" {
+					name = "ModifyDate"
+				}
+				value = epubDate(strings.TrimSpace(value))
+			}
+			add(name, value)
+			continue
+		}
+
+		// EPUB 2 <meta name content>, EPUB 3 <meta property>text</meta>
+		name, value := child.attr("name"), child.attr("content")
+		if name == "" {
+			name, value = child.attr("property"), child.text
+			if child.attr("refines") != "" {
+				continue // roles and sort keys of elements reported above
+			}
+		}
+		switch {
+		case name == "Sigil version":
+			name, value = "Software", "Sigil "+value
+		case slices.Contains(epubGenerators, name):
+			name = "Software"
+		case name == "dcterms:modified" && strings.TrimSpace(value) == epubClearedDate:
+			continue
+		case name == "dcterms:modified":
+			name, value = "ModifyDate", epubDate(strings.TrimSpace(value))
+		case name == "calibre:timestamp":
+			value = epubDate(strings.TrimSpace(value))
+		case name == "cover":
+			continue
+		}
+		add(name, value)
+	}
+	return metadata, nil
+}
+
+// dates in the W3CDTF profile of ISO 8601 as the other formats give them;
+// a year or year and month alone stays as it is
+func epubDate(value string) string {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date.Format("2006:01:02")
+	}
+	return odfDate(value)
+}
+
+// removes creators, contributors, dates, identifiers and the tools and
+// timestamps calibre and Sigil record, and clears the timestamps of every
+// entry. The unique identifier the book needs becomes a random one,
+// unless fonts are obfuscated with it; dcterms:modified, which EPUB 3
+// requires, becomes 1980-01-01
+func wipeEPUBMetadata(path string) error {
+	pkg, err := openEPUB(path)
+	if err != nil {
+		return err
+	}
+	root, metadata, children, err := pkg.metadata()
+	if err != nil {
+		return err
+	}
+
+	unique := root.attr("unique-identifier")
+	drop, removed := epubDrop(children, func(child xmlElement) bool {
+		switch child.local {
+		case "creator", "contributor", "date":
+			return true
+		case "identifier":
+			return child.attr("id") != unique || !pkg.obfuscated
+		case "meta":
+			name := child.attr("name") + child.attr("property")
+			return slices.Contains(epubGenerators, name) || name == "dcterms:modified" ||
+				strings.HasPrefix(name, "calibre:") && !slices.Contains(epubCalibreKept, name)
+		}
+		return false
+	})
+
+	replace := make(map[string][]byte)
+	var markup strings.Builder
+	name := epubNamer(root, metadata)
+	if unique != "" && removed[unique] {
+		uid := "urn:uuid:" + randomUUID()
+		identifier, decl := name(nsDC, "identifier")
+		fmt.Fprintf(&markup, `<%s%s id="%s">%s</%s>`, identifier, decl, xmlEscape(unique), uid, identifier)
+		ncx, data, err := pkg.ncxWithUID(path, uid)
+		if err != nil {
+			return err
+		}
+		if data != nil {
+			replace[ncx] = data
+		}
+	}
+	if strings.HasPrefix(root.attr("version"), "3") {
+		meta, decl := name(nsOPF, "meta")
+		fmt.Fprintf(&markup, `<%s%s property="dcterms:modified">%s</%s>`, meta, decl, epubClearedDate, meta)
+	}
+
+	dropped := func(child xmlElement) bool { return drop[child.start] }
+	replace[pkg.opf] = editXMLChildren(pkg.data, metadata, children, dropped, markup.String())
+	return rewriteZip(path, replace, nil)
+}
+
+// the offsets of the children match picks, and of the EPUB 3 <meta>
+// elements refining them, which follow what they refine; with the IDs of
+// the elements picked
+func epubDrop(children []xmlElement, match func(xmlElement) bool) (map[int]bool, map[string]bool) {
+	drop, removed := make(map[int]bool), make(map[string]bool)
+	for _, child := range children {
+		refines := strings.TrimPrefix(child.attr("refines"), "#")
+		if !match(child) && !(child.local == "meta" && removed[refines]) {
+			continue
+		}
+		drop[child.start] = true
+		if id := child.attr("id"); id != "" {
+			removed[id] = true
+		}
+	}
+	return drop, removed
+}
+
+// a function naming elements in the metadata of a package document: by
+// the prefixes the package or metadata binds, or with a declaration
+func epubNamer(root, metadata *xmlElement) func(namespace, local string) (string, string) {
+	prefixes := map[string]string{nsDC: "dc", nsOPF: "opf"}
+	return func(namespace, local string) (string, string) {
+		if _, ok := root.prefixFor(namespace); ok {
+			return root.nameIn(namespace, local, prefixes)
+		}
+		return metadata.nameIn(namespace, local, prefixes)
+	}
+}
+
+// the EPUB 2 table of contents with its dtb:uid set to uid, which has to
+// match the unique identifier; nil when the book has none
+func (pkg *epubPackage) ncxWithUID(path, uid string) (string, []byte, error) {
+	var ncx string
+	for _, item := range pkg.items {
+		if item.MediaType == "application/x-dtbncx+xml" {
+			ncx = pkg.resolve(item.Href)
+		}
+	}
+	if ncx == "" {
+		return "", nil, nil
+	}
+	parts, err := readZipParts(path, ncx)
+	if err != nil || parts[ncx] == nil {
+		return "", nil, err
+	}
+
+	data := parts[ncx]
+	head, children, err := xmlChildren(data, 2)
+	if err != nil || head.local != "head" {
+		return "", nil, nil // a damaged table of contents is left as it is
+	}
+	for _, child := range children {
+		if child.local == "meta" && child.attr("name") == "dtb:uid" {
+			markup := fmt.Sprintf(`<%s name="dtb:uid" content="%s"/>`, child.qname(), xmlEscape(uid))
+			old := func(c xmlElement) bool { return c.start == child.start }
+			return ncx, editXMLChildren(data, head, children, old, markup), nil
+		}
+	}
+	return "", nil, nil
+}
+
+// sets Dublin Core elements from the profile: author as the creator,
+// comment as the description, organization as the publisher, and
+// software as a generator <meta>
+func injectEPUBMetadata(path string, profile map[string]string) error {
+	pkg, err := openEPUB(path)
+	if err != nil {
+		return err
+	}
+	root, metadata, children, err := pkg.metadata()
+	if err != nil {
+		return err
+	}
+
+	name := epubNamer(root, metadata)
+	set := make(map[string]bool) // the elements replaced, by local name
+	var markup strings.Builder
+	dc := func(local, value string) {
+		element, decl := name(nsDC, local)
+		fmt.Fprintf(&markup, "<%s%s>%s</%s>", element, decl, xmlEscape(value), element)
+		set[local] = true
+	}
+	for _, key := range sortedKeys(profile) {
+		value := profile[key]
+		switch strings.ToLower(key) {
+		case "author":
+			dc("creator", value)
+		case "comment":
+			dc("description", value)
+		case "created":
+			date, err := time.Parse("2006:01:02 15:04:05", value)
+			if err != nil {
+				return fmt.Errorf("invalid date %q: %w", value, err)
+			}
+			dc("date", date.Format("2006-01-02T15:04:05Z"))
+		case "organization":
+			dc("publisher", value)
+		case "software":
+			meta, decl := name(nsOPF, "meta")
+			fmt.Fprintf(&markup, `<%s%s name="generator" content="%s"/>`, meta, decl, xmlEscape(value))
+			set["generator"] = true
+		}
+	}
+	if markup.Len() == 0 {
+		return nil
+	}
+
+	drop, _ := epubDrop(children, func(child xmlElement) bool {
+		if child.local == "meta" {
+			return set["generator"] && slices.Contains(epubGenerators, child.attr("name"))
+		}
+		return set[child.local]
+	})
+	dropped := func(child xmlElement) bool { return drop[child.start] }
+	edited := editXMLChildren(pkg.data, metadata, children, dropped, markup.String())
+	return rewriteZip(path, map[string][]byte{pkg.opf: edited}, nil)
+}
+
+// lists the cover, the other pictures and obfuscated fonts
+func listEPUBEmbedded(path string) ([]Embedded, error) {
+	pkg, err := openEPUB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded []Embedded
+	pictures, fonts := 0, 0
+	for _, item := range pkg.items {
+		switch {
+		case item.ID == pkg.cover || slices.Contains(strings.Fields(item.Properties), "cover-image"):
+			embedded = append(embedded, Embedded{Kind: "picture", Name: pkg.resolve(item.Href), Detail: "cover image; its own metadata is not wiped"})
+		case strings.HasPrefix(item.MediaType, "image/"):
+			pictures++
+		case strings.HasPrefix(item.MediaType, "font/") || strings.Contains(item.MediaType, "opentype") || strings.Contains(item.MediaType, "font-"):
+			fonts++
+		}
+	}
+	if pictures > 0 {
+		embedded = append(embedded, Embedded{Kind: "picture", Name: "pictures", Detail: fmt.Sprintf("images in the book: %d; their own metadata is not wiped", pictures)})
+	}
+	if pkg.obfuscated && fonts > 0 {
+		embedded = append(embedded, Embedded{Kind: "font", Name: "obfuscated fonts", Detail: "keyed to the book's unique identifier, which wiping therefore keeps"})
+	}
+	return embedded, nil
+}
+
+// checks that the archive decompresses, starts with its mimetype entry,
+// and that the package document parses
+func verifyEPUB(path string) bool {
+	if !verifyZip(path) {
+		return false
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	first := len(archive.File) > 0 && archive.File[0].Name == "mimetype" && archive.File[0].Method == zip.Store
+	archive.Close()
+	if !first {
+		return false
+	}
+	pkg, err := openEPUB(path)
+	if err != nil {
+		return false
+	}
+	_, _, _, err = pkg.metadata()
+	return err == nil
+}
+
+// a version 4 UUID
+func randomUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
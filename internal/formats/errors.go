@@ -0,0 +1,17 @@
+// BYZRA ⸻ internal/formats/errors.go
+// errors callers can branch on with errors.Is
+
+package formats
+
+import (
+	"errors"
+	"strings"
+)
+
+// wrapped by every failure to find a handler for a file
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// the extensions caligra handles, for error hints
+func SupportedList() string {
+	return strings.Join(SupportedFormats(), ", ")
+}
@@ -0,0 +1,136 @@
+// BYZRA ⸻ internal/formats/executable.go
+// executable handler: ELF and PE build traces reported, non-essential
+// sections stripped
+
+package formats
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// implements FormatHandler for ELF and PE (Windows) executables and
+// libraries
+type ExecutableHandler struct{}
+
+var errPESigned = errors.New("signed executables are not supported: wiping would break the Authenticode signature")
+
+// compile directories reported from the debug info; the rest are counted
+const executableMaxDirs = 5
+
+// "elf" for an ELF file, "exe" or "dll" for a PE image, "" otherwise
+func ExecutableType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	header := make([]byte, 64)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return ""
+	}
+	if bytes.HasPrefix(header, []byte("\x7fELF")) {
+		return "elf"
+	}
+	if !bytes.HasPrefix(header, []byte("MZ")) {
+		return ""
+	}
+
+	// the DOS header points to the PE signature and the COFF header after it
+	coff := make([]byte, 24)
+	if _, err := file.ReadAt(coff, int64(binary.LittleEndian.Uint32(header[0x3C:]))); err != nil ||
+		!bytes.HasPrefix(coff, []byte("PE\x00\x00")) {
+		return ""
+	}
+	if binary.LittleEndian.Uint16(coff[22:])&0x2000 != 0 { // IMAGE_FILE_DLL
+		return "dll"
+	}
+	return "exe"
+}
+
+// build IDs, compiler notes, debug paths and link times
+func (h *ExecutableHandler) ExtractMetadata(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("\x7fELF")) {
+		return extractELFMetadata(data)
+	}
+	return extractPEMetadata(data)
+}
+
+// strips what records how, when and where the file was built; code, data
+// and what the loader reads stay in place, byte for byte
+func (h *ExecutableHandler) WipeMetadata(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(data, []byte("\x7fELF")) {
+		err = stripELF(data)
+	} else {
+		err = stripPE(data)
+	}
+	if err != nil {
+		return err
+	}
+	return replaceFile(path, data)
+}
+
+// executables hold no profile fields
+func (h *ExecutableHandler) InjectMetadata(path string, profile map[string]string) error {
+	return nil
+}
+
+// the headers parse and every section and segment lies within the file;
+// PE checksums, when set, match
+func (h *ExecutableHandler) VerifyIntegrity(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if bytes.HasPrefix(data, []byte("\x7fELF")) {
+		return checkELF(data)
+	}
+	return checkPE(data)
+}
+
+// the directories the debug info says the sources were compiled in, which
+// often hold the user's name
+func compileDirectories(debug *dwarf.Data) string {
+	var dirs []string
+	r := debug.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		if entry.Tag == dwarf.TagCompileUnit {
+			if dir, ok := entry.Val(dwarf.AttrCompDir).(string); ok && dir != "" && !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+		r.SkipChildren()
+	}
+	if len(dirs) > executableMaxDirs {
+		return fmt.Sprintf("%s (and %d more)", strings.Join(dirs[:executableMaxDirs], "; "), len(dirs)-executableMaxDirs)
+	}
+	return strings.Join(dirs, "; ")
+}
+
+// zeroes data[off:off+n], clipped to data
+func zeroRange(data []byte, off, n uint64) {
+	if off >= uint64(len(data)) {
+		return
+	}
+	clear(data[off:min(off+n, uint64(len(data)))])
+}
@@ -35,17 +35,45 @@ func GetHandler(format string) (FormatHandler, error) {
 		return &VideoHandler{}, nil
 	case "text":
 		return &TextHandler{}, nil
+	case "matroska":
+		return &MatroskaHandler{Options: DefaultMatroskaOptions()}, nil
+	case "document":
+		return &DocumentHandler{}, nil
+	case "pdf":
+		return &PDFHandler{}, nil
+	case "archive":
+		return &ArchiveHandler{}, nil
+	case "executable":
+		return &ExecutableHandler{}, nil
+	case "email":
+		return &EmailHandler{}, nil
+	case "gps":
+		return &GPSTrackHandler{}, nil
+	case "database":
+		return &SQLiteHandler{}, nil
+	case "calendar":
+		return &CalendarHandler{}, nil
 	default:
-		return nil, fmt.Errorf("no handler for format: %s", format)
+		return nil, fmt.Errorf("%w: no handler for %s", ErrUnsupportedFormat, format)
 	}
 }
 
 // all supported extensions by format
 var (
-	ImageExtensions = []string{"jpg", "jpeg", "png", "gif", "tiff", "svg"}
-	AudioExtensions = []string{"mp3", "flac", "opus", "ogg"}
-	VideoExtensions = []string{"mp4", "avi"}
-	TextExtensions  = []string{"txt", "md", "html"}
+	ImageExtensions = []string{"jpg", "jpeg", "png", "gif", "tiff", "webp", "avif", "svg", "cr2", "nef", "arw", "dng", "psd"}
+	AudioExtensions = []string{"mp3", "flac", "opus", "ogg", "m4a", "wav", "aiff", "aif"}
+	VideoExtensions = []string{"mp4", "mov", "avi"}
+	TextExtensions  = []string{"txt", "md", "html", "srt", "ass", "ssa", "vtt"}
+
+	MatroskaExtensions   = []string{"mkv", "mka", "webm"}
+	DocumentExtensions   = []string{"doc", "xls", "ppt", "rtf", "docx", "xlsx", "pptx", "odt", "ods", "odp", "epub"}
+	PDFExtensions        = []string{"pdf"}
+	ArchiveExtensions    = []string{"zip", "tar", "tgz", "gz"}
+	ExecutableExtensions = []string{"exe", "dll", "so", "elf"}
+	EmailExtensions      = []string{"eml"}
+	GPSExtensions        = []string{"gpx", "kml"}
+	DatabaseExtensions   = []string{"sqlite", "sqlite3", "db3"}
+	CalendarExtensions   = []string{"ics"}
 )
 
 // list of all supported file extensions
@@ -55,6 +83,15 @@ func SupportedFormats() []string {
 	allFormats = append(allFormats, AudioExtensions...)
 	allFormats = append(allFormats, VideoExtensions...)
 	allFormats = append(allFormats, TextExtensions...)
+	allFormats = append(allFormats, MatroskaExtensions...)
+	allFormats = append(allFormats, DocumentExtensions...)
+	allFormats = append(allFormats, PDFExtensions...)
+	allFormats = append(allFormats, ArchiveExtensions...)
+	allFormats = append(allFormats, ExecutableExtensions...)
+	allFormats = append(allFormats, EmailExtensions...)
+	allFormats = append(allFormats, GPSExtensions...)
+	allFormats = append(allFormats, DatabaseExtensions...)
+	allFormats = append(allFormats, CalendarExtensions...)
 	return allFormats
 }
 
@@ -95,5 +132,41 @@ func GetFormatType(extension string) (string, error) {
 		return "text", nil
 	}
 
-	return "", fmt.Errorf("unsupported extension: %s", extension)
+	if slices.Contains(MatroskaExtensions, extension) {
+		return "matroska", nil
+	}
+
+	if slices.Contains(DocumentExtensions, extension) {
+		return "document", nil
+	}
+
+	if slices.Contains(PDFExtensions, extension) {
+		return "pdf", nil
+	}
+
+	if slices.Contains(ArchiveExtensions, extension) {
+		return "archive", nil
+	}
+
+	if slices.Contains(ExecutableExtensions, extension) {
+		return "executable", nil
+	}
+
+	if slices.Contains(EmailExtensions, extension) {
+		return "email", nil
+	}
+
+	if slices.Contains(GPSExtensions, extension) {
+		return "gps", nil
+	}
+
+	if slices.Contains(DatabaseExtensions, extension) {
+		return "database", nil
+	}
+
+	if slices.Contains(CalendarExtensions, extension) {
+		return "calendar", nil
+	}
+
+	return "", fmt.Errorf("%w: .%s", ErrUnsupportedFormat, extension)
 }
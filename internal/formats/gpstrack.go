@@ -0,0 +1,518 @@
+// BYZRA ⸻ internal/formats/gpstrack.go
+// GPX and KML tracks: creators, authors, devices and timestamps reported and
+// removed or coarsened; coordinates kept unless the policy drops them
+
+package formats
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"caligra/internal/config"
+)
+
+// implements FormatHandler and PolicyWiper for GPX and KML files
+type GPSTrackHandler struct{}
+
+// elements a wipe cuts out whole: who made the file, with what, and links
+// to their accounts; KML features also carry postal addresses and phone
+// numbers, and arbitrary fields in ExtendedData
+var gpxRemoved = []string{"author", "copyright", "link", "src", "extensions"}
+var kmlRemoved = []string{"author", "link", "address", "phoneNumber", "AddressDetails", "ExtendedData"}
+
+// elements holding positions, cut out when the policy removes coordinates;
+// KML views (LookAt, Camera) and regions point at places too
+var gpxPoints = []string{"wpt", "rtept", "trkpt", "bounds"}
+var kmlPoints = []string{
+	"Point", "LineString", "LinearRing", "Polygon", "MultiGeometry", "Model",
+	"Track", "MultiTrack", "LookAt", "Camera", "Region", "LatLonBox", "LatLonQuad",
+}
+
+// names listed one by one; the rest are counted
+const gpsMaxNames = 20
+
+// the creator attribute of the gpx element
+var gpxCreatorAttr = regexp.MustCompile(`\screator\s*=\s*(?:"[^"]*"|'[^']*')`)
+
+// layouts of GPX times (xsd:dateTime) and KML times, which may also be a
+// bare date, month or year
+var gpsTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"}
+
+// "gpx" or "kml" by the root element, "" for other files
+func GPSTrackType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 4096)
+	n, _ := io.ReadFull(file, head)
+	decoder := xml.NewDecoder(bytes.NewReader(head[:n]))
+	decoder.Strict = false
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "gpx":
+				return "gpx"
+			case "kml":
+				return "kml"
+			}
+			return ""
+		}
+	}
+}
+
+// ╭─ WALKING ───────────────────────────────────╮
+
+// an element of a track file and where it sits in the bytes
+type gpsElement struct {
+	local      string
+	attrs      []xml.Attr
+	start, end int    // the whole element
+	inner      int    // where its content starts
+	close      int    // where its end tag starts
+	text       string // character data directly inside
+	path       []string
+}
+
+func (e *gpsElement) attr(local string) string {
+	for _, attr := range e.attrs {
+		if attr.Name.Local == local && attr.Name.Space != "xmlns" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// the local name of the enclosing element, "" for the root
+func (e *gpsElement) parent() string {
+	if len(e.path) == 0 {
+		return ""
+	}
+	return e.path[len(e.path)-1]
+}
+
+// calls visit for every element once its end tag is read, children first
+func walkGPSTrack(data []byte, visit func(e *gpsElement)) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var stack []*gpsElement
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid XML: %w", err)
+		}
+		next := int(decoder.InputOffset())
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			path := make([]string, len(stack))
+			for i, open := range stack {
+				path[i] = open.local
+			}
+			stack = append(stack, &gpsElement{local: t.Name.Local, attrs: t.Attr, start: offset, inner: next, path: path})
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return fmt.Errorf("invalid XML: unexpected </%s>", t.Name.Local)
+			}
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			e.close, e.end = offset, next
+			e.text = strings.TrimSpace(e.text)
+			visit(e)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("invalid XML: <%s> is not closed", stack[len(stack)-1].local)
+	}
+	return nil
+}
+
+// whether e holds a time: GPX <time>, KML <when>, <begin> and <end>
+func isGPSTime(kind string, e *gpsElement) bool {
+	if kind == "gpx" {
+		return e.local == "time"
+	}
+	return e.local == "when" || e.local == "begin" || e.local == "end"
+}
+
+func parseGPSTime(value string) (time.Time, bool) {
+	for _, layout := range gpsTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ╭─ READING ───────────────────────────────────╮
+
+// the creator, author, links, device names, extension fields and times;
+// names and point counts are reported too, as what the wipe keeps
+func (h *GPSTrackHandler) ExtractMetadata(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read track file: %w", err)
+	}
+	kind := GPSTrackType(path)
+
+	var authors, copyrights, links, devices, addresses, phones, extensions []string
+	var trackNames, waypointNames []string
+	var times []time.Time
+	var unparsed []string
+	points := make(map[string]int)
+	creator := ""
+
+	add := func(list *[]string, value string) {
+		if value = strings.Join(strings.Fields(value), " "); value != "" && !slices.Contains(*list, value) {
+			*list = append(*list, value)
+		}
+	}
+	err = walkGPSTrack(data, func(e *gpsElement) {
+		switch {
+		case e.local == "gpx" && len(e.path) == 0:
+			creator = e.attr("creator")
+		case isGPSTime(kind, e) && e.text != "":
+			if t, ok := parseGPSTime(e.text); ok {
+				times = append(times, t)
+			} else {
+				add(&unparsed, e.text)
+			}
+		case e.parent() == "author" && e.local == "name":
+			add(&authors, e.text)
+		case e.parent() == "author" && e.local == "email":
+			if id, domain := e.attr("id"), e.attr("domain"); id != "" {
+				add(&authors, id+"@"+domain) // GPX
+			} else {
+				add(&authors, e.text) // Atom, in KML
+			}
+		case e.local == "copyright":
+			add(&copyrights, e.attr("author"))
+		case e.local == "link" && e.attr("href") != "":
+			add(&links, e.attr("href"))
+		case e.local == "src":
+			add(&devices, e.text)
+		case e.local == "address" || (slices.Contains(e.path, "AddressDetails") && e.text != ""):
+			add(&addresses, e.text)
+		case e.local == "phoneNumber":
+			add(&phones, e.text)
+		case slices.Contains(e.path, "extensions") && e.text != "":
+			add(&extensions, e.local)
+		case slices.Contains(e.path, "ExtendedData") && (e.local == "Data" || e.local == "SimpleData"):
+			add(&extensions, e.attr("name"))
+		case e.local == "name" && (e.parent() == "wpt" || e.parent() == "Placemark"):
+			add(&waypointNames, e.text)
+		case e.local == "name" && slices.Contains([]string{"trk", "rte", "metadata", "Document", "Folder"}, e.parent()):
+			add(&trackNames, e.text)
+		case kind == "gpx" && slices.Contains(gpxPoints[:3], e.local):
+			points[e.local]++
+		case e.local == "coordinates":
+			points["position"] += len(strings.Fields(e.text))
+		case e.local == "coord":
+			points["position"]++
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse track file: %w", err)
+	}
+
+	metadata := make(map[string]any)
+	set := func(key string, values []string) {
+		if len(values) > gpsMaxNames {
+			values = append(values[:gpsMaxNames:gpsMaxNames], fmt.Sprintf("and %d more", len(values)-gpsMaxNames))
+		}
+		if len(values) > 0 {
+			metadata[key] = strings.Join(values, "; ")
+		}
+	}
+	if creator != "" {
+		metadata["Software"] = creator
+	}
+	set("Author", authors)
+	set("Copyright", copyrights)
+	set("ActivityLink", links)
+	set("DeviceName", devices)
+	set("Address", addresses)
+	set("PhoneNumber", phones)
+	set("Extensions", extensions)
+	set("TrackNames", trackNames)
+	set("WaypointNames", waypointNames)
+	if key, value := describeGPSTimes(times, unparsed); value != "" {
+		metadata[key] = value
+	}
+
+	var counts []string
+	for _, point := range []struct{ key, label string }{
+		{"trkpt", "track point"}, {"rtept", "route point"}, {"wpt", "waypoint"}, {"position", "position"},
+	} {
+		if n := points[point.key]; n > 0 {
+			counts = append(counts, pluralCount(n, point.label))
+		}
+	}
+	if len(counts) > 0 {
+		metadata["Points"] = strings.Join(counts, ", ")
+	}
+	return metadata, nil
+}
+
+// the span of the times, as TrackDates; times that all fall on midnight
+// UTC (coarsened, or KML dates without a time) say only the day and are
+// reported as TrackDays
+func describeGPSTimes(times []time.Time, unparsed []string) (string, string) {
+	if len(times) == 0 {
+		if len(unparsed) == 0 {
+			return "", ""
+		}
+		return "TrackDates", strings.Join(unparsed, "; ")
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	key, layout := "TrackDays", "2006-01-02"
+	for _, t := range times {
+		if !t.Equal(t.Truncate(24 * time.Hour)) {
+			key, layout = "TrackDates", time.RFC3339
+			break
+		}
+	}
+	if len(unparsed) > 0 {
+		key = "TrackDates"
+	}
+
+	first, last := times[0].Format(layout), times[len(times)-1].Format(layout)
+	if first == last {
+		return key, fmt.Sprintf("%s, %s", pluralCount(len(times), "timestamp"), first)
+	}
+	return key, fmt.Sprintf("%s, %s to %s", pluralCount(len(times), "timestamp"), first, last)
+}
+
+// "1 waypoint", "2 waypoints"
+func pluralCount(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// ╭─ WRITING ───────────────────────────────────╮
+
+// a byte range of the file and what replaces it
+type gpsEdit struct {
+	start, end int
+	text       string
+	whole      bool // an element cut out, with the indentation before it
+}
+
+// removes creators, authors, links, devices and times
+func (h *GPSTrackHandler) WipeMetadata(path string) error {
+	_, err := h.WipeWithPolicy(path, config.DefaultPolicy())
+	return err
+}
+
+// wipes a track file: times removed, kept or coarsened by policy.Dates, and
+// every point dropped when policy.Coordinates is "remove"
+func (h *GPSTrackHandler) WipeWithPolicy(path string, policy *config.Policy) (*PolicyOutcome, error) {
+	outcome := &PolicyOutcome{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return outcome, fmt.Errorf("failed to read track file: %w", err)
+	}
+	kind := GPSTrackType(path)
+
+	removed, pointElements := gpxRemoved, gpxPoints
+	if kind == "kml" {
+		removed, pointElements = kmlRemoved, kmlPoints
+	}
+	stripPoints := policy.Coordinates == config.Remove
+
+	var edits []gpsEdit
+	cut := func(e *gpsElement) {
+		// edits inside the element go with it; children end first, so
+		// theirs are the last ones made
+		for len(edits) > 0 && edits[len(edits)-1].start >= e.start {
+			edits = edits[:len(edits)-1]
+		}
+		edits = append(edits, gpsEdit{start: e.start, end: e.end, whole: true})
+	}
+	times, points := 0, 0
+	coarsened := ""
+	err = walkGPSTrack(data, func(e *gpsElement) {
+		switch {
+		case kind == "gpx" && e.local == "gpx" && len(e.path) == 0:
+			if loc := gpxCreatorAttr.FindIndex(data[e.start:e.inner]); loc != nil {
+				edits = append(edits, gpsEdit{start: e.start + loc[0], end: e.start + loc[1], text: ` creator=""`})
+			}
+		case slices.Contains(removed, e.local):
+			cut(e)
+		case stripPoints && slices.Contains(pointElements, e.local):
+			points++
+			cut(e)
+		case isGPSTime(kind, e):
+			times++
+			switch policy.Dates {
+			case config.Keep:
+			case config.Remove:
+				if kind == "kml" && (e.parent() == "TimeStamp" || e.parent() == "TimeSpan") {
+					return // the TimeStamp or TimeSpan goes whole
+				}
+				cut(e)
+			default:
+				if coarse, ok := coarsenGPSTime(kind, e.text, policy.Dates); ok {
+					edits = append(edits, gpsEdit{start: e.inner, end: e.close, text: coarse})
+					if coarsened == "" {
+						coarsened = coarse
+					}
+				}
+			}
+		case kind == "kml" && policy.Dates == config.Remove && (e.local == "TimeStamp" || e.local == "TimeSpan"):
+			cut(e)
+		}
+	})
+	if err != nil {
+		return outcome, fmt.Errorf("failed to parse track file: %w", err)
+	}
+
+	switch {
+	case times == 0:
+		outcome.Dates = "none present"
+	case policy.Dates == config.Keep:
+		outcome.Dates = "kept"
+	case policy.Dates == config.Remove:
+		outcome.Dates = fmt.Sprintf("removed (%d)", times)
+	default:
+		outcome.Dates = fmt.Sprintf("coarsened to %s (%s)", policy.Dates, coarsened)
+	}
+	if stripPoints {
+		outcome.Coordinates = fmt.Sprintf("removed (%s)", pluralCount(points, "element"))
+	}
+
+	if len(edits) == 0 {
+		return outcome, nil
+	}
+	return outcome, replaceFile(path, applyGPSEdits(data, edits))
+}
+
+// a time cut down to the day, month or year; KML takes a bare date, GPX
+// needs a full time
+func coarsenGPSTime(kind, value, precision string) (string, bool) {
+	t, ok := parseGPSTime(value)
+	if !ok {
+		return "", false
+	}
+	year, month, day := t.Date()
+	layout := "2006-01-02"
+	switch precision {
+	case config.DatesYear:
+		month, day, layout = time.January, 1, "2006"
+	case config.DatesMonth:
+		day, layout = 1, "2006-01"
+	}
+	coarse := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if kind == "gpx" {
+		return coarse.Format("2006-01-02T15:04:05Z"), true
+	}
+	return coarse.Format(layout), true
+}
+
+// data with the edits made, in order; elements cut out whole take the line
+// they stood on when nothing else is on it
+func applyGPSEdits(data []byte, edits []gpsEdit) []byte {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var out []byte
+	pos := 0
+	for _, edit := range edits {
+		start := edit.start
+		if edit.whole {
+			if i := bytes.LastIndexByte(data[pos:start], '\n'); i >= 0 && len(bytes.Trim(data[pos+i:start], " \t\n")) == 0 {
+				start = pos + i
+				if start > pos && data[start-1] == '\r' {
+					start--
+				}
+			}
+		}
+		out = append(out, data[pos:start]...)
+		out = append(out, edit.text...)
+		pos = edit.end
+	}
+	return append(out, data[pos:]...)
+}
+
+// the profile's software as the GPX creator; KML has no such field
+func (h *GPSTrackHandler) InjectMetadata(path string, profile map[string]string) error {
+	software := ""
+	for key, value := range profile {
+		if strings.EqualFold(key, "software") {
+			software = value
+		}
+	}
+	if software == "" || GPSTrackType(path) != "gpx" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read track file: %w", err)
+	}
+	var root *gpsElement
+	if err := walkGPSTrack(data, func(e *gpsElement) {
+		if len(e.path) == 0 {
+			root = e
+		}
+	}); err != nil || root == nil {
+		return fmt.Errorf("failed to parse track file: %v", err)
+	}
+
+	// in place of the old attribute, or right after the element name
+	name := root.start + 1 + bytes.IndexAny(data[root.start+1:root.inner], " \t\r\n/>")
+	edit := gpsEdit{start: name, end: name, text: ` creator="` + xmlEscape(software) + `"`}
+	if loc := gpxCreatorAttr.FindIndex(data[root.start:root.inner]); loc != nil {
+		edit.start, edit.end = root.start+loc[0], root.start+loc[1]
+	}
+	return replaceFile(path, applyGPSEdits(data, []gpsEdit{edit}))
+}
+
+// the XML is well formed and its root is gpx or kml
+func (h *GPSTrackHandler) VerifyIntegrity(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(file)
+	root := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return root == "gpx" || root == "kml"
+		}
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok && root == "" {
+			root = start.Name.Local
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// BYZRA ⸻ internal/formats/id3.go
+// ID3v2 frame parsing for chapters and lyrics
+
+package formats
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// frames that carry chapter tables and lyrics
+var id3ContentFrames = map[string]string{
+	"CHAP": "chapter",
+	"CTOC": "chapter-toc",
+	"USLT": "lyrics",
+	"SYLT": "lyrics",
+}
+
+// one frame of an ID3v2 tag
+type id3Frame struct {
+	ID     string
+	Data   []byte
+	offset int // within the tag body
+	size   int // header + data
+}
+
+// parsed ID3v2 tag at the start of a file
+type id3Tag struct {
+	Version int
+	Frames  []id3Frame
+	body    []byte // everything after the 10-byte header
+}
+
+// reads the ID3v2 tag of a file; nil when there is none or it can't be parsed
+// safely. A tag cut short by the end of the file yields the frames it holds
+func readID3Tag(path string) (*id3Tag, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 10)
+	if _, err := file.ReadAt(header, 0); err != nil || string(header[:3]) != "ID3" {
+		return nil, nil
+	}
+	body := make([]byte, synchsafe(header[6:10]))
+	n, err := file.ReadAt(body, 10)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read ID3 tag: %w", err)
+	}
+	return parseID3Tag(header, body[:n]), nil
+}
+
+// the frames of a tag given its 10-byte header and its body; nil for
+// versions and flags left to exiftool
+func parseID3Tag(header, body []byte) *id3Tag {
+	version := int(header[3])
+	flags := header[5]
+	if version < 3 || version > 4 || flags&0x80 != 0 {
+		return nil // v2.2 and unsynchronised tags are left to exiftool
+	}
+
+	tag := &id3Tag{Version: version, body: body}
+
+	pos := 0
+	if flags&0x40 != 0 && len(body) >= 4 {
+		// extended header
+		if version == 4 {
+			pos = synchsafe(body[:4])
+		} else {
+			pos = int(binary.BigEndian.Uint32(body[:4])) + 4
+		}
+	}
+
+	for pos+10 <= len(body) {
+		id := string(body[pos : pos+4])
+		if body[pos] == 0 {
+			break // padding
+		}
+
+		var frameSize int
+		if version == 4 {
+			frameSize = synchsafe(body[pos+4 : pos+8])
+		} else {
+			frameSize = int(binary.BigEndian.Uint32(body[pos+4 : pos+8]))
+		}
+		if frameSize < 0 || pos+10+frameSize > len(body) {
+			break
+		}
+
+		tag.Frames = append(tag.Frames, id3Frame{
+			ID:     id,
+			Data:   body[pos+10 : pos+10+frameSize],
+			offset: pos,
+			size:   10 + frameSize,
+		})
+		pos += 10 + frameSize
+	}
+
+	return tag
+}
+
+// text frames as exiftool names them
+var id3TextNames = map[string]string{
+	"TPE1": "Artist", "TPE2": "Band", "TIT2": "Title", "TALB": "Album",
+	"TCOM": "Composer", "TCOP": "Copyright", "TPUB": "Publisher", "TCON": "Genre",
+	"TENC": "EncodedBy", "TSSE": "EncoderSettings", "TDRC": "RecordingTime",
+	"TYER": "Year", "TDEN": "EncodingTime", "TOWN": "FileOwner",
+}
+
+// the text, comment and user-defined frames of a tag, keyed like exiftool
+func (t *id3Tag) texts() map[string]string {
+	texts := make(map[string]string)
+	for _, frame := range t.Frames {
+		if len(frame.Data) < 2 {
+			continue
+		}
+		encoding, data := frame.Data[0], frame.Data[1:]
+		var name, value string
+		switch {
+		case frame.ID == "COMM" && len(data) >= 3:
+			_, text := cutTerminated(data[3:], encoding) // after the language
+			name, value = "Comment", decodeID3Text(encoding, text)
+		case frame.ID == "TXXX":
+			description, text := cutTerminated(data, encoding)
+			name, value = description, decodeID3Text(encoding, text)
+		case id3TextNames[frame.ID] != "":
+			name, value = id3TextNames[frame.ID], decodeID3Text(encoding, data)
+		}
+		if value = strings.TrimSpace(value); name != "" && value != "" {
+			texts[name] = value
+		}
+	}
+	return texts
+}
+
+// removes the given frames in place, padding the tag to its original size
+func stripID3Frames(path string, ids map[string]string) ([]string, error) {
+	tag, err := readID3Tag(path)
+	if err != nil || tag == nil {
+		return nil, err
+	}
+
+	var removed []string
+	rebuilt := make([]byte, 0, len(tag.body))
+	last := 0
+	for _, frame := range tag.Frames {
+		if _, drop := ids[frame.ID]; !drop {
+			continue
+		}
+		rebuilt = append(rebuilt, tag.body[last:frame.offset]...)
+		last = frame.offset + frame.size
+		removed = append(removed, frame.ID)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	rebuilt = append(rebuilt, tag.body[last:]...)
+
+	// zero padding keeps the audio where it is
+	rebuilt = append(rebuilt, make([]byte, len(tag.body)-len(rebuilt))...)
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteAt(rebuilt, 10); err != nil {
+		return nil, fmt.Errorf("failed to rewrite ID3 tag: %w", err)
+	}
+	return removed, nil
+}
+
+// chapter and lyrics frames as embedded items
+func listID3Content(path string) ([]Embedded, error) {
+	tag, err := readID3Tag(path)
+	if err != nil || tag == nil {
+		return nil, err
+	}
+
+	var embedded []Embedded
+	for _, frame := range tag.Frames {
+		kind, ok := id3ContentFrames[frame.ID]
+		if !ok {
+			continue
+		}
+
+		var name, detail string
+		switch frame.ID {
+		case "CHAP":
+			name, detail = describeChapter(frame.Data, tag.Version)
+		case "CTOC":
+			name, detail = describeTOC(frame.Data, tag.Version)
+		case "USLT":
+			name, detail = describeLyrics(frame.Data)
+		case "SYLT":
+			name, detail = "SYLT", "synchronised lyrics"
+		}
+
+		embedded = append(embedded, Embedded{Kind: kind, Name: name, Detail: detail})
+	}
+	return embedded, nil
+}
+
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// "CHAP ch1", "Intro @ 00:00:00 (https://...)"
+func describeChapter(data []byte, version int) (string, string) {
+	id, rest := cutTerminated(data, 0)
+	name := "CHAP " + id
+	if len(rest) < 16 {
+		return name, ""
+	}
+
+	start := time.Duration(binary.BigEndian.Uint32(rest[:4])) * time.Millisecond
+	title, urls := describeSubFrames(rest[16:], version)
+
+	detail := strings.TrimSpace(title + " @ " + formatClock(start))
+	if len(urls) > 0 {
+		detail += " (" + strings.Join(urls, ", ") + ")"
+	}
+	return name, detail
+}
+
+// "CTOC toc", "Contents, 5 entries"
+func describeTOC(data []byte, version int) (string, string) {
+	id, rest := cutTerminated(data, 0)
+	name := "CTOC " + id
+	if len(rest) < 2 {
+		return name, ""
+	}
+
+	count := int(rest[1])
+	rest = rest[2:]
+	for i := 0; i < count && len(rest) > 0; i++ {
+		_, rest = cutTerminated(rest, 0)
+	}
+
+	title, _ := describeSubFrames(rest, version)
+	return name, strings.TrimSpace(fmt.Sprintf("%s, %d entries", title, count))
+}
+
+// "USLT (eng)", first words of the lyrics plus any URLs
+func describeLyrics(data []byte) (string, string) {
+	if len(data) < 4 {
+		return "USLT", ""
+	}
+
+	encoding := data[0]
+	lang := string(data[1:4])
+	_, text := cutTerminated(data[4:], encoding)
+	lyrics := decodeID3Text(encoding, text)
+
+	detail := strings.Join(strings.Fields(lyrics), " ")
+	if runes := []rune(detail); len(runes) > 60 {
+		detail = string(runes[:60]) + "…"
+	}
+	if urls := urlPattern.FindAllString(lyrics, -1); len(urls) > 0 {
+		detail += " (" + strings.Join(urls, ", ") + ")"
+	}
+	return "USLT (" + lang + ")", detail
+}
+
+// TIT2 title and WXXX/W*** URLs inside a CHAP/CTOC frame
+func describeSubFrames(data []byte, version int) (string, []string) {
+	title := ""
+	var urls []string
+
+	for len(data) >= 10 {
+		id := string(data[:4])
+		var size int
+		if version == 4 {
+			size = synchsafe(data[4:8])
+		} else {
+			size = int(binary.BigEndian.Uint32(data[4:8]))
+		}
+		if size <= 0 || 10+size > len(data) {
+			break
+		}
+		body := data[10 : 10+size]
+
+		switch {
+		case id == "TIT2" && len(body) > 0:
+			title = decodeID3Text(body[0], body[1:])
+		case id == "WXXX" && len(body) > 0:
+			_, url := cutTerminated(body[1:], body[0])
+			urls = append(urls, strings.TrimRight(string(url), "\x00"))
+		case strings.HasPrefix(id, "W"):
+			urls = append(urls, strings.TrimRight(string(body), "\x00"))
+		}
+		data = data[10+size:]
+	}
+
+	return title, urls
+}
+
+// splits at the encoding's terminator (one zero byte, or two for UTF-16)
+func cutTerminated(data []byte, encoding byte) (string, []byte) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return decodeID3Text(encoding, data[:i]), data[i+2:]
+			}
+		}
+		return decodeID3Text(encoding, data), nil
+	}
+
+	for i, b := range data {
+		if b == 0 {
+			return string(data[:i]), data[i+1:]
+		}
+	}
+	return string(data), nil
+}
+
+// text in one of the four ID3 encodings
+func decodeID3Text(encoding byte, data []byte) string {
+	switch encoding {
+	case 1, 2:
+		bigEndian := encoding == 2
+		if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE {
+			bigEndian, data = false, data[2:]
+		} else if len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF {
+			bigEndian, data = true, data[2:]
+		}
+
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			if bigEndian {
+				units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+			} else {
+				units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+			}
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	case 3:
+		return strings.TrimRight(string(data), "\x00")
+	default:
+		// ISO-8859-1 maps directly onto the first 256 code points
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.TrimRight(string(runes), "\x00")
+	}
+}
+
+func synchsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+func formatClock(d time.Duration) string {
+	total := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total/60%60, total%60)
+}
@@ -5,8 +5,8 @@ package formats
 
 import (
 	"fmt"
-	"os/exec"
 	"strings"
+	"time"
 
 	"caligra/internal/util"
 )
@@ -32,10 +32,26 @@ func (h *ImageHandler) ExtractMetadata(path string) (map[string]any, error) {
 
 // removes all metadata from image files
 func (h *ImageHandler) WipeMetadata(path string) error {
+	if _, err := stripJPEGComments(path); err != nil {
+		return fmt.Errorf("failed to strip JPEG comments: %w", err)
+	}
+	if _, err := stripWebPMetadata(path); err != nil {
+		return fmt.Errorf("failed to strip WebP metadata chunks: %w", err)
+	}
+	if _, err := stripPSDResources(path, psdMetadataResources); err != nil {
+		return fmt.Errorf("failed to strip Photoshop image resources: %w", err)
+	}
+
 	err := util.ExifToolRemove(path)
 	if err != nil {
 		return fmt.Errorf("failed to wipe image metadata: %w", err)
 	}
+
+	if kind := RawImageType(path); kind != "" {
+		if err := scrubRawMakerNote(path, kind); err != nil {
+			return fmt.Errorf("failed to scrub %s MakerNote: %w", strings.ToUpper(kind), err)
+		}
+	}
 	return nil
 }
 
@@ -48,8 +64,7 @@ func (h *ImageHandler) InjectMetadata(path string, profile map[string]string) er
 			continue // skip unmapped keys
 		}
 
-		cmd := exec.Command("exiftool", fmt.Sprintf("-%s=%s", tag, value), "-overwrite_original", path)
-		if err := cmd.Run(); err != nil {
+		if err := util.ExifToolSetTag(path, tag, value); err != nil {
 			return fmt.Errorf("failed to inject %s metadata: %w", key, err)
 		}
 	}
@@ -58,10 +73,7 @@ func (h *ImageHandler) InjectMetadata(path string, profile map[string]string) er
 
 // ensures the image is still valid after modification
 func (h *ImageHandler) VerifyIntegrity(path string) bool {
-	// for images, use identify from ImageMagick
-	cmd := exec.Command("identify", path)
-	err := cmd.Run()
-	return err == nil
+	return checkImage(path)
 }
 
 // maps profile keys to ExifTool tag names
@@ -83,3 +95,8 @@ func mapProfileKeyToExifTag(key string) string {
 		return ""
 	}
 }
+
+// rewrites date-time tags into loc and strips offset tags
+func (h *ImageHandler) NormalizeTimestamps(path string, loc *time.Location) ([]string, error) {
+	return exifToolNormalizeTimestamps(path, loc)
+}
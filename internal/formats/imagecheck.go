@@ -0,0 +1,340 @@
+// BYZRA ⸻ internal/formats/imagecheck.go
+// pure-Go integrity checks for images
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// images declaring more pixels than this are left to identify, whose
+// resource limits keep a forged header from exhausting memory
+const maxDecodePixels = 100_000_000
+
+// decodes the whole image where Go has a decoder (JPEG, PNG, GIF: every
+// pixel, every frame) and parses SVG; ImageMagick's identify is left for
+// what Go cannot read, such as TIFF or JPEG features outside baseline and
+// progressive. WebP and AVIF have their container and bitstream headers
+// checked here, and are decoded by identify too when it is installed, as
+// are Photoshop files once their sections check out; camera RAW has its
+// IFDs and image data offsets checked
+func checkImage(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	// the declared size decides what a full decode would allocate
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil &&
+		int64(config.Width)*int64(config.Height) > maxDecodePixels {
+		return identify(path)
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		_, err := jpeg.Decode(bytes.NewReader(data))
+		var unsupported jpeg.UnsupportedError
+		if errors.As(err, &unsupported) {
+			return identify(path)
+		}
+		return err == nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		_, err := png.Decode(bytes.NewReader(data))
+		return err == nil
+	case bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a")):
+		_, err := gif.DecodeAll(bytes.NewReader(data))
+		return err == nil
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		if !checkWebP(data) {
+			return false
+		}
+		if _, err := exec.LookPath("identify"); err != nil {
+			return true
+		}
+		return identify(path)
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		if !checkAVIF(data) {
+			return false
+		}
+		if _, err := exec.LookPath("identify"); err != nil {
+			return true
+		}
+		return identify(path)
+	case bytes.HasPrefix(data, []byte("8BPS")):
+		if !checkPSD(data) {
+			return false
+		}
+		if _, err := exec.LookPath("identify"); err != nil {
+			return true
+		}
+		return identify(path)
+	case rawImageKind(data) != "":
+		return checkRaw(data)
+	case isSVG(data):
+		return checkSVG(data)
+	}
+	return identify(path)
+}
+
+// does ImageMagick read the file?
+func identify(path string) bool {
+	return exec.Command("identify", path).Run() == nil
+}
+
+// ╭─ SVG ───────────────────────────────────────╮
+
+func isSVG(data []byte) bool {
+	head := strings.ToLower(string(data[:min(len(data), 1024)]))
+	return strings.Contains(head, "<svg")
+}
+
+// well-formed XML whose root element is <svg>
+func checkSVG(data []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+	root := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return root == "svg"
+		}
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok && root == "" {
+			root = start.Name.Local
+		}
+	}
+}
+
+// ╭─ WEBP ──────────────────────────────────────╮
+
+// the RIFF container holds whole chunks, and the image chunks (VP8 for
+// lossy, VP8L for lossless, inside ANMF for animations) carry valid frame
+// headers with non-zero dimensions
+func checkWebP(data []byte) bool {
+	riffSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	if riffSize < 4 || 8+riffSize > len(data) {
+		return false // truncated
+	}
+	frames, ok := checkWebPChunks(data[12 : 8+riffSize])
+	return ok && frames > 0
+}
+
+// walks a chunk list, returning how many image frames it holds
+func checkWebPChunks(chunks []byte) (int, bool) {
+	frames := 0
+	for len(chunks) > 0 {
+		if len(chunks) < 8 {
+			return 0, false
+		}
+		fourcc := string(chunks[:4])
+		size := int(binary.LittleEndian.Uint32(chunks[4:8]))
+		if size > len(chunks)-8 {
+			return 0, false
+		}
+		payload := chunks[8 : 8+size]
+
+		switch fourcc {
+		case "VP8 ":
+			if !checkVP8(payload) {
+				return 0, false
+			}
+			frames++
+		case "VP8L":
+			if !checkVP8L(payload) {
+				return 0, false
+			}
+			frames++
+		case "ANMF":
+			// frame position, size and timing, then the frame's own chunks
+			if len(payload) < 16 {
+				return 0, false
+			}
+			n, ok := checkWebPChunks(payload[16:])
+			if !ok || n == 0 {
+				return 0, false
+			}
+			frames += n
+		case "VP8X":
+			if len(payload) < 10 {
+				return 0, false
+			}
+		}
+
+		// chunks are padded to an even size
+		next := 8 + size + size&1
+		if next > len(chunks) {
+			next = len(chunks)
+		}
+		chunks = chunks[next:]
+	}
+	return frames, true
+}
+
+// a lossy key frame: frame tag, start code and dimensions
+func checkVP8(frame []byte) bool {
+	if len(frame) < 10 {
+		return false
+	}
+	tag := uint32(frame[0]) | uint32(frame[1])<<8 | uint32(frame[2])<<16
+	keyFrame := tag&1 == 0
+	firstPartition := int(tag >> 5)
+	if !keyFrame || firstPartition > len(frame)-10 {
+		return false
+	}
+	if !bytes.Equal(frame[3:6], []byte{0x9d, 0x01, 0x2a}) {
+		return false
+	}
+	width := binary.LittleEndian.Uint16(frame[6:8]) & 0x3fff
+	height := binary.LittleEndian.Uint16(frame[8:10]) & 0x3fff
+	return width > 0 && height > 0
+}
+
+// a lossless bitstream: signature and version 0
+func checkVP8L(stream []byte) bool {
+	if len(stream) < 5 || stream[0] != 0x2f {
+		return false
+	}
+	bits := binary.LittleEndian.Uint32(stream[1:5])
+	return bits>>29 == 0 // width and height are stored minus one, so never zero
+}
+
+// ╭─ AVIF ──────────────────────────────────────╮
+
+// the boxes fill the file, a meta box names a primary item, and every
+// extent its item locations point to lies within the file
+func checkAVIF(data []byte) bool {
+	boxes, ok := bmffBoxes(data)
+	if !ok || len(boxes) == 0 || boxes[0].kind != "ftyp" {
+		return false
+	}
+	for _, box := range boxes {
+		if box.kind != "meta" || len(box.payload) < 4 {
+			continue
+		}
+		children, ok := bmffBoxes(box.payload[4:]) // a full box
+		if !ok {
+			return false
+		}
+		var primary, locations bool
+		for _, child := range children {
+			switch child.kind {
+			case "pitm":
+				primary = true
+			case "iloc":
+				if !checkItemLocations(child.payload, len(data)) {
+					return false
+				}
+				locations = true
+			}
+		}
+		return primary && locations
+	}
+	return false
+}
+
+type bmffBox struct {
+	kind    string
+	payload []byte
+}
+
+// splits data into boxes; false when one runs past the end
+func bmffBoxes(data []byte) ([]bmffBox, bool) {
+	var boxes []bmffBox
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, false
+		}
+		size, header := uint64(binary.BigEndian.Uint32(data[:4])), uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, false
+			}
+			size, header = binary.BigEndian.Uint64(data[8:16]), 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, false
+		}
+		boxes = append(boxes, bmffBox{kind: string(data[4:8]), payload: data[header:size]})
+		data = data[size:]
+	}
+	return boxes, true
+}
+
+// the iloc box: extents stored at file offsets must end within the file
+func checkItemLocations(iloc []byte, fileSize int) bool {
+	r := bmffFields{data: iloc}
+	version := r.uint(1)
+	r.uint(3) // flags
+	sizes := r.uint(2)
+	offsetSize, lengthSize, baseSize, indexSize := sizes>>12, sizes>>8&0xf, sizes>>4&0xf, sizes&0xf
+	if version == 0 {
+		indexSize = 0 // reserved
+	}
+	count := r.uint(2)
+	if version == 2 {
+		count = r.uint(4)
+	}
+
+	for range count {
+		if r.short {
+			return false
+		}
+		if version == 2 {
+			r.uint(4) // item ID
+		} else {
+			r.uint(2)
+		}
+		method := uint64(0)
+		if version > 0 {
+			method = r.uint(2) & 0xf
+		}
+		r.uint(2) // data reference index
+		base := r.uint(int(baseSize))
+		extents := r.uint(2)
+		for range extents {
+			r.uint(int(indexSize))
+			offset, length := r.uint(int(offsetSize)), r.uint(int(lengthSize))
+			// method 0 is an offset into the file; 1 and 2 point into idat
+			// and other items
+			if method == 0 && base+offset+length > uint64(fileSize) {
+				return false
+			}
+		}
+	}
+	return !r.short
+}
+
+// reads big-endian fields of 0 to 8 bytes, noting when data runs out
+type bmffFields struct {
+	data  []byte
+	short bool
+}
+
+func (r *bmffFields) uint(n int) uint64 {
+	if n > len(r.data) || n > 8 {
+		r.short, r.data = true, nil
+		return 0
+	}
+	var v uint64
+	for _, b := range r.data[:n] {
+		v = v<<8 | uint64(b)
+	}
+	r.data = r.data[n:]
+	return v
+}
@@ -0,0 +1,228 @@
+// BYZRA ⸻ internal/formats/imagepolicy.go
+// policy-aware image wipe: exposure and colour tags kept, dates coarsened
+
+package formats
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"caligra/internal/config"
+	"caligra/internal/util"
+)
+
+// capture dates a policy can keep or coarsen
+var imageDateTags = []string{"DateTimeOriginal", "CreateDate"}
+
+// offsets travel with kept dates, never with coarsened ones
+var imageOffsetTags = []string{"OffsetTime", "OffsetTimeOriginal", "OffsetTimeDigitized"}
+
+// wipes an image, keeping what the policy asks for
+func (h *ImageHandler) WipeWithPolicy(path string, policy *config.Policy) (*PolicyOutcome, error) {
+	outcome := &PolicyOutcome{}
+
+	// removed as one block, so no vendor sub-IFD survives a partial rewrite
+	if policy.ExciseMakerNote {
+		note, err := exciseMakerNote(path)
+		if err != nil {
+			return outcome, fmt.Errorf("failed to excise MakerNote: %w", err)
+		}
+		outcome.MakerNote = "none present"
+		if note != nil {
+			outcome.MakerNote = "excised (" + note.describe() + ")"
+		}
+	}
+
+	keepOrientation := keepsImageTag(policy, "Orientation")
+	if policy.AutoRotate && !keepOrientation {
+		applied, err := h.orient(path, policy)
+		if err != nil {
+			return outcome, fmt.Errorf("failed to apply orientation: %w", err)
+		}
+		outcome.Orientation = applied
+		keepOrientation = applied == orientationAtReencode || applied == orientationKept
+	}
+
+	if len(policy.KeepImageTags) == 0 && policy.Dates == config.Remove && !keepOrientation {
+		return outcome, h.WipeMetadata(path)
+	}
+
+	metadata, err := h.ExtractMetadata(path)
+	if err != nil {
+		return outcome, err
+	}
+
+	// comments go unless the policy keeps them by name
+	if !keepsImageTag(policy, "Comment") {
+		if _, err := stripJPEGComments(path); err != nil {
+			return outcome, fmt.Errorf("failed to strip JPEG comments: %w", err)
+		}
+	}
+
+	keep := append([]string{}, policy.KeepImageTags...)
+	if keepOrientation && !keepsImageTag(policy, "Orientation") {
+		keep = append(keep, "Orientation")
+	}
+	if policy.Dates == config.Keep {
+		keep = append(append(keep, imageDateTags...), imageOffsetTags...)
+	}
+
+	// the native strippers drop whole EXIF and XMP blocks, kept tags and
+	// all, so those are copied back from a snapshot taken first
+	source := "@"
+	if len(keep) > 0 && holdsMetadataChunks(path) {
+		snapshot, err := snapshotImage(path)
+		if err != nil {
+			return outcome, err
+		}
+		defer os.Remove(snapshot)
+		source = snapshot
+	}
+
+	if _, err := stripWebPMetadata(path); err != nil {
+		return outcome, fmt.Errorf("failed to strip WebP metadata chunks: %w", err)
+	}
+	if _, err := stripPSDResources(path, psdMetadataResources); err != nil {
+		return outcome, fmt.Errorf("failed to strip Photoshop image resources: %w", err)
+	}
+	if err := util.ExifToolRemoveExceptFrom(path, source, keep); err != nil {
+		return outcome, fmt.Errorf("failed to wipe image metadata: %w", err)
+	}
+	if kind := RawImageType(path); kind != "" {
+		if err := scrubRawMakerNote(path, kind); err != nil {
+			return outcome, fmt.Errorf("failed to scrub %s MakerNote: %w", strings.ToUpper(kind), err)
+		}
+	}
+
+	if len(policy.KeepImageTags) > 0 {
+		outcome.ImageTags = "kept " + strings.Join(policy.KeepImageTags, ", ") + " where present; removed the rest"
+	}
+
+	switch policy.Dates {
+	case config.Keep:
+		outcome.Dates = "kept"
+	case config.Remove:
+		outcome.Dates = "removed"
+	default:
+		assignments, coarsened := coarsenImageDates(metadata, policy.Dates)
+		if len(assignments) == 0 {
+			outcome.Dates = "none present"
+			break
+		}
+		if err := util.ExifToolWrite(path, assignments); err != nil {
+			return outcome, fmt.Errorf("failed to write coarsened dates: %w", err)
+		}
+		outcome.Dates = fmt.Sprintf("coarsened to %s (%s)", policy.Dates, coarsened)
+	}
+
+	return outcome, nil
+}
+
+// does the file keep metadata in blocks the native strippers remove whole?
+func holdsMetadataChunks(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, 12)
+	if _, err := io.ReadFull(file, head); err != nil {
+		return false
+	}
+	webp := string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP"
+	return webp || string(head[:4]) == "8BPS"
+}
+
+// a temporary copy of path, for exiftool to copy kept tags from
+func snapshotImage(path string) (string, error) {
+	file, err := util.CreateTempFile("caligra-keep-*" + filepath.Ext(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	file.Close()
+
+	if err := util.SafeCopy(path, file.Name()); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// outcomes of orient that leave the tag for later
+const (
+	orientationAtReencode = "applied when re-encoding"
+	orientationKept       = "kept (no lossless rotation for this format)"
+)
+
+// bakes the orientation in before the tag goes; a re-encode does it for
+// free, so the tag is left for it, and formats without a lossless path keep
+// the tag rather than turn sideways
+func (h *ImageHandler) orient(path string, policy *config.Policy) (string, error) {
+	if policy.Reencode {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if o, _ := findOrientation(data); o == 1 {
+			return "upright already", nil
+		}
+		return orientationAtReencode, nil
+	}
+
+	applied, err := applyOrientation(path)
+	if err != nil || applied != "" {
+		return applied, err
+	}
+	return orientationKept, nil
+}
+
+func keepsImageTag(policy *config.Policy, name string) bool {
+	return slices.ContainsFunc(policy.KeepImageTags, func(tag string) bool { return strings.EqualFold(tag, name) })
+}
+
+// exiftool assignments for the capture dates, truncated to day/month/year
+func coarsenImageDates(metadata map[string]any, precision string) ([]string, string) {
+	var assignments []string
+	var shown string
+
+	for _, tag := range imageDateTags {
+		value, ok := metadata[tag].(string)
+		if !ok {
+			continue
+		}
+		coarse, ok := coarsenExifDate(value, precision)
+		if !ok {
+			continue
+		}
+		assignments = append(assignments, fmt.Sprintf("-%s=%s", tag, coarse))
+		if shown == "" {
+			shown = coarse
+		}
+	}
+
+	return assignments, shown
+}
+
+// "2024:05:17 14:03:22+02:00" -> "2024:05:01 00:00:00" for month
+func coarsenExifDate(value, precision string) (string, bool) {
+	if len(value) < 10 || value[4] != ':' || value[7] != ':' {
+		return "", false
+	}
+	year, month, day := value[0:4], value[5:7], value[8:10]
+	if year == "0000" {
+		return "", false
+	}
+
+	switch precision {
+	case config.DatesYear:
+		month, day = "01", "01"
+	case config.DatesMonth:
+		day = "01"
+	}
+	return fmt.Sprintf("%s:%s:%s 00:00:00", year, month, day), true
+}
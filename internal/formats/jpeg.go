@@ -0,0 +1,200 @@
+// BYZRA ⸻ internal/formats/jpeg.go
+// native JPEG segment walking for COM comments
+
+package formats
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	jpegSOI = 0xD8
+	jpegEOI = 0xD9
+	jpegSOS = 0xDA
+	jpegCOM = 0xFE
+)
+
+// longest comment text shown in a report
+const jpegCommentPreview = 80
+
+var errNotJPEG = errors.New("not a JPEG file")
+
+// one marker segment; start and end span the marker bytes and the payload
+type jpegSegment struct {
+	marker  byte
+	payload []byte
+	start   int
+	end     int
+}
+
+// marker segments in file order, scans included; entropy-coded data between
+// them is skipped, so comments after the first scan are found too
+func jpegSegments(data []byte) ([]jpegSegment, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegSOI {
+		return nil, errNotJPEG
+	}
+
+	var segments []jpegSegment
+	pos := 2
+	for pos < len(data) {
+		if data[pos] != 0xFF {
+			return nil, fmt.Errorf("corrupt JPEG: expected a marker at offset %d", pos)
+		}
+		start := pos
+		for pos < len(data) && data[pos] == 0xFF {
+			pos++ // fill bytes
+		}
+		if pos >= len(data) {
+			break
+		}
+		marker := data[pos]
+		pos++
+
+		if marker == jpegEOI {
+			segments = append(segments, jpegSegment{marker: marker, start: start, end: pos})
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			continue // TEM and RSTn stand alone
+		}
+
+		if pos+2 > len(data) {
+			return nil, fmt.Errorf("corrupt JPEG: truncated segment at offset %d", start)
+		}
+		length := int(data[pos])<<8 | int(data[pos+1])
+		if length < 2 || pos+length > len(data) {
+			return nil, fmt.Errorf("corrupt JPEG: bad segment length at offset %d", start)
+		}
+		segments = append(segments, jpegSegment{
+			marker:  marker,
+			payload: data[pos+2 : pos+length],
+			start:   start,
+			end:     pos + length,
+		})
+		pos += length
+
+		if marker == jpegSOS {
+			pos = skipEntropyData(data, pos)
+		}
+	}
+	return segments, nil
+}
+
+// offset of the next marker after scan data; 0xFF00 is a stuffed byte and
+// RSTn markers belong to the scan
+func skipEntropyData(data []byte, pos int) int {
+	for pos+1 < len(data) {
+		if data[pos] == 0xFF {
+			next := data[pos+1]
+			if next != 0x00 && (next < 0xD0 || next > 0xD7) && next != 0xFF {
+				return pos
+			}
+		}
+		pos++
+	}
+	return len(data)
+}
+
+// texts of the file's COM segments; nil for anything but a well-formed JPEG
+func readJPEGComments(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := jpegSegments(data)
+	if err != nil {
+		return nil, nil // not a JPEG, or one only exiftool makes sense of
+	}
+
+	var comments []string
+	for _, segment := range segments {
+		if segment.marker == jpegCOM {
+			comments = append(comments, string(bytes.TrimRight(segment.payload, "\x00")))
+		}
+	}
+	return comments, nil
+}
+
+// removes every COM segment in place; returns how many there were
+func stripJPEGComments(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	segments, err := jpegSegments(data)
+	if err != nil {
+		return 0, nil
+	}
+
+	var out bytes.Buffer
+	removed, last := 0, 0
+	for _, segment := range segments {
+		if segment.marker != jpegCOM {
+			continue
+		}
+		out.Write(data[last:segment.start])
+		last = segment.end
+		removed++
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	out.Write(data[last:])
+
+	if err := replaceFile(path, out.Bytes()); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// lists the vendor MakerNote, JPEG comment segments and Photoshop
+// thumbnails, which tag-focused tools often leave behind
+func (h *ImageHandler) ListEmbedded(path string) ([]Embedded, error) {
+	var embedded []Embedded
+
+	note, err := readMakerNote(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MakerNote: %w", err)
+	}
+	if note != nil {
+		embedded = append(embedded, Embedded{Kind: "makernote", Name: "MakerNote", Detail: note.describe()})
+	}
+
+	comments, err := readJPEGComments(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JPEG comments: %w", err)
+	}
+	for i, comment := range comments {
+		embedded = append(embedded, Embedded{
+			Kind:   "comment",
+			Name:   fmt.Sprintf("JPEG COM #%d", i+1),
+			Detail: previewComment(comment),
+		})
+	}
+
+	thumbnails, err := listPSDThumbnails(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Photoshop image resources: %w", err)
+	}
+	return append(embedded, thumbnails...), nil
+}
+
+// one line, quoted, cut at jpegCommentPreview characters
+func previewComment(comment string) string {
+	if !utf8.ValidString(comment) {
+		return fmt.Sprintf("%d bytes of binary data", len(comment))
+	}
+	comment = strings.Join(strings.Fields(comment), " ")
+	if comment == "" {
+		return "empty"
+	}
+	if runes := []rune(comment); len(runes) > jpegCommentPreview {
+		comment = string(runes[:jpegCommentPreview]) + "…"
+	}
+	return fmt.Sprintf("%q", comment)
+}
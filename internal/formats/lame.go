@@ -0,0 +1,159 @@
+// BYZRA ⸻ internal/formats/lame.go
+// LAME/Info header scrubbing in the first MP3 frame
+
+package formats
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// what to clear from the LAME extension of a Xing/Info frame
+type lameScrub struct {
+	encoder    bool // version string and encoding settings (lowpass, ATH, preset)
+	replayGain bool // peak amplitude, radio/audiophile gain, MP3Gain
+}
+
+// rewrites the LAME header in place; reports whether one was found
+func scrubLAMEHeader(path string, scrub lameScrub) (bool, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	// ID3v2 tags come first and are skipped by their synchsafe size
+	start := int64(0)
+	header := make([]byte, 10)
+	if _, err := file.ReadAt(header, 0); err == nil && string(header[:3]) == "ID3" {
+		size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+		start = 10 + size
+		if header[5]&0x10 != 0 {
+			start += 10 // footer
+		}
+	}
+
+	frame := make([]byte, 512)
+	n, err := file.ReadAt(frame, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read the first MP3 frame: %w", err)
+	}
+	if n < 200 {
+		return false, nil
+	}
+	frame = frame[:n]
+
+	// first frame sync within a few bytes of padding
+	offset := -1
+	for i := 0; i+4 < len(frame) && i < 64; i++ {
+		if frame[i] == 0xFF && frame[i+1]&0xE0 == 0xE0 {
+			offset = i
+			break
+		}
+	}
+	if offset < 0 {
+		return false, nil
+	}
+	frame = frame[offset:]
+
+	lame := lameOffset(frame)
+	if lame < 0 || lame+36 > len(frame) {
+		return false, nil
+	}
+
+	if scrub.encoder {
+		// keep the family ("LAME", "Lavc") that decoders use to read gapless info
+		for i := lame + 4; i < lame+9; i++ {
+			frame[i] = ' '
+		}
+		frame[lame+10] = 0                    // lowpass
+		frame[lame+19] = 0                    // encoding flags + ATH type
+		frame[lame+26], frame[lame+27] = 0, 0 // surround + preset
+	}
+
+	if scrub.replayGain {
+		for i := lame + 11; i < lame+19; i++ {
+			frame[i] = 0 // peak amplitude, radio and audiophile gain
+		}
+		frame[lame+25] = 0 // MP3Gain
+	}
+
+	// the info tag CRC covers the frame up to itself
+	crcPos := lame + 34
+	crc := crc16(frame[:crcPos])
+	frame[crcPos] = byte(crc >> 8)
+	frame[crcPos+1] = byte(crc)
+
+	if _, err := file.WriteAt(frame[:lame+36], start+int64(offset)); err != nil {
+		return true, fmt.Errorf("failed to rewrite LAME header: %w", err)
+	}
+	return true, nil
+}
+
+// offset of the LAME extension within a Xing/Info frame, or -1
+func lameOffset(frame []byte) int {
+	mpeg1 := frame[1]&0x18 == 0x18
+	mono := frame[3]&0xC0 == 0xC0
+
+	sideInfo := 32
+	switch {
+	case mpeg1 && mono:
+		sideInfo = 17
+	case !mpeg1 && !mono:
+		sideInfo = 17
+	case !mpeg1 && mono:
+		sideInfo = 9
+	}
+
+	xing := 4 + sideInfo
+	if xing+8 > len(frame) {
+		return -1
+	}
+	tag := string(frame[xing : xing+4])
+	if tag != "Xing" && tag != "Info" {
+		return -1
+	}
+
+	flags := frame[xing+7]
+	pos := xing + 8
+	if flags&0x1 != 0 {
+		pos += 4 // frame count
+	}
+	if flags&0x2 != 0 {
+		pos += 4 // byte count
+	}
+	if flags&0x4 != 0 {
+		pos += 100 // seek table
+	}
+	if flags&0x8 != 0 {
+		pos += 4 // quality
+	}
+
+	if pos+9 > len(frame) {
+		return -1
+	}
+	for _, b := range frame[pos : pos+4] {
+		if b < 0x20 || b > 0x7E {
+			return -1 // no LAME extension
+		}
+	}
+	return pos
+}
+
+// CRC-16/ARC, as used by the LAME info tag
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
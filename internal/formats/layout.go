@@ -0,0 +1,518 @@
+// BYZRA ⸻ internal/formats/layout.go
+// on-disk structure of files: segments, chunks, atoms and blocks, with their
+// offsets, for raw dumps
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// payload bytes kept per segment for previews
+const layoutPreview = 32
+
+// one structural unit of a file
+type Segment struct {
+	Name    string // "APP1 Exif", "tEXt", "moov", ...
+	Offset  int64
+	Length  int64 // header included
+	Depth   int   // nesting level, 0 for top-level units
+	Preview []byte
+
+	// holds tags, comments or other data about the content rather than
+	// the content itself
+	Metadata bool
+}
+
+// the structure of a whole file
+type Layout struct {
+	Container string
+	Size      int64
+	Segments  []Segment
+}
+
+// reads the structure of a file by its content, not its name
+func ReadLayout(path string) (*Layout, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	l := &layoutReader{file: file, size: info.Size()}
+	layout := &Layout{Size: l.size}
+
+	head := l.read(0, 16)
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, jpegSOI}):
+		layout.Container = "JPEG"
+		err = l.jpeg()
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		layout.Container = "PNG"
+		l.png()
+	case bytes.HasPrefix(head, []byte("GIF8")):
+		layout.Container = "GIF"
+		l.gif()
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		layout.Container = "TIFF"
+		err = l.tiffFile()
+	case bytes.HasPrefix(head, []byte("RIFF")):
+		layout.Container = "RIFF " + string(l.read(8, 4))
+		l.riff(0, l.size, 0)
+	case len(head) >= 8 && (string(head[4:8]) == "ftyp" || bmffContainers[string(head[4:8])] ||
+		string(head[4:8]) == "mdat" || string(head[4:8]) == "wide"):
+		layout.Container = "ISO base media"
+		l.bmff(0, l.size, 0, "")
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		layout.Container = "FLAC"
+		l.flac(0)
+	case bytes.HasPrefix(head, []byte("ID3")):
+		layout.Container = l.id3Container()
+	case bytes.HasPrefix(head, []byte("OggS")):
+		layout.Container = "Ogg"
+		l.ogg()
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		layout.Container = "EBML (Matroska/WebM)"
+		l.ebml(0, l.size, 0)
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		layout.Container = "MPEG audio"
+		l.mp3(0)
+	case isText(head):
+		return nil, fmt.Errorf("no binary structure: this is a text file, read it directly")
+	default:
+		return nil, fmt.Errorf("%w: unrecognized file structure", ErrUnsupportedFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range l.segments {
+		l.segments[i].Metadata = isMetadataSegment(l.segments[i].Name)
+	}
+	layout.Segments = l.segments
+	return layout, nil
+}
+
+// metadata segments not nested in another one, so no byte is counted twice
+func (l *Layout) MetadataRegions() []Segment {
+	var regions []Segment
+	for _, segment := range l.Segments {
+		if !segment.Metadata {
+			continue
+		}
+		nested := slices.ContainsFunc(regions, func(r Segment) bool {
+			return segment.Offset >= r.Offset && segment.Offset+segment.Length <= r.Offset+r.Length
+		})
+		if !nested {
+			regions = append(regions, segment)
+		}
+	}
+	return regions
+}
+
+// total length of regions
+func RegionBytes(regions []Segment) int64 {
+	var total int64
+	for _, r := range regions {
+		total += r.Length
+	}
+	return total
+}
+
+// segment names that are metadata in every container they appear in
+var metadataSegments = map[string]bool{
+	// JPEG, PNG, GIF
+	"COM": true, "tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true, "iCCP": true,
+	"comment extension": true,
+	// TIFF tags
+	"XMP": true, "IPTC": true, "Photoshop": true, "ICC profile": true, "MakerNote": true, "UserComment": true,
+	"JPEG thumbnail": true,
+	// ISO base media
+	"udta": true, "meta": true,
+	// RIFF
+	"LIST INFO": true, "id3 ": true, "ID3 ": true, "bext": true, "iXML": true, "XMP ": true,
+	"_PMX": true, "EXIF": true, "ICCP": true,
+	// FLAC, MP3
+	"VORBIS_COMMENT": true, "PICTURE": true, "APPLICATION": true, "APE tag": true, "ID3v1 tag": true,
+	// Matroska
+	"Tags": true, "Attachments": true, "Chapters": true, "Title": true,
+	"MuxingApp": true, "WritingApp": true, "DateUTC": true, "SegmentUID": true,
+}
+
+// application segments that only describe how to decode the image
+var structuralSegments = map[string]bool{
+	"APP0 JFIF": true, "APP0 JFXX": true, "APP14 Adobe": true,
+	"application extension NETSCAPE2.0": true, "application extension ANIMEXTS1.0": true,
+}
+
+func isMetadataSegment(name string) bool {
+	name, _ = runName(name)
+	if metadataSegments[name] {
+		return true
+	}
+	if structuralSegments[name] {
+		return false
+	}
+	for _, prefix := range []string{
+		"APP", "application extension", "trailing data", "Exif IFD", "GPS IFD", "Interop IFD",
+		"uuid ", "ID3v2", "header page (OpusTags)", "header page (Vorbis comment)",
+	} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isText(head []byte) bool {
+	for _, b := range head {
+		if b < 0x09 || (b > 0x0D && b < 0x20 && b != 0x1B) {
+			return false
+		}
+	}
+	return len(head) > 0
+}
+
+// reads pieces of a file, collecting segments as they are found
+type layoutReader struct {
+	file     *os.File
+	size     int64
+	segments []Segment
+}
+
+// n bytes at off, fewer at the end of the file
+func (l *layoutReader) read(off, n int64) []byte {
+	if off < 0 || off >= l.size || n <= 0 {
+		return nil
+	}
+	n = min(n, l.size-off)
+	buf := make([]byte, n)
+	got, err := l.file.ReadAt(buf, off)
+	if err != nil && err != io.EOF {
+		return nil
+	}
+	return buf[:got]
+}
+
+// records a segment with a preview of its payload
+func (l *layoutReader) add(name string, off, length, header int64, depth int) {
+	l.segments = append(l.segments, Segment{
+		Name:    name,
+		Offset:  off,
+		Length:  length,
+		Depth:   depth,
+		Preview: l.read(off+header, min(layoutPreview, length-header)),
+	})
+}
+
+// folds repeated units (audio pages, clusters, frames) into one counted
+// segment; the count goes in the name as "name ×n"
+func (l *layoutReader) addRun(name string, off, length, header int64, depth int) {
+	if n := len(l.segments); n > 0 {
+		last := &l.segments[n-1]
+		base, count := runName(last.Name)
+		if base == name && last.Depth == depth && last.Offset+last.Length <= off {
+			last.Length = off + length - last.Offset
+			last.Name = fmt.Sprintf("%s ×%d", name, count+1)
+			return
+		}
+	}
+	l.add(name, off, length, header, depth)
+}
+
+func runName(name string) (string, int) {
+	base, count, found := strings.Cut(name, " ×")
+	if !found {
+		return name, 1
+	}
+	n := 0
+	fmt.Sscanf(count, "%d", &n)
+	return base, n
+}
+
+// ╭─ JPEG ──────────────────────────────────────╮
+
+func (l *layoutReader) jpeg() error {
+	data := l.read(0, l.size)
+	segments, err := jpegSegments(data)
+	if err != nil {
+		return err
+	}
+
+	l.add("SOI", 0, 2, 2, 0)
+	for i, segment := range segments {
+		length := int64(segment.end - segment.start)
+		if segment.marker == jpegEOI {
+			l.add("EOI", int64(segment.start), length, length, 0)
+			if trailing := l.size - int64(segment.end); trailing > 0 {
+				l.add("trailing data after EOI", int64(segment.end), trailing, 0, 0)
+			}
+			break
+		}
+
+		l.add(jpegMarkerName(segment), int64(segment.start), length, 4, 0)
+
+		if segment.marker == 0xE1 && bytes.HasPrefix(segment.payload, []byte("Exif\x00\x00")) {
+			if view := exifView(data); view != nil && view.base == segment.start+10 {
+				l.tiff(view, 1)
+			}
+		}
+		if segment.marker == jpegSOS {
+			next := len(data)
+			if i+1 < len(segments) {
+				next = segments[i+1].start
+			}
+			if next > segment.end {
+				l.add("entropy-coded data", int64(segment.end), int64(next-segment.end), 0, 1)
+			}
+		}
+	}
+	return nil
+}
+
+func jpegMarkerName(segment jpegSegment) string {
+	m := segment.marker
+	switch {
+	case m >= 0xE0 && m <= 0xEF:
+		name := fmt.Sprintf("APP%d", m-0xE0)
+		if id := segmentIdentifier(segment.payload); id != "" {
+			name += " " + id
+		}
+		return name
+	case m == 0xC4:
+		return "DHT"
+	case m == 0xCC:
+		return "DAC"
+	case m >= 0xC0 && m <= 0xCF && m != 0xC8:
+		return fmt.Sprintf("SOF%d", m-0xC0)
+	}
+	switch m {
+	case 0xDB:
+		return "DQT"
+	case 0xDD:
+		return "DRI"
+	case jpegSOS:
+		return "SOS"
+	case jpegCOM:
+		return "COM"
+	}
+	return fmt.Sprintf("marker 0x%02X", m)
+}
+
+// the NUL-terminated name APPn payloads start with ("Exif", "ICC_PROFILE")
+func segmentIdentifier(payload []byte) string {
+	end := bytes.IndexByte(payload, 0)
+	if end <= 0 || end > 40 {
+		return ""
+	}
+	for _, b := range payload[:end] {
+		if b < 0x20 || b > 0x7E {
+			return ""
+		}
+	}
+	return string(payload[:end])
+}
+
+// ╭─ PNG ───────────────────────────────────────╮
+
+func (l *layoutReader) png() {
+	l.add("signature", 0, 8, 8, 0)
+	for off := int64(8); off+12 <= l.size; {
+		head := l.read(off, 8)
+		length := int64(binary.BigEndian.Uint32(head))
+		kind := string(head[4:8])
+		total := 12 + length
+		if off+total > l.size {
+			l.add(kind+" (truncated)", off, l.size-off, 8, 0)
+			return
+		}
+		if kind == "IDAT" {
+			l.addRun(kind, off, total, 8, 0)
+		} else {
+			l.add(kind, off, total, 8, 0)
+		}
+		off += total
+		if kind == "IEND" {
+			if off < l.size {
+				l.add("trailing data after IEND", off, l.size-off, 0, 0)
+			}
+			return
+		}
+	}
+}
+
+// ╭─ GIF ───────────────────────────────────────╮
+
+var gifExtensions = map[byte]string{
+	0x01: "plain text extension",
+	0xF9: "graphic control extension",
+	0xFE: "comment extension",
+	0xFF: "application extension",
+}
+
+func (l *layoutReader) gif() {
+	l.add("header", 0, 6, 0, 0)
+	l.add("logical screen descriptor", 6, 7, 0, 0)
+	off := int64(13)
+	if flags := l.read(10, 1); len(flags) == 1 && flags[0]&0x80 != 0 {
+		size := int64(3) << (flags[0]&0x07 + 1)
+		l.add("global color table", off, size, 0, 0)
+		off += size
+	}
+
+	for off < l.size {
+		b := l.read(off, 2)
+		switch b[0] {
+		case 0x21:
+			if len(b) < 2 {
+				return
+			}
+			name, ok := gifExtensions[b[1]]
+			if !ok {
+				name = fmt.Sprintf("extension 0x%02X", b[1])
+			}
+			if b[1] == 0xFF {
+				if id := l.read(off+3, 11); len(id) == 11 {
+					name += " " + strings.TrimRight(string(id), "\x00")
+				}
+			}
+			end := l.gifSubBlocks(off + 2)
+			l.add(name, off, end-off, 2, 0)
+			off = end
+		case 0x2C:
+			header := int64(10)
+			if flags := l.read(off+9, 1); len(flags) == 1 && flags[0]&0x80 != 0 {
+				header += int64(3) << (flags[0]&0x07 + 1)
+			}
+			end := l.gifSubBlocks(off + header + 1)
+			l.addRun("image", off, end-off, 0, 0)
+			off = end
+		case 0x3B:
+			l.add("trailer", off, 1, 0, 0)
+			if off+1 < l.size {
+				l.add("trailing data after trailer", off+1, l.size-off-1, 0, 0)
+			}
+			return
+		default:
+			l.add("unknown data", off, l.size-off, 0, 0)
+			return
+		}
+	}
+}
+
+// offset just past a chain of length-prefixed sub-blocks
+func (l *layoutReader) gifSubBlocks(off int64) int64 {
+	for off < l.size {
+		n := l.read(off, 1)
+		if len(n) == 0 {
+			break
+		}
+		off += 1 + int64(n[0])
+		if n[0] == 0 {
+			break
+		}
+	}
+	return min(off, l.size)
+}
+
+// ╭─ TIFF ──────────────────────────────────────╮
+
+// IFDs that hang off a tag
+var tiffSubIFDs = map[uint16]string{
+	0x8769: "Exif IFD",
+	0x8825: "GPS IFD",
+	0xA005: "Interop IFD",
+	0x014A: "SubIFD",
+}
+
+// tags whose value is a blob worth showing on its own
+var tiffBlobs = map[uint16]string{
+	0x02BC: "XMP",
+	0x83BB: "IPTC",
+	0x8649: "Photoshop",
+	0x8773: "ICC profile",
+	0x927C: "MakerNote",
+	0x9286: "UserComment",
+	0x0201: "JPEG thumbnail",
+}
+
+// bytes per value of each TIFF field type
+var tiffTypeSizes = map[uint16]int64{
+	1: 1, 2: 1, 6: 1, 7: 1, 3: 2, 8: 2, 4: 4, 9: 4, 11: 4, 13: 4, 5: 8, 10: 8, 12: 8,
+}
+
+func (l *layoutReader) tiffFile() error {
+	data := l.read(0, l.size)
+	view := exifView(data)
+	if view == nil {
+		return fmt.Errorf("corrupt TIFF header")
+	}
+	l.tiff(view, 0)
+	return nil
+}
+
+// header, IFD chain and the sub-IFDs and blobs each IFD points to
+func (l *layoutReader) tiff(view *tiffView, depth int) {
+	order := "little-endian"
+	if view.order == binary.BigEndian {
+		order = "big-endian"
+	}
+	l.add("TIFF header ("+order+")", int64(view.base), 8, 0, depth)
+
+	seen := make(map[int]bool)
+	var visit func(name string, offset uint32, depth int)
+	visit = func(name string, offset uint32, depth int) {
+		start, count, ok := view.ifd(offset)
+		if !ok || seen[start] {
+			return
+		}
+		seen[start] = true
+		l.add(fmt.Sprintf("%s, %d entries", name, count), int64(start), int64(2+count*12+4), 2, depth)
+
+		for i := range count {
+			tag, kind, n, value := view.entry(start, i)
+			if sub, ok := tiffSubIFDs[tag]; ok && n >= 1 {
+				visit(sub, view.order.Uint32(view.data[value:]), depth+1)
+				continue
+			}
+			blob, ok := tiffBlobs[tag]
+			if !ok {
+				continue
+			}
+			size := int64(n) * tiffTypeSizes[kind]
+			if tag == 0x0201 { // thumbnail offset; its length is tag 0x0202
+				for j := range count {
+					if t, _, _, v := view.entry(start, j); t == 0x0202 {
+						size = int64(view.order.Uint32(view.data[v:]))
+					}
+				}
+			}
+			if size <= 4 {
+				continue
+			}
+			at := int64(view.base) + int64(view.order.Uint32(view.data[value:]))
+			if at+size <= int64(len(view.data)) {
+				l.add(blob, at, size, 0, depth+1)
+			}
+		}
+
+		if next := view.order.Uint32(view.data[start+2+count*12:]); next != 0 {
+			index := 1
+			if _, err := fmt.Sscanf(name, "IFD%d", &index); err == nil {
+				index++
+			}
+			visit(fmt.Sprintf("IFD%d", index), next, depth)
+		}
+	}
+	visit("IFD0", view.order.Uint32(view.data[view.base+4:]), depth)
+}
@@ -0,0 +1,362 @@
+// BYZRA ⸻ internal/formats/layout_media.go
+// on-disk structure of audio and video containers
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// deepest level listed in nested containers
+const layoutMaxDepth = 8
+
+// ╭─ ISO BASE MEDIA (MP4, MOV, M4A) ────────────╮
+
+// atoms made of other atoms
+var bmffContainers = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "stbl": true,
+	"udta": true, "meta": true, "ilst": true, "edts": true, "dinf": true,
+	"moof": true, "traf": true, "mvex": true, "mfra": true, "tref": true,
+	"sinf": true, "schi": true, "iprp": true, "ipco": true,
+}
+
+func (l *layoutReader) bmff(start, end int64, depth int, parent string) {
+	for off := start; off+8 <= end; {
+		head := l.read(off, 16)
+		size := int64(binary.BigEndian.Uint32(head))
+		kind := string(head[4:8])
+		header := int64(8)
+		switch size {
+		case 0:
+			size = end - off // to the end of the file
+		case 1:
+			if len(head) < 16 {
+				return
+			}
+			size, header = int64(binary.BigEndian.Uint64(head[8:16])), 16
+		}
+		if size < header || off+size > end {
+			l.add(printable(kind)+" (truncated)", off, end-off, header, depth)
+			return
+		}
+
+		name := printable(kind)
+		if kind == "uuid" {
+			if id := l.read(off+header, 16); len(id) == 16 {
+				name = fmt.Sprintf("uuid %X", id)
+			}
+			header += 16
+		}
+		l.add(name, off, size, header, depth)
+
+		// iTunes items under ilst hold their values in data atoms
+		if (bmffContainers[kind] || parent == "ilst") && depth < layoutMaxDepth {
+			children := off + header
+			if kind == "meta" && string(l.read(children+4, 4)) != "hdlr" {
+				children += 4 // ISO meta is a full box; QuickTime's is not
+			}
+			l.bmff(children, off+size, depth+1, kind)
+		}
+		off += size
+	}
+}
+
+// atom and chunk names as text, with the © of iTunes tags intact
+func printable(kind string) string {
+	var sb []byte
+	for _, b := range []byte(kind) {
+		switch {
+		case b == 0xA9:
+			sb = append(sb, "©"...)
+		case b >= 0x20 && b <= 0x7E:
+			sb = append(sb, b)
+		default:
+			sb = append(sb, '.')
+		}
+	}
+	return string(sb)
+}
+
+// ╭─ RIFF (AVI, WAV, WebP) ─────────────────────╮
+
+func (l *layoutReader) riff(start, end int64, depth int) {
+	for off := start; off+8 <= end; {
+		head := l.read(off, 12)
+		id := string(head[:4])
+		size := int64(binary.LittleEndian.Uint32(head[4:8]))
+		total := 8 + size + size&1
+		if off+8+size > end {
+			l.add(printable(id)+" (truncated)", off, end-off, 8, depth)
+			return
+		}
+
+		if (id == "RIFF" || id == "LIST") && len(head) == 12 {
+			form := string(head[8:12])
+			l.add(id+" "+printable(form), off, 8+size, 12, depth)
+			// interleaved audio/video chunks are only counted
+			if form != "movi" && depth < layoutMaxDepth {
+				l.riff(off+12, off+8+size, depth+1)
+			}
+		} else {
+			l.add(printable(id), off, 8+size, 8, depth)
+		}
+		off += total
+	}
+}
+
+// ╭─ FLAC ──────────────────────────────────────╮
+
+var flacBlockTypes = []string{
+	"STREAMINFO", "PADDING", "APPLICATION", "SEEKTABLE", "VORBIS_COMMENT", "CUESHEET", "PICTURE",
+}
+
+func (l *layoutReader) flac(off int64) {
+	l.add("fLaC marker", off, 4, 0, 0)
+	off += 4
+	for off+4 <= l.size {
+		head := l.read(off, 4)
+		kind := int(head[0] & 0x7F)
+		size := int64(head[1])<<16 | int64(head[2])<<8 | int64(head[3])
+		name := fmt.Sprintf("block type %d", kind)
+		if kind < len(flacBlockTypes) {
+			name = flacBlockTypes[kind]
+		}
+		l.add(name, off, 4+size, 4, 0)
+		off += 4 + size
+		if head[0]&0x80 != 0 {
+			break // last metadata block
+		}
+	}
+	if off < l.size {
+		l.add("audio frames", off, l.size-off, 0, 0)
+	}
+}
+
+// ╭─ MP3 AND ID3 ───────────────────────────────╮
+
+// an ID3v2 tag may front FLAC as well as MP3
+func (l *layoutReader) id3Container() string {
+	end := l.id3v2()
+	if bytes.HasPrefix(l.read(end, 4), []byte("fLaC")) {
+		l.flac(end)
+		return "FLAC with ID3v2"
+	}
+	l.mp3(end)
+	return "MPEG audio with ID3v2"
+}
+
+// lists an ID3v2 tag and its frames; returns where the tag ends
+func (l *layoutReader) id3v2() int64 {
+	header := l.read(0, 10)
+	if len(header) < 10 {
+		return 0
+	}
+	size := int64(synchsafe(header[6:10]))
+	end := 10 + size
+	if header[5]&0x10 != 0 {
+		end += 10 // footer
+	}
+	l.add(fmt.Sprintf("ID3v2.%d tag", header[3]), 0, min(end, l.size), 10, 0)
+
+	tag, err := readID3Tag(l.file.Name())
+	if err != nil || tag == nil {
+		return end
+	}
+	last := int64(10)
+	for _, frame := range tag.Frames {
+		last = 10 + int64(frame.offset+frame.size)
+		l.add(frame.ID, 10+int64(frame.offset), int64(frame.size), 10, 1)
+	}
+	if last < 10+size {
+		l.add("padding", last, 10+size-last, 0, 1)
+	}
+	return end
+}
+
+// audio frames, then APE and ID3v1 tags at the end
+func (l *layoutReader) mp3(start int64) {
+	end := l.size
+	var tail []Segment
+
+	if end-128 >= start && bytes.HasPrefix(l.read(end-128, 3), []byte("TAG")) {
+		end -= 128
+		tail = append(tail, Segment{Name: "ID3v1 tag", Offset: end, Length: 128, Preview: l.read(end+3, layoutPreview)})
+	}
+	if footer := l.read(end-32, 32); len(footer) == 32 && bytes.HasPrefix(footer, []byte("APETAGEX")) {
+		size := int64(binary.LittleEndian.Uint32(footer[12:16]))
+		if binary.LittleEndian.Uint32(footer[20:24])&0x80000000 != 0 {
+			size += 32 // header
+		}
+		if end-size >= start {
+			end -= size
+			tail = append([]Segment{{Name: "APE tag", Offset: end, Length: size, Preview: l.read(end, layoutPreview)}}, tail...)
+		}
+	}
+
+	if end > start {
+		l.add("audio frames", start, end-start, 0, 0)
+	}
+	l.segments = append(l.segments, tail...)
+}
+
+// ╭─ OGG ───────────────────────────────────────╮
+
+// header packets by their first bytes
+var oggHeaders = []struct{ prefix, name string }{
+	{"OpusHead", "OpusHead"},
+	{"OpusTags", "OpusTags"},
+	{"\x01vorbis", "Vorbis identification"},
+	{"\x03vorbis", "Vorbis comment"},
+	{"\x05vorbis", "Vorbis setup"},
+	{"\x7fFLAC", "FLAC header"},
+	{"OggTheora", "Theora"},
+}
+
+// header pages (granule position 0) one by one, audio pages as a run
+func (l *layoutReader) ogg() {
+	for off := int64(0); off+27 <= l.size; {
+		head := l.read(off, 27)
+		if !bytes.HasPrefix(head, []byte("OggS")) {
+			l.add("unknown data", off, l.size-off, 0, 0)
+			return
+		}
+		count := int64(head[26])
+		table := l.read(off+27, count)
+		header := 27 + count
+		length := header
+		for _, lacing := range table {
+			length += int64(lacing)
+		}
+		if off+length > l.size {
+			l.add("page (truncated)", off, l.size-off, header, 0)
+			return
+		}
+
+		if binary.LittleEndian.Uint64(head[6:14]) == 0 {
+			name := "header page"
+			payload := l.read(off+header, 16)
+			for _, h := range oggHeaders {
+				if bytes.HasPrefix(payload, []byte(h.prefix)) {
+					name += " (" + h.name + ")"
+					break
+				}
+			}
+			if head[5]&0x01 != 0 {
+				name += " (continued)"
+			}
+			l.add(name, off, length, header, 0)
+		} else {
+			l.addRun("audio pages", off, length, header, 0)
+		}
+		off += length
+	}
+}
+
+// ╭─ EBML (MATROSKA, WEBM) ─────────────────────╮
+
+var ebmlNames = map[uint64]string{
+	0x1A45DFA3: "EBML header",
+	0x18538067: "Segment",
+	0x114D9B74: "SeekHead",
+	0x1549A966: "Info",
+	0x1654AE6B: "Tracks",
+	0x1F43B675: "Cluster",
+	0x1C53BB6B: "Cues",
+	0x1941A469: "Attachments",
+	0x1043A770: "Chapters",
+	0x1254C367: "Tags",
+	0xEC:       "Void",
+	0xBF:       "CRC-32",
+	0xAE:       "TrackEntry",
+	0x61A7:     "AttachedFile",
+	0x45B9:     "EditionEntry",
+	0x7373:     "Tag",
+	0x7BA9:     "Title",
+	0x4D80:     "MuxingApp",
+	0x5741:     "WritingApp",
+	0x4461:     "DateUTC",
+	0x73A4:     "SegmentUID",
+}
+
+// elements listed with their children
+var ebmlContainers = map[uint64]bool{
+	0x18538067: true, 0x1549A966: true, 0x1654AE6B: true,
+	0x1941A469: true, 0x1043A770: true, 0x1254C367: true,
+}
+
+func (l *layoutReader) ebml(start, end int64, depth int) {
+	for off := start; off < end; {
+		head := l.read(off, 12)
+		id, idLen := ebmlID(head)
+		if idLen == 0 {
+			l.add("unknown data", off, end-off, 0, depth)
+			return
+		}
+		value, sizeLen, known := ebmlSize(head[idLen:])
+		if sizeLen == 0 {
+			l.add("unknown data", off, end-off, 0, depth)
+			return
+		}
+		header, size := int64(idLen+sizeLen), int64(value)
+		if !known || size < 0 || off+header+size > end {
+			size = end - off - header // live streams leave sizes open
+		}
+
+		name, ok := ebmlNames[id]
+		if !ok {
+			name = fmt.Sprintf("element 0x%X", id)
+		}
+		if id == 0x1F43B675 {
+			l.addRun(name, off, header+size, header, depth)
+		} else {
+			l.add(name, off, header+size, header, depth)
+		}
+		if ebmlContainers[id] && depth < 2 {
+			l.ebml(off+header, off+header+size, depth+1)
+		}
+		off += header + size
+	}
+}
+
+// element IDs keep their length marker bits
+func ebmlID(b []byte) (uint64, int) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0
+	}
+	n := 1
+	for mask := byte(0x80); b[0]&mask == 0; mask >>= 1 {
+		n++
+	}
+	if n > 4 || n > len(b) {
+		return 0, 0
+	}
+	var id uint64
+	for _, c := range b[:n] {
+		id = id<<8 | uint64(c)
+	}
+	return id, n
+}
+
+// sizes drop the marker bit; all ones means unknown
+func ebmlSize(b []byte) (uint64, int, bool) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0, false
+	}
+	n := 1
+	mask := byte(0x80)
+	for ; b[0]&mask == 0; mask >>= 1 {
+		n++
+	}
+	if n > len(b) {
+		return 0, 0, false
+	}
+	value := uint64(b[0] & (mask - 1))
+	allOnes := value == uint64(mask-1)
+	for _, c := range b[1:n] {
+		value = value<<8 | uint64(c)
+		allOnes = allOnes && c == 0xFF
+	}
+	return value, n, !allOnes
+}
@@ -0,0 +1,222 @@
+// BYZRA ⸻ internal/formats/makernote.go
+// vendor MakerNote blocks in EXIF: reporting and excision
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	tiffTagMake      = 0x010F
+	tiffTagExifIFD   = 0x8769
+	tiffTagMakerNote = 0x927C
+)
+
+// header signatures of vendors that put one before their IFD; Canon and
+// others write none, and are named by the Make tag instead
+var makerNoteSignatures = []struct{ prefix, vendor string }{
+	{"Nikon\x00", "Nikon"},
+	{"OLYMPUS\x00", "Olympus"},
+	{"OLYMP\x00", "Olympus"},
+	{"OM SYSTEM", "OM System"},
+	{"FUJIFILM", "Fujifilm"},
+	{"Panasonic\x00", "Panasonic"},
+	{"SONY", "Sony"},
+	{"Apple iOS\x00", "Apple"},
+	{"LEICA", "Leica"},
+	{"AOC\x00", "Pentax"},
+	{"PENTAX ", "Pentax"},
+	{"SIGMA\x00", "Sigma"},
+	{"FOVEON\x00", "Sigma"},
+	{"KDK", "Kodak"},
+	{"Ricoh", "Ricoh"},
+	{"RICOH", "Ricoh"},
+	{"SAMSUNG", "Samsung"},
+	{"DJI", "DJI"},
+}
+
+// where a MakerNote sits; offsets are from the start of the file
+type makerNote struct {
+	Vendor string
+	Size   int
+
+	data  int // first byte of the block
+	ifd   int // the Exif IFD holding its entry
+	entry int // index of that entry
+	order binary.ByteOrder
+}
+
+// a TIFF structure inside a file: byte order and where it starts
+type tiffView struct {
+	data  []byte
+	base  int
+	order binary.ByteOrder
+}
+
+// locates the EXIF TIFF structure of a JPEG (APP1), a PNG (eXIf) or a
+// TIFF file; nil when there is none, or the JPEG is too damaged to walk
+func exifView(data []byte) *tiffView {
+	base := -1
+	segments, err := jpegSegments(data)
+	switch {
+	case err == nil:
+		for _, segment := range segments {
+			if segment.marker == 0xE1 && bytes.HasPrefix(segment.payload, []byte("Exif\x00\x00")) {
+				base = segment.start + 4 + 6 // marker, length, "Exif\0\0"
+				break
+			}
+		}
+	case bytes.HasPrefix(data, []byte(pngSignature)):
+		for _, chunk := range pngChunks(data) {
+			if chunk.kind == "eXIf" {
+				base = chunk.offset
+				break
+			}
+		}
+	case errors.Is(err, errNotJPEG):
+		base = 0
+	}
+	if base < 0 || base+8 > len(data) {
+		return nil
+	}
+
+	view := &tiffView{data: data, base: base}
+	switch string(data[base : base+4]) {
+	case "II*\x00":
+		view.order = binary.LittleEndian
+	case "MM\x00*":
+		view.order = binary.BigEndian
+	default:
+		return nil
+	}
+	return view
+}
+
+// file offset of an IFD given its TIFF offset, if the IFD fits
+func (v *tiffView) ifd(offset uint32) (int, int, bool) {
+	start := v.base + int(offset)
+	if offset == 0 || start+2 > len(v.data) {
+		return 0, 0, false
+	}
+	count := int(v.order.Uint16(v.data[start:]))
+	if start+2+count*12+4 > len(v.data) {
+		return 0, 0, false
+	}
+	return start, count, true
+}
+
+// tag, type, count and value/offset field of one IFD entry
+func (v *tiffView) entry(ifd, i int) (tag, kind uint16, count uint32, value int) {
+	at := ifd + 2 + i*12
+	return v.order.Uint16(v.data[at:]), v.order.Uint16(v.data[at+2:]),
+		v.order.Uint32(v.data[at+4:]), at + 8
+}
+
+// finds the MakerNote and names its vendor; nil when the file has none
+func findMakerNote(data []byte) (*makerNote, error) {
+	view := exifView(data)
+	if view == nil {
+		return nil, nil
+	}
+
+	ifd0, count, ok := view.ifd(view.order.Uint32(view.data[view.base+4:]))
+	if !ok {
+		return nil, nil
+	}
+
+	var cameraMake string
+	var exifOffset uint32
+	for i := range count {
+		tag, kind, n, value := view.entry(ifd0, i)
+		switch tag {
+		case tiffTagMake:
+			if kind == 2 && n > 4 {
+				at := view.base + int(view.order.Uint32(view.data[value:]))
+				if at+int(n) <= len(view.data) {
+					cameraMake = strings.TrimRight(string(view.data[at:at+int(n)]), "\x00 ")
+				}
+			} else if kind == 2 {
+				cameraMake = strings.TrimRight(string(view.data[value:value+int(n)]), "\x00 ")
+			}
+		case tiffTagExifIFD:
+			exifOffset = view.order.Uint32(view.data[value:])
+		}
+	}
+
+	exif, count, ok := view.ifd(exifOffset)
+	if !ok {
+		return nil, nil
+	}
+	for i := range count {
+		tag, _, n, value := view.entry(exif, i)
+		if tag != tiffTagMakerNote || n <= 4 {
+			continue
+		}
+		start := view.base + int(view.order.Uint32(view.data[value:]))
+		if start+int(n) > len(view.data) {
+			return nil, fmt.Errorf("MakerNote runs past the end of the file")
+		}
+		note := &makerNote{Size: int(n), data: start, ifd: exif, entry: i, order: view.order}
+		note.Vendor = makerNoteVendor(view.data[start:start+int(n)], cameraMake)
+		return note, nil
+	}
+	return nil, nil
+}
+
+func makerNoteVendor(block []byte, cameraMake string) string {
+	for _, signature := range makerNoteSignatures {
+		if bytes.HasPrefix(block, []byte(signature.prefix)) {
+			return signature.vendor
+		}
+	}
+	if cameraMake != "" {
+		return cameraMake
+	}
+	return "unknown vendor"
+}
+
+// MakerNote vendor and size, for analysis
+func readMakerNote(path string) (*makerNote, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return findMakerNote(data)
+}
+
+// drops the MakerNote entry from the Exif IFD and zeroes its block, in
+// place; offsets elsewhere stay valid, but strict parsers may warn about
+// the unreferenced space left behind
+func exciseMakerNote(path string) (*makerNote, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	note, err := findMakerNote(data)
+	if err != nil || note == nil {
+		return nil, err
+	}
+
+	clear(data[note.data : note.data+note.Size])
+
+	// shift the later entries and the next-IFD offset up by one slot
+	count := int(note.order.Uint16(data[note.ifd:]))
+	entries := note.ifd + 2
+	removed := entries + note.entry*12
+	end := entries + count*12 + 4
+	copy(data[removed:], data[removed+12:end])
+	clear(data[end-12 : end])
+	note.order.PutUint16(data[note.ifd:], uint16(count-1))
+
+	return note, replaceFile(path, data)
+}
+
+func (n *makerNote) describe() string {
+	return fmt.Sprintf("%s, %d bytes", n.Vendor, n.Size)
+}
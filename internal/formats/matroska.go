@@ -0,0 +1,348 @@
+// BYZRA ⸻ internal/formats/matroska.go
+// Matroska (MKV/MKA/WebM) format handler
+
+package formats
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// which Matroska elements survive a wipe
+type MatroskaOptions struct {
+	KeepFonts      bool `json:"keep_fonts"`       // attached fonts (styled subtitles need them)
+	KeepCoverArt   bool `json:"keep_cover_art"`   // attached images
+	KeepChapters   bool `json:"keep_chapters"`    // chapter markers; their names are always cleared
+	KeepTrackNames bool `json:"keep_track_names"` // per-track titles
+}
+
+// fonts stay so subtitles keep rendering; everything else goes
+func DefaultMatroskaOptions() MatroskaOptions {
+	return MatroskaOptions{KeepFonts: true}
+}
+
+// parses a comma-separated keep list: fonts, covers, chapters, track-names, none
+func ParseMatroskaKeep(list string) (MatroskaOptions, error) {
+	var options MatroskaOptions
+	for _, item := range strings.Split(list, ",") {
+		switch strings.TrimSpace(strings.ToLower(item)) {
+		case "fonts":
+			options.KeepFonts = true
+		case "covers", "cover-art":
+			options.KeepCoverArt = true
+		case "chapters":
+			options.KeepChapters = true
+		case "track-names", "tracks":
+			options.KeepTrackNames = true
+		case "none", "":
+		default:
+			return options, fmt.Errorf("unknown Matroska element %q (use fonts, covers, chapters, track-names or none)", item)
+		}
+	}
+	return options, nil
+}
+
+// implements FormatHandler for Matroska files
+type MatroskaHandler struct {
+	Options MatroskaOptions
+}
+
+// extracts metadata from Matroska files; SegmentInfo fields ffprobe does
+// not pass on (the writing app) are read from the file directly
+func (h *MatroskaHandler) ExtractMetadata(path string) (map[string]any, error) {
+	metadata, err := extractMediaMetadata(path, "Matroska")
+	if err != nil {
+		return nil, err
+	}
+	info, err := readMatroskaInfo(path)
+	if err != nil {
+		return metadata, nil // exiftool or ffprobe already made sense of it
+	}
+	for name, value := range info {
+		if _, exists := metadata[name]; !exists {
+			metadata[name] = value
+		}
+	}
+	return metadata, nil
+}
+
+// removes tags and the attachments, chapters and track names not kept
+// (exiftool can't write Matroska, so this is an ffmpeg remux)
+func (h *MatroskaHandler) WipeMetadata(path string) error {
+	probe, err := util.FFProbe(path)
+	if err != nil {
+		return fmt.Errorf("failed to inspect Matroska file: %w", err)
+	}
+
+	args := []string{"-map", "0"}
+	output := 0 // index of each kept stream in the output
+	var keptStreams []util.ProbeStream
+	var keptIndexes []int
+
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "attachment" && !h.keepAttachment(stream) {
+			args = append(args, "-map", "-0:"+strconv.Itoa(stream.Index))
+			continue
+		}
+		keptStreams = append(keptStreams, stream)
+		keptIndexes = append(keptIndexes, output)
+		output++
+	}
+
+	// container tags always go; chapter names always go
+	args = append(args, "-map_metadata", "-1", "-map_metadata:c", "-1")
+	if !h.Options.KeepChapters {
+		args = append(args, "-map_chapters", "-1")
+	}
+
+	if !h.Options.KeepTrackNames {
+		args = append(args, "-map_metadata:s", "-1")
+
+		// languages and attachment names are functional, put them back
+		for i, stream := range keptStreams {
+			for _, tag := range []string{"language", "filename", "mimetype"} {
+				if value := stream.Tags[tag]; value != "" {
+					args = append(args, fmt.Sprintf("-metadata:s:%d", keptIndexes[i]), tag+"="+value)
+				}
+			}
+		}
+	}
+
+	err = remuxInPlace(path, func(src, dst string) error {
+		return util.FFmpegRemux(src, dst, args)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wipe Matroska metadata: %w", err)
+	}
+	return nil
+}
+
+// adds profile metadata to Matroska files as container tags
+func (h *MatroskaHandler) InjectMetadata(path string, profile map[string]string) error {
+	args := []string{"-map", "0", "-map_metadata", "0"}
+	for key, value := range profile {
+		tag := mapProfileKeyToMatroskaTag(key)
+		if tag == "" {
+			continue // skip unmapped keys
+		}
+		args = append(args, "-metadata", tag+"="+value)
+	}
+
+	err := remuxInPlace(path, func(src, dst string) error {
+		return util.FFmpegRemux(src, dst, args)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to inject Matroska metadata: %w", err)
+	}
+	return nil
+}
+
+// ensures the file still demuxes
+func (h *MatroskaHandler) VerifyIntegrity(path string) bool {
+	cmd := exec.Command("ffprobe", "-v", "error", path)
+	return cmd.Run() == nil
+}
+
+// lists attachments, chapters and track names
+func (h *MatroskaHandler) ListEmbedded(path string) ([]Embedded, error) {
+	if !util.HasFFProbe() {
+		return nil, nil
+	}
+
+	probe, err := util.FFProbe(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded []Embedded
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "attachment" {
+			name := stream.Tags["filename"]
+			if name == "" {
+				name = fmt.Sprintf("attachment #%d", stream.Index)
+			}
+			embedded = append(embedded, Embedded{
+				Kind:   attachmentKind(stream),
+				Name:   name,
+				Detail: stream.Tags["mimetype"],
+			})
+			continue
+		}
+
+		if title := stream.Tags["title"]; title != "" {
+			embedded = append(embedded, Embedded{
+				Kind:   "track-name",
+				Name:   fmt.Sprintf("stream #%d (%s)", stream.Index, stream.CodecType),
+				Detail: title,
+			})
+		}
+	}
+
+	for i, chapter := range probe.Chapters {
+		embedded = append(embedded, Embedded{
+			Kind:   "chapter",
+			Name:   fmt.Sprintf("chapter %d", i+1),
+			Detail: strings.TrimSpace(chapter.Tags["title"] + " @ " + chapter.StartTime),
+		})
+	}
+
+	return embedded, nil
+}
+
+func (h *MatroskaHandler) keepAttachment(stream util.ProbeStream) bool {
+	switch attachmentKind(stream) {
+	case "font":
+		return h.Options.KeepFonts
+	case "cover-art":
+		return h.Options.KeepCoverArt
+	default:
+		return false
+	}
+}
+
+// "font", "cover-art" or "attachment"
+func attachmentKind(stream util.ProbeStream) string {
+	mime := strings.ToLower(stream.Tags["mimetype"])
+	ext := strings.ToLower(filepath.Ext(stream.Tags["filename"]))
+
+	switch {
+	case strings.Contains(mime, "font") || ext == ".ttf" || ext == ".otf" || ext == ".ttc":
+		return "font"
+	case strings.HasPrefix(mime, "image/"):
+		return "cover-art"
+	default:
+		return "attachment"
+	}
+}
+
+// maps profile keys to Matroska tag names
+func mapProfileKeyToMatroskaTag(key string) string {
+	switch strings.ToLower(key) {
+	case "author":
+		return "ARTIST"
+	case "created":
+		return "DATE_RELEASED"
+	case "organization":
+		return "COPYRIGHT"
+	case "comment":
+		return "COMMENT"
+	default:
+		return ""
+	}
+}
+
+// ╭─ SEGMENT INFO ──────────────────────────────╮
+
+// SegmentInfo children, as exiftool names them
+var matroskaInfoNames = map[uint64]string{
+	0x7BA9: "Title",
+	0x4D80: "MuxingApp",
+	0x5741: "WritingApp",
+	0x4461: "DateTimeOriginal",
+}
+
+// DateUTC counts nanoseconds from here
+var matroskaEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// largest Info element read
+const matroskaMaxInfo = 1 << 20
+
+// the title, muxing and writing apps and date of the first segment
+func readMatroskaInfo(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// the EBML header, then the segment's children up to Info
+	var off int64
+	for {
+		id, size, header, err := readEBMLHeader(file, off)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case id == 0x18538067: // Segment
+			off += header
+			continue
+		case id == 0x1549A966: // Info
+			if size < 0 || size > matroskaMaxInfo {
+				return nil, fmt.Errorf("Info element of %d bytes", size)
+			}
+			data := make([]byte, size)
+			if _, err := file.ReadAt(data, off+header); err != nil {
+				return nil, err
+			}
+			return parseMatroskaInfo(data), nil
+		case id == 0x1F43B675 || size < 0: // Cluster, or a live stream
+			return nil, fmt.Errorf("no Info element before the media data")
+		}
+		off += header + size
+	}
+}
+
+// ID and size of the element at off, and the length of its header; size
+// is -1 when unknown
+func readEBMLHeader(r io.ReaderAt, off int64) (uint64, int64, int64, error) {
+	head := make([]byte, 12)
+	n, err := r.ReadAt(head, off)
+	if n == 0 {
+		return 0, 0, 0, err
+	}
+	id, idLen := ebmlID(head[:n])
+	if idLen == 0 {
+		return 0, 0, 0, fmt.Errorf("invalid element ID at %d", off)
+	}
+	value, sizeLen, known := ebmlSize(head[idLen:n])
+	if sizeLen == 0 {
+		return 0, 0, 0, fmt.Errorf("invalid element size at %d", off)
+	}
+	size := int64(value)
+	if !known || size < 0 {
+		size = -1
+	}
+	return id, size, int64(idLen + sizeLen), nil
+}
+
+func parseMatroskaInfo(data []byte) map[string]string {
+	info := make(map[string]string)
+	for len(data) > 0 {
+		id, idLen := ebmlID(data)
+		if idLen == 0 {
+			break
+		}
+		value, sizeLen, known := ebmlSize(data[idLen:])
+		header := idLen + sizeLen
+		if sizeLen == 0 || !known || value > uint64(len(data)-header) {
+			break
+		}
+		payload := data[header : header+int(value)]
+		data = data[header+int(value):]
+
+		name, ok := matroskaInfoNames[id]
+		if !ok {
+			continue
+		}
+		if id == 0x4461 {
+			if len(payload) == 8 {
+				date := matroskaEpoch.Add(time.Duration(int64(binary.BigEndian.Uint64(payload))))
+				info[name] = date.Format("2006:01:02 15:04:05Z")
+			}
+			continue
+		}
+		if text := strings.TrimRight(string(payload), "\x00"); text != "" {
+			info[name] = text
+		}
+	}
+	return info
+}
@@ -0,0 +1,343 @@
+// BYZRA ⸻ internal/formats/odf.go
+// LibreOffice and OpenOffice documents (OpenDocument)
+
+package formats
+
+import (
+	"archive/zip"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// namespaces of meta.xml and the manifest
+const (
+	nsODFOffice   = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	nsODFMeta     = "urn:oasis:names:tc:opendocument:xmlns:meta:1.0"
+	nsODFManifest = "urn:oasis:names:tc:opendocument:xmlns:manifest:1.0"
+)
+
+// the document types, by the content of the mimetype entry
+var odfMimeTypes = map[string]string{
+	"application/vnd.oasis.opendocument.text":         "odt",
+	"application/vnd.oasis.opendocument.spreadsheet":  "ods",
+	"application/vnd.oasis.opendocument.presentation": "odp",
+}
+
+// meta.xml elements as exiftool names them
+var odfMetaNames = map[string]string{
+	"initial-creator": "InitialCreator", "creator": "Creator", "printed-by": "PrintedBy",
+	"creation-date": "CreateDate", "date": "ModifyDate", "print-date": "PrintDate",
+	"editing-cycles": "EditingCycles", "editing-duration": "EditingDuration",
+	"generator": "Software", "template": "Template",
+	"title": "Title", "subject": "Subject", "description": "Description",
+	"keyword": "Keywords", "language": "Language",
+}
+
+// "odt", "ods" or "odp" for a ZIP holding an OpenDocument file, ""
+// otherwise
+func ODFDocumentType(path string) string {
+	parts, err := readZipParts(path, "mimetype")
+	if err != nil {
+		return ""
+	}
+	return odfMimeTypes[strings.TrimSpace(string(parts["mimetype"]))]
+}
+
+// the properties in meta.xml
+func extractODFMetadata(path string) (map[string]any, error) {
+	parts, err := readZipParts(path, "meta.xml")
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]any)
+	data := parts["meta.xml"]
+	if data == nil {
+		return metadata, nil
+	}
+	_, children, err := odfMetaChildren(data)
+	if err != nil {
+		return nil, fmt.Errorf("meta.xml: %w", err)
+	}
+
+	for _, child := range children {
+		value := strings.TrimSpace(child.text)
+		name := odfMetaNames[child.local]
+		switch child.local {
+		case "user-defined":
+			name = child.attr("name")
+		case "template":
+			value = child.attr("href")
+		case "creation-date", "date", "print-date":
+			value = odfDate(value)
+		case "editing-duration":
+			value = odfDuration(value)
+		}
+		if name == "" || value == "" {
+			continue // document-statistic only counts pages and words
+		}
+		if previous, ok := metadata[name].(string); ok {
+			value = previous + ", " + value // one meta:keyword element per keyword
+		}
+		metadata[name] = value
+	}
+	return metadata, nil
+}
+
+// the office:meta element of meta.xml and its children
+func odfMetaChildren(data []byte) (*xmlElement, []xmlElement, error) {
+	meta, children, err := xmlChildren(data, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta.local != "meta" {
+		return nil, nil, fmt.Errorf("no office:meta element")
+	}
+	return meta, children, nil
+}
+
+// "2019-03-04T10:20:00.123456789" as "2019:03:04 10:20:00"; LibreOffice
+// writes local time without a zone
+func odfDate(value string) string {
+	if date, err := time.Parse("2006-01-02T15:04:05.999999999", value); err == nil {
+		return date.Format("2006:01:02 15:04:05")
+	}
+	return ooxmlDate(value)
+}
+
+var odfDurationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)(?:[.,]\d+)?S)?)?$`)
+
+// "PT2H30M15S" as "2h30m15s"
+func odfDuration(value string) string {
+	match := odfDurationPattern.FindStringSubmatch(value)
+	if match == nil {
+		return value
+	}
+	var total time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		n, _ := strconv.Atoi(match[i+1])
+		total += time.Duration(n) * unit
+	}
+	if total == 0 {
+		return ""
+	}
+	return total.String()
+}
+
+// the meta.xml parts of the document and of the objects embedded in it
+func odfMetaParts(path string) ([]string, error) {
+	names, err := listZipParts(path)
+	if err != nil {
+		return nil, err
+	}
+	var parts []string
+	for _, name := range names {
+		if name == "meta.xml" || strings.HasSuffix(name, "/meta.xml") {
+			parts = append(parts, name)
+		}
+	}
+	return parts, nil
+}
+
+// leaves office:meta empty in every meta.xml, keeping the root element and
+// its namespace declarations, and clears the timestamps of every entry
+func wipeODFMetadata(path string) error {
+	names, err := odfMetaParts(path)
+	if err != nil {
+		return err
+	}
+	parts, err := readZipParts(path, names...)
+	if err != nil {
+		return err
+	}
+
+	replace := make(map[string][]byte)
+	for name, data := range parts {
+		root, children, err := xmlChildren(data, 1)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		meta := xmlElement{prefix: root.prefix, local: "meta"}
+		all := func(xmlElement) bool { return true }
+		replace[name] = editXMLChildren(data, root, children, all, "<"+meta.qname()+"/>")
+	}
+	return rewriteZip(path, replace, nil)
+}
+
+// standard prefixes, declared on the element when meta.xml binds none
+var odfPrefixes = map[string]string{
+	nsODFOffice: "office", nsODFMeta: "meta", nsDC: "dc",
+}
+
+// a meta.xml element to write; user-defined ones carry a name
+type odfProperty struct {
+	namespace, local, name, value string
+}
+
+// a meta.xml with nothing in office:meta, for documents that have none
+const emptyODFMeta = `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+	`<office:document-meta xmlns:office="` + nsODFOffice + `" xmlns:meta="` + nsODFMeta + `" xmlns:dc="` + nsDC +
+	`" office:version="1.2"><office:meta/></office:document-meta>`
+
+// sets meta.xml properties from the profile: author as initial creator
+// and creator, organization as a "Company" user-defined field, with the
+// editing cycles reset to 1
+func injectODFMetadata(path string, profile map[string]string) error {
+	var props []odfProperty
+	for _, key := range sortedKeys(profile) {
+		value := profile[key]
+		switch strings.ToLower(key) {
+		case "author":
+			props = append(props,
+				odfProperty{namespace: nsODFMeta, local: "initial-creator", value: value},
+				odfProperty{namespace: nsDC, local: "creator", value: value})
+		case "comment":
+			props = append(props, odfProperty{namespace: nsDC, local: "description", value: value})
+		case "created":
+			date, err := time.Parse("2006:01:02 15:04:05", value)
+			if err != nil {
+				return fmt.Errorf("invalid date %q: %w", value, err)
+			}
+			props = append(props, odfProperty{namespace: nsODFMeta, local: "creation-date", value: date.Format("2006-01-02T15:04:05")})
+		case "software":
+			props = append(props, odfProperty{namespace: nsODFMeta, local: "generator", value: value})
+		case "organization":
+			props = append(props, odfProperty{namespace: nsODFMeta, local: "user-defined", name: "Company", value: value})
+		}
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	props = append(props, odfProperty{namespace: nsODFMeta, local: "editing-cycles", value: "1"})
+
+	parts, err := readZipParts(path, "meta.xml", "META-INF/manifest.xml")
+	if err != nil {
+		return err
+	}
+	replace, add := make(map[string][]byte), make(map[string][]byte)
+	data := parts["meta.xml"]
+	if data == nil {
+		// no meta.xml yet: add it, with its manifest entry
+		manifest, err := odfManifestEntry(parts["META-INF/manifest.xml"], "meta.xml", "text/xml")
+		if err != nil {
+			return err
+		}
+		data, replace["META-INF/manifest.xml"] = []byte(emptyODFMeta), manifest
+	}
+
+	edited, err := setODFProperties(data, props)
+	if err != nil {
+		return fmt.Errorf("meta.xml: %w", err)
+	}
+	if parts["meta.xml"] == nil {
+		add["meta.xml"] = edited
+	} else {
+		replace["meta.xml"] = edited
+	}
+	return rewriteZip(path, replace, add)
+}
+
+// replaces the properties in office:meta, keeping everything else
+func setODFProperties(data []byte, props []odfProperty) ([]byte, error) {
+	root, _, err := xmlChildren(data, 1)
+	if err != nil {
+		return nil, err
+	}
+	meta, children, err := odfMetaChildren(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var markup strings.Builder
+	for _, p := range props {
+		name, decl := root.nameIn(p.namespace, p.local, odfPrefixes)
+		if p.name != "" {
+			attr, attrDecl := root.nameIn(nsODFMeta, "name", odfPrefixes)
+			if attrDecl == decl {
+				attrDecl = ""
+			}
+			decl += attrDecl + ` ` + attr + `="` + xmlEscape(p.name) + `"`
+		}
+		fmt.Fprintf(&markup, "<%s%s>%s</%s>", name, decl, xmlEscape(p.value), name)
+	}
+
+	set := func(child xmlElement) bool {
+		return slices.ContainsFunc(props, func(p odfProperty) bool {
+			return p.local == child.local && p.name == child.attr("name")
+		})
+	}
+	return editXMLChildren(data, meta, children, set, markup.String()), nil
+}
+
+// adds a file entry for a new part to the manifest
+func odfManifestEntry(manifest []byte, name, mediaType string) ([]byte, error) {
+	if manifest == nil {
+		return nil, fmt.Errorf("cannot add %s: the document has no manifest", name)
+	}
+	root, children, err := xmlChildren(manifest, 1)
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	prefixes := map[string]string{nsODFManifest: "manifest"}
+	entry, decl := root.nameIn(nsODFManifest, "file-entry", prefixes)
+	fullPath, _ := root.nameIn(nsODFManifest, "full-path", prefixes)
+	media, _ := root.nameIn(nsODFManifest, "media-type", prefixes)
+	markup := fmt.Sprintf(`<%s%s %s="%s" %s="%s"/>`, entry, decl, fullPath, name, media, mediaType)
+	return editXMLChildren(manifest, root, children, func(xmlElement) bool { return false }, markup), nil
+}
+
+// lists the thumbnail and embedded objects
+func listODFEmbedded(path string) ([]Embedded, error) {
+	names, err := listZipParts(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded []Embedded
+	for _, name := range names {
+		switch {
+		case strings.HasPrefix(name, "Thumbnails/"):
+			embedded = append(embedded, Embedded{Kind: "thumbnail", Name: name, Detail: "preview of the first page"})
+		case strings.HasSuffix(name, "/meta.xml"):
+			embedded = append(embedded, Embedded{Kind: "data", Name: strings.TrimSuffix(name, "/meta.xml"), Detail: "embedded object; its meta.xml is wiped with the document's"})
+		case strings.HasPrefix(name, "ObjectReplacements/"):
+			embedded = append(embedded, Embedded{Kind: "thumbnail", Name: name, Detail: "preview of an embedded object"})
+		}
+	}
+	return embedded, nil
+}
+
+// checks that the archive decompresses, starts with its mimetype entry,
+// and that meta.xml parses and content.xml is there
+func verifyODF(path string) bool {
+	if !verifyZip(path) {
+		return false
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	defer archive.Close()
+	if len(archive.File) == 0 || archive.File[0].Name != "mimetype" || archive.File[0].Method != zip.Store {
+		return false
+	}
+	if !slices.ContainsFunc(archive.File, func(file *zip.File) bool { return file.Name == "content.xml" }) {
+		return false
+	}
+	for _, file := range archive.File {
+		if file.Name != "meta.xml" {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return false
+		}
+		if _, _, err := odfMetaChildren(data); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,363 @@
+// BYZRA ⸻ internal/formats/ole2.go
+// OLE2 compound files, the container of legacy Office documents
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// D0 CF 11 E0 A1 B1 1A E1
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+const (
+	oleEndOfChain = 0xFFFFFFFE
+	oleFreeSector = 0xFFFFFFFF
+	oleMaxSector  = 0xFFFFFFFA
+	oleNoStream   = 0xFFFFFFFF
+
+	oleEntrySize = 128
+	oleStorage   = 1
+	oleStream    = 2
+	oleRoot      = 5
+)
+
+var errOLECorrupt = errors.New("corrupt OLE2 compound file")
+
+// a compound file held in memory: a FAT-style filesystem of storages
+// (directories) and streams, with small streams packed into a mini stream
+type compoundFile struct {
+	data       []byte
+	sectorSize int
+	miniSize   int
+	miniCutoff uint64
+	fat        []uint32
+	miniFAT    []uint32
+	miniChain  []uint32 // sectors of the root entry, which hold the mini stream
+	entries    []oleEntry
+}
+
+// a directory entry
+type oleEntry struct {
+	name               string
+	kind               byte
+	left, right, child uint32
+	start              uint32
+	size               uint64
+	offset             int // of the entry in data
+}
+
+// parses the header, allocation tables and directory of a compound file
+func parseCompoundFile(data []byte) (*compoundFile, error) {
+	if len(data) < 512 || !bytes.HasPrefix(data, oleSignature) {
+		return nil, fmt.Errorf("%w: no compound file header", errOLECorrupt)
+	}
+	le := binary.LittleEndian
+	if le.Uint16(data[0x1C:]) != 0xFFFE {
+		return nil, fmt.Errorf("%w: bad byte order mark", errOLECorrupt)
+	}
+
+	cf := &compoundFile{data: data}
+	major := le.Uint16(data[0x1A:])
+	switch shift := le.Uint16(data[0x1E:]); {
+	case major == 3 && shift == 9, major == 4 && shift == 12:
+		cf.sectorSize = 1 << shift
+	default:
+		return nil, fmt.Errorf("%w: version %d with %d-byte sectors", errOLECorrupt, major, 1<<shift)
+	}
+	cf.miniSize = 1 << le.Uint16(data[0x20:])
+	cf.miniCutoff = uint64(le.Uint32(data[0x38:]))
+	if cf.miniSize != 64 || cf.miniCutoff != 4096 {
+		return nil, fmt.Errorf("%w: unexpected mini stream layout", errOLECorrupt)
+	}
+
+	if err := cf.readFAT(); err != nil {
+		return nil, err
+	}
+
+	dirChain, err := cf.chain(le.Uint32(data[0x30:]), cf.fat)
+	if err != nil {
+		return nil, fmt.Errorf("directory: %w", err)
+	}
+	for _, sector := range dirChain {
+		start, ok := cf.sectorOffset(sector)
+		if !ok {
+			return nil, fmt.Errorf("%w: directory sector %d out of range", errOLECorrupt, sector)
+		}
+		for off := start; off+oleEntrySize <= start+cf.sectorSize; off += oleEntrySize {
+			cf.entries = append(cf.entries, parseOLEEntry(data, off, major))
+		}
+	}
+	if len(cf.entries) == 0 || cf.entries[0].kind != oleRoot {
+		return nil, fmt.Errorf("%w: no root entry", errOLECorrupt)
+	}
+
+	if first := le.Uint32(data[0x3C:]); first != oleEndOfChain && le.Uint32(data[0x40:]) > 0 {
+		sectors, err := cf.chain(first, cf.fat)
+		if err != nil {
+			return nil, fmt.Errorf("mini FAT: %w", err)
+		}
+		for _, sector := range sectors {
+			start, ok := cf.sectorOffset(sector)
+			if !ok {
+				return nil, fmt.Errorf("%w: mini FAT sector %d out of range", errOLECorrupt, sector)
+			}
+			cf.miniFAT = appendUint32s(cf.miniFAT, data[start:start+cf.sectorSize])
+		}
+	}
+	if root := cf.entries[0]; root.size > 0 {
+		if cf.miniChain, err = cf.chain(root.start, cf.fat); err != nil {
+			return nil, fmt.Errorf("mini stream: %w", err)
+		}
+	}
+	return cf, nil
+}
+
+// collects the FAT from the sector list in the header and the DIFAT chain
+func (cf *compoundFile) readFAT() error {
+	le := binary.LittleEndian
+	count := int(le.Uint32(cf.data[0x2C:]))
+	if count > len(cf.data)/cf.sectorSize {
+		return fmt.Errorf("%w: %d FAT sectors in a %d-byte file", errOLECorrupt, count, len(cf.data))
+	}
+
+	sectors := appendUint32s(nil, cf.data[0x4C:0x200])
+	next := le.Uint32(cf.data[0x44:])
+	for hops := 0; next != oleEndOfChain && next != oleFreeSector && len(sectors) < count; hops++ {
+		start, ok := cf.sectorOffset(next)
+		if !ok || hops > count {
+			return fmt.Errorf("%w: broken DIFAT chain", errOLECorrupt)
+		}
+		entries := appendUint32s(nil, cf.data[start:start+cf.sectorSize])
+		sectors = append(sectors, entries[:len(entries)-1]...)
+		next = entries[len(entries)-1]
+	}
+	if len(sectors) < count {
+		return fmt.Errorf("%w: FAT sectors missing", errOLECorrupt)
+	}
+
+	for _, sector := range sectors[:count] {
+		start, ok := cf.sectorOffset(sector)
+		if !ok {
+			return fmt.Errorf("%w: FAT sector %d out of range", errOLECorrupt, sector)
+		}
+		cf.fat = appendUint32s(cf.fat, cf.data[start:start+cf.sectorSize])
+	}
+	return nil
+}
+
+func parseOLEEntry(data []byte, off int, major uint16) oleEntry {
+	le := binary.LittleEndian
+	raw := data[off : off+oleEntrySize]
+
+	nameLen := min(int(le.Uint16(raw[0x40:])), 64)
+	units := make([]uint16, 0, 32)
+	for i := 0; i+1 < nameLen-1; i += 2 {
+		units = append(units, le.Uint16(raw[i:]))
+	}
+
+	size := le.Uint64(raw[0x78:])
+	if major == 3 {
+		size &= 0xFFFFFFFF // version 3 files may leave garbage in the high half
+	}
+	return oleEntry{
+		name:   string(utf16.Decode(units)),
+		kind:   raw[0x42],
+		left:   le.Uint32(raw[0x44:]),
+		right:  le.Uint32(raw[0x48:]),
+		child:  le.Uint32(raw[0x4C:]),
+		start:  le.Uint32(raw[0x74:]),
+		size:   size,
+		offset: off,
+	}
+}
+
+func appendUint32s(dst []uint32, b []byte) []uint32 {
+	for i := 0; i+4 <= len(b); i += 4 {
+		dst = append(dst, binary.LittleEndian.Uint32(b[i:]))
+	}
+	return dst
+}
+
+// where a sector starts in the file; the header takes the first sector's
+// worth of bytes
+func (cf *compoundFile) sectorOffset(sector uint32) (int, bool) {
+	if sector > oleMaxSector {
+		return 0, false
+	}
+	start := (int(sector) + 1) * cf.sectorSize
+	return start, start+cf.sectorSize <= len(cf.data)
+}
+
+// follows a chain of sectors through an allocation table
+func (cf *compoundFile) chain(start uint32, table []uint32) ([]uint32, error) {
+	var sectors []uint32
+	for sector := start; sector != oleEndOfChain; sector = table[sector] {
+		if int(sector) >= len(table) || len(sectors) > len(table) {
+			return nil, fmt.Errorf("%w: broken sector chain", errOLECorrupt)
+		}
+		sectors = append(sectors, sector)
+	}
+	return sectors, nil
+}
+
+// the byte ranges of the file holding a stream, in order
+func (cf *compoundFile) extents(entry oleEntry) ([][2]int, error) {
+	if entry.size == 0 {
+		return nil, nil
+	}
+
+	var extents [][2]int
+	if entry.size < cf.miniCutoff {
+		sectors, err := cf.chain(entry.start, cf.miniFAT)
+		if err != nil {
+			return nil, err
+		}
+		for _, mini := range sectors {
+			pos := int(mini) * cf.miniSize
+			index := pos / cf.sectorSize
+			if index >= len(cf.miniChain) {
+				return nil, fmt.Errorf("%w: mini sector %d beyond the mini stream", errOLECorrupt, mini)
+			}
+			start, ok := cf.sectorOffset(cf.miniChain[index])
+			if !ok {
+				return nil, fmt.Errorf("%w: mini stream sector out of range", errOLECorrupt)
+			}
+			start += pos % cf.sectorSize
+			extents = append(extents, [2]int{start, start + cf.miniSize})
+		}
+	} else {
+		sectors, err := cf.chain(entry.start, cf.fat)
+		if err != nil {
+			return nil, err
+		}
+		for _, sector := range sectors {
+			start, ok := cf.sectorOffset(sector)
+			if !ok {
+				return nil, fmt.Errorf("%w: sector %d out of range", errOLECorrupt, sector)
+			}
+			extents = append(extents, [2]int{start, start + cf.sectorSize})
+		}
+	}
+
+	capacity := 0
+	for _, extent := range extents {
+		capacity += extent[1] - extent[0]
+	}
+	if uint64(capacity) < entry.size {
+		return nil, fmt.Errorf("%w: stream %q is shorter than its size", errOLECorrupt, entry.name)
+	}
+	return extents, nil
+}
+
+// the content of a stream
+func (cf *compoundFile) read(entry oleEntry) ([]byte, error) {
+	extents, err := cf.extents(entry)
+	if err != nil {
+		return nil, err
+	}
+	content := make([]byte, 0, entry.size)
+	for _, extent := range extents {
+		content = append(content, cf.data[extent[0]:extent[1]]...)
+	}
+	return content[:entry.size], nil
+}
+
+// replaces the content of a stream within the sectors it already has,
+// zeroing what is left of them; the stream cannot move between the mini
+// stream and regular sectors, so content is zero-padded to stay at or
+// above the cutoff when the stream was there
+func (cf *compoundFile) write(index int, content []byte) error {
+	entry := cf.entries[index]
+	extents, err := cf.extents(entry)
+	if err != nil {
+		return err
+	}
+
+	size := uint64(len(content))
+	capacity := 0
+	for _, extent := range extents {
+		capacity += extent[1] - extent[0]
+	}
+	switch {
+	case entry.size >= cf.miniCutoff && size < cf.miniCutoff:
+		size = cf.miniCutoff
+	case entry.size < cf.miniCutoff && size >= cf.miniCutoff, size > uint64(capacity):
+		return fmt.Errorf("%d bytes do not fit in the %d allocated to %q", len(content), capacity, entry.name)
+	}
+
+	for _, extent := range extents {
+		n := copy(cf.data[extent[0]:extent[1]], content)
+		clear(cf.data[extent[0]+n : extent[1]])
+		content = content[n:]
+	}
+
+	cf.entries[index].size = size
+	raw := cf.data[entry.offset : entry.offset+oleEntrySize]
+	binary.LittleEndian.PutUint64(raw[0x78:], size)
+	return nil
+}
+
+// clears the creation and modification times of every directory entry
+func (cf *compoundFile) clearTimes() {
+	for _, entry := range cf.entries {
+		clear(cf.data[entry.offset+0x64 : entry.offset+0x74])
+	}
+}
+
+// indexes of the entries directly inside a storage, walking the
+// red-black tree of its children
+func (cf *compoundFile) children(storage int) []int {
+	var found []int
+	seen := make(map[uint32]bool)
+	var walk func(id uint32)
+	walk = func(id uint32) {
+		if id == oleNoStream || int(id) >= len(cf.entries) || seen[id] {
+			return
+		}
+		seen[id] = true
+		walk(cf.entries[id].left)
+		found = append(found, int(id))
+		walk(cf.entries[id].right)
+	}
+	walk(cf.entries[storage].child)
+	return found
+}
+
+// the entry named name directly inside storage, or -1
+func (cf *compoundFile) lookup(storage int, name string) int {
+	for _, i := range cf.children(storage) {
+		if cf.entries[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// every stream named name, at any depth, with its path ("ObjectPool/_1/...")
+func (cf *compoundFile) findAll(name string) map[int]string {
+	found := make(map[int]string)
+	seen := make(map[int]bool)
+	var walk func(storage int, prefix string)
+	walk = func(storage int, prefix string) {
+		if seen[storage] {
+			return
+		}
+		seen[storage] = true
+		for _, i := range cf.children(storage) {
+			entry := cf.entries[i]
+			switch {
+			case entry.kind == oleStream && entry.name == name:
+				found[i] = prefix + entry.name
+			case entry.kind == oleStorage:
+				walk(i, prefix+entry.name+"/")
+			}
+		}
+	}
+	walk(0, "")
+	return found
+}
@@ -0,0 +1,309 @@
+// BYZRA ⸻ internal/formats/oledoc.go
+// Word, Excel and PowerPoint 97-2003 documents
+
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// "doc", "xls" or "ppt" for an OLE2 compound file holding a Word, Excel or
+// PowerPoint document, "" otherwise
+func OLEDocumentType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, len(oleSignature))
+	if _, err := file.Read(head); err != nil || !bytes.Equal(head, oleSignature) {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	cf, err := parseCompoundFile(data)
+	if err != nil {
+		return ""
+	}
+	return oleApplication(cf)
+}
+
+// the application a compound file belongs to, by its main stream
+func oleApplication(cf *compoundFile) string {
+	switch {
+	case cf.lookup(0, "WordDocument") >= 0:
+		return "doc"
+	case cf.lookup(0, "Workbook") >= 0, cf.lookup(0, "Book") >= 0:
+		return "xls"
+	case cf.lookup(0, "PowerPoint Document") >= 0:
+		return "ppt"
+	}
+	return ""
+}
+
+// reads the compound file at path
+func openCompoundFile(path string) (*compoundFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	return parseCompoundFile(data)
+}
+
+// document properties from the SummaryInformation streams
+func extractOLEMetadata(path string) (map[string]any, error) {
+	cf, err := openCompoundFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]any)
+	for _, stream := range []string{summaryStream, documentSummaryStream} {
+		index := cf.lookup(0, stream)
+		if index < 0 {
+			continue
+		}
+		set, err := readPropertySet(cf, index)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.TrimPrefix(stream, "\x05"), err)
+		}
+		for key, value := range set.fields(stream) {
+			metadata[key] = value
+		}
+	}
+	return metadata, nil
+}
+
+func readPropertySet(cf *compoundFile, index int) (*propertySet, error) {
+	data, err := cf.read(cf.entries[index])
+	if err != nil {
+		return nil, err
+	}
+	return parsePropertySet(data)
+}
+
+// the readable properties of a set, named as exiftool does; user-defined
+// properties keep their own names
+func (set *propertySet) fields(stream string) map[string]string {
+	names := summaryProperties
+	if stream == documentSummaryStream {
+		names = documentSummaryProperties
+	}
+
+	fields := make(map[string]string)
+	for i, section := range set.sections {
+		codepage := section.codepage()
+		custom := map[uint32]string{}
+		if i > 0 {
+			custom = section.dictionary()
+		}
+		for _, p := range section.props {
+			name := names[p.id]
+			if i > 0 {
+				name = custom[p.id]
+			}
+			value := p.text(codepage)
+			if name == "TotalEditTime" {
+				value = editTime(p)
+			}
+			if name != "" && value != "" && p.id > pidCodepage {
+				fields[name] = value
+			}
+		}
+	}
+	return fields
+}
+
+// empties the SummaryInformation streams of the document and of the
+// objects embedded in it, and clears the directory timestamps
+func wipeOLEMetadata(path string) error {
+	cf, err := openCompoundFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, stream := range []string{summaryStream, documentSummaryStream} {
+		for index := range cf.findAll(stream) {
+			set, err := readPropertySet(cf, index)
+			if err != nil {
+				return fmt.Errorf("%s: %w", strings.TrimPrefix(stream, "\x05"), err)
+			}
+
+			// one section left, holding only the code page
+			first := set.sections[:min(len(set.sections), 1)]
+			for i := range first {
+				codepage := first[i].codepage()
+				if codepage == 0 {
+					codepage = 1252
+				}
+				first[i].props = []property{codepageProperty(codepage)}
+			}
+			set.sections = first
+			if err := cf.write(index, set.bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	cf.clearTimes()
+
+	if err := os.WriteFile(path, cf.data, 0644); err != nil {
+		return fmt.Errorf("failed to write cleaned document: %w", err)
+	}
+	return nil
+}
+
+// profile fields mapped to document properties, by stream
+func mapProfileKeyToDocumentTag(key string) (stream string, pid uint32) {
+	switch strings.ToLower(key) {
+	case "author":
+		return summaryStream, 4
+	case "comment":
+		return summaryStream, 6
+	case "created":
+		return summaryStream, 12
+	case "software":
+		return summaryStream, 18
+	case "organization":
+		return documentSummaryStream, 15
+	default:
+		return "", 0
+	}
+}
+
+// writes profile fields into the SummaryInformation streams; a stream only
+// grows into the sectors it already has
+func injectOLEMetadata(path string, profile map[string]string) error {
+	cf, err := openCompoundFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, stream := range []string{summaryStream, documentSummaryStream} {
+		index := cf.lookup(0, stream)
+		if index < 0 {
+			continue
+		}
+		set, err := readPropertySet(cf, index)
+		if err != nil || len(set.sections) == 0 {
+			continue
+		}
+
+		section := &set.sections[0]
+		changed := false
+		for _, key := range sortedKeys(profile) {
+			value := profile[key]
+			target, pid := mapProfileKeyToDocumentTag(key)
+			if target != stream || value == "" {
+				continue
+			}
+			if pid == 12 {
+				date, err := time.Parse("2006:01:02 15:04:05", value)
+				if err != nil {
+					return fmt.Errorf("invalid date %q: %w", value, err)
+				}
+				section.set(filetimeProperty(pid, date))
+			} else {
+				if !isASCII(value) && section.codepage() != codepageUTF8 {
+					section.toUTF16()
+				}
+				section.set(stringProperty(pid, value, section.codepage()))
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		if err := cf.write(index, set.bytes()); err != nil {
+			return fmt.Errorf("%s: %w", strings.TrimPrefix(stream, "\x05"), err)
+		}
+	}
+
+	if err := os.WriteFile(path, cf.data, 0644); err != nil {
+		return fmt.Errorf("failed to write document with metadata: %w", err)
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// lists the thumbnail in SummaryInformation and the property streams of
+// embedded objects, which keep the author of the file they came from
+func listOLEEmbedded(path string) ([]Embedded, error) {
+	cf, err := openCompoundFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded []Embedded
+	if index := cf.lookup(0, summaryStream); index >= 0 {
+		if set, err := readPropertySet(cf, index); err == nil && len(set.sections) > 0 {
+			for _, p := range set.sections[0].props {
+				if p.id == pidThumbnail && p.kind() == vtCF {
+					embedded = append(embedded, Embedded{
+						Kind:   "thumbnail",
+						Name:   "SummaryInformation thumbnail",
+						Detail: fmt.Sprintf("preview of the first page, %d bytes", len(p.value)),
+					})
+				}
+			}
+		}
+	}
+
+	nested := cf.findAll(summaryStream)
+	for _, index := range slices.Sorted(maps.Keys(nested)) {
+		name := nested[index]
+		if !strings.Contains(name, "/") {
+			continue
+		}
+		set, err := readPropertySet(cf, index)
+		if err != nil {
+			continue
+		}
+		fields := set.fields(summaryStream)
+		if len(fields) == 0 {
+			continue
+		}
+		detail := "document properties of an embedded object"
+		if author := fields["Author"]; author != "" {
+			detail += ", author " + author
+		}
+		embedded = append(embedded, Embedded{
+			Kind:   "data",
+			Name:   strings.TrimSuffix(name, "/"+summaryStream),
+			Detail: detail,
+		})
+	}
+	return embedded, nil
+}
+
+// checks that the compound file and its property streams parse
+func verifyOLE(path string) bool {
+	cf, err := openCompoundFile(path)
+	if err != nil {
+		return false
+	}
+	for _, stream := range []string{summaryStream, documentSummaryStream} {
+		if index := cf.lookup(0, stream); index >= 0 {
+			if _, err := readPropertySet(cf, index); err != nil {
+				return false
+			}
+		}
+	}
+	return oleApplication(cf) != ""
+}
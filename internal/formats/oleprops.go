@@ -0,0 +1,369 @@
+// BYZRA ⸻ internal/formats/oleprops.go
+// OLE property sets: the SummaryInformation streams of Office files
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// stream names; the leading \x05 marks a property set
+const (
+	summaryStream         = "\x05SummaryInformation"
+	documentSummaryStream = "\x05DocumentSummaryInformation"
+)
+
+// property types (VARENUM)
+const (
+	vtI2       = 0x02
+	vtI4       = 0x03
+	vtBool     = 0x0B
+	vtUI4      = 0x13
+	vtLPSTR    = 0x1E
+	vtLPWSTR   = 0x1F
+	vtFiletime = 0x40
+	vtCF       = 0x47
+)
+
+// property 1 of every section: the code page of its VT_LPSTR strings
+const (
+	pidCodepage   = 1
+	codepageUTF16 = 1200
+	codepageUTF8  = 65001
+)
+
+// property names as exiftool reports them, by stream
+var summaryProperties = map[uint32]string{
+	2: "Title", 3: "Subject", 4: "Author", 5: "Keywords", 6: "Comments",
+	7: "Template", 8: "LastModifiedBy", 9: "RevisionNumber", 10: "TotalEditTime",
+	11: "LastPrinted", 12: "CreateDate", 13: "ModifyDate", 14: "Pages",
+	15: "Words", 16: "Characters", 18: "Software", 19: "Security",
+}
+
+var documentSummaryProperties = map[uint32]string{
+	2: "Category", 3: "PresentationTarget", 4: "Bytes", 5: "Lines",
+	6: "Paragraphs", 7: "Slides", 8: "Notes", 9: "HiddenSlides",
+	10: "MMClips", 14: "Manager", 15: "Company", 17: "CharCountWithSpaces",
+	23: "AppVersion", 26: "ContentType", 27: "ContentStatus",
+	28: "Language", 29: "DocVersion",
+}
+
+// the thumbnail Office stores in SummaryInformation
+const pidThumbnail = 17
+
+// a parsed property set stream
+type propertySet struct {
+	header   []byte // byte order, version, system and class identifiers
+	sections []propertySection
+}
+
+type propertySection struct {
+	fmtid []byte
+	props []property
+}
+
+// a property with its value as stored: type, then data, padded to four bytes
+type property struct {
+	id    uint32
+	value []byte
+}
+
+func (p property) kind() uint32 {
+	if len(p.value) < 4 {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(p.value) & 0xFFFF
+}
+
+func parsePropertySet(data []byte) (*propertySet, error) {
+	le := binary.LittleEndian
+	if len(data) < 28 || le.Uint16(data) != 0xFFFE {
+		return nil, fmt.Errorf("not a property set")
+	}
+
+	set := &propertySet{header: data[:24]}
+	count := int(le.Uint32(data[24:]))
+	if count > 2 || 28+count*20 > len(data) {
+		return nil, fmt.Errorf("property set with %d sections", count)
+	}
+
+	for i := range count {
+		entry := data[28+i*20:]
+		start := int(le.Uint32(entry[16:]))
+		if start+8 > len(data) {
+			return nil, fmt.Errorf("section %d out of range", i)
+		}
+		size := int(le.Uint32(data[start:]))
+		n := int(le.Uint32(data[start+4:]))
+		if size < 8+n*8 || start+size > len(data) {
+			return nil, fmt.Errorf("section %d out of range", i)
+		}
+		section := data[start : start+size]
+
+		// each value runs up to the next one, or the end of the section
+		offsets := make([]int, 0, n+1)
+		for j := range n {
+			offsets = append(offsets, int(le.Uint32(section[12+j*8:])))
+		}
+		ends := append(slices.Clone(offsets), size)
+		slices.Sort(ends)
+
+		props := make([]property, 0, n)
+		for j, offset := range offsets {
+			if offset < 8+n*8 || offset+4 > size {
+				return nil, fmt.Errorf("property %d out of range", j)
+			}
+			end := ends[slices.Index(ends, offset)+1]
+			if offset+4 > end {
+				return nil, fmt.Errorf("property %d overlaps the next", j)
+			}
+			props = append(props, property{id: le.Uint32(section[8+j*8:]), value: section[offset:end]})
+		}
+		set.sections = append(set.sections, propertySection{fmtid: entry[:16], props: props})
+	}
+	return set, nil
+}
+
+// the stream bytes of a property set
+func (set *propertySet) bytes() []byte {
+	le := binary.LittleEndian
+	var out bytes.Buffer
+	out.Write(set.header)
+	out.Write(le.AppendUint32(nil, uint32(len(set.sections))))
+
+	offset := 28 + 20*len(set.sections)
+	var bodies [][]byte
+	for _, section := range set.sections {
+		out.Write(section.fmtid)
+		out.Write(le.AppendUint32(nil, uint32(offset)))
+		body := section.bytes()
+		bodies = append(bodies, body)
+		offset += len(body)
+	}
+	for _, body := range bodies {
+		out.Write(body)
+	}
+	return out.Bytes()
+}
+
+func (section *propertySection) bytes() []byte {
+	le := binary.LittleEndian
+	table := 8 + 8*len(section.props)
+	size := table
+	for _, p := range section.props {
+		size += len(p.value)
+	}
+
+	out := make([]byte, table, size)
+	le.PutUint32(out, uint32(size))
+	le.PutUint32(out[4:], uint32(len(section.props)))
+	offset := table
+	for i, p := range section.props {
+		le.PutUint32(out[8+i*8:], p.id)
+		le.PutUint32(out[12+i*8:], uint32(offset))
+		out = append(out, p.value...)
+		offset += len(p.value)
+	}
+	return out
+}
+
+func (section *propertySection) codepage() int {
+	for _, p := range section.props {
+		if p.id == pidCodepage && p.kind() == vtI2 && len(p.value) >= 6 {
+			return int(binary.LittleEndian.Uint16(p.value[4:]))
+		}
+	}
+	return 0
+}
+
+// replaces or adds a property, keeping the table ordered by id
+func (section *propertySection) set(p property) {
+	i, found := slices.BinarySearchFunc(section.props, p.id, func(q property, id uint32) int {
+		return int(int64(q.id) - int64(id))
+	})
+	if found {
+		section.props[i] = p
+		return
+	}
+	slices.SortFunc(section.props, func(a, b property) int { return int(int64(a.id) - int64(b.id)) })
+	i, _ = slices.BinarySearchFunc(section.props, p.id, func(q property, id uint32) int {
+		return int(int64(q.id) - int64(id))
+	})
+	section.props = slices.Insert(section.props, i, p)
+}
+
+// ╭─ VALUES ────────────────────────────────────╮
+
+// a property as text, or "" for types that are not shown (vectors, blobs)
+func (p property) text(codepage int) string {
+	le := binary.LittleEndian
+	data := p.value[4:]
+	switch p.kind() {
+	case vtLPSTR:
+		if len(data) < 4 {
+			return ""
+		}
+		n := min(int(le.Uint32(data)), len(data)-4)
+		return decodeCodepage(codepage, data[4:4+n])
+	case vtLPWSTR:
+		if len(data) < 4 {
+			return ""
+		}
+		n := min(int(le.Uint32(data))*2, len(data)-4)
+		return decodeID3Text(1, data[4:4+n])
+	case vtI2:
+		if len(data) >= 2 {
+			return fmt.Sprint(int16(le.Uint16(data)))
+		}
+	case vtI4:
+		if len(data) >= 4 {
+			return fmt.Sprint(int32(le.Uint32(data)))
+		}
+	case vtUI4:
+		if len(data) >= 4 {
+			return fmt.Sprint(le.Uint32(data))
+		}
+	case vtBool:
+		if len(data) >= 2 {
+			return fmt.Sprint(le.Uint16(data) != 0)
+		}
+	case vtFiletime:
+		if len(data) >= 8 {
+			if date := filetime(le.Uint64(data)); !date.IsZero() {
+				return date.Format("2006:01:02 15:04:05")
+			}
+		}
+	}
+	return ""
+}
+
+// VT_LPSTR text in the section's code page; single-byte code pages other
+// than UTF-8 are read as Latin-1
+func decodeCodepage(codepage int, data []byte) string {
+	switch codepage {
+	case codepageUTF16:
+		return decodeID3Text(1, data)
+	case codepageUTF8:
+		return decodeID3Text(3, data)
+	}
+	return decodeID3Text(0, data)
+}
+
+// seconds from 1601, where FILETIME counts from, to 1970
+const filetimeEpoch = 11644473600
+
+// 100-nanosecond intervals since 1601
+func filetime(ticks uint64) time.Time {
+	if ticks == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(ticks/1e7)-filetimeEpoch, int64(ticks%1e7)*100).UTC()
+}
+
+func toFiletime(date time.Time) uint64 {
+	return uint64(date.Unix()+filetimeEpoch)*1e7 + uint64(date.Nanosecond()/100)
+}
+
+// TotalEditTime is a FILETIME holding a duration
+func editTime(p property) string {
+	if p.kind() != vtFiletime || len(p.value) < 12 {
+		return ""
+	}
+	d := time.Duration(binary.LittleEndian.Uint64(p.value[4:])/1e4) * time.Millisecond
+	if d == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// a VT_LPSTR value in the given code page
+func stringProperty(id uint32, value string, codepage int) property {
+	var data []byte
+	if codepage == codepageUTF16 {
+		for _, unit := range utf16.Encode([]rune(value + "\x00")) {
+			data = binary.LittleEndian.AppendUint16(data, unit)
+		}
+	} else {
+		data = append([]byte(value), 0)
+	}
+	out := binary.LittleEndian.AppendUint32(nil, vtLPSTR)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(data)))
+	return property{id: id, value: pad4(append(out, data...))}
+}
+
+func filetimeProperty(id uint32, date time.Time) property {
+	out := binary.LittleEndian.AppendUint32(nil, vtFiletime)
+	return property{id: id, value: binary.LittleEndian.AppendUint64(out, toFiletime(date))}
+}
+
+func codepageProperty(codepage int) property {
+	out := binary.LittleEndian.AppendUint32(nil, vtI2)
+	return property{id: pidCodepage, value: binary.LittleEndian.AppendUint32(out, uint32(uint16(codepage)))}
+}
+
+func pad4(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// rewrites a section's strings as UTF-16, so any text can be added
+func (section *propertySection) toUTF16() {
+	codepage := section.codepage()
+	if codepage == codepageUTF16 {
+		return
+	}
+	for i, p := range section.props {
+		if p.kind() == vtLPSTR {
+			section.props[i] = stringProperty(p.id, p.text(codepage), codepageUTF16)
+		}
+	}
+	section.set(codepageProperty(codepageUTF16))
+}
+
+// ╭─ USER-DEFINED PROPERTIES ───────────────────╮
+
+// the names of the second DocumentSummaryInformation section, from its
+// dictionary (property 0)
+func (section *propertySection) dictionary() map[uint32]string {
+	names := make(map[uint32]string)
+	codepage := section.codepage()
+	le := binary.LittleEndian
+	for _, p := range section.props {
+		if p.id != 0 {
+			continue
+		}
+		data := p.value // no type field: the count comes first
+		if len(data) < 4 {
+			return names
+		}
+		count := int(le.Uint32(data))
+		pos := 4
+		for range count {
+			if pos+8 > len(data) {
+				break
+			}
+			id, n := le.Uint32(data[pos:]), int(le.Uint32(data[pos+4:]))
+			pos += 8
+			if codepage == codepageUTF16 {
+				n *= 2
+			}
+			if n < 0 || pos+n > len(data) {
+				break
+			}
+			names[id] = decodeCodepage(codepage, data[pos:pos+n])
+			pos += n
+			if codepage == codepageUTF16 {
+				pos = (pos + 3) &^ 3
+			}
+		}
+	}
+	return names
+}
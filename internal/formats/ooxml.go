@@ -0,0 +1,428 @@
+// BYZRA ⸻ internal/formats/ooxml.go
+// Word, Excel and PowerPoint 2007+ documents (Office Open XML)
+
+package formats
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const ooxmlContentTypes = "[Content_Types].xml"
+
+// namespaces of the property parts
+const (
+	nsCoreProperties   = "http://schemas.openxmlformats.org/package/2006/metadata/core-properties"
+	nsAppProperties    = "http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"
+	nsCustomProperties = "http://schemas.openxmlformats.org/officeDocument/2006/custom-properties"
+	nsDocPropsVTypes   = "http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"
+	nsDC               = "http://purl.org/dc/elements/1.1/"
+	nsDCTerms          = "http://purl.org/dc/terms/"
+	nsXSI              = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// relationship types end the same in transitional and strict documents
+const (
+	relOfficeDocument   = "/officeDocument"
+	relCoreProperties   = "/metadata/core-properties"
+	relAppProperties    = "/extended-properties"
+	relCustomProperties = "/custom-properties"
+	relThumbnail        = "/metadata/thumbnail"
+)
+
+// core property names as exiftool reports them
+var ooxmlCoreNames = map[string]string{
+	"creator": "Creator", "lastModifiedBy": "LastModifiedBy", "revision": "RevisionNumber",
+	"created": "CreateDate", "modified": "ModifyDate", "lastPrinted": "LastPrinted",
+	"title": "Title", "subject": "Subject", "description": "Description",
+	"keywords": "Keywords", "category": "Category", "contentStatus": "ContentStatus",
+	"identifier": "Identifier", "language": "Language", "version": "Version",
+}
+
+// extended (app.xml) properties renamed to match the other formats
+var ooxmlAppNames = map[string]string{
+	"Application": "Software",
+	"TotalTime":   "TotalEditTime",
+}
+
+// the package relationships, from _rels/.rels
+type ooxmlRelationships struct {
+	Relationships []struct {
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// the part a package relationship of the given type points to, or ""
+func (rels *ooxmlRelationships) target(kind string) string {
+	for _, rel := range rels.Relationships {
+		if strings.HasSuffix(rel.Type, kind) {
+			return strings.TrimPrefix(path.Clean("/"+rel.Target), "/")
+		}
+	}
+	return ""
+}
+
+// "docx", "xlsx" or "pptx" for a ZIP holding an Office Open XML
+// document, "" otherwise
+func OOXMLDocumentType(path string) string {
+	parts, err := readZipParts(path, ooxmlContentTypes, "_rels/.rels")
+	if err != nil || parts[ooxmlContentTypes] == nil {
+		return ""
+	}
+	var rels ooxmlRelationships
+	if xml.Unmarshal(parts["_rels/.rels"], &rels) != nil {
+		return ""
+	}
+	return ooxmlApplication(rels.target(relOfficeDocument))
+}
+
+// the application of a main document part, by its folder
+func ooxmlApplication(main string) string {
+	switch {
+	case strings.HasPrefix(main, "word/"):
+		return "docx"
+	case strings.HasPrefix(main, "xl/"):
+		return "xlsx"
+	case strings.HasPrefix(main, "ppt/"):
+		return "pptx"
+	}
+	return ""
+}
+
+// the property parts of a document, "" where it has none
+type ooxmlPackage struct {
+	rels                    ooxmlRelationships
+	main, core, app, custom string
+	thumbnail               string
+	parts                   map[string][]byte
+}
+
+func openOOXML(path string) (*ooxmlPackage, error) {
+	parts, err := readZipParts(path, "_rels/.rels", ooxmlContentTypes)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &ooxmlPackage{}
+	if err := xml.Unmarshal(parts["_rels/.rels"], &pkg.rels); err != nil {
+		return nil, fmt.Errorf("invalid package relationships: %w", err)
+	}
+	pkg.main = pkg.rels.target(relOfficeDocument)
+	pkg.core = pkg.rels.target(relCoreProperties)
+	pkg.app = pkg.rels.target(relAppProperties)
+	pkg.custom = pkg.rels.target(relCustomProperties)
+	pkg.thumbnail = pkg.rels.target(relThumbnail)
+
+	var names []string
+	for _, name := range []string{pkg.core, pkg.app, pkg.custom} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	if pkg.parts, err = readZipParts(path, names...); err != nil {
+		return nil, err
+	}
+	pkg.parts["_rels/.rels"] = parts["_rels/.rels"]
+	pkg.parts[ooxmlContentTypes] = parts[ooxmlContentTypes]
+	return pkg, nil
+}
+
+// core, extended and custom document properties
+func extractOOXMLMetadata(path string) (map[string]any, error) {
+	pkg, err := openOOXML(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]any)
+	if data := pkg.parts[pkg.core]; data != nil {
+		_, children, err := xmlChildren(data, 1)
+		if err != nil {
+			return nil, fmt.Errorf("core properties: %w", err)
+		}
+		for _, child := range children {
+			name, value := ooxmlCoreNames[child.local], strings.TrimSpace(child.text)
+			if name == "" {
+				name = child.local
+			}
+			if child.local == "created" || child.local == "modified" || child.local == "lastPrinted" {
+				value = ooxmlDate(value)
+			}
+			if value != "" {
+				metadata[name] = value
+			}
+		}
+	}
+
+	if data := pkg.parts[pkg.app]; data != nil {
+		_, children, err := xmlChildren(data, 1)
+		if err != nil {
+			return nil, fmt.Errorf("extended properties: %w", err)
+		}
+		for _, child := range children {
+			value := strings.TrimSpace(child.text)
+			if child.nested || value == "" {
+				continue // HeadingPairs and TitlesOfParts only list sheets and slides
+			}
+			name := child.local
+			if renamed, ok := ooxmlAppNames[name]; ok {
+				name = renamed
+			}
+			if child.local == "TotalTime" {
+				value = strings.TrimSuffix(ooxmlMinutes(value), "0s")
+			}
+			if value != "" {
+				metadata[name] = value
+			}
+		}
+	}
+
+	if data := pkg.parts[pkg.custom]; data != nil {
+		_, children, err := xmlChildren(data, 1)
+		if err != nil {
+			return nil, fmt.Errorf("custom properties: %w", err)
+		}
+		for _, child := range children {
+			if name, value := child.attr("name"), strings.TrimSpace(child.text); name != "" && value != "" {
+				metadata[name] = value
+			}
+		}
+	}
+	return metadata, nil
+}
+
+// "2019-03-04T10:20:00+01:00" as "2019:03:04 10:20:00+01:00"; UTC dates,
+// which Office always writes, get no zone, as the profile gives them
+func ooxmlDate(value string) string {
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	if _, offset := date.Zone(); offset == 0 {
+		return date.Format("2006:01:02 15:04:05")
+	}
+	return date.Format("2006:01:02 15:04:05-07:00")
+}
+
+// TotalTime counts minutes
+func ooxmlMinutes(value string) string {
+	var minutes int
+	if _, err := fmt.Sscan(value, &minutes); err != nil || minutes <= 0 {
+		return ""
+	}
+	return (time.Duration(minutes) * time.Minute).String()
+}
+
+// empty property parts, declaring the namespaces injection needs
+const (
+	emptyCoreProperties = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<cp:coreProperties xmlns:cp="` + nsCoreProperties + `" xmlns:dc="` + nsDC + `" xmlns:dcterms="` + nsDCTerms +
+		`" xmlns:dcmitype="http://purl.org/dc/dcmitype/" xmlns:xsi="` + nsXSI + `"></cp:coreProperties>`
+	emptyAppProperties = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<Properties xmlns="` + nsAppProperties + `" xmlns:vt="` + nsDocPropsVTypes + `"></Properties>`
+	emptyCustomProperties = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<Properties xmlns="` + nsCustomProperties + `" xmlns:vt="` + nsDocPropsVTypes + `"></Properties>`
+)
+
+// empties the property parts and clears the timestamps of every entry
+func wipeOOXMLMetadata(path string) error {
+	pkg, err := openOOXML(path)
+	if err != nil {
+		return err
+	}
+
+	replace := make(map[string][]byte)
+	for _, part := range [][2]string{
+		{pkg.core, emptyCoreProperties},
+		{pkg.app, emptyAppProperties},
+		{pkg.custom, emptyCustomProperties},
+	} {
+		if pkg.parts[part[0]] != nil {
+			replace[part[0]] = []byte(part[1])
+		}
+	}
+	return rewriteZip(path, replace, nil)
+}
+
+// standard prefixes, declared on the element when the part binds none
+var ooxmlPrefixes = map[string]string{
+	nsCoreProperties: "cp", nsDC: "dc", nsDCTerms: "dcterms", nsXSI: "xsi",
+}
+
+// a property element to write: name under the prefix bound to namespace
+type ooxmlProperty struct {
+	namespace, local, value string
+	typed                   bool // carries xsi:type="dcterms:W3CDTF"
+}
+
+// sets core and extended properties from the profile: author as creator
+// and last modifier, with the revision reset to 1
+func injectOOXMLMetadata(path string, profile map[string]string) error {
+	pkg, err := openOOXML(path)
+	if err != nil {
+		return err
+	}
+
+	var core, app []ooxmlProperty
+	for _, key := range sortedKeys(profile) {
+		value := profile[key]
+		switch strings.ToLower(key) {
+		case "author":
+			core = append(core,
+				ooxmlProperty{namespace: nsDC, local: "creator", value: value},
+				ooxmlProperty{namespace: nsCoreProperties, local: "lastModifiedBy", value: value})
+		case "comment":
+			core = append(core, ooxmlProperty{namespace: nsDC, local: "description", value: value})
+		case "created":
+			date, err := time.Parse("2006:01:02 15:04:05", value)
+			if err != nil {
+				return fmt.Errorf("invalid date %q: %w", value, err)
+			}
+			core = append(core, ooxmlProperty{namespace: nsDCTerms, local: "created", value: date.Format("2006-01-02T15:04:05Z"), typed: true})
+		case "software":
+			app = append(app, ooxmlProperty{namespace: nsAppProperties, local: "Application", value: value})
+		case "organization":
+			app = append(app, ooxmlProperty{namespace: nsAppProperties, local: "Company", value: value})
+		}
+	}
+	if len(core) > 0 {
+		core = append(core, ooxmlProperty{namespace: nsCoreProperties, local: "revision", value: "1"})
+	}
+
+	replace, add := make(map[string][]byte), make(map[string][]byte)
+	for _, part := range []struct {
+		name, fallback, empty, rel, contentType string
+		props                                   []ooxmlProperty
+	}{
+		{pkg.core, "docProps/core.xml", emptyCoreProperties,
+			"http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties",
+			"application/vnd.openxmlformats-package.core-properties+xml", core},
+		{pkg.app, "docProps/app.xml", emptyAppProperties,
+			"http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties",
+			"application/vnd.openxmlformats-officedocument.extended-properties+xml", app},
+	} {
+		if len(part.props) == 0 {
+			continue
+		}
+		data, name := pkg.parts[part.name], part.name
+		if data == nil {
+			// no such part yet: add it, with its relationship and content type
+			data, name = []byte(part.empty), part.fallback
+			rels, types, err := pkg.register(name, part.rel, part.contentType)
+			if err != nil {
+				return err
+			}
+			replace["_rels/.rels"], replace[ooxmlContentTypes] = rels, types
+			pkg.parts["_rels/.rels"], pkg.parts[ooxmlContentTypes] = rels, types
+		}
+		edited, err := setOOXMLProperties(data, part.props)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if part.name == "" {
+			add[name] = edited
+		} else {
+			replace[name] = edited
+		}
+	}
+	return rewriteZip(path, replace, add)
+}
+
+// replaces the properties in a property part, keeping everything else
+func setOOXMLProperties(data []byte, props []ooxmlProperty) ([]byte, error) {
+	root, children, err := xmlChildren(data, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var markup strings.Builder
+	for _, p := range props {
+		name, decl := root.nameIn(p.namespace, p.local, ooxmlPrefixes)
+		if p.typed {
+			xsi, xsiDecl := root.nameIn(nsXSI, "type", ooxmlPrefixes)
+			terms, termsDecl := root.nameIn(nsDCTerms, "W3CDTF", ooxmlPrefixes)
+			if termsDecl == decl {
+				termsDecl = ""
+			}
+			decl += xsiDecl + termsDecl + ` ` + xsi + `="` + terms + `"`
+		}
+		fmt.Fprintf(&markup, "<%s%s>%s</%s>", name, decl, xmlEscape(p.value), name)
+	}
+
+	set := func(child xmlElement) bool {
+		return slices.ContainsFunc(props, func(p ooxmlProperty) bool { return p.local == child.local })
+	}
+	return editXMLChildren(data, root, children, set, markup.String()), nil
+}
+
+// adds a package relationship and a content type override for a new part
+func (pkg *ooxmlPackage) register(name, relType, contentType string) ([]byte, []byte, error) {
+	rels, types := string(pkg.parts["_rels/.rels"]), string(pkg.parts[ooxmlContentTypes])
+	end, typesEnd := strings.LastIndex(rels, "</Relationships>"), strings.LastIndex(types, "</Types>")
+	if end < 0 || typesEnd < 0 {
+		return nil, nil, fmt.Errorf("cannot add %s to the package", name)
+	}
+
+	id := 1
+	for strings.Contains(rels, fmt.Sprintf(`Id="rId%d"`, id)) {
+		id++
+	}
+	rels = rels[:end] + fmt.Sprintf(`<Relationship Id="rId%d" Type="%s" Target="%s"/>`, id, relType, name) + rels[end:]
+	types = types[:typesEnd] + fmt.Sprintf(`<Override PartName="/%s" ContentType="%s"/>`, name, contentType) + types[typesEnd:]
+	return []byte(rels), []byte(types), nil
+}
+
+// lists the thumbnail, embedded objects and custom XML data
+func listOOXMLEmbedded(path string) ([]Embedded, error) {
+	pkg, err := openOOXML(path)
+	if err != nil {
+		return nil, err
+	}
+	names, err := listZipParts(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded []Embedded
+	for _, name := range names {
+		switch {
+		case name == pkg.thumbnail:
+			embedded = append(embedded, Embedded{Kind: "thumbnail", Name: name, Detail: "preview of the first page"})
+		case strings.Contains(name, "/embeddings/"):
+			embedded = append(embedded, Embedded{Kind: "data", Name: name, Detail: "embedded object; its own properties are not wiped"})
+		case strings.HasPrefix(name, "customXml/item") && !strings.Contains(name, "Props"):
+			embedded = append(embedded, Embedded{Kind: "data", Name: name, Detail: "custom XML data, such as document library properties"})
+		}
+	}
+	return embedded, nil
+}
+
+// checks that the archive decompresses, the property parts parse and the
+// main document part is there
+func verifyOOXML(path string) bool {
+	if !verifyZip(path) {
+		return false
+	}
+	pkg, err := openOOXML(path)
+	if err != nil || ooxmlApplication(pkg.main) == "" {
+		return false
+	}
+	for _, name := range []string{pkg.core, pkg.app, pkg.custom} {
+		if data := pkg.parts[name]; data != nil {
+			if _, _, err := xmlChildren(data, 1); err != nil {
+				return false
+			}
+		}
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	defer archive.Close()
+	return slices.ContainsFunc(archive.File, func(file *zip.File) bool { return file.Name == pkg.main })
+}
@@ -0,0 +1,278 @@
+// BYZRA ⸻ internal/formats/orientation.go
+// applying the EXIF orientation to the image itself, so the tag can go
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"caligra/internal/util"
+)
+
+const tiffTagOrientation = 0x0112
+
+// jpegtran transforms for orientations 2-8
+var orientationTransforms = map[int][]string{
+	2: {"-flip", "horizontal"},
+	3: {"-rotate", "180"},
+	4: {"-flip", "vertical"},
+	5: {"-transpose"},
+	6: {"-rotate", "90"},
+	7: {"-transverse"},
+	8: {"-rotate", "270"},
+}
+
+var orientationNames = map[int]string{
+	2: "mirrored horizontally",
+	3: "rotated 180°",
+	4: "mirrored vertically",
+	5: "transposed",
+	6: "rotated 90° clockwise",
+	7: "transversed",
+	8: "rotated 90° counter-clockwise",
+}
+
+// the IFD0 orientation (1-8) and the file offset of its value; 1 and -1
+// when the file has none
+func findOrientation(data []byte) (int, int) {
+	view := exifView(data)
+	if view == nil {
+		return 1, -1
+	}
+	ifd0, count, ok := view.ifd(view.order.Uint32(view.data[view.base+4:]))
+	if !ok {
+		return 1, -1
+	}
+	for i := range count {
+		tag, kind, n, value := view.entry(ifd0, i)
+		if tag == tiffTagOrientation && kind == 3 && n == 1 {
+			o := int(view.order.Uint16(view.data[value:]))
+			if o < 1 || o > 8 {
+				return 1, -1
+			}
+			return o, value
+		}
+	}
+	return 1, -1
+}
+
+// sets the orientation tag to 1 (as stored) in place, so nothing rotates
+// the pixels twice
+func resetOrientation(data []byte) {
+	if o, at := findOrientation(data); o != 1 {
+		view := exifView(data)
+		view.order.PutUint16(data[at:], 1)
+	}
+}
+
+// bakes the EXIF orientation into a JPEG or PNG and resets the tag; returns
+// what was done, or "" when the format has no lossless path
+func applyOrientation(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	o, _ := findOrientation(data)
+	if o == 1 {
+		return "upright already", nil
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, jpegSOI}):
+		trimmed, err := transformJPEG(path, orientationTransforms[o])
+		if err != nil {
+			return "", err
+		}
+		if trimmed {
+			return orientationNames[o] + " losslessly (partial edge blocks trimmed)", nil
+		}
+		return orientationNames[o] + " losslessly", nil
+	case bytes.HasPrefix(data, []byte(pngSignature)):
+		if err := orientPNG(path, data, o); err != nil {
+			return "", err
+		}
+		return orientationNames[o] + " (pixels, lossless)", nil
+	}
+	return "", nil
+}
+
+// runs jpegtran into a temp file and resets the tag it copied over
+func transformJPEG(path string, transform []string) (bool, error) {
+	if err := util.RequireTool("jpegtran"); err != nil {
+		return false, err
+	}
+	tmp := filepath.Join(filepath.Dir(path), ".caligra-rotate-"+filepath.Base(path))
+	defer os.Remove(tmp)
+
+	trimmed, err := util.JpegtranTransform(path, tmp, transform)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(tmp)
+	if err != nil {
+		return false, err
+	}
+	resetOrientation(data)
+	return trimmed, replaceFile(path, data)
+}
+
+// ╭─ PIXELS ────────────────────────────────────╮
+
+// a copy of img as it should be displayed, in the same pixel type where
+// Go's decoders produce one, so no colour is converted
+func orientImage(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	bounds := image.Rect(0, 0, w, h)
+	if o >= 5 {
+		bounds = image.Rect(0, 0, h, w)
+	}
+
+	var dst draw.Image
+	switch src := img.(type) {
+	case *image.Paletted:
+		dst = image.NewPaletted(bounds, src.Palette)
+	case *image.Gray:
+		dst = image.NewGray(bounds)
+	case *image.Gray16:
+		dst = image.NewGray16(bounds)
+	case *image.RGBA:
+		dst = image.NewRGBA(bounds)
+	case *image.RGBA64:
+		dst = image.NewRGBA64(bounds)
+	case *image.NRGBA64:
+		dst = image.NewNRGBA64(bounds)
+	default:
+		dst = image.NewNRGBA(bounds)
+	}
+
+	for y := range bounds.Dy() {
+		for x := range bounds.Dx() {
+			var sx, sy int
+			switch o {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// ╭─ PNG ───────────────────────────────────────╮
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// chunks that describe the pixels, taken from the re-encoded image
+var pngPixelChunks = map[string]bool{"IHDR": true, "PLTE": true, "tRNS": true, "IDAT": true, "IEND": true}
+
+type pngChunk struct {
+	kind   string
+	data   []byte
+	offset int // of data, in the file
+}
+
+// chunks in file order; nil when the data is not a well-formed PNG
+func pngChunks(data []byte) []pngChunk {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil
+	}
+	var chunks []pngChunk
+	for off := len(pngSignature); off+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[off:]))
+		if length < 0 || off+12+length > len(data) {
+			return nil
+		}
+		chunks = append(chunks, pngChunk{kind: string(data[off+4 : off+8]), data: data[off+8 : off+8+length], offset: off + 8})
+		off += 12 + length
+	}
+	return chunks
+}
+
+// rotates the pixels and splices them between the original ancillary
+// chunks (ICC profile, text, eXIf), which survive unchanged apart from the
+// orientation tag
+func orientPNG(path string, data []byte, o int) error {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode PNG: %w", err)
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, orientImage(img, o)); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	pixels := pngChunks(encoded.Bytes())
+
+	resetOrientation(data)
+
+	// each new pixel chunk takes the place of the first old one that may
+	// stand there, so ancillary chunks keep their required order
+	slots := map[string][]string{
+		"IHDR": {"IHDR"},
+		"PLTE": {"PLTE", "tRNS", "IDAT"},
+		"tRNS": {"tRNS", "IDAT"},
+		"IDAT": {"IDAT"},
+	}
+	written := make(map[string]bool)
+	emit := func(out *bytes.Buffer, at string) {
+		var done []string
+		for _, pixel := range pixels {
+			if !written[pixel.kind] && slices.Contains(slots[pixel.kind], at) {
+				writePNGChunk(out, pixel)
+				done = append(done, pixel.kind)
+			}
+		}
+		for _, kind := range done {
+			written[kind] = true
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString(pngSignature)
+	for _, chunk := range pngChunks(data) {
+		if pngPixelChunks[chunk.kind] {
+			if chunk.kind != "IEND" {
+				emit(&out, chunk.kind)
+			}
+			continue
+		}
+		writePNGChunk(&out, chunk)
+	}
+	writePNGChunk(&out, pngChunk{kind: "IEND"})
+
+	return replaceFile(path, out.Bytes())
+}
+
+func writePNGChunk(out *bytes.Buffer, chunk pngChunk) {
+	binary.Write(out, binary.BigEndian, uint32(len(chunk.data)))
+	out.WriteString(chunk.kind)
+	out.Write(chunk.data)
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunk.kind))
+	crc.Write(chunk.data)
+	binary.Write(out, binary.BigEndian, crc.Sum32())
+}
@@ -0,0 +1,475 @@
+// BYZRA ⸻ internal/formats/pdf.go
+// PDF format handler implementation
+
+package formats
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// implements FormatHandler for PDF documents
+type PDFHandler struct{}
+
+var errPDFEncrypted = errors.New("encrypted PDF documents are not supported")
+
+// Info dictionary keys exiftool renames
+var pdfInfoNames = map[pdfName]string{
+	"CreationDate": "CreateDate",
+	"ModDate":      "ModifyDate",
+}
+
+// reads and parses the PDF at path
+func openPDF(path string) (*pdfFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	f, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := f.trailer["Encrypt"]; ok {
+		return nil, errPDFEncrypted
+	}
+	if f.catalog() == nil {
+		return nil, fmt.Errorf("%w: document catalog is missing", errPDFSyntax)
+	}
+	return f, nil
+}
+
+func (f *pdfFile) catalog() pdfDict {
+	catalog, _ := f.resolve(f.trailer["Root"]).(pdfDict)
+	return catalog
+}
+
+// extracts the Info dictionary, the document ID and the XMP packet
+func (h *PDFHandler) ExtractMetadata(path string) (map[string]any, error) {
+	f, err := openPDF(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]any{"PDFVersion": f.version}
+	if version := f.catalog().name("Version"); string(version) > f.version {
+		metadata["PDFVersion"] = string(version)
+	}
+	if pages := len(f.pages()); pages > 0 {
+		metadata["PageCount"] = fmt.Sprint(pages)
+	}
+
+	if info, ok := f.resolve(f.trailer["Info"]).(pdfDict); ok {
+		for key, value := range info {
+			text := pdfText(f.resolve(value))
+			if key == "CreationDate" || key == "ModDate" {
+				text = pdfDate(text)
+			}
+			name := string(key)
+			if renamed, ok := pdfInfoNames[key]; ok {
+				name = renamed
+			}
+			if text != "" {
+				metadata[name] = text
+			}
+		}
+	}
+	if id, ok := f.resolve(f.trailer["ID"]).(pdfArray); ok && len(id) > 0 {
+		if first, ok := f.resolve(id[0]).(pdfString); ok && len(first) > 0 {
+			metadata["DocumentID"] = hex.EncodeToString(first)
+		}
+	}
+
+	// the Info dictionary wins where both name a field
+	if stream, ok := f.resolve(f.catalog()["Metadata"]).(*pdfStream); ok {
+		if data, err := f.decode(stream); err == nil {
+			for key, value := range parseXMP(data) {
+				if _, ok := metadata[key]; !ok {
+					metadata[key] = value
+				}
+			}
+		}
+	}
+	return metadata, nil
+}
+
+// rewrites the document without its Info dictionary, XMP packets,
+// document ID and earlier revisions
+func (h *PDFHandler) WipeMetadata(path string) error {
+	f, err := openPDF(path)
+	if err != nil {
+		return err
+	}
+	return replaceFile(path, f.rewrite(nil))
+}
+
+// adds profile metadata as an Info dictionary
+func (h *PDFHandler) InjectMetadata(path string, profile map[string]string) error {
+	f, err := openPDF(path)
+	if err != nil {
+		return err
+	}
+
+	info := make(pdfDict)
+	if old, ok := f.resolve(f.trailer["Info"]).(pdfDict); ok {
+		for key, value := range old {
+			switch value := f.resolve(value).(type) {
+			case pdfString, pdfName, pdfNumber, bool:
+				info[key] = value
+			}
+		}
+	}
+	for _, key := range sortedKeys(profile) {
+		name := mapProfileKeyToPDFKey(key)
+		if name == "" {
+			continue
+		}
+		value := profile[key]
+		if name == "CreationDate" {
+			date, err := time.Parse("2006:01:02 15:04:05", value)
+			if err != nil {
+				return fmt.Errorf("invalid date %q: %w", value, err)
+			}
+			value = date.Format("D:20060102150405")
+		}
+		info[name] = pdfTextString(value)
+	}
+	return replaceFile(path, f.rewrite(info))
+}
+
+// ensures the document still parses and has pages
+func (h *PDFHandler) VerifyIntegrity(path string) bool {
+	f, err := openPDF(path)
+	if err != nil {
+		return false
+	}
+	return len(f.pages()) > 0
+}
+
+// lists earlier revisions, attached files and signatures
+func (h *PDFHandler) ListEmbedded(path string) ([]Embedded, error) {
+	f, err := openPDF(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded []Embedded
+	if f.updates > 0 {
+		embedded = append(embedded, Embedded{
+			Kind:   "revision",
+			Name:   "earlier revisions",
+			Detail: fmt.Sprintf("incremental saves: %d; each keeps the version before it, metadata included; wiping drops them", f.updates),
+		})
+	}
+	attach := func(name string, spec any) {
+		spec = f.resolve(spec)
+		dict, _ := spec.(pdfDict)
+		if file := pdfText(f.resolve(dict["UF"])); file != "" {
+			name = file
+		} else if file := pdfText(f.resolve(dict["F"])); file != "" && name == "" {
+			name = file
+		}
+		detail := "attached file; its own metadata is not wiped"
+		if files, ok := f.resolve(dict["EF"]).(pdfDict); ok {
+			if stream, ok := f.resolve(files["F"]).(*pdfStream); ok {
+				detail = fmt.Sprintf("attached file, %d bytes stored; its own metadata is not wiped", len(stream.data))
+			}
+		}
+		embedded = append(embedded, Embedded{Kind: "attachment", Name: name, Detail: detail})
+	}
+
+	if names, ok := f.resolve(f.catalog()["Names"]).(pdfDict); ok {
+		for _, entry := range f.nameTree(names["EmbeddedFiles"]) {
+			attach(pdfText(entry[0]), entry[1])
+		}
+	}
+	for _, page := range f.pages() {
+		annots, _ := f.resolve(page["Annots"]).(pdfArray)
+		for _, annot := range annots {
+			if dict, ok := f.resolve(annot).(pdfDict); ok && dict.name("Subtype") == "FileAttachment" {
+				attach("", dict["FS"])
+			}
+		}
+	}
+
+	if form, ok := f.resolve(f.catalog()["AcroForm"]).(pdfDict); ok {
+		for _, field := range f.formFields(form["Fields"]) {
+			if field.name("FT") != "Sig" {
+				continue
+			}
+			signature, ok := f.resolve(field["V"]).(pdfDict)
+			if !ok {
+				continue
+			}
+			detail := "digital signature; wiping rewrites the file and invalidates it"
+			if signer := pdfText(f.resolve(signature["Name"])); signer != "" {
+				detail = "signed by " + signer + "; wiping rewrites the file and invalidates the signature"
+			}
+			name := pdfText(f.resolve(field["T"]))
+			if name == "" {
+				name = "signature"
+			}
+			embedded = append(embedded, Embedded{Kind: "signature", Name: name, Detail: detail, Critical: true})
+		}
+	}
+	return embedded, nil
+}
+
+// maps profile keys to Info dictionary keys
+func mapProfileKeyToPDFKey(key string) pdfName {
+	switch strings.ToLower(key) {
+	case "author":
+		return "Author"
+	case "comment":
+		return "Subject"
+	case "created":
+		return "CreationDate"
+	case "software":
+		return "Producer"
+	case "organization":
+		return "Company"
+	default:
+		return ""
+	}
+}
+
+// ╭─ DOCUMENT STRUCTURE ────────────────────────╮
+
+// the leaves of the page tree, in order
+func (f *pdfFile) pages() []pdfDict {
+	var pages []pdfDict
+	seen := make(map[pdfRef]bool)
+	var walk func(node any, depth int)
+	walk = func(node any, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if seen[ref] {
+				return
+			}
+			seen[ref] = true
+		}
+		dict, ok := f.resolve(node).(pdfDict)
+		if !ok || depth > pdfMaxDepth {
+			return
+		}
+		kids, ok := f.resolve(dict["Kids"]).(pdfArray)
+		if !ok {
+			pages = append(pages, dict)
+			return
+		}
+		for _, kid := range kids {
+			walk(kid, depth+1)
+		}
+	}
+	walk(f.catalog()["Pages"], 0)
+	return pages
+}
+
+// the key and value pairs of a name tree
+func (f *pdfFile) nameTree(root any) [][2]any {
+	var entries [][2]any
+	seen := make(map[pdfRef]bool)
+	var walk func(node any, depth int)
+	walk = func(node any, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if seen[ref] {
+				return
+			}
+			seen[ref] = true
+		}
+		dict, ok := f.resolve(node).(pdfDict)
+		if !ok || depth > pdfMaxDepth {
+			return
+		}
+		names, _ := f.resolve(dict["Names"]).(pdfArray)
+		for i := 0; i+1 < len(names); i += 2 {
+			entries = append(entries, [2]any{f.resolve(names[i]), names[i+1]})
+		}
+		kids, _ := f.resolve(dict["Kids"]).(pdfArray)
+		for _, kid := range kids {
+			walk(kid, depth+1)
+		}
+	}
+	walk(root, 0)
+	return entries
+}
+
+// the terminal fields of an interactive form; kids inherit /FT
+func (f *pdfFile) formFields(fields any) []pdfDict {
+	var found []pdfDict
+	seen := make(map[pdfRef]bool)
+	var walk func(node any, kind pdfName, depth int)
+	walk = func(node any, kind pdfName, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if seen[ref] {
+				return
+			}
+			seen[ref] = true
+		}
+		dict, ok := f.resolve(node).(pdfDict)
+		if !ok || depth > pdfMaxDepth {
+			return
+		}
+		if own := dict.name("FT"); own != "" {
+			kind = own
+		}
+		if kids, ok := f.resolve(dict["Kids"]).(pdfArray); ok {
+			for _, kid := range kids {
+				walk(kid, kind, depth+1)
+			}
+			return
+		}
+		field := make(pdfDict, len(dict)+1)
+		for key, value := range dict {
+			field[key] = value
+		}
+		field["FT"] = kind
+		found = append(found, field)
+	}
+	array, _ := f.resolve(fields).(pdfArray)
+	for _, field := range array {
+		walk(field, "", 0)
+	}
+	return found
+}
+
+// ╭─ TEXT AND DATES ────────────────────────────╮
+
+// a text string: UTF-16BE or UTF-8 after a byte order mark, otherwise
+// PDFDocEncoding, read as Latin-1
+func pdfText(value any) string {
+	switch v := value.(type) {
+	case pdfString:
+		switch {
+		case bytes.HasPrefix(v, []byte{0xFE, 0xFF}):
+			return decodeID3Text(2, v)
+		case bytes.HasPrefix(v, []byte{0xEF, 0xBB, 0xBF}):
+			return decodeID3Text(3, v[3:])
+		}
+		return decodeID3Text(0, v)
+	case pdfName:
+		return string(v)
+	case pdfNumber:
+		return string(v)
+	case bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// a text string that can hold value: ASCII as is, anything else UTF-16BE
+func pdfTextString(value string) pdfString {
+	if strings.IndexFunc(value, func(r rune) bool { return r > unicode.MaxASCII }) < 0 {
+		return pdfString(value)
+	}
+	out := []byte{0xFE, 0xFF}
+	for _, unit := range utf16.Encode([]rune(value)) {
+		out = append(out, byte(unit>>8), byte(unit))
+	}
+	return out
+}
+
+// "D:20190304102000+01'00'" as "2019:03:04 10:20:00+01:00"; fields
+// left out default as the spec says, and unreadable dates are kept
+func pdfDate(value string) string {
+	raw := strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	digits := len(raw) - len(strings.TrimLeft(raw, "0123456789"))
+	if digits < 4 || digits > 14 || digits%2 != 0 {
+		return value
+	}
+	date := raw[:digits] + "0101000000"[digits-4:]
+	out := fmt.Sprintf("%s:%s:%s %s:%s:%s", date[0:4], date[4:6], date[6:8], date[8:10], date[10:12], date[12:14])
+
+	zone := strings.ReplaceAll(raw[digits:], "'", "")
+	switch {
+	case zone == "Z", strings.HasPrefix(zone, "Z"):
+		return out + "Z"
+	case len(zone) >= 3 && (zone[0] == '+' || zone[0] == '-'):
+		minutes := "00"
+		if len(zone) >= 5 {
+			minutes = zone[3:5]
+		}
+		return out + zone[:3] + ":" + minutes
+	}
+	return out
+}
+
+// ╭─ XMP ───────────────────────────────────────╮
+
+const rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// the properties of an XMP packet by local name, as exiftool flattens
+// them: attributes of rdf:Description and the text under each property,
+// with list items joined
+func parseXMP(data []byte) map[string]string {
+	values := make(map[string][]string)
+	var order []string
+	add := func(name, value string) {
+		value = strings.TrimSpace(value)
+		if name == "" || value == "" || !utf8.ValidString(value) {
+			return
+		}
+		r, size := utf8.DecodeRuneInString(name)
+		name = string(unicode.ToUpper(r)) + name[size:]
+		if _, ok := values[name]; !ok {
+			order = append(order, name)
+		}
+		values[name] = append(values[name], value)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	var stack []xml.Name
+	// the property being read: the element right under the outermost
+	// rdf:Description
+	property := func() string {
+		for i, name := range stack {
+			if name.Space == rdfNamespace && name.Local == "Description" && i+1 < len(stack) {
+				return stack[i+1].Local
+			}
+		}
+		return ""
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name)
+			outer := property()
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" || attr.Name.Space == rdfNamespace ||
+					attr.Name.Space == "xml" || attr.Name.Space == "http://www.w3.org/XML/1998/namespace" {
+					continue
+				}
+				switch {
+				case outer != "":
+					add(outer, attr.Value)
+				case t.Name.Space == rdfNamespace && t.Name.Local == "Description":
+					add(attr.Name.Local, attr.Value)
+				}
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			add(property(), string(t))
+		}
+	}
+
+	fields := make(map[string]string, len(order))
+	for _, name := range order {
+		fields[name] = strings.Join(values[name], ", ")
+	}
+	return fields
+}
@@ -0,0 +1,681 @@
+// BYZRA ⸻ internal/formats/pdffile.go
+// PDF file structure: cross-reference tables, object streams and rewriting
+
+package formats
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// where an object lives: at a byte offset, or inside an object stream
+type pdfXref struct {
+	offset int
+	stream int // number of the object stream, 0 if none
+	index  int // position in the object stream
+	free   bool
+}
+
+// a PDF held in memory, read object by object through its cross-reference
+// sections, newest first
+type pdfFile struct {
+	data    []byte
+	version string
+	xref    map[int]pdfXref
+	trailer pdfDict
+	cache   map[int]any
+	streams map[int][]any // parsed object streams
+	scanned map[int]int   // object offsets found by scanning, when the xref lies
+	updates int           // incremental updates appended to the original
+}
+
+// largest decoded stream read into memory
+const pdfMaxDecoded = 256 << 20
+
+var pdfHeader = regexp.MustCompile(`%PDF-(\d\.\d)`)
+
+func parsePDF(data []byte) (*pdfFile, error) {
+	head := data[:min(len(data), 1024)]
+	match := pdfHeader.FindSubmatch(head)
+	if match == nil {
+		return nil, fmt.Errorf("%w: no %%PDF header", errPDFSyntax)
+	}
+	f := &pdfFile{
+		data:    data,
+		version: string(match[1]),
+		xref:    make(map[int]pdfXref),
+		cache:   make(map[int]any),
+		streams: make(map[int][]any),
+	}
+
+	if err := f.readXrefChain(); err != nil {
+		// a damaged or missing cross-reference: find the objects by scanning
+		f.xref, f.trailer = make(map[int]pdfXref), nil
+		if err := f.reconstruct(); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := f.trailer["Root"].(pdfRef); !ok {
+		return nil, fmt.Errorf("%w: no document catalog", errPDFSyntax)
+	}
+	return f, nil
+}
+
+// the offset after the last "startxref"
+func (f *pdfFile) startxref() (int, error) {
+	tail := max(0, len(f.data)-2048)
+	i := bytes.LastIndex(f.data[tail:], []byte("startxref"))
+	if i < 0 {
+		return 0, fmt.Errorf("%w: no startxref", errPDFSyntax)
+	}
+	lx := &pdfLexer{data: f.data, pos: tail + i + len("startxref")}
+	offset := lx.integer()
+	if offset < 0 || offset >= len(f.data) {
+		return 0, fmt.Errorf("%w: startxref out of range", errPDFSyntax)
+	}
+	return offset, nil
+}
+
+// reads every cross-reference section, following /Prev; entries from
+// newer sections shadow older ones, and the newest trailer wins
+func (f *pdfFile) readXrefChain() error {
+	offset, err := f.startxref()
+	if err != nil {
+		return err
+	}
+
+	f.trailer = make(pdfDict)
+	seen := make(map[int]bool)
+	for !seen[offset] {
+		seen[offset] = true
+		f.updates = len(seen) - 1
+		trailer, err := f.readXrefSection(offset)
+		if err != nil {
+			return err
+		}
+		for key, value := range trailer {
+			if _, ok := f.trailer[key]; !ok && key != "Prev" && key != "XRefStm" {
+				f.trailer[key] = value
+			}
+		}
+		prev, ok := pdfInt(trailer["Prev"])
+		if !ok {
+			break
+		}
+		if prev < 0 || prev >= len(f.data) {
+			return fmt.Errorf("%w: /Prev out of range", errPDFSyntax)
+		}
+		offset = prev
+	}
+	// a linearized file has a section of its own for the first page
+	if f.updates > 0 && bytes.Contains(f.data[:min(len(f.data), 1024)], []byte("/Linearized")) {
+		f.updates--
+	}
+	return nil
+}
+
+// reads a table or stream section at offset and returns its trailer
+func (f *pdfFile) readXrefSection(offset int) (pdfDict, error) {
+	lx := &pdfLexer{data: f.data, pos: offset}
+	if !lx.expect("xref") {
+		return f.readXrefStream(offset)
+	}
+
+	var entries [][2]int // object number, position of the entry
+	for {
+		save := lx.pos
+		first, count := lx.integer(), lx.integer()
+		if first < 0 || count < 0 {
+			lx.pos = save
+			break
+		}
+		for i := range count {
+			pos, gen := lx.integer(), lx.integer()
+			lx.skip()
+			kind := lx.word()
+			if pos < 0 || gen < 0 || (kind != "n" && kind != "f") {
+				return nil, fmt.Errorf("%w: bad xref entry", errPDFSyntax)
+			}
+			if kind == "f" {
+				pos = -1
+			}
+			entries = append(entries, [2]int{first + i, pos})
+		}
+	}
+	if !lx.expect("trailer") {
+		return nil, fmt.Errorf("%w: no trailer after xref table", errPDFSyntax)
+	}
+	value, err := lx.object(0)
+	if err != nil {
+		return nil, err
+	}
+	trailer, ok := value.(pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("%w: trailer is not a dictionary", errPDFSyntax)
+	}
+
+	// a hybrid file lists compressed objects in a stream beside the table
+	if stm, ok := pdfInt(trailer["XRefStm"]); ok && stm >= 0 && stm < len(f.data) {
+		if _, err := f.readXrefStream(stm); err != nil {
+			return nil, err
+		}
+	}
+	for _, entry := range entries {
+		if _, ok := f.xref[entry[0]]; !ok {
+			f.xref[entry[0]] = pdfXref{offset: entry[1], free: entry[1] < 0}
+		}
+	}
+	return trailer, nil
+}
+
+// reads a cross-reference stream (PDF 1.5) at offset; its dictionary is
+// the trailer
+func (f *pdfFile) readXrefStream(offset int) (pdfDict, error) {
+	_, value, err := f.parseIndirect(offset)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := value.(*pdfStream)
+	if !ok || stream.dict.name("Type") != "XRef" {
+		return nil, fmt.Errorf("%w: no xref at %d", errPDFSyntax, offset)
+	}
+	data, err := f.decode(stream)
+	if err != nil {
+		return nil, fmt.Errorf("xref stream: %w", err)
+	}
+
+	var widths [3]int
+	w, _ := stream.dict["W"].(pdfArray)
+	if len(w) != 3 {
+		return nil, fmt.Errorf("%w: bad /W in xref stream", errPDFSyntax)
+	}
+	for i := range widths {
+		n, ok := pdfInt(w[i])
+		if !ok || n < 0 || n > 8 {
+			return nil, fmt.Errorf("%w: bad /W in xref stream", errPDFSyntax)
+		}
+		widths[i] = n
+	}
+	size, _ := pdfInt(stream.dict["Size"])
+	index := pdfArray{pdfNumber("0"), pdfNumber(strconv.Itoa(size))}
+	if array, ok := stream.dict["Index"].(pdfArray); ok {
+		index = array
+	}
+
+	row := widths[0] + widths[1] + widths[2]
+	field := func(b []byte) int {
+		n := 0
+		for _, c := range b {
+			n = n<<8 | int(c)
+		}
+		return n
+	}
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		first, ok1 := pdfInt(index[i])
+		count, ok2 := pdfInt(index[i+1])
+		if !ok1 || !ok2 || first < 0 || count < 0 {
+			return nil, fmt.Errorf("%w: bad /Index in xref stream", errPDFSyntax)
+		}
+		for num := first; num < first+count && pos+row <= len(data); num++ {
+			entry := data[pos : pos+row]
+			pos += row
+			kind := 1
+			if widths[0] > 0 {
+				kind = field(entry[:widths[0]])
+			}
+			a, b := field(entry[widths[0]:widths[0]+widths[1]]), field(entry[widths[0]+widths[1]:])
+			if _, ok := f.xref[num]; ok {
+				continue
+			}
+			switch kind {
+			case 0:
+				f.xref[num] = pdfXref{free: true}
+			case 1:
+				f.xref[num] = pdfXref{offset: a}
+			case 2:
+				f.xref[num] = pdfXref{stream: a, index: b}
+			}
+		}
+	}
+	return stream.dict, nil
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(?m)(?:^|[\r\n\s])(\d+)[ \t\r\n\f\x00]+(\d+)[ \t\r\n\f\x00]+obj\b`)
+
+// rebuilds the cross-reference by finding every "n g obj"; the last
+// definition of an object wins, and the trailer is the last one found,
+// or the last cross-reference stream
+func (f *pdfFile) reconstruct() error {
+	f.scanned = make(map[int]int)
+	for _, match := range pdfObjectHeader.FindAllSubmatchIndex(f.data, -1) {
+		num, _ := strconv.Atoi(string(f.data[match[2]:match[3]]))
+		f.scanned[num] = match[2]
+		f.xref[num] = pdfXref{offset: match[2]}
+	}
+
+	f.trailer = make(pdfDict)
+	if i := bytes.LastIndex(f.data, []byte("trailer")); i >= 0 {
+		lx := &pdfLexer{data: f.data, pos: i + len("trailer")}
+		if value, err := lx.object(0); err == nil {
+			if dict, ok := value.(pdfDict); ok {
+				f.trailer = dict
+			}
+		}
+	}
+	if _, ok := f.trailer["Root"]; !ok {
+		for _, num := range slices.Sorted(maps.Keys(f.scanned)) {
+			if stream, ok := f.resolve(pdfRef{num, 0}).(*pdfStream); ok && stream.dict.name("Type") == "XRef" {
+				f.trailer = stream.dict
+			}
+		}
+	}
+	// the catalog, when no trailer names it
+	if _, ok := f.trailer["Root"]; !ok {
+		for _, num := range slices.Sorted(maps.Keys(f.xref)) {
+			if dict, ok := f.resolve(pdfRef{num, 0}).(pdfDict); ok && dict.name("Type") == "Catalog" {
+				f.trailer["Root"] = pdfRef{num, 0}
+			}
+		}
+	}
+	// objects in object streams are only listed by their stream
+	for _, num := range slices.Sorted(maps.Keys(f.scanned)) {
+		stream, ok := f.resolve(pdfRef{num, 0}).(*pdfStream)
+		if !ok || stream.dict.name("Type") != "ObjStm" {
+			continue
+		}
+		numbers, _, err := f.objectStreamIndex(stream)
+		if err != nil {
+			continue
+		}
+		for i, inner := range numbers {
+			if _, ok := f.xref[inner]; !ok {
+				f.xref[inner] = pdfXref{stream: num, index: i}
+			}
+		}
+	}
+	return nil
+}
+
+// ╭─ OBJECTS ───────────────────────────────────╮
+
+// parses "n g obj ... endobj" at offset, with the stream data if any
+func (f *pdfFile) parseIndirect(offset int) (int, any, error) {
+	lx := &pdfLexer{data: f.data, pos: offset}
+	num, gen := lx.integer(), lx.integer()
+	if num < 0 || gen < 0 || !lx.expect("obj") {
+		return 0, nil, fmt.Errorf("%w: no object at byte %d", errPDFSyntax, offset)
+	}
+	value, err := lx.object(0)
+	if err != nil {
+		return 0, nil, err
+	}
+	dict, ok := value.(pdfDict)
+	if !ok || !lx.expect("stream") {
+		return num, value, nil
+	}
+
+	// the data starts after the end of line that follows "stream"
+	start := lx.pos
+	if start < len(f.data) && f.data[start] == '\r' {
+		start++
+	}
+	if start < len(f.data) && f.data[start] == '\n' {
+		start++
+	}
+
+	length, ok := pdfInt(dict["Length"])
+	if ref, isRef := dict["Length"].(pdfRef); isRef && ref.num != num {
+		length, ok = pdfInt(f.resolve(ref))
+	}
+	end := start + length
+	if ok && length >= 0 && end <= len(f.data) {
+		after := &pdfLexer{data: f.data, pos: end}
+		if after.expect("endstream") {
+			return num, &pdfStream{dict: dict, data: f.data[start:end]}, nil
+		}
+	}
+
+	// a wrong /Length: the data runs to "endstream"
+	i := bytes.Index(f.data[start:], []byte("endstream"))
+	if i < 0 {
+		return 0, nil, fmt.Errorf("%w: stream %d has no end", errPDFSyntax, num)
+	}
+	data := f.data[start : start+i]
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	return num, &pdfStream{dict: dict, data: data}, nil
+}
+
+// the object a reference points to; missing and unreadable objects are
+// null, as readers treat them
+func (f *pdfFile) resolve(value any) any {
+	ref, ok := value.(pdfRef)
+	if !ok {
+		return value
+	}
+	if cached, ok := f.cache[ref.num]; ok {
+		return cached
+	}
+	f.cache[ref.num] = nil // guards against reference loops
+
+	object, err := f.load(ref.num)
+	if err != nil {
+		return nil
+	}
+	f.cache[ref.num] = object
+	return object
+}
+
+func (f *pdfFile) load(num int) (any, error) {
+	entry, ok := f.xref[num]
+	if !ok || entry.free {
+		return nil, fmt.Errorf("object %d is not in the file", num)
+	}
+	if entry.stream > 0 {
+		objects, err := f.objectStream(entry.stream)
+		if err != nil {
+			return nil, err
+		}
+		if entry.index >= len(objects) {
+			return nil, fmt.Errorf("object %d is not in its object stream", num)
+		}
+		return objects[entry.index], nil
+	}
+
+	found, object, err := f.parseIndirect(entry.offset)
+	if err == nil && found == num {
+		return object, nil
+	}
+	// the offset is off: look for the object itself
+	if f.scanned == nil {
+		f.scanned = make(map[int]int)
+		for _, match := range pdfObjectHeader.FindAllSubmatchIndex(f.data, -1) {
+			n, _ := strconv.Atoi(string(f.data[match[2]:match[3]]))
+			f.scanned[n] = match[2]
+		}
+	}
+	offset, ok := f.scanned[num]
+	if !ok {
+		return nil, fmt.Errorf("object %d not found", num)
+	}
+	_, object, err = f.parseIndirect(offset)
+	return object, err
+}
+
+// the objects of an object stream, in order
+func (f *pdfFile) objectStream(num int) ([]any, error) {
+	if objects, ok := f.streams[num]; ok {
+		return objects, nil
+	}
+	f.streams[num] = nil
+
+	stream, ok := f.resolve(pdfRef{num, 0}).(*pdfStream)
+	if !ok {
+		return nil, fmt.Errorf("object stream %d is missing", num)
+	}
+	_, offsets, err := f.objectStreamIndex(stream)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := f.decode(stream)
+	first, _ := pdfInt(stream.dict["First"])
+
+	objects := make([]any, len(offsets))
+	for i, offset := range offsets {
+		lx := &pdfLexer{data: data, pos: first + offset}
+		if lx.pos >= len(data) {
+			continue
+		}
+		if object, err := lx.object(0); err == nil {
+			objects[i] = object
+		}
+	}
+	f.streams[num] = objects
+	return objects, nil
+}
+
+// the object numbers and offsets an object stream starts with
+func (f *pdfFile) objectStreamIndex(stream *pdfStream) ([]int, []int, error) {
+	data, err := f.decode(stream)
+	if err != nil {
+		return nil, nil, fmt.Errorf("object stream: %w", err)
+	}
+	n, _ := pdfInt(stream.dict["N"])
+	first, _ := pdfInt(stream.dict["First"])
+	if n < 0 || first < 0 || first > len(data) {
+		return nil, nil, fmt.Errorf("%w: bad object stream header", errPDFSyntax)
+	}
+
+	lx := &pdfLexer{data: data[:first]}
+	var numbers, offsets []int
+	for range n {
+		num, offset := lx.integer(), lx.integer()
+		if num < 0 || offset < 0 {
+			return nil, nil, fmt.Errorf("%w: bad object stream header", errPDFSyntax)
+		}
+		numbers = append(numbers, num)
+		offsets = append(offsets, offset)
+	}
+	return numbers, offsets, nil
+}
+
+// the decoded data of a stream; only FlateDecode is supported, which is
+// what object, xref and metadata streams use
+func (f *pdfFile) decode(stream *pdfStream) ([]byte, error) {
+	var filters pdfArray
+	switch filter := f.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = pdfArray{filter}
+	case pdfArray:
+		filters = filter
+	}
+	var params pdfArray
+	switch p := f.resolve(stream.dict["DecodeParms"]).(type) {
+	case pdfDict:
+		params = pdfArray{p}
+	case pdfArray:
+		params = p
+	}
+
+	data := stream.data
+	for i, filter := range filters {
+		if f.resolve(filter) != pdfName("FlateDecode") {
+			return nil, fmt.Errorf("unsupported filter %v", filter)
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := io.ReadAll(io.LimitReader(r, pdfMaxDecoded))
+		if err != nil && len(decoded) == 0 {
+			return nil, err
+		}
+		data = decoded
+		if i < len(params) {
+			if p, ok := f.resolve(params[i]).(pdfDict); ok {
+				if data, err = unpredict(data, p); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return data, nil
+}
+
+// reverses the PNG predictors xref streams are usually written with
+func unpredict(data []byte, params pdfDict) ([]byte, error) {
+	predictor, _ := pdfInt(params["Predictor"])
+	if predictor < 10 {
+		if predictor > 1 {
+			return nil, fmt.Errorf("unsupported predictor %d", predictor)
+		}
+		return data, nil
+	}
+	columns, ok := pdfInt(params["Columns"])
+	if !ok {
+		columns = 1
+	}
+	colors, ok := pdfInt(params["Colors"])
+	if !ok {
+		colors = 1
+	}
+	bits, ok := pdfInt(params["BitsPerComponent"])
+	if !ok {
+		bits = 8
+	}
+	bpp := max(1, colors*bits/8)
+	stride := (columns*colors*bits + 7) / 8
+	if columns < 1 || stride < 1 || stride > len(data) {
+		return nil, fmt.Errorf("%w: bad predictor parameters", errPDFSyntax)
+	}
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, stride)
+	for pos := 0; pos+stride+1 <= len(data); pos += stride + 1 {
+		kind, row := data[pos], slices.Clone(data[pos+1:pos+1+stride])
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+			switch kind {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ╭─ REWRITING ─────────────────────────────────╮
+
+// keys dropped from every dictionary: XMP packets, application private
+// data, and the dates of pages, annotations and attached files
+var pdfMetadataKeys = []pdfName{"Metadata", "PieceInfo", "LastModified", "CreationDate", "ModDate"}
+
+// annotations whose /T is the name of their author, not a field name
+var pdfMarkupAnnotations = []pdfName{
+	"Text", "FreeText", "Line", "Square", "Circle", "Polygon", "PolyLine",
+	"Highlight", "Underline", "Squiggly", "StrikeOut", "Stamp", "Caret",
+	"Ink", "FileAttachment", "Sound", "Redact",
+}
+
+// writes the document anew from its catalog. Only the objects the catalog
+// reaches are kept, renumbered in the order they are found, so earlier
+// revisions, the Info dictionary and unused objects are left behind; the
+// trailer gets no /ID, and comments lose their author. info, if not nil, becomes the new Info dictionary
+func (f *pdfFile) rewrite(info pdfDict) []byte {
+	numbers := make(map[int]int)
+	var order []int
+	var clean func(value any) any
+	clean = func(value any) any {
+		switch v := value.(type) {
+		case pdfRef:
+			if f.resolve(v) == nil {
+				return nil
+			}
+			if _, ok := numbers[v.num]; !ok {
+				order = append(order, v.num)
+				numbers[v.num] = len(order)
+			}
+			return pdfRef{numbers[v.num], 0}
+		case pdfArray:
+			out := make(pdfArray, len(v))
+			for i, item := range v {
+				out[i] = clean(item)
+			}
+			return out
+		case pdfDict:
+			out := make(pdfDict, len(v))
+			markup := slices.Contains(pdfMarkupAnnotations, v.name("Subtype")) && v.name("Type") != "XObject"
+			for key, item := range v {
+				if markup && (key == "T" || key == "M") {
+					continue // the author and modification date of a comment
+				}
+				if !slices.Contains(pdfMetadataKeys, key) {
+					if item = clean(item); item != nil {
+						out[key] = item
+					}
+				}
+			}
+			return out
+		}
+		return value
+	}
+
+	out := fmt.Appendf(nil, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", f.version)
+	var offsets []int
+	root := clean(f.trailer["Root"])
+	for i := 0; i < len(order); i++ {
+		offsets = append(offsets, len(out))
+		out = fmt.Appendf(out, "%d 0 obj\n", i+1)
+		switch object := f.resolve(pdfRef{order[i], 0}).(type) {
+		case *pdfStream:
+			dict := maps.Clone(object.dict)
+			delete(dict, "Length")
+			dict = clean(dict).(pdfDict)
+			dict["Length"] = pdfNumber(strconv.Itoa(len(object.data)))
+			out = appendPDFObject(out, dict)
+			out = append(out, "\nstream\n"...)
+			out = append(out, object.data...)
+			out = append(out, "\nendstream"...)
+		default:
+			out = appendPDFObject(out, clean(object))
+		}
+		out = append(out, "\nendobj\n"...)
+	}
+
+	trailer := pdfDict{"Root": root}
+	if info != nil {
+		offsets = append(offsets, len(out))
+		trailer["Info"] = pdfRef{len(offsets), 0}
+		out = fmt.Appendf(out, "%d 0 obj\n", len(offsets))
+		out = appendPDFObject(out, info)
+		out = append(out, "\nendobj\n"...)
+	}
+	trailer["Size"] = pdfNumber(strconv.Itoa(len(offsets) + 1))
+
+	xref := len(out)
+	out = fmt.Appendf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		out = fmt.Appendf(out, "%010d 00000 n \n", offset)
+	}
+	out = append(out, "trailer\n"...)
+	out = appendPDFObject(out, trailer)
+	return fmt.Appendf(out, "\nstartxref\n%d\n%%%%EOF\n", xref)
+}
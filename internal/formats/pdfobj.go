@@ -0,0 +1,400 @@
+// BYZRA ⸻ internal/formats/pdfobj.go
+// PDF objects: parsing and writing the COS syntax
+
+package formats
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// PDF object values; null, true and false are nil and bool
+type (
+	pdfName   string
+	pdfString []byte
+	pdfNumber string // kept as written
+	pdfArray  []any
+	pdfDict   map[pdfName]any
+)
+
+// an indirect reference, "12 0 R"
+type pdfRef struct {
+	num, gen int
+}
+
+// a stream with its data as stored, still encoded
+type pdfStream struct {
+	dict pdfDict
+	data []byte
+}
+
+var errPDFSyntax = errors.New("invalid PDF syntax")
+
+// deepest nesting of arrays and dictionaries read
+const pdfMaxDepth = 256
+
+func isPDFSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isPDFRegular(c byte) bool {
+	return !isPDFSpace(c) && !isPDFDelimiter(c)
+}
+
+// reads objects from data starting at pos
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+// skips whitespace and comments
+func (lx *pdfLexer) skip() {
+	for lx.pos < len(lx.data) {
+		switch c := lx.data[lx.pos]; {
+		case isPDFSpace(c):
+			lx.pos++
+		case c == '%':
+			for lx.pos < len(lx.data) && lx.data[lx.pos] != '\n' && lx.data[lx.pos] != '\r' {
+				lx.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// the run of regular characters at pos
+func (lx *pdfLexer) word() string {
+	start := lx.pos
+	for lx.pos < len(lx.data) && isPDFRegular(lx.data[lx.pos]) {
+		lx.pos++
+	}
+	return string(lx.data[start:lx.pos])
+}
+
+// reads keyword after whitespace, or fails without moving
+func (lx *pdfLexer) expect(keyword string) bool {
+	save := lx.pos
+	lx.skip()
+	if lx.word() == keyword {
+		return true
+	}
+	lx.pos = save
+	return false
+}
+
+// a non-negative integer, or -1
+func (lx *pdfLexer) integer() int {
+	lx.skip()
+	word := lx.word()
+	n, err := strconv.Atoi(word)
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+func (lx *pdfLexer) object(depth int) (any, error) {
+	if depth > pdfMaxDepth {
+		return nil, fmt.Errorf("%w: nested too deeply", errPDFSyntax)
+	}
+	lx.skip()
+	if lx.pos >= len(lx.data) {
+		return nil, fmt.Errorf("%w: unexpected end of data", errPDFSyntax)
+	}
+
+	switch c := lx.data[lx.pos]; {
+	case c == '/':
+		lx.pos++
+		return lx.name(), nil
+	case c == '(':
+		lx.pos++
+		return lx.literal()
+	case c == '<' && lx.pos+1 < len(lx.data) && lx.data[lx.pos+1] == '<':
+		lx.pos += 2
+		return lx.dict(depth)
+	case c == '<':
+		lx.pos++
+		return lx.hex()
+	case c == '[':
+		lx.pos++
+		var array pdfArray
+		for {
+			lx.skip()
+			if lx.pos < len(lx.data) && lx.data[lx.pos] == ']' {
+				lx.pos++
+				return array, nil
+			}
+			item, err := lx.object(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+	case c >= '0' && c <= '9', c == '+', c == '-', c == '.':
+		start := lx.pos
+		number := lx.word()
+		if _, err := strconv.ParseFloat(number, 64); err != nil {
+			return nil, fmt.Errorf("%w: bad number %q at byte %d", errPDFSyntax, number, start)
+		}
+		// "12 0 R" reads as a reference
+		if num, err := strconv.Atoi(number); err == nil && num >= 0 {
+			save := lx.pos
+			if gen := lx.integer(); gen >= 0 && lx.expect("R") {
+				return pdfRef{num, gen}, nil
+			}
+			lx.pos = save
+		}
+		return pdfNumber(number), nil
+	}
+
+	start := lx.pos
+	switch word := lx.word(); word {
+	case "null":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "":
+		return nil, fmt.Errorf("%w: unexpected %q at byte %d", errPDFSyntax, lx.data[start], start)
+	default:
+		return nil, fmt.Errorf("%w: unexpected %q at byte %d", errPDFSyntax, word, start)
+	}
+}
+
+// a name after its slash, with #xx escapes decoded
+func (lx *pdfLexer) name() pdfName {
+	raw := lx.word()
+	if !bytes.ContainsRune([]byte(raw), '#') {
+		return pdfName(raw)
+	}
+	var out []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if b, err := strconv.ParseUint(raw[i+1:i+3], 16, 8); err == nil {
+				out = append(out, byte(b))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, raw[i])
+	}
+	return pdfName(out)
+}
+
+// a literal string after its opening parenthesis
+func (lx *pdfLexer) literal() (pdfString, error) {
+	var out []byte
+	depth := 1
+	for lx.pos < len(lx.data) {
+		c := lx.data[lx.pos]
+		lx.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return out, nil
+			}
+		case '\r':
+			// an end of line in a string reads as a line feed
+			if lx.pos < len(lx.data) && lx.data[lx.pos] == '\n' {
+				lx.pos++
+			}
+			c = '\n'
+		case '\\':
+			if lx.pos >= len(lx.data) {
+				break
+			}
+			c = lx.data[lx.pos]
+			lx.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// a line continuation
+				if c == '\r' && lx.pos < len(lx.data) && lx.data[lx.pos] == '\n' {
+					lx.pos++
+				}
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					value := int(c - '0')
+					for range 2 {
+						if lx.pos < len(lx.data) && lx.data[lx.pos] >= '0' && lx.data[lx.pos] <= '7' {
+							value = value*8 + int(lx.data[lx.pos]-'0')
+							lx.pos++
+						}
+					}
+					c = byte(value)
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return nil, fmt.Errorf("%w: unterminated string", errPDFSyntax)
+}
+
+// a hex string after its opening angle bracket
+func (lx *pdfLexer) hex() (pdfString, error) {
+	var digits []byte
+	for lx.pos < len(lx.data) {
+		c := lx.data[lx.pos]
+		lx.pos++
+		switch {
+		case c == '>':
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			out := make([]byte, len(digits)/2)
+			for i := range out {
+				b, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+				out[i] = byte(b)
+			}
+			return out, nil
+		case isPDFSpace(c):
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			digits = append(digits, c)
+		default:
+			return nil, fmt.Errorf("%w: bad hex string", errPDFSyntax)
+		}
+	}
+	return nil, fmt.Errorf("%w: unterminated hex string", errPDFSyntax)
+}
+
+// a dictionary after its opening "<<"
+func (lx *pdfLexer) dict(depth int) (pdfDict, error) {
+	dict := make(pdfDict)
+	for {
+		lx.skip()
+		if lx.pos+1 < len(lx.data) && lx.data[lx.pos] == '>' && lx.data[lx.pos+1] == '>' {
+			lx.pos += 2
+			return dict, nil
+		}
+		key, err := lx.object(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			return nil, fmt.Errorf("%w: dictionary key is not a name", errPDFSyntax)
+		}
+		value, err := lx.object(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			dict[name] = value // a null value is the same as no entry
+		}
+	}
+}
+
+// ╭─ ACCESSORS ─────────────────────────────────╮
+
+func (d pdfDict) name(key pdfName) pdfName {
+	name, _ := d[key].(pdfName)
+	return name
+}
+
+func pdfInt(value any) (int, bool) {
+	number, ok := value.(pdfNumber)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(string(number))
+	return n, err == nil
+}
+
+// ╭─ WRITING ───────────────────────────────────╮
+
+// appends an object in PDF syntax; dictionary keys are sorted so the same
+// document always gives the same bytes
+func appendPDFObject(out []byte, value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(out, "null"...)
+	case bool:
+		return strconv.AppendBool(out, v)
+	case pdfNumber:
+		return append(out, v...)
+	case pdfName:
+		return appendPDFName(out, v)
+	case pdfString:
+		return appendPDFString(out, v)
+	case pdfRef:
+		return fmt.Appendf(out, "%d %d R", v.num, v.gen)
+	case pdfArray:
+		out = append(out, '[')
+		for i, item := range v {
+			if i > 0 {
+				out = append(out, ' ')
+			}
+			out = appendPDFObject(out, item)
+		}
+		return append(out, ']')
+	case pdfDict:
+		out = append(out, "<<"...)
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			out = appendPDFName(out, key)
+			out = append(out, ' ')
+			out = appendPDFObject(out, v[key])
+		}
+		return append(out, ">>"...)
+	}
+	panic(fmt.Sprintf("not a PDF object: %T", value))
+}
+
+func appendPDFName(out []byte, name pdfName) []byte {
+	out = append(out, '/')
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 0x21 || c > 0x7E || c == '#' || isPDFDelimiter(c) {
+			out = fmt.Appendf(out, "#%02X", c)
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func appendPDFString(out []byte, s pdfString) []byte {
+	out = append(out, '(')
+	for _, c := range s {
+		switch c {
+		case '(', ')', '\\':
+			out = append(out, '\\', c)
+		case '\r':
+			out = append(out, `\r`...)
+		case '\n':
+			out = append(out, `\n`...)
+		default:
+			out = append(out, c)
+		}
+	}
+	return append(out, ')')
+}
@@ -0,0 +1,328 @@
+// BYZRA ⸻ internal/formats/pebinary.go
+// PE images (EXE, DLL): link times, debug directory, Rich header and DWARF
+// read; timestamps and build records zeroed
+
+package formats
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// data directories, by index
+const (
+	peExportDirectory   = 0
+	peResourceDirectory = 2
+	peSecurityDirectory = 4 // Authenticode; a file offset, not an address
+	peDebugDirectory    = 6
+)
+
+// debug directory entries that record the build rather than help run it:
+// CodeView (the PDB path), misc (an old image name), VC feature counts,
+// profile-guided optimization, and the reproducible-build hash
+var peBuildRecords = []uint32{2, 4, 12, 13, 16}
+
+// the parts of a PE image the handler reads and patches
+type peImage struct {
+	file     *pe.File
+	data     []byte
+	coff     int // COFF file header, after the PE signature
+	checksum int // CheckSum in the optional header
+	dirs     int // the data directories
+	ndirs    int
+	sections int // the section table
+}
+
+func openPE(data []byte) (*peImage, error) {
+	f, err := pe.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PE: %w", err)
+	}
+	p := &peImage{file: f, data: data, coff: int(binary.LittleEndian.Uint32(data[0x3C:])) + 4}
+	optional := p.coff + 20
+	p.checksum = optional + 64
+	p.sections = optional + int(f.FileHeader.SizeOfOptionalHeader)
+	switch header := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		p.dirs, p.ndirs = optional+96, int(header.NumberOfRvaAndSizes)
+	case *pe.OptionalHeader64:
+		p.dirs, p.ndirs = optional+112, int(header.NumberOfRvaAndSizes)
+	default:
+		return nil, fmt.Errorf("failed to parse PE: no optional header")
+	}
+	if p.dirs+8*p.ndirs > len(data) {
+		return nil, fmt.Errorf("failed to parse PE: data directories lie past the end of the file")
+	}
+	return p, nil
+}
+
+// the address and size of data directory i; 0, 0 when absent
+func (p *peImage) directory(i int) (uint32, uint32) {
+	if i >= p.ndirs {
+		return 0, 0
+	}
+	at := p.dirs + 8*i
+	return binary.LittleEndian.Uint32(p.data[at:]), binary.LittleEndian.Uint32(p.data[at+4:])
+}
+
+// the file offset of a relative virtual address, through the section that
+// maps it
+func (p *peImage) offset(rva uint32) (int, bool) {
+	for _, section := range p.file.Sections {
+		if rva >= section.VirtualAddress && rva-section.VirtualAddress < max(section.VirtualSize, section.Size) {
+			off := int(section.Offset) + int(rva-section.VirtualAddress)
+			return off, off < len(p.data)
+		}
+	}
+	return 0, false
+}
+
+// the 28-byte entries of the debug directory, by file offset
+func (p *peImage) debugEntries() []int {
+	rva, size := p.directory(peDebugDirectory)
+	off, ok := p.offset(rva)
+	if rva == 0 || !ok {
+		return nil
+	}
+	var entries []int
+	for at := off; at+28 <= off+int(size) && at+28 <= len(p.data); at += 28 {
+		entries = append(entries, at)
+	}
+	return entries
+}
+
+// the Rich header Microsoft linkers leave in the DOS stub: "DanS", then
+// the tools and build numbers that made the file, XORed with the key
+// after "Rich"
+func peRichHeader(data []byte) (start, end, tools int, ok bool) {
+	stub := data[:min(int(binary.LittleEndian.Uint32(data[0x3C:])), len(data))]
+	rich := bytes.LastIndex(stub, []byte("Rich"))
+	if rich < 0 || rich+8 > len(stub) {
+		return 0, 0, 0, false
+	}
+	key := binary.LittleEndian.Uint32(stub[rich+4:])
+	for at := rich - 4; at >= 0; at -= 4 {
+		if binary.LittleEndian.Uint32(stub[at:])^key == 0x536E6144 { // "DanS"
+			return at, rich + 8, max(rich-at-16, 0) / 8, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// ╭─ READING ───────────────────────────────────╮
+
+func extractPEMetadata(data []byte) (map[string]any, error) {
+	p, err := openPE(data)
+	if err != nil {
+		return nil, err
+	}
+	defer p.file.Close()
+
+	metadata := make(map[string]any)
+	peDate := func(key string, stamp uint32) {
+		if stamp != 0 {
+			if _, seen := metadata[key]; !seen {
+				metadata[key] = time.Unix(int64(stamp), 0).UTC().Format("2006:01:02 15:04:05")
+			}
+		}
+	}
+
+	// reproducible builds store a hash here instead, which reads as a date
+	peDate("LinkDate", p.file.FileHeader.TimeDateStamp)
+
+	for _, entry := range p.debugEntries() {
+		peDate("DebugDate", binary.LittleEndian.Uint32(data[entry+4:]))
+		if binary.LittleEndian.Uint32(data[entry+12:]) == 2 { // CodeView
+			if path := peCodeViewPath(data, entry); path != "" {
+				metadata["PDBPath"] = path
+			}
+		}
+	}
+	if rva, _ := p.directory(peExportDirectory); rva != 0 {
+		if off, ok := p.offset(rva); ok && off+8 <= len(data) {
+			peDate("ExportDate", binary.LittleEndian.Uint32(data[off+4:]))
+		}
+	}
+	if rva, _ := p.directory(peResourceDirectory); rva != 0 {
+		if off, ok := p.offset(rva); ok && off+8 <= len(data) {
+			peDate("ResourceDate", binary.LittleEndian.Uint32(data[off+4:]))
+		}
+	}
+	if _, _, tools, ok := peRichHeader(data); ok {
+		metadata["RichHeader"] = fmt.Sprintf("%d tool entries (compiler and linker build numbers)", tools)
+	}
+	if _, size := p.directory(peSecurityDirectory); size != 0 {
+		metadata["Signature"] = fmt.Sprintf("Authenticode, %s", util.FormatBytes(int64(size)))
+	}
+
+	// MinGW and Go keep DWARF in sections of their own
+	var debugSections []string
+	var debugSize uint32
+	for _, section := range p.file.Sections {
+		if peDebugSection(section) && section.Size > 0 {
+			debugSections = append(debugSections, section.Name)
+			debugSize += section.Size
+		}
+	}
+	if len(debugSections) > 0 {
+		metadata["DebugInfo"] = fmt.Sprintf("%s (%s)", strings.Join(debugSections, ", "), util.FormatBytes(int64(debugSize)))
+		if debug, err := p.file.DWARF(); err == nil {
+			if dirs := compileDirectories(debug); dirs != "" {
+				metadata["CompileDirectory"] = dirs
+			}
+		}
+	}
+	return metadata, nil
+}
+
+// DWARF, which debug/pe finds under both prefixes
+func peDebugSection(section *pe.Section) bool {
+	return strings.HasPrefix(section.Name, ".debug_") || strings.HasPrefix(section.Name, ".zdebug_")
+}
+
+// the PDB path of a CodeView debug entry: "RSDS", GUID and age (PDB 7),
+// or "NB10", offset, signature and age (PDB 2.0), then the path
+func peCodeViewPath(data []byte, entry int) string {
+	size := int(binary.LittleEndian.Uint32(data[entry+16:]))
+	off := int(binary.LittleEndian.Uint32(data[entry+24:]))
+	if off <= 0 || off >= len(data) || size > len(data)-off {
+		return ""
+	}
+	record := data[off : off+size]
+	var path []byte
+	switch {
+	case bytes.HasPrefix(record, []byte("RSDS")) && len(record) > 24:
+		path = record[24:]
+	case bytes.HasPrefix(record, []byte("NB10")) && len(record) > 16:
+		path = record[16:]
+	default:
+		return ""
+	}
+	path, _, _ = bytes.Cut(path, []byte{0})
+	return string(path)
+}
+
+// ╭─ WRITING ───────────────────────────────────╮
+
+// zeroes the link time and every other timestamp, the build records in the
+// debug directory (PDB path included) and the Rich header, empties the DWARF
+// sections, then updates the checksum when the file has one. Signed files
+// are refused
+func stripPE(data []byte) error {
+	p, err := openPE(data)
+	if err != nil {
+		return err
+	}
+	defer p.file.Close()
+	if _, size := p.directory(peSecurityDirectory); size != 0 {
+		return errPESigned
+	}
+
+	clear(data[p.coff+4 : p.coff+8])
+
+	for _, entry := range p.debugEntries() {
+		clear(data[entry+4 : entry+8])
+		if slices.Contains(peBuildRecords, binary.LittleEndian.Uint32(data[entry+12:])) {
+			size := binary.LittleEndian.Uint32(data[entry+16:])
+			zeroRange(data, uint64(binary.LittleEndian.Uint32(data[entry+24:])), uint64(size))
+		}
+	}
+	if rva, _ := p.directory(peExportDirectory); rva != 0 {
+		if off, ok := p.offset(rva); ok && off+8 <= len(data) {
+			clear(data[off+4 : off+8])
+		}
+	}
+	if rva, _ := p.directory(peResourceDirectory); rva != 0 {
+		if off, ok := p.offset(rva); ok {
+			clearResourceDates(data, off, off, 0)
+		}
+	}
+	if start, end, _, ok := peRichHeader(data); ok {
+		clear(data[start:end])
+	}
+	for i, section := range p.file.Sections {
+		if !peDebugSection(section) || section.Size == 0 {
+			continue
+		}
+		// no raw data: the section is loaded, if at all, as zeroes
+		header := p.sections + 40*i
+		if header+24 > len(data) {
+			return fmt.Errorf("section header %d lies past the end of the file", i)
+		}
+		zeroRange(data, uint64(section.Offset), uint64(section.Size))
+		clear(data[header+16 : header+24]) // SizeOfRawData, PointerToRawData
+	}
+
+	if p.checksum+4 <= len(data) && binary.LittleEndian.Uint32(data[p.checksum:]) != 0 {
+		binary.LittleEndian.PutUint32(data[p.checksum:], peChecksum(data, p.checksum))
+	}
+	return nil
+}
+
+// zeroes the timestamp of the resource table at off and of the tables
+// below it (type, name and language: three levels); subtables are
+// addressed from the start of the resource directory, root
+func clearResourceDates(data []byte, root, off, depth int) {
+	if depth > 2 || off+16 > len(data) {
+		return
+	}
+	clear(data[off+4 : off+8])
+	entries := int(binary.LittleEndian.Uint16(data[off+12:])) + int(binary.LittleEndian.Uint16(data[off+14:]))
+	for i := range entries {
+		at := off + 16 + 8*i
+		if at+8 > len(data) {
+			return
+		}
+		if target := binary.LittleEndian.Uint32(data[at+4:]); target&0x80000000 != 0 {
+			clearResourceDates(data, root, root+int(target&0x7FFFFFFF), depth+1)
+		}
+	}
+}
+
+// the image checksum: the file summed as 16-bit words with the carry
+// folded back in, the CheckSum field skipped, plus the file's length
+func peChecksum(data []byte, field int) uint32 {
+	var sum uint64
+	for i := 0; i+1 < len(data); i += 2 {
+		if i == field || i == field+2 {
+			continue
+		}
+		sum += uint64(binary.LittleEndian.Uint16(data[i:]))
+		sum = sum&0xFFFF + sum>>16
+	}
+	if len(data)%2 == 1 {
+		sum += uint64(data[len(data)-1])
+		sum = sum&0xFFFF + sum>>16
+	}
+	return uint32(sum) + uint32(len(data))
+}
+
+// ╭─ INTEGRITY ─────────────────────────────────╮
+
+// the headers parse, every section's raw data lies within the file, and a
+// checksum, when set, matches
+func checkPE(data []byte) bool {
+	p, err := openPE(data)
+	if err != nil {
+		return false
+	}
+	defer p.file.Close()
+
+	for _, section := range p.file.Sections {
+		if section.Offset != 0 && uint64(section.Offset)+uint64(section.Size) > uint64(len(data)) {
+			return false
+		}
+	}
+	if p.checksum+4 > len(data) {
+		return false
+	}
+	checksum := binary.LittleEndian.Uint32(data[p.checksum:])
+	return checksum == 0 || checksum == peChecksum(data, p.checksum)
+}
@@ -0,0 +1,53 @@
+// BYZRA ⸻ internal/formats/policy.go
+// policy-aware wiping
+
+package formats
+
+import "caligra/internal/config"
+
+// what a policy wipe did beyond removing tags, for separate reporting
+type PolicyOutcome struct {
+	Tags        string // ordinary tags kept by name
+	ImageTags   string // e.g. "kept Orientation, ICC_Profile where present; removed the rest"
+	Dates       string // e.g. "coarsened to month (2024:05:01 00:00:00)"
+	MakerNote   string // e.g. "excised (Nikon, 28412 bytes)"
+	Orientation string // e.g. "rotated 90° clockwise losslessly"
+	CoverArt    string // e.g. "stripped metadata from 1 image (240 KB → 236 KB)"
+	Encoder     string // e.g. "removed (TSSE, LAME header)"
+	ReplayGain  string
+	MusicBrainz string
+	AcoustID    string
+	Frames      string // chapter and lyrics frames removed
+	Coordinates string // e.g. "removed (1534 elements)"
+	Attendees   string // e.g. "names and addresses removed (12)"
+}
+
+// labelled, non-empty outcome lines
+func (o *PolicyOutcome) Lines() []string {
+	var lines []string
+	for _, item := range []struct{ label, value string }{
+		{"Tags", o.Tags},
+		{"Image tags", o.ImageTags},
+		{"Dates", o.Dates},
+		{"MakerNote", o.MakerNote},
+		{"Orientation", o.Orientation},
+		{"Cover art", o.CoverArt},
+		{"Encoder", o.Encoder},
+		{"ReplayGain", o.ReplayGain},
+		{"MusicBrainz IDs", o.MusicBrainz},
+		{"AcoustID", o.AcoustID},
+		{"Chapters/lyrics", o.Frames},
+		{"Coordinates", o.Coordinates},
+		{"Attendees", o.Attendees},
+	} {
+		if item.value != "" {
+			lines = append(lines, item.label+": "+item.value)
+		}
+	}
+	return lines
+}
+
+// optional capability: wipe while honouring a policy's keep/remove choices
+type PolicyWiper interface {
+	WipeWithPolicy(path string, policy *config.Policy) (*PolicyOutcome, error)
+}
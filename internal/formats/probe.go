@@ -0,0 +1,36 @@
+// BYZRA ⸻ internal/formats/probe.go
+// audio/video tag extraction, with ffprobe standing in for exiftool
+
+package formats
+
+import (
+	"fmt"
+
+	"caligra/internal/util"
+)
+
+// tags of an audio or video file: exiftool's when it is installed, else
+// ffprobe's container and stream tags under exiftool's names; kind names
+// the file in errors ("audio", "video", "Matroska")
+func extractMediaMetadata(path, kind string) (map[string]any, error) {
+	if util.RequireTool("exiftool") != nil && util.HasFFProbe() {
+		metadata, err := util.FFProbeMetadata(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s metadata: %w", kind, err)
+		}
+		return metadata, nil
+	}
+
+	data, err := util.ExifToolExtract(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract %s metadata: %w", kind, err)
+	}
+
+	// parse the JSON response into a map
+	metadata, err := util.ParseExifToolOutput(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s metadata: %w", kind, err)
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,147 @@
+// BYZRA ⸻ internal/formats/profilevalues.go
+// checks profile values against what each format's tags accept
+
+package formats
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// longest profile value injected, in characters
+const maxProfileValue = 256
+
+// a profile field left out of injection, and why
+type RejectedField struct {
+	Field  string
+	Reason string
+}
+
+// field names become HTML meta names, front matter keys and comment labels
+var profileKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+// EXIF tags the TIFF spec defines as 7-bit ASCII
+var asciiExifTags = []string{"Artist", "Software", "Copyright"}
+
+// the fields TAR archives take: entry owners, dates and a global comment
+var tarProfileKeys = []string{"author", "created", "comment"}
+
+// the fields email headers take: the mail client and a comment
+var emailProfileKeys = []string{"software", "comment"}
+
+// date layouts accepted for "created"
+var profileDateLayouts = []string{
+	"2006-01-02", "2006:01:02",
+	"2006-01-02 15:04:05", "2006:01:02 15:04:05", "2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// the fields of profile format can take, with dates rewritten the way its
+// tags expect; every other field is rejected with a reason. Unmapped keys
+// pass through: handlers skip them. The extension tells text subformats
+// apart ("srt", "vtt", ...)
+func CheckProfile(format, extension string, profile map[string]string) (map[string]string, []RejectedField) {
+	checked := make(map[string]string, len(profile))
+	var rejected []RejectedField
+	for key, value := range profile {
+		value, err := checkProfileValue(format, extension, key, value)
+		if err != nil {
+			rejected = append(rejected, RejectedField{Field: key, Reason: err.Error()})
+			continue
+		}
+		checked[key] = value
+	}
+	return checked, rejected
+}
+
+func checkProfileValue(format, extension, key, value string) (string, error) {
+	switch {
+	case format == "text" && extension == "srt":
+		return "", fmt.Errorf("SubRip files have no header to hold it")
+	case format == "text" && extension == "vtt" && strings.Contains(value, "-->"):
+		return "", fmt.Errorf("\"-->\" would end the WebVTT header")
+	case format == "executable":
+		return "", fmt.Errorf("executables hold no profile fields")
+	case format == "archive" && extension == "zip" && !strings.EqualFold(key, "comment"):
+		return "", fmt.Errorf("ZIP archives hold a comment and no other field")
+	case format == "archive" && !slices.Contains(tarProfileKeys, strings.ToLower(key)):
+		return "", fmt.Errorf("TAR archives hold an owner name, a date and a comment and no other field")
+	case format == "email" && !slices.Contains(emailProfileKeys, strings.ToLower(key)):
+		return "", fmt.Errorf("emails hold a user agent and a comment and no other field")
+	case format == "calendar" && !strings.EqualFold(key, "software"):
+		return "", fmt.Errorf("calendars hold the producing software and no other field")
+	case format == "database":
+		return "", fmt.Errorf("SQLite databases hold no profile fields")
+	case format == "gps" && extension == "kml":
+		return "", fmt.Errorf("KML files hold no profile fields")
+	case format == "gps" && !strings.EqualFold(key, "software"):
+		return "", fmt.Errorf("GPX files hold the creating software and no other field")
+	case !profileKeyPattern.MatchString(key):
+		return "", fmt.Errorf("field names may only hold letters, digits, '_', '.' and '-'")
+	case !utf8.ValidString(value):
+		return "", fmt.Errorf("not valid UTF-8")
+	case strings.IndexFunc(value, unicode.IsControl) >= 0:
+		return "", fmt.Errorf("contains line breaks or control characters")
+	case utf8.RuneCountInString(value) > maxProfileValue:
+		return "", fmt.Errorf("longer than %d characters", maxProfileValue)
+	}
+
+	var tag string
+	switch format {
+	case "image":
+		tag = mapProfileKeyToExifTag(key)
+	case "video":
+		tag = mapProfileKeyToVideoTag(key)
+	case "audio":
+		tag = mapProfileKeyToAudioTag(key)
+	case "matroska":
+		tag = mapProfileKeyToMatroskaTag(key)
+	case "document":
+		tag, _ = mapProfileKeyToDocumentTag(key)
+	case "pdf":
+		tag = string(mapProfileKeyToPDFKey(key))
+	case "archive":
+		tag = key
+	default:
+		return value, nil
+	}
+
+	if strings.EqualFold(key, "created") && tag != "" {
+		date, layout, ok := parseProfileDate(value)
+		if !ok {
+			return "", fmt.Errorf("%q is not a date (YYYY-MM-DD, optionally with HH:MM:SS)", value)
+		}
+		switch format {
+		case "image", "video", "document", "pdf", "archive":
+			return date.Format("2006:01:02 15:04:05"), nil // EXIF, QuickTime, OLE, PDF and TAR
+		default:
+			if layout == "2006" {
+				return value, nil // a year alone is a valid ID3 date
+			}
+			return date.Format("2006-01-02"), nil
+		}
+	}
+
+	if format == "image" && slices.Contains(asciiExifTags, tag) && strings.IndexFunc(value, func(r rune) bool { return r > unicode.MaxASCII }) >= 0 {
+		return "", fmt.Errorf("EXIF %s holds ASCII only", tag)
+	}
+	return value, nil
+}
+
+func parseProfileDate(value string) (time.Time, string, bool) {
+	layouts := profileDateLayouts
+	if len(value) == 4 {
+		layouts = []string{"2006"}
+	}
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, layout, true
+		}
+	}
+	return time.Time{}, "", false
+}
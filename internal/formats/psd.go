@@ -0,0 +1,268 @@
+// BYZRA ⸻ internal/formats/psd.go
+// Photoshop (PSD, PSB): metadata image resources dropped, layers untouched
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// image resources carrying metadata or a picture of the document
+var psdMetadataResources = map[uint16]string{
+	0x03F0: "caption",
+	0x0404: "IPTC-NAA record",
+	0x0409: "thumbnail", // Photoshop 4.0
+	0x040B: "URL",
+	0x040C: "thumbnail",
+	0x041E: "URL list",
+	0x0422: "EXIF data",
+	0x0423: "EXIF data",
+	0x0424: "XMP metadata",
+	0x0425: "caption digest",
+}
+
+// the previews, which no policy keeps: they show the document as it was
+// when last saved, crops and hidden layers included
+var psdThumbnailResources = map[uint16]string{
+	0x0409: "thumbnail",
+	0x040C: "thumbnail",
+}
+
+// one block of the image resources section, from its signature to the end
+// of its padded data
+type psdResource struct {
+	id         uint16
+	start, end int
+	data       []byte
+}
+
+// the sections of a PSD (version 1) or PSB (version 2) file
+type psdFile struct {
+	version              uint16
+	channels             int
+	height, width, depth int
+	resourceStart        int // of the section's length field
+	resources            []psdResource
+	layerStart, layerEnd int // the layer and mask section with its length
+	imageStart           int // of the compression field
+}
+
+// ╭─ READING ───────────────────────────────────╮
+
+// splits a Photoshop file into its sections; false when any of them runs
+// past the end of the file or the resources do not parse
+func parsePSD(data []byte) (*psdFile, bool) {
+	if len(data) < 26 || string(data[:4]) != "8BPS" {
+		return nil, false
+	}
+	p := &psdFile{
+		version:  binary.BigEndian.Uint16(data[4:]),
+		channels: int(binary.BigEndian.Uint16(data[12:])),
+		height:   int(binary.BigEndian.Uint32(data[14:])),
+		width:    int(binary.BigEndian.Uint32(data[18:])),
+		depth:    int(binary.BigEndian.Uint16(data[22:])),
+	}
+	if p.version != 1 && p.version != 2 {
+		return nil, false
+	}
+
+	// colour mode data: the palette of indexed images
+	off, ok := psdSection(data, 26, 4)
+	if !ok {
+		return nil, false
+	}
+
+	p.resourceStart = off
+	end, ok := psdSection(data, off, 4)
+	if !ok {
+		return nil, false
+	}
+	for at := off + 4; at < end; {
+		resource, ok := psdReadResource(data[:end], at)
+		if !ok {
+			return nil, false
+		}
+		p.resources = append(p.resources, resource)
+		at = resource.end
+	}
+
+	// the layer and mask section's length is 64-bit in PSB
+	width := 4
+	if p.version == 2 {
+		width = 8
+	}
+	p.layerStart = end
+	if p.layerEnd, ok = psdSection(data, end, width); !ok {
+		return nil, false
+	}
+	if p.layerEnd > p.layerStart+width {
+		// the layer records and channel data lead the section
+		if _, ok := psdSection(data[:p.layerEnd], p.layerStart+width, width); !ok {
+			return nil, false
+		}
+	}
+	p.imageStart = p.layerEnd
+	return p, p.imageStart+2 <= len(data)
+}
+
+// the end of the length-prefixed section at off
+func psdSection(data []byte, off, width int) (int, bool) {
+	if off+width > len(data) {
+		return 0, false
+	}
+	var size uint64
+	if width == 8 {
+		size = binary.BigEndian.Uint64(data[off:])
+	} else {
+		size = uint64(binary.BigEndian.Uint32(data[off:]))
+	}
+	if size > uint64(len(data)-off-width) {
+		return 0, false
+	}
+	return off + width + int(size), true
+}
+
+// the resource block at off: signature, ID, a Pascal name and the data,
+// both padded to an even size
+func psdReadResource(data []byte, off int) (psdResource, bool) {
+	if off+7 > len(data) {
+		return psdResource{}, false
+	}
+	switch string(data[off : off+4]) {
+	case "8BIM", "MeSa", "PHUT", "AgHg", "DCSR":
+	default:
+		return psdResource{}, false
+	}
+	resource := psdResource{id: binary.BigEndian.Uint16(data[off+4:]), start: off}
+	name := 1 + int(data[off+6])
+	at := off + 6 + name + name&1
+	if at+4 > len(data) {
+		return psdResource{}, false
+	}
+	size := int(binary.BigEndian.Uint32(data[at:]))
+	if size > len(data)-at-4 {
+		return psdResource{}, false
+	}
+	resource.data = data[at+4 : at+4+size]
+	resource.end = min(at+4+size+size&1, len(data))
+	return resource, true
+}
+
+// the previews the image resources hold
+func listPSDThumbnails(path string) ([]Embedded, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := parsePSD(data)
+	if !ok {
+		return nil, nil
+	}
+
+	var embedded []Embedded
+	for _, resource := range p.resources {
+		if psdThumbnailResources[resource.id] == "" {
+			continue
+		}
+		// format, width and height lead the JPEG
+		detail := fmt.Sprintf("preview of the composite image, %d bytes", len(resource.data))
+		if len(resource.data) >= 12 {
+			detail = fmt.Sprintf("%d×%d preview of the composite image, %d bytes",
+				binary.BigEndian.Uint32(resource.data[4:]), binary.BigEndian.Uint32(resource.data[8:]), len(resource.data))
+		}
+		embedded = append(embedded, Embedded{Kind: "thumbnail", Name: "Photoshop thumbnail", Detail: detail})
+	}
+	return embedded, nil
+}
+
+// ╭─ WRITING ───────────────────────────────────╮
+
+// removes the image resources listed in drop, rewriting the section's
+// length; colour mode data, layers and image data are copied as they are.
+// Returns how many resources there were
+func stripPSDResources(path string, drop map[uint16]string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.HasPrefix(data, []byte("8BPS")) {
+		return 0, nil
+	}
+	p, ok := parsePSD(data)
+	if !ok {
+		return 0, nil // damaged; left to exiftool
+	}
+
+	var resources bytes.Buffer
+	removed := 0
+	for _, resource := range p.resources {
+		if drop[resource.id] != "" {
+			removed++
+			continue
+		}
+		resources.Write(data[resource.start:resource.end])
+		if (resource.end-resource.start)&1 == 1 {
+			resources.WriteByte(0) // the pad byte, missing at the end of some sections
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(data))
+	out.Write(data[:p.resourceStart])
+	binary.Write(&out, binary.BigEndian, uint32(resources.Len()))
+	out.Write(resources.Bytes())
+	out.Write(data[p.layerStart:])
+
+	if err := replaceFile(path, out.Bytes()); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// ╭─ INTEGRITY ─────────────────────────────────╮
+
+// most channels Photoshop allows, and pixels per side for PSD and PSB
+const psdMaxChannels = 56
+
+var psdMaxSide = map[uint16]int{1: 30000, 2: 300000}
+
+// the header is one Photoshop writes, every section and resource parses,
+// the layer info fits its section, and the merged image has a known
+// compression and, when uncompressed, all its bytes
+func checkPSD(data []byte) bool {
+	p, ok := parsePSD(data)
+	if !ok {
+		return false
+	}
+	if p.channels < 1 || p.channels > psdMaxChannels ||
+		p.height < 1 || p.height > psdMaxSide[p.version] ||
+		p.width < 1 || p.width > psdMaxSide[p.version] {
+		return false
+	}
+	switch p.depth {
+	case 1, 8, 16, 32:
+	default:
+		return false
+	}
+	compression := binary.BigEndian.Uint16(data[p.imageStart:])
+	switch compression {
+	case 0: // raw
+		row := (p.width*p.depth + 7) / 8
+		return uint64(len(data)-p.imageStart-2) >= uint64(p.channels)*uint64(p.height)*uint64(row)
+	case 1: // RLE: a byte count for every row of every channel first
+		counts := 2
+		if p.version == 2 {
+			counts = 4
+		}
+		return uint64(len(data)-p.imageStart-2) >= uint64(p.channels)*uint64(p.height)*uint64(counts)
+	case 2, 3: // ZIP, ZIP with prediction
+		return true
+	}
+	return false
+}
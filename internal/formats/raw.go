@@ -0,0 +1,194 @@
+// BYZRA ⸻ internal/formats/raw.go
+// camera RAW (CR2, NEF, ARW, DNG): TIFF files whose image data must survive
+
+package formats
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+
+	"caligra/internal/util"
+)
+
+const (
+	tiffTagSubIFDs         = 0x014A
+	tiffTagStripOffsets    = 0x0111
+	tiffTagStripByteCounts = 0x0117
+	tiffTagTileOffsets     = 0x0144
+	tiffTagTileByteCounts  = 0x0145
+	tiffTagDNGVersion      = 0xC612
+)
+
+// how much of the file is read to tell RAW formats apart; IFD0 and the
+// Make string sit at the start
+const rawHeadSize = 64 << 10
+
+// most IFDs walked when checking a RAW file
+const rawMaxIFDs = 64
+
+// MakerNote tags naming the camera, lens or owner; the rest of the
+// MakerNote stays, since RAW converters read colour and decoding data from
+// it (compressed NEF needs its linearization table)
+var rawMakerNoteTags = []string{
+	"SerialNumber", "InternalSerialNumber", "LensSerialNumber",
+	"OwnerName", "CameraOwnerName", "BodySerialNumber",
+}
+
+// "cr2", "nef", "arw" or "dng" for a camera RAW file, "" for anything else
+// (a plain TIFF included)
+func RawImageType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, rawHeadSize)
+	n, _ := io.ReadFull(file, head)
+	return rawImageKind(head[:n])
+}
+
+func rawImageKind(data []byte) string {
+	view := &tiffView{data: data}
+	switch {
+	case len(data) < 8:
+		return ""
+	case string(data[:4]) == "II*\x00":
+		view.order = binary.LittleEndian
+	case string(data[:4]) == "MM\x00*":
+		view.order = binary.BigEndian
+	default:
+		return ""
+	}
+	// Canon marks CR2 right after the header
+	if len(data) >= 11 && string(data[8:10]) == "CR" && data[10] == 2 {
+		return "cr2"
+	}
+
+	ifd0, count, ok := view.ifd(view.order.Uint32(data[4:]))
+	if !ok {
+		return ""
+	}
+	var cameraMake string
+	for i := range count {
+		tag, kind, n, value := view.entry(ifd0, i)
+		switch {
+		case tag == tiffTagDNGVersion:
+			return "dng" // whoever made the camera
+		case tag == tiffTagMake && kind == 2:
+			at := value
+			if n > 4 {
+				at = int(view.order.Uint32(data[value:]))
+			}
+			if at+int(n) <= len(data) {
+				cameraMake = strings.ToUpper(string(data[at : at+int(n)]))
+			}
+		}
+	}
+	switch {
+	case strings.HasPrefix(cameraMake, "NIKON"):
+		return "nef"
+	case strings.HasPrefix(cameraMake, "SONY"):
+		return "arw"
+	}
+	return ""
+}
+
+// after the tag wipe: DNG readers need nothing from the MakerNote, so it
+// is cut out whole; other RAW formats keep theirs, minus the tags that
+// identify the camera and its owner
+func scrubRawMakerNote(path, kind string) error {
+	if kind == "dng" {
+		_, err := exciseMakerNote(path)
+		return err
+	}
+	deletions := make([]string, len(rawMakerNoteTags))
+	for i, tag := range rawMakerNoteTags {
+		deletions[i] = "-MakerNotes:" + tag + "="
+	}
+	return util.ExifToolWrite(path, deletions)
+}
+
+// every IFD reachable from the header parses, and the strips and tiles
+// they point to lie within the file; RAW decoders ImageMagick might use
+// are rarely installed, so this is the whole check
+func checkRaw(data []byte) bool {
+	view := &tiffView{data: data}
+	switch string(data[:4]) {
+	case "II*\x00":
+		view.order = binary.LittleEndian
+	case "MM\x00*":
+		view.order = binary.BigEndian
+	default:
+		return false
+	}
+
+	queue := []uint32{view.order.Uint32(data[4:])}
+	seen := make(map[uint32]bool)
+	images := 0
+	for len(queue) > 0 && len(seen) < rawMaxIFDs {
+		offset := queue[0]
+		queue = queue[1:]
+		if offset == 0 || seen[offset] {
+			continue
+		}
+		seen[offset] = true
+
+		ifd, count, ok := view.ifd(offset)
+		if !ok {
+			return false
+		}
+		var offsets, sizes []uint32
+		for i := range count {
+			tag, kind, n, value := view.entry(ifd, i)
+			switch tag {
+			case tiffTagSubIFDs:
+				queue = append(queue, view.longs(kind, n, value)...)
+			case tiffTagStripOffsets, tiffTagTileOffsets:
+				offsets = view.longs(kind, n, value)
+			case tiffTagStripByteCounts, tiffTagTileByteCounts:
+				sizes = view.longs(kind, n, value)
+			}
+		}
+		if len(offsets) != len(sizes) {
+			return false
+		}
+		for i := range offsets {
+			if uint64(offsets[i])+uint64(sizes[i]) > uint64(len(data)) {
+				return false
+			}
+		}
+		if len(offsets) > 0 {
+			images++
+		}
+		queue = append(queue, view.order.Uint32(data[ifd+2+count*12:]))
+	}
+	return images > 0
+}
+
+// the values of a SHORT or LONG entry; nil when they run past the file
+func (v *tiffView) longs(kind uint16, count uint32, value int) []uint32 {
+	size := map[uint16]int{3: 2, 4: 4, 13: 4}[kind] // SHORT, LONG, IFD
+	if size == 0 || count > uint32(len(v.data)) {
+		return nil
+	}
+	at := value
+	if size*int(count) > 4 {
+		at = v.base + int(v.order.Uint32(v.data[value:]))
+	}
+	if at+size*int(count) > len(v.data) {
+		return nil
+	}
+
+	values := make([]uint32, count)
+	for i := range values {
+		if size == 2 {
+			values[i] = uint32(v.order.Uint16(v.data[at+2*i:]))
+		} else {
+			values[i] = v.order.Uint32(v.data[at+4*i:])
+		}
+	}
+	return values
+}
@@ -0,0 +1,111 @@
+// BYZRA ⸻ internal/formats/rebuild.go
+// rebuilding files from their content: image re-encoding, container remuxing
+
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"caligra/internal/util"
+)
+
+// optional capability: decode and re-encode, so only the pixels survive
+type Reencoder interface {
+	Reencode(path string) error
+}
+
+// optional capability: rebuild the container around its streams
+type Remuxer interface {
+	Remux(path string) error
+}
+
+// re-encode quality; high enough to be visually lossless
+const reencodeJPEGQuality = 92
+
+// rewrites a JPEG, PNG or GIF through Go's own encoders, which write
+// no metadata, comments or application segments; the orientation tag is
+// dropped with the rest, so it is applied to the pixels first
+func (h *ImageHandler) Reencode(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	orientation, _ := findOrientation(data)
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to read image for re-encoding: %w", err)
+	}
+
+	var out bytes.Buffer
+	switch format {
+	case "jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to decode JPEG: %w", err)
+		}
+		err = jpeg.Encode(&out, orientImage(img, orientation), &jpeg.Options{Quality: reencodeJPEGQuality})
+		if err != nil {
+			return fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+	case "png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to decode PNG: %w", err)
+		}
+		if err := png.Encode(&out, orientImage(img, orientation)); err != nil {
+			return fmt.Errorf("failed to encode PNG: %w", err)
+		}
+	case "gif":
+		// every frame, not just the first
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to decode GIF: %w", err)
+		}
+		if err := gif.EncodeAll(&out, anim); err != nil {
+			return fmt.Errorf("failed to encode GIF: %w", err)
+		}
+	default:
+		return fmt.Errorf("re-encoding %s images is not supported", format)
+	}
+
+	return replaceFile(path, out.Bytes())
+}
+
+// remuxes without global metadata or chapters
+func remuxClean(path string) error {
+	return remuxInPlace(path, func(src, dst string) error {
+		return util.FFmpegRemux(src, dst, []string{"-map", "0", "-map_metadata", "-1", "-map_chapters", "-1"})
+	})
+}
+
+func (h *AudioHandler) Remux(path string) error    { return remuxClean(path) }
+func (h *VideoHandler) Remux(path string) error    { return remuxClean(path) }
+func (h *MatroskaHandler) Remux(path string) error { return remuxClean(path) }
+
+// writes data over path through a temp file, keeping its permissions
+func replaceFile(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(path), ".caligra-rebuild-"+filepath.Base(path))
+	if err := os.WriteFile(tmp, data, info.Mode().Perm()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write rebuilt file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace file after rebuild: %w", err)
+	}
+	return nil
+}
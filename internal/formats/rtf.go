@@ -0,0 +1,530 @@
+// BYZRA ⸻ internal/formats/rtf.go
+// Rich Text Format documents
+
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// {\rtf
+var rtfSignature = []byte(`{\rtf`)
+
+// a group: its braces and its destination, the control word that opens it
+// ("author" for {\author ...} and {\*\author ...})
+type rtfGroup struct {
+	start, end int // end is past the closing brace
+	dest       string
+	depth      int // 0 for the document group
+}
+
+// finds every group of a document, ordered by where it starts; fails on
+// unbalanced braces and truncated \bin data
+func scanRTF(data []byte) ([]rtfGroup, error) {
+	if !bytes.HasPrefix(data, rtfSignature) {
+		return nil, fmt.Errorf("not an RTF document")
+	}
+
+	type open struct {
+		group   rtfGroup
+		settled bool // destination known, or text came first
+	}
+	var stack []open
+	var groups []rtfGroup
+	closed := false
+
+	for i := 0; i < len(data); {
+		switch c := data[i]; {
+		case c == '{':
+			if closed {
+				return nil, fmt.Errorf("content after the document group at byte %d", i)
+			}
+			stack = append(stack, open{group: rtfGroup{start: i, depth: len(stack)}})
+			i++
+		case c == '}':
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unbalanced '}' at byte %d", i)
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			top.group.end = i + 1
+			groups = append(groups, top.group)
+			closed = len(stack) == 0
+			i++
+		case c == '\\':
+			word, param, next := rtfControl(data, i)
+			if word == "bin" && param > 0 {
+				if next+param > len(data) {
+					return nil, fmt.Errorf("\\bin data runs past the end")
+				}
+				next += param
+			}
+			if n := len(stack); n > 0 && !stack[n-1].settled && word != "*" {
+				stack[n-1].group.dest = word
+				stack[n-1].settled = true
+			}
+			i = next
+		case c == '\r' || c == '\n':
+			i++
+		default:
+			if closed && c != 0 && c != ' ' && c != '\t' {
+				return nil, fmt.Errorf("content after the document group at byte %d", i)
+			}
+			if n := len(stack); n > 0 {
+				stack[n-1].settled = true
+			}
+			i++
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%d groups left open", len(stack))
+	}
+
+	slices.SortFunc(groups, func(a, b rtfGroup) int { return a.start - b.start })
+	return groups, nil
+}
+
+// the control word or symbol at data[i] (a backslash), its numeric
+// parameter, and where the next token starts. Symbols come back as
+// themselves ("*", "'", "{"), \'hh with its two hex digits consumed
+func rtfControl(data []byte, i int) (string, int, int) {
+	j := i + 1
+	if j >= len(data) {
+		return "", 0, j
+	}
+	if !isLetter(data[j]) {
+		if data[j] == '\'' {
+			return "'", 0, min(j+3, len(data))
+		}
+		return string(data[j]), 0, j + 1
+	}
+
+	for j < len(data) && isLetter(data[j]) {
+		j++
+	}
+	word := string(data[i+1 : j])
+	k := j
+	if k < len(data) && data[k] == '-' {
+		k++
+	}
+	for k < len(data) && data[k] >= '0' && data[k] <= '9' {
+		k++
+	}
+	param, _ := strconv.Atoi(string(data[j:k]))
+	if k < len(data) && data[k] == ' ' {
+		k++ // the delimiting space belongs to the control word
+	}
+	return word, param, k
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// the groups directly inside group g
+func rtfChildren(groups []rtfGroup, g rtfGroup) []rtfGroup {
+	var children []rtfGroup
+	for _, child := range groups {
+		if child.depth == g.depth+1 && child.start > g.start && child.end < g.end {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// the text of a group, nested groups included, with escapes decoded and
+// control words dropped; \'hh bytes are read as Windows-1252 (Latin-1)
+func rtfText(data []byte) string {
+	var b strings.Builder
+	skip := 0 // characters standing in for the last \u
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '{' || c == '}' || c == '\r' || c == '\n':
+			i++
+		case c == '\\':
+			word, param, next := rtfControl(data, i)
+			switch word {
+			case "'":
+				if skip > 0 {
+					skip--
+				} else if v, err := strconv.ParseUint(string(data[i+2:next]), 16, 8); err == nil {
+					b.WriteRune(rune(v))
+				}
+			case "u":
+				b.WriteRune(rune(utf16.Decode([]uint16{uint16(int16(param))})[0]))
+				skip = 1
+			case "\\", "{", "}":
+				b.WriteString(word)
+			case "~", "tab", "par", "line":
+				b.WriteByte(' ')
+			}
+			i = next
+		default:
+			if skip > 0 {
+				skip--
+			} else {
+				b.WriteByte(c)
+			}
+			i++
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// the numeric control words of a group, outside its nested groups
+func rtfParams(data []byte) map[string]int {
+	params := make(map[string]int)
+	depth := 0
+	for i := 0; i < len(data); {
+		switch data[i] {
+		case '{':
+			depth++
+			i++
+		case '}':
+			depth--
+			i++
+		case '\\':
+			word, param, next := rtfControl(data, i)
+			if depth == 1 {
+				params[word] = param
+			}
+			i = next
+		default:
+			i++
+		}
+	}
+	return params
+}
+
+// ╭─ INFO GROUP ────────────────────────────────╮
+
+// text destinations of \info, named as exiftool does
+var rtfInfoFields = map[string]string{
+	"title": "Title", "subject": "Subject", "author": "Author", "manager": "Manager",
+	"company": "Company", "operator": "LastModifiedBy", "category": "Category",
+	"keywords": "Keywords", "comment": "Comment", "doccomm": "Comments",
+	"hlinkbase": "HyperlinkBase",
+}
+
+// date destinations of \info
+var rtfInfoDates = map[string]string{
+	"creatim": "CreateDate", "revtim": "ModifyDate", "printim": "LastPrinted", "buptim": "BackupTime",
+}
+
+// counters written straight into \info
+var rtfInfoCounters = map[string]string{
+	"version": "RevisionNumber", "vern": "InternalVersionNumber", "edmins": "TotalEditTime",
+	"nofpages": "Pages", "nofwords": "Words", "nofchars": "Characters",
+	"nofcharsws": "CharactersWithSpaces", "id": "InternalIDNumber",
+}
+
+// destinations removed whole: document properties, custom properties and
+// the name of the program that wrote the file
+var rtfMetadataGroups = []string{"info", "userprops", "generator"}
+
+// tables of user names that other control words index, so their entries
+// are renamed rather than removed
+var rtfNameTables = []string{"revtbl", "protusertbl"}
+
+// annotation authors, by name and by initials
+var rtfAnnotationGroups = []string{"atnauthor", "atnid"}
+
+func readRTF(path string) ([]byte, []rtfGroup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read RTF document: %w", err)
+	}
+	groups, err := scanRTF(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid RTF document: %w", err)
+	}
+	return data, groups, nil
+}
+
+// the \info properties, custom properties, generator, and the names in
+// revision and annotation marks
+func extractRTFMetadata(path string) (map[string]any, error) {
+	data, groups, err := readRTF(path)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]any)
+	var revisionAuthors, annotationAuthors, annotationInitials []string
+	for _, g := range groups {
+		body := data[g.start:g.end]
+		switch {
+		case g.dest == "info":
+			for word, value := range rtfParams(body) {
+				if name := rtfInfoCounters[word]; name != "" && value > 0 {
+					metadata[name] = strconv.Itoa(value)
+					if word == "edmins" {
+						metadata[name] = (time.Duration(value) * time.Minute).String()
+					}
+				}
+			}
+			for _, child := range rtfChildren(groups, g) {
+				text := rtfText(data[child.start:child.end])
+				if name := rtfInfoFields[child.dest]; name != "" && text != "" {
+					metadata[name] = text
+				}
+				if name := rtfInfoDates[child.dest]; name != "" {
+					if date, ok := rtfDate(data[child.start:child.end]); ok {
+						metadata[name] = date.Format("2006:01:02 15:04:05")
+					}
+				}
+			}
+		case g.dest == "userprops":
+			name := ""
+			for _, child := range rtfChildren(groups, g) {
+				switch child.dest {
+				case "propname":
+					name = rtfText(data[child.start:child.end])
+				case "staticval":
+					if value := rtfText(data[child.start:child.end]); name != "" && value != "" {
+						metadata[name] = value
+					}
+				}
+			}
+		case g.dest == "generator":
+			if text := strings.TrimSuffix(rtfText(body), ";"); text != "" {
+				metadata["Software"] = text
+			}
+		case slices.Contains(rtfNameTables, g.dest):
+			for _, child := range rtfChildren(groups, g) {
+				name := strings.TrimSuffix(rtfText(data[child.start:child.end]), ";")
+				if name != "" && name != "Unknown" && !slices.Contains(revisionAuthors, name) {
+					revisionAuthors = append(revisionAuthors, name)
+				}
+			}
+		case g.dest == "atnauthor":
+			if name := rtfText(body); name != "" && !slices.Contains(annotationAuthors, name) {
+				annotationAuthors = append(annotationAuthors, name)
+			}
+		case g.dest == "atnid":
+			if id := rtfText(body); id != "" && !slices.Contains(annotationInitials, id) {
+				annotationInitials = append(annotationInitials, id)
+			}
+		}
+	}
+
+	if len(revisionAuthors) > 0 {
+		metadata["RevisionAuthors"] = strings.Join(revisionAuthors, ", ")
+	}
+	if len(annotationAuthors) > 0 {
+		metadata["AnnotationAuthors"] = strings.Join(annotationAuthors, ", ")
+	}
+	if len(annotationInitials) > 0 {
+		metadata["AnnotationInitials"] = strings.Join(annotationInitials, ", ")
+	}
+	return metadata, nil
+}
+
+// {\creatim\yr2019\mo3\dy4\hr10\min20}
+func rtfDate(group []byte) (time.Time, bool) {
+	p := rtfParams(group)
+	if p["yr"] == 0 || p["mo"] == 0 || p["dy"] == 0 {
+		return time.Time{}, false
+	}
+	return time.Date(p["yr"], time.Month(p["mo"]), p["dy"], p["hr"], p["min"], p["sec"], 0, time.UTC), true
+}
+
+// ╭─ WIPE ──────────────────────────────────────╮
+
+// a byte range of the document and what replaces it
+type rtfEdit struct {
+	start, end int
+	text       string
+}
+
+// applies edits that do not overlap; one inside an earlier edit is dropped
+func applyRTFEdits(data []byte, edits []rtfEdit) []byte {
+	slices.SortFunc(edits, func(a, b rtfEdit) int {
+		if a.start != b.start {
+			return a.start - b.start
+		}
+		return a.end - b.end // an insertion before a removal at the same place
+	})
+	var out bytes.Buffer
+	pos := 0
+	for _, edit := range edits {
+		if edit.start < pos {
+			continue
+		}
+		out.Write(data[pos:edit.start])
+		out.WriteString(edit.text)
+		pos = edit.end
+	}
+	out.Write(data[pos:])
+	return out.Bytes()
+}
+
+// the edits that strip a document's metadata
+func rtfWipeEdits(data []byte, groups []rtfGroup) []rtfEdit {
+	var edits []rtfEdit
+	for _, g := range groups {
+		switch {
+		case slices.Contains(rtfMetadataGroups, g.dest):
+			edits = append(edits, rtfEdit{g.start, g.end, ""})
+		case slices.Contains(rtfNameTables, g.dest):
+			// revision marks point into the table by position
+			for _, child := range rtfChildren(groups, g) {
+				edits = append(edits, rtfEdit{child.start, child.end, "{Unknown;}"})
+			}
+		case slices.Contains(rtfAnnotationGroups, g.dest):
+			edits = append(edits, rtfEdit{g.start, g.end, `{\*\` + g.dest + ` }`})
+		}
+	}
+	return edits
+}
+
+// removes \info, custom properties and the generator, and renames the
+// authors of revisions and annotations
+func wipeRTFMetadata(path string) error {
+	data, groups, err := readRTF(path)
+	if err != nil {
+		return err
+	}
+	cleaned := applyRTFEdits(data, rtfWipeEdits(data, groups))
+	if err := os.WriteFile(path, cleaned, 0644); err != nil {
+		return fmt.Errorf("failed to write cleaned RTF document: %w", err)
+	}
+	return nil
+}
+
+// ╭─ INJECT ────────────────────────────────────╮
+
+// header destinations the \info group follows
+var rtfHeaderGroups = []string{
+	"fonttbl", "filetbl", "colortbl", "stylesheet", "listtable",
+	"listoverridetable", "revtbl", "rsidtbl", "generator",
+}
+
+// profile fields as an \info group (and a generator for software),
+// replacing the fields already there
+func injectRTFMetadata(path string, profile map[string]string) error {
+	data, groups, err := readRTF(path)
+	if err != nil {
+		return err
+	}
+
+	var info, generator strings.Builder
+	for _, key := range sortedKeys(profile) {
+		value := profile[key]
+		if value == "" {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "author":
+			fmt.Fprintf(&info, `{\author %s}`, rtfEscape(value))
+		case "comment":
+			fmt.Fprintf(&info, `{\doccomm %s}`, rtfEscape(value))
+		case "organization":
+			fmt.Fprintf(&info, `{\*\company %s}`, rtfEscape(value))
+		case "created":
+			date, err := time.Parse("2006:01:02 15:04:05", value)
+			if err != nil {
+				return fmt.Errorf("invalid date %q: %w", value, err)
+			}
+			fmt.Fprintf(&info, `{\creatim\yr%d\mo%d\dy%d\hr%d\min%d}`,
+				date.Year(), date.Month(), date.Day(), date.Hour(), date.Minute())
+		case "software":
+			fmt.Fprintf(&generator, `{\*\generator %s;}`, rtfEscape(value))
+		}
+	}
+	if info.Len() == 0 && generator.Len() == 0 {
+		return nil
+	}
+
+	// drop the old groups, then add the new ones after the header tables
+	edits := make([]rtfEdit, 0)
+	insert := -1
+	for _, g := range groups {
+		if g.depth != 1 {
+			continue
+		}
+		if g.dest == "info" || (g.dest == "generator" && generator.Len() > 0) {
+			edits = append(edits, rtfEdit{g.start, g.end, ""})
+		}
+		if slices.Contains(rtfHeaderGroups, g.dest) {
+			insert = g.end
+		}
+	}
+	if insert < 0 {
+		_, _, insert = rtfControl(data, 1) // after \rtf1
+	}
+	added := generator.String()
+	if info.Len() > 0 {
+		added += `{\info` + info.String() + `}`
+	}
+	edits = append(edits, rtfEdit{insert, insert, added})
+
+	if err := os.WriteFile(path, applyRTFEdits(data, edits), 0644); err != nil {
+		return fmt.Errorf("failed to write RTF document with metadata: %w", err)
+	}
+	return nil
+}
+
+// text for an RTF destination: braces and backslashes escaped, and
+// anything beyond ASCII as \uN with a "?" for readers without Unicode
+func rtfEscape(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x80:
+			b.WriteRune(r)
+		default:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%d?`, int16(unit))
+			}
+		}
+	}
+	return b.String()
+}
+
+// ╭─ EMBEDDED ──────────────────────────────────╮
+
+// OLE objects embedded in the text; each is a whole file of its own, with
+// its own properties
+func listRTFEmbedded(path string) ([]Embedded, error) {
+	data, groups, err := readRTF(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded []Embedded
+	for _, g := range groups {
+		if g.dest != "object" {
+			continue
+		}
+		class := "object"
+		for _, child := range rtfChildren(groups, g) {
+			if child.dest == "objclass" {
+				class = rtfText(data[child.start:child.end])
+			}
+		}
+		embedded = append(embedded, Embedded{
+			Kind:   "data",
+			Name:   fmt.Sprintf("embedded %s #%d", class, len(embedded)+1),
+			Detail: "OLE object; its own properties are not wiped",
+		})
+	}
+	return embedded, nil
+}
+
+// the braces balance and \bin data fits
+func verifyRTF(path string) bool {
+	_, _, err := readRTF(path)
+	return err == nil
+}
@@ -0,0 +1,311 @@
+// BYZRA ⸻ internal/formats/sqlite.go
+// SQLite handler: header fields and leftover free space reported, the
+// database rebuilt with VACUUM
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"caligra/internal/util"
+)
+
+// implements FormatHandler for SQLite 3 databases; tables and rows are
+// content and are never changed
+type SQLiteHandler struct{}
+
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// application IDs registered with SQLite (magic.txt)
+var sqliteApplications = map[uint32]string{
+	0x0f055111: "Fossil global configuration",
+	0x0f055112: "Fossil repository",
+	0x0f055113: "Fossil checkout",
+	0x47504b47: "GeoPackage",
+	0x47503130: "GeoPackage 1.0",
+	0x47503131: "GeoPackage 1.1",
+	0x4d504258: "MBTiles",
+}
+
+// the fields of the 100-byte database header the handler reads
+type sqliteHeader struct {
+	pageSize      int64
+	usable        int64 // page size less the bytes reserved at the end of each page
+	pages         int64
+	freelistTrunk uint32
+	freelistPages uint32
+	autoVacuum    bool // pointer map pages are interleaved
+	userVersion   uint32
+	applicationID uint32
+	wal           bool
+	version       uint32 // SQLITE_VERSION_NUMBER of the last library to write the file
+}
+
+// "sqlite" for a SQLite 3 database, "" otherwise
+func SQLiteType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(sqliteMagic))
+	if _, err := io.ReadFull(file, magic); err != nil || !bytes.Equal(magic, sqliteMagic) {
+		return ""
+	}
+	return "sqlite"
+}
+
+func readSQLiteHeader(file *os.File) (*sqliteHeader, error) {
+	data := make([]byte, 100)
+	if _, err := io.ReadFull(file, data); err != nil || !bytes.HasPrefix(data, sqliteMagic) {
+		return nil, fmt.Errorf("not a SQLite 3 database")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &sqliteHeader{
+		pageSize:      int64(binary.BigEndian.Uint16(data[16:])),
+		freelistTrunk: binary.BigEndian.Uint32(data[32:]),
+		freelistPages: binary.BigEndian.Uint32(data[36:]),
+		autoVacuum:    binary.BigEndian.Uint32(data[52:]) != 0,
+		userVersion:   binary.BigEndian.Uint32(data[60:]),
+		applicationID: binary.BigEndian.Uint32(data[68:]),
+		wal:           data[18] == 2,
+		version:       binary.BigEndian.Uint32(data[96:]),
+	}
+	if h.pageSize == 1 {
+		h.pageSize = 65536
+	}
+	if h.pageSize < 512 || h.pageSize&(h.pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size %d", h.pageSize)
+	}
+	h.usable = h.pageSize - int64(data[20])
+	if h.usable < 480 {
+		return nil, fmt.Errorf("invalid SQLite reserved space %d", data[20])
+	}
+
+	// the page count in the header is only current when the library that
+	// last wrote it knew of it; the file's length always is
+	h.pages = info.Size() / h.pageSize
+	if stored := int64(binary.BigEndian.Uint32(data[28:])); stored > 0 && stored < h.pages &&
+		binary.BigEndian.Uint32(data[92:]) == binary.BigEndian.Uint32(data[24:]) {
+		h.pages = stored
+	}
+	return h, nil
+}
+
+// ╭─ HANDLER ───────────────────────────────────╮
+
+// the application ID and user version, the library that last wrote the
+// file, the page count, and the free space that was not zeroed: freelist
+// pages and gaps inside tables and indexes keep what deleted or moved rows
+// held until the database is rebuilt
+func (h *SQLiteHandler) ExtractMetadata(path string) (map[string]any, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer file.Close()
+	header, err := readSQLiteHeader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]any)
+	if header.applicationID != 0 {
+		// decimal, as PRAGMA application_id shows it
+		id := fmt.Sprint(header.applicationID)
+		if name, ok := sqliteApplications[header.applicationID]; ok {
+			id += " (" + name + ")"
+		}
+		metadata["ApplicationID"] = id
+	}
+	if header.userVersion != 0 {
+		metadata["UserVersion"] = header.userVersion
+	}
+	if v := header.version; v != 0 {
+		metadata["SQLiteVersion"] = fmt.Sprintf("%d.%d.%d", v/1000000, v/1000%1000, v%1000)
+	}
+	if header.wal {
+		metadata["JournalMode"] = "WAL"
+	}
+
+	pages := pluralCount(int(header.pages), "page") + " of " + util.FormatBytes(header.pageSize)
+	if header.freelistPages > 0 {
+		pages += fmt.Sprintf(", %d on the freelist", header.freelistPages)
+	}
+	metadata["Pages"] = pages
+
+	if space := scanSQLiteFreeSpace(file, header); space.freePages > 0 || space.btreePages > 0 {
+		var parts []string
+		if space.freePages > 0 {
+			parts = append(parts, pluralCount(space.freePages, "freelist page"))
+		}
+		if space.btreePages > 0 {
+			parts = append(parts, fmt.Sprintf("gaps in %s", pluralCount(space.btreePages, "table or index page")))
+		}
+		metadata["DeletedData"] = fmt.Sprintf("%s of free space not zeroed (%s)",
+			util.FormatBytes(space.bytes), strings.Join(parts, ", "))
+	}
+	return metadata, nil
+}
+
+// rebuilds the database with VACUUM; the header fields the application
+// reads (application ID, user version) are kept
+func (h *SQLiteHandler) WipeMetadata(path string) error {
+	if SQLiteType(path) == "" {
+		return fmt.Errorf("not a SQLite 3 database")
+	}
+	return util.SQLiteVacuum(path)
+}
+
+// databases hold no profile fields
+func (h *SQLiteHandler) InjectMetadata(path string, profile map[string]string) error {
+	return nil
+}
+
+// PRAGMA quick_check passes
+func (h *SQLiteHandler) VerifyIntegrity(path string) bool {
+	return util.SQLiteQuickCheck(path) == nil
+}
+
+// ╭─ FREE SPACE ────────────────────────────────╮
+
+// free space holding non-zero bytes: whole freelist pages, and the gaps
+// and freeblocks of table and index pages
+type sqliteFreeSpace struct {
+	freePages  int
+	btreePages int
+	bytes      int64
+}
+
+// walks the freelist, then reads every other page that parses as a b-tree
+// page. Overflow pages start with a page number, which for files under 16M
+// pages never looks like a b-tree page flag; pointer map pages are skipped
+func scanSQLiteFreeSpace(file *os.File, header *sqliteHeader) sqliteFreeSpace {
+	var space sqliteFreeSpace
+	page := make([]byte, header.pageSize)
+	read := func(number uint32) bool {
+		if number == 0 || int64(number) > header.pages {
+			return false
+		}
+		_, err := file.ReadAt(page, (int64(number)-1)*header.pageSize)
+		return err == nil
+	}
+
+	// trunk pages: the next trunk, a count, then as many leaf page numbers;
+	// leaves are free whole
+	free := make(map[uint32]bool)
+	var leaves []uint32
+	for trunk := header.freelistTrunk; read(trunk) && !free[trunk]; trunk = binary.BigEndian.Uint32(page) {
+		free[trunk] = true
+		count := min(int64(binary.BigEndian.Uint32(page[4:])), header.usable/4-2)
+		for i := range count {
+			leaves = append(leaves, binary.BigEndian.Uint32(page[8+4*i:]))
+		}
+		if end := 8 + 4*count; nonZero(page[end:header.usable]) {
+			space.freePages++
+			space.bytes += header.usable - end
+		}
+	}
+	for _, leaf := range leaves {
+		if free[leaf] || !read(leaf) {
+			continue
+		}
+		free[leaf] = true
+		if nonZero(page[:header.usable]) {
+			space.freePages++
+			space.bytes += header.usable
+		}
+	}
+
+	// with auto-vacuum, page 2 and every usable/5+1 pages after it map
+	// pages to their parents
+	ptrmapStride := int64(header.usable/5 + 1)
+	for number := int64(1); number <= header.pages; number++ {
+		if free[uint32(number)] || (header.autoVacuum && number >= 2 && (number-2)%ptrmapStride == 0) {
+			continue
+		}
+		if !read(uint32(number)) {
+			continue
+		}
+		start := 0
+		if number == 1 {
+			start = 100 // after the database header
+		}
+		if n := sqliteBtreeSlack(page[:header.usable], start); n > 0 {
+			space.btreePages++
+			space.bytes += n
+		}
+	}
+	return space
+}
+
+// the bytes of a b-tree page's unused regions that are not all zero: the
+// gap between the cell pointers and the cell content, and each freeblock
+// past its 4-byte link. 0 for pages that are not b-tree pages
+func sqliteBtreeSlack(page []byte, start int) int64 {
+	if start+8 > len(page) {
+		return 0
+	}
+	headerSize := 8
+	switch page[start] {
+	case 2, 5: // interior index and table pages
+		headerSize = 12
+	case 10, 13: // leaves
+	default:
+		return 0
+	}
+	cells := int(binary.BigEndian.Uint16(page[start+3:]))
+	content := int(binary.BigEndian.Uint16(page[start+5:]))
+	if content == 0 {
+		content = 65536
+	}
+	pointers := start + headerSize + 2*cells
+	if pointers > content || content > len(page) {
+		return 0
+	}
+
+	var slack int64
+	if nonZero(page[pointers:content]) {
+		slack += int64(content - pointers)
+	}
+	// freeblocks are chained in order of offset, within the content area
+	for block := int(binary.BigEndian.Uint16(page[start+1:])); block != 0; {
+		if block < content || block+4 > len(page) {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(page[block+2:]))
+		if size < 4 || block+size > len(page) {
+			break
+		}
+		if nonZero(page[block+4 : block+size]) {
+			slack += int64(size - 4)
+		}
+		next := int(binary.BigEndian.Uint16(page[block:]))
+		if next != 0 && next <= block {
+			break
+		}
+		block = next
+	}
+	return slack
+}
+
+// ╭─ HELPERS ───────────────────────────────────╮
+
+func nonZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,349 @@
+// BYZRA ⸻ internal/formats/subtitle.go
+// SubRip, SubStation Alpha and WebVTT subtitles
+
+package formats
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// subtitle kinds, by extension; .ssa is the older SubStation Alpha layout
+// of the same sections
+var subtitleExtensions = map[string]string{
+	".srt": "srt",
+	".ass": "ass",
+	".ssa": "ass",
+	".vtt": "vtt",
+}
+
+// the first cue of a SubRip file: a counter, then a timing line
+var srtCuePattern = regexp.MustCompile(`^\d+\r?\n\d{1,2}:\d{2}:\d{2}[,.]\d{1,3} --> \d{1,2}:\d{2}:\d{2}[,.]\d{1,3}`)
+
+// "srt", "ass" or "vtt" for a subtitle file, "" for other text; the
+// extension decides, and content is checked for text without a known one
+func SubtitleType(path string, content []byte) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if kind, ok := subtitleExtensions[ext]; ok {
+		return kind
+	}
+	if ext == ".html" || ext == ".htm" || ext == ".md" {
+		return ""
+	}
+
+	head := strings.TrimLeft(strings.TrimPrefix(string(content[:min(len(content), 512)]), "\ufeff"), " \t\r\n")
+	switch {
+	case strings.HasPrefix(strings.ToLower(head), "[script info]"):
+		return "ass"
+	case head == "WEBVTT" || strings.HasPrefix(head, "WEBVTT ") ||
+		strings.HasPrefix(head, "WEBVTT\t") || strings.HasPrefix(head, "WEBVTT\n") ||
+		strings.HasPrefix(head, "WEBVTT\r"):
+		return "vtt"
+	case srtCuePattern.MatchString(head):
+		return "srt"
+	}
+	return ""
+}
+
+// ╭─ SUBSTATION ALPHA ──────────────────────────╮
+
+// [Script Info] keys that change how the script renders; the rest (Title,
+// Original Script, Synch Point, Script Updated By, ...) credits people or
+// tools and is removed
+var assRenderKeys = []string{
+	"scripttype", "playresx", "playresy", "playdepth", "layoutresx", "layoutresy",
+	"wrapstyle", "scaledborderandshadow", "ycbcr matrix", "collisions", "timer", "kerning",
+}
+
+// sections left by editors or holding attachments, removed whole
+var assDroppedSections = []string{
+	"aegisub project garbage", "aegisub extradata", "fonts", "graphics",
+}
+
+// a line with its line ending, and the section it sits in
+type assLine struct {
+	text    string
+	section string // lowercase, without brackets
+}
+
+// splits a script into lines, each with its ending and section
+func splitASS(content string) []assLine {
+	var lines []assLine
+	section := ""
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.ToLower(trimmed[1 : len(trimmed)-1])
+		}
+		lines = append(lines, assLine{text: line, section: section})
+	}
+	return lines
+}
+
+// "Key: Value" → "Key", "Value"
+func splitASSField(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.TrimSpace(key), strings.TrimSpace(value), ok
+}
+
+func isSectionHeader(line string) bool {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+	return strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]")
+}
+
+// credits and notes from [Script Info], and the media paths Aegisub keeps
+// in its project section; "; Script generated by ..." comments become
+// Software
+func extractASSMetadata(content string, metadata map[string]any) {
+	var comments []string
+	for _, line := range splitASS(content) {
+		if isSectionHeader(line.text) {
+			continue
+		}
+		trimmed := strings.TrimSpace(line.text)
+		switch line.section {
+		case "script info":
+			if strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "!:") {
+				comment := strings.TrimSpace(strings.TrimLeft(trimmed, ";!:"))
+				if _, software, ok := strings.Cut(comment, "generated by "); ok {
+					metadata["Software"] = strings.TrimSpace(software)
+				} else if comment != "" {
+					comments = append(comments, comment)
+				}
+				continue
+			}
+			key, value, ok := splitASSField(trimmed)
+			if ok && key != "" && value != "" && !slices.Contains(assRenderKeys, strings.ToLower(key)) {
+				metadata[key] = value
+			}
+		case "aegisub project garbage":
+			if key, value, ok := splitASSField(trimmed); ok && value != "" {
+				metadata[key] = value
+			}
+		}
+	}
+	if len(comments) > 0 {
+		metadata["Comment"] = strings.Join(comments, "; ")
+	}
+}
+
+// keeps the rendering keys of [Script Info], every style and every event;
+// credits, comments, editor sections and attachments go
+func removeASSMetadata(content string) string {
+	var b strings.Builder
+	for _, line := range splitASS(content) {
+		if slices.Contains(assDroppedSections, line.section) {
+			continue
+		}
+		if line.section == "script info" && !isSectionHeader(line.text) {
+			trimmed := strings.TrimSpace(line.text)
+			key, _, ok := splitASSField(trimmed)
+			if trimmed != "" && (!ok || !slices.Contains(assRenderKeys, strings.ToLower(key))) {
+				continue
+			}
+		}
+		b.WriteString(line.text)
+	}
+	return b.String()
+}
+
+// fonts and pictures attached in [Fonts] and [Graphics], uuencoded after a
+// "fontname:" or "filename:" line; Aegisub's extradata is listed too
+func listASSEmbedded(content string) []Embedded {
+	var embedded []Embedded
+	var sizes []int // decoded bytes of each attachment
+	for _, line := range splitASS(content) {
+		if isSectionHeader(line.text) {
+			if line.section == "aegisub extradata" {
+				embedded = append(embedded, Embedded{Kind: "data", Name: "Aegisub extradata", Detail: "editor data attached to events"})
+				sizes = append(sizes, -1)
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line.text)
+		key, value, _ := splitASSField(trimmed)
+		switch {
+		case line.section == "fonts" && strings.EqualFold(key, "fontname"):
+			embedded = append(embedded, Embedded{Kind: "font", Name: value, Detail: "embedded font"})
+			sizes = append(sizes, 0)
+		case line.section == "graphics" && strings.EqualFold(key, "filename"):
+			embedded = append(embedded, Embedded{Kind: "picture", Name: value, Detail: "embedded picture"})
+			sizes = append(sizes, 0)
+		case (line.section == "fonts" || line.section == "graphics") && trimmed != "" && len(sizes) > 0:
+			// uuencoded: four characters for every three bytes
+			sizes[len(sizes)-1] += len(trimmed) * 3 / 4
+		}
+	}
+	for i, size := range sizes {
+		if size > 0 {
+			embedded[i].Detail += fmt.Sprintf(", %d bytes", size)
+		}
+	}
+	return embedded
+}
+
+// profile fields as "Key: Value" lines at the top of [Script Info]
+func injectASSMetadata(content string, profile map[string]string) string {
+	fields := ""
+	for _, key := range sortedKeys(profile) {
+		fields += fmt.Sprintf("%s: %s\n", key, profile[key])
+	}
+
+	lines := splitASS(content)
+	for i, line := range lines {
+		if isSectionHeader(line.text) && line.section == "script info" {
+			if strings.HasSuffix(line.text, "\r\n") {
+				fields = strings.ReplaceAll(fields, "\n", "\r\n")
+			}
+			var b strings.Builder
+			for _, l := range lines[:i+1] {
+				b.WriteString(l.text)
+			}
+			b.WriteString(fields)
+			for _, l := range lines[i+1:] {
+				b.WriteString(l.text)
+			}
+			return b.String()
+		}
+	}
+	return "[Script Info]\n" + fields + "\n" + content
+}
+
+// ╭─ WEBVTT ────────────────────────────────────╮
+
+// header lines a player reads: the cue kind and language of the old
+// metadata headers, and the MPEG-TS timestamp mapping of HLS
+var vttRenderHeaders = []string{"kind", "language", "x-timestamp-map"}
+
+// WebVTT blocks are separated by blank lines
+var vttBlankLines = regexp.MustCompile(`\r?\n(?:[ \t]*\r?\n)+`)
+
+// splits a WebVTT file into blocks, each with the blank lines that follow it
+func splitVTT(content string) []string {
+	var blocks []string
+	for {
+		loc := vttBlankLines.FindStringIndex(content)
+		if loc == nil {
+			if content != "" {
+				blocks = append(blocks, content)
+			}
+			return blocks
+		}
+		blocks = append(blocks, content[:loc[1]])
+		content = content[loc[1]:]
+	}
+}
+
+// "Kind: captions" → "kind", "X-TIMESTAMP-MAP=LOCAL:..." → "x-timestamp-map"
+func vttHeaderKey(line string) string {
+	if i := strings.IndexAny(line, ":="); i >= 0 {
+		line = line[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(line))
+}
+
+func isVTTNote(block string) bool {
+	return block == "NOTE" || strings.HasPrefix(block, "NOTE ") ||
+		strings.HasPrefix(block, "NOTE\t") || strings.HasPrefix(block, "NOTE\n") ||
+		strings.HasPrefix(block, "NOTE\r")
+}
+
+// the header's lines after "WEBVTT": its own text, then "Key: Value" lines
+func vttHeaderLines(header string) (string, []string) {
+	lines := strings.Split(strings.TrimRight(header, "\r\n \t"), "\n")
+	first := strings.Trim(strings.TrimPrefix(strings.TrimPrefix(lines[0], "\ufeff"), "WEBVTT"), " \t\r-")
+	return first, lines[1:]
+}
+
+// the text after WEBVTT, header fields and NOTE comments
+func extractVTTMetadata(content string, metadata map[string]any) {
+	blocks := splitVTT(content)
+	if len(blocks) == 0 || !strings.HasPrefix(strings.TrimPrefix(blocks[0], "\ufeff"), "WEBVTT") {
+		return
+	}
+
+	title, fields := vttHeaderLines(blocks[0])
+	if title != "" {
+		metadata["Title"] = title
+	}
+	for _, line := range fields {
+		key, value, ok := splitASSField(line)
+		if ok && value != "" && !slices.Contains(vttRenderHeaders, vttHeaderKey(line)) {
+			metadata[key] = value
+		}
+	}
+
+	var notes []string
+	for _, block := range blocks[1:] {
+		if isVTTNote(block) {
+			note := strings.Join(strings.Fields(strings.TrimPrefix(block, "NOTE")), " ")
+			if note != "" {
+				notes = append(notes, note)
+			}
+		}
+	}
+	if len(notes) > 0 {
+		metadata["Note"] = strings.Join(notes, "; ")
+	}
+}
+
+// a bare "WEBVTT" header keeping only the fields players read; NOTE
+// blocks go, STYLE, REGION and cues stay
+func removeVTTMetadata(content string) string {
+	blocks := splitVTT(content)
+	if len(blocks) == 0 || !strings.HasPrefix(strings.TrimPrefix(blocks[0], "\ufeff"), "WEBVTT") {
+		return content
+	}
+
+	newline := "\n"
+	if strings.Contains(blocks[0], "\r\n") {
+		newline = "\r\n"
+	}
+
+	var b strings.Builder
+	if strings.HasPrefix(blocks[0], "\ufeff") {
+		b.WriteString("\ufeff")
+	}
+	b.WriteString("WEBVTT" + newline)
+	_, fields := vttHeaderLines(blocks[0])
+	for _, line := range fields {
+		if slices.Contains(vttRenderHeaders, vttHeaderKey(line)) {
+			b.WriteString(strings.TrimRight(line, "\r") + newline)
+		}
+	}
+	if len(blocks) > 1 {
+		b.WriteString(newline)
+	}
+
+	for _, block := range blocks[1:] {
+		if !isVTTNote(block) {
+			b.WriteString(block)
+		}
+	}
+	return b.String()
+}
+
+// profile fields as "Key: Value" header lines under WEBVTT
+func injectVTTMetadata(content string, profile map[string]string) string {
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+	fields := ""
+	for _, key := range sortedKeys(profile) {
+		fields += fmt.Sprintf("%s: %s%s", key, profile[key], newline)
+	}
+
+	end := strings.Index(content, "\n")
+	if !strings.HasPrefix(strings.TrimPrefix(content, "\ufeff"), "WEBVTT") || end < 0 {
+		return "WEBVTT" + newline + fields + newline + content
+	}
+	return content[:end+1] + fields + content[end+1:]
+}
@@ -0,0 +1,451 @@
+// BYZRA ⸻ internal/formats/tararchive.go
+// TAR archives, plain or gzip-compressed: entry owners and dates read, the
+// archive rewritten
+
+package formats
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// the date a wipe gives every entry, as in ZIP archives
+var tarResetDate = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// PAX records archive/tar reads into the header's own fields
+var tarHeaderRecords = []string{"path", "linkpath", "size", "uid", "gid", "uname", "gname", "mtime", "atime", "ctime"}
+
+// systems in the OS byte of a gzip header
+var gzipHostSystems = map[byte]string{
+	0: "MS-DOS/Windows", 3: "Unix", 7: "Macintosh", 11: "Windows NTFS",
+}
+
+// "tar" for a TAR archive, "tgz" for a gzip-compressed one, "" otherwise
+func TarArchiveType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	kind := "tar"
+	var r io.Reader = file
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return ""
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return ""
+		}
+		defer gz.Close()
+		kind, r = "tgz", gz
+	}
+
+	block := make([]byte, 512)
+	if _, err := io.ReadFull(r, block); err != nil || !isTarHeader(block) {
+		return ""
+	}
+	return kind
+}
+
+// a header block whose checksum matches: the sum of its bytes, the checksum
+// field counted as spaces
+func isTarHeader(block []byte) bool {
+	stored, err := strconv.ParseUint(strings.Trim(string(block[148:156]), " \x00"), 8, 32)
+	if err != nil {
+		return false
+	}
+	var sum uint64
+	for i, b := range block {
+		if i >= 148 && i < 156 {
+			b = ' '
+		}
+		sum += uint64(b)
+	}
+	return sum == stored
+}
+
+// a TAR archive being read, through gzip when it is compressed
+type tarSource struct {
+	*tar.Reader
+	file *os.File
+	gz   *gzip.Reader // nil for a plain archive
+}
+
+func openTar(path string) (*tarSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	src := &tarSource{file: file}
+	var r io.Reader = file
+	if TarArchiveType(path) == "tgz" {
+		if src.gz, err = gzip.NewReader(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to open archive: %w", err)
+		}
+		r = src.gz
+	}
+	src.Reader = tar.NewReader(r)
+	return src, nil
+}
+
+func (s *tarSource) Close() error {
+	if s.gz != nil {
+		s.gz.Close()
+	}
+	return s.file.Close()
+}
+
+// ╭─ READING ───────────────────────────────────╮
+
+func extractTarMetadata(path string) (map[string]any, error) {
+	src, err := openTar(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	metadata := make(map[string]any)
+	if src.gz != nil {
+		// gzip keeps the name and date of the file it compressed
+		if src.gz.Name != "" {
+			metadata["OriginalFilename"] = src.gz.Name
+		}
+		if src.gz.Comment != "" {
+			metadata["GzipComment"] = src.gz.Comment
+		}
+		if !src.gz.ModTime.IsZero() {
+			metadata["GzipModifyDate"] = src.gz.ModTime.UTC().Format("2006:01:02 15:04:05")
+		}
+		if system := gzipHostSystems[src.gz.OS]; system != "" {
+			metadata["HostSystem"] = system
+		}
+	}
+
+	members := 0
+	for {
+		header, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		// global headers apply to every entry; git archive stores the
+		// commit ID in one
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			if comment := header.PAXRecords["comment"]; comment != "" {
+				metadata["Comment"] = comment
+			}
+			if records := tarExtraRecords(header.PAXRecords); records != "" {
+				metadata["PAXRecords"] = records
+			}
+			continue
+		}
+
+		members++
+		if members > archiveMaxListed {
+			continue
+		}
+		for field, value := range tarMemberFields(header) {
+			metadata[header.Name+": "+field] = value
+		}
+	}
+	metadata["Members"] = members
+	if members > archiveMaxListed {
+		metadata["MembersNotListed"] = members - archiveMaxListed
+	}
+	return metadata, nil
+}
+
+// an entry's owner, dates and PAX records; values a wipe sets (UID and GID
+// 0, no names, the 1980-01-01 date) are left out
+func tarMemberFields(header *tar.Header) map[string]string {
+	fields := make(map[string]string)
+	if !header.ModTime.Equal(tarResetDate) {
+		fields["ModifyDate"] = header.ModTime.UTC().Format("2006:01:02 15:04:05")
+	}
+	if !header.AccessTime.IsZero() {
+		fields["AccessDate"] = header.AccessTime.UTC().Format("2006:01:02 15:04:05")
+	}
+	if !header.ChangeTime.IsZero() {
+		fields["InodeChangeDate"] = header.ChangeTime.UTC().Format("2006:01:02 15:04:05")
+	}
+	if header.Uid != 0 || header.Gid != 0 {
+		fields["Owner"] = fmt.Sprintf("UID %d, GID %d", header.Uid, header.Gid)
+	}
+	if header.Uname != "" {
+		fields["UserName"] = header.Uname
+	}
+	if header.Gname != "" {
+		fields["GroupName"] = header.Gname
+	}
+	if records := tarExtraRecords(header.PAXRecords); records != "" {
+		fields["PAXRecords"] = records
+	}
+	return fields
+}
+
+// the names of PAX records beyond the header fields: extended attributes
+// (SCHILY.xattr.*), file flags, creation times
+func tarExtraRecords(records map[string]string) string {
+	var names []string
+	for name := range records {
+		if !slices.Contains(tarHeaderRecords, name) && name != "comment" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// supported regular files, which a wipe runs their handler on
+func (h *ArchiveHandler) listTarMembers(path string) ([]Embedded, error) {
+	src, err := openTar(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var embedded []Embedded
+	for {
+		header, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || h.memberHandler(header.Name) == nil {
+			continue
+		}
+		if len(embedded) == archiveMaxListed {
+			embedded = append(embedded, Embedded{Kind: "member", Name: "…", Detail: "more supported members, wiped too"})
+			break
+		}
+		detail := fmt.Sprintf("%s, %s; wiped with the archive", memberFormat(header.Name), util.FormatBytes(header.Size))
+		embedded = append(embedded, Embedded{Kind: "member", Name: header.Name, Detail: detail})
+	}
+	return embedded, nil
+}
+
+// every entry reads to its end and, when compressed, the gzip checksum
+// matches
+func verifyTar(path string) bool {
+	src, err := openTar(path)
+	if err != nil {
+		return false
+	}
+	defer src.Close()
+
+	for {
+		_, err := src.Next()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+		if _, err := io.Copy(io.Discard, src); err != nil {
+			return false
+		}
+	}
+}
+
+// ╭─ WRITING ───────────────────────────────────╮
+
+// rewrites the archive with every entry owned by UID and GID 0 without
+// names, dated 1980-01-01, and with supported members wiped
+func (h *ArchiveHandler) wipeTar(path string) error {
+	dir, err := os.MkdirTemp("", "caligra-tar-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	stamp := func(header *tar.Header) {
+		header.Uid, header.Gid = 0, 0
+		header.Uname, header.Gname = "", ""
+		header.ModTime = tarResetDate
+	}
+	return rebuildTar(path, "", stamp, func(header *tar.Header, r io.Reader) (string, error) {
+		handler := h.memberHandler(header.Name)
+		if handler == nil || header.Typeflag != tar.TypeReg {
+			return "", nil
+		}
+		return wipeMember(handler, dir, header.Name, r)
+	})
+}
+
+// sets the profile's author as every entry's user and group name, its date
+// as their modification date and its comment as a global PAX comment
+func injectTar(path string, profile map[string]string) error {
+	var author, comment string
+	var date time.Time
+	for key, value := range profile {
+		switch strings.ToLower(key) {
+		case "author":
+			author = value
+		case "created":
+			if parsed, _, ok := parseProfileDate(value); ok {
+				date = parsed
+			}
+		case "comment":
+			comment = value
+		}
+	}
+	if author == "" && date.IsZero() && comment == "" {
+		return nil
+	}
+
+	return rebuildTar(path, comment, func(header *tar.Header) {
+		if author != "" {
+			header.Uname, header.Gname = author, author
+		}
+		if !date.IsZero() {
+			header.ModTime = date
+		}
+	}, nil)
+}
+
+// rewrites the TAR archive at path entry by entry, compressed again when it
+// was, with an empty gzip header. Entries keep type, name, link target,
+// mode and size, owner and date until stamp changes them; access and change
+// times, PAX records and global headers are dropped, and comment, when set,
+// becomes a new global header. A member replace returns a file for is
+// stored with that content instead
+func rebuildTar(path, comment string, stamp func(header *tar.Header), replace func(header *tar.Header, r io.Reader) (string, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	src, err := openTar(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return remuxInPlace(path, func(_, dst string) error {
+		out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		var gz *gzip.Writer
+		var w io.Writer = out
+		if src.gz != nil {
+			gz = gzip.NewWriter(out)
+			w = gz
+		}
+		tw := tar.NewWriter(w)
+		if comment != "" {
+			global := &tar.Header{Typeflag: tar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": comment}, Format: tar.FormatPAX}
+			if err := tw.WriteHeader(global); err != nil {
+				return err
+			}
+		}
+
+		for {
+			old, err := src.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read archive: %w", err)
+			}
+			if old.Typeflag == tar.TypeXGlobalHeader {
+				continue
+			}
+
+			header := &tar.Header{
+				Typeflag: old.Typeflag,
+				Name:     old.Name,
+				Linkname: old.Linkname,
+				Size:     old.Size,
+				Mode:     old.Mode,
+				Uid:      old.Uid,
+				Gid:      old.Gid,
+				Uname:    old.Uname,
+				Gname:    old.Gname,
+				ModTime:  old.ModTime,
+				Devmajor: old.Devmajor,
+				Devminor: old.Devminor,
+			}
+			if header.Typeflag == tar.TypeGNUSparse {
+				header.Typeflag = tar.TypeReg // read expanded, written whole
+			}
+			stamp(header)
+
+			var wiped string
+			if replace != nil {
+				if wiped, err = replace(header, src); err != nil {
+					return err
+				}
+			}
+			if wiped == "" {
+				if err := tw.WriteHeader(header); err != nil {
+					return fmt.Errorf("%s: %w", header.Name, err)
+				}
+				if _, err := io.Copy(tw, src); err != nil {
+					return fmt.Errorf("%s: %w", header.Name, err)
+				}
+				continue
+			}
+			if err := writeTarFile(tw, header, wiped); err != nil {
+				return err
+			}
+		}
+
+		if err := tw.Close(); err != nil {
+			return fmt.Errorf("failed to finish archive: %w", err)
+		}
+		if gz != nil {
+			if err := gz.Close(); err != nil {
+				return fmt.Errorf("failed to finish archive: %w", err)
+			}
+		}
+		src.Close() // before the file is replaced, which Windows requires
+		return out.Close()
+	})
+}
+
+// adds the content of the file at src as the entry header describes,
+// with its size
+func writeTarFile(tw *tar.Writer, header *tar.Header, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	defer os.Remove(src)
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	header.Size = info.Size()
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("%s: %w", header.Name, err)
+	}
+	if _, err := io.Copy(tw, in); err != nil {
+		return fmt.Errorf("%s: %w", header.Name, err)
+	}
+	return nil
+}
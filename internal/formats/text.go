@@ -5,9 +5,13 @@ package formats
 
 import (
 	"fmt"
+	"html"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"caligra/internal/util"
 )
 
 // implements FormatHandler for text files
@@ -23,6 +27,19 @@ func (h *TextHandler) ExtractMetadata(path string) (map[string]any, error) {
 
 	metadata := make(map[string]any)
 
+	// subtitles keep their credits in a header of their own; dialogue lines
+	// must not be read as "Author:" headers
+	switch SubtitleType(path, content) {
+	case "ass":
+		extractASSMetadata(string(content), metadata)
+		return metadata, nil
+	case "vtt":
+		extractVTTMetadata(string(content), metadata)
+		return metadata, nil
+	case "srt":
+		return metadata, nil
+	}
+
 	// HTML metadata in meta tags
 	if strings.HasSuffix(strings.ToLower(path), ".html") ||
 		strings.HasSuffix(strings.ToLower(path), ".htm") {
@@ -51,14 +68,21 @@ func (h *TextHandler) WipeMetadata(path string) error {
 	var newContent string
 
 	// process based on file type
-	if strings.HasSuffix(strings.ToLower(path), ".html") ||
-		strings.HasSuffix(strings.ToLower(path), ".htm") {
-		newContent = removeHTMLMetadata(string(content))
-	} else if strings.HasSuffix(strings.ToLower(path), ".md") {
-		newContent = removeMarkdownFrontMatter(string(content))
-	} else {
+	switch subtitle := SubtitleType(path, content); {
+	case subtitle == "srt":
+		return nil // counters, timings and dialogue only
+	case subtitle == "ass":
+		newContent = removeASSMetadata(string(content))
+	case subtitle == "vtt":
+		newContent = removeVTTMetadata(string(content))
+	case strings.HasSuffix(strings.ToLower(path), ".html") ||
+		strings.HasSuffix(strings.ToLower(path), ".htm"):
+		newContent = removePGPSignatures(removeHTMLMetadata(string(content)))
+	case strings.HasSuffix(strings.ToLower(path), ".md"):
+		newContent = removePGPSignatures(removeMarkdownFrontMatter(string(content)))
+	default:
 		// for general text, remove any lines that look like metadata
-		newContent = removeCommonTextMetadata(string(content))
+		newContent = removePGPSignatures(removeCommonTextMetadata(string(content)))
 	}
 
 	// write back to the file
@@ -80,12 +104,22 @@ func (h *TextHandler) InjectMetadata(path string, profile map[string]string) err
 	var newContent string
 
 	// process based on file type
-	if strings.HasSuffix(strings.ToLower(path), ".html") ||
-		strings.HasSuffix(strings.ToLower(path), ".htm") {
+	switch subtitle := SubtitleType(path, content); {
+	case subtitle == "srt":
+		if len(profile) > 0 {
+			return fmt.Errorf("SubRip files have no header to hold metadata")
+		}
+		return nil
+	case subtitle == "ass":
+		newContent = injectASSMetadata(string(content), profile)
+	case subtitle == "vtt":
+		newContent = injectVTTMetadata(string(content), profile)
+	case strings.HasSuffix(strings.ToLower(path), ".html") ||
+		strings.HasSuffix(strings.ToLower(path), ".htm"):
 		newContent = injectHTMLMetadata(string(content), profile)
-	} else if strings.HasSuffix(strings.ToLower(path), ".md") {
+	case strings.HasSuffix(strings.ToLower(path), ".md"):
 		newContent = injectMarkdownFrontMatter(string(content), profile)
-	} else {
+	default:
 		// for general text, add metadata as comments at the top
 		newContent = injectTextFileComments(string(content), profile)
 	}
@@ -98,6 +132,33 @@ func (h *TextHandler) InjectMetadata(path string, profile map[string]string) err
 	return nil
 }
 
+// lists OpenPGP signatures, keys and encrypted messages in the body;
+// encrypted messages are body content, so their recipients are only noted.
+// SubStation Alpha scripts list their attached fonts and pictures instead
+func (h *TextHandler) ListEmbedded(path string) ([]Embedded, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text file: %w", err)
+	}
+	switch SubtitleType(path, content) {
+	case "ass":
+		return listASSEmbedded(string(content)), nil
+	case "srt", "vtt":
+		return nil, nil
+	}
+
+	var embedded []Embedded
+	for i, block := range util.FindPGPBlocks(string(content)) {
+		embedded = append(embedded, Embedded{
+			Kind:     EmbeddedOpenPGP,
+			Name:     fmt.Sprintf("PGP %s #%d", block.Kind, i+1),
+			Detail:   block.Describe(),
+			Critical: block.Kind != util.PGPMessage,
+		})
+	}
+	return embedded, nil
+}
+
 // for text files just checks if the file is readable
 func (h *TextHandler) VerifyIntegrity(path string) bool {
 	_, err := os.ReadFile(path)
@@ -113,7 +174,7 @@ func extractHTMLMetadata(content string, metadata map[string]any) {
 
 	for _, match := range matches {
 		if len(match) == 3 {
-			metadata[match[1]] = match[2]
+			metadata[match[1]] = html.UnescapeString(match[2])
 		}
 	}
 
@@ -136,7 +197,7 @@ func extractMarkdownFrontMatter(content string, metadata map[string]any) {
 
 		for _, kv := range matches {
 			if len(kv) == 3 {
-				metadata[strings.TrimSpace(kv[1])] = strings.TrimSpace(kv[2])
+				metadata[strings.TrimSpace(kv[1])] = yamlUnquote(strings.TrimSpace(kv[2]))
 			}
 		}
 	}
@@ -198,25 +259,48 @@ func removeCommonTextMetadata(content string) string {
 	return content
 }
 
+var (
+	pgpSignedHeaderPattern = regexp.MustCompile(`-----BEGIN PGP SIGNED MESSAGE-----\r?\n(?:[^\r\n]+: [^\r\n]*\r?\n)*\r?\n`)
+	pgpStrippedPattern     = regexp.MustCompile(`(?s)\r?\n?-----BEGIN PGP (?:SIGNATURE|PUBLIC KEY BLOCK|PRIVATE KEY BLOCK)-----.*?-----END PGP [A-Z ]+-----\r?\n?`)
+)
+
+// drops signature and key blocks; clearsigned text keeps its message, with
+// the armor header gone and dash-escaping undone
+func removePGPSignatures(content string) string {
+	for {
+		loc := pgpSignedHeaderPattern.FindStringIndex(content)
+		if loc == nil {
+			break
+		}
+		end := strings.Index(content[loc[1]:], "-----BEGIN PGP SIGNATURE-----")
+		if end < 0 {
+			end = len(content) - loc[1]
+		}
+		message := content[loc[1] : loc[1]+end]
+		message = regexp.MustCompile(`(?m)^- `).ReplaceAllString(message, "")
+		content = content[:loc[0]] + message + content[loc[1]+end:]
+	}
+	return pgpStrippedPattern.ReplaceAllString(content, "\n")
+}
+
 // helper functions for injecting metadata
 
 func injectHTMLMetadata(content string, profile map[string]string) string {
-	// prepare meta tags
+	// prepare meta tags; values are escaped, and spliced in literally so a
+	// "$1" in one is not expanded
 	metaTags := ""
 	for key, value := range profile {
-		metaTags += fmt.Sprintf(`<meta name="%s" content="%s">`, key, value)
+		metaTags += fmt.Sprintf(`<meta name="%s" content="%s">`, html.EscapeString(key), html.EscapeString(value))
 	}
 
 	// find head tag to insert meta tags
-	headRegex := regexp.MustCompile(`<head[^>]*>`)
-	if headRegex.MatchString(content) {
-		return headRegex.ReplaceAllString(content, `$0`+metaTags)
+	if loc := regexp.MustCompile(`<head[^>]*>`).FindStringIndex(content); loc != nil {
+		return content[:loc[1]] + metaTags + content[loc[1]:]
 	}
 
 	// if no head tag, add one
-	if strings.Contains(strings.ToLower(content), "<html") {
-		htmlRegex := regexp.MustCompile(`<html[^>]*>`)
-		return htmlRegex.ReplaceAllString(content, `$0<head>`+metaTags+`</head>`)
+	if loc := regexp.MustCompile(`<html[^>]*>`).FindStringIndex(content); loc != nil {
+		return content[:loc[1]] + `<head>` + metaTags + `</head>` + content[loc[1]:]
 	}
 
 	// last resort, add at the beginning
@@ -230,7 +314,7 @@ func injectMarkdownFrontMatter(content string, profile map[string]string) string
 	// create new front matter
 	frontMatter := "---\n"
 	for key, value := range profile {
-		frontMatter += fmt.Sprintf("%s: %s\n", key, value)
+		frontMatter += fmt.Sprintf("%s: %s\n", key, yamlScalar(value))
 	}
 	frontMatter += "---\n\n"
 
@@ -247,3 +331,29 @@ func injectTextFileComments(content string, profile map[string]string) string {
 
 	return header + content
 }
+
+// value as a YAML scalar: plain when it reads back unchanged, double-quoted
+// otherwise (": ", a leading "#", "[" or quote, "yes", numbers, ...)
+func yamlScalar(value string) string {
+	plain := value != "" && value == strings.TrimSpace(value) &&
+		!strings.ContainsAny(value[:1], "!&*-?{}[],#|>@`\"'%:") &&
+		!strings.Contains(value, ": ") && !strings.Contains(value, " #") &&
+		!yamlSpecialPattern.MatchString(value)
+	if plain {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// scalars YAML would read as something other than a string
+var yamlSpecialPattern = regexp.MustCompile(`(?i)^(?:true|false|yes|no|on|off|y|n|null|~|[-+]?[0-9][0-9_.:eE+-]*|[-+]?\.(?:inf|nan))$`)
+
+// a front matter value as written by yamlScalar
+func yamlUnquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+	return value
+}
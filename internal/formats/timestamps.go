@@ -0,0 +1,170 @@
+// BYZRA ⸻ internal/formats/timestamps.go
+// timezone normalization of date-time tags
+
+package formats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// optional capability: rewrite date-time tags into one timezone
+type TimestampNormalizer interface {
+	// converts dates into loc and strips offset tags; returns the tags rewritten
+	NormalizeTimestamps(path string, loc *time.Location) ([]string, error)
+}
+
+// offset tags, keyed by the date tag whose offset they hold
+var offsetTags = map[string]string{
+	"DateTimeOriginal": "OffsetTimeOriginal",
+	"CreateDate":       "OffsetTimeDigitized",
+	"ModifyDate":       "OffsetTime",
+}
+
+// tags that only carry a timezone; removed after conversion
+var timezoneTags = []string{
+	"OffsetTime", "OffsetTimeOriginal", "OffsetTimeDigitized", "TimeZoneOffset",
+}
+
+// groups that are computed or describe the filesystem, not the file's metadata
+var skippedTimeGroups = []string{"System", "File", "Composite", "ExifTool"}
+
+// date layouts exiftool prints, with and without an inline offset
+var exifDateLayouts = []string{
+	"2006:01:02 15:04:05.999999999-07:00",
+	"2006:01:02 15:04:05-07:00",
+	"2006:01:02 15:04:05.999999999Z",
+	"2006:01:02 15:04:05Z",
+	"2006:01:02 15:04:05",
+}
+
+// normalizes timestamps in any exiftool-supported file
+func exifToolNormalizeTimestamps(path string, loc *time.Location) ([]string, error) {
+	data, err := util.ExifToolReadTimes(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timestamps: %w", err)
+	}
+
+	tags, err := util.ParseExifToolOutput(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timestamps: %w", err)
+	}
+
+	// offsets recorded in separate tags, by tag name
+	offsets := make(map[string]string)
+	for key, value := range tags {
+		_, name := splitGroupTag(key)
+		for _, tzTag := range timezoneTags {
+			if name == tzTag {
+				offsets[name] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	var args, rewritten []string
+	for key, value := range tags {
+		group, name := splitGroupTag(key)
+		if group == "" || isSkippedTimeGroup(group) || isTimezoneTag(name) {
+			continue
+		}
+
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		converted, ok := convertExifDate(raw, offsets[offsetTags[name]], loc)
+		if !ok || converted == raw {
+			continue
+		}
+
+		args = append(args, fmt.Sprintf("-%s=%s", key, converted))
+		rewritten = append(rewritten, key)
+	}
+
+	// the offset tags themselves give the location away
+	for _, tzTag := range timezoneTags {
+		if _, ok := offsets[tzTag]; ok {
+			args = append(args, "-"+tzTag+"=")
+			rewritten = append(rewritten, tzTag)
+		}
+	}
+
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	if err := util.ExifToolWrite(path, args); err != nil {
+		return nil, fmt.Errorf("failed to rewrite timestamps: %w", err)
+	}
+	return rewritten, nil
+}
+
+// converts one exiftool date into loc; dates without any known offset are left alone
+func convertExifDate(raw, offset string, loc *time.Location) (string, bool) {
+	raw = strings.TrimSpace(raw)
+
+	for _, layout := range exifDateLayouts {
+		t, err := time.Parse(layout, raw)
+		if err != nil {
+			continue
+		}
+
+		inline := strings.Contains(layout, "07:00") || strings.HasSuffix(layout, "Z")
+		if !inline {
+			zone, ok := parseOffset(offset)
+			if !ok {
+				return "", false // local time of unknown origin
+			}
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), zone)
+		}
+
+		out := t.In(loc).Format("2006:01:02 15:04:05")
+		if inline {
+			out += t.In(loc).Format("-07:00")
+		}
+		return out, true
+	}
+
+	return "", false
+}
+
+// "+02:00" / "-05:30" into a fixed zone
+func parseOffset(offset string) (*time.Location, bool) {
+	t, err := time.Parse("-07:00", strings.TrimSpace(offset))
+	if err != nil {
+		return nil, false
+	}
+	_, seconds := t.Zone()
+	return time.FixedZone(offset, seconds), true
+}
+
+// "ExifIFD:DateTimeOriginal" -> ("ExifIFD", "DateTimeOriginal")
+func splitGroupTag(key string) (string, string) {
+	group, name, ok := strings.Cut(key, ":")
+	if !ok {
+		return "", key
+	}
+	return group, name
+}
+
+func isSkippedTimeGroup(group string) bool {
+	for _, skipped := range skippedTimeGroups {
+		if group == skipped {
+			return true
+		}
+	}
+	return false
+}
+
+func isTimezoneTag(name string) bool {
+	for _, tzTag := range timezoneTags {
+		if name == tzTag {
+			return true
+		}
+	}
+	return false
+}
@@ -5,8 +5,11 @@ package formats
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"caligra/internal/util"
 )
@@ -16,22 +19,16 @@ type VideoHandler struct{}
 
 // extracts metadata from video files
 func (h *VideoHandler) ExtractMetadata(path string) (map[string]interface{}, error) {
-	data, err := util.ExifToolExtract(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract video metadata: %w", err)
-	}
-
-	// parse the JSON response into a map
-	metadata, err := util.ParseExifToolOutput(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse video metadata: %w", err)
-	}
-
-	return metadata, nil
+	return extractMediaMetadata(path, "video")
 }
 
 // removes all metadata from video files
 func (h *VideoHandler) WipeMetadata(path string) error {
+	// GPS tracks live in their own streams, out of exiftool's reach
+	if err := dropTelemetryStreams(path); err != nil {
+		return fmt.Errorf("failed to remove telemetry streams: %w", err)
+	}
+
 	err := util.ExifToolRemove(path)
 	if err != nil {
 		return fmt.Errorf("failed to wipe video metadata: %w", err)
@@ -41,15 +38,18 @@ func (h *VideoHandler) WipeMetadata(path string) error {
 
 // adds profile metadata to video files
 func (h *VideoHandler) InjectMetadata(path string, profile map[string]string) error {
+	mapTag := mapProfileKeyToVideoTag
+	if isQuickTime(path) {
+		mapTag = mapProfileKeyToQuickTimeTag
+	}
 	for key, value := range profile {
 		// map profile keys to video metadata tags
-		tag := mapProfileKeyToVideoTag(key)
+		tag := mapTag(key)
 		if tag == "" {
 			continue // Skip unmapped keys
 		}
 
-		cmd := exec.Command("exiftool", fmt.Sprintf("-%s=%s", tag, value), "-overwrite_original", path)
-		if err := cmd.Run(); err != nil {
+		if err := util.ExifToolSetTag(path, tag, value); err != nil {
 			return fmt.Errorf("failed to inject %s metadata: %w", key, err)
 		}
 	}
@@ -83,3 +83,58 @@ func mapProfileKeyToVideoTag(key string) string {
 		return ""
 	}
 }
+
+// maps profile keys to the QuickTime Keys tags Apple's apps read and
+// write, falling back to the MP4 tags for the rest
+func mapProfileKeyToQuickTimeTag(key string) string {
+	switch strings.ToLower(key) {
+	case "author":
+		return "Keys:Author"
+	case "software":
+		return "Keys:Software"
+	case "organization":
+		return "Keys:Copyright"
+	case "comment":
+		return "Keys:Comment"
+	default:
+		return mapProfileKeyToVideoTag(key)
+	}
+}
+
+// a QuickTime movie rather than an MP4
+func isQuickTime(path string) bool {
+	return bmffBrand(path) == "qt  "
+}
+
+// the major brand of an ISO base media file's ftyp box; "qt  " for
+// QuickTime files from before ftyp existed, "" for anything else
+func bmffBrand(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 12)
+	if _, err := io.ReadFull(file, head); err != nil {
+		return ""
+	}
+	switch string(head[4:8]) {
+	case "ftyp":
+		return string(head[8:12])
+	case "moov", "mdat", "wide":
+		return "qt  "
+	}
+	return ""
+}
+
+// rewrites date-time tags into loc and strips offset tags
+func (h *VideoHandler) NormalizeTimestamps(path string, loc *time.Location) ([]string, error) {
+	return exifToolNormalizeTimestamps(path, loc)
+}
+
+// lists data and subtitle streams, flagging GPS/sensor telemetry
+func (h *VideoHandler) ListEmbedded(path string) ([]Embedded, error) {
+	embedded, _, err := probeEmbeddedStreams(path)
+	return embedded, err
+}
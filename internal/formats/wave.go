@@ -0,0 +1,456 @@
+// BYZRA ⸻ internal/formats/wave.go
+// WAV and AIFF: metadata chunks read, dropped and written natively
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// largest metadata chunk read into memory
+const waveMaxChunk = 16 << 20
+
+// one top-level chunk; LIST chunks carry their list type
+type waveChunk struct {
+	id     string
+	list   string
+	offset int64 // of the chunk header
+	size   int64 // of the payload, without the pad byte
+}
+
+// a RIFF WAVE or FORM AIFF/AIFC file: byte order, form type and chunks
+type waveFile struct {
+	kind   string // "wav" or "aiff"
+	order  binary.ByteOrder
+	form   string
+	chunks []waveChunk
+}
+
+// ╭─ CHUNK NAMES ───────────────────────────────╮
+
+// RIFF INFO fields, as exiftool names them
+var riffInfoNames = map[string]string{
+	"IART": "Artist", "ICMT": "Comment", "ICOP": "Copyright", "ICRD": "DateCreated",
+	"IENG": "Engineer", "IGNR": "Genre", "IKEY": "Keywords", "INAM": "Title",
+	"IPRD": "Product", "ISBJ": "Subject", "ISFT": "Software", "ISRC": "Source",
+	"ITCH": "Technician", "ICMS": "Commissioned", "IARL": "ArchivalLocation",
+}
+
+// AIFF text chunks, as exiftool names them
+var aiffTextNames = map[string]string{
+	"NAME": "Name", "AUTH": "Author", "(c) ": "Copyright", "ANNO": "Annotation",
+}
+
+// chunks dropped by a wipe: tags, broadcast and production notes, XMP
+var waveMetadataChunks = map[string]map[string]bool{
+	"wav": {
+		"LIST INFO": true, "id3 ": true, "ID3 ": true, "bext": true, "iXML": true,
+		"_PMX": true, "cart": true, "DISP": true, "umid": true,
+	},
+	"aiff": {
+		"NAME": true, "AUTH": true, "(c) ": true, "ANNO": true, "COMT": true,
+		"ID3 ": true, "APPL": true,
+	},
+}
+
+func (c waveChunk) name() string {
+	if c.list != "" {
+		return c.id + " " + c.list
+	}
+	return c.id
+}
+
+// ╭─ READING ───────────────────────────────────╮
+
+// "wav" or "aiff" for a RIFF WAVE or FORM AIFF/AIFC file, "" otherwise
+func waveKind(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 12)
+	if _, err := io.ReadFull(file, head); err != nil {
+		return ""
+	}
+	switch {
+	case string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return "wav"
+	case string(head[:4]) == "FORM" && (string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC"):
+		return "aiff"
+	}
+	return ""
+}
+
+// lists the top-level chunks; a chunk running past the end of the file is
+// refused, since rewriting it would lose what follows
+func readWaveFile(file *os.File) (*waveFile, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 12)
+	if _, err := file.ReadAt(head, 0); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	w := &waveFile{form: string(head[8:12])}
+	switch string(head[:4]) {
+	case "RIFF":
+		w.kind, w.order = "wav", binary.LittleEndian
+	case "FORM":
+		w.kind, w.order = "aiff", binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not a RIFF or FORM file")
+	}
+	end := min(8+int64(w.order.Uint32(head[4:8])), info.Size())
+
+	for off := int64(12); off+8 <= end; {
+		if n, err := file.ReadAt(head, off); n < 8 {
+			return nil, err
+		}
+		chunk := waveChunk{id: string(head[:4]), offset: off, size: int64(w.order.Uint32(head[4:8]))}
+		if off+8+chunk.size > info.Size() {
+			return nil, fmt.Errorf("%q chunk runs past the end of the file", chunk.id)
+		}
+		if chunk.id == "LIST" && chunk.size >= 4 {
+			chunk.list = string(head[8:12])
+		}
+		w.chunks = append(w.chunks, chunk)
+		off += 8 + chunk.size + chunk.size&1
+	}
+	return w, nil
+}
+
+// the payload of a metadata chunk
+func (w *waveFile) payload(file *os.File, chunk waveChunk) ([]byte, error) {
+	if chunk.size > waveMaxChunk {
+		return nil, fmt.Errorf("%q chunk of %d bytes is too large to read", chunk.id, chunk.size)
+	}
+	data := make([]byte, chunk.size)
+	if _, err := file.ReadAt(data, chunk.offset+8); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// the INFO list, ID3, broadcast (bext) and AIFF text chunks, keyed like
+// exiftool
+func extractWaveMetadata(path string) (map[string]any, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	w, err := readWaveFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]any)
+	set := func(name, value string) {
+		if value = strings.TrimSpace(strings.TrimRight(value, "\x00")); value != "" {
+			metadata[name] = value
+		}
+	}
+	for _, chunk := range w.chunks {
+		if !waveMetadataChunks[w.kind][chunk.name()] {
+			continue
+		}
+		data, err := w.payload(file, chunk)
+		if err != nil {
+			return nil, err
+		}
+
+		switch chunk.name() {
+		case "LIST INFO":
+			for _, field := range riffSubchunks(data[4:]) {
+				if name := riffInfoNames[field.id]; name != "" {
+					set(name, string(field.data))
+				}
+			}
+		case "bext":
+			for name, value := range parseBext(data) {
+				set(name, value)
+			}
+		case "id3 ", "ID3 ":
+			if len(data) >= 10 && string(data[:3]) == "ID3" {
+				body := data[10:min(10+synchsafe(data[6:10]), len(data))]
+				if tag := parseID3Tag(data[:10], body); tag != nil {
+					for name, value := range tag.texts() {
+						set(name, value)
+					}
+				}
+			}
+		case "COMT":
+			set("Comment", strings.Join(aiffComments(data), "\n"))
+		case "APPL":
+			if len(data) >= 4 {
+				set("ApplicationSignature", string(data[:4]))
+			}
+		case "iXML", "_PMX", "cart", "DISP", "umid":
+			// listed as embedded content
+		default:
+			set(aiffTextNames[chunk.id], string(data))
+		}
+	}
+	return metadata, nil
+}
+
+type riffField struct {
+	id   string
+	data []byte
+}
+
+// the subchunks of a LIST payload after its type
+func riffSubchunks(data []byte) []riffField {
+	var fields []riffField
+	for len(data) >= 8 {
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if size > len(data)-8 {
+			break
+		}
+		fields = append(fields, riffField{id: string(data[:4]), data: data[8 : 8+size]})
+		data = data[min(8+size+size&1, len(data)):]
+	}
+	return fields
+}
+
+// the Broadcast Wave fields that name people, places and tools
+func parseBext(data []byte) map[string]string {
+	fields := make(map[string]string)
+	text := func(from, to int) string {
+		if to > len(data) {
+			return ""
+		}
+		return string(bytes.TrimRight(data[from:to], "\x00 "))
+	}
+	fields["Description"] = text(0, 256)
+	fields["Originator"] = text(256, 288)
+	fields["OriginatorReference"] = text(288, 320)
+	// the spec allows any separator: "2024-05-17", "2024:05:17", "10.30.00"
+	date := strings.Map(bextSeparator('-'), text(320, 330)) + " " + strings.Map(bextSeparator(':'), text(330, 338))
+	if origination, err := time.Parse("2006-01-02 15:04:05", date); err == nil {
+		fields["DateTimeOriginal"] = origination.Format("2006:01:02 15:04:05")
+	}
+	if len(data) > 602 {
+		fields["CodingHistory"] = string(bytes.TrimRight(data[602:], "\x00\r\n "))
+	}
+	return fields
+}
+
+func bextSeparator(separator rune) func(rune) rune {
+	return func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return separator
+	}
+}
+
+// the texts of an AIFF COMT chunk
+func aiffComments(data []byte) []string {
+	if len(data) < 2 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	var comments []string
+	for range count {
+		if len(data) < 8 {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(data[6:8]))
+		if size > len(data)-8 {
+			break
+		}
+		comments = append(comments, string(data[8:8+size]))
+		data = data[min(8+size+size&1, len(data)):]
+	}
+	return comments
+}
+
+// production notes and XMP, which wiping drops with the tags
+func listWaveEmbedded(path string) ([]Embedded, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	w, err := readWaveFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]string{
+		"iXML": "production notes (project, scene, take, recorder)",
+		"_PMX": "XMP packet",
+		"cart": "broadcast cart chunk (title, artist, client)",
+		"DISP": "display title",
+		"umid": "unique material identifier",
+		"APPL": "application-specific data",
+	}
+	var embedded []Embedded
+	for _, chunk := range w.chunks {
+		if detail, ok := details[chunk.id]; ok {
+			embedded = append(embedded, Embedded{
+				Kind:   "data",
+				Name:   strings.TrimSpace(chunk.id) + " chunk",
+				Detail: fmt.Sprintf("%s, %d bytes", detail, chunk.size),
+			})
+		}
+	}
+	return embedded, nil
+}
+
+// ╭─ WRITING ───────────────────────────────────╮
+
+// drops every metadata chunk; the audio and the chunks describing it
+// (format, cue points, markers, loops) are copied unchanged
+func wipeWaveMetadata(path string) error {
+	return rewriteWave(path, func(w *waveFile, _ *os.File) (map[string]bool, []byte, error) {
+		return waveMetadataChunks[w.kind], nil, nil
+	})
+}
+
+// chunks profile fields go into
+var waveProfileChunks = map[string]map[string]string{
+	"wav":  {"author": "IART", "software": "ISFT", "created": "ICRD", "organization": "ICOP", "comment": "ICMT"},
+	"aiff": {"author": "AUTH", "organization": "(c) ", "comment": "ANNO"},
+}
+
+// sets profile fields: in the INFO list for WAV, which is rewritten with
+// the fields it had, and as AUTH, "(c) " and ANNO chunks for AIFF
+func injectWaveMetadata(path string, profile map[string]string) error {
+	return rewriteWave(path, func(w *waveFile, file *os.File) (map[string]bool, []byte, error) {
+		var fields []riffField
+		for _, key := range sortedKeys(profile) {
+			if id := waveProfileChunks[w.kind][strings.ToLower(key)]; id != "" && profile[key] != "" {
+				fields = append(fields, riffField{id: id, data: []byte(profile[key])})
+			}
+		}
+		if len(fields) == 0 {
+			return nil, nil, nil
+		}
+
+		drop := make(map[string]bool)
+		var added bytes.Buffer
+		if w.kind == "aiff" {
+			for _, field := range fields {
+				drop[field.id] = true
+				w.writeChunk(&added, field.id, field.data)
+			}
+			return drop, added.Bytes(), nil
+		}
+
+		// INFO strings end with a zero byte
+		var list bytes.Buffer
+		list.WriteString("INFO")
+		for _, field := range fields {
+			w.writeChunk(&list, field.id, append(slices.Clone(field.data), 0))
+		}
+		drop["LIST INFO"] = true
+		for _, chunk := range w.chunks {
+			if chunk.name() != "LIST INFO" {
+				continue
+			}
+			data, err := w.payload(file, chunk)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, field := range riffSubchunks(data[4:]) {
+				if !slices.ContainsFunc(fields, func(f riffField) bool { return f.id == field.id }) {
+					w.writeChunk(&list, field.id, field.data)
+				}
+			}
+		}
+		w.writeChunk(&added, "LIST", list.Bytes())
+		return drop, added.Bytes(), nil
+	})
+}
+
+// rewrites path without the chunks edit names, appending the chunks it
+// returns; nothing is written when it drops and adds nothing
+func rewriteWave(path string, edit func(w *waveFile, file *os.File) (map[string]bool, []byte, error)) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	w, err := readWaveFile(in)
+	if err != nil {
+		return err
+	}
+	drop, added, err := edit(w, in)
+	if err != nil {
+		return err
+	}
+
+	var kept []waveChunk
+	total := int64(4 + len(added))
+	for _, chunk := range w.chunks {
+		if drop[chunk.name()] {
+			continue
+		}
+		kept = append(kept, chunk)
+		total += 8 + chunk.size + chunk.size&1
+	}
+	if len(kept) == len(w.chunks) && len(added) == 0 {
+		return nil
+	}
+	if total > 1<<32-1 {
+		return fmt.Errorf("file too large for a %s header", strings.ToUpper(w.kind))
+	}
+
+	return remuxInPlace(path, func(_, dst string) error {
+		out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		header := make([]byte, 12)
+		copy(header, map[string]string{"wav": "RIFF", "aiff": "FORM"}[w.kind])
+		w.order.PutUint32(header[4:], uint32(total))
+		copy(header[8:], w.form)
+		if _, err := out.Write(header); err != nil {
+			return err
+		}
+		for _, chunk := range kept {
+			if _, err := io.Copy(out, io.NewSectionReader(in, chunk.offset, 8+chunk.size)); err != nil {
+				return err
+			}
+			if chunk.size&1 == 1 {
+				out.Write([]byte{0}) // the pad byte, missing at the end of some files
+			}
+		}
+		if _, err := out.Write(added); err != nil {
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// appends a chunk with its header and pad byte
+func (w *waveFile) writeChunk(buf *bytes.Buffer, id string, data []byte) {
+	header := make([]byte, 8)
+	copy(header, id)
+	w.order.PutUint32(header[4:], uint32(len(data)))
+	buf.Write(header)
+	buf.Write(data)
+	if len(data)&1 == 1 {
+		buf.WriteByte(0)
+	}
+}
@@ -0,0 +1,74 @@
+// BYZRA ⸻ internal/formats/webp.go
+// WebP: EXIF and XMP chunks dropped from the RIFF container
+
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+)
+
+// VP8X feature flags announcing the metadata chunks
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+// removes the top-level EXIF and XMP chunks in place, clearing their flags
+// in the VP8X header; returns how many there were
+func stripWebPMetadata(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, nil
+	}
+	end := 8 + int(binary.LittleEndian.Uint32(data[4:8]))
+	if end > len(data) {
+		return 0, nil // truncated; left to exiftool
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:12])
+	removed, vp8x := 0, -1
+	for off := 12; off < end; {
+		if off+8 > end {
+			return 0, nil
+		}
+		size := int(binary.LittleEndian.Uint32(data[off+4 : off+8]))
+		if off+8+size > end {
+			return 0, nil
+		}
+		// chunks are padded to an even size
+		next := min(off+8+size+size&1, end)
+
+		switch string(data[off : off+4]) {
+		case "EXIF", "XMP ":
+			removed++
+			off = next
+			continue
+		case "VP8X":
+			vp8x = out.Len()
+		}
+		out.Write(data[off:next])
+		off = next
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	riff := out.Len()
+	out.Write(data[end:])
+	result := out.Bytes()
+	binary.LittleEndian.PutUint32(result[4:8], uint32(riff-8))
+	if vp8x >= 0 && vp8x+9 <= riff {
+		result[vp8x+8] &^= webpFlagEXIF | webpFlagXMP
+	}
+
+	if err := replaceFile(path, result); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
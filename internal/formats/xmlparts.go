@@ -0,0 +1,164 @@
+// BYZRA ⸻ internal/formats/xmlparts.go
+// editing property parts of XML documents in place, by byte offsets
+
+package formats
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// an element of an XML part and where it sits in the bytes
+type xmlElement struct {
+	prefix, local string
+	attrs         []xml.Attr // as written: Name.Space is the prefix
+	start, end    int        // the whole element
+	inner, close  int        // its content runs from inner to close
+	text          string     // character data inside, at any depth
+	nested        bool       // holds elements of its own
+}
+
+// the first element at depth (1 for the root) and the elements directly
+// inside it
+func xmlChildren(data []byte, depth int) (*xmlElement, []xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var parent *xmlElement
+	var children []xmlElement
+	level := 0
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid XML: %w", err)
+		}
+		next := int(decoder.InputOffset())
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			level++
+			element := xmlElement{prefix: t.Name.Space, local: t.Name.Local, attrs: t.Attr, start: offset, inner: next}
+			switch {
+			case parent == nil && level == depth:
+				parent = &element
+			case parent != nil && level == depth+1:
+				children = append(children, element)
+			case parent != nil && level > depth+1:
+				children[len(children)-1].nested = true
+			}
+		case xml.EndElement:
+			switch {
+			case parent != nil && level == depth+1:
+				children[len(children)-1].close = offset
+				children[len(children)-1].end = next
+			case parent != nil && level == depth:
+				parent.close, parent.end = offset, next
+				return parent, children, nil
+			}
+			level--
+		case xml.CharData:
+			if parent != nil && level > depth {
+				children[len(children)-1].text += string(t)
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("invalid XML: no element at depth %d", depth)
+}
+
+// the qualified name, as written
+func (e *xmlElement) qname() string {
+	if e.prefix == "" {
+		return e.local
+	}
+	return e.prefix + ":" + e.local
+}
+
+// the value of an attribute, by local name
+func (e *xmlElement) attr(local string) string {
+	for _, attr := range e.attrs {
+		if attr.Name.Local == local && attr.Name.Space != "xmlns" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// the prefix the element binds to namespace; "" with ok for the default
+func (e *xmlElement) prefixFor(namespace string) (string, bool) {
+	for _, attr := range e.attrs {
+		switch {
+		case attr.Name.Space == "xmlns" && attr.Value == namespace:
+			return attr.Name.Local, true
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns" && attr.Value == namespace:
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// the name to write local under namespace with, as seen inside e: the
+// prefix e binds, else the one prefixes suggests with its declaration
+func (e *xmlElement) nameIn(namespace, local string, prefixes map[string]string) (name, decl string) {
+	prefix, ok := e.prefixFor(namespace)
+	if !ok {
+		prefix = prefixes[namespace]
+		decl = ` xmlns="` + namespace + `"`
+		if prefix != "" {
+			decl = ` xmlns:` + prefix + `="` + namespace + `"`
+		}
+	}
+	if prefix == "" {
+		return local, decl
+	}
+	return prefix + ":" + local, decl
+}
+
+// the bytes of data with the children remove picks cut out and markup
+// added at the end of parent
+func editXMLChildren(data []byte, parent *xmlElement, children []xmlElement, remove func(xmlElement) bool, markup string) []byte {
+	var out []byte
+	pos := 0
+	for _, child := range children {
+		if !remove(child) {
+			continue
+		}
+		start := child.start
+		if i := bytes.LastIndexByte(data[pos:start], '\n'); i >= 0 && len(bytes.Trim(data[pos+i:start], " \t\n")) == 0 {
+			start = pos + i // with the line break and indentation before it
+			if start > pos && data[start-1] == '\r' {
+				start--
+			}
+		}
+		out = append(out, data[pos:start]...)
+		pos = child.end
+	}
+
+	if parent.inner == parent.close && bytes.HasSuffix(data[:parent.inner], []byte("/>")) {
+		// a self-closing parent has to be opened up
+		if markup == "" {
+			return append(out, data[pos:]...)
+		}
+		out = append(out, data[pos:parent.inner-2]...)
+		out = append(out, '>')
+		out = append(out, markup...)
+		out = append(out, "</"+parent.qname()+">"...)
+		return append(out, data[parent.end:]...)
+	}
+	out = append(out, data[pos:parent.close]...)
+	out = append(out, markup...)
+	return append(out, data[parent.close:]...)
+}
+
+// text escaped for element content and attribute values
+func xmlEscape(text string) string {
+	var out strings.Builder
+	xml.EscapeText(&out, []byte(text))
+	return out.String()
+}
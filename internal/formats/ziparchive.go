@@ -0,0 +1,318 @@
+// BYZRA ⸻ internal/formats/ziparchive.go
+// ZIP archives: member dates and extra fields read, the archive rebuilt
+
+package formats
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// systems in the high byte of "version made by"
+var zipHostSystems = map[uint16]string{
+	0: "MS-DOS/Windows", 3: "Unix", 7: "Macintosh", 10: "Windows NTFS", 19: "macOS",
+}
+
+// extra fields by ID, as the reports name them
+var zipExtraNames = map[uint16]string{
+	0x0001: "ZIP64 sizes",
+	0x000a: "NTFS timestamps",
+	0x4453: "Windows security descriptor",
+	0x5455: "extended timestamps",
+	0x5855: "Info-ZIP Unix (old)",
+	0x6375: "Unicode comment",
+	0x7075: "Unicode path",
+	0x756e: "ASi Unix",
+	0x7855: "Info-ZIP Unix",
+	0x7875: "Info-ZIP Unix UID/GID",
+	0xcafe: "JAR marker",
+	0xd935: "Android alignment",
+}
+
+// 100 ns intervals from 1601 to 1970, for NTFS FILETIMEs
+const ntfsEpochOffset = 116444736000000000
+
+// ╭─ READING ───────────────────────────────────╮
+
+func extractZipMetadata(path string) (map[string]any, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	metadata := map[string]any{"Members": len(archive.File)}
+	if archive.Comment != "" {
+		metadata["Comment"] = archive.Comment
+	}
+
+	var systems []string
+	for i, file := range archive.File {
+		if system := zipHostSystems[file.CreatorVersion>>8]; system != "" && !slices.Contains(systems, system) {
+			systems = append(systems, system)
+		}
+		if i >= archiveMaxListed {
+			continue
+		}
+		for field, value := range zipMemberFields(file) {
+			metadata[file.Name+": "+field] = value
+		}
+	}
+	if len(systems) > 0 {
+		metadata["HostSystem"] = strings.Join(systems, ", ")
+	}
+	if len(archive.File) > archiveMaxListed {
+		metadata["MembersNotListed"] = len(archive.File) - archiveMaxListed
+	}
+	return metadata, nil
+}
+
+// a member's dates, owner, comment and extra fields; a date a wipe reset
+// (1980-01-01 00:00) is left out
+func zipMemberFields(file *zip.File) map[string]string {
+	fields := make(map[string]string)
+	if file.ModifiedDate != zipEpochDate || file.ModifiedTime != 0 {
+		fields["ModifyDate"] = file.Modified.Format("2006:01:02 15:04:05")
+	}
+	if file.Comment != "" {
+		fields["Comment"] = file.Comment
+	}
+
+	var names []string
+	for id, data := range zipExtraFields(file.Extra) {
+		if name := zipExtraNames[id]; name != "" {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("0x%04x", id))
+		}
+
+		switch id {
+		case 0x000a:
+			// reserved, then tag 1: modification, access and creation times
+			if len(data) >= 32 && binary.LittleEndian.Uint16(data[4:]) == 1 {
+				fields["AccessDate"] = ntfsTime(data[16:]).Format("2006:01:02 15:04:05")
+				fields["CreateDate"] = ntfsTime(data[24:]).Format("2006:01:02 15:04:05")
+			}
+		case 0x5455:
+			// flags, then the times they announce: modification (already
+			// read by archive/zip), access, creation
+			at := 1
+			for bit, field := range []string{"ModifyDate", "AccessDate", "CreateDate"} {
+				if len(data) == 0 || data[0]&(1<<bit) == 0 || at+4 > len(data) {
+					continue
+				}
+				if bit > 0 {
+					fields[field] = time.Unix(int64(binary.LittleEndian.Uint32(data[at:])), 0).UTC().Format("2006:01:02 15:04:05")
+				}
+				at += 4
+			}
+		case 0x7875:
+			// version, then UID and GID with their sizes
+			if len(data) >= 2 {
+				uidSize := int(data[1])
+				if 2+uidSize+1 <= len(data) {
+					uid := zipUnsigned(data[2 : 2+uidSize])
+					gidSize := int(data[2+uidSize])
+					if gid := data[3+uidSize:]; gidSize <= len(gid) {
+						fields["Owner"] = fmt.Sprintf("UID %d, GID %d", uid, zipUnsigned(gid[:gidSize]))
+					}
+				}
+			}
+		case 0x7855:
+			if len(data) >= 4 {
+				fields["Owner"] = fmt.Sprintf("UID %d, GID %d", binary.LittleEndian.Uint16(data), binary.LittleEndian.Uint16(data[2:]))
+			}
+		case 0x5855:
+			if len(data) >= 12 {
+				fields["Owner"] = fmt.Sprintf("UID %d, GID %d", binary.LittleEndian.Uint16(data[8:]), binary.LittleEndian.Uint16(data[10:]))
+			}
+		case 0x756e:
+			// checksum, mode, link size, then UID and GID
+			if len(data) >= 14 {
+				fields["Owner"] = fmt.Sprintf("UID %d, GID %d", binary.LittleEndian.Uint16(data[10:]), binary.LittleEndian.Uint16(data[12:]))
+			}
+		case 0x4453:
+			fields["SecurityDescriptor"] = fmt.Sprintf("%d bytes (owner and group SIDs)", len(data))
+		}
+	}
+	if len(names) > 0 {
+		slices.Sort(names)
+		fields["ExtraFields"] = strings.Join(names, ", ")
+	}
+	return fields
+}
+
+// the extra fields of a header by ID; a field running past the end ends
+// the list
+func zipExtraFields(extra []byte) map[uint16][]byte {
+	fields := make(map[uint16][]byte)
+	for len(extra) >= 4 {
+		id, size := binary.LittleEndian.Uint16(extra), int(binary.LittleEndian.Uint16(extra[2:]))
+		if 4+size > len(extra) {
+			break
+		}
+		fields[id] = extra[4 : 4+size]
+		extra = extra[4+size:]
+	}
+	return fields
+}
+
+// a little-endian integer of 1 to 8 bytes
+func zipUnsigned(data []byte) uint64 {
+	var value uint64
+	for i := len(data) - 1; i >= 0 && i < 8; i-- {
+		value = value<<8 | uint64(data[i])
+	}
+	return value
+}
+
+func ntfsTime(data []byte) time.Time {
+	ticks := int64(binary.LittleEndian.Uint64(data)) - ntfsEpochOffset
+	return time.Unix(ticks/1e7, ticks%1e7*100).UTC()
+}
+
+// supported members, which a wipe runs their handler on
+func (h *ArchiveHandler) listZipMembers(path string) ([]Embedded, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	var embedded []Embedded
+	for _, file := range archive.File {
+		if h.memberHandler(file.Name) == nil {
+			continue
+		}
+		if len(embedded) == archiveMaxListed {
+			embedded = append(embedded, Embedded{Kind: "member", Name: "…", Detail: "more supported members, wiped too"})
+			break
+		}
+		format := memberFormat(file.Name)
+		detail := fmt.Sprintf("%s, %s; wiped with the archive", format, util.FormatBytes(int64(file.UncompressedSize64)))
+		if file.Flags&0x1 != 0 {
+			detail = fmt.Sprintf("%s, encrypted; copied as it is", format)
+		}
+		embedded = append(embedded, Embedded{Kind: "member", Name: file.Name, Detail: detail})
+	}
+	return embedded, nil
+}
+
+// ╭─ WRITING ───────────────────────────────────╮
+
+// rebuilds the archive with every date reset and supported members wiped
+func (h *ArchiveHandler) wipeZip(path string) error {
+	dir, err := os.MkdirTemp("", "caligra-zip-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	return rebuildZip(path, "", true, func(file *zip.File) (string, error) {
+		handler := h.memberHandler(file.Name)
+		if handler == nil || file.Flags&0x1 != 0 {
+			return "", nil
+		}
+		r, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", file.Name, err)
+		}
+		defer r.Close()
+		return wipeMember(handler, dir, file.Name, r)
+	})
+}
+
+// rewrites the ZIP archive at path entry by entry, with comment as the
+// archive comment. Entries keep name, method and attributes and lose extra
+// fields and comments; their dates go back to 1980-01-01 with resetDates.
+// A member replace returns a file for is stored with that content instead,
+// others are copied without recompressing
+func rebuildZip(path, comment string, resetDates bool, replace func(file *zip.File) (string, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	return remuxInPlace(path, func(_, dst string) error {
+		out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		w := zip.NewWriter(out)
+		for _, file := range archive.File {
+			header := &zip.FileHeader{
+				Name:           file.Name,
+				Method:         file.Method,
+				CreatorVersion: file.CreatorVersion, // tells how to read the attributes
+				ModifiedDate:   file.ModifiedDate,
+				ModifiedTime:   file.ModifiedTime,
+				ExternalAttrs:  file.ExternalAttrs,
+			}
+			if resetDates {
+				header.ModifiedDate, header.ModifiedTime = zipEpochDate, 0
+			}
+
+			var wiped string
+			if replace != nil {
+				if wiped, err = replace(file); err != nil {
+					return err
+				}
+			}
+			if wiped == "" {
+				if err := copyZipRaw(w, header, file); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := writeZipFile(w, header, wiped); err != nil {
+				return err
+			}
+		}
+		if err := w.SetComment(comment); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("failed to finish archive: %w", err)
+		}
+		archive.Close() // before the file is replaced, which Windows requires
+		return out.Close()
+	})
+}
+
+// adds the content of the file at src as an entry, compressed unless the
+// original was stored
+func writeZipFile(w *zip.Writer, header *zip.FileHeader, src string) error {
+	if header.Method != zip.Store {
+		header.Method = zip.Deflate
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	defer os.Remove(src)
+
+	dst, err := w.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("%s: %w", header.Name, err)
+	}
+	if _, err := io.Copy(dst, in); err != nil {
+		return fmt.Errorf("%s: %w", header.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// BYZRA ⸻ internal/formats/zipparts.go
+// ZIP-based documents: reading parts and rewriting the container
+
+package formats
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// PK\x03\x04, the first local file header
+var zipSignature = []byte("PK\x03\x04")
+
+// largest part read into memory
+const zipMaxPart = 64 << 20
+
+// 1980-01-01, the earliest date a ZIP entry can hold, in MS-DOS format
+const zipEpochDate = 1<<5 | 1
+
+// the content of the named parts of the archive at path; missing parts
+// are left out
+func readZipParts(path string, names ...string) (map[string][]byte, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	parts := make(map[string][]byte)
+	for _, file := range archive.File {
+		for _, name := range names {
+			if file.Name != name {
+				continue
+			}
+			data, err := readZipFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			parts[name] = data
+		}
+	}
+	return parts, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > zipMaxPart {
+		return nil, fmt.Errorf("%d bytes is too large to read", file.UncompressedSize64)
+	}
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, zipMaxPart))
+}
+
+// the names of the entries of the archive at path, in order
+func listZipParts(path string) ([]string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	names := make([]string, 0, len(archive.File))
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	return names, nil
+}
+
+// rewrites the archive at path in entry order. Parts named in replace get
+// the new content, or are dropped when it is nil; parts in add are
+// appended. Every entry loses its timestamps, extra fields and comment,
+// as does the archive; unchanged entries are copied without recompressing
+func rewriteZip(path string, replace, add map[string][]byte) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	var out bytes.Buffer
+	w := zip.NewWriter(&out)
+	for _, file := range archive.File {
+		header := &zip.FileHeader{
+			Name:          file.Name,
+			Method:        file.Method,
+			ModifiedDate:  zipEpochDate,
+			ExternalAttrs: file.ExternalAttrs,
+		}
+
+		content, replaced := replace[file.Name]
+		if !replaced {
+			if err := copyZipRaw(w, header, file); err != nil {
+				return err
+			}
+			continue
+		}
+		if content == nil {
+			continue
+		}
+		if err := writeZipPart(w, header, content); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(add)) {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, ModifiedDate: zipEpochDate}
+		if err := writeZipPart(w, header, add[name]); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	archive.Close() // before the file is replaced, which Windows requires
+	return replaceFile(path, out.Bytes())
+}
+
+// copies an entry under header without recompressing it
+func copyZipRaw(w *zip.Writer, header *zip.FileHeader, file *zip.File) error {
+	header.Flags = file.Flags & 0x1 // encrypted entries stay encrypted
+	header.CRC32 = file.CRC32
+	header.CompressedSize64 = file.CompressedSize64
+	header.UncompressedSize64 = file.UncompressedSize64
+	dst, err := w.CreateRaw(header)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file.Name, err)
+	}
+	src, err := file.OpenRaw()
+	if err != nil {
+		return fmt.Errorf("%s: %w", file.Name, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("%s: %w", file.Name, err)
+	}
+	return nil
+}
+
+func writeZipPart(w *zip.Writer, header *zip.FileHeader, content []byte) error {
+	if header.Method != zip.Store {
+		header.Method = zip.Deflate
+	}
+	dst, err := w.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("%s: %w", header.Name, err)
+	}
+	if _, err := dst.Write(content); err != nil {
+		return fmt.Errorf("%s: %w", header.Name, err)
+	}
+	return nil
+}
+
+// checks that every entry of the archive at path decompresses
+func verifyZip(path string) bool {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return false
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			return false
+		}
+		_, err = io.Copy(io.Discard, r)
+		r.Close()
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,282 @@
+// BYZRA ⸻ internal/i18n/de.go
+// German messages
+
+package i18n
+
+var de = catalog{
+	// commands
+	"Unknown command: %s":             "Unbekannter Befehl: %s",
+	"Usage: %s":                       "Aufruf: %s",
+	"Downloading: %s":                 "Lade herunter: %s",
+	"File not found: %s":              "Datei nicht gefunden: %s",
+	"Processing: %s":                  "Verarbeite: %s",
+	"Invalid --max-size: %s":          "Ungültige --max-size: %s",
+	"Unknown timezone: %s":            "Unbekannte Zeitzone: %s",
+	"No file specified for analysis":  "Keine Datei zur Analyse angegeben",
+	"Analyzing: %s":                   "Analysiere: %s",
+	"Analyzing metadata":              "Analysiere Metadaten",
+	"Analysis failed: %s":             "Analyse fehlgeschlagen: %s",
+	"Analysis completed successfully": "Analyse erfolgreich abgeschlossen",
+	"No file specified for wiping":    "Keine Datei zum Bereinigen angegeben",
+	"Removing metadata":               "Entferne Metadaten",
+	"Wipe failed: %s":                 "Bereinigung fehlgeschlagen: %s",
+	"Wipe completed successfully":     "Bereinigung erfolgreich abgeschlossen",
+
+	// batch and remote wipes
+	"--resume takes its inputs from the manifest":         "--resume liest die Eingaben aus dem Manifest",
+	"--resume needs the manifest of the interrupted run":  "--resume braucht das Manifest des abgebrochenen Laufs",
+	"Remote locations are wiped one at a time":            "Entfernte Ziele werden einzeln bereinigt",
+	"Journal: %s (continue with --resume if interrupted)": "Journal: %s (bei Abbruch mit --resume fortsetzen)",
+	"Resuming: %s":                                                 "Setze fort: %s",
+	"%d files already done, skipping them":                         "%d Dateien bereits erledigt, werden übersprungen",
+	"Wiping with the options of the interrupted run":               "Bereinige mit den Optionen des abgebrochenen Laufs",
+	"Wiped %d files (%d with issues, %d failed)":                   "%d Dateien bereinigt (%d mit Problemen, %d fehlgeschlagen)",
+	"Manifest written to: %s":                                      "Manifest geschrieben nach: %s",
+	"Retry the failures with: %s":                                  "Fehlgeschlagene erneut versuchen mit: %s",
+	"Random renaming and attestation are skipped for remote files": "Zufällige Umbenennung und Bescheinigung entfallen bei entfernten Dateien",
+	"No objects found under %s":                                    "Keine Objekte unter %s gefunden",
+	"%d of %d objects sanitized":                                   "%d von %d Objekten bereinigt",
+	"metadata remains after the wipe":                              "nach der Bereinigung bleiben Metadaten zurück",
+	"no copy was written":                                          "es wurde keine Kopie geschrieben",
+	"the original was not replaced":                                "das Original wurde nicht ersetzt",
+
+	// progress
+	"Reading tags with exiftool":   "Lese Tags mit exiftool",
+	"Stripping tags with exiftool": "Entferne Tags mit exiftool",
+	"Wiping metadata from %s":      "Bereinige Metadaten von %s",
+	"Metadata removed":             "Metadaten entfernt",
+	"Profile injected":             "Profil eingefügt",
+	"Verifying output":             "Prüfe Ergebnis",
+	"Image re-encoded":             "Bild neu kodiert",
+	"Container remuxed":            "Container neu gemuxt",
+
+	// help
+	"USAGE":            "AUFRUF",
+	"COMMANDS":         "BEFEHLE",
+	"ANALYSE OPTIONS":  "ANALYSE-OPTIONEN",
+	"WIPE OPTIONS":     "BEREINIGUNGS-OPTIONEN",
+	"IMPORT OPTIONS":   "IMPORT-OPTIONEN",
+	"REPORT OPTIONS":   "BERICHT-OPTIONEN",
+	"FIND OPTIONS":     "SUCH-OPTIONEN",
+	"SELFTEST OPTIONS": "SELBSTTEST-OPTIONEN",
+	"LOG OPTIONS":      "LOG-OPTIONEN",
+	"GLOBAL OPTIONS":   "GLOBALE OPTIONEN",
+
+	"analyze metadata in a file":                       "Metadaten einer Datei analysieren",
+	"remove metadata from a file":                      "Metadaten aus einer Datei entfernen",
+	"wipe many files, writing a JSON manifest":         "viele Dateien bereinigen, mit JSON-Manifest",
+	"clean remote objects in place (prefix with /)":    "entfernte Objekte direkt bereinigen (Präfix mit /)",
+	"manage background monitoring service":             "Hintergrundüberwachung steuern",
+	"watch <dir> (--policy <name>), no restart needed": "<dir> überwachen (--policy <name>), ohne Neustart",
+	"stop watching <dir>":                              "<dir> nicht mehr überwachen",
+	"view the daemon log":                              "Daemon-Log anzeigen",
+	"follow what the running daemon does, live":        "verfolgt live, was der laufende Daemon tut",
+	"[~] Following daemon events; Ctrl-C stops":        "[~] Verfolge Daemon-Ereignisse; Strg-C beendet",
+	"Detected %s":                                              "Erkannt: %s",
+	"No sensitive metadata in %s":                              "Keine sensiblen Metadaten in %s",
+	"%d sensitive fields in %s, not wiped":                     "%d sensible Felder in %s, nicht bereinigt",
+	"Wiped %s with issues: %s":                                 "%s mit Problemen bereinigt: %s",
+	"Wiped %s → %s":                                            "%s bereinigt → %s",
+	"show daemon activity trends (default 30d)":                "Daemon-Aktivität anzeigen (Standard 30d)",
+	"register the daemon as a Windows service":                 "Daemon als Windows-Dienst registrieren",
+	"remove the Windows service":                               "Windows-Dienst entfernen",
+	"start the daemon at login on macOS (LaunchAgent)":         "Daemon bei der macOS-Anmeldung starten (LaunchAgent)",
+	"copy and clean a camera card's DCIM folder":               "DCIM-Ordner einer Kamerakarte kopieren und bereinigen",
+	"strip metadata from the clipboard image":                  "Metadaten aus dem Bild in der Zwischenablage entfernen",
+	"verify a signed wipe attestation":                         "signierte Bereinigungsbescheinigung prüfen",
+	"start the hash-chained audit journal of wipes":            "startet das hash-verkettete Audit-Journal der Bereinigungen",
+	"list recent journal entries (default 20)":                 "listet die letzten Journal-Einträge (Standard 20)",
+	"anchor the journal head (--to <file> for a copy)":         "verankert den Journal-Kopf (--to <Datei> für eine Kopie)",
+	"detect changes to the journal (--anchors <file>)":         "erkennt Änderungen am Journal (--anchors <Datei>)",
+	"check scroud.toml, yogra.toml and the profile":            "prüft scroud.toml, yogra.toml und das Profil",
+	"No %s found; defaults are used":                           "Keine %s gefunden; Standardwerte werden verwendet",
+	"%s: no problems found":                                    "%s: keine Probleme gefunden",
+	"%d errors, %d warnings":                                   "%d Fehler, %d Warnungen",
+	"No audit journal; start one with: caligra history init":   "Kein Audit-Journal; starten mit: caligra history init",
+	"Audit journal already exists: %s":                         "Audit-Journal existiert bereits: %s",
+	"Audit journal started: %s":                                "Audit-Journal gestartet: %s",
+	"Wipes by the CLI and the daemon are recorded from now on": "Bereinigungen durch CLI und Daemon werden ab jetzt aufgezeichnet",
+	"Anchored entry %d (%s) in %s":                             "Eintrag %d (%s) verankert in %s",
+	"Audit journal was tampered with":                          "Das Audit-Journal wurde manipuliert",
+	"anchors":                                                  "Anker",
+	"line %d":                                                  "Zeile %d",
+	"Audit journal intact: %d entries, %d anchors checked":     "Audit-Journal intakt: %d Einträge, %d Anker geprüft",
+	"Head: %s": "Kopf: %s",
+	"No anchors yet; without one, a rewritten journal cannot be told apart": "Noch keine Anker; ohne Anker ist ein neu geschriebenes Journal nicht zu erkennen",
+	"Unknown history command: %s":                                           "Unbekannter history-Befehl: %s",
+	"journal started":                                                       "Journal gestartet",
+	"write an HTML/PDF compliance report for a directory":                   "HTML/PDF-Konformitätsbericht für ein Verzeichnis schreiben",
+	"show the built-in color themes":                                        "eingebaute Farbschemata anzeigen",
+	"switch the color theme":                                                "Farbschema wechseln",
+	"show a theme's colors and sample output":                               "Farben und Beispielausgabe eines Schemas anzeigen",
+	"check which format pipelines work on this machine":                     "prüft, welche Formate auf diesem Rechner funktionieren",
+	"count formats, exposure and leaking apps in a tree":                    "zählt Formate, Offenlegung und verratende Apps in einem Baum",
+	"record a tree's metadata as a baseline":                                "Metadaten eines Verzeichnisbaums als Referenz festhalten",
+	"list files whose metadata appeared or changed since":                   "Dateien auflisten, deren Metadaten seitdem hinzukamen oder sich änderten",
+	"--compare needs a snapshot file":                                       "--compare braucht eine Snapshot-Datei",
+	"No directory specified for the snapshot":                               "Kein Verzeichnis für den Snapshot angegeben",
+	"Scanning: %s":                                       "Durchsuche: %s",
+	"Snapshot failed: %s":                                "Snapshot fehlgeschlagen: %s",
+	"%d files recorded, %d errors":                       "%d Dateien erfasst, %d Fehler",
+	"Snapshot written to: %s":                            "Snapshot geschrieben nach: %s",
+	"Check for drift with: caligra analyse --compare %s": "Auf Abweichungen prüfen mit: caligra analyse --compare %s",
+	"Comparing %s with the snapshot of %s":               "Vergleiche %s mit dem Snapshot vom %s",
+	"Comparison failed: %s":                              "Vergleich fehlgeschlagen: %s",
+	"metadata changed":                                   "Metadaten geändert",
+	"new file with metadata":                             "neue Datei mit Metadaten",
+	"sensitive: %s":                                      "sensibel: %s",
+	"%d unchanged, %d changed, %d new with metadata, %d cleaned, %d removed": "%d unverändert, %d geändert, %d neu mit Metadaten, %d bereinigt, %d entfernt",
+	"print paths of sensitive files, for xargs":                              "gibt Pfade sensibler Dateien aus, für xargs",
+	"index analysis results for instant queries":                             "indiziert Analyseergebnisse für sofortige Abfragen",
+	"query the index (find criteria, --changed-since)":                       "fragt den Index ab (find-Kriterien, --changed-since)",
+	"list past index builds":                                                 "listet frühere Indexläufe",
+	"print the JSON schema of --json output":                                 "gibt das JSON-Schema der --json-Ausgabe aus",
+	"run the HTTP API for analysing and wiping uploads":                      "startet die HTTP-API zum Analysieren und Bereinigen von Uploads",
+	"SERVE OPTIONS": "SERVE-OPTIONEN",
+	"address to listen on (default 127.0.0.1:8470)":     "Adresse, auf der gelauscht wird (Standard 127.0.0.1:8470)",
+	"bearer tokens, one per line (chmod 600)":           "Bearer-Tokens, eines pro Zeile (chmod 600)",
+	"requests per client per minute (default 60)":       "Anfragen pro Client und Minute (Standard 60)",
+	"requests a client may send at once":                "Anfragen, die ein Client auf einmal senden darf",
+	"upload limit (default 100)":                        "Upload-Limit (Standard 100)",
+	"serve HTTPS with this certificate (and --tls-key)": "HTTPS mit diesem Zertifikat (und --tls-key)",
+	"require client certificates signed by this CA":     "verlangt von dieser CA signierte Client-Zertifikate",
+	"wipe policy applied to every upload":               "Bereinigungsrichtlinie für jeden Upload",
+	"Invalid %s: %s":                                    "Ungültiges %s: %s",
+	"Serving on %s":                                     "Bereit auf %s",
+	"POST /v1/analyse, /v1/wipe and /v1/jobs with multipart field \"file\"; Ctrl-C stops": "POST /v1/analyse, /v1/wipe und /v1/jobs mit dem Multipart-Feld \"file\"; Strg-C beendet",
+	"show this help information":                                   "diese Hilfe anzeigen",
+	"show build information (and backend versions)":                "Build-Informationen (und Versionen der Werkzeuge) anzeigen",
+	"scan text bodies for emails, phones, IBANs, IPs":              "Texte nach E-Mails, Telefonnummern, IBANs, IPs durchsuchen",
+	"dump every segment with offset, length and hex preview":       "jedes Segment mit Offset, Länge und Hex-Vorschau ausgeben",
+	"print a versioned JSON document (see 'caligra schema')":       "gibt ein versioniertes JSON-Dokument aus (siehe 'caligra schema')",
+	"print the result as JSON (one local file)":                    "gibt das Ergebnis als JSON aus (eine lokale Datei)",
+	"download limit for URLs (default 100)":                        "Download-Limit für URLs (Standard 100)",
+	"include sub-directories of directory inputs":                  "Unterverzeichnisse der Eingabeverzeichnisse einbeziehen",
+	"where to write the batch manifest":                            "Ziel für das Stapel-Manifest",
+	"continue an interrupted batch wipe":                           "abgebrochene Stapelbereinigung fortsetzen",
+	"wipe only files matching, e.g. 'size<500MB && ext=jpg'":       "nur passende Dateien bereinigen, z. B. 'size<500MB && ext=jpg'",
+	"don't inject profile metadata":                                "keine Profil-Metadaten einfügen",
+	"modify file in place (don't create copy)":                     "Datei direkt ändern (keine Kopie anlegen)",
+	"don't keep backup of original file":                           "keine Sicherung des Originals behalten",
+	"securely overwrite original data":                             "Originaldaten sicher überschreiben",
+	"inject <name>.lua instead of profile.lua":                     "<name>.lua statt profile.lua einfügen",
+	"rewrite dates into <zone>, strip offsets":                     "Daten in <zone> umrechnen, Zeitversätze entfernen",
+	"same as --timezone UTC":                                       "wie --timezone UTC",
+	"replace personal data in text bodies":                         "personenbezogene Daten in Texten ersetzen",
+	"apply a policy from policies.toml or a built-in preset":       "Richtlinie aus policies.toml oder Voreinstellung anwenden",
+	"audio cover art: keep | remove | strip":                       "Cover-Bilder: keep | remove | strip",
+	"photo and track dates: keep | remove | day | month | year":    "Aufnahme- und Track-Daten: keep | remove | day | month | year",
+	"calendar organizers and attendees: keep | remove | strip":     "Organisatoren und Teilnehmer in Kalendern: keep | remove | strip",
+	"drop the points of GPS tracks, keep their names":              "Punkte von GPS-Tracks entfernen, ihre Namen behalten",
+	"cut the vendor MakerNote out of EXIF whole":                   "schneidet die Hersteller-MakerNote komplett aus EXIF",
+	"apply EXIF orientation to the pixels before removing it":      "wendet die EXIF-Ausrichtung auf die Pixel an, bevor sie entfernt wird",
+	"keep encoder tags and LAME settings":                          "Encoder-Tags und LAME-Einstellungen behalten",
+	"keep ReplayGain/R128 gain tags":                               "ReplayGain/R128-Tags behalten",
+	"keep MusicBrainz track/release/artist IDs":                    "MusicBrainz-IDs für Titel/Veröffentlichung/Künstler behalten",
+	"keep AcoustID IDs and fingerprints":                           "AcoustID-IDs und Fingerabdrücke behalten",
+	"XMP sidecars (photo.xmp, photo.jpg.xmp): wipe | delete":       "XMP-Begleitdateien (photo.xmp, photo.jpg.xmp): wipe | delete",
+	"MKV parts to keep: fonts,covers,chapters,track-names|none":    "zu behaltende MKV-Teile: fonts,covers,chapters,track-names|none",
+	"destination for cleaned copies":                               "Ziel für bereinigte Kopien",
+	"keep | sequence | date":                                       "keep | sequence | date",
+	"where to write the import manifest":                           "Ziel für das Import-Manifest",
+	"document format (default html)":                               "Dokumentformat (Standard html)",
+	"policy the directory is held to":                              "Richtlinie, an der das Verzeichnis gemessen wird",
+	"where to write the report":                                    "Ziel für den Bericht",
+	"gps, author, serial, identifier, device, software, timestamp": "gps, author, serial, identifier, device, software, timestamp",
+	"image, audio, video, text, matroska or an extension":          "image, audio, video, text, matroska oder eine Endung",
+	"modified since a date (2023, 2023-05) or span (30d)":          "geändert seit einem Datum (2023, 2023-05) oder Zeitraum (30d)",
+	"files analysed in parallel (default: CPU count)":              "parallel analysierte Dateien (Standard: CPU-Anzahl)",
+	"separate paths with NUL, for xargs -0":                        "trennt Pfade mit NUL, für xargs -0",
+	"keep the generated samples and their wiped copies":            "behält die erzeugten Proben und ihre bereinigten Kopien",
+	"keep printing new entries":                                    "neue Einträge laufend ausgeben",
+	"minimum level (debug|info|warning|error)":                     "Mindeststufe (debug|info|warning|error)",
+	"existing lines to show (0 = all)":                             "anzuzeigende vorhandene Zeilen (0 = alle)",
+	"no colors, spinner or screen clearing (automatic when piped)": "ohne Farben, Spinner und Bildschirmlöschen (automatisch bei Umleitung)",
+	"clear the screen first (or clear_screen in yogra.toml)":       "Bildschirm vorher löschen (oder clear_screen in yogra.toml)",
+	"detect by content only; unrecognized files are unknown":       "erkennt nur am Inhalt; unbekannte Inhalte bleiben unbekannt",
+	"skip larger files (or max_size_mb in scroud.toml)":            "größere Dateien überspringen (oder max_size_mb in scroud.toml)",
+	"--max-file-size takes a size in MB (0 for no limit), not %q":  "--max-file-size erwartet eine Größe in MB (0 für kein Limit), nicht %q",
+	"message language: %s (default from LANG)":                     "Sprache der Meldungen: %s (Standard aus LANG)",
+
+	// selftest
+	"Running analyse → wipe → verify on generated samples":       "Analyse → Bereinigung → Prüfung mit erzeugten Proben",
+	"%d pipelines work, %d failed, %d skipped":                   "%d Formate funktionieren, %d fehlgeschlagen, %d übersprungen",
+	"Install the missing backends to enable the skipped formats": "Installieren Sie die fehlenden externen Werkzeuge, um die übersprungenen Formate zu aktivieren",
+	"Samples kept in: %s": "Proben behalten in: %s",
+	"skipped: %s":         "übersprungen: %s",
+
+	// version
+	"A CLI metadata control utility for Linux": "Ein Kommandozeilenwerkzeug zur Kontrolle von Metadaten",
+	"BACKENDS":                           "WERKZEUGE",
+	"not found (%s)":                     "nicht gefunden (%s)",
+	"image, audio and document metadata": "Metadaten von Bildern, Audio und Dokumenten",
+	"audio/video remuxing":               "Audio/Video-Remuxing",
+	"stream and chapter inspection":      "Untersuchung von Streams und Kapiteln",
+	"ImageMagick image inspection":       "Bilduntersuchung mit ImageMagick",
+
+	// analysis report
+	"File: ": "Datei: ",
+	"Type: ": "Typ: ",
+	"%d other hard links share this file's content; an in-place wipe or secure delete reaches them too": "%d weitere Hardlinks teilen den Inhalt dieser Datei; eine Bereinigung vor Ort oder sicheres Löschen erreicht sie ebenfalls",
+	"XMP sidecar %s holds metadata for this file; 'caligra wipe --sidecars wipe|delete' handles it too": "XMP-Begleitdatei %s enthält Metadaten zu dieser Datei; 'caligra wipe --sidecars wipe|delete' behandelt sie mit",
+	"exiftool not found; container and stream tags read with ffprobe":                                   "exiftool nicht gefunden; Container- und Stream-Tags mit ffprobe gelesen",
+	"Container: ":  "Container: ",
+	"%s, %d bytes": "%s, %d Bytes",
+	"offset":       "Offset",
+	"length":       "Länge",
+	"segment":      "Segment",
+	"%d segments; previews show the first %d payload bytes": "%d Segmente; Vorschau zeigt die ersten %d Nutzdaten-Bytes",
+	"No metadata detected": "Keine Metadaten gefunden",
+	"Detected Metadata:":   "Gefundene Metadaten:",
+	"Embedded Content:":    "Eingebettete Inhalte:",
+	"%s, critical":         "%s, kritisch",
+	"Found %d potentially sensitive metadata fields.": "%d potenziell sensible Metadatenfelder gefunden.",
+	"%d critical: %s": "%d kritisch: %s",
+	"This file has already been processed by CALIGRA. Consider checking profile configuration.": "Diese Datei wurde bereits von CALIGRA verarbeitet. Prüfen Sie die Profilkonfiguration.",
+	"Consider using 'caligra wipe' to remove metadata.":                                         "Mit 'caligra wipe' lassen sich die Metadaten entfernen.",
+	"No sensitive metadata detected":                                                            "Keine sensiblen Metadaten gefunden",
+	"Content Findings:":                                                                         "Funde im Inhalt:",
+	"No personal data found in the body":                                                        "Keine personenbezogenen Daten im Text gefunden",
+	"line %d:":                                                                                  "Zeile %d:",
+
+	// wipe result
+	"Found %d sensitive metadata fields":                  "%d sensible Metadatenfelder gefunden",
+	"File successfully processed":                         "Datei erfolgreich verarbeitet",
+	"Redacted %d pieces of personal data in the body":     "%d personenbezogene Angaben im Text geschwärzt",
+	"Normalized %d timestamp tags":                        "%d Zeitstempel-Tags vereinheitlicht",
+	"Excised %s of metadata (%d segments → %d)":           "%s Metadaten entfernt (%d Segmente → %d)",
+	"Size: %s → %s (%s saved)":                            "Größe: %s → %s (%s gespart)",
+	"Size: %s → %s (%s added)":                            "Größe: %s → %s (%s hinzugekommen)",
+	"%s → %s across %d files, %s saved":                   "%s → %s über %d Dateien, %s gespart",
+	"%s → %s across %d files, %s added":                   "%s → %s über %d Dateien, %s hinzugekommen",
+	"%s of metadata across %d segments":                   "%s Metadaten in %d Segmenten",
+	"Output saved to: %s":                                 "Ausgabe gespeichert unter: %s",
+	"Backup created at: %s":                               "Sicherung angelegt unter: %s",
+	"Original securely overwritten and deleted":           "Original sicher überschrieben und gelöscht",
+	"Original deleted without overwriting (see warnings)": "Original ohne Überschreiben gelöscht (siehe Warnungen)",
+	"Processing completed with issues...":                 "Verarbeitung mit Problemen abgeschlossen...",
+	"Original preserved at: %s":                           "Original erhalten unter: %s",
+	"Attestation written to: %s":                          "Bescheinigung geschrieben nach: %s",
+
+	// XMP sidecars
+	"XMP sidecar %s still holds the original metadata; use --sidecars wipe or --sidecars delete": "XMP-Begleitdatei %s enthält weiterhin die ursprünglichen Metadaten; --sidecars wipe oder --sidecars delete verwenden",
+	"XMP sidecar wiped: %s": "XMP-Begleitdatei bereinigt: %s",
+	"XMP sidecar %s left with the original; the copy has none": "XMP-Begleitdatei %s bleibt beim Original; die Kopie hat keine",
+	"XMP sidecar deleted: %s (backup at %s.bak)":               "XMP-Begleitdatei gelöscht: %s (Sicherung unter %s.bak)",
+	"XMP sidecar deleted: %s":                                  "XMP-Begleitdatei gelöscht: %s",
+
+	// secure removal
+	"Original": "Das Original",
+	"Backup":   "Die Sicherung",
+	"Sidecar":  "Die Begleitdatei",
+	"%s is on %s, which writes changes to new blocks; secure overwrite skipped, file removed normally. Its data stays in any snapshot and in free space until trimmed (fstrim)": "%s liegt auf %s, das Änderungen in neue Blöcke schreibt; sicheres Überschreiben übersprungen, Datei normal entfernt. Die Daten bleiben in Snapshots und im freien Speicher, bis er getrimmt wird (fstrim)",
+	"%s is on a network filesystem; secure overwrite skipped, file removed normally":                                                                                            "%s liegt auf einem Netzwerkdateisystem; sicheres Überschreiben übersprungen, Datei normal entfernt",
+
+	// hard links
+	"File had %d other hard links; it was given its own copy first, and they keep the original metadata":             "Die Datei hatte %d weitere Hardlinks; sie bekam zuerst eine eigene Kopie, die Hardlinks behalten die ursprünglichen Metadaten",
+	"File has %d other hard links; depending on the format they change with this wipe or keep the original metadata": "Die Datei hat %d weitere Hardlinks; je nach Format ändern sie sich mit dieser Bereinigung oder behalten die ursprünglichen Metadaten",
+	"Original has %d other hard links; only this name was removed, the data remains under the others":                "Das Original hat %d weitere Hardlinks; nur dieser Name wurde entfernt, die Daten bleiben unter den anderen erhalten",
+
+	// rebuilding
+	"Rebuilding is not supported for %s files; metadata was stripped only": "Neuaufbau wird für %s-Dateien nicht unterstützt; Metadaten wurden nur entfernt",
+}
@@ -0,0 +1,89 @@
+// BYZRA ⸻ internal/i18n/i18n.go
+// message catalog and locale selection for CLI output
+
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// messages are looked up by their English text (with its fmt verbs), so
+// untranslated ones fall back to English as-is
+type catalog map[string]string
+
+// available translations; English needs no catalog
+var catalogs = map[string]catalog{
+	"pt": pt,
+	"de": de,
+}
+
+// language of the current run
+var current = "en"
+
+// languages that can be selected, English first
+func Languages() []string {
+	langs := []string{"en"}
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// selects a language by code ("pt", "de_DE.UTF-8", ...)
+func SetLanguage(lang string) error {
+	code := normalize(lang)
+	if _, ok := catalogs[code]; !ok && code != "en" {
+		return fmt.Errorf("unsupported language %q (available: %s)", lang, strings.Join(Languages(), ", "))
+	}
+	current = code
+	return nil
+}
+
+// language in use
+func Language() string {
+	return current
+}
+
+// language from the environment, following gettext's order
+// (LC_ALL, LC_MESSAGES, LANG); English when unset or unsupported
+func FromEnvironment() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		code := normalize(value)
+		if _, ok := catalogs[code]; ok {
+			return code
+		}
+		return "en"
+	}
+	return "en"
+}
+
+// "pt_BR.UTF-8" → "pt"; "C" and "POSIX" are English
+func normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "" || lang == "c" || lang == "posix" {
+		return "en"
+	}
+	return lang
+}
+
+// translates message into the current language, formatting args into it
+func T(message string, args ...any) string {
+	if translated, ok := catalogs[current][message]; ok {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
@@ -0,0 +1,282 @@
+// BYZRA ⸻ internal/i18n/pt.go
+// Portuguese messages
+
+package i18n
+
+var pt = catalog{
+	// commands
+	"Unknown command: %s":             "Comando desconhecido: %s",
+	"Usage: %s":                       "Uso: %s",
+	"Downloading: %s":                 "Baixando: %s",
+	"File not found: %s":              "Arquivo não encontrado: %s",
+	"Processing: %s":                  "Processando: %s",
+	"Invalid --max-size: %s":          "--max-size inválido: %s",
+	"Unknown timezone: %s":            "Fuso horário desconhecido: %s",
+	"No file specified for analysis":  "Nenhum arquivo indicado para análise",
+	"Analyzing: %s":                   "Analisando: %s",
+	"Analyzing metadata":              "Analisando metadados",
+	"Analysis failed: %s":             "Falha na análise: %s",
+	"Analysis completed successfully": "Análise concluída com sucesso",
+	"No file specified for wiping":    "Nenhum arquivo indicado para limpeza",
+	"Removing metadata":               "Removendo metadados",
+	"Wipe failed: %s":                 "Falha na limpeza: %s",
+	"Wipe completed successfully":     "Limpeza concluída com sucesso",
+
+	// batch and remote wipes
+	"--resume takes its inputs from the manifest":         "--resume obtém as entradas do manifesto",
+	"--resume needs the manifest of the interrupted run":  "--resume precisa do manifesto da execução interrompida",
+	"Remote locations are wiped one at a time":            "Locais remotos são limpos um de cada vez",
+	"Journal: %s (continue with --resume if interrupted)": "Diário: %s (continue com --resume se for interrompido)",
+	"Resuming: %s":                                                 "Retomando: %s",
+	"%d files already done, skipping them":                         "%d arquivos já concluídos, ignorando-os",
+	"Wiping with the options of the interrupted run":               "Limpando com as opções da execução interrompida",
+	"Wiped %d files (%d with issues, %d failed)":                   "%d arquivos limpos (%d com problemas, %d com falha)",
+	"Manifest written to: %s":                                      "Manifesto gravado em: %s",
+	"Retry the failures with: %s":                                  "Repita as falhas com: %s",
+	"Random renaming and attestation are skipped for remote files": "Renomeação aleatória e atestado não se aplicam a arquivos remotos",
+	"No objects found under %s":                                    "Nenhum objeto encontrado em %s",
+	"%d of %d objects sanitized":                                   "%d de %d objetos limpos",
+	"metadata remains after the wipe":                              "restam metadados após a limpeza",
+	"no copy was written":                                          "nenhuma cópia foi gravada",
+	"the original was not replaced":                                "o original não foi substituído",
+
+	// progress
+	"Reading tags with exiftool":   "Lendo as tags com o exiftool",
+	"Stripping tags with exiftool": "Removendo as tags com o exiftool",
+	"Wiping metadata from %s":      "Limpando os metadados de %s",
+	"Metadata removed":             "Metadados removidos",
+	"Profile injected":             "Perfil injetado",
+	"Verifying output":             "Verificando o resultado",
+	"Image re-encoded":             "Imagem recodificada",
+	"Container remuxed":            "Contêiner remuxado",
+
+	// help
+	"USAGE":            "USO",
+	"COMMANDS":         "COMANDOS",
+	"ANALYSE OPTIONS":  "OPÇÕES DE ANÁLISE",
+	"WIPE OPTIONS":     "OPÇÕES DE LIMPEZA",
+	"IMPORT OPTIONS":   "OPÇÕES DE IMPORTAÇÃO",
+	"REPORT OPTIONS":   "OPÇÕES DE RELATÓRIO",
+	"FIND OPTIONS":     "OPÇÕES DE BUSCA",
+	"SELFTEST OPTIONS": "OPÇÕES DO AUTOTESTE",
+	"LOG OPTIONS":      "OPÇÕES DE LOG",
+	"GLOBAL OPTIONS":   "OPÇÕES GLOBAIS",
+
+	"analyze metadata in a file":                       "analisa os metadados de um arquivo",
+	"remove metadata from a file":                      "remove os metadados de um arquivo",
+	"wipe many files, writing a JSON manifest":         "limpa vários arquivos, gravando um manifesto JSON",
+	"clean remote objects in place (prefix with /)":    "limpa objetos remotos no lugar (prefixo com /)",
+	"manage background monitoring service":             "gerencia o serviço de monitoramento em segundo plano",
+	"watch <dir> (--policy <name>), no restart needed": "monitora <dir> (--policy <nome>), sem reiniciar",
+	"stop watching <dir>":                              "deixa de monitorar <dir>",
+	"view the daemon log":                              "mostra o log do daemon",
+	"follow what the running daemon does, live":        "acompanha ao vivo o que o daemon em execução faz",
+	"[~] Following daemon events; Ctrl-C stops":        "[~] Acompanhando eventos do daemon; Ctrl-C encerra",
+	"Detected %s":                                              "Detectado %s",
+	"No sensitive metadata in %s":                              "Nenhum metadado sensível em %s",
+	"%d sensitive fields in %s, not wiped":                     "%d campos sensíveis em %s, não limpos",
+	"Wiped %s with issues: %s":                                 "%s limpo com problemas: %s",
+	"Wiped %s → %s":                                            "%s limpo → %s",
+	"show daemon activity trends (default 30d)":                "mostra a atividade do daemon (padrão 30d)",
+	"register the daemon as a Windows service":                 "registra o daemon como serviço do Windows",
+	"remove the Windows service":                               "remove o serviço do Windows",
+	"start the daemon at login on macOS (LaunchAgent)":         "inicia o daemon no login do macOS (LaunchAgent)",
+	"copy and clean a camera card's DCIM folder":               "copia e limpa a pasta DCIM de um cartão de câmera",
+	"strip metadata from the clipboard image":                  "remove os metadados da imagem na área de transferência",
+	"verify a signed wipe attestation":                         "verifica um atestado de limpeza assinado",
+	"start the hash-chained audit journal of wipes":            "inicia o diário de auditoria encadeado por hash das limpezas",
+	"list recent journal entries (default 20)":                 "lista as entradas recentes do diário (padrão 20)",
+	"anchor the journal head (--to <file> for a copy)":         "ancora o topo do diário (--to <arquivo> para uma cópia)",
+	"detect changes to the journal (--anchors <file>)":         "detecta alterações no diário (--anchors <arquivo>)",
+	"check scroud.toml, yogra.toml and the profile":            "verifica scroud.toml, yogra.toml e o perfil",
+	"No %s found; defaults are used":                           "Nenhum %s encontrado; os padrões são usados",
+	"%s: no problems found":                                    "%s: nenhum problema encontrado",
+	"%d errors, %d warnings":                                   "%d erros, %d avisos",
+	"No audit journal; start one with: caligra history init":   "Nenhum diário de auditoria; inicie um com: caligra history init",
+	"Audit journal already exists: %s":                         "O diário de auditoria já existe: %s",
+	"Audit journal started: %s":                                "Diário de auditoria iniciado: %s",
+	"Wipes by the CLI and the daemon are recorded from now on": "Limpezas feitas pela CLI e pelo daemon serão registradas a partir de agora",
+	"Anchored entry %d (%s) in %s":                             "Entrada %d (%s) ancorada em %s",
+	"Audit journal was tampered with":                          "O diário de auditoria foi adulterado",
+	"anchors":                                                  "âncoras",
+	"line %d":                                                  "linha %d",
+	"Audit journal intact: %d entries, %d anchors checked":     "Diário de auditoria íntegro: %d entradas, %d âncoras verificadas",
+	"Head: %s": "Topo: %s",
+	"No anchors yet; without one, a rewritten journal cannot be told apart": "Ainda sem âncoras; sem uma, um diário reescrito não pode ser distinguido",
+	"Unknown history command: %s":                                           "Comando history desconhecido: %s",
+	"journal started":                                                       "diário iniciado",
+	"write an HTML/PDF compliance report for a directory":                   "gera um relatório de conformidade HTML/PDF de um diretório",
+	"show the built-in color themes":                                        "mostra os temas de cores disponíveis",
+	"switch the color theme":                                                "troca o tema de cores",
+	"show a theme's colors and sample output":                               "mostra as cores de um tema e um exemplo",
+	"check which format pipelines work on this machine":                     "verifica quais formatos funcionam nesta máquina",
+	"count formats, exposure and leaking apps in a tree":                    "conta formatos, exposição e apps que vazam em uma árvore",
+	"record a tree's metadata as a baseline":                                "registra os metadados de uma árvore como referência",
+	"list files whose metadata appeared or changed since":                   "lista arquivos cujos metadados surgiram ou mudaram desde então",
+	"--compare needs a snapshot file":                                       "--compare precisa de um arquivo de snapshot",
+	"No directory specified for the snapshot":                               "Nenhum diretório informado para o snapshot",
+	"Scanning: %s":                                       "Varrendo: %s",
+	"Snapshot failed: %s":                                "Falha no snapshot: %s",
+	"%d files recorded, %d errors":                       "%d arquivos registrados, %d erros",
+	"Snapshot written to: %s":                            "Snapshot gravado em: %s",
+	"Check for drift with: caligra analyse --compare %s": "Verifique mudanças com: caligra analyse --compare %s",
+	"Comparing %s with the snapshot of %s":               "Comparando %s com o snapshot de %s",
+	"Comparison failed: %s":                              "Falha na comparação: %s",
+	"metadata changed":                                   "metadados mudaram",
+	"new file with metadata":                             "arquivo novo com metadados",
+	"sensitive: %s":                                      "sensíveis: %s",
+	"%d unchanged, %d changed, %d new with metadata, %d cleaned, %d removed": "%d inalterados, %d alterados, %d novos com metadados, %d limpos, %d removidos",
+	"print paths of sensitive files, for xargs":                              "mostra caminhos de arquivos sensíveis, para xargs",
+	"index analysis results for instant queries":                             "indexa os resultados da análise para consultas instantâneas",
+	"query the index (find criteria, --changed-since)":                       "consulta o índice (critérios do find, --changed-since)",
+	"list past index builds":                                                 "lista as indexações anteriores",
+	"print the JSON schema of --json output":                                 "imprime o JSON schema da saída --json",
+	"run the HTTP API for analysing and wiping uploads":                      "executa a API HTTP para analisar e limpar uploads",
+	"SERVE OPTIONS": "OPÇÕES DO SERVE",
+	"address to listen on (default 127.0.0.1:8470)":     "endereço de escuta (padrão 127.0.0.1:8470)",
+	"bearer tokens, one per line (chmod 600)":           "tokens bearer, um por linha (chmod 600)",
+	"requests per client per minute (default 60)":       "requisições por cliente por minuto (padrão 60)",
+	"requests a client may send at once":                "requisições que um cliente pode enviar de uma vez",
+	"upload limit (default 100)":                        "limite de upload (padrão 100)",
+	"serve HTTPS with this certificate (and --tls-key)": "serve HTTPS com este certificado (e --tls-key)",
+	"require client certificates signed by this CA":     "exige certificados de cliente assinados por esta CA",
+	"wipe policy applied to every upload":               "política de limpeza aplicada a cada upload",
+	"Invalid %s: %s":                                    "%s inválido: %s",
+	"Serving on %s":                                     "Servindo em %s",
+	"POST /v1/analyse, /v1/wipe and /v1/jobs with multipart field \"file\"; Ctrl-C stops": "POST /v1/analyse, /v1/wipe e /v1/jobs com o campo multipart \"file\"; Ctrl-C encerra",
+	"show this help information":                                   "mostra esta ajuda",
+	"show build information (and backend versions)":                "mostra a versão (e as versões das ferramentas externas)",
+	"scan text bodies for emails, phones, IBANs, IPs":              "procura e-mails, telefones, IBANs e IPs no texto",
+	"dump every segment with offset, length and hex preview":       "lista cada segmento com posição, tamanho e prévia hex",
+	"print a versioned JSON document (see 'caligra schema')":       "imprime um documento JSON versionado (veja 'caligra schema')",
+	"print the result as JSON (one local file)":                    "imprime o resultado em JSON (um arquivo local)",
+	"download limit for URLs (default 100)":                        "limite de download para URLs (padrão 100)",
+	"include sub-directories of directory inputs":                  "inclui os subdiretórios dos diretórios de entrada",
+	"where to write the batch manifest":                            "onde gravar o manifesto do lote",
+	"continue an interrupted batch wipe":                           "continua uma limpeza em lote interrompida",
+	"wipe only files matching, e.g. 'size<500MB && ext=jpg'":       "limpa só ficheiros que correspondem, p. ex. 'size<500MB && ext=jpg'",
+	"don't inject profile metadata":                                "não injeta os metadados do perfil",
+	"modify file in place (don't create copy)":                     "modifica o arquivo no lugar (sem criar cópia)",
+	"don't keep backup of original file":                           "não mantém backup do arquivo original",
+	"securely overwrite original data":                             "sobrescreve os dados originais com segurança",
+	"inject <name>.lua instead of profile.lua":                     "injeta <nome>.lua em vez de profile.lua",
+	"rewrite dates into <zone>, strip offsets":                     "reescreve as datas em <zona>, remove os deslocamentos",
+	"same as --timezone UTC":                                       "o mesmo que --timezone UTC",
+	"replace personal data in text bodies":                         "substitui dados pessoais no texto",
+	"apply a policy from policies.toml or a built-in preset":       "aplica uma política de policies.toml ou predefinida",
+	"audio cover art: keep | remove | strip":                       "capa do áudio: keep | remove | strip",
+	"photo and track dates: keep | remove | day | month | year":    "datas de captura e de trilhas: keep | remove | day | month | year",
+	"calendar organizers and attendees: keep | remove | strip":     "organizadores e participantes de calendários: keep | remove | strip",
+	"drop the points of GPS tracks, keep their names":              "remover os pontos das trilhas GPS, mantendo os nomes",
+	"cut the vendor MakerNote out of EXIF whole":                   "recorta a MakerNote do fabricante do EXIF por inteiro",
+	"apply EXIF orientation to the pixels before removing it":      "aplica a orientação EXIF aos pixels antes de removê-la",
+	"keep encoder tags and LAME settings":                          "mantém as tags do codificador e as configurações LAME",
+	"keep ReplayGain/R128 gain tags":                               "mantém as tags de ganho ReplayGain/R128",
+	"keep MusicBrainz track/release/artist IDs":                    "mantém os IDs MusicBrainz de faixa/lançamento/artista",
+	"keep AcoustID IDs and fingerprints":                           "mantém os IDs e impressões digitais AcoustID",
+	"XMP sidecars (photo.xmp, photo.jpg.xmp): wipe | delete":       "arquivos XMP auxiliares (photo.xmp, photo.jpg.xmp): wipe | delete",
+	"MKV parts to keep: fonts,covers,chapters,track-names|none":    "partes do MKV a manter: fonts,covers,chapters,track-names|none",
+	"destination for cleaned copies":                               "destino das cópias limpas",
+	"keep | sequence | date":                                       "keep | sequence | date",
+	"where to write the import manifest":                           "onde gravar o manifesto da importação",
+	"document format (default html)":                               "formato do documento (padrão html)",
+	"policy the directory is held to":                              "política exigida do diretório",
+	"where to write the report":                                    "onde gravar o relatório",
+	"gps, author, serial, identifier, device, software, timestamp": "gps, author, serial, identifier, device, software, timestamp",
+	"image, audio, video, text, matroska or an extension":          "image, audio, video, text, matroska ou uma extensão",
+	"modified since a date (2023, 2023-05) or span (30d)":          "modificado desde uma data (2023, 2023-05) ou período (30d)",
+	"files analysed in parallel (default: CPU count)":              "arquivos analisados em paralelo (padrão: número de CPUs)",
+	"separate paths with NUL, for xargs -0":                        "separa os caminhos com NUL, para xargs -0",
+	"keep the generated samples and their wiped copies":            "mantém as amostras geradas e suas cópias limpas",
+	"keep printing new entries":                                    "continua mostrando novas entradas",
+	"minimum level (debug|info|warning|error)":                     "nível mínimo (debug|info|warning|error)",
+	"existing lines to show (0 = all)":                             "linhas existentes a mostrar (0 = todas)",
+	"no colors, spinner or screen clearing (automatic when piped)": "sem cores, animação ou limpeza de tela (automático quando redirecionado)",
+	"clear the screen first (or clear_screen in yogra.toml)":       "limpa a tela antes (ou clear_screen em yogra.toml)",
+	"detect by content only; unrecognized files are unknown":       "detecta só pelo conteúdo; arquivos não reconhecidos ficam desconhecidos",
+	"skip larger files (or max_size_mb in scroud.toml)":            "ignora arquivos maiores (ou max_size_mb no scroud.toml)",
+	"--max-file-size takes a size in MB (0 for no limit), not %q":  "--max-file-size recebe um tamanho em MB (0 para sem limite), não %q",
+	"message language: %s (default from LANG)":                     "idioma das mensagens: %s (padrão de LANG)",
+
+	// selftest
+	"Running analyse → wipe → verify on generated samples":       "Executando análise → limpeza → verificação em amostras geradas",
+	"%d pipelines work, %d failed, %d skipped":                   "%d formatos funcionam, %d falharam, %d ignorados",
+	"Install the missing backends to enable the skipped formats": "Instale as ferramentas externas ausentes para habilitar os formatos ignorados",
+	"Samples kept in: %s": "Amostras mantidas em: %s",
+	"skipped: %s":         "ignorado: %s",
+
+	// version
+	"A CLI metadata control utility for Linux": "Um utilitário de linha de comando para controle de metadados",
+	"BACKENDS":                           "FERRAMENTAS EXTERNAS",
+	"not found (%s)":                     "não encontrado (%s)",
+	"image, audio and document metadata": "metadados de imagens, áudio e documentos",
+	"audio/video remuxing":               "remux de áudio/vídeo",
+	"stream and chapter inspection":      "inspeção de fluxos e capítulos",
+	"ImageMagick image inspection":       "inspeção de imagens ImageMagick",
+
+	// analysis report
+	"File: ": "Arquivo: ",
+	"Type: ": "Tipo: ",
+	"%d other hard links share this file's content; an in-place wipe or secure delete reaches them too": "%d outros links físicos compartilham o conteúdo deste arquivo; uma limpeza no lugar ou exclusão segura também os alcança",
+	"XMP sidecar %s holds metadata for this file; 'caligra wipe --sidecars wipe|delete' handles it too": "O arquivo XMP auxiliar %s guarda metadados deste arquivo; 'caligra wipe --sidecars wipe|delete' também o trata",
+	"exiftool not found; container and stream tags read with ffprobe":                                   "exiftool não encontrado; tags do contêiner e das faixas lidas com ffprobe",
+	"Container: ":  "Contêiner: ",
+	"%s, %d bytes": "%s, %d bytes",
+	"offset":       "posição",
+	"length":       "tamanho",
+	"segment":      "segmento",
+	"%d segments; previews show the first %d payload bytes": "%d segmentos; as prévias mostram os primeiros %d bytes de conteúdo",
+	"No metadata detected": "Nenhum metadado detectado",
+	"Detected Metadata:":   "Metadados detectados:",
+	"Embedded Content:":    "Conteúdo incorporado:",
+	"%s, critical":         "%s, crítico",
+	"Found %d potentially sensitive metadata fields.": "Encontrados %d campos de metadados potencialmente sensíveis.",
+	"%d critical: %s": "%d críticos: %s",
+	"This file has already been processed by CALIGRA. Consider checking profile configuration.": "Este arquivo já foi processado pelo CALIGRA. Verifique a configuração do perfil.",
+	"Consider using 'caligra wipe' to remove metadata.":                                         "Use 'caligra wipe' para remover os metadados.",
+	"No sensitive metadata detected":                                                            "Nenhum metadado sensível detectado",
+	"Content Findings:":                                                                         "Achados no conteúdo:",
+	"No personal data found in the body":                                                        "Nenhum dado pessoal encontrado no texto",
+	"line %d:":                                                                                  "linha %d:",
+
+	// wipe result
+	"Found %d sensitive metadata fields":                  "Encontrados %d campos de metadados sensíveis",
+	"File successfully processed":                         "Arquivo processado com sucesso",
+	"Redacted %d pieces of personal data in the body":     "%d dados pessoais ocultados no texto",
+	"Normalized %d timestamp tags":                        "%d tags de data normalizadas",
+	"Excised %s of metadata (%d segments → %d)":           "%s de metadados removidos (%d segmentos → %d)",
+	"Size: %s → %s (%s saved)":                            "Tamanho: %s → %s (%s economizados)",
+	"Size: %s → %s (%s added)":                            "Tamanho: %s → %s (%s acrescentados)",
+	"%s → %s across %d files, %s saved":                   "%s → %s em %d arquivos, %s economizados",
+	"%s → %s across %d files, %s added":                   "%s → %s em %d arquivos, %s acrescentados",
+	"%s of metadata across %d segments":                   "%s de metadados em %d segmentos",
+	"Output saved to: %s":                                 "Saída gravada em: %s",
+	"Backup created at: %s":                               "Backup criado em: %s",
+	"Original securely overwritten and deleted":           "Original sobrescrito com segurança e excluído",
+	"Original deleted without overwriting (see warnings)": "Original excluído sem sobrescrever (veja os avisos)",
+	"Processing completed with issues...":                 "Processamento concluído com problemas...",
+	"Original preserved at: %s":                           "Original preservado em: %s",
+	"Attestation written to: %s":                          "Atestado gravado em: %s",
+
+	// XMP sidecars
+	"XMP sidecar %s still holds the original metadata; use --sidecars wipe or --sidecars delete": "O arquivo XMP auxiliar %s ainda guarda os metadados originais; use --sidecars wipe ou --sidecars delete",
+	"XMP sidecar wiped: %s": "Arquivo XMP auxiliar limpo: %s",
+	"XMP sidecar %s left with the original; the copy has none": "Arquivo XMP auxiliar %s mantido com o original; a cópia não tem nenhum",
+	"XMP sidecar deleted: %s (backup at %s.bak)":               "Arquivo XMP auxiliar excluído: %s (backup em %s.bak)",
+	"XMP sidecar deleted: %s":                                  "Arquivo XMP auxiliar excluído: %s",
+
+	// secure removal
+	"Original": "O original",
+	"Backup":   "O backup",
+	"Sidecar":  "O arquivo auxiliar",
+	"%s is on %s, which writes changes to new blocks; secure overwrite skipped, file removed normally. Its data stays in any snapshot and in free space until trimmed (fstrim)": "%s está em %s, que grava alterações em novos blocos; sobrescrita segura ignorada, arquivo removido normalmente. Os dados ficam em snapshots e no espaço livre até serem descartados (fstrim)",
+	"%s is on a network filesystem; secure overwrite skipped, file removed normally":                                                                                            "%s está em um sistema de arquivos de rede; sobrescrita segura ignorada, arquivo removido normalmente",
+
+	// hard links
+	"File had %d other hard links; it was given its own copy first, and they keep the original metadata":             "O arquivo tinha %d outros links físicos; ele recebeu antes uma cópia própria, e eles mantêm os metadados originais",
+	"File has %d other hard links; depending on the format they change with this wipe or keep the original metadata": "O arquivo tem %d outros links físicos; conforme o formato, eles mudam com esta limpeza ou mantêm os metadados originais",
+	"Original has %d other hard links; only this name was removed, the data remains under the others":                "O original tem %d outros links físicos; só este nome foi removido, os dados continuam sob os outros",
+
+	// rebuilding
+	"Rebuilding is not supported for %s files; metadata was stripped only": "Reconstrução não é suportada para arquivos %s; os metadados foram apenas removidos",
+}
@@ -0,0 +1,478 @@
+// BYZRA ⸻ internal/index/index.go
+// local bbolt index of analysis results, with a snapshot per scan
+
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"caligra/internal/analyse"
+	"caligra/internal/census"
+	"caligra/internal/filter"
+	"caligra/internal/find"
+	"caligra/internal/formats"
+)
+
+var (
+	filesBucket     = []byte("files")     // path -> Entry, the latest state
+	scansBucket     = []byte("scans")     // scan ID -> Scan
+	snapshotsBucket = []byte("snapshots") // scan ID -> bucket of path -> Entry
+)
+
+// one file as last analysed
+type Entry struct {
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Format    string    `json:"format,omitempty"`
+	Extension string    `json:"extension,omitempty"`
+	Sensitive []string  `json:"sensitive,omitempty"`
+	Exposure  []string  `json:"exposure,omitempty"` // census exposure keys
+	Error     string    `json:"error,omitempty"`
+	Analysed  time.Time `json:"analysed"`
+}
+
+// one run of Build
+type Scan struct {
+	ID       string    `json:"id"` // start time, sortable
+	Root     string    `json:"root"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Files    int       `json:"files"`
+	Analysed int       `json:"analysed"` // new or changed since the last scan
+	Reused   int       `json:"reused"`   // unchanged, taken from the index
+	Removed  int       `json:"removed"`  // indexed before, gone now
+	Errors   int       `json:"errors"`
+}
+
+// what differs between a file's state in two scans
+type Change struct {
+	Path   string
+	Kind   string   // ChangeAdded, ChangeRemoved or ChangeModified
+	Gained []string // exposure keys
+	Lost   []string
+}
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+type Index struct {
+	db *bolt.DB
+}
+
+// ~/.caligra/index.db
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra", "index.db")
+}
+
+func Open(path string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{filesBucket, scansBucket, snapshotsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Index{db: db}, nil
+}
+
+func (x *Index) Close() error {
+	return x.db.Close()
+}
+
+// ╭─ BUILD ─────────────────────────────────────╮
+
+// indexes every supported file under dir: unchanged files (same size and
+// mtime) are taken from the index, the rest analysed on workers goroutines
+// (NumCPU when 0); progress is called after each file
+func (x *Index) Build(dir string, workers int, progress func(done, total int)) (*Scan, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", dir)
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	scan := &Scan{Root: root, Started: time.Now().UTC()}
+	scan.ID = scan.Started.Format("20060102T150405.000000000Z")
+
+	var found []Entry
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !formats.IsSupported(filepath.Ext(path)) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		found = append(found, Entry{Path: path, Size: info.Size(), ModTime: info.ModTime().UTC()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+
+	// reuse what the index already knows
+	var stale []int
+	err = x.db.View(func(tx *bolt.Tx) error {
+		files := tx.Bucket(filesBucket)
+		for i := range found {
+			var known Entry
+			if data := files.Get([]byte(found[i].Path)); data != nil && json.Unmarshal(data, &known) == nil &&
+				known.Size == found[i].Size && known.ModTime.Equal(found[i].ModTime) && known.Error == "" {
+				found[i] = known
+				scan.Reused++
+				continue
+			}
+			stale = append(stale, i)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	done := scan.Reused
+	if progress != nil && done > 0 {
+		progress(done, len(found))
+	}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				analyseEntry(&found[i])
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done, len(found))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, i := range stale {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	scan.Files = len(found)
+	scan.Analysed = len(stale)
+	for _, entry := range found {
+		if entry.Error != "" {
+			scan.Errors++
+		}
+	}
+
+	err = x.db.Update(func(tx *bolt.Tx) error {
+		files := tx.Bucket(filesBucket)
+
+		// files under root the walk no longer found
+		present := make(map[string]bool, len(found))
+		for _, entry := range found {
+			present[entry.Path] = true
+		}
+		var gone [][]byte
+		cursor := files.Cursor()
+		prefix := []byte(root + string(filepath.Separator))
+		for key, _ := cursor.Seek(prefix); key != nil && strings.HasPrefix(string(key), string(prefix)); key, _ = cursor.Next() {
+			if !present[string(key)] {
+				gone = append(gone, slices.Clone(key))
+			}
+		}
+		for _, key := range gone {
+			if err := files.Delete(key); err != nil {
+				return err
+			}
+		}
+		scan.Removed = len(gone)
+
+		snapshot, err := tx.Bucket(snapshotsBucket).CreateBucket([]byte(scan.ID))
+		if err != nil {
+			return err
+		}
+		for _, entry := range found {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := files.Put([]byte(entry.Path), data); err != nil {
+				return err
+			}
+			if err := snapshot.Put([]byte(entry.Path), data); err != nil {
+				return err
+			}
+		}
+
+		scan.Finished = time.Now().UTC()
+		data, err := json.Marshal(scan)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(scansBucket).Put([]byte(scan.ID), data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write index: %w", err)
+	}
+	return scan, nil
+}
+
+func analyseEntry(entry *Entry) {
+	entry.Analysed = time.Now().UTC()
+	report, err := analyse.Analyze(entry.Path)
+	if err != nil {
+		entry.Error = err.Error()
+		return
+	}
+	entry.Format = report.FileType.Format
+	entry.Extension = report.FileType.Extension
+	entry.Sensitive = slices.Sorted(slices.Values(report.SensitiveFields))
+	for _, exposure := range census.Classify(report) {
+		entry.Exposure = append(entry.Exposure, exposure.Key)
+	}
+}
+
+// ╭─ QUERY ─────────────────────────────────────╮
+
+// does the entry meet the same criteria find applies to live files?
+// files that could not be analysed never match
+func (e Entry) Matches(criteria find.Criteria) bool {
+	if e.Error != "" {
+		return false
+	}
+	if len(criteria.Formats) > 0 && !slices.Contains(criteria.Formats, e.Format) &&
+		!slices.Contains(criteria.Formats, e.Extension) {
+		return false
+	}
+	if !criteria.Since.IsZero() && e.ModTime.Before(criteria.Since) {
+		return false
+	}
+	if !criteria.Filter.Match(filter.File{Path: e.Path, Size: e.Size, ModTime: e.ModTime}, analyse.DetectType) {
+		return false
+	}
+	if len(criteria.Has) == 0 {
+		return len(e.Sensitive) > 0
+	}
+	for _, want := range criteria.Has {
+		if !slices.Contains(e.Exposure, want.Key) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexed files under dir ("" for all), sorted by path
+func (x *Index) Files(dir string) ([]Entry, error) {
+	prefix, err := scopePrefix(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	err = x.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(filesBucket).ForEach(func(key, data []byte) error {
+			if !strings.HasPrefix(string(key), prefix) {
+				return nil
+			}
+			var entry Entry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// every scan, oldest first
+func (x *Index) Scans() ([]Scan, error) {
+	var list []Scan
+	err := x.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(scansBucket).ForEach(func(id, data []byte) error {
+			var scan Scan
+			if err := json.Unmarshal(data, &scan); err != nil {
+				return err
+			}
+			list = append(list, scan)
+			return nil
+		})
+	})
+	return list, err
+}
+
+// how files under dir changed between the last scan covering dir at or
+// before since and the current index; the scan compared against is returned
+func (x *Index) ChangesSince(dir string, since time.Time) ([]Change, *Scan, error) {
+	prefix, err := scopePrefix(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	scans, err := x.Scans()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var base *Scan
+	for i := len(scans) - 1; i >= 0; i-- {
+		if covers(scans[i], prefix) && !scans[i].Started.After(since) {
+			base = &scans[i]
+			break
+		}
+	}
+	if base == nil {
+		return nil, nil, fmt.Errorf("no scan of %s at or before %s; run 'caligra index build' first", displayScope(dir), since.Format("2006-01-02 15:04"))
+	}
+
+	before := make(map[string]Entry)
+	err = x.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(snapshotsBucket).Bucket([]byte(base.ID)).ForEach(func(key, data []byte) error {
+			if !strings.HasPrefix(string(key), prefix) {
+				return nil
+			}
+			var entry Entry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return err
+			}
+			before[entry.Path] = entry
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// only the part of the index the base scan covered is comparable
+	current, err := x.Files(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	rootPrefix := base.Root + string(filepath.Separator)
+
+	var changes []Change
+	for _, entry := range current {
+		if !strings.HasPrefix(entry.Path, rootPrefix) {
+			continue
+		}
+		old, ok := before[entry.Path]
+		delete(before, entry.Path)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: entry.Path, Kind: ChangeAdded, Gained: entry.Exposure})
+		case old.Size != entry.Size || !old.ModTime.Equal(entry.ModTime):
+			changes = append(changes, Change{
+				Path: entry.Path, Kind: ChangeModified,
+				Gained: missing(entry.Exposure, old.Exposure),
+				Lost:   missing(old.Exposure, entry.Exposure),
+			})
+		}
+	}
+	for _, old := range before {
+		changes = append(changes, Change{Path: old.Path, Kind: ChangeRemoved, Lost: old.Exposure})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, base, nil
+}
+
+// the scan before the latest one covering dir, for "what changed since
+// the last build"
+func (x *Index) PreviousScan(dir string) (*Scan, error) {
+	prefix, err := scopePrefix(dir)
+	if err != nil {
+		return nil, err
+	}
+	scans, err := x.Scans()
+	if err != nil {
+		return nil, err
+	}
+	seen := 0
+	for i := len(scans) - 1; i >= 0; i-- {
+		if covers(scans[i], prefix) {
+			if seen++; seen == 2 {
+				return &scans[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s has fewer than two scans; run 'caligra index build' again later", displayScope(dir))
+}
+
+// did the scan include everything under prefix ("" for any scan)?
+func covers(scan Scan, prefix string) bool {
+	return prefix == "" || strings.HasPrefix(prefix, scan.Root+string(filepath.Separator))
+}
+
+// the items of a not in b
+func missing(a, b []string) []string {
+	var out []string
+	for _, item := range a {
+		if !slices.Contains(b, item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// absolute dir with a trailing separator, "" for everything
+func scopePrefix(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(abs, string(filepath.Separator)) + string(filepath.Separator), nil
+}
+
+func displayScope(dir string) string {
+	if dir == "" {
+		return "the index"
+	}
+	return dir
+}
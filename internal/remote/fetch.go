@@ -0,0 +1,100 @@
+// BYZRA ⸻ internal/remote/fetch.go
+// downloading published files for analysis
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// default cap on downloaded file size
+const DefaultMaxSize int64 = 100 << 20 // 100MB
+
+// overall timeout for a single download
+const fetchTimeout = 2 * time.Minute
+
+// is the argument an http(s) URL rather than a local path?
+func IsURL(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+// filename a URL refers to, used for extension detection and local output
+func FileName(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "download"
+	}
+
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" || name == "" {
+		return "download"
+	}
+	return util.SanitizeFilename(name)
+}
+
+// downloads a URL into a private temp file, refusing anything over maxSize
+// the caller must call cleanup once done with the file
+func Fetch(rawURL string, maxSize int64) (string, func(), error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	if resp.ContentLength > maxSize {
+		return "", nil, fmt.Errorf("remote file is %d bytes, over the %d byte limit", resp.ContentLength, maxSize)
+	}
+
+	tmp, err := util.CreateTempFile("caligra-remote-*-" + FileName(rawURL))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	cleanup := func() { os.Remove(tmpPath) }
+
+	if err := util.EnsureSafePermissions(tmpPath); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to secure temp file: %w", err)
+	}
+
+	// read one byte past the limit to detect oversized bodies without a length
+	written, err := io.Copy(tmp, io.LimitReader(resp.Body, maxSize+1))
+	closeErr := tmp.Close()
+	if err != nil || closeErr != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to save download: %w", errors.Join(err, closeErr))
+	}
+
+	if written > maxSize {
+		cleanup()
+		return "", nil, fmt.Errorf("remote file exceeds the %d byte limit", maxSize)
+	}
+
+	return tmpPath, cleanup, nil
+}
+
+// local path for the sanitized copy of a remote file
+func LocalOutputPath(rawURL string) string {
+	return util.GenerateOutputPath(filepath.Join(".", FileName(rawURL)))
+}
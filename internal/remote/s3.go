@@ -0,0 +1,375 @@
+// BYZRA ⸻ internal/remote/s3.go
+// S3 objects, signed with AWS Signature Version 4
+
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// hash of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// access keys for signing
+type s3Credentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// an object (or prefix, when key ends in "/") in a bucket
+type S3Target struct {
+	bucket   string
+	key      string
+	region   string
+	endpoint string // custom endpoint for S3-compatible stores (path-style)
+	creds    s3Credentials
+	client   *http.Client
+}
+
+func parseS3Target(location string) (*S3Target, error) {
+	rest := strings.TrimPrefix(location, "s3://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("missing bucket in %s", location)
+	}
+
+	creds, err := loadS3Credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Target{
+		bucket:   bucket,
+		key:      key,
+		region:   s3Region(),
+		endpoint: strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/"),
+		creds:    creds,
+		client:   &http.Client{Timeout: fetchTimeout},
+	}, nil
+}
+
+// credentials from the environment, then the shared credentials file
+func loadS3Credentials() (s3Credentials, error) {
+	if key := os.Getenv("AWS_ACCESS_KEY_ID"); key != "" {
+		return s3Credentials{
+			accessKey:    key,
+			secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+
+	credsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credsFile == "" {
+		credsFile = filepath.Join(os.Getenv("HOME"), ".aws", "credentials")
+	}
+
+	values := readINISection(credsFile, profile)
+	if values["aws_access_key_id"] == "" {
+		return s3Credentials{}, fmt.Errorf("no S3 credentials found (set AWS_ACCESS_KEY_ID or configure profile %q)", profile)
+	}
+
+	return s3Credentials{
+		accessKey:    values["aws_access_key_id"],
+		secretKey:    values["aws_secret_access_key"],
+		sessionToken: values["aws_session_token"],
+	}, nil
+}
+
+// region from the environment or the shared config file
+func s3Region() string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region
+		}
+	}
+
+	profile := os.Getenv("AWS_PROFILE")
+	section := "default"
+	if profile != "" && profile != "default" {
+		section = "profile " + profile
+	}
+
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		configFile = filepath.Join(os.Getenv("HOME"), ".aws", "config")
+	}
+
+	if region := readINISection(configFile, section)["region"]; region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+// key/value pairs of one [section] in an AWS-style INI file
+func readINISection(path, section string) map[string]string {
+	values := make(map[string]string)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return values
+}
+
+func (t *S3Target) Name() string {
+	return path.Base(t.key)
+}
+
+func (t *S3Target) String() string {
+	return "s3://" + t.bucket + "/" + t.key
+}
+
+// request URL for a key (virtual-hosted on AWS, path-style on custom endpoints)
+func (t *S3Target) objectURL(key string, query url.Values) *url.URL {
+	u := &url.URL{Scheme: "https"}
+
+	if t.endpoint != "" {
+		base, err := url.Parse(t.endpoint)
+		if err == nil {
+			u.Scheme = base.Scheme
+			u.Host = base.Host
+		}
+		u.Path = "/" + t.bucket + "/" + key
+	} else {
+		u.Host = fmt.Sprintf("%s.s3.%s.amazonaws.com", t.bucket, t.region)
+		u.Path = "/" + key
+	}
+
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	return u
+}
+
+func (t *S3Target) Download(dst string, maxSize int64) error {
+	req, err := http.NewRequest(http.MethodGet, t.objectURL(t.key, nil).String(), nil)
+	if err != nil {
+		return err
+	}
+	t.sign(req, emptyPayloadHash)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("S3 download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError("S3 download", resp)
+	}
+	return saveBody(resp, dst, maxSize)
+}
+
+func (t *S3Target) Upload(src string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read sanitized file: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	req, err := http.NewRequest(http.MethodPut, t.objectURL(t.key, nil).String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	t.sign(req, hex.EncodeToString(sum[:]))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("S3 upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError("S3 upload", resp)
+	}
+	return nil
+}
+
+// ListObjectsV2 response fields we need
+type s3ListResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (t *S3Target) List() ([]Target, error) {
+	if t.key != "" && !strings.HasSuffix(t.key, "/") {
+		return []Target{t}, nil
+	}
+
+	var targets []Target
+	token := ""
+
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if t.key != "" {
+			query.Set("prefix", t.key)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, t.objectURL("", query).String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		t.sign(req, emptyPayloadHash)
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("S3 listing failed: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := responseError("S3 listing", resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse S3 listing: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			if strings.HasSuffix(object.Key, "/") {
+				continue // folder placeholder
+			}
+			child := *t
+			child.key = object.Key
+			targets = append(targets, &child)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	return targets, nil
+}
+
+// adds SigV4 authorization headers to a request
+func (t *S3Target) sign(req *http.Request, payloadHash string) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if t.creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", t.creds.sessionToken)
+	}
+
+	// canonical headers: lowercase names, sorted
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + t.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+t.creds.secretKey), day)
+	key = hmacSHA256(key, t.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		t.creds.accessKey, scope, signedHeaders, signature))
+}
+
+// query string with keys sorted and values encoded the way SigV4 expects
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, sigv4Escape(key)+"="+sigv4Escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// RFC 3986 escaping (spaces as %20, not +)
+func sigv4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	io.WriteString(mac, data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,112 @@
+// BYZRA ⸻ internal/remote/target.go
+// remote objects that can be downloaded, sanitized and re-uploaded
+
+package remote
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"caligra/internal/util"
+)
+
+// a remote object store location (a single object or a prefix)
+type Target interface {
+	// downloads the object into dst, refusing anything over maxSize
+	Download(dst string, maxSize int64) error
+
+	// replaces the object with the contents of src
+	Upload(src string) error
+
+	// objects under a prefix target; a single-object target returns itself
+	List() ([]Target, error)
+
+	// base filename of the object, used for type detection
+	Name() string
+
+	String() string
+}
+
+// is the argument an s3:// or webdav:// location?
+func IsTarget(location string) bool {
+	for _, scheme := range []string{"s3://", "webdav://", "webdavs://"} {
+		if strings.HasPrefix(location, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// parses a target location into the matching backend
+func ParseTarget(location string) (Target, error) {
+	switch {
+	case strings.HasPrefix(location, "s3://"):
+		return parseS3Target(location)
+	case strings.HasPrefix(location, "webdav://"), strings.HasPrefix(location, "webdavs://"):
+		return parseWebDAVTarget(location)
+	default:
+		return nil, fmt.Errorf("unsupported remote target: %s", location)
+	}
+}
+
+// downloads a target into a private temp file; cleanup removes it
+func FetchTarget(target Target, maxSize int64) (string, func(), error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	tmp, err := util.CreateTempFile("caligra-remote-*-" + util.SanitizeFilename(target.Name()))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	cleanup := func() { os.Remove(tmpPath) }
+
+	if err := util.EnsureSafePermissions(tmpPath); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to secure temp file: %w", err)
+	}
+
+	if err := target.Download(tmpPath, maxSize); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return tmpPath, cleanup, nil
+}
+
+// writes a response body to dst with the size limit applied
+func saveBody(resp *http.Response, dst string, maxSize int64) error {
+	if resp.ContentLength > maxSize {
+		return fmt.Errorf("remote file is %d bytes, over the %d byte limit", resp.ContentLength, maxSize)
+	}
+
+	file, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open temp file: %w", err)
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to save download: %w", err)
+	}
+	if written > maxSize {
+		return fmt.Errorf("remote file exceeds the %d byte limit", maxSize)
+	}
+	return nil
+}
+
+// turns a non-2xx response into an error with the server's message
+func responseError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		return fmt.Errorf("%s failed: %s", action, resp.Status)
+	}
+	return fmt.Errorf("%s failed: %s: %s", action, resp.Status, message)
+}
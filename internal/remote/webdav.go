@@ -0,0 +1,228 @@
+// BYZRA ⸻ internal/remote/webdav.go
+// WebDAV resources over HTTP(S)
+
+package remote
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// a resource (or collection, when the path ends in "/") on a WebDAV server
+type WebDAVTarget struct {
+	url      *url.URL
+	user     string
+	password string
+	client   *http.Client
+}
+
+// webdav:// maps to http, webdavs:// to https
+func parseWebDAVTarget(location string) (*WebDAVTarget, error) {
+	raw := location
+	if strings.HasPrefix(raw, "webdavs://") {
+		raw = "https://" + strings.TrimPrefix(raw, "webdavs://")
+	} else {
+		raw = "http://" + strings.TrimPrefix(raw, "webdav://")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebDAV location: %w", err)
+	}
+
+	target := &WebDAVTarget{
+		url:    u,
+		client: &http.Client{Timeout: fetchTimeout},
+	}
+
+	// credentials: URL userinfo, then environment, then ~/.netrc
+	switch {
+	case u.User != nil:
+		target.user = u.User.Username()
+		target.password, _ = u.User.Password()
+		u.User = nil
+	case os.Getenv("CALIGRA_WEBDAV_USER") != "":
+		target.user = os.Getenv("CALIGRA_WEBDAV_USER")
+		target.password = os.Getenv("CALIGRA_WEBDAV_PASSWORD")
+	default:
+		target.user, target.password = netrcLogin(u.Hostname())
+	}
+
+	return target, nil
+}
+
+// login and password for a host from ~/.netrc
+func netrcLogin(host string) (string, string) {
+	file, err := os.Open(filepath.Join(os.Getenv("HOME"), ".netrc"))
+	if err != nil {
+		return "", ""
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		tokens = append(tokens, strings.Fields(scanner.Text())...)
+	}
+
+	login, password := "", ""
+	matched := false
+	for i := 0; i < len(tokens)-1; i++ {
+		switch tokens[i] {
+		case "machine":
+			if matched {
+				return login, password
+			}
+			matched = tokens[i+1] == host
+			i++
+		case "default":
+			if !matched {
+				matched = true
+			}
+		case "login":
+			if matched {
+				login = tokens[i+1]
+			}
+			i++
+		case "password":
+			if matched {
+				password = tokens[i+1]
+			}
+			i++
+		}
+	}
+
+	if matched {
+		return login, password
+	}
+	return "", ""
+}
+
+func (t *WebDAVTarget) Name() string {
+	return path.Base(t.url.Path)
+}
+
+func (t *WebDAVTarget) String() string {
+	return t.url.String()
+}
+
+func (t *WebDAVTarget) request(method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if t.user != "" {
+		req.SetBasicAuth(t.user, t.password)
+	}
+	return req, nil
+}
+
+func (t *WebDAVTarget) Download(dst string, maxSize int64) error {
+	req, err := t.request(http.MethodGet, t.url.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("WebDAV download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError("WebDAV download", resp)
+	}
+	return saveBody(resp, dst, maxSize)
+}
+
+func (t *WebDAVTarget) Upload(src string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to read sanitized file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := t.request(http.MethodPut, t.url.String(), file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("WebDAV upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError("WebDAV upload", resp)
+	}
+	return nil
+}
+
+// PROPFIND multistatus fields we need
+type davMultistatus struct {
+	Responses []struct {
+		Href       string `xml:"href"`
+		Collection *struct {
+		} `xml:"propstat>prop>resourcetype>collection"`
+	} `xml:"response"`
+}
+
+func (t *WebDAVTarget) List() ([]Target, error) {
+	if !strings.HasSuffix(t.url.Path, "/") {
+		return []Target{t}, nil
+	}
+
+	req, err := t.request("PROPFIND", t.url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("WebDAV listing failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, responseError("WebDAV listing", resp)
+	}
+
+	var status davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to parse WebDAV listing: %w", err)
+	}
+
+	var targets []Target
+	for _, entry := range status.Responses {
+		if entry.Collection != nil {
+			continue // the collection itself and sub-collections
+		}
+
+		href, err := url.Parse(entry.Href)
+		if err != nil {
+			continue
+		}
+
+		child := *t
+		child.url = t.url.ResolveReference(href)
+		targets = append(targets, &child)
+	}
+
+	return targets, nil
+}
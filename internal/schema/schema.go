@@ -0,0 +1,273 @@
+// BYZRA ⸻ internal/schema/schema.go
+// versioned JSON documents for analyse and wipe, and the schema they follow
+
+package schema
+
+import (
+	_ "embed"
+	"errors"
+	"sort"
+
+	"caligra/internal/analyse"
+	"caligra/internal/util"
+	"caligra/internal/wipe"
+)
+
+// bumped when a field is renamed, removed or changes type; adding a field
+// does not change it
+const Version = 1
+
+// the JSON Schema (draft 2020-12) every document of this version follows
+//
+//go:embed v1.json
+var document []byte
+
+// the schema, as printed by 'caligra schema'
+func JSON() []byte {
+	return document
+}
+
+// document kinds
+const (
+	KindAnalysis = "analysis"
+	KindWipe     = "wipe"
+	KindError    = "error"
+)
+
+// ╭─ ANALYSIS ──────────────────────────────────╮
+
+type Analysis struct {
+	SchemaVersion int    `json:"schema_version"`
+	Kind          string `json:"kind"`
+	File          string `json:"file"`
+	Format        string `json:"format"`
+	MimeType      string `json:"mime_type"`
+	Extension     string `json:"extension"`
+
+	Metadata        map[string]string `json:"metadata"`
+	SensitiveFields []string          `json:"sensitive_fields"`
+	CriticalFields  []string          `json:"critical_fields"`
+	Leaks           []Leak            `json:"leaks"`
+	Embedded        []Embedded        `json:"embedded"`
+
+	MetadataBytes int64    `json:"metadata_bytes"`
+	Regions       []Region `json:"regions"`
+
+	ContentScanned  bool             `json:"content_scanned"`
+	ContentFindings []ContentFinding `json:"content_findings"`
+
+	HardLinks int      `json:"hard_links"`
+	Sidecars  []string `json:"sidecars"`
+}
+
+// an identifier found in a field's value
+type Leak struct {
+	Field    string `json:"field"`
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+}
+
+type Embedded struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Detail   string `json:"detail"`
+	Critical bool   `json:"critical"`
+}
+
+// where metadata sits in the file
+type Region struct {
+	Name   string `json:"name"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+}
+
+// personal data found in a text body
+type ContentFinding struct {
+	Line  int    `json:"line"`
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// the analysis document of a report; lists are never null
+func FromReport(report *analyse.AnalysisReport) *Analysis {
+	doc := &Analysis{
+		SchemaVersion:   Version,
+		Kind:            KindAnalysis,
+		File:            report.Path,
+		Format:          report.FileType.Format,
+		MimeType:        report.FileType.MimeType,
+		Extension:       report.FileType.Extension,
+		Metadata:        report.Fields(),
+		SensitiveFields: nonNil(report.SensitiveFields),
+		CriticalFields:  nonNil(report.CriticalFields()),
+		Leaks:           []Leak{},
+		Embedded:        []Embedded{},
+		MetadataBytes:   report.MetadataBytes(),
+		Regions:         []Region{},
+		ContentScanned:  report.ContentScanned,
+		ContentFindings: []ContentFinding{},
+		HardLinks:       report.HardLinks,
+		Sidecars:        nonNil(report.Sidecars),
+	}
+
+	for field, kind := range report.ValueLeaks {
+		doc.Leaks = append(doc.Leaks, Leak{Field: field, Kind: kind, Severity: util.LeakSeverity(kind)})
+	}
+	sort.Slice(doc.Leaks, func(i, j int) bool { return doc.Leaks[i].Field < doc.Leaks[j].Field })
+
+	for _, item := range report.Embedded {
+		doc.Embedded = append(doc.Embedded, Embedded{Kind: item.Kind, Name: item.Name, Detail: item.Detail, Critical: item.Critical})
+	}
+	for _, region := range report.Regions {
+		doc.Regions = append(doc.Regions, Region{Name: region.Name, Offset: region.Offset, Length: region.Length})
+	}
+	for _, finding := range report.ContentFindings {
+		doc.ContentFindings = append(doc.ContentFindings, ContentFinding{Line: finding.Line, Kind: finding.Kind, Value: finding.Value})
+	}
+	return doc
+}
+
+// ╭─ WIPE ──────────────────────────────────────╮
+
+type Wipe struct {
+	SchemaVersion int    `json:"schema_version"`
+	Kind          string `json:"kind"`
+	Success       bool   `json:"success"`
+	Input         string `json:"input"`
+	Output        string `json:"output"` // the input itself for in-place wipes
+	Backup        string `json:"backup"`
+	Attestation   string `json:"attestation"`
+
+	SensitiveFields []string  `json:"sensitive_fields"`
+	Errors          []Failure `json:"errors"`
+	Warnings        []string  `json:"warnings"`
+	PolicyNotes     []string  `json:"policy_notes"`
+	Sidecars        []string  `json:"sidecars"`
+	Rebuilt         []string  `json:"rebuilt"`
+	Timestamps      []string  `json:"timestamps"`
+	Redactions      int       `json:"redactions"`
+	Renamed         bool      `json:"renamed"`
+	OriginalDeleted bool      `json:"original_deleted"`
+
+	// false when the original sat where an overwrite proves nothing
+	OriginalOverwritten bool `json:"original_overwritten"`
+
+	SizeBefore          int64 `json:"size_before"`
+	SizeAfter           int64 `json:"size_after"`
+	MetadataBytesBefore int64 `json:"metadata_bytes_before"`
+	MetadataBytesAfter  int64 `json:"metadata_bytes_after"`
+	RegionsBefore       int   `json:"regions_before"`
+	RegionsAfter        int   `json:"regions_after"`
+
+	Verification *Verification `json:"verification"`
+	Injection    *Injection    `json:"injection"`
+}
+
+// one failed step
+type Failure struct {
+	Step    string `json:"step"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"`
+}
+
+type Verification struct {
+	Success         bool     `json:"success"`
+	FileIntact      bool     `json:"file_intact"`
+	MetadataRemoved bool     `json:"metadata_removed"`
+	ProfileInjected bool     `json:"profile_injected"`
+	RemainingFields []string `json:"remaining_fields"`
+	MissingFields   []string `json:"missing_fields"`
+	Errors          []string `json:"errors"`
+}
+
+type Injection struct {
+	Success      bool     `json:"success"`
+	FieldsAdded  []string `json:"fields_added"`
+	FieldsFailed []string `json:"fields_failed"`
+}
+
+// the wipe document of a result; verification and injection are null when
+// those steps did not run
+func FromWipe(result *wipe.WipeResult) *Wipe {
+	output := result.OutputPath
+	if output == "" {
+		output = result.OriginalPath
+	}
+	doc := &Wipe{
+		SchemaVersion:       Version,
+		Kind:                KindWipe,
+		Success:             result.Success,
+		Input:               result.OriginalPath,
+		Output:              output,
+		Backup:              result.BackupPath,
+		Attestation:         result.Attestation,
+		SensitiveFields:     nonNil(result.SensitiveData),
+		Errors:              []Failure{},
+		Warnings:            nonNil(result.Warnings),
+		PolicyNotes:         nonNil(result.PolicyNotes),
+		Sidecars:            nonNil(result.Sidecars),
+		Rebuilt:             nonNil(result.Rebuilt),
+		Timestamps:          nonNil(result.Timestamps),
+		Redactions:          result.Redactions,
+		Renamed:             result.Renamed,
+		OriginalDeleted:     result.OriginalDeleted,
+		OriginalOverwritten: result.OriginalOverwritten,
+		SizeBefore:          result.SizeBefore,
+		SizeAfter:           result.SizeAfter,
+		MetadataBytesBefore: result.BytesBefore,
+		MetadataBytesAfter:  result.BytesAfter,
+		RegionsBefore:       result.RegionsBefore,
+		RegionsAfter:        result.RegionsAfter,
+	}
+
+	for _, failure := range result.Failures {
+		doc.Errors = append(doc.Errors, Failure{Step: failure.Op, Message: failure.Err.Error(), Hint: failure.Hint()})
+	}
+
+	if v := result.Verification; v != nil {
+		doc.Verification = &Verification{
+			Success:         v.Success,
+			FileIntact:      v.FileIntact,
+			MetadataRemoved: v.MetadataRemoved,
+			ProfileInjected: v.ProfileInjected,
+			RemainingFields: nonNil(v.RemainingFields),
+			MissingFields:   nonNil(v.MissingFields),
+			Errors:          nonNil(v.ValidationErrors),
+		}
+	}
+	if in := result.Injection; in != nil {
+		doc.Injection = &Injection{
+			Success:      in.Success,
+			FieldsAdded:  nonNil(in.FieldsAdded),
+			FieldsFailed: nonNil(in.FieldsFailed),
+		}
+	}
+	return doc
+}
+
+// ╭─ ERRORS ────────────────────────────────────╮
+
+// a command that failed before producing its document
+type Error struct {
+	SchemaVersion int    `json:"schema_version"`
+	Kind          string `json:"kind"`
+	Message       string `json:"message"`
+	Hint          string `json:"hint"`
+	ToolMissing   string `json:"tool_missing"` // name of the missing backend, if that was the cause
+}
+
+func FromError(err error) *Error {
+	doc := &Error{SchemaVersion: Version, Kind: KindError, Message: err.Error(), Hint: wipe.Hint(err)}
+	var missing *util.ToolMissingError
+	if errors.As(err, &missing) {
+		doc.ToolMissing = missing.Tool
+	}
+	return doc
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
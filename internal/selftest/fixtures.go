@@ -0,0 +1,547 @@
+// BYZRA ⸻ internal/selftest/fixtures.go
+// small sample files of each supported format, carrying known metadata
+
+package selftest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// planted in every fixture; must be gone after the wipe
+const Marker = "Jane Selftest"
+
+// how to build one fixture and which backends its pipeline needs
+type fixture struct {
+	ext      string
+	needs    []string // backends for the whole analyse → wipe → verify run
+	generate func(path string) error
+}
+
+var imageTools = []string{"exiftool"}
+var tiffTools = []string{"exiftool", "identify"} // Go has no TIFF decoder
+var mediaTools = []string{"exiftool", "ffmpeg"}
+var waveTools = []string{"ffmpeg"} // WAV and AIFF tags are read and written in Go
+var matroskaTools = []string{"exiftool", "ffmpeg", "ffprobe"}
+var databaseTools = []string{"sqlite3"}
+
+var fixtures = []fixture{
+	{"jpg", imageTools, rasterFixture(encodeJPEG)},
+	{"jpeg", imageTools, rasterFixture(encodeJPEG)},
+	{"png", imageTools, rasterFixture(png.Encode)},
+	{"gif", imageTools, rasterFixture(encodeGIF)},
+	{"tiff", tiffTools, rasterFixture(encodeTIFF)},
+	{"webp", imageTools, webpFixture},
+	{"psd", imageTools, psdFixture},
+	{"svg", imageTools, svgFixture},
+
+	{"mp3", mediaTools, audioFixture("-c:a", "libmp3lame")},
+	{"flac", mediaTools, audioFixture("-c:a", "flac")},
+	{"opus", mediaTools, audioFixture("-c:a", "opus", "-strict", "-2", "-ar", "48000", "-ac", "2")},
+	{"ogg", mediaTools, audioFixture("-c:a", "vorbis", "-strict", "-2", "-ac", "2")},
+	{"wav", waveTools, audioFixture("-c:a", "pcm_s16le")},
+	{"aiff", waveTools, audioFixture("-c:a", "pcm_s16be", "-write_id3v2", "1")},
+
+	{"mp4", mediaTools, videoFixture("-c:v", "mpeg4")},
+	{"avi", mediaTools, videoFixture("-c:v", "mpeg4")},
+
+	{"mkv", matroskaTools, videoFixture("-c:v", "mpeg4")},
+	{"mka", matroskaTools, audioFixture("-c:a", "flac")},
+	{"webm", matroskaTools, audioFixture("-c:a", "vorbis", "-strict", "-2", "-ac", "2")},
+
+	{"txt", nil, textFixture("Author: " + Marker + "\nCreated: 2024-05-17\n\nSelf-test body.\n")},
+	{"md", nil, textFixture("---\nauthor: " + Marker + "\ndate: 2024-05-17\n---\n\n# Self-test\n")},
+	{"html", nil, textFixture(`<!DOCTYPE html>
+<html><head>
+<meta name="author" content="` + Marker + `">
+<title>Self-test</title>
+</head><body><p>Self-test body.</p></body></html>
+`)},
+	{"ass", nil, textFixture(`[Script Info]
+; Script generated by Aegisub 3.2.2
+Original Script: ` + Marker + `
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize
+Style: Default,Arial,48
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,Self-test line.
+`)},
+	{"vtt", nil, textFixture("WEBVTT\nAuthor: " + Marker + "\n\nNOTE edited by " + Marker + "\n\n00:00:01.000 --> 00:00:02.000\nSelf-test line.\n")},
+
+	{"pdf", nil, pdfFixture},
+	{"docx", nil, docxFixture},
+	{"odt", nil, odtFixture},
+	{"epub", nil, epubFixture},
+
+	{"zip", imageTools, zipArchiveFixture},
+	{"tar", nil, tarArchiveFixture},
+
+	{"elf", nil, elfFixture},
+
+	{"eml", nil, textFixture("Received: from selftest.example.org by mx.example.net; Fri, 17 May 2024 10:00:00 +0000\n" +
+		"Message-ID: <selftest@example.org>\nUser-Agent: " + Marker + " Mail 1.0\nFrom: sender@example.org\n" +
+		"Subject: Self-test\nDate: Fri, 17 May 2024 10:00:00 +0000\n\nSelf-test body.\n")},
+
+	{"gpx", nil, textFixture(`<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="` + Marker + `" xmlns="http://www.topografix.com/GPX/1/1">
+  <metadata><author><name>` + Marker + `</name></author></metadata>
+  <trk><name>Self-test</name><trkseg>
+    <trkpt lat="52.5200" lon="13.4050"><time>2024-05-17T10:00:00Z</time></trkpt>
+  </trkseg></trk>
+</gpx>
+`)},
+
+	{"sqlite", databaseTools, sqliteFixture},
+
+	{"ics", nil, textFixture("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//" + Marker + "//Calendar 1.0//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:selftest@example.org\r\nDTSTAMP:20240517T100000Z\r\nDTSTART:20240520T090000Z\r\n" +
+		"ORGANIZER;CN=" + Marker + ":mailto:organizer@example.org\r\nSUMMARY:Self-test\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")},
+}
+
+// ╭─ IMAGES ────────────────────────────────────╮
+
+// a tiny gradient in the given encoding, then metadata planted by exiftool
+func rasterFixture(encode func(w io.Writer, img image.Image) error) func(string) error {
+	return func(path string) error {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				img.Set(x, y, color.RGBA{uint8(x * 32), uint8(y * 32), 128, 255})
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := encode(&buf, img); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		return util.ExifToolWrite(path, []string{
+			"-XMP-dc:Creator=" + Marker,
+			"-XMP-dc:Rights=Copyright " + Marker,
+		})
+	}
+}
+
+func encodeJPEG(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, nil)
+}
+
+func encodeGIF(w io.Writer, img image.Image) error {
+	return gif.Encode(w, img, nil)
+}
+
+// uncompressed 8-bit grayscale baseline TIFF; the standard library has no
+// TIFF encoder
+func encodeTIFF(out io.Writer, img image.Image) error {
+	var w bytes.Buffer
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	type entry struct {
+		tag, kind uint16
+		value     uint32
+	}
+	const short, long = 3, 4
+	const entries = 9
+	pixelOffset := uint32(8 + 2 + entries*12 + 4)
+
+	ifd := []entry{
+		{256, short, uint32(width)},         // ImageWidth
+		{257, short, uint32(height)},        // ImageLength
+		{258, short, 8},                     // BitsPerSample
+		{259, short, 1},                     // Compression: none
+		{262, short, 1},                     // PhotometricInterpretation: black is zero
+		{273, long, pixelOffset},            // StripOffsets
+		{277, short, 1},                     // SamplesPerPixel
+		{278, short, uint32(height)},        // RowsPerStrip
+		{279, long, uint32(width * height)}, // StripByteCounts
+	}
+
+	le := binary.LittleEndian
+	w.WriteString("II")
+	binary.Write(&w, le, uint16(42))
+	binary.Write(&w, le, uint32(8))
+	binary.Write(&w, le, uint16(len(ifd)))
+	for _, e := range ifd {
+		binary.Write(&w, le, e.tag)
+		binary.Write(&w, le, e.kind)
+		binary.Write(&w, le, uint32(1))
+		if e.kind == short {
+			binary.Write(&w, le, uint16(e.value))
+			binary.Write(&w, le, uint16(0))
+		} else {
+			binary.Write(&w, le, e.value)
+		}
+	}
+	binary.Write(&w, le, uint32(0)) // no next IFD
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			w.WriteByte(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+	_, err := out.Write(w.Bytes())
+	return err
+}
+
+// a 1×1 lossless WebP (the standard library has no WebP encoder), with
+// the marker in EXIF and XMP chunks exiftool adds
+func webpFixture(path string) error {
+	webp := "RIFF\x1a\x00\x00\x00WEBP" +
+		"VP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00"
+	if err := os.WriteFile(path, []byte(webp), 0644); err != nil {
+		return err
+	}
+	return util.ExifToolWrite(path, []string{
+		"-EXIF:Artist=" + Marker,
+		"-XMP-dc:Creator=" + Marker,
+	})
+}
+
+// a 1×1 RGB Photoshop file with no layers, its merged image uncompressed;
+// exiftool adds the marker as IPTC and XMP image resources
+func psdFixture(path string) error {
+	var psd bytes.Buffer
+	psd.WriteString("8BPS\x00\x01\x00\x00\x00\x00\x00\x00")
+	// 3 channels, 1 pixel high and wide, 8 bits, RGB
+	for _, field := range []any{uint16(3), uint32(1), uint32(1), uint16(8), uint16(3)} {
+		binary.Write(&psd, binary.BigEndian, field)
+	}
+	psd.Write(make([]byte, 4+4+4)) // no colour mode data, resources or layers
+	psd.Write([]byte{0, 0, 0xC0, 0x40, 0x20})
+	if err := os.WriteFile(path, psd.Bytes(), 0644); err != nil {
+		return err
+	}
+	return util.ExifToolWrite(path, []string{
+		"-IPTC:By-line=" + Marker,
+		"-XMP-dc:Creator=" + Marker,
+	})
+}
+
+// SVG metadata lives in the XML itself (exiftool cannot write SVG)
+func svgFixture(path string) error {
+	svg := `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:dc="http://purl.org/dc/elements/1.1/" width="8" height="8">
+  <metadata>
+    <rdf:RDF><rdf:Description><dc:creator>` + Marker + `</dc:creator></rdf:Description></rdf:RDF>
+  </metadata>
+  <rect width="8" height="8" fill="#88AABB"/>
+</svg>
+`
+	return os.WriteFile(path, []byte(svg), 0644)
+}
+
+// ╭─ AUDIO & VIDEO ─────────────────────────────╮
+
+// one second of a sine tone; codec picks an encoder ffmpeg builds in where
+// there is one
+func audioFixture(codec ...string) func(string) error {
+	return func(path string) error {
+		args := []string{"-f", "lavfi", "-i", "sine=frequency=440:duration=1"}
+		return ffmpegFixture(path, append(args, codec...))
+	}
+}
+
+// one second of a 64x64 test pattern
+func videoFixture(codec ...string) func(string) error {
+	return func(path string) error {
+		args := []string{"-f", "lavfi", "-i", "testsrc=size=64x64:rate=5:duration=1", "-pix_fmt", "yuv420p"}
+		return ffmpegFixture(path, append(args, codec...))
+	}
+}
+
+func ffmpegFixture(path string, args []string) error {
+	full := append([]string{"-v", "error", "-y"}, args...)
+	full = append(full,
+		"-metadata", "artist="+Marker,
+		"-metadata", "comment=recorded by "+Marker,
+		path)
+
+	cmd := exec.Command("ffmpeg", full...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %s", firstLine(msg))
+		}
+		return util.ToolError("ffmpeg", err)
+	}
+	return nil
+}
+
+// ╭─ TEXT ──────────────────────────────────────╮
+
+func textFixture(content string) func(string) error {
+	return func(path string) error {
+		return os.WriteFile(path, []byte(content), 0644)
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// ╭─ DOCUMENTS ─────────────────────────────────╮
+
+// a one-page PDF with the marker as its author, in the Info dictionary
+// and in an XMP packet
+func pdfFixture(path string) error {
+	xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<dc:creator><rdf:Seq><rdf:li>` + Marker + `</rdf:li></rdf:Seq></dc:creator>` +
+		`</rdf:Description></rdf:RDF></x:xmpmeta>`
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 5 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 72 72] >>",
+		"<< /Author (" + Marker + ") /CreationDate (D:20240517120000Z) >>",
+		fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// a Word document with the marker as creator and last modifier in
+// docProps/core.xml
+func docxFixture(path string) error {
+	parts := [][2]string{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+			`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/></Relationships>`},
+		{"word/document.xml", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:body><w:p><w:r><w:t>Self-test body.</w:t></w:r></w:p></w:body></w:document>`},
+		{"docProps/core.xml", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+			`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+			`<dc:creator>` + Marker + `</dc:creator><cp:lastModifiedBy>` + Marker + `</cp:lastModifiedBy>` +
+			`<dcterms:created xsi:type="dcterms:W3CDTF">2024-05-17T12:00:00Z</dcterms:created></cp:coreProperties>`},
+	}
+
+	return zipFixture(path, parts)
+}
+
+// a text document with the marker as initial creator and creator in
+// meta.xml
+func odtFixture(path string) error {
+	return zipFixture(path, [][2]string{
+		{"mimetype", "application/vnd.oasis.opendocument.text"},
+		{"content.xml", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+			`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" office:version="1.2">` +
+			`<office:body><office:text><text:p>Self-test body.</text:p></office:text></office:body></office:document-content>`},
+		{"meta.xml", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+			`xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/" office:version="1.2">` +
+			`<office:meta><meta:initial-creator>` + Marker + `</meta:initial-creator><dc:creator>` + Marker + `</dc:creator>` +
+			`<meta:creation-date>2024-05-17T12:00:00</meta:creation-date></office:meta></office:document-meta>`},
+		{"META-INF/manifest.xml", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">` +
+			`<manifest:file-entry manifest:full-path="/" manifest:media-type="application/vnd.oasis.opendocument.text"/>` +
+			`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+			`<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/></manifest:manifest>`},
+	})
+}
+
+// an EPUB 3 book with the marker as creator in its package document
+func epubFixture(path string) error {
+	return zipFixture(path, [][2]string{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container"><rootfiles>` +
+			`<rootfile full-path="content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"content.opf", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">` +
+			`<metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:identifier id="id">urn:uuid:0f4c3a52-5b3e-4d38-9b7e-2f6a4c1d8e90</dc:identifier>` +
+			`<dc:title>Self-test</dc:title><dc:language>en</dc:language><dc:creator>` + Marker + `</dc:creator>` +
+			`<dc:date>2024-05-17</dc:date><meta property="dcterms:modified">2024-05-17T12:00:00Z</meta></metadata>` +
+			`<manifest><item id="body" href="body.xhtml" media-type="application/xhtml+xml" properties="nav"/></manifest>` +
+			`<spine><itemref idref="body"/></spine></package>`},
+		{"body.xhtml", `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><head><title>Self-test</title></head>` +
+			`<body><nav epub:type="toc"><ol><li><a href="body.xhtml">Self-test</a></li></ol></nav><p>Self-test body.</p></body></html>`},
+	})
+}
+
+// writes parts to a ZIP archive in order; a "mimetype" part is stored
+// uncompressed, as OpenDocument requires
+func zipFixture(path string, parts [][2]string) error {
+	var out bytes.Buffer
+	w := zip.NewWriter(&out)
+	for _, part := range parts {
+		header := &zip.FileHeader{Name: part[0], Method: zip.Deflate}
+		if part[0] == "mimetype" {
+			header.Method = zip.Store
+		}
+		f, err := w.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part[1]); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// ╭─ ARCHIVES ──────────────────────────────────╮
+
+// a ZIP holding a tagged PNG, stored so the marker stays readable in the
+// archive's bytes, with the marker as member and archive comment
+func zipArchiveFixture(path string) error {
+	member := path + ".png"
+	if err := rasterFixture(png.Encode)(member); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(member)
+	os.Remove(member)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	w := zip.NewWriter(&out)
+	f, err := w.CreateHeader(&zip.FileHeader{
+		Name:     "gradient.png",
+		Method:   zip.Store,
+		Modified: time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC),
+		Comment:  "scanned by " + Marker,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := w.SetComment("packed by " + Marker); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// an uncompressed TAR whose entry is owned by the marker, with the marker as
+// the global comment
+func tarArchiveFixture(path string) error {
+	body := "Self-test body.\n"
+	var out bytes.Buffer
+	w := tar.NewWriter(&out)
+	headers := []*tar.Header{
+		{Typeflag: tar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": Marker}, Format: tar.FormatPAX},
+		{Typeflag: tar.TypeReg, Name: "notes.txt", Mode: 0644, Size: int64(len(body)),
+			Uid: 1000, Gid: 1000, Uname: Marker, Gname: Marker, ModTime: time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, header := range headers {
+		if err := w.WriteHeader(header); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// ╭─ EXECUTABLES ───────────────────────────────╮
+
+// an x86-64 ELF file holding only a .comment section that names the marker
+// as the compiler
+func elfFixture(path string) error {
+	comment := "GCC: (" + Marker + ") 12.2.0\x00"
+	names := "\x00.comment\x00.shstrtab\x00"
+	headerSize, sectionSize := binary.Size(elf.Header64{}), binary.Size(elf.Section64{})
+	namesAt := headerSize + len(comment)
+	tableAt := (namesAt + len(names) + 7) &^ 7
+
+	header := elf.Header64{
+		Type: uint16(elf.ET_EXEC), Machine: uint16(elf.EM_X86_64), Version: uint32(elf.EV_CURRENT),
+		Shoff: uint64(tableAt), Ehsize: uint16(headerSize), Shentsize: uint16(sectionSize), Shnum: 3, Shstrndx: 2,
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	sections := []elf.Section64{
+		{},
+		{Name: 1, Type: uint32(elf.SHT_PROGBITS), Flags: uint64(elf.SHF_MERGE | elf.SHF_STRINGS),
+			Off: uint64(headerSize), Size: uint64(len(comment)), Addralign: 1, Entsize: 1},
+		{Name: 10, Type: uint32(elf.SHT_STRTAB), Off: uint64(namesAt), Size: uint64(len(names)), Addralign: 1},
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, header)
+	out.WriteString(comment)
+	out.WriteString(names)
+	out.Write(make([]byte, tableAt-out.Len()))
+	binary.Write(&out, binary.LittleEndian, sections)
+	return os.WriteFile(path, out.Bytes(), 0755)
+}
+
+// ╭─ DATABASES ─────────────────────────────────╮
+
+// a database whose rows naming the marker were deleted, with secure_delete
+// off so they stay behind in free space
+func sqliteFixture(path string) error {
+	cmd := exec.Command("sqlite3", path, "PRAGMA secure_delete = OFF; CREATE TABLE notes(author TEXT, body TEXT);"+
+		"INSERT INTO notes VALUES ('"+Marker+"', 'Self-test body.'); DELETE FROM notes;")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("sqlite3: %s", firstLine(msg))
+		}
+		return util.ToolError("sqlite3", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// BYZRA ⸻ internal/selftest/selftest.go
+// runs analyse → wipe → verify on generated fixtures to show which format
+// pipelines work with the backends installed here
+
+package selftest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"caligra/internal/analyse"
+	"caligra/internal/formats"
+	"caligra/internal/wipe"
+)
+
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// outcome of one format's pipeline
+type Result struct {
+	Format  string
+	Needs   []string // backends the pipeline uses
+	Missing []string // of those, not found in PATH
+	Status  Status
+	Stage   string // "fixture", "analyse", "wipe" or "verify" when failed
+	Detail  string
+	Hint    string
+}
+
+// runs the pipeline for each extension in exts (all supported ones when
+// empty) inside dir, reporting each result as it finishes
+func Run(dir string, exts []string, progress func(Result)) ([]Result, error) {
+	selected, err := selectFixtures(exts)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, f := range selected {
+		result := runFixture(dir, f)
+		results = append(results, result)
+		if progress != nil {
+			progress(result)
+		}
+	}
+	return results, nil
+}
+
+// counts per status
+func Summarize(results []Result) (ok, failed, skipped int) {
+	for _, r := range results {
+		switch r.Status {
+		case StatusOK:
+			ok++
+		case StatusFailed:
+			failed++
+		case StatusSkipped:
+			skipped++
+		}
+	}
+	return ok, failed, skipped
+}
+
+func selectFixtures(exts []string) ([]fixture, error) {
+	if len(exts) == 0 {
+		return fixtures, nil
+	}
+
+	var selected []fixture
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+		i := slices.IndexFunc(fixtures, func(f fixture) bool { return f.ext == ext })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: .%s (supported: %s)", formats.ErrUnsupportedFormat, ext, formats.SupportedList())
+		}
+		selected = append(selected, fixtures[i])
+	}
+	return selected, nil
+}
+
+func runFixture(dir string, f fixture) Result {
+	result := Result{Format: f.ext, Needs: f.needs}
+	for _, tool := range f.needs {
+		if _, err := exec.LookPath(tool); err != nil {
+			result.Missing = append(result.Missing, tool)
+		}
+	}
+	if len(result.Missing) > 0 {
+		result.Status = StatusSkipped
+		result.Detail = "needs " + strings.Join(result.Missing, ", ")
+		return result
+	}
+
+	fail := func(stage string, err error) Result {
+		result.Status = StatusFailed
+		result.Stage = stage
+		result.Detail = err.Error()
+		result.Hint = wipe.Hint(err)
+		return result
+	}
+
+	path := filepath.Join(dir, "selftest."+f.ext)
+	if err := f.generate(path); err != nil {
+		return fail("fixture", err)
+	}
+
+	report, err := analyse.Analyze(path)
+	if err != nil {
+		return fail("analyse", err)
+	}
+	if len(report.SensitiveFields) == 0 {
+		return fail("analyse", fmt.Errorf("planted metadata was not detected"))
+	}
+
+	options := wipe.DefaultWipeOptions()
+	options.InjectProfile = false
+	wiped, err := wipe.WipeFile(path, options)
+	if err != nil {
+		return fail("wipe", err)
+	}
+	if len(wiped.Failures) > 0 {
+		return fail("wipe", wiped.Failures[0])
+	}
+
+	if err := wiped.Err(); err != nil {
+		return fail("verify", err)
+	}
+	data, err := os.ReadFile(wiped.OutputPath)
+	if err != nil {
+		return fail("verify", err)
+	}
+	if bytes.Contains(data, []byte(Marker)) {
+		return fail("verify", fmt.Errorf("%w: planted value %q is still in the output", wipe.ErrVerificationFailed, Marker))
+	}
+
+	result.Status = StatusOK
+	result.Detail = fmt.Sprintf("%d sensitive fields removed", len(report.SensitiveFields))
+	return result
+}
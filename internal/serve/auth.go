@@ -0,0 +1,122 @@
+// BYZRA ⸻ internal/serve/auth.go
+// bearer tokens and client certificates
+
+package serve
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// shortest token accepted; anything shorter is guessable
+const minTokenLength = 16
+
+// known tokens by their SHA-256, so they are compared in constant time and
+// never logged
+type tokenSet [][sha256.Size]byte
+
+// reads one token per line; blank lines and # comments are skipped
+func loadTokens(path string) (tokenSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	defer file.Close()
+
+	// Windows reports no meaningful permission bits
+	if info, err := file.Stat(); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("token file %s is readable by others (chmod 600 it)", path)
+	}
+
+	var tokens tokenSet
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		token := strings.TrimSpace(scanner.Text())
+		if token == "" || strings.HasPrefix(token, "#") {
+			continue
+		}
+		if len(token) < minTokenLength {
+			return nil, fmt.Errorf("%s:%d: tokens must be at least %d characters", path, line, minTokenLength)
+		}
+		tokens = append(tokens, sha256.Sum256([]byte(token)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("token file %s holds no tokens", path)
+	}
+	return tokens, nil
+}
+
+// the client name of a valid token ("token:1a2b3c4d"), "" otherwise
+func (t tokenSet) match(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	found := -1
+	for i, known := range t {
+		if subtle.ConstantTimeCompare(sum[:], known[:]) == 1 {
+			found = i
+		}
+	}
+	if found < 0 {
+		return ""
+	}
+	return "token:" + hex.EncodeToString(t[found][:4])
+}
+
+// who is asking: the token, else the client certificate, else the address;
+// ok is false when a token is required and missing or wrong
+func (s *Server) identify(r *http.Request) (client string, ok bool) {
+	if len(s.tokens) > 0 {
+		scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return "", false
+		}
+		client = s.tokens.match(strings.TrimSpace(token))
+		return client, client != ""
+	}
+
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return "cert:" + r.TLS.PeerCertificates[0].Subject.CommonName, true
+	}
+	return remoteKey(r), true
+}
+
+// the limiter key of the connecting address, "ip:<host>"
+func remoteKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// TLS settings; with a client CA, only certificates it signed get in
+func tlsConfig(clientCA string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCA == "" {
+		return config, nil
+	}
+
+	pem, err := os.ReadFile(clientCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", clientCA)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
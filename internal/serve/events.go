@@ -0,0 +1,81 @@
+// BYZRA ⸻ internal/serve/events.go
+// the daemon's activity as server-sent events
+
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"caligra/internal/daemon"
+)
+
+// comment lines sent while idle, so proxies keep the connection open
+const keepAlive = 30 * time.Second
+
+// relays the running daemon's events, one "event: <type>" message each,
+// until the client or the daemon goes away; 503 when no daemon runs
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	sub, err := daemon.Subscribe(r.Context())
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	defer sub.Close()
+
+	events := make(chan daemon.Event)
+	ended := make(chan error, 1)
+	go func() {
+		defer close(events)
+		for {
+			event, err := sub.Next()
+			if err != nil {
+				ended <- err
+				return
+			}
+			select {
+			case events <- event:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+
+	controller := http.NewResponseController(w)
+	// the stream outlives the server's read timeout
+	_ = controller.SetReadDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	controller.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				if r.Context().Err() != nil {
+					return
+				}
+				// the daemon stopped or dropped us; say so before hanging up
+				data, _ := json.Marshal(map[string]string{"message": (<-ended).Error()})
+				fmt.Fprintf(w, "event: end\ndata: %s\n\n", data)
+				controller.Flush()
+				return
+			}
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,619 @@
+// BYZRA ⸻ internal/serve/jobs.go
+// asynchronous bulk wipes: many files in, one bundle out
+
+package serve
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"caligra/internal/batch"
+	"caligra/internal/config"
+	"caligra/internal/util"
+)
+
+// limits on bulk jobs
+const (
+	maxJobFiles      = 1000        // files per job, archives included
+	maxClientJobs    = 4           // unfinished jobs per client
+	maxRunningJobs   = 2           // jobs processed at once, server-wide
+	archiveExpansion = 10          // extracted bytes per uploaded byte
+	jobTTL           = time.Hour   // how long a finished bundle is kept
+	jobSweep         = time.Minute // how often expired jobs are removed
+)
+
+// job states
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+// name of the manifest inside a result bundle
+const bundleManifest = "manifest.json"
+
+type job struct {
+	id     string
+	client string
+	dir    string   // private directory holding the inputs, then the bundle
+	files  []string // inputs, relative to dir/in
+
+	mu        sync.Mutex
+	status    string
+	processed int
+	summary   map[string]int
+	err       string
+	created   time.Time
+	finished  time.Time
+}
+
+// what GET /v1/jobs/{id} returns
+type jobStatus struct {
+	ID        string         `json:"id"`
+	Status    string         `json:"status"`
+	Files     int            `json:"files"`
+	Processed int            `json:"processed"`
+	Summary   map[string]int `json:"summary"` // entries per manifest status
+	Error     string         `json:"error,omitempty"`
+	Created   time.Time      `json:"created"`
+	Finished  *time.Time     `json:"finished,omitempty"`
+	StatusURL string         `json:"status_url"`
+	ResultURL string         `json:"result_url"`
+}
+
+func (j *job) snapshot() jobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := jobStatus{
+		ID:        j.id,
+		Status:    j.status,
+		Files:     len(j.files),
+		Processed: j.processed,
+		Summary:   map[string]int{},
+		Error:     j.err,
+		Created:   j.created,
+		StatusURL: "/v1/jobs/" + j.id,
+		ResultURL: "/v1/jobs/" + j.id + "/result",
+	}
+	for key, count := range j.summary {
+		status.Summary[key] = count
+	}
+	if !j.finished.IsZero() {
+		finished := j.finished
+		status.Finished = &finished
+	}
+	return status
+}
+
+func (j *job) finish(status string, summary map[string]int, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status, j.summary, j.finished = status, summary, time.Now().UTC()
+	if err != nil {
+		j.err = err.Error()
+	}
+}
+
+func (j *job) bundle() string {
+	return filepath.Join(j.dir, "result.zip")
+}
+
+// all jobs of the server, with a limited number running at once
+type jobQueue struct {
+	mu        sync.Mutex
+	jobs      map[string]*job
+	receiving map[string]int // uploads under way, by client
+	slots     chan struct{}
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func newJobQueue() *jobQueue {
+	return &jobQueue{
+		jobs:      make(map[string]*job),
+		receiving: make(map[string]int),
+		slots:     make(chan struct{}, maxRunningJobs),
+		stop:      make(chan struct{}),
+	}
+}
+
+// the job with id, if client owns it; other clients' jobs do not exist
+func (q *jobQueue) get(id, client string) *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if j, ok := q.jobs[id]; ok && j.client == client {
+		return j
+	}
+	return nil
+}
+
+// holds a job slot for client's upload, before any of it is read, unless
+// the client already has too many unfinished jobs; add or release frees it
+func (q *jobQueue) reserve(client string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := q.receiving[client]
+	for _, other := range q.jobs {
+		other.mu.Lock()
+		if other.client == client && other.finished.IsZero() {
+			pending++
+		}
+		other.mu.Unlock()
+	}
+	if pending >= maxClientJobs {
+		return fmt.Errorf("%d jobs are still running; wait for one to finish", pending)
+	}
+	q.receiving[client]++
+	return nil
+}
+
+// gives back a slot whose upload failed
+func (q *jobQueue) release(client string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.unreserve(client)
+}
+
+// registers j in the slot reserved for its upload
+func (q *jobQueue) add(j *job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.unreserve(j.client)
+	q.jobs[j.id] = j
+}
+
+// caller holds the lock
+func (q *jobQueue) unreserve(client string) {
+	if q.receiving[client] <= 1 {
+		delete(q.receiving, client)
+	} else {
+		q.receiving[client]--
+	}
+}
+
+// forgets a job and deletes its files
+func (q *jobQueue) remove(j *job) {
+	q.mu.Lock()
+	delete(q.jobs, j.id)
+	q.mu.Unlock()
+	os.RemoveAll(j.dir)
+}
+
+// removes finished jobs older than jobTTL until ctx is cancelled
+func (q *jobQueue) expire(ctx context.Context) {
+	ticker := time.NewTicker(jobSweep)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			var expired []*job
+			q.mu.Lock()
+			for _, j := range q.jobs {
+				j.mu.Lock()
+				if !j.finished.IsZero() && now.Sub(j.finished) > jobTTL {
+					expired = append(expired, j)
+				}
+				j.mu.Unlock()
+			}
+			q.mu.Unlock()
+			for _, j := range expired {
+				q.remove(j)
+			}
+		}
+	}
+}
+
+// lets running jobs finish, drops queued ones and deletes every job's files
+func (q *jobQueue) close() {
+	close(q.stop)
+	q.wg.Wait()
+
+	q.mu.Lock()
+	jobs := make([]*job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		jobs = append(jobs, j)
+	}
+	q.mu.Unlock()
+	for _, j := range jobs {
+		q.remove(j)
+	}
+}
+
+// ╭─ HANDLERS ──────────────────────────────────╮
+
+// any number of "file" parts, zip archives unpacked; answers 202 with the
+// job status while the files are wiped in the background
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	client, _ := s.identify(r)
+
+	// checked before the upload is read, so refused jobs cost no unpacking
+	if err := s.jobs.reserve(client); err != nil {
+		writeError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	id, err := jobID()
+	if err != nil {
+		s.jobs.release(client)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "caligra-job-*")
+	if err != nil {
+		s.jobs.release(client)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	j := &job{id: id, client: client, dir: dir, status: jobQueued, created: time.Now().UTC()}
+
+	j.files, err = receiveFiles(r, filepath.Join(dir, "in"), s.config.MaxUpload*archiveExpansion)
+	if err != nil {
+		s.jobs.release(client)
+		os.RemoveAll(dir)
+		writeError(w, uploadStatus(err), err)
+		return
+	}
+	s.jobs.add(j)
+
+	s.jobs.wg.Add(1)
+	go s.runJob(j)
+
+	w.Header().Set("Location", "/v1/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, j.snapshot())
+}
+
+func (s *Server) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	if j := s.ownJob(w, r); j != nil {
+		writeJSON(w, http.StatusOK, j.snapshot())
+	}
+}
+
+// the bundle: sanitized files under their uploaded names, and manifest.json
+func (s *Server) handleJobResult(w http.ResponseWriter, r *http.Request) {
+	j := s.ownJob(w, r)
+	if j == nil {
+		return
+	}
+	if status := j.snapshot(); status.Status != jobDone {
+		writeError(w, http.StatusConflict, fmt.Errorf("job is %s", status.Status))
+		return
+	}
+
+	bundle, err := os.Open(j.bundle())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer bundle.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "caligra-"+j.id+".zip"))
+	io.Copy(w, bundle)
+}
+
+// deletes a job's files before it expires; a running job finishes first
+func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
+	j := s.ownJob(w, r)
+	if j == nil {
+		return
+	}
+	if status := j.snapshot(); status.Finished == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("job is %s", status.Status))
+		return
+	}
+	s.jobs.remove(j)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) ownJob(w http.ResponseWriter, r *http.Request) *job {
+	client, _ := s.identify(r)
+	j := s.jobs.get(r.PathValue("id"), client)
+	if j == nil {
+		writeError(w, http.StatusNotFound, errors.New("no such job"))
+	}
+	return j
+}
+
+// ╭─ PROCESSING ────────────────────────────────╮
+
+// waits for a slot, wipes every file and packs the bundle; the inputs and
+// outputs are deleted once the bundle is written
+func (s *Server) runJob(j *job) {
+	defer s.jobs.wg.Done()
+
+	select {
+	case s.jobs.slots <- struct{}{}:
+		defer func() { <-s.jobs.slots }()
+	case <-s.jobs.stop:
+		j.finish(jobFailed, nil, errors.New("server shut down before the job started"))
+		return
+	}
+
+	j.mu.Lock()
+	j.status = jobRunning
+	j.mu.Unlock()
+
+	in := filepath.Join(j.dir, "in")
+	paths := make([]string, len(j.files))
+	for i, name := range j.files {
+		paths[i] = filepath.Join(in, filepath.FromSlash(name))
+	}
+
+	manifest, err := batch.Wipe(paths, false, "", s.wipeOptions(), "", func(batch.ManifestEntry) {
+		j.mu.Lock()
+		j.processed++
+		j.mu.Unlock()
+	})
+	if err == nil {
+		err = s.writeBundle(j, in, manifest)
+	}
+	os.RemoveAll(in)
+
+	if err != nil {
+		os.Remove(j.bundle())
+		j.finish(jobFailed, nil, err)
+		s.logf("job %s failed: %v", j.id, err)
+		return
+	}
+	j.finish(jobDone, manifest.Summary(), nil)
+	s.logf("job %s done: %d files", j.id, len(manifest.Entries))
+}
+
+// zips the outputs under their uploaded names with a manifest whose paths
+// are names inside the bundle; server paths never leave the server
+func (s *Server) writeBundle(j *job, in string, manifest *batch.Manifest) error {
+	file, err := os.OpenFile(j.bundle(), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	renamed := s.config.Policy != nil && s.config.Policy.Rename == config.RenameRandom
+	bundleName := func(input, output string) string {
+		name, _ := filepath.Rel(in, input)
+		if renamed {
+			name = filepath.Join(filepath.Dir(name), filepath.Base(output))
+		}
+		return filepath.ToSlash(name)
+	}
+
+	archive := zip.NewWriter(file)
+	manifest.Source, manifest.Inputs = "upload", nil
+	for i, entry := range manifest.Entries {
+		input := entry.Input
+		entry.Input = bundleName(input, input)
+		entry.Backup = ""
+
+		if entry.Output != "" {
+			name := bundleName(input, entry.Output)
+			if err := addToZip(archive, name, entry.Output); err != nil {
+				return err
+			}
+			entry.Output = name
+		}
+		if entry.Attestation != "" {
+			name := bundleName(input, entry.Attestation)
+			if renamed {
+				name = path.Join(path.Dir(name), filepath.Base(entry.Attestation))
+			} else {
+				name += ".attestation.json"
+			}
+			if err := addToZip(archive, name, entry.Attestation); err != nil {
+				return err
+			}
+			entry.Attestation = name
+		}
+		manifest.Entries[i] = entry
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: bundleManifest, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func addToZip(archive *zip.Writer, name, source string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, file)
+	return err
+}
+
+// ╭─ UPLOADS ───────────────────────────────────╮
+
+var errArchiveTooLarge = errors.New("archives unpack to more than the upload limit allows")
+
+// saves every "file" part under dir, unpacking .zip parts into a directory
+// of the archive's name; returns the saved files relative to dir, with
+// slashes. Unpacked bytes are capped at limit.
+func receiveFiles(r *http.Request, dir string, limit int64) ([]string, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errNoFile
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	taken := make(map[string]bool)
+	add := func(name string) (string, error) {
+		if len(files) >= maxJobFiles {
+			return "", fmt.Errorf("a job takes at most %d files", maxJobFiles)
+		}
+		name = uniqueName(taken, name)
+		files = append(files, name)
+		return filepath.Join(dir, filepath.FromSlash(name)), nil
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		name := util.SanitizeFilename(part.FileName())
+		if name == "" || name == "." {
+			name = "upload"
+		}
+		if strings.EqualFold(filepath.Ext(name), ".zip") {
+			if err := unpackPart(part, dir, strings.TrimSuffix(name, filepath.Ext(name)), &limit, add); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		target, err := add(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveFile(target, part); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, errNoFile
+	}
+	return files, nil
+}
+
+// unpacks the regular files of a zip part under prefix; entries that would
+// leave it are refused
+func unpackPart(part *multipart.Part, dir, prefix string, limit *int64, add func(string) (string, error)) error {
+	spool, err := os.CreateTemp(dir, ".archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	size, err := io.Copy(spool, part)
+	if err != nil {
+		return err
+	}
+	archive, err := zip.NewReader(spool, size)
+	if err != nil {
+		return fmt.Errorf("%s.zip: %w", prefix, err)
+	}
+
+	for _, entry := range archive.File {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+		name, err := archiveName(entry.Name)
+		if err != nil {
+			return fmt.Errorf("%s.zip: %w", prefix, err)
+		}
+		if name == "" {
+			continue
+		}
+
+		target, err := add(prefix + "/" + name)
+		if err != nil {
+			return err
+		}
+		source, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("%s.zip: %w", prefix, err)
+		}
+		// counted as read, not as declared: headers can lie
+		budget := &io.LimitedReader{R: source, N: *limit + 1}
+		err = saveFile(target, budget)
+		source.Close()
+		if err != nil {
+			return err
+		}
+		if *limit -= *limit + 1 - budget.N; *limit < 0 {
+			return errArchiveTooLarge
+		}
+	}
+	return nil
+}
+
+// the sanitized slash-separated path of a zip entry; "" for entries to skip
+// (hidden files, macOS resource forks)
+func archiveName(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("absolute path in archive: %s", name)
+	}
+
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		switch {
+		case part == "" || part == ".":
+			continue
+		case part == "..":
+			return "", fmt.Errorf("path leaves the archive: %s", name)
+		case strings.HasPrefix(part, ".") || part == "__MACOSX":
+			return "", nil
+		}
+		parts = append(parts, util.SanitizeFilename(part))
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// name, or "name (2).ext" and so on when it is taken
+func uniqueName(taken map[string]bool, name string) string {
+	candidate := name
+	ext := path.Ext(name)
+	for n := 2; taken[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+	}
+	taken[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func jobID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,70 @@
+// BYZRA ⸻ internal/serve/ratelimit.go
+// per-client token buckets
+
+package serve
+
+import (
+	"sync"
+	"time"
+)
+
+// buckets untouched this long are dropped, so client churn cannot grow the map
+const bucketIdle = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// allows perMinute requests per client on average, with bursts of burst
+type limiter struct {
+	mu        sync.Mutex
+	perSecond float64
+	burst     float64
+	buckets   map[string]*bucket
+	swept     time.Time
+	now       func() time.Time
+}
+
+func newLimiter(perMinute, burst int) *limiter {
+	if burst < 1 {
+		burst = max(1, perMinute/6)
+	}
+	return &limiter{
+		perSecond: float64(perMinute) / 60,
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		now:       time.Now,
+	}
+}
+
+// takes a token for client; when none is left, how long until one is
+func (l *limiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) > bucketIdle {
+		for key, b := range l.buckets {
+			if now.Sub(b.last) > bucketIdle {
+				delete(l.buckets, key)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.perSecond)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
@@ -0,0 +1,387 @@
+// BYZRA ⸻ internal/serve/serve.go
+// HTTP API: analyse and wipe uploaded files
+
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"caligra/internal/analyse"
+	"caligra/internal/config"
+	"caligra/internal/schema"
+	"caligra/internal/util"
+	"caligra/internal/wipe"
+)
+
+// defaults for the serve command
+const (
+	DefaultAddr      = "127.0.0.1:8470"
+	DefaultRate      = 60 // requests per client per minute
+	DefaultMaxUpload = 100 << 20
+)
+
+// how long a client may take to send its request headers, its whole
+// request (an upload included), and how long an idle keep-alive
+// connection stays open
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 10 * time.Minute
+	idleTimeout       = 2 * time.Minute
+)
+
+type Config struct {
+	Addr string
+
+	// bearer tokens, one per line; required unless client certificates are
+	TokenFile string
+
+	Rate      int   // requests per client per minute
+	Burst     int   // requests a client may make at once; 0 picks Rate/6
+	MaxUpload int64 // bytes per request body
+
+	// serve HTTPS; with ClientCA, clients must present a certificate it signed
+	TLSCert  string
+	TLSKey   string
+	ClientCA string
+
+	Policy *config.Policy // applied to every wipe; nil removes everything
+
+	// one line per request; nil logs nothing
+	Log func(format string, args ...any)
+}
+
+type Server struct {
+	config  Config
+	tokens  tokenSet
+	limiter *limiter
+	jobs    *jobQueue
+	http    *http.Server
+}
+
+// checks the configuration; a server that anyone could reach and use is
+// refused
+func New(cfg Config) (*Server, error) {
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultAddr
+	}
+	if cfg.Rate <= 0 {
+		cfg.Rate = DefaultRate
+	}
+	if cfg.MaxUpload <= 0 {
+		cfg.MaxUpload = DefaultMaxUpload
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return nil, fmt.Errorf("--tls-cert and --tls-key go together")
+	}
+	if cfg.ClientCA != "" && cfg.TLSCert == "" {
+		return nil, fmt.Errorf("client certificates need TLS: set --tls-cert and --tls-key")
+	}
+	if cfg.TokenFile == "" && cfg.ClientCA == "" {
+		return nil, fmt.Errorf("refusing to serve without authentication: set --token-file or --client-ca")
+	}
+
+	s := &Server{config: cfg, limiter: newLimiter(cfg.Rate, cfg.Burst), jobs: newJobQueue()}
+	if cfg.TokenFile != "" {
+		tokens, err := loadTokens(cfg.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		s.tokens = tokens
+	}
+
+	tlsConf, err := tlsConfig(cfg.ClientCA)
+	if err != nil {
+		return nil, err
+	}
+	s.http = &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           s.Handler(),
+		TLSConfig:         tlsConf,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	return s, nil
+}
+
+// the API routes behind authentication and rate limiting; /v1/health is open
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "schema_version": schema.Version})
+	})
+	mux.Handle("POST /v1/analyse", s.guard(s.handleAnalyse))
+	mux.Handle("POST /v1/wipe", s.guard(s.handleWipe))
+	mux.Handle("GET /v1/events", s.guard(s.handleEvents))
+	mux.Handle("POST /v1/jobs", s.guard(s.handleCreateJob))
+	mux.Handle("GET /v1/jobs/{id}", s.guard(s.handleJobStatus))
+	mux.Handle("GET /v1/jobs/{id}/result", s.guard(s.handleJobResult))
+	mux.Handle("DELETE /v1/jobs/{id}", s.guard(s.handleDeleteJob))
+	return mux
+}
+
+// serves until ctx is cancelled, then lets running requests and jobs
+// finish; every job's files are deleted on the way out
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	defer s.jobs.close()
+	go s.jobs.expire(ctx)
+
+	done := make(chan error, 1)
+	go func() {
+		if s.config.TLSCert != "" {
+			done <- s.http.ServeTLS(listener, s.config.TLSCert, s.config.TLSKey)
+		} else {
+			done <- s.http.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		return s.http.Shutdown(shutdown)
+	}
+}
+
+// ╭─ MIDDLEWARE ────────────────────────────────╮
+
+// rate limits by address, authenticates, rate limits by client and caps
+// the body before handler runs; the address limit comes first so token
+// guessing is throttled too
+func (s *Server) guard(handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		status := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		client, ok := s.identify(r)
+		defer func() {
+			s.logf("%s %s %d %s %s", r.Method, r.URL.Path, status.status, clientName(client), time.Since(started).Round(time.Millisecond))
+		}()
+
+		address := remoteKey(r)
+		if !s.allow(status, address) {
+			return
+		}
+		if !ok {
+			status.Header().Set("WWW-Authenticate", `Bearer realm="caligra"`)
+			writeError(status, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		if client != address && !s.allow(status, client) {
+			return
+		}
+		if r.ContentLength > s.config.MaxUpload {
+			writeError(status, http.StatusRequestEntityTooLarge, fmt.Errorf("upload is over the %d byte limit", s.config.MaxUpload))
+			return
+		}
+		r.Body = http.MaxBytesReader(status, r.Body, s.config.MaxUpload)
+
+		handler(status, r)
+	})
+}
+
+// takes a request from key's budget, answering 429 when it is spent
+func (s *Server) allow(w http.ResponseWriter, key string) bool {
+	allowed, wait := s.limiter.allow(key)
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		writeError(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+	}
+	return allowed
+}
+
+func clientName(client string) string {
+	if client == "" {
+		return "anonymous"
+	}
+	return client
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.config.Log != nil {
+		s.config.Log(format, args...)
+	}
+}
+
+// remembers the status for the request log
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// lets http.ResponseController reach Flush on event streams
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ╭─ HANDLERS ──────────────────────────────────╮
+
+// multipart field "file" in, analysis document out
+func (s *Server) handleAnalyse(w http.ResponseWriter, r *http.Request) {
+	path, name, cleanup, err := receiveUpload(r)
+	if err != nil {
+		writeError(w, uploadStatus(err), err)
+		return
+	}
+	defer cleanup()
+
+	report, err := analyse.Analyze(path)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	report.Path = name
+	writeJSON(w, http.StatusOK, schema.FromReport(report))
+}
+
+// multipart field "file" in, the sanitized file out; ?report=json returns
+// the wipe document instead
+func (s *Server) handleWipe(w http.ResponseWriter, r *http.Request) {
+	path, name, cleanup, err := receiveUpload(r)
+	if err != nil {
+		writeError(w, uploadStatus(err), err)
+		return
+	}
+	defer cleanup()
+
+	result, err := wipe.WipeFile(path, s.wipeOptions())
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// server-side paths mean nothing to the client, and are gone after this
+	doc := schema.FromWipe(result)
+	doc.Input, doc.Output, doc.Backup, doc.Attestation = name, name, "", ""
+	if r.URL.Query().Get("report") == "json" {
+		writeJSON(w, http.StatusOK, doc)
+		return
+	}
+	if !result.Success {
+		writeJSON(w, http.StatusUnprocessableEntity, doc)
+		return
+	}
+
+	output, err := os.Open(result.OutputPath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer output.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("X-Caligra-Sensitive-Fields", strconv.Itoa(len(result.SensitiveData)))
+	w.Header().Set("X-Caligra-Excised-Bytes", strconv.FormatInt(result.Excised(), 10))
+	io.Copy(w, output)
+}
+
+// uploads get no profile and leave no backup
+func (s *Server) wipeOptions() *wipe.WipeOptions {
+	options := wipe.DefaultWipeOptions()
+	options.InjectProfile = false
+	options.KeepBackup = false
+	options.Policy = s.config.Policy
+	return options
+}
+
+// ╭─ UPLOADS ───────────────────────────────────╮
+
+var errNoFile = errors.New(`no file: send it as multipart field "file"`)
+
+// saves the "file" part into a private temp directory under its own
+// (sanitized) name, whose extension format detection may fall back to
+func receiveUpload(r *http.Request) (path, name string, cleanup func(), err error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return "", "", nil, errNoFile
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", "", nil, errNoFile
+		}
+		if err != nil {
+			return "", "", nil, err
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		name = util.SanitizeFilename(part.FileName())
+		if name == "" || name == "." {
+			name = "upload"
+		}
+		dir, err := os.MkdirTemp("", "caligra-serve-*")
+		if err != nil {
+			return "", "", nil, err
+		}
+		cleanup = func() { os.RemoveAll(dir) }
+
+		path = filepath.Join(dir, name)
+		if err := saveFile(path, part); err != nil {
+			cleanup()
+			return "", "", nil, err
+		}
+		return path, name, cleanup, nil
+	}
+}
+
+// writes a new file readable only by the server, creating its directory
+func saveFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func uploadStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || errors.Is(err, errArchiveTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func writeJSON(w http.ResponseWriter, status int, doc any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(doc)
+}
+
+// errors use the error document of the published schema
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, schema.FromError(err))
+}
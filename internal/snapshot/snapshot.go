@@ -0,0 +1,269 @@
+// BYZRA ⸻ internal/snapshot/snapshot.go
+// baseline of a tree's metadata, and what drifted from it since
+
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"caligra/internal/analyse"
+	"caligra/internal/formats"
+)
+
+// bumped when the file layout changes
+const Version = 1
+
+// the metadata of every supported file under Scope at one moment; values
+// are kept as digests, so a snapshot of a leaky tree does not leak itself
+type Snapshot struct {
+	Version int                  `json:"version"`
+	Scope   string               `json:"scope"` // absolute directory
+	Taken   time.Time            `json:"taken"`
+	Files   map[string]FileState `json:"files"` // by path relative to Scope
+	Errors  int                  `json:"errors"`
+}
+
+// one file's metadata when the snapshot was taken
+type FileState struct {
+	Size      int64             `json:"size"`
+	ModTime   time.Time         `json:"mod_time"`
+	Fields    map[string]string `json:"fields"` // field -> digest of its value
+	Sensitive []string          `json:"sensitive,omitempty"`
+}
+
+// short SHA-256 of a field value; enough to tell values apart
+func digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+// analyses every supported file under dir, skipping hidden folders and
+// .volena outputs; progress is called after each file with the number done
+// and the total
+func Take(dir string, progress func(done, total int)) (*Snapshot, error) {
+	scope, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(scope)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", dir)
+	}
+
+	var paths []string
+	err = filepath.WalkDir(scope, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != scope && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() && formats.IsSupported(filepath.Ext(path)) &&
+			!strings.Contains(entry.Name(), ".volena.") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	sort.Strings(paths)
+
+	snap := &Snapshot{Version: Version, Scope: scope, Taken: time.Now().UTC(), Files: make(map[string]FileState)}
+	for i, path := range paths {
+		state, err := stateOf(path)
+		if progress != nil {
+			progress(i+1, len(paths))
+		}
+		if err != nil {
+			snap.Errors++
+			continue
+		}
+		rel, _ := filepath.Rel(scope, path)
+		snap.Files[filepath.ToSlash(rel)] = state
+	}
+	return snap, nil
+}
+
+func stateOf(path string) (FileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileState{}, err
+	}
+	report, err := analyse.Analyze(path)
+	if err != nil {
+		return FileState{}, err
+	}
+
+	state := FileState{
+		Size:      info.Size(),
+		ModTime:   info.ModTime().UTC(),
+		Fields:    make(map[string]string),
+		Sensitive: slices.Sorted(slices.Values(report.SensitiveFields)),
+	}
+	for field, value := range report.Fields() {
+		state.Fields[field] = digest(value)
+	}
+	for _, item := range report.Embedded {
+		state.Fields["embedded:"+item.Name] = digest(item.Kind + " " + item.Detail)
+	}
+	return state, nil
+}
+
+// writes the snapshot as JSON, readable only by its owner
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%s is not a snapshot: %w", path, err)
+	}
+	if snap.Version != Version || snap.Files == nil {
+		return nil, fmt.Errorf("%s is not a version %d snapshot", path, Version)
+	}
+	return &snap, nil
+}
+
+// ╭─ DRIFT ─────────────────────────────────────╮
+
+// kinds of drift
+const (
+	DriftNew     = "new"     // not in the snapshot, and carries metadata
+	DriftChanged = "changed" // fields appeared or changed value
+	DriftCleaned = "cleaned" // fields only went away
+	DriftRemoved = "removed" // in the snapshot, gone now
+)
+
+// how one file's metadata differs from the snapshot
+type Drift struct {
+	Path      string // relative to the scope
+	Kind      string
+	Added     []string // fields that were not there
+	Changed   []string // fields with a different value
+	Removed   []string // fields that went away
+	Sensitive []string // of Added and Changed, those that are sensitive now
+}
+
+// the result of comparing a tree with its snapshot
+type Comparison struct {
+	Snapshot  *Snapshot
+	Current   *Snapshot
+	Drifts    []Drift // sorted by path
+	Unchanged int
+}
+
+// files whose metadata appeared or changed; the ones to look at
+func (c *Comparison) Regressions() []Drift {
+	var drifts []Drift
+	for _, drift := range c.Drifts {
+		if drift.Kind == DriftNew || drift.Kind == DriftChanged {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts
+}
+
+// takes dir (the snapshot's scope when "") again and compares it with snap
+func Compare(snap *Snapshot, dir string, progress func(done, total int)) (*Comparison, error) {
+	if dir == "" {
+		dir = snap.Scope
+	}
+	current, err := Take(dir, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &Comparison{Snapshot: snap, Current: current}
+	for path, now := range current.Files {
+		before, ok := snap.Files[path]
+		if !ok {
+			if len(now.Fields) > 0 {
+				comparison.Drifts = append(comparison.Drifts, Drift{
+					Path: path, Kind: DriftNew, Added: slices.Sorted(maps.Keys(now.Fields)),
+					Sensitive: slices.Clone(now.Sensitive),
+				})
+			}
+			continue
+		}
+		drift := diff(path, before, now)
+		if drift == nil {
+			comparison.Unchanged++
+			continue
+		}
+		comparison.Drifts = append(comparison.Drifts, *drift)
+	}
+	for path := range snap.Files {
+		if _, ok := current.Files[path]; !ok {
+			comparison.Drifts = append(comparison.Drifts, Drift{Path: path, Kind: DriftRemoved})
+		}
+	}
+	sort.Slice(comparison.Drifts, func(i, j int) bool { return comparison.Drifts[i].Path < comparison.Drifts[j].Path })
+	return comparison, nil
+}
+
+// nil when the fields are the same
+func diff(path string, before, now FileState) *Drift {
+	drift := &Drift{Path: path}
+	for field, value := range now.Fields {
+		old, ok := before.Fields[field]
+		switch {
+		case !ok:
+			drift.Added = append(drift.Added, field)
+		case old != value:
+			drift.Changed = append(drift.Changed, field)
+		}
+	}
+	for field := range before.Fields {
+		if _, ok := now.Fields[field]; !ok {
+			drift.Removed = append(drift.Removed, field)
+		}
+	}
+	if len(drift.Added)+len(drift.Changed)+len(drift.Removed) == 0 {
+		return nil
+	}
+	sort.Strings(drift.Added)
+	sort.Strings(drift.Changed)
+	sort.Strings(drift.Removed)
+
+	drift.Kind = DriftCleaned
+	if len(drift.Added)+len(drift.Changed) > 0 {
+		drift.Kind = DriftChanged
+	}
+	for _, field := range append(slices.Clone(drift.Added), drift.Changed...) {
+		if slices.Contains(now.Sensitive, field) {
+			drift.Sensitive = append(drift.Sensitive, field)
+		}
+	}
+	sort.Strings(drift.Sensitive)
+	return drift
+}
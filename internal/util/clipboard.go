@@ -0,0 +1,142 @@
+// BYZRA ⸻ internal/util/clipboard.go
+// clipboard access through wl-clipboard or xclip
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// image types tried in order of preference
+var clipboardImageTypes = []string{"image/png", "image/jpeg", "image/webp", "image/gif", "image/tiff"}
+
+// clipboard helper commands for the current session
+type clipboardBackend struct {
+	name  string
+	types func() ([]string, error)
+	read  func(mime string) ([]byte, error)
+	write func(mime string, data []byte) error
+}
+
+// picks wl-clipboard on Wayland and xclip on X11
+func detectClipboard() (*clipboardBackend, error) {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := exec.LookPath("wl-paste"); err == nil {
+			return &clipboardBackend{
+				name: "wl-clipboard",
+				types: func() ([]string, error) {
+					return clipboardLines(exec.Command("wl-paste", "--list-types"))
+				},
+				read: func(mime string) ([]byte, error) {
+					return exec.Command("wl-paste", "--no-newline", "--type", mime).Output()
+				},
+				write: func(mime string, data []byte) error {
+					cmd := exec.Command("wl-copy", "--type", mime)
+					cmd.Stdin = bytes.NewReader(data)
+					return cmd.Run()
+				},
+			}, nil
+		}
+	}
+
+	if os.Getenv("DISPLAY") != "" {
+		if _, err := exec.LookPath("xclip"); err == nil {
+			return &clipboardBackend{
+				name: "xclip",
+				types: func() ([]string, error) {
+					return clipboardLines(exec.Command("xclip", "-selection", "clipboard", "-t", "TARGETS", "-o"))
+				},
+				read: func(mime string) ([]byte, error) {
+					return exec.Command("xclip", "-selection", "clipboard", "-t", mime, "-o").Output()
+				},
+				write: func(mime string, data []byte) error {
+					cmd := exec.Command("xclip", "-selection", "clipboard", "-t", mime, "-i")
+					cmd.Stdin = bytes.NewReader(data)
+					return cmd.Run()
+				},
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no clipboard tool found (install wl-clipboard or xclip)")
+}
+
+// runs a command and splits its output into trimmed lines
+func clipboardLines(cmd *exec.Cmd) ([]string, error) {
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// reads the clipboard's image content and its MIME type
+func ReadClipboardImage() ([]byte, string, error) {
+	backend, err := detectClipboard()
+	if err != nil {
+		return nil, "", err
+	}
+
+	available, err := backend.types()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query clipboard via %s: %w", backend.name, err)
+	}
+
+	for _, mime := range clipboardImageTypes {
+		for _, offered := range available {
+			if offered != mime {
+				continue
+			}
+			data, err := backend.read(mime)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read clipboard via %s: %w", backend.name, err)
+			}
+			if len(data) == 0 {
+				return nil, "", fmt.Errorf("clipboard image is empty")
+			}
+			return data, mime, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("clipboard does not contain an image")
+}
+
+// replaces the clipboard content with an image
+func WriteClipboardImage(data []byte, mime string) error {
+	backend, err := detectClipboard()
+	if err != nil {
+		return err
+	}
+
+	if err := backend.write(mime, data); err != nil {
+		return fmt.Errorf("failed to write clipboard via %s: %w", backend.name, err)
+	}
+	return nil
+}
+
+// file extension for an image MIME type
+func ImageExtensionForMIME(mime string) string {
+	switch mime {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/webp":
+		return ".webp"
+	case "image/gif":
+		return ".gif"
+	case "image/tiff":
+		return ".tiff"
+	default:
+		return ".png"
+	}
+}
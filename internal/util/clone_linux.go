@@ -0,0 +1,19 @@
+// BYZRA ⸻ internal/util/clone_linux.go
+// reflink copies via FICLONE
+
+//go:build linux
+
+package util
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// makes dst share src's extents (btrfs, XFS, bcachefs, ...), so the copy
+// takes no time and no space until one of them changes; false when the
+// filesystem cannot, or the files are on different ones
+func cloneFile(dst, src *os.File) bool {
+	return unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())) == nil
+}
@@ -0,0 +1,13 @@
+// BYZRA ⸻ internal/util/clone_other.go
+// reflink copies are only implemented on Linux
+
+//go:build !linux
+
+package util
+
+import "os"
+
+// never shares extents here; SafeCopy copies the bytes
+func cloneFile(dst, src *os.File) bool {
+	return false
+}
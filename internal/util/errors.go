@@ -0,0 +1,71 @@
+// BYZRA ⸻ internal/util/errors.go
+// errors callers can branch on with errors.Is / errors.As
+
+package util
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// matched by every ToolMissingError
+var ErrToolMissing = errors.New("external tool not found")
+
+// the path is a FIFO, socket, device or other non-regular file, which can
+// block on open or never end
+var ErrNotRegularFile = errors.New("not a regular file")
+
+// the file is larger than SetMaxFileSize allows
+var ErrFileTooLarge = errors.New("file exceeds the maximum size")
+
+// another process still has the file open for writing
+var ErrFileBusy = errors.New("file is open for writing by another process")
+
+// the file has other hard links and the policy refuses to wipe it
+var ErrHardLinked = errors.New("file has other hard links")
+
+// an external program (exiftool, ffmpeg, ...) is not installed
+type ToolMissingError struct {
+	Tool string
+}
+
+func (e *ToolMissingError) Error() string {
+	return e.Tool + " not found in PATH"
+}
+
+func (e *ToolMissingError) Is(target error) bool {
+	return target == ErrToolMissing
+}
+
+// how to install the tool
+func (e *ToolMissingError) Hint() string {
+	switch e.Tool {
+	case "exiftool":
+		return "install ExifTool (apt install libimage-exiftool-perl, dnf install perl-Image-ExifTool, brew install exiftool)"
+	case "ffmpeg", "ffprobe":
+		return "install FFmpeg, which provides ffmpeg and ffprobe (apt install ffmpeg, brew install ffmpeg)"
+	case "identify":
+		return "install ImageMagick (apt install imagemagick, brew install imagemagick)"
+	case "jpegtran":
+		return "install libjpeg-turbo's jpegtran (apt install libjpeg-turbo-progs, brew install jpeg-turbo)"
+	case "sqlite3":
+		return "install the sqlite3 command-line shell (apt install sqlite3, brew install sqlite)"
+	}
+	return "install " + e.Tool + " and make sure it is in PATH"
+}
+
+// a ToolMissingError when err says tool could not be started, err otherwise
+func ToolError(tool string, err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return &ToolMissingError{Tool: tool}
+	}
+	return err
+}
+
+// nil when tool is in PATH, a ToolMissingError otherwise
+func RequireTool(tool string) error {
+	if _, err := exec.LookPath(tool); err != nil {
+		return &ToolMissingError{Tool: tool}
+	}
+	return nil
+}
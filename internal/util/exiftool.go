@@ -5,31 +5,49 @@ package util
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
+
+	"caligra/internal/i18n"
 )
 
 // runs exiftool to extract all metadata as JSON
 func ExifToolExtract(path string) (string, error) {
-	return SpinWhile("[~] Analyzing metadata", func() (string, error) {
-		cmd := exec.Command("exiftool", "-json", path)
-		var out bytes.Buffer
-		cmd.Stdout = &out
-		err := cmd.Run()
-		return out.String(), err
+	var out bytes.Buffer
+	err := Track(i18n.T("Reading tags with exiftool"), func() error {
+		return runExifTool(path, []string{"-json"}, &out)
 	})
+	return out.String(), err
 }
 
 // runs exiftool to remove all metadata
 func ExifToolRemove(path string) error {
-	_, err := SpinWhile("[~] Removing metadata", func() (string, error) {
-		cmd := exec.Command("exiftool", "-all=", "-overwrite_original", path)
-		err := cmd.Run()
-		return "", err
+	return Track(i18n.T("Stripping tags with exiftool"), func() error {
+		return runExifTool(path, []string{"-all=", "-overwrite_original"}, nil)
 	})
-	return err
+}
+
+// runs exiftool to remove all metadata, copying the listed tags back
+func ExifToolRemoveExcept(path string, keep []string) error {
+	return ExifToolRemoveExceptFrom(path, "@", keep)
+}
+
+// ExifToolRemoveExcept with the kept tags copied from source, an earlier
+// copy of path ("@" is path itself)
+func ExifToolRemoveExceptFrom(path, source string, keep []string) error {
+	args := []string{"-all=", "-tagsFromFile", source}
+	for _, tag := range keep {
+		args = append(args, "-"+tag)
+	}
+	return ExifToolWrite(path, args)
 }
 
 // parses JSON output from exiftool into a map
@@ -51,23 +69,59 @@ func ParseExifToolOutput(output string) (map[string]any, error) {
 		return make(map[string]any), nil // return empty map, not an error
 	}
 
+	// text that is not valid UTF-8 comes back as "base64:..."
+	for key, value := range results[0] {
+		if text, ok := value.(string); ok {
+			results[0][key] = decodeExifToolText(text)
+		}
+	}
+
 	return results[0], nil
 }
 
 // returns names of potentially sensitive metadata fields
 func GetSensitiveMetadataFields() []string {
 	return []string{
-		"GPSLatitude", "GPSLongitude", "GPSPosition", "Location",
+		"GPSLatitude", "GPSLongitude", "GPSPosition", "GPSCoordinates", "Location",
 		"Author", "Creator", "Artist", "Owner", "Copyright",
 		"Email", "CameraSerialNumber", "SerialNumber", "DeviceID",
 		"OriginalFilename", "FileName", "UserName", "HostComputer",
 		"Make", "Model", "Software", "CreateDate", "ModifyDate",
+		"LastModifiedBy", "Company", "Manager", "PrintedBy",
+		"Producer", "DocumentID", "InstanceID", "Contributor",
+		// QuickTime Keys: capture time with its zone, the Live Photo pairing
+		"CreationDate", "ContentIdentifier",
+		// RIFF INFO and Broadcast Wave
+		"DateCreated", "Engineer", "Technician", "Originator", "CodingHistory",
+		// IPTC, as Photoshop writes it
+		"By-line", "Writer-Editor", "CaptionWriter",
+		// ZIP extra fields: access times, Windows owner and group SIDs
+		"AccessDate", "SecurityDescriptor",
+		// TAR entries: group names, inode change times, extended attributes
+		"GroupName", "InodeChangeDate", "PAXRecords",
+		// executables: compiler notes, build paths, link and debug times
+		"Compiler", "DebugLink", "CompileDirectory", "PDBPath", "RichHeader",
+		"LinkDate", "DebugDate", "ExportDate", "ResourceDate",
+		// email trace fields: relays, client addresses, message IDs, clients
+		"Received", "Originating-IP", "Sender-IP", "Client-IP", "Message-ID",
+		"Return-Path", "Delivered-To", "Envelope", "User-Agent", "Mailer",
+		// GPS tracks: point times, recording devices, account links, KML
+		// addresses and phone numbers, vendor extensions
+		"TrackDates", "DeviceName", "ActivityLink", "Address", "PhoneNumber", "Extensions",
+		// SQLite free space still holding deleted rows
+		"DeletedData",
+		// calendars: who was invited by whom, entry IDs, when entries were
+		// made and changed
+		"Organizer", "Attendees", "Contact", "EventUID", "StampDates",
+		// SubStation Alpha credits
+		"Original Script", "Original Translation", "Original Editing",
+		"Original Timing", "Script Updated By",
 	}
 }
 
 // returns true if the field might contain sensitive data
 func IsSensitiveField(fieldName string) bool {
-	fieldName = strings.ToLower(fieldName)
+	fieldName = strings.ToLower(MemberField(fieldName))
 	sensitiveFields := GetSensitiveMetadataFields()
 
 	for _, sensitive := range sensitiveFields {
@@ -82,3 +136,160 @@ func IsSensitiveField(fieldName string) bool {
 
 	return false
 }
+
+// groups a sensitive field into a reporting category
+func SensitiveFieldCategory(fieldName string) string {
+	lower := strings.ToLower(MemberField(fieldName))
+
+	switch {
+	case strings.Contains(lower, "gps") || strings.Contains(lower, "location") ||
+		lower == "address":
+		return "location"
+	case strings.Contains(lower, "serial") || strings.Contains(lower, "deviceid") ||
+		strings.Contains(lower, "make") || strings.Contains(lower, "model") ||
+		strings.Contains(lower, "hostcomputer") || strings.Contains(lower, "devicename"):
+		return "device"
+	case strings.Contains(lower, "author") || strings.Contains(lower, "creator") ||
+		strings.Contains(lower, "artist") || strings.Contains(lower, "owner") ||
+		strings.Contains(lower, "copyright") || strings.Contains(lower, "email") ||
+		strings.Contains(lower, "username") || strings.Contains(lower, "groupname") ||
+		strings.HasPrefix(lower, "original ") ||
+		strings.Contains(lower, "modifiedby") || strings.Contains(lower, "company") ||
+		strings.Contains(lower, "manager") || strings.Contains(lower, "printedby") ||
+		strings.Contains(lower, "contributor") || strings.Contains(lower, "engineer") ||
+		strings.Contains(lower, "technician") || strings.Contains(lower, "originator") ||
+		strings.Contains(lower, "by-line") || strings.Contains(lower, "writer") ||
+		strings.Contains(lower, "securitydescriptor") ||
+		strings.Contains(lower, "activitylink") || strings.Contains(lower, "phonenumber") ||
+		strings.Contains(lower, "organizer") || strings.Contains(lower, "attendee") ||
+		strings.Contains(lower, "contact") ||
+		strings.Contains(lower, "updated by"):
+		return "identity"
+	case strings.Contains(lower, "software") || strings.Contains(lower, "producer") ||
+		strings.Contains(lower, "codinghistory") || strings.Contains(lower, "compiler") ||
+		strings.Contains(lower, "richheader") || strings.Contains(lower, "user-agent") ||
+		strings.Contains(lower, "mailer"):
+		return "software"
+	case strings.Contains(lower, "received") || strings.HasSuffix(lower, "-ip") ||
+		strings.Contains(lower, "message-id") || strings.Contains(lower, "return-path") ||
+		strings.Contains(lower, "delivered-to") || strings.Contains(lower, "envelope") ||
+		strings.Contains(lower, "eventuid"):
+		return "network"
+	case strings.Contains(lower, "date"):
+		return "timestamp"
+	case strings.Contains(lower, "filename") || strings.Contains(lower, "pdbpath") ||
+		strings.Contains(lower, "debuglink") || strings.Contains(lower, "compiledirectory"):
+		return "filename"
+	default:
+		return "other"
+	}
+}
+
+// runs exiftool to list every date-time tag with its group, as JSON
+func ExifToolReadTimes(path string) (string, error) {
+	var out bytes.Buffer
+	err := runExifTool(path, []string{"-json", "-a", "-G1", "-time:all"}, &out)
+	return out.String(), err
+}
+
+// runs exiftool with tag assignments (e.g. "-ExifIFD:DateTimeOriginal=...")
+func ExifToolWrite(path string, assignments []string) error {
+	return runExifTool(path, append(slices.Clone(assignments), "-overwrite_original"), nil)
+}
+
+// sets one tag, e.g. ("Artist", "nynynn"); the value is written as-is, even
+// with "=", quotes or a leading dash
+func ExifToolSetTag(path, tag, value string) error {
+	return ExifToolWrite(path, []string{"-" + tag + "=" + strings.ToValidUTF8(value, "\uFFFD")})
+}
+
+// ╭─ INVOCATION ────────────────────────────────╮
+
+// runs exiftool on path with options. Everything goes through an argument
+// file (-@) of C strings, so no file name or tag value is split, trimmed or
+// taken for an option, whatever bytes it holds; the file is named by its
+// absolute path, which never starts with a dash. File names and values are
+// read and written as UTF-8 on every platform
+func runExifTool(path string, options []string, stdout io.Writer) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	argfile, err := os.CreateTemp("", "caligra-exiftool-*.args")
+	if err != nil {
+		return fmt.Errorf("failed to create exiftool argument file: %w", err)
+	}
+	defer os.Remove(argfile.Name())
+
+	args := append([]string{"-charset", "filename=UTF8", "-charset", "UTF8"}, options...)
+	_, err = argfile.WriteString(exifToolArgfile(append(args, abs)))
+	if closeErr := argfile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write exiftool argument file: %w", err)
+	}
+
+	cmd := exec.Command("exiftool", "-@", argfile.Name())
+	cmd.Stdout = stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return ToolError("exiftool", err)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// one "#[CSTR]" line per argument
+func exifToolArgfile(args []string) string {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString("#[CSTR]" + exifToolCString(arg) + "\n")
+	}
+	return b.String()
+}
+
+// escapes an argument for a "#[CSTR]" argfile line: backslashes and control
+// characters are escaped, everything else (UTF-8 included) is kept as bytes.
+// exiftool escapes quotes, "$" and "@" itself before un-escaping the line
+func exifToolCString(arg string) string {
+	var b strings.Builder
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; {
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// undoes exiftool's base64 encoding of text that is not valid UTF-8, keeping
+// what can be read
+func decodeExifToolText(value string) string {
+	encoded, ok := strings.CutPrefix(value, "base64:")
+	if !ok {
+		return value
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return value
+	}
+	return strings.ToValidUTF8(string(raw), "\uFFFD")
+}
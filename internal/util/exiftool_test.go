@@ -0,0 +1,145 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// reads an argfile back the way exiftool does for "#[CSTR]" lines
+func parseExifToolArgfile(t *testing.T, argfile string) []string {
+	t.Helper()
+	var args []string
+	for _, line := range strings.SplitAfter(argfile, "\n") {
+		if line == "" {
+			continue
+		}
+		body, ok := strings.CutPrefix(line, "#[CSTR]")
+		if !ok {
+			t.Fatalf("line %q is not a C string line", line)
+		}
+		body, ok = strings.CutSuffix(body, "\n")
+		if !ok {
+			t.Fatalf("line %q is not terminated", line)
+		}
+		var b strings.Builder
+		for i := 0; i < len(body); i++ {
+			if body[i] != '\\' {
+				b.WriteByte(body[i])
+				continue
+			}
+			if i+1 == len(body) {
+				t.Fatalf("line %q ends in a lone backslash", line)
+			}
+			i++
+			switch body[i] {
+			case '\\':
+				b.WriteByte('\\')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'x':
+				if i+2 >= len(body) {
+					t.Fatalf("line %q has a short \\x escape", line)
+				}
+				c, err := strconv.ParseUint(body[i+1:i+3], 16, 8)
+				if err != nil {
+					t.Fatalf("line %q: %v", line, err)
+				}
+				b.WriteByte(byte(c))
+				i += 2
+			default:
+				t.Fatalf("line %q has unknown escape \\%c", line, body[i])
+			}
+		}
+		args = append(args, b.String())
+	}
+	return args
+}
+
+func TestExifToolArgfile(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  string
+	}{
+		{"plain", "/home/user/photo.jpg"},
+		{"leading dash", "-overwrite_original.jpg"},
+		{"tag value with dash", "-Artist=-nynynn"},
+		{"embedded newline", "/tmp/a\n-delete_original!\n.jpg"},
+		{"carriage return and tab", "/tmp/a\r\tb.jpg"},
+		{"comment line", "# not a comment\n#[CSTR]-all="},
+		{"backslashes", `C:\photos\\new\x41.jpg`},
+		{"control bytes", "/tmp/\x00\x01\x1b\x7f.jpg"},
+		{"invalid utf-8", "/tmp/\xff\xfe\xc3.jpg"},
+		{"non-ascii", "/home/nynynn/Fotos/Über/照片 😀.jpg"},
+		{"quotes and variables", `-Comment="$Artist" @foo 'x'`},
+		{"trailing space", "/tmp/a.jpg "},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := []string{"-charset", "UTF8", tt.arg}
+			argfile := exifToolArgfile(args)
+			if got := strings.Count(argfile, "\n"); got != len(args) {
+				t.Fatalf("argfile has %d lines, want %d:\n%q", got, len(args), argfile)
+			}
+			got := parseExifToolArgfile(t, argfile)
+			if len(got) != len(args) {
+				t.Fatalf("read back %d args, want %d", len(got), len(args))
+			}
+			for i := range args {
+				if got[i] != args[i] {
+					t.Errorf("arg %d: read back %q, want %q", i, got[i], args[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExifToolCString(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"photo.jpg", "photo.jpg"},
+		{"-a", "-a"},
+		{"a\nb", `a\nb`},
+		{"a\\nb", `a\\nb`},
+		{"\x00\x1f\x7f", `\x00\x1f\x7f`},
+		{"\xff", "\xff"},
+		{"Über", "Über"},
+	}
+
+	for _, tt := range tests {
+		if got := exifToolCString(tt.arg); got != tt.want {
+			t.Errorf("exifToolCString(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeExifToolText(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "Canon EOS 5D", "Canon EOS 5D"},
+		{"non-ascii", "Über 照片", "Über 照片"},
+		{"base64 utf-8", "base64:w5xiZXI=", "Über"},
+		{"base64 invalid utf-8", "base64:YWL/Yw==", "ab\uFFFDc"},
+		{"not base64", "base64:not base64!", "base64:not base64!"},
+		{"prefix elsewhere", "see base64:YWJj", "see base64:YWJj"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeExifToolText(tt.value); got != tt.want {
+				t.Errorf("decodeExifToolText(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+// BYZRA ⸻ internal/util/ffmpeg.go
+// ffprobe/ffmpeg wrappers for stream-level operations
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// one stream as reported by ffprobe
+type ProbeStream struct {
+	Index       int               `json:"index"`
+	CodecType   string            `json:"codec_type"`
+	CodecName   string            `json:"codec_name"`
+	CodecTag    string            `json:"codec_tag_string"`
+	Tags        map[string]string `json:"tags"`
+	Disposition map[string]int    `json:"disposition"`
+}
+
+// one chapter as reported by ffprobe
+type ProbeChapter struct {
+	ID        int64             `json:"id"`
+	StartTime string            `json:"start_time"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// streams and chapters of a media file
+type ProbeResult struct {
+	Streams  []ProbeStream  `json:"streams"`
+	Chapters []ProbeChapter `json:"chapters"`
+}
+
+// is ffprobe available?
+func HasFFProbe() bool {
+	_, err := exec.LookPath("ffprobe")
+	return err == nil
+}
+
+// lists the streams and chapters of a media file
+func FFProbe(path string) (*ProbeResult, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-print_format", "json", "-show_streams", "-show_chapters", path)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, ToolError("ffprobe", err)
+		}
+		return nil, fmt.Errorf("ffprobe failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var result ProbeResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	return &result, nil
+}
+
+// container-level tags of a media file
+func FFProbeFormatTags(path string) (map[string]string, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-print_format", "json", "-show_format", path)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, ToolError("ffprobe", err)
+		}
+		return nil, fmt.Errorf("ffprobe failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var result struct {
+		Format struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	return result.Format.Tags, nil
+}
+
+// lists the streams of a media file
+func FFProbeStreams(path string) ([]ProbeStream, error) {
+	result, err := FFProbe(path)
+	if err != nil {
+		return nil, err
+	}
+	return result.Streams, nil
+}
+
+// copies src to dst without re-encoding; args select streams and metadata.
+// bitexact keeps ffmpeg from stamping its own encoder tag on the output
+func FFmpegRemux(src, dst string, args []string) error {
+	if err := RequireTool("ffmpeg"); err != nil {
+		return err
+	}
+
+	full := []string{"-v", "error", "-y", "-i", src}
+	full = append(full, args...)
+	full = append(full, "-c", "copy", "-fflags", "+bitexact", dst)
+
+	cmd := exec.Command("ffmpeg", full...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg remux failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// copies src to dst without re-encoding, leaving out the given stream indexes
+func FFmpegDropStreams(src, dst string, drop []int) error {
+	args := []string{"-map", "0"}
+	for _, index := range drop {
+		args = append(args, "-map", "-0:"+strconv.Itoa(index))
+	}
+	args = append(args, "-map_metadata", "-1")
+	return FFmpegRemux(src, dst, args)
+}
+
+// writes one stream of src to dst as-is (e.g. an attached picture)
+func FFmpegExtractStream(src string, index int, dst string) error {
+	args := []string{"-v", "error", "-y", "-i", src, "-map", "0:" + strconv.Itoa(index), "-c", "copy", "-f", "image2", dst}
+	cmd := exec.Command("ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return ToolError("ffmpeg", err)
+		}
+		return fmt.Errorf("ffmpeg extract failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// copies the audio of src to dst with picture attached as its cover
+func FFmpegAttachPicture(src, picture, dst string) error {
+	args := []string{"-v", "error", "-y", "-i", src, "-i", picture,
+		"-map", "0:a", "-map", "1", "-map_chapters", "-1", "-c", "copy", "-disposition:v:0", "attached_pic",
+		"-fflags", "+bitexact", dst}
+	cmd := exec.Command("ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return ToolError("ffmpeg", err)
+		}
+		return fmt.Errorf("ffmpeg attach failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ╭─ METADATA ──────────────────────────────────╮
+
+// ffprobe tag names (lowercase) and the exiftool names they stand for
+var probeTagNames = map[string]string{
+	"title":         "Title",
+	"artist":        "Artist",
+	"album_artist":  "AlbumArtist",
+	"album":         "Album",
+	"composer":      "Composer",
+	"performer":     "Performer",
+	"comment":       "Comment",
+	"description":   "Description",
+	"genre":         "Genre",
+	"date":          "Date",
+	"creation_time": "CreateDate",
+	"creationdate":  "CreationDate",
+	"encoder":       "Encoder",
+	"encoded_by":    "EncodedBy",
+	"copyright":     "Copyright",
+	"publisher":     "Publisher",
+	"handler_name":  "HandlerName",
+	"vendor_id":     "VendorID",
+	"make":          "Make",
+	"model":         "Model",
+	"software":      "Software",
+	"author":        "Author",
+}
+
+// container and stream tags of a media file as a metadata map keyed like
+// exiftool's (Artist, CreateDate, Encoder, ...), for when exiftool is not
+// installed. Container tags win over stream tags of the same name
+func FFProbeMetadata(path string) (map[string]any, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-print_format", "json", "-show_format", "-show_streams", path)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, ToolError("ffprobe", err)
+		}
+		return nil, fmt.Errorf("ffprobe failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var result struct {
+		Format struct {
+			FormatName string            `json:"format_name"`
+			Duration   string            `json:"duration"`
+			Tags       map[string]string `json:"tags"`
+		} `json:"format"`
+		Streams []ProbeStream `json:"streams"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	metadata := map[string]any{"_source": "ffprobe"}
+	if result.Format.FormatName != "" {
+		metadata["FileType"] = result.Format.FormatName
+	}
+	if result.Format.Duration != "" {
+		metadata["Duration"] = result.Format.Duration
+	}
+
+	add := func(tags map[string]string, replace bool) {
+		for key, value := range tags {
+			name := probeTagName(key)
+			if _, exists := metadata[name]; exists && !replace {
+				continue
+			}
+			metadata[name] = value
+
+			// exiftool derives a position from QuickTime's ISO 6709 string
+			if name == "GPSCoordinates" {
+				metadata["GPSPosition"] = value
+			}
+		}
+	}
+	for _, stream := range result.Streams {
+		add(stream.Tags, false)
+	}
+	add(result.Format.Tags, true)
+
+	return metadata, nil
+}
+
+// exiftool-style name of an ffprobe tag: "creation_time" → "CreateDate",
+// "com.apple.quicktime.location.ISO6709" → "GPSCoordinates",
+// "major_brand" → "MajorBrand"
+func probeTagName(key string) string {
+	lower := strings.ToLower(key)
+	lower = strings.TrimPrefix(lower, "com.apple.quicktime.")
+	switch lower {
+	case "location", "location-eng", "location.iso6709":
+		return "GPSCoordinates" // not location.name, location.accuracy.horizontal, ...
+	}
+	if name, ok := probeTagNames[lower]; ok {
+		return name
+	}
+
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(lower, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || r == ':' || r == ' '
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
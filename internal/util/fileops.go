@@ -7,10 +7,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
 )
 
 // copies a file with integrity verification
@@ -32,8 +35,17 @@ func SafeCopy(src, dst string) error {
 	}
 	defer dstFile.Close()
 
-	// copy contents
-	if _, err = io.Copy(dstFile, srcFile); err != nil {
+	// programs stay runnable
+	if info, err := srcFile.Stat(); err == nil && info.Mode().Perm()&0111 != 0 {
+		if err := dstFile.Chmod(info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to set destination permissions: %w", err)
+		}
+	}
+
+	// copy contents, hashing the source as it streams by
+	sum := newCopyHash()
+	hashed, err := copyContents(dstFile, srcFile, sum)
+	if err != nil {
 		return fmt.Errorf("failed to copy file contents: %w", err)
 	}
 
@@ -42,14 +54,82 @@ func SafeCopy(src, dst string) error {
 		return fmt.Errorf("failed to sync destination file: %w", err)
 	}
 
-	// verify integrity
-	if err = verifyFileIntegrity(src, dst); err != nil {
+	// verify integrity; only the copy is read back when the source was
+	// hashed during the copy (a reflink is not)
+	if !hashed {
+		return verifyFileIntegrity(src, dst)
+	}
+	dstSum, err := fileDigest(dst, newCopyHash())
+	if err != nil {
 		return err
 	}
+	if dstSum != hex.EncodeToString(sum.Sum(nil)) {
+		return fmt.Errorf("integrity verification failed: file checksums don't match")
+	}
 
 	return nil
 }
 
+// a run of bytes holding data; the holes between extents read as zeros
+// and take no space on disk
+type extent struct {
+	offset, length int64
+}
+
+// copies src into the empty dst: a reflink where the filesystem shares
+// extents, else only the data extents, so a sparse file (a disk image, a
+// VM disk) stays sparse instead of growing to its full size. Unless it was
+// a reflink, sum (when not nil) has seen every byte of src and hashed is true
+func copyContents(dst, src *os.File, sum hash.Hash) (hashed bool, err error) {
+	if cloneFile(dst, src) {
+		return false, nil
+	}
+	info, err := src.Stat()
+	if err != nil {
+		return false, err
+	}
+	size := info.Size()
+
+	var reader io.Reader = src
+	if sum != nil {
+		reader = io.TeeReader(src, sum)
+	}
+
+	extents := dataExtents(src, size)
+	if len(extents) == 1 && extents[0] == (extent{0, size}) {
+		// dense: without a hash, io.Copy uses copy_file_range on Linux
+		_, err := io.Copy(dst, reader)
+		return sum != nil, err
+	}
+
+	var offset int64
+	for _, e := range extents {
+		if sum != nil {
+			io.CopyN(sum, zeros{}, e.offset-offset) // the hole before it reads as zeros
+		}
+		section := io.Reader(io.NewSectionReader(src, e.offset, e.length))
+		if sum != nil {
+			section = io.TeeReader(section, sum)
+		}
+		if _, err := io.Copy(io.NewOffsetWriter(dst, e.offset), section); err != nil {
+			return false, err
+		}
+		offset = e.offset + e.length
+	}
+	if sum != nil {
+		io.CopyN(sum, zeros{}, size-offset)
+	}
+	return sum != nil, dst.Truncate(size) // a trailing hole
+}
+
+// an endless run of zero bytes
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
 // create a backup
 func CreateBackup(path string) (string, error) {
 	backupPath := path + ".bak"
@@ -82,6 +162,50 @@ func RestoreBackup(backupPath string) error {
 	return nil
 }
 
+// gives path its own copy of its content, so changing it no longer changes
+// the other hard links to it
+func BreakHardlink(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	// same directory, so the rename below cannot cross filesystems
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create copy: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+
+	if _, err := copyContents(tmp, src, nil); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy file contents: %w", err)
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync copy: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write copy: %w", err)
+	}
+	os.Chtimes(tmp.Name(), info.ModTime(), info.ModTime())
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace file with its copy: %w", err)
+	}
+	return nil
+}
+
 // creates the output path with volena suffix
 func GenerateOutputPath(path string) string {
 	ext := filepath.Ext(path)
@@ -89,34 +213,108 @@ func GenerateOutputPath(path string) string {
 	return basePath + ".volena" + ext
 }
 
-func ValidatePath(path string) error {
-	_, err := os.Stat(path)
-	if err != nil {
-		return fmt.Errorf("path validation failed: %w", err)
+// XMP sidecars photo editors keep next to path: photo.xmp (Lightroom,
+// Capture One) and photo.jpg.xmp (darktable, digiKam), in either case
+func FindSidecars(path string) []string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	var found []string
+	var seen []os.FileInfo
+	for _, candidate := range []string{base + ".xmp", base + ".XMP", path + ".xmp", path + ".XMP"} {
+		info, err := os.Stat(candidate)
+		if err != nil || !info.Mode().IsRegular() || candidate == path {
+			continue
+		}
+		// one file on case-insensitive filesystems
+		if slices.ContainsFunc(seen, func(other os.FileInfo) bool { return os.SameFile(other, info) }) {
+			continue
+		}
+		seen = append(seen, info)
+		found = append(found, candidate)
 	}
+	return found
+}
 
-	fileInfo, err := os.Stat(path)
+func ValidatePath(path string) error {
+	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("failed to stat path: %w", err)
+		return fmt.Errorf("path validation failed: %w", err)
 	}
 
-	if fileInfo.IsDir() {
+	switch {
+	case info.IsDir():
 		return fmt.Errorf("path is a directory, expected a file: %s", path)
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("%w (%s): %s", ErrNotRegularFile, fileKind(info.Mode()), path)
+	case maxFileSize > 0 && info.Size() > maxFileSize:
+		return fmt.Errorf("%w of %s (%s): %s", ErrFileTooLarge, FormatBytes(maxFileSize), FormatBytes(info.Size()), path)
 	}
 
-	file, err := os.Open(path)
+	// non-blocking, in case the path was swapped for a FIFO since the Stat
+	file, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
 	if err != nil {
 		return fmt.Errorf("file is not readable: %w", err)
 	}
 	file.Close()
 
-	file, err = os.OpenFile(path, os.O_WRONLY, 0)
+	return nil
+}
+
+// checks an in-place wipe can rewrite the file; opening it does not
+// change its times
+func ValidateWritable(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
 	if err != nil {
 		return fmt.Errorf("file is not writable: %w", err)
 	}
-	file.Close()
+	return file.Close()
+}
 
-	return nil
+// a process with a file open
+type FileHolder struct {
+	PID     int
+	Command string
+}
+
+func (h FileHolder) String() string {
+	return fmt.Sprintf("%s (pid %d)", h.Command, h.PID)
+}
+
+// ErrFileBusy naming the processes writing path, nil when there are none
+// or they cannot be found out
+func CheckNotBusy(path string) error {
+	holders, err := OpenWriters(path)
+	if err != nil || len(holders) == 0 {
+		return nil
+	}
+	names := make([]string, len(holders))
+	for i, holder := range holders {
+		names[i] = holder.String()
+	}
+	return fmt.Errorf("%w: %s", ErrFileBusy, strings.Join(names, ", "))
+}
+
+// largest file ValidatePath accepts, 0 for no limit
+var maxFileSize int64
+
+// sets the largest file analysed or wiped ([filter] max_size_mb, --max-file-size)
+func SetMaxFileSize(bytes int64) {
+	maxFileSize = bytes
+}
+
+func fileKind(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "block device"
+	case mode&os.ModeIrregular != 0:
+		return "irregular file"
+	}
+	return "special file"
 }
 
 // temporary file for processing
@@ -130,14 +328,14 @@ func RemoveFile(path string) error {
 	return os.Remove(path)
 }
 
-// checks if two files have the same content using SHA-256
+// checks if two files have the same content using the copy hash
 func verifyFileIntegrity(file1, file2 string) error {
-	hash1, err := calculateSHA256(file1)
+	hash1, err := fileDigest(file1, newCopyHash())
 	if err != nil {
 		return err
 	}
 
-	hash2, err := calculateSHA256(file2)
+	hash2, err := fileDigest(file2, newCopyHash())
 	if err != nil {
 		return err
 	}
@@ -149,20 +347,60 @@ func verifyFileIntegrity(file1, file2 string) error {
 	return nil
 }
 
+// hex SHA-256 of a file's contents
+func FileSHA256(path string) (string, error) {
+	return calculateSHA256(path)
+}
+
 // computes the SHA-256 hash of a file
 func calculateSHA256(filePath string) (string, error) {
+	return fileDigest(filePath, sha256.New())
+}
+
+// hex digest of a file's contents under sum
+func fileDigest(filePath string, sum hash.Hash) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file for hashing: %w", err)
 	}
 	defer file.Close()
 
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
+	if _, err := io.Copy(sum, file); err != nil {
 		return "", fmt.Errorf("failed to calculate file hash: %w", err)
 	}
 
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// ╭─ COPY HASH ─────────────────────────────────╮
+
+// hashes SafeCopy can check copies with; attestations and manifests always
+// use SHA-256
+const (
+	CopyHashSHA256 = "sha256"
+	CopyHashXXHash = "xxhash" // XXH64: catches corruption, not tampering, at a fraction of the cost
+)
+
+var copyHash = CopyHashSHA256
+
+// picks the hash SafeCopy checks copies with ([wipe] copy_hash); "" for SHA-256
+func SetCopyHash(name string) error {
+	switch name {
+	case "":
+		copyHash = CopyHashSHA256
+	case CopyHashSHA256, CopyHashXXHash:
+		copyHash = name
+	default:
+		return fmt.Errorf("unknown copy hash %q (sha256 or xxhash)", name)
+	}
+	return nil
+}
+
+func newCopyHash() hash.Hash {
+	if copyHash == CopyHashXXHash {
+		return newXXH64()
+	}
+	return sha256.New()
 }
 
 // file info, following symlinks
@@ -175,8 +413,20 @@ func ListDirectory(path string) ([]os.DirEntry, error) {
 	return os.ReadDir(path)
 }
 
-// metadata keys match, ignoring case and common variations
+// the field of an archive member's key ("src/main.c: ModifyDate" ->
+// "ModifyDate"); other keys are returned as they are
+func MemberField(key string) string {
+	if i := strings.LastIndex(key, ": "); i >= 0 {
+		return key[i+2:]
+	}
+	return key
+}
+
+// metadata keys match, ignoring case and common variations; archive
+// members' keys by their field
 func KeysMatch(key1, key2 string) bool {
+	key1, key2 = MemberField(key1), MemberField(key2)
+
 	// direct match
 	if strings.EqualFold(key1, key2) {
 		return true
@@ -184,7 +434,7 @@ func KeysMatch(key1, key2 string) bool {
 
 	// common variations (Author/Creator/Artist)
 	authorVariations := map[string]bool{
-		"author": true, "creator": true, "artist": true, "by": true,
+		"author": true, "creator": true, "artist": true, "by": true, "username": true,
 	}
 
 	key1Lower := strings.ToLower(key1)
@@ -205,12 +455,39 @@ func KeysMatch(key1, key2 string) bool {
 
 	// date variations
 	dateVariations := map[string]bool{
-		"date": true, "created": true, "createdate": true, "when": true,
+		"date": true, "created": true, "createdate": true, "contentcreatedate": true, "datecreated": true, "when": true,
 	}
 
 	if dateVariations[key1Lower] && dateVariations[key2Lower] {
 		return true
 	}
 
+	// document properties (OLE SummaryInformation, PDF Info, OOXML core, EPUB)
+	documentVariations := [][2]string{
+		{"organization", "company"}, {"comment", "comments"},
+		{"comment", "subject"}, {"software", "producer"},
+		{"comment", "description"}, {"organization", "publisher"},
+		{"organization", "copyright"}, {"software", "user-agent"},
+	}
+	for _, pair := range documentVariations {
+		if (key1Lower == pair[0] && key2Lower == pair[1]) || (key1Lower == pair[1] && key2Lower == pair[0]) {
+			return true
+		}
+	}
+
 	return false
 }
+
+// 1536 → "1.5 KB"
+func FormatBytes(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
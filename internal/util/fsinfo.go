@@ -0,0 +1,34 @@
+// BYZRA ⸻ internal/util/fsinfo.go
+// filesystem classification shared across platforms
+
+package util
+
+import "slices"
+
+// filesystems backed by remote or userspace storage
+var networkFilesystems = []string{"nfs", "smb", "cifs", "smb2", "fuse", "ceph", "afs", "9p"}
+
+// is path on NFS/SMB/FUSE or similar?
+// inotify misses remote changes there, and overwrites may not reach the disk
+func IsNetworkFilesystem(path string) bool {
+	fsType, err := FilesystemType(path)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(networkFilesystems, fsType)
+}
+
+// filesystems that write changed blocks somewhere new: copy-on-write
+// (btrfs, ZFS, bcachefs), log-structured (f2fs, NILFS) and overlays, which
+// copy a lower-layer file up before changing it
+var copyOnWriteFilesystems = []string{"btrfs", "zfs", "bcachefs", "f2fs", "nilfs2", "overlay"}
+
+// name of path's filesystem when it is one where overwriting a file leaves
+// the old blocks (and snapshots) intact, "" otherwise
+func CopyOnWriteFilesystem(path string) string {
+	fsType, err := FilesystemType(path)
+	if err != nil || !slices.Contains(copyOnWriteFilesystems, fsType) {
+		return ""
+	}
+	return fsType
+}
@@ -0,0 +1,49 @@
+// BYZRA ⸻ internal/util/fsinfo_linux.go
+// filesystem type detection via statfs
+
+//go:build linux
+
+package util
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// statfs f_type magic numbers of interest
+var filesystemMagic = map[int64]string{
+	0x6969:     "nfs",
+	0x517B:     "smb",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x65735546: "fuse",
+	0x00C36400: "ceph",
+	0x5346414F: "afs",
+	0x01021997: "9p",
+	0xEF53:     "ext4",
+	0x58465342: "xfs",
+	0x9123683E: "btrfs",
+	0x2FC12FC1: "zfs",
+	0x01021994: "tmpfs",
+	0x4D44:     "vfat",
+	0x5346544E: "ntfs",
+	0x2011BAB0: "exfat",
+	0x794C7630: "overlay",
+	0xF2F52010: "f2fs",
+	0xCA451A4E: "bcachefs",
+	0x3434:     "nilfs2",
+}
+
+// name of the filesystem holding path ("unknown" if unrecognised)
+func FilesystemType(path string) (string, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return "", fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+
+	if name, ok := filesystemMagic[int64(st.Type)]; ok {
+		return name, nil
+	}
+	return "unknown", nil
+}
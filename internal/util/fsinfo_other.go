@@ -0,0 +1,11 @@
+// BYZRA ⸻ internal/util/fsinfo_other.go
+// filesystem type detection is only implemented on Linux
+
+//go:build !linux
+
+package util
+
+// name of the filesystem holding path; always "unknown" here
+func FilesystemType(path string) (string, error) {
+	return "unknown", nil
+}
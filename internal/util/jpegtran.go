@@ -0,0 +1,46 @@
+// BYZRA ⸻ internal/util/jpegtran.go
+// jpegtran wrapper for lossless JPEG transforms
+
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// error jpegtran reports when -perfect cannot be honoured
+var errImperfect = errors.New("transform is not perfect")
+
+// applies a lossless transform ("-rotate 90", "-flip horizontal", ...) from
+// src to dst, keeping every marker; edge blocks that do not fill a whole MCU
+// cannot be moved losslessly and are trimmed rather than left garbled
+func JpegtranTransform(src, dst string, transform []string) (trimmed bool, err error) {
+	err = jpegtran(src, dst, append([]string{"-perfect"}, transform...))
+	if errors.Is(err, errImperfect) {
+		return true, jpegtran(src, dst, append([]string{"-trim"}, transform...))
+	}
+	return false, err
+}
+
+func jpegtran(src, dst string, transform []string) error {
+	args := append([]string{"-copy", "all"}, transform...)
+	args = append(args, "-outfile", dst, src)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("jpegtran", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return ToolError("jpegtran", err)
+		}
+		message := strings.TrimSpace(stderr.String())
+		if strings.Contains(message, "perfect") {
+			return errImperfect
+		}
+		return fmt.Errorf("jpegtran failed: %s", message)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// BYZRA ⸻ internal/util/leaks.go
+// value-level detection of host and user identifiers
+
+package util
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// kinds of identifiers found inside metadata values
+const (
+	LeakUsername     = "username"
+	LeakHostname     = "hostname"
+	LeakMACAddress   = "mac-address"
+	LeakMachineID    = "machine-id"
+	LeakEmail        = "email"
+	LeakSerialNumber = "serial-number"
+	LeakPGPKey       = "pgp-key"
+)
+
+// severity levels for findings
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+)
+
+var (
+	// /home/<user>/, /Users/<user>/, C:\Users\<user>\
+	homePathPattern = regexp.MustCompile(`(?i)(?:/home/|/var/home/|/Users/|[a-z]:\\Users\\|[a-z]:\\Documents and Settings\\)([a-z0-9._-]+)`)
+
+	// \\HOST\share
+	uncPathPattern = regexp.MustCompile(`\\\\([A-Za-z0-9-]{2,})\\`)
+
+	// names on local-only DNS suffixes
+	localHostPattern = regexp.MustCompile(`(?i)\b[a-z0-9-]+\.(?:local|lan|home|internal|corp|localdomain)\b`)
+
+	macPattern = regexp.MustCompile(`\b[0-9A-Fa-f]{2}(?:[:-][0-9A-Fa-f]{2}){5}\b`)
+
+	// systemd/dbus machine-id format
+	machineIDPattern = regexp.MustCompile(`\b[0-9a-f]{32}\b`)
+
+	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)
+)
+
+// home directory names that don't belong to a person
+var sharedHomes = map[string]bool{
+	"shared": true, "public": true, "default": true, "all users": true,
+}
+
+// identifiers of the machine running caligra, matched as whole names
+var localIdentifiers = loadLocalIdentifiers()
+
+func loadLocalIdentifiers() map[string]string {
+	ids := make(map[string]string)
+
+	if host, err := os.Hostname(); err == nil && len(host) >= 4 && host != "localhost" {
+		ids[strings.ToLower(host)] = LeakHostname
+	}
+
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				ids[strings.ToLower(id)] = LeakMachineID
+			}
+		}
+	}
+
+	return ids
+}
+
+// kind of host/user identifier a metadata value contains ("" if none)
+func DetectValueLeak(value string) string {
+	if value == "" {
+		return ""
+	}
+	lower := strings.ToLower(value)
+
+	// this machine's own identifiers are the strongest signal
+	for id, kind := range localIdentifiers {
+		if containsWord(lower, id) {
+			return kind
+		}
+	}
+
+	if match := homePathPattern.FindStringSubmatch(value); match != nil && !sharedHomes[strings.ToLower(match[1])] {
+		return LeakUsername
+	}
+
+	if macPattern.MatchString(value) {
+		return LeakMACAddress
+	}
+
+	if emailPattern.MatchString(value) {
+		return LeakEmail
+	}
+
+	if uncPathPattern.MatchString(value) || localHostPattern.MatchString(value) {
+		return LeakHostname
+	}
+
+	if machineIDPattern.MatchString(value) {
+		return LeakMachineID
+	}
+
+	return ""
+}
+
+// does s hold word as a whole name, not as part of a longer one? Letters,
+// digits, "-" and "_" continue a name, so host "studio" is not found in
+// "studios" or "studio-2" but is in "studio.local"
+func containsWord(s, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(s[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		if (i == 0 || !isNameByte(s[i-1])) && (end == len(s) || !isNameByte(s[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// how bad a leak kind is: device serials tie a file to one physical object,
+// signing keys to one person
+func LeakSeverity(kind string) string {
+	switch kind {
+	case LeakSerialNumber, LeakMachineID, LeakPGPKey:
+		return SeverityCritical
+	default:
+		return SeverityHigh
+	}
+}
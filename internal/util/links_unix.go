@@ -0,0 +1,26 @@
+// BYZRA ⸻ internal/util/links_unix.go
+// hard link counts from stat
+
+//go:build !windows
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// names the file at path has on its filesystem; above 1, other paths share
+// its content
+func LinkCount(path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat file for link count: %w", err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 1, nil
+	}
+	return int(stat.Nlink), nil
+}
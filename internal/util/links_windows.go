@@ -0,0 +1,29 @@
+// BYZRA ⸻ internal/util/links_windows.go
+// hard link counts from the file's handle information
+
+//go:build windows
+
+package util
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// names the file at path has on its volume; above 1, other paths share
+// its content
+func LinkCount(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file for link count: %w", err)
+	}
+	defer file.Close()
+
+	var info windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(windows.Handle(file.Fd()), &info); err != nil {
+		return 0, fmt.Errorf("failed to read link count: %w", err)
+	}
+	return int(info.NumberOfLinks), nil
+}
@@ -0,0 +1,76 @@
+// BYZRA ⸻ internal/util/openfiles_linux.go
+// processes writing a file, found by scanning /proc
+
+//go:build linux
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// processes other than this one that have path open for writing; processes
+// of other users are only visible to root
+func OpenWriters(path string) ([]FileHolder, error) {
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	self := os.Getpid()
+
+	var holders []FileHolder
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		procDir := filepath.Join("/proc", proc.Name())
+		fds, err := os.ReadDir(filepath.Join(procDir, "fd"))
+		if err != nil {
+			continue // exited, or not ours to inspect
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(procDir, "fd", fd.Name()))
+			if err != nil || link != target || !openForWriting(procDir, fd.Name()) {
+				continue
+			}
+			holders = append(holders, FileHolder{PID: pid, Command: processName(procDir)})
+			break
+		}
+	}
+	return holders, nil
+}
+
+// the access mode in /proc/<pid>/fdinfo/<fd> is O_WRONLY or O_RDWR
+func openForWriting(procDir, fd string) bool {
+	data, err := os.ReadFile(filepath.Join(procDir, "fdinfo", fd))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "flags:"); ok {
+			flags, err := strconv.ParseUint(strings.TrimSpace(value), 8, 64)
+			return err == nil && flags&uint64(os.O_WRONLY|os.O_RDWR) != 0
+		}
+	}
+	return false
+}
+
+func processName(procDir string) string {
+	data, err := os.ReadFile(filepath.Join(procDir, "comm"))
+	if err != nil {
+		return "?"
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,11 @@
+// BYZRA ⸻ internal/util/openfiles_other.go
+// open-file detection is only implemented on Linux
+
+//go:build !linux
+
+package util
+
+// processes writing path; always none here
+func OpenWriters(path string) ([]FileHolder, error) {
+	return nil, nil
+}
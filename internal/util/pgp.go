@@ -0,0 +1,345 @@
+// BYZRA ⸻ internal/util/pgp.go
+// OpenPGP key IDs, signatures and signer identities in values and text
+
+package util
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// what an armored block carries
+const (
+	PGPSignature  = "signature"
+	PGPPublicKey  = "public key"
+	PGPPrivateKey = "private key"
+	PGPMessage    = "encrypted message"
+)
+
+// one ASCII-armored block and the identities inside it
+type PGPBlock struct {
+	Kind    string
+	KeyIDs  []string // 16 hex digits, upper case
+	UserIDs []string // "Name <email>" from keys and signer subpackets
+}
+
+var (
+	armorPattern = regexp.MustCompile(`-----BEGIN PGP ([A-Z ]+)-----\r?\n`)
+
+	// 0x1234ABCD, 0x1234567890ABCDEF
+	pgpKeyIDPattern = regexp.MustCompile(`(?i)\b0x(?:[0-9a-f]{8}){1,2}\b`)
+
+	// fingerprints as gpg prints them, in groups of four
+	pgpFingerprintPattern = regexp.MustCompile(`(?i)\b[0-9a-f]{4}(?:  ?[0-9a-f]{4}){9}\b`)
+
+	// bare hex of key ID, v4 or v6 fingerprint length
+	pgpHexPattern = regexp.MustCompile(`(?i)^(?:0x)?(?:[0-9a-f]{8}|[0-9a-f]{16}|[0-9a-f]{40}|[0-9a-f]{64})$`)
+
+	signedByPattern = regexp.MustCompile(`(?i)\b(?:signed[- ]by|good signature from|gpg: signature made)\b`)
+)
+
+// tag names that only ever hold signing details
+var pgpKeyHints = []string{"pgp", "gpg", "openpgp", "signer", "signedby"}
+
+// tag names that hold signing details when the value looks like a key
+var pgpKeyLooseHints = []string{"signature", "keyid", "fingerprint"}
+
+// does this tag/value pair carry an OpenPGP key ID, signature or signer?
+func IsPGPIdentifier(key, value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false
+	}
+
+	if strings.Contains(value, "-----BEGIN PGP") || pgpKeyIDPattern.MatchString(value) ||
+		pgpFingerprintPattern.MatchString(value) || signedByPattern.MatchString(value) {
+		return true
+	}
+
+	lowerKey := strings.ToLower(key)
+	for _, hint := range pgpKeyHints {
+		if strings.Contains(lowerKey, hint) {
+			return true
+		}
+	}
+	for _, hint := range pgpKeyLooseHints {
+		if strings.Contains(lowerKey, hint) && pgpHexPattern.MatchString(strings.ReplaceAll(value, " ", "")) {
+			return true
+		}
+	}
+	return false
+}
+
+// armored blocks in text, with the key IDs and user IDs their packets name;
+// clearsigned messages show up as their signature block
+func FindPGPBlocks(text string) []PGPBlock {
+	var blocks []PGPBlock
+	for _, loc := range armorPattern.FindAllStringSubmatchIndex(text, -1) {
+		label := text[loc[2]:loc[3]]
+		end := strings.Index(text[loc[1]:], "-----END PGP "+label+"-----")
+		if end < 0 {
+			continue
+		}
+
+		var kind string
+		switch label {
+		case "SIGNATURE":
+			kind = PGPSignature
+		case "PUBLIC KEY BLOCK":
+			kind = PGPPublicKey
+		case "PRIVATE KEY BLOCK":
+			kind = PGPPrivateKey
+		case "MESSAGE":
+			kind = PGPMessage
+		default:
+			continue // "SIGNED MESSAGE" ends where its signature block begins
+		}
+
+		block := PGPBlock{Kind: kind}
+		if data, ok := dearmor(text[loc[1] : loc[1]+end]); ok {
+			block.readPackets(data)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// short description: "key 0x…, Jane Doe <jane@example.com>"
+func (b PGPBlock) Describe() string {
+	var parts []string
+	for _, id := range b.KeyIDs {
+		parts = append(parts, "key 0x"+id)
+	}
+	parts = append(parts, b.UserIDs...)
+	if len(parts) == 0 {
+		return "no readable key ID"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// base64 body of an armored block, skipping armor headers and the checksum
+func dearmor(body string) ([]byte, bool) {
+	lines := strings.Split(strings.ReplaceAll(body, "\r", ""), "\n")
+
+	// "Version: ...", "Hash: ..." until the blank line
+	if blank := slices.Index(lines, ""); blank >= 0 && blank < len(lines)-1 {
+		headers := true
+		for _, line := range lines[:blank] {
+			if !strings.Contains(line, ": ") {
+				headers = false
+			}
+		}
+		if headers {
+			lines = lines[blank+1:]
+		}
+	}
+
+	var sb strings.Builder
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "=") && len(line) == 5 {
+			break
+		}
+		sb.WriteString(line)
+	}
+	data, err := base64.StdEncoding.DecodeString(sb.String())
+	return data, err == nil
+}
+
+// ╭─ PACKETS ───────────────────────────────────╮
+
+// walks the packet stream, collecting issuers, key IDs, recipients and user IDs
+func (b *PGPBlock) readPackets(data []byte) {
+	for len(data) > 0 {
+		tag, body, rest, ok := nextPacket(data)
+		if !ok {
+			return
+		}
+		switch tag {
+		case 1: // public-key encrypted session key: the recipient
+			if len(body) >= 9 && body[0] == 3 {
+				b.addKeyID(body[1:9])
+			}
+		case 2:
+			b.readSignature(body)
+		case 6, 14: // public key, public subkey
+			b.readPublicKey(body)
+		case 13:
+			b.addUserID(string(body))
+		}
+		data = rest
+	}
+}
+
+// splits off one packet; partial lengths (streamed data) end the walk
+func nextPacket(data []byte) (tag int, body, rest []byte, ok bool) {
+	if len(data) < 2 || data[0]&0x80 == 0 {
+		return 0, nil, nil, false
+	}
+
+	var length, header int
+	if data[0]&0x40 != 0 {
+		tag = int(data[0] & 0x3f)
+		switch first := int(data[1]); {
+		case first < 192:
+			length, header = first, 2
+		case first < 224:
+			if len(data) < 3 {
+				return 0, nil, nil, false
+			}
+			length, header = (first-192)<<8+int(data[2])+192, 3
+		case first == 255:
+			if len(data) < 6 {
+				return 0, nil, nil, false
+			}
+			length, header = int(binary.BigEndian.Uint32(data[2:6])), 6
+		default:
+			return 0, nil, nil, false
+		}
+	} else {
+		tag = int(data[0]>>2) & 0x0f
+		switch data[0] & 0x03 {
+		case 0:
+			length, header = int(data[1]), 2
+		case 1:
+			if len(data) < 3 {
+				return 0, nil, nil, false
+			}
+			length, header = int(binary.BigEndian.Uint16(data[1:3])), 3
+		case 2:
+			if len(data) < 5 {
+				return 0, nil, nil, false
+			}
+			length, header = int(binary.BigEndian.Uint32(data[1:5])), 5
+		default:
+			length, header = len(data)-1, 1
+		}
+	}
+
+	if length < 0 || header+length > len(data) {
+		return 0, nil, nil, false
+	}
+	return tag, data[header : header+length], data[header+length:], true
+}
+
+// v3 signatures carry the issuer inline, v4 and v6 in subpackets
+func (b *PGPBlock) readSignature(body []byte) {
+	if len(body) < 1 {
+		return
+	}
+	switch body[0] {
+	case 3:
+		if len(body) >= 15 {
+			b.addKeyID(body[7:15])
+		}
+	case 4, 6:
+		if len(body) < 4 {
+			return
+		}
+		areas := body[4:]
+		for range 2 { // hashed, then unhashed
+			var size int
+			if body[0] == 4 {
+				if len(areas) < 2 {
+					return
+				}
+				size, areas = int(binary.BigEndian.Uint16(areas)), areas[2:]
+			} else {
+				if len(areas) < 4 {
+					return
+				}
+				size, areas = int(binary.BigEndian.Uint32(areas)), areas[4:]
+			}
+			if size > len(areas) {
+				return
+			}
+			b.readSubpackets(areas[:size])
+			areas = areas[size:]
+		}
+	}
+}
+
+func (b *PGPBlock) readSubpackets(data []byte) {
+	for len(data) > 0 {
+		var length, header int
+		switch first := int(data[0]); {
+		case first < 192:
+			length, header = first, 1
+		case first < 255:
+			if len(data) < 2 {
+				return
+			}
+			length, header = (first-192)<<8+int(data[1])+192, 2
+		default:
+			if len(data) < 5 {
+				return
+			}
+			length, header = int(binary.BigEndian.Uint32(data[1:5])), 5
+		}
+		if length < 1 || header+length > len(data) {
+			return
+		}
+
+		kind, value := data[header]&0x7f, data[header+1:header+length]
+		switch kind {
+		case 16: // issuer key ID
+			if len(value) == 8 {
+				b.addKeyID(value)
+			}
+		case 33: // issuer fingerprint: v4 keys are named by its tail, v6 by its head
+			if len(value) == 21 {
+				b.addKeyID(value[13:])
+			} else if len(value) == 33 {
+				b.addKeyID(value[1:9])
+			}
+		case 28: // signer's user ID
+			b.addUserID(string(value))
+		}
+		data = data[header+length:]
+	}
+}
+
+// key IDs come from the fingerprint of the key material itself
+func (b *PGPBlock) readPublicKey(body []byte) {
+	if len(body) < 1 {
+		return
+	}
+	switch body[0] {
+	case 4:
+		h := sha1.New()
+		h.Write([]byte{0x99, byte(len(body) >> 8), byte(len(body))})
+		h.Write(body)
+		b.addKeyID(h.Sum(nil)[12:])
+	case 6:
+		h := sha256.New()
+		size := make([]byte, 4)
+		binary.BigEndian.PutUint32(size, uint32(len(body)))
+		h.Write([]byte{0x9b})
+		h.Write(size)
+		h.Write(body)
+		b.addKeyID(h.Sum(nil)[:8])
+	}
+}
+
+func (b *PGPBlock) addKeyID(id []byte) {
+	hex := fmt.Sprintf("%X", id)
+	if strings.Trim(hex, "0") == "" { // wildcard recipient
+		return
+	}
+	if !slices.Contains(b.KeyIDs, hex) {
+		b.KeyIDs = append(b.KeyIDs, hex)
+	}
+}
+
+func (b *PGPBlock) addUserID(id string) {
+	id = strings.TrimSpace(id)
+	if id != "" && !slices.Contains(b.UserIDs, id) {
+		b.UserIDs = append(b.UserIDs, id)
+	}
+}
@@ -0,0 +1,161 @@
+// BYZRA ⸻ internal/util/pii.go
+// personal data in document bodies
+
+package util
+
+import (
+	"math/big"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// kinds of personal data found in content
+const (
+	PIIEmail = "email"
+	PIIPhone = "phone"
+	PIIIBAN  = "iban"
+	PIIIP    = "ip-address"
+)
+
+// one piece of personal data in a text body
+type PIIMatch struct {
+	Line  int // 1-based
+	Kind  string
+	Value string
+	start int
+	end   int
+}
+
+var (
+	phonePattern = regexp.MustCompile(`(?:\+|\b0|\()[\d\s().-]{7,20}\d\b`)
+	ibanPattern  = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,4})?\b`)
+	ipv4Pattern  = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern  = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+)
+
+// finds emails, phone numbers, IBANs and IP addresses, line by line
+func ScanPII(text string) []PIIMatch {
+	var matches []PIIMatch
+	for i, line := range strings.Split(text, "\n") {
+		matches = append(matches, scanLine(line, i+1)...)
+	}
+	return matches
+}
+
+// replaces personal data with [REDACTED-<KIND>] markers; returns the count
+func RedactPII(text string) (string, int) {
+	lines := strings.Split(text, "\n")
+	count := 0
+
+	for i, line := range lines {
+		found := scanLine(line, i+1)
+		if len(found) == 0 {
+			continue
+		}
+
+		// replace right to left so offsets stay valid
+		sort.Slice(found, func(a, b int) bool { return found[a].start > found[b].start })
+		for _, match := range found {
+			marker := "[REDACTED-" + strings.ToUpper(match.Kind) + "]"
+			line = line[:match.start] + marker + line[match.end:]
+			count++
+		}
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n"), count
+}
+
+// scans one line; overlapping candidates keep the first kind that claimed the span
+func scanLine(line string, lineNo int) []PIIMatch {
+	var matches []PIIMatch
+	claimed := func(start, end int) bool {
+		for _, m := range matches {
+			if start < m.end && end > m.start {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(kind string, loc []int) {
+		if !claimed(loc[0], loc[1]) {
+			matches = append(matches, PIIMatch{
+				Line: lineNo, Kind: kind, Value: line[loc[0]:loc[1]], start: loc[0], end: loc[1],
+			})
+		}
+	}
+
+	for _, loc := range emailPattern.FindAllStringIndex(line, -1) {
+		add(PIIEmail, loc)
+	}
+	for _, loc := range ibanPattern.FindAllStringIndex(line, -1) {
+		if validIBAN(line[loc[0]:loc[1]]) {
+			add(PIIIBAN, loc)
+		}
+	}
+	for _, loc := range ipv4Pattern.FindAllStringIndex(line, -1) {
+		if net.ParseIP(line[loc[0]:loc[1]]) != nil {
+			add(PIIIP, loc)
+		}
+	}
+	for _, loc := range ipv6Pattern.FindAllStringIndex(line, -1) {
+		candidate := line[loc[0]:loc[1]]
+		if strings.Count(candidate, ":") >= 2 && net.ParseIP(candidate) != nil && strings.ContainsAny(candidate, "0123456789abcdefABCDEF") {
+			add(PIIIP, loc)
+		}
+	}
+	for _, loc := range phonePattern.FindAllStringIndex(line, -1) {
+		if validPhone(line[loc[0]:loc[1]]) {
+			add(PIIPhone, loc)
+		}
+	}
+
+	sort.Slice(matches, func(a, b int) bool { return matches[a].start < matches[b].start })
+	return matches
+}
+
+// 8-15 digits, and not a date like 2024-01-02
+func validPhone(candidate string) bool {
+	digits := 0
+	for _, r := range candidate {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits < 8 || digits > 15 {
+		return false
+	}
+	return !datePattern.MatchString(candidate)
+}
+
+var datePattern = regexp.MustCompile(`^\(?\d{4}[-./]\d{2}[-./]\d{2}`)
+
+// ISO 13616 mod-97 check
+func validIBAN(candidate string) bool {
+	iban := strings.ReplaceAll(candidate, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	rearranged := iban[4:] + iban[:4]
+	var numeric strings.Builder
+	for _, r := range rearranged {
+		switch {
+		case r >= '0' && r <= '9':
+			numeric.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			numeric.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+
+	n, ok := new(big.Int).SetString(numeric.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
@@ -0,0 +1,205 @@
+// BYZRA ⸻ internal/util/progress.go
+// nested progress phases with step results streamed as they happen
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+)
+
+// receives progress as work runs; phases nest (a wipe analyses the file,
+// then strips it, ...) and steps report results inside the current one.
+// the caller of the outermost phase reports its error itself
+type Reporter interface {
+	Begin(label string)
+	Step(message string)
+	End(label string, err error)
+}
+
+var (
+	reporterMu sync.Mutex
+	reporter   Reporter // nil picks one from the output mode
+	spinnerOut = &SpinnerReporter{out: os.Stdout}
+	linesOut   = &LineReporter{out: os.Stdout}
+)
+
+// discards all progress; for the daemon and other unattended callers
+var Silent Reporter = silentReporter{}
+
+// routes progress to r (nil restores the default) and returns the previous one
+func SetReporter(r Reporter) Reporter {
+	reporterMu.Lock()
+	defer reporterMu.Unlock()
+	previous := reporter
+	reporter = r
+	return previous
+}
+
+func currentReporter() Reporter {
+	reporterMu.Lock()
+	defer reporterMu.Unlock()
+	if reporter != nil {
+		return reporter
+	}
+	if plain {
+		return linesOut
+	}
+	return spinnerOut
+}
+
+// runs fn as a phase named label
+func Track(label string, fn func() error) error {
+	r := currentReporter()
+	r.Begin(label)
+	err := fn()
+	r.End(label, err)
+	return err
+}
+
+// reports a finished step of the current phase
+func Step(message string) {
+	currentReporter().Step(message)
+}
+
+// ╭─ TERMINAL ──────────────────────────────────╮
+
+// one spinner line naming the open phases ("Removing metadata › Reading
+// tags"), with step results printed above it
+type SpinnerReporter struct {
+	out    io.Writer
+	mu     sync.Mutex
+	phases []string
+	frame  int
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func (s *SpinnerReporter) Begin(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.phases = append(s.phases, label)
+	if len(s.phases) == 1 {
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.spin(s.stop, s.done)
+	}
+	s.draw()
+}
+
+func (s *SpinnerReporter) Step(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.erase()
+	fmt.Fprintln(s.out, s.indent()+SEC.Render("✓ ")+SUB.Render(message))
+	s.draw()
+}
+
+func (s *SpinnerReporter) End(label string, err error) {
+	s.mu.Lock()
+	if err != nil && len(s.phases) > 1 {
+		s.erase()
+		fmt.Fprintln(s.out, s.indent()+BRH.Render("✗ "+label+": "+err.Error()))
+	}
+	if len(s.phases) > 0 {
+		s.phases = s.phases[:len(s.phases)-1]
+	}
+	if len(s.phases) > 0 {
+		s.draw()
+		s.mu.Unlock()
+		return
+	}
+
+	stop, done := s.stop, s.done
+	s.mu.Unlock()
+
+	// the spinner goroutine takes the lock to draw, so wait for it unlocked
+	close(stop)
+	<-done
+	s.mu.Lock()
+	s.erase()
+	s.mu.Unlock()
+}
+
+func (s *SpinnerReporter) spin(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(spinner.Meter.FPS)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			s.frame = (s.frame + 1) % len(spinner.Meter.Frames)
+			s.draw()
+			s.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// callers hold mu
+func (s *SpinnerReporter) draw() {
+	if len(s.phases) == 0 {
+		return
+	}
+	frame := ORN.Render(spinner.Meter.Frames[s.frame])
+	fmt.Fprintf(s.out, "\r\033[K%s %s", frame, LBL.Render(strings.Join(s.phases, " › ")))
+}
+
+func (s *SpinnerReporter) erase() {
+	fmt.Fprint(s.out, "\r\033[K")
+}
+
+// steps line up under the phase they belong to
+func (s *SpinnerReporter) indent() string {
+	return strings.Repeat("  ", max(len(s.phases), 1))
+}
+
+// ╭─ PLAIN ─────────────────────────────────────╮
+
+// one log line per phase and step, indented by depth; for pipes, cron and
+// --plain
+type LineReporter struct {
+	out   io.Writer
+	mu    sync.Mutex
+	depth int
+}
+
+func (l *LineReporter) Begin(label string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "%s[~] %s\n", strings.Repeat("  ", l.depth), label)
+	l.depth++
+}
+
+func (l *LineReporter) Step(message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "%s✓ %s\n", strings.Repeat("  ", l.depth), message)
+}
+
+func (l *LineReporter) End(label string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.depth > 0 {
+		l.depth--
+	}
+	if err != nil && l.depth > 0 {
+		fmt.Fprintf(l.out, "%s[X] %s: %s\n", strings.Repeat("  ", l.depth), label, err)
+	}
+}
+
+type silentReporter struct{}
+
+func (silentReporter) Begin(string)      {}
+func (silentReporter) Step(string)       {}
+func (silentReporter) End(string, error) {}
@@ -10,39 +10,77 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
+	"sync"
 	"time"
 )
 
+// secure overwrites share one write budget, so several at once still
+// respect the limit
+var overwritePace struct {
+	sync.Mutex
+	bytesPerSec int64
+	next        time.Time // when the budget allows the next write
+}
+
+// caps how fast SecureOverwriteFile writes, in bytes per second across all
+// callers; 0 removes the cap
+func SetOverwriteRate(bytesPerSec int64) {
+	overwritePace.Lock()
+	defer overwritePace.Unlock()
+	overwritePace.bytesPerSec = bytesPerSec
+	overwritePace.next = time.Time{}
+}
+
+// waits until n more bytes fit within the overwrite rate
+func paceOverwrite(n int64) {
+	overwritePace.Lock()
+	rate := overwritePace.bytesPerSec
+	if rate <= 0 {
+		overwritePace.Unlock()
+		return
+	}
+	now := time.Now()
+	start := overwritePace.next
+	if start.Before(now) {
+		start = now
+	}
+	overwritePace.next = start.Add(time.Duration(n * int64(time.Second) / rate))
+	overwritePace.Unlock()
+
+	time.Sleep(time.Until(start))
+}
+
 // overwrites a file multiple times before deletion
-// helps prevent data recovery
+// helps prevent data recovery; only the data extents of a sparse file are
+// overwritten, since its holes have no blocks to recover and filling them
+// would grow it to full size
 func SecureOverwriteFile(path string) error {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
 		return fmt.Errorf("failed to stat file for secure overwrite: %w", err)
 	}
 
-	size := fileInfo.Size()
-
 	file, err := os.OpenFile(path, os.O_WRONLY, 0)
 	if err != nil {
 		return fmt.Errorf("failed to open file for secure overwrite: %w", err)
 	}
 	defer file.Close()
 
+	extents := dataExtents(file, fileInfo.Size())
+
 	// multiple pass overwrite
 	// pass 1: all zeros
-	if err := overwriteWithPattern(file, size, 0x00); err != nil {
+	if err := overwriteWithPattern(file, extents, 0x00); err != nil {
 		return err
 	}
 
 	// pass 2: all ones
-	if err := overwriteWithPattern(file, size, 0xFF); err != nil {
+	if err := overwriteWithPattern(file, extents, 0xFF); err != nil {
 		return err
 	}
 
 	// pass 3: random data
-	if err := overwriteWithRandom(file, size); err != nil {
+	if err := overwriteWithRandom(file, extents); err != nil {
 		return err
 	}
 
@@ -79,29 +117,6 @@ func EnsureSafePermissions(path string) error {
 	return os.Chmod(path, 0600)
 }
 
-// verifies the current user owns the file
-func CheckFileOwnership(path string) error {
-	info, err := os.Stat(path)
-	if err != nil {
-		return fmt.Errorf("failed to stat file for ownership check: %w", err)
-	}
-
-	stat, ok := info.Sys().(*syscall.Stat_t)
-	if !ok {
-		return fmt.Errorf("failed to get file stats")
-	}
-
-	// get current user ID
-	currentUID := os.Getuid()
-
-	// current user file owner check
-	if int(stat.Uid) != currentUID {
-		return fmt.Errorf("file is not owned by current user")
-	}
-
-	return nil
-}
-
 // removes potentially unsafe characters from a filename
 func SanitizeFilename(filename string) string {
 	// remove path elements
@@ -121,66 +136,65 @@ func SanitizeFilename(filename string) string {
 	return filename
 }
 
-// overwrites a file with a specific byte pattern
-func overwriteWithPattern(file *os.File, size int64, pattern byte) error {
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek to beginning: %w", err)
-	}
-
+// overwrites the extents of a file with a specific byte pattern
+func overwriteWithPattern(file *os.File, extents []extent, pattern byte) error {
 	// create a buffer of the pattern (1MB chunks for efficiency)
-	const maxBufSize int64 = 1024 * 1024 // 1MB
-	bufSize := min(size, maxBufSize)
-	buf := make([]byte, bufSize)
+	buf := make([]byte, overwriteBufSize(extents))
 	for i := range buf {
 		buf[i] = pattern
 	}
 
-	// write the pattern repeatedly until the file is covered
-	remaining := size
-	for remaining > 0 {
-		writeSize := min(remaining, bufSize)
+	// write the pattern repeatedly until every extent is covered
+	for _, e := range extents {
+		for offset := e.offset; offset < e.offset+e.length; {
+			writeSize := min(e.offset+e.length-offset, int64(len(buf)))
+			paceOverwrite(writeSize)
 
-		if _, err := file.Write(buf[:writeSize]); err != nil {
-			return fmt.Errorf("failed to write pattern: %w", err)
+			if _, err := file.WriteAt(buf[:writeSize], offset); err != nil {
+				return fmt.Errorf("failed to write pattern: %w", err)
+			}
+			offset += writeSize
 		}
-
-		remaining -= writeSize
 	}
 
 	return nil
 }
 
-// overwrites a file with random data
-func overwriteWithRandom(file *os.File, size int64) error {
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek to beginning: %w", err)
-	}
-
-	// create a buffer for random data (1MB chunks for efficiency)
-	const maxBufSize int64 = 1024 * 1024 // 1MB
-	bufSize := min(size, maxBufSize)
-	buf := make([]byte, bufSize)
-
-	// write random data repeatedly until the file is covered
-	remaining := size
-	for remaining > 0 {
-		writeSize := min(remaining, bufSize)
-
-		// fill buffer with random data
-		if _, err := io.ReadFull(rand.Reader, buf[:writeSize]); err != nil {
-			return fmt.Errorf("failed to generate random data: %w", err)
-		}
-
-		if _, err := file.Write(buf[:writeSize]); err != nil {
-			return fmt.Errorf("failed to write random data: %w", err)
+// overwrites the extents of a file with random data
+func overwriteWithRandom(file *os.File, extents []extent) error {
+	buf := make([]byte, overwriteBufSize(extents))
+
+	// write random data repeatedly until every extent is covered
+	for _, e := range extents {
+		for offset := e.offset; offset < e.offset+e.length; {
+			writeSize := min(e.offset+e.length-offset, int64(len(buf)))
+
+			// fill buffer with random data
+			if _, err := io.ReadFull(rand.Reader, buf[:writeSize]); err != nil {
+				return fmt.Errorf("failed to generate random data: %w", err)
+			}
+
+			paceOverwrite(writeSize)
+			if _, err := file.WriteAt(buf[:writeSize], offset); err != nil {
+				return fmt.Errorf("failed to write random data: %w", err)
+			}
+			offset += writeSize
 		}
-
-		remaining -= writeSize
 	}
 
 	return nil
 }
 
+// 1MB, or less for small files
+func overwriteBufSize(extents []extent) int64 {
+	const maxBufSize int64 = 1024 * 1024
+	var largest int64
+	for _, e := range extents {
+		largest = max(largest, e.length)
+	}
+	return min(largest, maxBufSize)
+}
+
 // random identifier for metadata
 func GenerateRandomID() string {
 	// 8-byte random value
@@ -0,0 +1,35 @@
+// BYZRA ⸻ internal/util/security_unix.go
+// file ownership checks via the owner's uid
+
+//go:build !windows
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// verifies the current user owns the file
+func CheckFileOwnership(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file for ownership check: %w", err)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("failed to get file stats")
+	}
+
+	// get current user ID
+	currentUID := os.Getuid()
+
+	// current user file owner check
+	if int(stat.Uid) != currentUID {
+		return fmt.Errorf("file is not owned by current user")
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+// BYZRA ⸻ internal/util/security_windows.go
+// file ownership checks via the owner SID
+
+//go:build windows
+
+package util
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// verifies the current user owns the file
+func CheckFileOwnership(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to stat file for ownership check: %w", err)
+	}
+
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return fmt.Errorf("failed to get file owner: %w", err)
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return fmt.Errorf("failed to get file owner: %w", err)
+	}
+
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	if !owner.Equals(user.User.Sid) {
+		return fmt.Errorf("file is not owned by current user")
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+// BYZRA ⸻ internal/util/serials.go
+// heuristics for device serial numbers under any tag name
+
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+// serial-like values: one token of letters/digits (dashes allowed), with digits in it
+var serialValuePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9-]{4,30}[A-Z0-9]$`)
+
+// tag name fragments that mark an identifier slot
+var serialKeyHints = []string{
+	"serial", "sn", "id", "number", "no", "unique", "body", "lens", "drone", "aircraft", "camera",
+}
+
+// names that look like identifier slots but hold model/firmware info
+var serialKeyExclusions = []string{
+	"model", "version", "firmware", "make", "format", "date", "time", "count", "index",
+	"idc", "width", "height", "size", "orientation", "iso",
+}
+
+// does this tag/value pair look like a device serial number?
+func IsSerialNumber(key, value string) bool {
+	lowerKey := strings.ToLower(key)
+	value = strings.TrimSpace(value)
+
+	// named serials are serials whatever the value looks like
+	if strings.Contains(lowerKey, "serial") && value != "" && value != "0" {
+		return true
+	}
+
+	for _, exclusion := range serialKeyExclusions {
+		if strings.Contains(lowerKey, exclusion) {
+			return false
+		}
+	}
+
+	hinted := false
+	for _, hint := range serialKeyHints {
+		if strings.HasSuffix(lowerKey, hint) || strings.Contains(lowerKey, hint+"number") ||
+			(len(hint) > 2 && strings.Contains(lowerKey, hint)) {
+			hinted = true
+			break
+		}
+	}
+	if !hinted {
+		return false
+	}
+
+	return looksLikeSerial(value)
+}
+
+// value shape: long digit run, or a mix of letters and digits
+func looksLikeSerial(value string) bool {
+	upper := strings.ToUpper(value)
+	if !serialValuePattern.MatchString(upper) {
+		return false
+	}
+
+	digits, letters := 0, 0
+	for _, r := range upper {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r >= 'A' && r <= 'Z':
+			letters++
+		}
+	}
+
+	if letters == 0 {
+		return digits >= 6
+	}
+	return digits >= 3 && letters >= 1
+}
@@ -0,0 +1,45 @@
+// BYZRA ⸻ internal/util/sparse_linux.go
+// holes in sparse files via SEEK_DATA/SEEK_HOLE
+
+//go:build linux
+
+package util
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// the parts of file holding data, in order; the whole file as one extent
+// where the filesystem cannot tell holes apart. The file offset is left
+// where it was
+func dataExtents(file *os.File, size int64) []extent {
+	whole := []extent{{0, size}}
+	fd := int(file.Fd())
+
+	pos, err := unix.Seek(fd, 0, unix.SEEK_CUR)
+	if err != nil {
+		return whole
+	}
+	defer unix.Seek(fd, pos, unix.SEEK_SET)
+
+	var extents []extent
+	for offset := int64(0); offset < size; {
+		start, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if err == unix.ENXIO {
+			break // nothing but a hole up to the end
+		}
+		if err != nil {
+			return whole
+		}
+		end, err := unix.Seek(fd, start, unix.SEEK_HOLE)
+		if err != nil {
+			return whole
+		}
+		end = min(end, size)
+		extents = append(extents, extent{start, end - start})
+		offset = end
+	}
+	return extents
+}
@@ -0,0 +1,13 @@
+// BYZRA ⸻ internal/util/sparse_other.go
+// hole detection is only implemented on Linux
+
+//go:build !linux
+
+package util
+
+import "os"
+
+// the whole file as one extent
+func dataExtents(file *os.File, size int64) []extent {
+	return []extent{{0, size}}
+}
@@ -0,0 +1,57 @@
+// BYZRA ⸻ internal/util/sqlite.go
+// sqlite3 shell wrapper for rebuilding and checking databases
+
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// rebuilds the database at path with VACUUM: every page is written afresh,
+// so the freelist and the space deleted rows leave inside pages are gone.
+// secure_delete makes SQLite zero what it frees while copying, rather than
+// leave copies of moved rows behind in the new file
+func SQLiteVacuum(path string) error {
+	_, err := sqlite3(path, "PRAGMA secure_delete = ON; VACUUM;")
+	return err
+}
+
+// nil when PRAGMA quick_check finds the database sound
+func SQLiteQuickCheck(path string) error {
+	out, err := sqlite3(path, "PRAGMA quick_check;")
+	if err != nil {
+		return err
+	}
+	if out != "ok" {
+		return fmt.Errorf("sqlite3 quick_check: %s", out)
+	}
+	return nil
+}
+
+func sqlite3(path, sql string) (string, error) {
+	// an absolute path cannot be taken for an option or a file: URI
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sqlite3", "-batch", "-bail", path, sql)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", ToolError("sqlite3", err)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("sqlite3 failed: %s", message)
+		}
+		return "", fmt.Errorf("sqlite3 failed: %w", err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -7,27 +7,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strings"
-	"time"
 
-	"github.com/BurntSushi/toml"
-	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 )
 
-type ColorConfig struct {
-	Colors struct {
-		CHRM string
-		HEAT string
-		HOTP string
-		GUNM string
-		VBLK string
-		CSTL string
-	}
-}
-
 // ╭─ COLOR ROLES ───────────────────────────────╮
 var (
 	CHRM lipgloss.Color
@@ -51,16 +37,66 @@ var (
 	ORN lipgloss.Style
 )
 
+// palette the styles were last built from
+var active Palette
+
+// no spinner, colors or screen clearing; on by default when stdout is not
+// a terminal (pipes, redirects, cron)
+var plain = !term.IsTerminal(os.Stdout.Fd())
+
+// Wiper only clears when asked to (clear_screen in yogra.toml, --clear);
+// scrollback is the user's
+var clearScreen bool
+
 func init() {
-	// load from TOML
-	config := loadColorConfig()
+	// load from yogra.toml
+	config, err := LoadThemeConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using the %s theme\n", err, DefaultTheme)
+	}
+	clearScreen = config.ClearScreen
 
-	CHRM = lipgloss.Color(config.Colors.CHRM)
-	HEAT = lipgloss.Color(config.Colors.HEAT)
-	HOTP = lipgloss.Color(config.Colors.HOTP)
-	GUNM = lipgloss.Color(config.Colors.GUNM)
-	VBLK = lipgloss.Color(config.Colors.VBLK)
-	CSTL = lipgloss.Color(config.Colors.CSTL)
+	palette, err := config.Palette()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using the %s theme\n", err, DefaultTheme)
+		theme, _ := FindTheme(DefaultTheme)
+		palette = theme.Palette
+	}
+	applyPalette(palette)
+}
+
+// forces plain output on or off (--plain)
+func SetPlain(on bool) {
+	plain = on
+	applyPalette(active)
+}
+
+func Plain() bool {
+	return plain
+}
+
+// lets Wiper clear the screen (--clear); plain output still never clears
+func SetClearScreen(on bool) {
+	clearScreen = on
+}
+
+// rebuilds every style from palette; in plain mode they render bare text
+func applyPalette(palette Palette) {
+	active = palette
+	if plain {
+		bare := lipgloss.NewStyle()
+		BRH, BRU, LBL, SUB, NSH, SHE, SEC, NLL, ORN = bare, bare, bare, bare, bare, bare, bare, bare, bare
+		Ornament = "›"
+		Divider = strings.Repeat("─", 48)
+		return
+	}
+
+	CHRM = lipgloss.Color(palette.CHRM)
+	HEAT = lipgloss.Color(palette.HEAT)
+	HOTP = lipgloss.Color(palette.HOTP)
+	GUNM = lipgloss.Color(palette.GUNM)
+	VBLK = lipgloss.Color(palette.VBLK)
+	CSTL = lipgloss.Color(palette.CSTL)
 
 	BRH = lipgloss.NewStyle().Foreground(HOTP).Bold(true)
 	BRU = lipgloss.NewStyle().Foreground(HOTP).Bold(true).Underline(true)
@@ -71,83 +107,17 @@ func init() {
 	SEC = lipgloss.NewStyle().Foreground(CSTL).Bold(true)
 	NLL = lipgloss.NewStyle().Foreground(VBLK).Faint(true)
 	ORN = lipgloss.NewStyle().Foreground(GUNM).Bold(true)
-}
-
-func loadColorConfig() ColorConfig {
-	var config ColorConfig
-
-	paths := []string{
-		"yogra.toml",
-		"data/yogra.toml",
-		filepath.Join(os.Getenv("HOME"), "./caligra/config/yogra.toml"),
-	}
 
-	for _, path := range paths {
-		if _, err := toml.DecodeFile(path, &config); err == nil {
-			return config
-		}
-	}
-
-	// panic or use defaults
-	fmt.Println("Warning: Could not find yogra.toml, using hardcoded defaults")
-
-	// default values
-	config.Colors.CHRM = "#C0C0C0"
-	config.Colors.HEAT = "#FF5C00"
-	config.Colors.HOTP = "#FF007F"
-	config.Colors.GUNM = "#444444"
-	config.Colors.VBLK = "#121212"
-	config.Colors.CSTL = "#88AABB"
-
-	return config
+	Ornament = ORN.Render("›")
+	Divider = SUB.Render(strings.Repeat("─", 48))
 }
 
 // ╭─ ORNAMENT ──────────────────────────────────╮
 var (
-	Ornament = ORN.Render("›") // prefix UX lines
-	Divider  = SUB.Render(strings.Repeat("─", 48))
+	Ornament string // prefix UX lines
+	Divider  string
 )
 
-// ╭─ SPINNER ───────────────────────────────────╮
-func SpinWhile(label string, fn func() (string, error)) (string, error) {
-	s := spinner.New(spinner.WithSpinner(spinner.Meter))
-	ticker := time.NewTicker(s.Spinner.FPS)
-	defer ticker.Stop()
-
-	done := make(chan struct{})
-	result := make(chan struct {
-		out string
-		err error
-	})
-
-	go func() {
-		frame := 0
-		frames := s.Spinner.Frames
-		for {
-			select {
-			case <-ticker.C:
-				fmt.Printf("\r%s %s", ORN.Render(frames[frame]), LBL.Render(label))
-				frame = (frame + 1) % len(frames)
-			case <-done:
-				return
-			}
-		}
-	}()
-
-	go func() {
-		out, err := fn()
-		result <- struct {
-			out string
-			err error
-		}{out, err}
-	}()
-
-	res := <-result
-	close(done)
-	Wiper()
-	return res.out, res.err
-}
-
 func SuccessSymbol() string {
 	return LBL.Render("[✓]")
 }
@@ -166,6 +136,9 @@ func ErrorSymbol() string {
 
 // ╭─ CLEAR ─────────────────────────────────────╮
 func Wiper() {
+	if plain || !clearScreen {
+		return
+	}
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
 		cmd = exec.Command("cmd", "/c", "cls")
@@ -0,0 +1,244 @@
+// BYZRA ⸻ internal/util/theme.go
+// built-in color themes and the yogra.toml that selects one
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// the six color roles every theme fills
+type Palette struct {
+	CHRM string // body text
+	HEAT string // labels, success
+	HOTP string // errors, warnings
+	GUNM string // ornaments, secondary text
+	VBLK string // null values
+	CSTL string // info
+}
+
+type Theme struct {
+	Name        string
+	Description string
+	Palette     Palette
+}
+
+// used when yogra.toml is missing or names no theme
+const DefaultTheme = "byzra"
+
+var themes = []Theme{
+	{"byzra", "the BYZRA Series palette", Palette{
+		CHRM: "#C0C0C0", HEAT: "#FF5C00", HOTP: "#FF007F",
+		GUNM: "#444444", VBLK: "#121212", CSTL: "#88AABB",
+	}},
+	{"high-contrast", "bright colors for low vision and washed-out screens", Palette{
+		CHRM: "#FFFFFF", HEAT: "#FFD700", HOTP: "#FF4040",
+		GUNM: "#B0B0B0", VBLK: "#909090", CSTL: "#00FFFF",
+	}},
+	{"monochrome", "grays only; bold and underline carry the emphasis", Palette{
+		CHRM: "#D0D0D0", HEAT: "#FFFFFF", HOTP: "#FFFFFF",
+		GUNM: "#808080", VBLK: "#5A5A5A", CSTL: "#B0B0B0",
+	}},
+	{"nord", "muted arctic blues", Palette{
+		CHRM: "#D8DEE9", HEAT: "#EBCB8B", HOTP: "#BF616A",
+		GUNM: "#4C566A", VBLK: "#3B4252", CSTL: "#88C0D0",
+	}},
+	{"solarized", "Solarized accents, readable on light and dark terminals", Palette{
+		CHRM: "#93A1A1", HEAT: "#B58900", HOTP: "#DC322F",
+		GUNM: "#586E75", VBLK: "#073642", CSTL: "#268BD2",
+	}},
+}
+
+// yogra.toml: a built-in theme plus optional per-role overrides, and
+// whether commands may clear the screen
+type ThemeConfig struct {
+	Theme       string  `toml:"theme"`
+	ClearScreen bool    `toml:"clear_screen"`
+	Colors      Palette `toml:"colors"`
+
+	Path string `toml:"-"` // file it was read from, "" when none
+}
+
+// built-in themes, the default first
+func Themes() []Theme {
+	return themes
+}
+
+func FindTheme(name string) (Theme, bool) {
+	for _, theme := range themes {
+		if strings.EqualFold(theme.Name, name) {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
+func themeNames() string {
+	names := make([]string, len(themes))
+	for i, theme := range themes {
+		names[i] = theme.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// $XDG_CONFIG_HOME/caligra/yogra.toml, ~/.config when unset
+func ThemePath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" || !filepath.IsAbs(dir) {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(dir, "caligra", "yogra.toml")
+}
+
+// where install.sh used to put it; still read when the XDG file is absent
+func legacyThemePath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra/config/yogra.toml")
+}
+
+// the yogra.toml in use, "" when there is none
+func ThemeConfigFile() string {
+	for _, path := range []string{ThemePath(), legacyThemePath()} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// reads the first yogra.toml found; an empty config when there is none
+func LoadThemeConfig() (*ThemeConfig, error) {
+	path := ThemeConfigFile()
+	if path == "" {
+		return &ThemeConfig{}, nil
+	}
+	config := &ThemeConfig{Path: path}
+	if _, err := toml.DecodeFile(path, config); err != nil {
+		return &ThemeConfig{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// name of the selected theme, DefaultTheme when none is set
+func (c *ThemeConfig) Name() string {
+	if c.Theme == "" {
+		return DefaultTheme
+	}
+	return c.Theme
+}
+
+// does [colors] change any role of the selected theme?
+func (c *ThemeConfig) Customized() bool {
+	theme, ok := FindTheme(c.Name())
+	palette, err := c.Palette()
+	return ok && err == nil && palette != theme.Palette
+}
+
+// the selected theme's palette with [colors] laid over it
+func (c *ThemeConfig) Palette() (Palette, error) {
+	theme, ok := FindTheme(c.Name())
+	if !ok {
+		return Palette{}, fmt.Errorf("unknown theme: %s (built-in: %s)", c.Theme, themeNames())
+	}
+	palette := theme.Palette
+	overlay := func(role *string, value string) {
+		if value != "" {
+			*role = value
+		}
+	}
+	overlay(&palette.CHRM, c.Colors.CHRM)
+	overlay(&palette.HEAT, c.Colors.HEAT)
+	overlay(&palette.HOTP, c.Colors.HOTP)
+	overlay(&palette.GUNM, c.Colors.GUNM)
+	overlay(&palette.VBLK, c.Colors.VBLK)
+	overlay(&palette.CSTL, c.Colors.CSTL)
+	return palette, nil
+}
+
+// selects a built-in theme by writing ThemePath(); an existing file with
+// custom [colors] is kept as yogra.toml.bak, whose path is returned
+func SetTheme(name string) (string, error) {
+	theme, ok := FindTheme(name)
+	if !ok {
+		return "", fmt.Errorf("unknown theme: %s (built-in: %s)", name, themeNames())
+	}
+
+	path := ThemePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	backup := ""
+	current, err := LoadThemeConfig()
+	if err == nil && current.Customized() && current.Path == path {
+		backup = path + ".bak"
+		if err := os.Rename(path, backup); err != nil {
+			return "", err
+		}
+	}
+
+	content := fmt.Sprintf(`# caligra color theme; see "caligra theme list"
+theme = %q
+
+# clear the screen before each command (--clear does it for one run)
+clear_screen = %t
+
+# [colors] overrides single roles on top of the theme:
+# [colors]
+# HOTP = "#FF007F"
+`, theme.Name, current.ClearScreen)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// swatches and sample output in the named theme, or in the configured one
+// when name is ""
+func PreviewTheme(name string) (string, error) {
+	palette := active
+	if name != "" {
+		theme, ok := FindTheme(name)
+		if !ok {
+			return "", fmt.Errorf("unknown theme: %s (built-in: %s)", name, themeNames())
+		}
+		palette = theme.Palette
+	}
+
+	previous := active
+	applyPalette(palette)
+	defer applyPalette(previous)
+
+	var b strings.Builder
+	roles := []struct{ role, color, use string }{
+		{"CHRM", palette.CHRM, "body text"},
+		{"HEAT", palette.HEAT, "labels, success"},
+		{"HOTP", palette.HOTP, "errors, warnings"},
+		{"GUNM", palette.GUNM, "ornaments, secondary text"},
+		{"VBLK", palette.VBLK, "null values"},
+		{"CSTL", palette.CSTL, "info"},
+	}
+	for _, r := range roles {
+		swatch := "████"
+		if !plain {
+			swatch = lipgloss.NewStyle().Foreground(lipgloss.Color(r.color)).Render(swatch)
+		}
+		fmt.Fprintf(&b, "  %s %s %s  %s\n", swatch, NSH.Render(r.role), SUB.Render(r.color), SUB.Render(r.use))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(LBL.Render("[✓] Wipe completed successfully") + "\n")
+	b.WriteString(SEC.Render("[i] Output saved to: photo.volena.jpg") + "\n")
+	b.WriteString(BRH.Render("[!] Found 3 sensitive metadata fields") + "\n")
+	b.WriteString(BRH.Render("[X] Wipe failed: permission denied") + "\n\n")
+	b.WriteString(SHE.Render("Detected Metadata:") + "\n")
+	b.WriteString("  " + Ornament + " " + NSH.Render("GPSLatitude: ") + SUB.Render("38 deg 42' 51.00\" N") + "\n")
+	b.WriteString("  " + Ornament + " " + NSH.Render("Comment: ") + NLL.Render("(empty)") + "\n")
+	b.WriteString(Divider + "\n")
+	return b.String(), nil
+}
@@ -0,0 +1,67 @@
+// BYZRA ⸻ internal/util/tools.go
+// detection of the external programs caligra shells out to
+
+package util
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// an external backend and what was found of it
+type ToolInfo struct {
+	Name    string
+	Purpose string
+	Path    string // empty when not on PATH
+	Version string // first line of its version output
+}
+
+// how long a backend may take to print its version
+const toolVersionTimeout = 5 * time.Second
+
+// backends in the order they matter, with the flag that prints their version
+var tools = []struct {
+	name, purpose string
+	args          []string
+}{
+	{"exiftool", "image, audio and document metadata", []string{"-ver"}},
+	{"ffmpeg", "audio/video remuxing", []string{"-version"}},
+	{"ffprobe", "stream and chapter inspection", []string{"-version"}},
+	{"identify", "ImageMagick image inspection", []string{"-version"}},
+	{"jpegtran", "lossless JPEG rotation", []string{"-version"}},
+	{"sqlite3", "SQLite database rebuilds", []string{"-version"}},
+}
+
+// looks up every backend and asks it for its version
+func DetectTools() []ToolInfo {
+	infos := make([]ToolInfo, 0, len(tools))
+	for _, tool := range tools {
+		info := ToolInfo{Name: tool.name, Purpose: tool.purpose}
+
+		path, err := exec.LookPath(tool.name)
+		if err == nil {
+			info.Path = path
+			info.Version = toolVersion(path, tool.args)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// first line of a backend's version output, "unknown" if it prints none
+func toolVersion(path string, args []string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), toolVersionTimeout)
+	defer cancel()
+
+	// some print their version to stderr, and some exit non-zero after it
+	out, _ := exec.CommandContext(ctx, path, args...).CombinedOutput()
+
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	line, _, _ = strings.Cut(line, " Copyright") // ffmpeg, ImageMagick
+	if line = strings.TrimSpace(line); line == "" {
+		return "unknown"
+	}
+	return line
+}
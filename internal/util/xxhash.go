@@ -0,0 +1,125 @@
+// BYZRA ⸻ internal/util/xxhash.go
+// XXH64, a fast non-cryptographic hash for copy integrity checks
+
+package util
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// streaming XXH64 with seed 0
+type xxh64 struct {
+	v1, v2, v3, v4 uint64
+	total          uint64
+	mem            [32]byte
+	n              int // bytes buffered in mem
+}
+
+func newXXH64() hash.Hash64 {
+	x := &xxh64{}
+	x.Reset()
+	return x
+}
+
+func (x *xxh64) Reset() {
+	var seed uint64
+	x.v1 = seed + xxPrime1 + xxPrime2
+	x.v2 = seed + xxPrime2
+	x.v3 = seed
+	x.v4 = seed - xxPrime1
+	x.total = 0
+	x.n = 0
+}
+
+func (x *xxh64) Size() int      { return 8 }
+func (x *xxh64) BlockSize() int { return 32 }
+
+func (x *xxh64) Write(b []byte) (int, error) {
+	written := len(b)
+	x.total += uint64(written)
+
+	if x.n+len(b) < 32 {
+		x.n += copy(x.mem[x.n:], b)
+		return written, nil
+	}
+	if x.n > 0 {
+		c := copy(x.mem[x.n:], b)
+		b = b[c:]
+		x.stripe(x.mem[:])
+		x.n = 0
+	}
+	for ; len(b) >= 32; b = b[32:] {
+		x.stripe(b)
+	}
+	x.n = copy(x.mem[:], b)
+	return written, nil
+}
+
+func (x *xxh64) stripe(b []byte) {
+	x.v1 = xxRound(x.v1, binary.LittleEndian.Uint64(b[0:]))
+	x.v2 = xxRound(x.v2, binary.LittleEndian.Uint64(b[8:]))
+	x.v3 = xxRound(x.v3, binary.LittleEndian.Uint64(b[16:]))
+	x.v4 = xxRound(x.v4, binary.LittleEndian.Uint64(b[24:]))
+}
+
+func (x *xxh64) Sum64() uint64 {
+	var h uint64
+	if x.total >= 32 {
+		h = bits.RotateLeft64(x.v1, 1) + bits.RotateLeft64(x.v2, 7) +
+			bits.RotateLeft64(x.v3, 12) + bits.RotateLeft64(x.v4, 18)
+		h = xxMerge(h, x.v1)
+		h = xxMerge(h, x.v2)
+		h = xxMerge(h, x.v3)
+		h = xxMerge(h, x.v4)
+	} else {
+		h = xxPrime5 // + seed
+	}
+	h += x.total
+
+	b := x.mem[:x.n]
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func (x *xxh64) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, x.Sum64())
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMerge(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
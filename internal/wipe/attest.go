@@ -0,0 +1,200 @@
+// BYZRA ⸻ internal/wipe/attest.go
+// signed attestation reports of what a wipe did
+
+package wipe
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"caligra/internal/util"
+)
+
+// signed record of a wipe; it names the output only, never the original
+type Attestation struct {
+	Tool      string   `json:"tool"`
+	Created   string   `json:"created"` // UTC, RFC 3339
+	Policy    string   `json:"policy,omitempty"`
+	File      string   `json:"file"` // base name of the output
+	SHA256    string   `json:"sha256"`
+	Size      int64    `json:"size"`
+	Success   bool     `json:"success"`
+	Steps     []string `json:"steps"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+	PublicKey string   `json:"public_key"` // base64 Ed25519
+	Signature string   `json:"signature,omitempty"`
+}
+
+// where the signing key lives
+func AttestationKeyPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".caligra", "keys", "attestation.ed25519")
+}
+
+// report path for an output file
+func AttestationPath(outputPath string) string {
+	return outputPath + ".attestation.json"
+}
+
+// loads the signing key, creating one on first use
+func loadAttestationKey() (ed25519.PrivateKey, error) {
+	path := AttestationKeyPath()
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid attestation key: %s", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read attestation key: %w", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate attestation key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key.Seed())+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to save attestation key: %w", err)
+	}
+	return key, nil
+}
+
+// builds, signs and writes the attestation for a finished wipe
+func WriteAttestation(result *WipeResult, outputPath, policy string) (string, error) {
+	key, err := loadAttestationKey()
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return "", err
+	}
+	sum, err := util.FileSHA256(outputPath)
+	if err != nil {
+		return "", err
+	}
+
+	att := &Attestation{
+		Tool:      "caligra",
+		Created:   time.Now().UTC().Format(time.RFC3339),
+		Policy:    policy,
+		File:      filepath.Base(outputPath),
+		SHA256:    sum,
+		Size:      info.Size(),
+		Success:   result.Success,
+		Steps:     attestationSteps(result),
+		Warnings:  result.Warnings,
+		Errors:    result.WipeErrors,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+
+	payload, err := json.Marshal(att)
+	if err != nil {
+		return "", err
+	}
+	att.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+
+	data, err := json.MarshalIndent(att, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := AttestationPath(outputPath)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write attestation: %w", err)
+	}
+	return path, nil
+}
+
+// what was done, in order, without naming the original
+func attestationSteps(result *WipeResult) []string {
+	steps := []string{fmt.Sprintf("metadata removed (%d sensitive fields found)", len(result.SensitiveData))}
+	if result.RegionsBefore > 0 {
+		steps = append(steps, fmt.Sprintf("excised %d bytes of metadata (%d segments → %d)",
+			result.Excised(), result.RegionsBefore, result.RegionsAfter))
+	}
+	steps = append(steps, result.PolicyNotes...)
+	steps = append(steps, result.Rebuilt...)
+	if len(result.Timestamps) > 0 {
+		steps = append(steps, fmt.Sprintf("normalized %d timestamp tags", len(result.Timestamps)))
+	}
+	if result.Injection != nil && result.Injection.Success {
+		steps = append(steps, "profile metadata injected")
+	}
+	if result.Verification != nil {
+		if result.Verification.Success {
+			steps = append(steps, "verification passed")
+		} else {
+			steps = append(steps, "verification failed")
+		}
+	}
+	if result.Renamed {
+		steps = append(steps, "renamed to a random name")
+	}
+	if result.OriginalOverwritten {
+		steps = append(steps, "original securely overwritten and deleted")
+	} else if result.OriginalDeleted {
+		steps = append(steps, "original deleted without overwriting")
+	}
+	return steps
+}
+
+// checks an attestation's signature and, when the file sits next to it, its hash
+func VerifyAttestation(path string) (*Attestation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var att Attestation
+	if err := json.Unmarshal(data, &att); err != nil {
+		return nil, fmt.Errorf("invalid attestation: %w", err)
+	}
+
+	pub, err := base64.StdEncoding.DecodeString(att.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return &att, fmt.Errorf("invalid public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(att.Signature)
+	if err != nil {
+		return &att, fmt.Errorf("invalid signature encoding")
+	}
+
+	unsigned := att
+	unsigned.Signature = ""
+	payload, err := json.Marshal(&unsigned)
+	if err != nil {
+		return &att, err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), payload, sig) {
+		return &att, fmt.Errorf("signature does not match")
+	}
+
+	filePath := filepath.Join(filepath.Dir(path), att.File)
+	if _, err := os.Stat(filePath); err == nil {
+		sum, err := util.FileSHA256(filePath)
+		if err != nil {
+			return &att, err
+		}
+		if sum != att.SHA256 {
+			return &att, fmt.Errorf("%s has changed since it was attested", att.File)
+		}
+	}
+
+	return &att, nil
+}
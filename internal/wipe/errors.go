@@ -0,0 +1,121 @@
+// BYZRA ⸻ internal/wipe/errors.go
+// typed wipe failures with remediation hints
+
+package wipe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"caligra/internal/config"
+	"caligra/internal/formats"
+	"caligra/internal/util"
+)
+
+// sensitive fields or a broken file were found after wiping
+var ErrVerificationFailed = errors.New("verification failed")
+
+// one failed step of a wipe; errors.Is/As see through to the cause
+// (formats.ErrUnsupportedFormat, util.ErrToolMissing, ...)
+type WipeError struct {
+	Op   string // "analysis", "metadata wipe", "profile injection", ...
+	Path string
+	Err  error
+}
+
+func (e *WipeError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Op, e.Err)
+}
+
+func (e *WipeError) Unwrap() error {
+	return e.Err
+}
+
+// what the user can do about it, "" when there is nothing specific
+func (e *WipeError) Hint() string {
+	return Hint(e.Err)
+}
+
+// remediation for any error from this package, "" when there is none
+func Hint(err error) string {
+	var missing *util.ToolMissingError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &missing):
+		return missing.Hint()
+	case errors.Is(err, formats.ErrUnsupportedFormat):
+		return "supported extensions: " + formats.SupportedList()
+	case errors.Is(err, ErrVerificationFailed):
+		return "run 'caligra analyse' on the output to see what remained, or wipe with a policy that sets reencode"
+	case errors.Is(err, config.ErrProfileSandbox):
+		return "profiles may not use io, os beyond clock/date/time, require or dofile; check with 'caligra config validate'"
+	case errors.Is(err, util.ErrNotRegularFile):
+		return "only regular files can be analysed or wiped; copy the data into a file first"
+	case errors.Is(err, util.ErrFileTooLarge):
+		return "raise [filter] max_size_mb in scroud.toml, or pass --max-file-size"
+	case errors.Is(err, util.ErrFileBusy):
+		return "wait until the program has finished saving and closed the file, then wipe again"
+	case errors.Is(err, util.ErrHardLinked):
+		return "set hardlinks = \"break\" in the policy to give this name its own copy, or remove the other links first"
+	case errors.Is(err, os.ErrPermission):
+		return "check that you can read the file and write to its directory"
+	case errors.Is(err, os.ErrNotExist):
+		return "check the path; the file may have been moved or deleted"
+	}
+	return ""
+}
+
+// records a failed step in both forms: the typed error and the report line
+func (r *WipeResult) fail(op string, err error) {
+	wipeErr := &WipeError{Op: op, Path: r.OriginalPath, Err: err}
+	r.Failures = append(r.Failures, wipeErr)
+	r.WipeErrors = append(r.WipeErrors, "[X] "+strings.ToUpper(op[:1])+op[1:]+" failed: "+err.Error())
+}
+
+// remediation for each failure, without repeats
+func (r *WipeResult) Hints() []string {
+	var hints []string
+	for _, failure := range r.failures() {
+		if hint := failure.Hint(); hint != "" && !slices.Contains(hints, hint) {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// every failure of the wipe joined, including a failed verification; nil on
+// success
+func (r *WipeResult) Err() error {
+	var errs []error
+	for _, failure := range r.failures() {
+		errs = append(errs, failure)
+	}
+	return errors.Join(errs...)
+}
+
+// Err's message on one line, for manifests, journals and logs; "" on success
+func (r *WipeResult) ErrText() string {
+	err := r.Err()
+	if err == nil {
+		return ""
+	}
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
+
+// Failures plus a failed verification, which is reported but not recorded
+// as a step failure
+func (r *WipeResult) failures() []*WipeError {
+	failures := slices.Clone(r.Failures)
+	if v := r.Verification; v != nil && !v.Success {
+		detail := ErrVerificationFailed
+		if len(v.ValidationErrors) > 0 {
+			detail = fmt.Errorf("%w: %s", ErrVerificationFailed, strings.Join(v.ValidationErrors, "; "))
+		}
+		failures = append(failures, &WipeError{Op: "verification", Path: r.OriginalPath, Err: detail})
+	}
+	return failures
+}
@@ -4,6 +4,7 @@
 package wipe
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"strings"
@@ -21,6 +22,9 @@ type ProfileInjectionResult struct {
 	FieldsAdded  []string
 	FieldsFailed []string
 	Profile      map[string]string
+
+	// fields whose values the format cannot hold, not injected
+	Rejected []formats.RejectedField
 }
 
 // applies profile metadata 2 a file
@@ -40,6 +44,9 @@ func InjectProfile(path string, customProfile map[string]string) (*ProfileInject
 	} else {
 		// load profile from config
 		profile, err = config.LoadProfile()
+		if errors.Is(err, config.ErrProfileSandbox) {
+			return result, err
+		}
 		if err != nil {
 			// fall back to default
 			profile = config.GetDefaultProfile()
@@ -60,6 +67,9 @@ func InjectProfile(path string, customProfile map[string]string) (*ProfileInject
 
 	profile = processDynamicFields(profile)
 
+	// values the format's tags cannot hold are left out and reported
+	profile, result.Rejected = formats.CheckProfile(fileType.Format, fileType.Extension, profile)
+
 	err = handler.InjectMetadata(path, profile)
 	if err != nil {
 		return result, fmt.Errorf("metadata injection failed: %w", err)
@@ -86,6 +96,31 @@ func InjectProfile(path string, customProfile map[string]string) (*ProfileInject
 	return result, nil
 }
 
+// the profile for one file: CustomProfile, else ProfileName or the default
+// profile, called with the file when it returns a function; a default
+// profile that does not load falls back to the built-in one
+func resolveProfile(path string, report *analyse.AnalysisReport, options *WipeOptions) (map[string]string, error) {
+	if options.CustomProfile != nil {
+		return options.CustomProfile, nil
+	}
+	name := options.ProfileName
+	if name == "" {
+		name = "profile"
+	}
+
+	file := &config.ProfileFile{
+		Path:     absPath(path),
+		Format:   report.FileType.Format,
+		MIMEType: report.FileType.MimeType,
+		Metadata: report.Fields(),
+	}
+	profile, err := config.LoadProfileFor(name, file)
+	if err != nil && options.ProfileName == "" && !errors.Is(err, config.ErrProfileSandbox) {
+		return config.GetDefaultProfile(), nil
+	}
+	return profile, err
+}
+
 // dynamic values in the profile
 func processDynamicFields(profile map[string]string) map[string]string {
 	result := make(map[string]string, len(profile))
@@ -0,0 +1,114 @@
+// BYZRA ⸻ internal/wipe/sidecar.go
+// XMP sidecars: wiped or deleted along with the file they describe
+
+package wipe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"caligra/internal/i18n"
+	"caligra/internal/util"
+)
+
+// what a wipe does with XMP sidecars (--sidecars)
+const (
+	SidecarsKeep   = ""       // leave them, with a warning
+	SidecarsWipe   = "wipe"   // strip their tags
+	SidecarsDelete = "delete" // remove them
+)
+
+// checks a --sidecars choice
+func ValidateSidecars(choice string) error {
+	switch choice {
+	case SidecarsKeep, SidecarsWipe, SidecarsDelete:
+		return nil
+	}
+	return fmt.Errorf("--sidecars must be wipe or delete, not %q", choice)
+}
+
+// handles the sidecars of path once the wipe succeeded. With a copy, the
+// original keeps its sidecars and the copy gets wiped ones, or none, unless
+// the policy deleted the original; in place, they are wiped or deleted
+// where they are, backed up first unless --no-backup
+func wipeSidecars(path string, sidecars []string, options *WipeOptions, result *WipeResult) {
+	if len(sidecars) == 0 || !result.Success {
+		return
+	}
+
+	output := result.OutputPath
+	if output == "" {
+		output = path
+	}
+	// the original is gone: its sidecars describe nothing any more
+	inPlace := !options.CreateCopy || result.OriginalDeleted
+
+	for _, sidecar := range sidecars {
+		switch {
+		case options.Sidecars == SidecarsKeep:
+			result.Warnings = append(result.Warnings,
+				i18n.T("XMP sidecar %s still holds the original metadata; use --sidecars wipe or --sidecars delete", sidecar))
+
+		case options.Sidecars == SidecarsWipe:
+			target := sidecar
+			if options.CreateCopy {
+				target = sidecarPath(path, sidecar, output)
+				if err := util.SafeCopy(sidecar, target); err != nil {
+					result.fail("sidecar wipe", err)
+					continue
+				}
+			} else if options.KeepBackup {
+				if _, err := util.CreateBackup(sidecar); err != nil {
+					result.fail("sidecar backup", err)
+					continue
+				}
+			}
+			if err := util.ExifToolRemove(target); err != nil {
+				result.fail("sidecar wipe", err)
+				continue
+			}
+			result.Sidecars = append(result.Sidecars, i18n.T("XMP sidecar wiped: %s", target))
+			if target != sidecar && result.OriginalDeleted {
+				if _, err := removeSecurely(sidecar, i18n.T("Sidecar"), result); err != nil {
+					result.fail("sidecar removal", err)
+				}
+			}
+
+		case !inPlace:
+			result.Sidecars = append(result.Sidecars,
+				i18n.T("XMP sidecar %s left with the original; the copy has none", sidecar))
+
+		case options.KeepBackup && !result.OriginalDeleted:
+			if err := os.Rename(sidecar, sidecar+".bak"); err != nil {
+				result.fail("sidecar removal", err)
+				continue
+			}
+			result.Sidecars = append(result.Sidecars, i18n.T("XMP sidecar deleted: %s (backup at %s.bak)", sidecar, sidecar))
+
+		default:
+			if options.SecureDelete {
+				if _, err := removeSecurely(sidecar, i18n.T("Sidecar"), result); err != nil {
+					result.fail("sidecar removal", err)
+					continue
+				}
+			} else if err := util.RemoveFile(sidecar); err != nil {
+				result.fail("sidecar removal", err)
+				continue
+			}
+			result.Sidecars = append(result.Sidecars, i18n.T("XMP sidecar deleted: %s", sidecar))
+		}
+	}
+	result.Success = result.Success && len(result.WipeErrors) == 0
+}
+
+// the name a sidecar of path takes next to output, in the same style:
+// photo.xmp -> photo.volena.xmp, photo.jpg.xmp -> photo.volena.jpg.xmp
+func sidecarPath(path, sidecar, output string) string {
+	ext := filepath.Ext(sidecar)
+	if strings.TrimSuffix(sidecar, ext) == path {
+		return output + ext
+	}
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ext
+}
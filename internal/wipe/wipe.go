@@ -4,12 +4,20 @@
 package wipe
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"maps"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"caligra/internal/analyse"
+	"caligra/internal/audit"
+	"caligra/internal/config"
 	"caligra/internal/formats"
+	"caligra/internal/i18n"
 	"caligra/internal/util"
 )
 
@@ -20,6 +28,10 @@ type WipeOptions struct {
 	// custom profile to inject (nil for default)
 	CustomProfile map[string]string
 
+	// profile name or .lua path, loaded for each file so function profiles
+	// see it; "" for the default profile, ignored when CustomProfile is set
+	ProfileName string
+
 	// create a clean copy instead of modifying the original?
 	CreateCopy bool
 
@@ -28,6 +40,25 @@ type WipeOptions struct {
 
 	// securely overwrite original before deletion?
 	SecureDelete bool
+
+	// rewrite date-time tags into this timezone and strip offsets (nil leaves them)
+	Timezone *time.Location
+
+	// replace emails, phone numbers, IBANs and IPs in text bodies?
+	RedactPII bool
+
+	// Matroska elements to keep (nil for the handler defaults)
+	Matroska *formats.MatroskaOptions
+
+	// keep/remove choices for handlers that support them (nil wipes everything)
+	Policy *config.Policy
+
+	// what to do with XMP sidecars next to the file (SidecarsWipe,
+	// SidecarsDelete); SidecarsKeep leaves them and warns
+	Sidecars string
+
+	// who is wiping ("cli", "daemon"), for the audit journal; "" records nothing
+	Audit string
 }
 
 func DefaultWipeOptions() *WipeOptions {
@@ -41,14 +72,43 @@ func DefaultWipeOptions() *WipeOptions {
 }
 
 type WipeResult struct {
-	Success       bool
-	OriginalPath  string
-	OutputPath    string
-	BackupPath    string
-	SensitiveData []string
-	WipeErrors    []string
-	Verification  *VerificationResult
-	Injection     *ProfileInjectionResult
+	Success             bool
+	OriginalPath        string
+	OutputPath          string
+	BackupPath          string
+	SensitiveData       []string
+	WipeErrors          []string
+	Warnings            []string
+	Timestamps          []string     // tags rewritten by timezone normalization
+	Redactions          int          // personal data replaced in the body
+	PolicyNotes         []string     // what a policy kept or removed (cover art, encoder, ...)
+	Sidecars            []string     // what became of each XMP sidecar
+	Rebuilt             []string     // re-encoding/remuxing steps a policy asked for
+	Renamed             bool         // output given a random name
+	OriginalDeleted     bool         // original removed by secure delete
+	OriginalOverwritten bool         // and overwritten first; false where that proves nothing
+	Attestation         string       // signed report path, when the policy asks for one
+	Failures            []*WipeError // typed form of WipeErrors, for errors.Is/As
+	Verification        *VerificationResult
+	Injection           *ProfileInjectionResult
+
+	// metadata bytes and segments by file structure, before and after the
+	// wipe (profile injection excluded); zero when the structure is unknown
+	BytesBefore, BytesAfter     int64
+	RegionsBefore, RegionsAfter int
+
+	// file sizes of the input and of the final output
+	SizeBefore, SizeAfter int64
+}
+
+// metadata bytes the wipe removed
+func (r *WipeResult) Excised() int64 {
+	return r.BytesBefore - r.BytesAfter
+}
+
+// bytes the output is smaller than the input; negative when it grew
+func (r *WipeResult) Saved() int64 {
+	return r.SizeBefore - r.SizeAfter
 }
 
 // removes metadata from a file and optionally injects a profile
@@ -56,78 +116,229 @@ func WipeFile(path string, options *WipeOptions) (*WipeResult, error) {
 	if options == nil {
 		options = DefaultWipeOptions()
 	}
+	if options.Audit == "" || !audit.Enabled() {
+		return wipeFile(path, options)
+	}
+
+	// hashed first: in-place wipes overwrite it
+	inputHash, _ := util.FileSHA256(path)
+	result, err := wipeFile(path, options)
+	if auditErr := recordAudit(path, inputHash, options, result, err); auditErr != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Audit journal not updated: %v", auditErr))
+	}
+	return result, err
+}
 
+func wipeFile(path string, options *WipeOptions) (*WipeResult, error) {
 	result := &WipeResult{
 		OriginalPath: path,
 		WipeErrors:   []string{},
+		Warnings:     []string{},
 	}
 
 	if err := util.ValidatePath(path); err != nil {
-		return result, fmt.Errorf("invalid input file: %w", err)
+		return result, &WipeError{Op: "input validation", Path: path, Err: err}
+	}
+	if info, err := os.Stat(path); err == nil {
+		result.SizeBefore = info.Size()
+	}
+
+	// policy-level steps override the options without touching the caller's copy
+	policy := options.Policy
+	if policy != nil {
+		local := *options
+		if policy.SecureDelete {
+			local.SecureDelete = true
+			local.KeepBackup = false
+		}
+		if policy.Timezone != "" && local.Timezone == nil {
+			if loc, err := time.LoadLocation(policy.Timezone); err == nil {
+				local.Timezone = loc
+			}
+		}
+		options = &local
 	}
 
 	// get metadata before wiping
-	report, err := analyse.Analyze(path)
+	var report *analyse.AnalysisReport
+	err := util.Track(i18n.T("Analyzing metadata"), func() error {
+		var err error
+		report, err = analyse.Analyze(path)
+		return err
+	})
 	if err != nil {
-		return result, fmt.Errorf("failed to analyze file: %w", err)
+		return result, &WipeError{Op: "analysis", Path: path, Err: err}
 	}
+	util.Step(i18n.T("Found %d sensitive metadata fields", len(report.SensitiveFields)))
 
 	result.SensitiveData = report.SensitiveFields
+	result.BytesBefore, result.RegionsBefore = report.MetadataBytes(), len(report.Regions)
 
 	handler, err := formats.GetHandler(report.FileType.Format)
 	if err != nil {
-		return result, fmt.Errorf("no handler for format %s: %w", report.FileType.Format, err)
+		return result, &WipeError{Op: "handler lookup", Path: path, Err: err}
+	}
+
+	if mkv, ok := handler.(*formats.MatroskaHandler); ok && options.Matroska != nil {
+		mkv.Options = *options.Matroska
 	}
 
 	outputPath := path
 	if options.CreateCopy {
+		if err := checkHardLinks(path, report.HardLinks, options, result); err != nil {
+			return result, &WipeError{Op: "input validation", Path: path, Err: err}
+		}
+
 		// output with .volena ext
 		outputPath = util.GenerateOutputPath(path)
 		result.OutputPath = outputPath
 
 		// copy
 		if err := util.SafeCopy(path, outputPath); err != nil {
-			return result, fmt.Errorf("failed to create output file: %w", err)
+			return result, &WipeError{Op: "output copy", Path: path, Err: err}
 		}
 	} else {
+		if err := util.ValidateWritable(path); err != nil {
+			return result, &WipeError{Op: "input validation", Path: path, Err: err}
+		}
+		// rewriting a file another program is still saving corrupts both
+		if err := util.CheckNotBusy(path); err != nil {
+			return result, &WipeError{Op: "input validation", Path: path, Err: err}
+		}
+		if err := checkHardLinks(path, report.HardLinks, options, result); err != nil {
+			return result, &WipeError{Op: "input validation", Path: path, Err: err}
+		}
+
 		// backup original
 		backupPath, err := util.CreateBackup(path)
 		if err != nil {
-			return result, fmt.Errorf("failed to create backup: %w", err)
+			return result, &WipeError{Op: "backup", Path: path, Err: err}
 		}
 		result.BackupPath = backupPath
 	}
 
 	workingPath := outputPath
 
-	// wipe metadata
-	util.SpinWhile(fmt.Sprintf("[~] Wiping metadata from %s", filepath.Base(workingPath)), func() (string, error) {
+	// wipe metadata; a failure is recorded in the result, not returned
+	_ = util.Track(i18n.T("Wiping metadata from %s", filepath.Base(path)), func() error {
+		// handlers with policy support always get one, so removals are explicit
+		if policyWiper, ok := handler.(formats.PolicyWiper); ok {
+			policy := options.Policy
+			if policy == nil {
+				policy = config.DefaultPolicy()
+			}
+			outcome, err := policyWiper.WipeWithPolicy(workingPath, policy)
+			if outcome != nil && options.Policy != nil {
+				result.PolicyNotes = outcome.Lines()
+			}
+			if err != nil {
+				result.fail("metadata wipe", err)
+				return err
+			}
+			util.Step(i18n.T("Metadata removed"))
+			return nil
+		}
+
 		if err := handler.WipeMetadata(workingPath); err != nil {
-			result.WipeErrors = append(result.WipeErrors, fmt.Sprintf("[X] Metadata wipe failed: %s", err))
-			return "", err
+			result.fail("metadata wipe", err)
+			return err
 		}
-		return "Metadata removed", nil
+		util.Step(i18n.T("Metadata removed"))
+		return nil
 	})
 
+	// rebuilding drops whatever tag removal cannot reach; injection comes after
+	if policy != nil && len(result.WipeErrors) == 0 {
+		rebuildFile(handler, workingPath, policy, report.FileType.Format, result)
+	}
+
+	// body redaction runs before injection so the profile header is left alone
+	if options.RedactPII && len(result.WipeErrors) == 0 {
+		if report.FileType.Format == "text" {
+			count, err := redactContent(workingPath)
+			if err != nil {
+				result.fail("content redaction", err)
+			} else {
+				util.Step(i18n.T("Redacted %d pieces of personal data in the body", count))
+			}
+			result.Redactions = count
+		} else {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("Content redaction is not supported for %s files", report.FileType.Format))
+		}
+	}
+
+	// measured before injection, which adds metadata back on purpose
+	if result.RegionsBefore > 0 && len(result.WipeErrors) == 0 {
+		if layout, err := formats.ReadLayout(workingPath); err == nil {
+			regions := layout.MetadataRegions()
+			result.BytesAfter, result.RegionsAfter = formats.RegionBytes(regions), len(regions)
+		} else {
+			result.BytesAfter, result.RegionsAfter = result.BytesBefore, result.RegionsBefore
+		}
+	}
+
 	// profile injection
+	expected := options.CustomProfile
 	if options.InjectProfile && len(result.WipeErrors) == 0 {
-		injResult, err := InjectProfile(workingPath, options.CustomProfile)
+		profile, err := resolveProfile(path, report, options)
 		if err != nil {
-			result.WipeErrors = append(result.WipeErrors, fmt.Sprintf("[X] Profile injection failed: %s", err))
+			result.fail("profile injection", err)
+		} else {
+			if options.ProfileName != "" {
+				expected = profile
+			}
+			injResult, err := InjectProfile(workingPath, profile)
+			if err != nil {
+				result.fail("profile injection", err)
+			} else {
+				util.Step(i18n.T("Profile injected"))
+			}
+			if len(injResult.Rejected) > 0 && expected != nil {
+				expected = maps.Clone(expected)
+			}
+			for _, rejected := range injResult.Rejected {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("Profile field %s not injected: %s", rejected.Field, rejected.Reason))
+				delete(expected, rejected.Field)
+			}
+			result.Injection = injResult
 		}
-		result.Injection = injResult
 	}
 
-	verifyResult, err := VerifyFile(workingPath, options.CustomProfile)
+	// timezone normalization covers both leftover and injected dates
+	if options.Timezone != nil && len(result.WipeErrors) == 0 {
+		if normalizer, ok := handler.(formats.TimestampNormalizer); ok {
+			tags, err := normalizer.NormalizeTimestamps(workingPath, options.Timezone)
+			if err != nil {
+				result.fail("timestamp normalization", err)
+			} else {
+				util.Step(i18n.T("Normalized %d timestamp tags", len(tags)))
+			}
+			result.Timestamps = tags
+		} else {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("Timestamp normalization is not supported for %s files", report.FileType.Format))
+		}
+	}
+
+	var verifyResult *VerificationResult
+	err = util.Track(i18n.T("Verifying output"), func() error {
+		var err error
+		verifyResult, err = VerifyFile(workingPath, expected)
+		return err
+	})
 	if err != nil {
-		result.WipeErrors = append(result.WipeErrors, fmt.Sprintf("[X] Verification failed: %s", err))
+		result.fail("verification", err)
 	}
 	result.Verification = verifyResult
 
 	// option-based clean up
 	if !options.CreateCopy && !options.KeepBackup && result.BackupPath != "" && len(result.WipeErrors) == 0 {
 		if options.SecureDelete {
-			_ = util.SecureOverwriteFile(result.BackupPath)
+			overwritten, _ := removeSecurely(result.BackupPath, i18n.T("Backup"), result)
+			result.OriginalDeleted = policy != nil && policy.SecureDelete
+			result.OriginalOverwritten = result.OriginalDeleted && overwritten
 		} else {
 			_ = util.RemoveFile(result.BackupPath)
 		}
@@ -138,40 +349,307 @@ func WipeFile(path string, options *WipeOptions) (*WipeResult, error) {
 	result.Success = len(result.WipeErrors) == 0 &&
 		(result.Verification == nil || result.Verification.Success)
 
+	if policy != nil {
+		finishPolicy(path, workingPath, policy, options, result)
+	}
+	wipeSidecars(path, report.Sidecars, options, result)
+
+	finalPath := result.OutputPath
+	if finalPath == "" {
+		finalPath = workingPath
+	}
+	if info, err := os.Stat(finalPath); err == nil {
+		result.SizeAfter = info.Size()
+	}
+
 	return result, nil
 }
 
+// appends the outcome of a wipe to the audit journal
+func recordAudit(path, inputHash string, options *WipeOptions, result *WipeResult, err error) error {
+	entry := audit.Entry{
+		Operation:   audit.OperationWipe,
+		Source:      options.Audit,
+		Input:       absPath(path),
+		InputSHA256: inputHash,
+		Sensitive:   len(result.SensitiveData),
+		Status:      audit.StatusOK,
+	}
+	if options.Policy != nil {
+		entry.Policy = options.Policy.Name
+	}
+
+	switch {
+	case err != nil:
+		entry.Status, entry.Error = audit.StatusFailed, err.Error()
+	case !result.Success:
+		entry.Status, entry.Error = audit.StatusIssues, result.ErrText()
+	}
+	if err == nil {
+		output := result.OutputPath
+		if output == "" {
+			output = path
+		}
+		entry.Output = absPath(output)
+		entry.OutputSHA256, _ = util.FileSHA256(output)
+	}
+
+	_, appendErr := audit.Append(entry)
+	return appendErr
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// re-encodes images and remuxes containers when the policy asks for it
+func rebuildFile(handler formats.FormatHandler, path string, policy *config.Policy, format string, result *WipeResult) {
+	if policy.Reencode {
+		if reencoder, ok := handler.(formats.Reencoder); ok {
+			if err := reencoder.Reencode(path); err != nil {
+				result.fail("re-encoding", err)
+				return
+			}
+			result.Rebuilt = append(result.Rebuilt, "image re-encoded")
+			util.Step(i18n.T("Image re-encoded"))
+		}
+	}
+
+	if policy.Remux {
+		if remuxer, ok := handler.(formats.Remuxer); ok {
+			if err := remuxer.Remux(path); err != nil {
+				result.fail("remux", err)
+				return
+			}
+			result.Rebuilt = append(result.Rebuilt, "container remuxed")
+			util.Step(i18n.T("Container remuxed"))
+		}
+	}
+
+	if (policy.Reencode || policy.Remux) && len(result.Rebuilt) == 0 {
+		result.Warnings = append(result.Warnings,
+			i18n.T("Rebuilding is not supported for %s files; metadata was stripped only", format))
+	}
+}
+
+// removes a file holding the original data, overwriting it first only where
+// the overwrite reaches the blocks that held it; what (i18n.T("Original"),
+// i18n.T("Backup")) names it in warnings. Reports whether it was overwritten
+func removeSecurely(path, what string, result *WipeResult) (bool, error) {
+	if util.IsNetworkFilesystem(path) {
+		// the server decides where writes land; an overwrite proves nothing
+		result.Warnings = append(result.Warnings,
+			i18n.T("%s is on a network filesystem; secure overwrite skipped, file removed normally", what))
+		return false, util.RemoveFile(path)
+	}
+	if fsType := util.CopyOnWriteFilesystem(path); fsType != "" {
+		// the overwrite would go to fresh blocks and leave the old ones behind
+		result.Warnings = append(result.Warnings,
+			i18n.T("%s is on %s, which writes changes to new blocks; secure overwrite skipped, file removed normally. "+
+				"Its data stays in any snapshot and in free space until trimmed (fstrim)", what, fsType))
+		return false, util.RemoveFile(path)
+	}
+	return true, util.SecureOverwriteFile(path)
+}
+
+// applies the policy's hardlinks choice when the wipe reaches a file's
+// other names: wiping it in place, or securely deleting it after the copy
+func checkHardLinks(path string, links int, options *WipeOptions, result *WipeResult) error {
+	policy := options.Policy
+	secureDelete := policy != nil && policy.SecureDelete
+	if links < 2 || options.CreateCopy && !secureDelete {
+		return nil
+	}
+	choice := config.HardLinksWarn
+	if policy != nil && policy.HardLinks != "" {
+		choice = policy.HardLinks
+	}
+
+	switch {
+	case choice == config.HardLinksRefuse:
+		return fmt.Errorf("%w (%d); the policy leaves such files alone", util.ErrHardLinked, links-1)
+	case options.CreateCopy:
+		// secure delete removes just this name, see finishPolicy
+	case choice == config.HardLinksBreak:
+		if err := util.BreakHardlink(path); err != nil {
+			return err
+		}
+		result.Warnings = append(result.Warnings,
+			i18n.T("File had %d other hard links; it was given its own copy first, and they keep the original metadata", links-1))
+	default:
+		result.Warnings = append(result.Warnings,
+			i18n.T("File has %d other hard links; depending on the format they change with this wipe or keep the original metadata", links-1))
+	}
+	return nil
+}
+
+// renaming, deleting the original and attesting: the steps that touch
+// file names, run once the output is final
+func finishPolicy(path, workingPath string, policy *config.Policy, options *WipeOptions, result *WipeResult) {
+	finalPath := workingPath
+
+	if result.Success && policy.Rename == config.RenameRandom {
+		renamed := filepath.Join(filepath.Dir(workingPath), randomName()+filepath.Ext(workingPath))
+		if err := os.Rename(workingPath, renamed); err != nil {
+			result.fail("rename", err)
+		} else {
+			finalPath = renamed
+			result.OutputPath = renamed
+			result.Renamed = true
+		}
+	}
+
+	// with a copy, the original is still there
+	if result.Success && policy.SecureDelete && options.CreateCopy {
+		var overwritten bool
+		var err error
+		if links, _ := util.LinkCount(path); links > 1 {
+			// overwriting would garble the other names; the data stays with them
+			result.Warnings = append(result.Warnings,
+				i18n.T("Original has %d other hard links; only this name was removed, the data remains under the others", links-1))
+			if err := util.RemoveFile(path); err != nil {
+				result.fail("original removal", err)
+			}
+		} else if overwritten, err = removeSecurely(path, i18n.T("Original"), result); err != nil {
+			result.fail("secure delete", err)
+		}
+		result.OriginalDeleted = len(result.WipeErrors) == 0
+		result.OriginalOverwritten = result.OriginalDeleted && overwritten
+	}
+
+	if policy.Attestation {
+		// failures are attested too, so the report is always there
+		result.Success = result.Success && len(result.WipeErrors) == 0
+		attestation, err := WriteAttestation(result, finalPath, policy.Name)
+		if err != nil {
+			result.fail("attestation", err)
+		}
+		result.Attestation = attestation
+	}
+
+	result.Success = result.Success && len(result.WipeErrors) == 0
+}
+
+// 16 random hex characters
+func randomName() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strings.TrimPrefix(util.GenerateRandomID(), "caligra-")
+	}
+	return hex.EncodeToString(b)
+}
+
+// rewrites a text file with personal data replaced by markers
+func redactContent(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	redacted, count := util.RedactPII(string(data))
+	if count == 0 {
+		return 0, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return count, os.WriteFile(path, []byte(redacted), info.Mode().Perm())
+}
+
 // report of the wipe operation
 func FormatWipeResult(result *WipeResult) string {
 	var sb strings.Builder
 
 	if len(result.SensitiveData) > 0 {
-		message := fmt.Sprintf("[!] Found %d sensitive metadata fields", len(result.SensitiveData))
+		message := "[!] " + i18n.T("Found %d sensitive metadata fields", len(result.SensitiveData))
 		sb.WriteString(util.BRH.Render(message))
 		sb.WriteString("\n")
 	} else {
-		message := "[i] No sensitive metadata detected"
+		message := "[i] " + i18n.T("No sensitive metadata detected")
 		sb.WriteString(util.SEC.Render(message))
 		sb.WriteString("\n")
 	}
 
+	for _, warning := range result.Warnings {
+		sb.WriteString(util.BRH.Render("[!] " + warning))
+		sb.WriteString("\n")
+	}
+
 	if result.Success {
-		sb.WriteString(util.SEC.Render("✓ File successfully processed"))
+		sb.WriteString(util.SEC.Render("✓ " + i18n.T("File successfully processed")))
 		sb.WriteString("\n")
 
+		if result.SizeAfter > 0 {
+			message := "[i] " + i18n.T("Size: %s → %s (%s saved)",
+				util.FormatBytes(result.SizeBefore), util.FormatBytes(result.SizeAfter), util.FormatBytes(result.Saved()))
+			if result.Saved() < 0 {
+				message = "[i] " + i18n.T("Size: %s → %s (%s added)",
+					util.FormatBytes(result.SizeBefore), util.FormatBytes(result.SizeAfter), util.FormatBytes(-result.Saved()))
+			}
+			sb.WriteString(util.NSH.Render(message))
+			sb.WriteString("\n")
+		}
+
+		if result.RegionsBefore > 0 {
+			message := "[i] " + i18n.T("Excised %s of metadata (%d segments → %d)",
+				util.FormatBytes(result.Excised()), result.RegionsBefore, result.RegionsAfter)
+			sb.WriteString(util.NSH.Render(message))
+			sb.WriteString("\n")
+		}
+
+		for _, step := range result.Rebuilt {
+			sb.WriteString(util.NSH.Render("[i] " + strings.ToUpper(step[:1]) + step[1:]))
+			sb.WriteString("\n")
+		}
+
+		for _, note := range result.PolicyNotes {
+			sb.WriteString(util.NSH.Render("[i] " + note))
+			sb.WriteString("\n")
+		}
+
+		for _, note := range result.Sidecars {
+			sb.WriteString(util.NSH.Render("[i] " + note))
+			sb.WriteString("\n")
+		}
+
+		if result.Redactions > 0 {
+			message := "[i] " + i18n.T("Redacted %d pieces of personal data in the body", result.Redactions)
+			sb.WriteString(util.NSH.Render(message))
+			sb.WriteString("\n")
+		}
+
+		if len(result.Timestamps) > 0 {
+			message := "[i] " + i18n.T("Normalized %d timestamp tags", len(result.Timestamps))
+			sb.WriteString(util.NSH.Render(message))
+			sb.WriteString("\n")
+		}
+
 		if result.OutputPath != "" && result.OutputPath != result.OriginalPath {
-			message := fmt.Sprintf("[i] Output saved to: %s", result.OutputPath)
+			message := "[i] " + i18n.T("Output saved to: %s", result.OutputPath)
 			sb.WriteString(util.NSH.Render(message))
 			sb.WriteString("\n")
 		}
 
 		if result.BackupPath != "" {
-			message := fmt.Sprintf("[i] Backup created at: %s", result.BackupPath)
+			message := "[i] " + i18n.T("Backup created at: %s", result.BackupPath)
 			sb.WriteString(util.NSH.Render(message))
 			sb.WriteString("\n")
 		}
+
+		if result.OriginalOverwritten {
+			sb.WriteString(util.NSH.Render("[i] " + i18n.T("Original securely overwritten and deleted")))
+			sb.WriteString("\n")
+		} else if result.OriginalDeleted {
+			sb.WriteString(util.NSH.Render("[i] " + i18n.T("Original deleted without overwriting (see warnings)")))
+			sb.WriteString("\n")
+		}
 	} else {
-		sb.WriteString(util.BRH.Render("[!] Processing completed with issues..."))
+		sb.WriteString(util.BRH.Render("[!] " + i18n.T("Processing completed with issues...")))
 		sb.WriteString("\n")
 
 		for _, err := range result.WipeErrors {
@@ -180,13 +658,24 @@ func FormatWipeResult(result *WipeResult) string {
 			sb.WriteString("\n")
 		}
 
+		for _, hint := range result.Hints() {
+			sb.WriteString(util.SEC.Render("[i] " + hint))
+			sb.WriteString("\n")
+		}
+
 		if result.BackupPath != "" {
-			message := fmt.Sprintf("[i] Original preserved at: %s", result.BackupPath)
+			message := "[i] " + i18n.T("Original preserved at: %s", result.BackupPath)
 			sb.WriteString(util.SEC.Render(message))
 			sb.WriteString("\n")
 		}
 	}
 
+	if result.Attestation != "" {
+		message := "[i] " + i18n.T("Attestation written to: %s", result.Attestation)
+		sb.WriteString(util.NSH.Render(message))
+		sb.WriteString("\n")
+	}
+
 	if (result.Verification != nil && !result.Verification.Success) ||
 		(result.Injection != nil && !result.Injection.Success) {
 		sb.WriteString("\n")